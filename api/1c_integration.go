@@ -63,12 +63,16 @@ func (api *OneCIntegrationAPI) RegisterRoutes(r *gin.RouterGroup) {
 		// Экспорт данных в 1С
 		oneC.POST("/export/payment-registry", api.ExportPaymentRegistry)
 		oneC.POST("/export/payment-registry/auto", api.ScheduleAutoExport)
+		oneC.POST("/export/contracts/:id", api.ExportContract)
+		oneC.POST("/export/invoices/:id", api.ExportInvoice)
+		oneC.POST("/export/payroll/:id", api.ExportPayrollStatement)
 
 		// Импорт данных из 1С
 		oneC.POST("/import/counterparties", api.ImportCounterparties)
 
 		// Синхронизация
 		oneC.POST("/sync/payment-statuses", api.SyncPaymentStatuses)
+		oneC.POST("/sync/documents", api.SyncDocuments)
 
 		// Мониторинг и ошибки
 		oneC.GET("/errors", api.GetIntegrationErrors)
@@ -368,6 +372,118 @@ func (api *OneCIntegrationAPI) ScheduleAutoExport(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Автоэкспорт успешно запланирован"})
 }
 
+// ExportContract выгружает договор в 1С
+func (api *OneCIntegrationAPI) ExportContract(c *gin.Context) {
+	companyID := GetCompanyID(c)
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный ID договора"})
+		return
+	}
+
+	var contract models.Contract
+	if err := api.db.Where("id = ? AND company_id = ?", id, companyID).First(&contract).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Договор не найден"})
+		return
+	}
+
+	externalID, err := api.oneCIntegrationService.ExportContract(c.Request.Context(), companyID, &contract, c.Query("force") == "true")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Ошибка выгрузки договора в 1С",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "Договор успешно выгружен в 1С",
+		"external_id": externalID,
+	})
+}
+
+// ExportInvoice выгружает счет на оплату в 1С
+func (api *OneCIntegrationAPI) ExportInvoice(c *gin.Context) {
+	companyID := GetCompanyID(c)
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный ID счета"})
+		return
+	}
+
+	var invoice models.Invoice
+	if err := api.db.Preload("Items").Where("id = ? AND company_id = ?", id, companyID).First(&invoice).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Счет не найден"})
+		return
+	}
+
+	externalID, err := api.oneCIntegrationService.ExportInvoice(c.Request.Context(), companyID, &invoice, c.Query("force") == "true")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Ошибка выгрузки счета в 1С",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "Счет успешно выгружен в 1С",
+		"external_id": externalID,
+	})
+}
+
+// ExportPayrollStatement выгружает утвержденный расчетный лист монтажника в 1С
+func (api *OneCIntegrationAPI) ExportPayrollStatement(c *gin.Context) {
+	companyID := GetCompanyID(c)
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный ID расчетного листа"})
+		return
+	}
+
+	var statement models.PayrollStatement
+	if err := api.db.First(&statement, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Расчетный лист не найден"})
+		return
+	}
+
+	externalID, err := api.oneCIntegrationService.ExportPayrollStatement(c.Request.Context(), companyID, &statement, c.Query("force") == "true")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Ошибка выгрузки расчетного листа в 1С",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "Расчетный лист успешно выгружен в 1С",
+		"external_id": externalID,
+	})
+}
+
+// SyncDocuments выгружает в 1С измененные договоры и счета
+func (api *OneCIntegrationAPI) SyncDocuments(c *gin.Context) {
+	companyID := GetCompanyID(c)
+
+	result, err := api.oneCIntegrationService.SyncDocuments(c.Request.Context(), companyID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Ошибка выгрузки документов в 1С",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Выгрузка документов в 1С завершена",
+		"result":  result,
+	})
+}
+
 // ImportCounterparties импортирует контрагентов из 1С
 func (api *OneCIntegrationAPI) ImportCounterparties(c *gin.Context) {
 	companyID := GetCompanyID(c)
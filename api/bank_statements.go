@@ -0,0 +1,242 @@
+package api
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+
+	"backend_axenta/database"
+	"backend_axenta/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxBankStatementSize максимальный размер загружаемой выписки
+const maxBankStatementSize = 20 << 20
+
+// ImportBankStatement загружает банковскую выписку (1CClientBankExchange или CSV)
+// и проводит платежи, однозначно сопоставленные со счетами
+func ImportBankStatement(c *gin.Context) {
+	companyID := GetCompanyID(c)
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status": "error",
+			"error":  "Не передан файл выписки",
+		})
+		return
+	}
+
+	if fileHeader.Size > maxBankStatementSize {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status": "error",
+			"error":  "Файл выписки слишком большой",
+		})
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status": "error",
+			"error":  "Ошибка чтения файла выписки",
+		})
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status": "error",
+			"error":  "Ошибка чтения файла выписки",
+		})
+		return
+	}
+
+	statementService := services.NewBankStatementService(database.DB)
+	statement, err := statementService.ImportStatement(companyID, fileHeader.Filename, data, bankStatementUserID(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status": "error",
+			"error":  "Ошибка импорта выписки: " + err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Выписка загружена",
+		"data":    statement,
+	})
+}
+
+// GetBankStatements возвращает список загруженных выписок
+func GetBankStatements(c *gin.Context) {
+	companyID := GetCompanyID(c)
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+
+	statementService := services.NewBankStatementService(database.DB)
+	statements, total, err := statementService.GetStatements(companyID, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status": "error",
+			"error":  err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data": gin.H{
+			"items":  statements,
+			"total":  total,
+			"limit":  limit,
+			"offset": offset,
+		},
+	})
+}
+
+// GetBankStatement возвращает выписку с результатами сопоставления платежей
+func GetBankStatement(c *gin.Context) {
+	companyID := GetCompanyID(c)
+
+	statementID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status": "error",
+			"error":  "Неверный формат ID выписки",
+		})
+		return
+	}
+
+	statementService := services.NewBankStatementService(database.DB)
+	statement, err := statementService.GetStatement(companyID, uint(statementID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status": "error",
+			"error":  "Выписка не найдена",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   statement,
+	})
+}
+
+// GetBankPaymentsReview возвращает платежи, требующие ручного сопоставления
+func GetBankPaymentsReview(c *gin.Context) {
+	companyID := GetCompanyID(c)
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+
+	statementService := services.NewBankStatementService(database.DB)
+	items, total, err := statementService.GetReviewQueue(companyID, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status": "error",
+			"error":  err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data": gin.H{
+			"items":  items,
+			"total":  total,
+			"limit":  limit,
+			"offset": offset,
+		},
+	})
+}
+
+// MatchBankPayment вручную сопоставляет платеж со счетом и проводит оплату
+func MatchBankPayment(c *gin.Context) {
+	companyID := GetCompanyID(c)
+
+	paymentID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status": "error",
+			"error":  "Неверный формат ID платежа",
+		})
+		return
+	}
+
+	var req struct {
+		InvoiceID uint `json:"invoice_id" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status": "error",
+			"error":  "Не указан счет для сопоставления",
+		})
+		return
+	}
+
+	statementService := services.NewBankStatementService(database.DB)
+	payment, err := statementService.ConfirmMatch(companyID, uint(paymentID), req.InvoiceID, bankStatementUserID(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status": "error",
+			"error":  err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Платеж проведен по счету",
+		"data":    payment,
+	})
+}
+
+// IgnoreBankPayment исключает платеж из очереди ручного сопоставления
+func IgnoreBankPayment(c *gin.Context) {
+	companyID := GetCompanyID(c)
+
+	paymentID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status": "error",
+			"error":  "Неверный формат ID платежа",
+		})
+		return
+	}
+
+	var req struct {
+		Reason string `json:"reason"`
+	}
+	c.ShouldBindJSON(&req)
+
+	statementService := services.NewBankStatementService(database.DB)
+	payment, err := statementService.IgnorePayment(companyID, uint(paymentID), req.Reason, bankStatementUserID(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status": "error",
+			"error":  err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Платеж исключен из сопоставления",
+		"data":    payment,
+	})
+}
+
+// bankStatementUserID возвращает ID текущего пользователя, если он известен
+func bankStatementUserID(c *gin.Context) *uint {
+	if userID, exists := c.Get("user_id"); exists {
+		if uid, ok := userID.(uint); ok {
+			return &uid
+		}
+	}
+	return nil
+}
@@ -0,0 +1,177 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"backend_axenta/config"
+	"backend_axenta/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Bitrix24OAuthAPI API для установки и авторизации OAuth приложения Битрикс24
+type Bitrix24OAuthAPI struct {
+	oauthService *services.Bitrix24OAuthService
+}
+
+// NewBitrix24OAuthAPI создает новый API OAuth авторизации Битрикс24
+func NewBitrix24OAuthAPI(db *gorm.DB) *Bitrix24OAuthAPI {
+	logger := log.New(os.Stdout, "[BITRIX24_OAUTH] ", log.LstdFlags|log.Lshortfile)
+	client := services.NewBitrix24Client(logger)
+
+	return &Bitrix24OAuthAPI{
+		oauthService: services.NewBitrix24OAuthService(db, client, logger),
+	}
+}
+
+// RegisterRoutes регистрирует маршруты, требующие авторизации пользователя
+func (api *Bitrix24OAuthAPI) RegisterRoutes(r *gin.RouterGroup) {
+	bitrix := r.Group("/integration/bitrix24/oauth")
+	{
+		bitrix.GET("/authorize", api.GetAuthorizeURL)
+		bitrix.GET("/status", api.GetOAuthStatus)
+	}
+}
+
+// RegisterPublicRoutes регистрирует маршруты, которые вызывает сам Битрикс24
+func (api *Bitrix24OAuthAPI) RegisterPublicRoutes(r *gin.Engine) {
+	public := r.Group("/api/integration/bitrix24")
+	{
+		public.GET("/oauth/callback", api.OAuthCallback)
+		public.POST("/install/:company_id", api.Install)
+		public.POST("/events", api.HandleEvent)
+	}
+}
+
+// GetAuthorizeURL возвращает адрес страницы авторизации приложения на портале
+func (api *Bitrix24OAuthAPI) GetAuthorizeURL(c *gin.Context) {
+	companyID := GetCompanyID(c)
+
+	domain := c.Query("domain")
+	if domain == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Не указан домен портала Битрикс24"})
+		return
+	}
+
+	authorizeURL, err := api.oauthService.BuildAuthorizeURL(companyID, domain, bitrix24RedirectURI())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data": gin.H{
+			"authorize_url": authorizeURL,
+		},
+	})
+}
+
+// GetOAuthStatus возвращает состояние авторизации приложения Битрикс24
+func (api *Bitrix24OAuthAPI) GetOAuthStatus(c *gin.Context) {
+	companyID := GetCompanyID(c)
+
+	status, err := api.oauthService.GetStatus(companyID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка получения статуса авторизации: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   status,
+	})
+}
+
+// OAuthCallback принимает код авторизации от Битрикс24 и обменивает его на токены
+func (api *Bitrix24OAuthAPI) OAuthCallback(c *gin.Context) {
+	companyID, err := api.oauthService.HandleOAuthCallback(c.Request.Context(), c.Query("state"), c.Query("code"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Ошибка авторизации в Битрикс24: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Приложение Битрикс24 успешно авторизовано",
+		"data": gin.H{
+			"company_id": companyID,
+		},
+	})
+}
+
+// Install обрабатывает установку приложения на портале Битрикс24
+func (api *Bitrix24OAuthAPI) Install(c *gin.Context) {
+	companyID, err := uuid.Parse(c.Param("company_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный формат ID компании"})
+		return
+	}
+
+	// Битрикс24 передает параметры установки формой, а домен - в строке запроса
+	authExpires, _ := strconv.Atoi(firstNonEmpty(c.PostForm("AUTH_EXPIRES"), c.PostForm("auth[expires_in]")))
+	payload := &services.Bitrix24InstallPayload{
+		AuthID:           firstNonEmpty(c.PostForm("AUTH_ID"), c.PostForm("auth[access_token]")),
+		AuthExpires:      authExpires,
+		RefreshID:        firstNonEmpty(c.PostForm("REFRESH_ID"), c.PostForm("auth[refresh_token]")),
+		MemberID:         firstNonEmpty(c.PostForm("member_id"), c.PostForm("auth[member_id]")),
+		Domain:           firstNonEmpty(c.Query("DOMAIN"), c.PostForm("auth[domain]")),
+		ServerEndpoint:   firstNonEmpty(c.PostForm("SERVER_ENDPOINT"), c.PostForm("auth[client_endpoint]")),
+		ApplicationToken: c.PostForm("auth[application_token]"),
+	}
+
+	if err := api.oauthService.HandleInstall(c.Request.Context(), companyID, payload); err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, services.ErrBitrix24TokenRevoked) {
+			status = http.StatusUnauthorized
+		}
+		c.JSON(status, gin.H{"error": "Ошибка установки приложения Битрикс24: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Приложение Битрикс24 успешно установлено",
+	})
+}
+
+// HandleEvent обрабатывает события приложения Битрикс24 (удаление приложения)
+func (api *Bitrix24OAuthAPI) HandleEvent(c *gin.Context) {
+	event := strings.ToUpper(c.PostForm("event"))
+	if event != "ONAPPUNINSTALL" {
+		c.JSON(http.StatusOK, gin.H{"status": "ignored"})
+		return
+	}
+
+	if err := api.oauthService.HandleUninstall(c.Request.Context(), c.PostForm("auth[member_id]"), c.PostForm("auth[application_token]")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "success"})
+}
+
+// bitrix24RedirectURI возвращает адрес обратного вызова OAuth
+func bitrix24RedirectURI() string {
+	if config.GlobalConfig == nil || config.GlobalConfig.App.BaseURL == "" {
+		return ""
+	}
+	return strings.TrimRight(config.GlobalConfig.App.BaseURL, "/") + "/api/integration/bitrix24/oauth/callback"
+}
+
+// firstNonEmpty возвращает первое непустое значение
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,222 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"backend_axenta/database"
+	"backend_axenta/models"
+	"backend_axenta/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetClients получает список клиентов компании
+func GetClients(c *gin.Context) {
+	companyID := GetCompanyID(c)
+
+	query := database.DB.Model(&models.Client{}).Where("company_id = ?", companyID)
+
+	// Фильтрация по активности
+	if isActive := c.Query("is_active"); isActive != "" {
+		query = query.Where("is_active = ?", isActive == "true")
+	}
+
+	// Фильтрация по группе
+	if parentID := c.Query("parent_id"); parentID != "" {
+		query = query.Where("parent_id = ?", parentID)
+	}
+
+	// Группы или элементы справочника
+	if isFolder := c.Query("is_folder"); isFolder != "" {
+		query = query.Where("is_folder = ?", isFolder == "true")
+	}
+
+	// Поиск по наименованию или реквизитам
+	if search := c.Query("search"); search != "" {
+		query = query.Where("name ILIKE ? OR full_name ILIKE ? OR inn LIKE ?",
+			"%"+search+"%", "%"+search+"%", search+"%")
+	}
+
+	// Пагинация
+	page := 1
+	limit := 20
+	if p := c.Query("page"); p != "" {
+		if parsed, err := strconv.Atoi(p); err == nil && parsed > 0 {
+			page = parsed
+		}
+	}
+	if l := c.Query("limit"); l != "" {
+		if parsed, err := strconv.Atoi(l); err == nil && parsed > 0 && parsed <= 100 {
+			limit = parsed
+		}
+	}
+	offset := (page - 1) * limit
+
+	var total int64
+	query.Count(&total)
+
+	var clients []models.Client
+	if err := query.Order("is_folder DESC, name").Offset(offset).Limit(limit).Find(&clients).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status": "error",
+			"error":  "Ошибка при получении клиентов",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   clients,
+		"count":  len(clients),
+		"total":  total,
+		"page":   page,
+		"limit":  limit,
+	})
+}
+
+// GetClient получает клиента по ID
+func GetClient(c *gin.Context) {
+	clientID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status": "error",
+			"error":  "Неверный формат ID клиента",
+		})
+		return
+	}
+
+	clientService := services.NewClientService(database.DB)
+	client, err := clientService.GetClient(GetCompanyID(c), uint(clientID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status": "error",
+			"error":  "Клиент не найден",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   client,
+	})
+}
+
+// CreateClient создает клиента в справочнике
+func CreateClient(c *gin.Context) {
+	var client models.Client
+	if err := c.ShouldBindJSON(&client); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status": "error",
+			"error":  "Неверный формат данных",
+		})
+		return
+	}
+
+	if client.Name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status": "error",
+			"error":  "Наименование клиента обязательно",
+		})
+		return
+	}
+
+	client.ID = 0
+	client.CompanyID = GetCompanyID(c)
+
+	clientService := services.NewClientService(database.DB)
+	if err := clientService.CreateClient(&client); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, services.ErrClientDuplicate) {
+			status = http.StatusConflict
+		}
+		c.JSON(status, gin.H{
+			"status": "error",
+			"error":  err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"status": "success",
+		"data":   client,
+	})
+}
+
+// UpdateClient обновляет клиента и его реквизиты в связанных договорах
+func UpdateClient(c *gin.Context) {
+	clientID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status": "error",
+			"error":  "Неверный формат ID клиента",
+		})
+		return
+	}
+
+	clientService := services.NewClientService(database.DB)
+	client, err := clientService.GetClient(GetCompanyID(c), uint(clientID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status": "error",
+			"error":  "Клиент не найден",
+		})
+		return
+	}
+
+	// Системные поля не изменяются через API
+	id, companyID, createdAt := client.ID, client.CompanyID, client.CreatedAt
+	if err := c.ShouldBindJSON(client); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status": "error",
+			"error":  "Неверный формат данных",
+		})
+		return
+	}
+	client.ID, client.CompanyID, client.CreatedAt = id, companyID, createdAt
+	client.Parent = nil
+
+	if err := clientService.UpdateClient(client); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, services.ErrClientDuplicate) {
+			status = http.StatusConflict
+		}
+		c.JSON(status, gin.H{
+			"status": "error",
+			"error":  err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   client,
+	})
+}
+
+// DeleteClient удаляет клиента, не используемого в договорах и счетах
+func DeleteClient(c *gin.Context) {
+	clientID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status": "error",
+			"error":  "Неверный формат ID клиента",
+		})
+		return
+	}
+
+	clientService := services.NewClientService(database.DB)
+	if err := clientService.DeleteClient(GetCompanyID(c), uint(clientID)); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status": "error",
+			"error":  err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Клиент удален",
+	})
+}
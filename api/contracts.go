@@ -7,8 +7,10 @@ import (
 
 	"backend_axenta/database"
 	"backend_axenta/models"
+	"backend_axenta/services"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/shopspring/decimal"
 )
 
@@ -129,6 +131,20 @@ func CreateContract(c *gin.Context) {
 		return
 	}
 
+	if contract.CompanyID == uuid.Nil {
+		contract.CompanyID = GetCompanyID(c)
+	}
+
+	// Реквизиты клиента берутся из справочника, если клиент указан явно
+	clientService := services.NewClientService(database.DB)
+	if err := clientService.ResolveContractClient(&contract); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status": "error",
+			"error":  "Клиент не найден",
+		})
+		return
+	}
+
 	if contract.ClientName == "" {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"status": "error",
@@ -206,6 +222,8 @@ func CreateContract(c *gin.Context) {
 	// Загружаем связанные данные для ответа
 	database.DB.Preload("TariffPlan").First(&contract, contract.ID)
 
+	services.PublishWebhookEvent(contract.CompanyID, models.WebhookEventContractCreated, contract)
+
 	c.JSON(http.StatusCreated, gin.H{
 		"status": "success",
 		"data":   contract,
@@ -246,6 +264,20 @@ func UpdateContract(c *gin.Context) {
 		}
 	}
 
+	// При смене клиента подставляем его реквизиты из справочника
+	if updateData.ClientID != nil {
+		var client models.Client
+		if err := database.DB.Where("id = ? AND company_id = ? AND is_folder = ?", *updateData.ClientID, contract.CompanyID, false).
+			First(&client).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"status": "error",
+				"error":  "Клиент не найден",
+			})
+			return
+		}
+		updateData.ApplyClient(&client)
+	}
+
 	if err := database.DB.Model(&contract).Updates(updateData).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"status": "error",
@@ -257,6 +289,8 @@ func UpdateContract(c *gin.Context) {
 	// Загружаем обновленные данные
 	database.DB.Preload("TariffPlan").Preload("Appendices").Preload("Objects").First(&contract, contract.ID)
 
+	services.PublishWebhookEvent(contract.CompanyID, models.WebhookEventContractUpdated, contract)
+
 	c.JSON(http.StatusOK, gin.H{
 		"status": "success",
 		"data":   contract,
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -8,6 +9,7 @@ import (
 	"gorm.io/gorm"
 
 	"backend_axenta/models"
+	"backend_axenta/services"
 )
 
 // EquipmentAPI представляет API для работы с оборудованием
@@ -213,10 +215,28 @@ func (api *EquipmentAPI) UpdateEquipment(c *gin.Context) {
 		}
 	}
 
-	if err := api.DB.Model(&equipment).Updates(updateData).Error; err != nil {
+	// Статус меняется только по допустимым переходам
+	if updateData.Status != "" && updateData.Status != equipment.Status && !equipment.CanTransitionTo(updateData.Status) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Недопустимый переход статуса: " + equipment.Status + " → " + updateData.Status})
+		return
+	}
+
+	// Клиент передает версию записи, чтобы не затереть параллельные изменения
+	if updateData.Version != 0 && updateData.Version != equipment.Version {
+		c.JSON(http.StatusConflict, gin.H{"error": services.ErrEquipmentConflict.Error()})
+		return
+	}
+	updateData.Version = equipment.Version + 1
+
+	result := api.DB.Model(&equipment).Where("version = ?", equipment.Version).Updates(updateData)
+	if result.Error != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при обновлении оборудования"})
 		return
 	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": services.ErrEquipmentConflict.Error()})
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Оборудование успешно обновлено",
@@ -254,20 +274,9 @@ func (api *EquipmentAPI) DeleteEquipment(c *gin.Context) {
 
 // InstallEquipment устанавливает оборудование на объект
 func (api *EquipmentAPI) InstallEquipment(c *gin.Context) {
-	id := c.Param("id")
-	var equipment models.Equipment
-
-	if err := api.DB.First(&equipment, id).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Оборудование не найдено"})
-		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при поиске оборудования"})
-		}
-		return
-	}
-
-	if !equipment.IsAvailable() {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Оборудование недоступно для установки"})
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный ID оборудования"})
 		return
 	}
 
@@ -280,20 +289,16 @@ func (api *EquipmentAPI) InstallEquipment(c *gin.Context) {
 		return
 	}
 
-	// Проверяем существование объекта
-	var object models.Object
-	if err := api.DB.First(&object, installData.ObjectID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Объект не найден"})
+	// Установка выполняется в транзакции с блокировкой записи оборудования
+	warehouseService := services.NewWarehouseService(api.DB, nil)
+	warehouseService.CompanyID = GetCompanyID(c)
+	if err := warehouseService.ProcessEquipmentInstallation(uint(id), installData.ObjectID, equipmentUserID(c)); err != nil {
+		api.respondEquipmentError(c, err)
 		return
 	}
 
-	equipment.ObjectID = &installData.ObjectID
-	equipment.Status = "installed"
-
-	if err := api.DB.Save(&equipment).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при установке оборудования"})
-		return
-	}
+	var equipment models.Equipment
+	api.DB.First(&equipment, id)
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Оборудование успешно установлено",
@@ -303,37 +308,56 @@ func (api *EquipmentAPI) InstallEquipment(c *gin.Context) {
 
 // UninstallEquipment снимает оборудование с объекта
 func (api *EquipmentAPI) UninstallEquipment(c *gin.Context) {
-	id := c.Param("id")
-	var equipment models.Equipment
-
-	if err := api.DB.First(&equipment, id).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Оборудование не найдено"})
-		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при поиске оборудования"})
-		}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный ID оборудования"})
 		return
 	}
 
-	if equipment.Status != "installed" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Оборудование не установлено"})
-		return
+	var returnData struct {
+		WarehouseLocation string `json:"warehouse_location"`
 	}
+	// Тело запроса необязательно
+	_ = c.ShouldBindJSON(&returnData)
 
-	equipment.ObjectID = nil
-	equipment.Status = "in_stock"
-
-	if err := api.DB.Save(&equipment).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при снятии оборудования"})
+	warehouseService := services.NewWarehouseService(api.DB, nil)
+	warehouseService.CompanyID = GetCompanyID(c)
+	if err := warehouseService.ProcessEquipmentReturn(uint(id), equipmentUserID(c), returnData.WarehouseLocation); err != nil {
+		api.respondEquipmentError(c, err)
 		return
 	}
 
+	var equipment models.Equipment
+	api.DB.First(&equipment, id)
+
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Оборудование успешно снято с объекта",
 		"data":    equipment,
 	})
 }
 
+// respondEquipmentError возвращает ошибку складской операции с подходящим HTTP статусом
+func (api *EquipmentAPI) respondEquipmentError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrEquipmentConflict):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	}
+}
+
+// equipmentUserID возвращает ID текущего пользователя для журнала складских операций
+func equipmentUserID(c *gin.Context) uint {
+	if userID, exists := c.Get("user_id"); exists {
+		if uid, ok := userID.(uint); ok {
+			return uid
+		}
+	}
+	return 0
+}
+
 // GetEquipmentStatistics возвращает статистику по оборудованию
 func (api *EquipmentAPI) GetEquipmentStatistics(c *gin.Context) {
 	var stats struct {
@@ -0,0 +1,90 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"backend_axenta/services"
+)
+
+// GetEquipmentCodeImage возвращает QR код или штрихкод оборудования в PNG или SVG
+func (api *WarehouseAPI) GetEquipmentCodeImage(c *gin.Context) {
+	id, ok := parseEquipmentID(c)
+	if !ok {
+		return
+	}
+	size, _ := strconv.Atoi(c.DefaultQuery("size", "256"))
+
+	labelService := services.NewEquipmentLabelService(api.DB)
+	content, err := labelService.GetEquipmentCode(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Оборудование не найдено"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	data, contentType, err := services.RenderLabelCode(content, c.DefaultQuery("type", services.LabelCodeQR), c.DefaultQuery("format", services.LabelFormatPNG), size)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Data(http.StatusOK, contentType, data)
+}
+
+// PrintEquipmentLabels формирует PDF с этикетками выбранного оборудования или всего приходного документа
+func (api *WarehouseAPI) PrintEquipmentLabels(c *gin.Context) {
+	var req struct {
+		EquipmentIDs []uint `json:"equipment_ids"`
+		ReceiptID    *uint  `json:"receipt_id"`
+		services.LabelSheetOptions
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректные данные: " + err.Error()})
+		return
+	}
+
+	labelService := services.NewEquipmentLabelService(api.DB)
+	data, err := labelService.GenerateLabelSheet(req.EquipmentIDs, req.ReceiptID, req.LabelSheetOptions)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Content-Disposition", "attachment; filename=\"labels.pdf\"")
+	c.Data(http.StatusOK, "application/pdf", data)
+}
+
+// PrintGoodsReceiptLabels формирует PDF с этикетками всего оборудования приходного документа
+func (api *WarehouseAPI) PrintGoodsReceiptLabels(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный ID документа"})
+		return
+	}
+
+	var opts services.LabelSheetOptions
+	if err := c.ShouldBindQuery(&opts); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректные параметры: " + err.Error()})
+		return
+	}
+
+	receiptID := uint(id)
+	labelService := services.NewEquipmentLabelService(api.DB)
+	data, err := labelService.GenerateLabelSheet(nil, &receiptID, opts)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"labels_receipt_%d.pdf\"", receiptID))
+	c.Data(http.StatusOK, "application/pdf", data)
+}
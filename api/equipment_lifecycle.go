@@ -0,0 +1,239 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"backend_axenta/services"
+)
+
+// parseEquipmentID разбирает ID оборудования из пути запроса
+func parseEquipmentID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный ID оборудования"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// parseValuationDate разбирает дату расчета (YYYY-MM-DD); расчет ведется на конец дня.
+// Без параметра используется текущий момент.
+func parseValuationDate(c *gin.Context) (time.Time, bool) {
+	value := c.Query("date")
+	if value == "" {
+		return time.Now(), true
+	}
+	date, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный формат даты, ожидается YYYY-MM-DD"})
+		return time.Time{}, false
+	}
+	return date.AddDate(0, 0, 1).Add(-time.Nanosecond), true
+}
+
+// queryUintPointer возвращает числовой параметр запроса или nil
+func queryUintPointer(c *gin.Context, name string) *uint {
+	id, err := strconv.ParseUint(c.Query(name), 10, 32)
+	if err != nil {
+		return nil
+	}
+	value := uint(id)
+	return &value
+}
+
+// GetEquipmentDepreciation возвращает амортизацию и балансовую стоимость оборудования на дату
+func (api *WarehouseAPI) GetEquipmentDepreciation(c *gin.Context) {
+	id, ok := parseEquipmentID(c)
+	if !ok {
+		return
+	}
+	date, ok := parseValuationDate(c)
+	if !ok {
+		return
+	}
+
+	lifecycleService := services.NewEquipmentLifecycleService(api.DB)
+	info, err := lifecycleService.GetDepreciation(id, date, c.Query("schedule") == "true")
+	if err != nil {
+		respondWarehouseError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": info})
+}
+
+// GetDepreciationReport возвращает ведомость балансовой стоимости оборудования на дату
+func (api *WarehouseAPI) GetDepreciationReport(c *gin.Context) {
+	date, ok := parseValuationDate(c)
+	if !ok {
+		return
+	}
+
+	lifecycleService := services.NewEquipmentLifecycleService(api.DB)
+	report, err := lifecycleService.GetDepreciationReport(date, queryUintPointer(c, "category_id"), queryUintPointer(c, "warehouse_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": report})
+}
+
+// GetEquipmentTCO возвращает совокупную стоимость владения оборудованием
+func (api *WarehouseAPI) GetEquipmentTCO(c *gin.Context) {
+	id, ok := parseEquipmentID(c)
+	if !ok {
+		return
+	}
+	date, ok := parseValuationDate(c)
+	if !ok {
+		return
+	}
+
+	lifecycleService := services.NewEquipmentLifecycleService(api.DB)
+	tco, err := lifecycleService.GetTCO(id, date)
+	if err != nil {
+		respondWarehouseError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": tco})
+}
+
+// DisposeEquipment списывает оборудование
+func (api *WarehouseAPI) DisposeEquipment(c *gin.Context) {
+	id, ok := parseEquipmentID(c)
+	if !ok {
+		return
+	}
+
+	var req services.DisposalRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректные данные: " + err.Error()})
+		return
+	}
+	req.UserID = equipmentUserID(c)
+
+	lifecycleService := services.NewEquipmentLifecycleService(api.DB)
+	equipment, err := lifecycleService.DisposeEquipment(id, req)
+	if err != nil {
+		respondWarehouseError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Оборудование списано",
+		"data":    equipment,
+	})
+}
+
+// RecordEquipmentMaintenance регистрирует обслуживание оборудования
+func (api *WarehouseAPI) RecordEquipmentMaintenance(c *gin.Context) {
+	id, ok := parseEquipmentID(c)
+	if !ok {
+		return
+	}
+
+	var req services.MaintenanceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректные данные: " + err.Error()})
+		return
+	}
+	req.UserID = equipmentUserID(c)
+
+	lifecycleService := services.NewEquipmentLifecycleService(api.DB)
+	operation, err := lifecycleService.RecordMaintenance(id, req)
+	if err != nil {
+		respondWarehouseError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Обслуживание зарегистрировано",
+		"data":    operation,
+	})
+}
+
+// GetEquipmentRMAs возвращает список возвратов поставщикам
+func (api *WarehouseAPI) GetEquipmentRMAs(c *gin.Context) {
+	lifecycleService := services.NewEquipmentLifecycleService(api.DB)
+	rmas, err := lifecycleService.GetRMAs(c.Query("status"), queryUintPointer(c, "equipment_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": rmas})
+}
+
+// GetEquipmentRMA возвращает возврат поставщику
+func (api *WarehouseAPI) GetEquipmentRMA(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный ID возврата"})
+		return
+	}
+
+	lifecycleService := services.NewEquipmentLifecycleService(api.DB)
+	rma, err := lifecycleService.GetRMA(uint(id))
+	if err != nil {
+		respondWarehouseError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": rma})
+}
+
+// CreateEquipmentRMA отправляет оборудование поставщику по гарантии или в ремонт
+func (api *WarehouseAPI) CreateEquipmentRMA(c *gin.Context) {
+	var req services.RMARequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректные данные: " + err.Error()})
+		return
+	}
+	req.UserID = equipmentUserID(c)
+
+	lifecycleService := services.NewEquipmentLifecycleService(api.DB)
+	rma, err := lifecycleService.SendToRMA(req)
+	if err != nil {
+		respondWarehouseError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Оборудование отправлено поставщику",
+		"data":    rma,
+	})
+}
+
+// ResolveEquipmentRMA закрывает возврат по ответу поставщика
+func (api *WarehouseAPI) ResolveEquipmentRMA(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный ID возврата"})
+		return
+	}
+
+	var req services.RMAResolveRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректные данные: " + err.Error()})
+		return
+	}
+	req.UserID = equipmentUserID(c)
+
+	lifecycleService := services.NewEquipmentLifecycleService(api.DB)
+	rma, err := lifecycleService.ResolveRMA(uint(id), req)
+	if err != nil {
+		respondWarehouseError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Возврат закрыт",
+		"data":    rma,
+	})
+}
@@ -0,0 +1,198 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"backend_axenta/services"
+)
+
+const maxGoodsReceiptFileSize = 10 << 20
+
+// bindGoodsReceiptRequest читает приходный документ из JSON или из формы с файлом поставщика
+func bindGoodsReceiptRequest(c *gin.Context) (*services.GoodsReceiptRequest, error) {
+	var req services.GoodsReceiptRequest
+
+	if !strings.HasPrefix(c.ContentType(), "multipart/") {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			return nil, errors.New("Некорректные данные: " + err.Error())
+		}
+		return &req, nil
+	}
+
+	if err := c.ShouldBind(&req); err != nil {
+		return nil, errors.New("Некорректные данные: " + err.Error())
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		return nil, errors.New("Не передан файл со списком оборудования")
+	}
+	if fileHeader.Size > maxGoodsReceiptFileSize {
+		return nil, errors.New("Файл со списком оборудования слишком большой")
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return nil, errors.New("Ошибка чтения файла")
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, errors.New("Ошибка чтения файла")
+	}
+
+	lines, err := services.ParseGoodsReceiptFile(fileHeader.Filename, data)
+	if err != nil {
+		return nil, err
+	}
+	req.Items = lines
+	return &req, nil
+}
+
+// ValidateGoodsReceipt проверяет список оборудования перед оприходованием
+func (api *WarehouseAPI) ValidateGoodsReceipt(c *gin.Context) {
+	req, err := bindGoodsReceiptRequest(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	receiptService := services.NewGoodsReceiptService(api.DB)
+	lines, problems, err := receiptService.ValidateLines(req.Items)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": gin.H{
+			"items":    lines,
+			"problems": problems,
+			"quantity": len(lines),
+			"valid":    len(problems) == 0,
+		},
+	})
+}
+
+// CreateGoodsReceipt оприходует партию оборудования по накладной поставщика
+func (api *WarehouseAPI) CreateGoodsReceipt(c *gin.Context) {
+	req, err := bindGoodsReceiptRequest(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if userID, exists := c.Get("user_id"); exists {
+		if uid, ok := userID.(uint); ok {
+			req.UserID = uid
+		}
+	}
+
+	receiptService := services.NewGoodsReceiptService(api.DB)
+	receipt, err := receiptService.CreateReceipt(*req)
+	if err != nil {
+		var validationErr *services.GoodsReceiptValidationError
+		if errors.As(err, &validationErr) {
+			c.JSON(http.StatusConflict, gin.H{
+				"error":    err.Error(),
+				"problems": validationErr.Problems,
+			})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Оборудование успешно оприходовано",
+		"data":    receipt,
+	})
+}
+
+// GetGoodsReceipts возвращает список приходных документов
+func (api *WarehouseAPI) GetGoodsReceipts(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 20
+	}
+
+	var warehouseID *uint
+	if id, err := strconv.ParseUint(c.Query("warehouse_id"), 10, 32); err == nil {
+		value := uint(id)
+		warehouseID = &value
+	}
+
+	receiptService := services.NewGoodsReceiptService(api.DB)
+	receipts, total, err := receiptService.GetReceipts(warehouseID, c.Query("supplier"), limit, (page-1)*limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": receipts,
+		"pagination": gin.H{
+			"page":  page,
+			"limit": limit,
+			"total": total,
+			"pages": (total + int64(limit) - 1) / int64(limit),
+		},
+	})
+}
+
+// GetGoodsReceipt возвращает приходный документ со списком оборудования
+func (api *WarehouseAPI) GetGoodsReceipt(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный ID документа"})
+		return
+	}
+
+	receiptService := services.NewGoodsReceiptService(api.DB)
+	receipt, err := receiptService.GetReceipt(uint(id))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Приходный документ не найден"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": receipt})
+}
+
+// PrintGoodsReceipt возвращает печатную форму приходного документа
+func (api *WarehouseAPI) PrintGoodsReceipt(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный ID документа"})
+		return
+	}
+
+	receiptService := services.NewGoodsReceiptService(api.DB)
+	receipt, err := receiptService.GetReceipt(uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Приходный документ не найден"})
+		return
+	}
+
+	html, err := receiptService.RenderReceiptHTML(receipt)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Data(http.StatusOK, "text/html; charset=utf-8", html)
+}
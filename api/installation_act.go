@@ -0,0 +1,89 @@
+package api
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"backend_axenta/middleware"
+	"backend_axenta/models"
+	"backend_axenta/services"
+)
+
+// InstallationCompletedEvent данные события installation.completed со ссылкой на акт выполненных работ
+type InstallationCompletedEvent struct {
+	*models.Installation
+	ActURL string `json:"act_url,omitempty"`
+}
+
+// newInstallationActService создает сервис актов с часовым поясом текущей компании
+func (api *InstallationAPI) newInstallationActService(c *gin.Context) *services.InstallationActService {
+	actService := services.NewInstallationActService(api.DB)
+	if company := middleware.GetCurrentCompany(c); company != nil {
+		actService.Timezone = company.Timezone
+	}
+	return actService
+}
+
+// installationActURL адрес скачивания акта монтажа
+func installationActURL(installationID uint) string {
+	return fmt.Sprintf("/api/installations/%d/act", installationID)
+}
+
+// publishInstallationCompleted формирует акт выполненных работ и публикует событие о завершении монтажа.
+// Ошибка формирования акта не отменяет завершение: акт можно сформировать позже.
+func (api *InstallationAPI) publishInstallationCompleted(c *gin.Context, installation *models.Installation) {
+	event := InstallationCompletedEvent{Installation: installation}
+	if generated, err := api.newInstallationActService(c).GenerateAct(installation.ID); err != nil {
+		log.Printf("Ошибка формирования акта по монтажу %d: %v", installation.ID, err)
+	} else {
+		installation.ActNumber = generated.ActNumber
+		installation.ActGeneratedAt = generated.ActGeneratedAt
+		event.ActURL = installationActURL(installation.ID)
+	}
+
+	services.PublishWebhookEvent(GetCompanyID(c), models.WebhookEventInstallationCompleted, event)
+	api.syncServiceRequest(c, installation)
+}
+
+// GetInstallationAct отдает PDF-акт выполненных работ по монтажу
+func (api *InstallationAPI) GetInstallationAct(c *gin.Context) {
+	id, ok := fieldInstallationID(c)
+	if !ok {
+		return
+	}
+
+	path, err := api.newInstallationActService(c).GetActFile(id)
+	if err != nil {
+		respondFieldError(c, err)
+		return
+	}
+
+	c.Header("Content-Type", "application/pdf")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"act_installation_%d.pdf\"", id))
+	c.File(path)
+}
+
+// RegenerateInstallationAct формирует акт заново, например после исправления данных объекта
+func (api *InstallationAPI) RegenerateInstallationAct(c *gin.Context) {
+	id, ok := fieldInstallationID(c)
+	if !ok {
+		return
+	}
+
+	installation, err := api.newInstallationActService(c).GenerateAct(id)
+	if err != nil {
+		respondFieldError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Акт сформирован",
+		"data": InstallationCompletedEvent{
+			Installation: installation,
+			ActURL:       installationActURL(id),
+		},
+	})
+}
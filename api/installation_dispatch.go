@@ -0,0 +1,58 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"backend_axenta/services"
+)
+
+// ProposeInstallationDispatch предлагает распределение заявок между монтажниками и маршруты на день
+func (api *InstallationAPI) ProposeInstallationDispatch(c *gin.Context) {
+	var req services.DispatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректные данные: " + err.Error()})
+		return
+	}
+
+	installationService := api.newInstallationService(c)
+	plan, err := installationService.ProposeDispatch(req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": plan})
+}
+
+// ApplyInstallationDispatch распределяет заявки и создает запланированные монтажи
+func (api *InstallationAPI) ApplyInstallationDispatch(c *gin.Context) {
+	var req services.DispatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректные данные: " + err.Error()})
+		return
+	}
+
+	var userID uint
+	if value, exists := c.Get("user_id"); exists {
+		if uid, ok := value.(uint); ok {
+			userID = uid
+		}
+	}
+
+	installationService := api.newInstallationService(c)
+	plan, installations, err := installationService.ApplyDispatch(req, userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Монтажи распределены",
+		"data": gin.H{
+			"plan":          plan,
+			"installations": installations,
+		},
+	})
+}
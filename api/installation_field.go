@@ -0,0 +1,345 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"backend_axenta/models"
+	"backend_axenta/services"
+)
+
+// respondFieldError отвечает на ошибку полевого приложения монтажника
+func respondFieldError(c *gin.Context, err error) {
+	if errors.Is(err, services.ErrChecklistIncomplete) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+	respondWarehouseError(c, err)
+}
+
+// fieldInstallationID разбирает ID монтажа из пути запроса
+func fieldInstallationID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный ID монтажа"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// GetChecklistTemplates возвращает шаблоны чек-листов монтажей
+func (api *InstallationAPI) GetChecklistTemplates(c *gin.Context) {
+	fieldService := services.NewInstallationFieldService(api.DB)
+	templates, err := fieldService.GetChecklistTemplates(c.Query("installation_type"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при получении шаблонов чек-листов"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": templates})
+}
+
+// CreateChecklistTemplate создает шаблон чек-листа для типа работ
+func (api *InstallationAPI) CreateChecklistTemplate(c *gin.Context) {
+	var req services.ChecklistTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректные данные: " + err.Error()})
+		return
+	}
+
+	fieldService := services.NewInstallationFieldService(api.DB)
+	template, err := fieldService.CreateChecklistTemplate(req)
+	if err != nil {
+		respondFieldError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Шаблон чек-листа создан", "data": template})
+}
+
+// UpdateChecklistTemplate изменяет шаблон чек-листа
+func (api *InstallationAPI) UpdateChecklistTemplate(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный ID шаблона"})
+		return
+	}
+	var req services.ChecklistTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректные данные: " + err.Error()})
+		return
+	}
+
+	fieldService := services.NewInstallationFieldService(api.DB)
+	template, err := fieldService.UpdateChecklistTemplate(uint(id), req)
+	if err != nil {
+		respondFieldError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Шаблон чек-листа обновлен", "data": template})
+}
+
+// GetFieldInstallation возвращает монтаж с чек-листом, фотографиями и оборудованием
+func (api *InstallationAPI) GetFieldInstallation(c *gin.Context) {
+	id, ok := fieldInstallationID(c)
+	if !ok {
+		return
+	}
+
+	fieldService := services.NewInstallationFieldService(api.DB)
+	installation, err := fieldService.GetFieldInstallation(id)
+	if err != nil {
+		respondFieldError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": installation})
+}
+
+// StartFieldInstallation начинает работы по монтажу из полевого приложения
+func (api *InstallationAPI) StartFieldInstallation(c *gin.Context) {
+	id, ok := fieldInstallationID(c)
+	if !ok {
+		return
+	}
+	var req struct {
+		StartedAt *time.Time `json:"started_at"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil && err != io.EOF {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректные данные: " + err.Error()})
+		return
+	}
+
+	fieldService := services.NewInstallationFieldService(api.DB)
+	installation, err := fieldService.StartInstallation(id, req.StartedAt)
+	if err != nil {
+		respondFieldError(c, err)
+		return
+	}
+	api.syncServiceRequest(c, installation)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Монтаж начат", "data": installation})
+}
+
+// UpdateChecklistItem отмечает пункт чек-листа монтажа
+func (api *InstallationAPI) UpdateChecklistItem(c *gin.Context) {
+	id, ok := fieldInstallationID(c)
+	if !ok {
+		return
+	}
+	itemID, err := strconv.ParseUint(c.Param("item_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный ID пункта чек-листа"})
+		return
+	}
+	var req services.ChecklistItemUpdate
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректные данные: " + err.Error()})
+		return
+	}
+
+	fieldService := services.NewInstallationFieldService(api.DB)
+	item, applied, err := fieldService.UpdateChecklistItem(id, uint(itemID), req)
+	if err != nil {
+		respondFieldError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": item, "applied": applied})
+}
+
+// UploadInstallationPhoto загружает фотографию с монтажа (multipart/form-data, поле file)
+func (api *InstallationAPI) UploadInstallationPhoto(c *gin.Context) {
+	id, ok := fieldInstallationID(c)
+	if !ok {
+		return
+	}
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Не передан файл фотографии"})
+		return
+	}
+	if fileHeader.Size > services.MaxInstallationPhotoSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Слишком большой файл фотографии"})
+		return
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Не удалось прочитать файл фотографии"})
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Не удалось прочитать файл фотографии"})
+		return
+	}
+
+	upload := services.PhotoUpload{
+		ClientID: c.PostForm("client_id"),
+		Caption:  c.PostForm("caption"),
+		FileName: fileHeader.Filename,
+		Data:     data,
+	}
+	if value := c.PostForm("checklist_item_id"); value != "" {
+		itemID, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный ID пункта чек-листа"})
+			return
+		}
+		parsed := uint(itemID)
+		upload.ChecklistItemID = &parsed
+	}
+	if value := c.PostForm("taken_at"); value != "" {
+		takenAt, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректное время съемки"})
+			return
+		}
+		upload.TakenAt = &takenAt
+	}
+
+	fieldService := services.NewInstallationFieldService(api.DB)
+	photo, err := fieldService.UploadPhoto(id, upload)
+	if err != nil {
+		respondFieldError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Фотография загружена", "data": photo})
+}
+
+// GetInstallationPhoto отдает файл фотографии монтажа; ?thumbnail=true - миниатюру
+func (api *InstallationAPI) GetInstallationPhoto(c *gin.Context) {
+	id, ok := fieldInstallationID(c)
+	if !ok {
+		return
+	}
+	photoID, err := strconv.ParseUint(c.Param("photo_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный ID фотографии"})
+		return
+	}
+
+	fieldService := services.NewInstallationFieldService(api.DB)
+	photo, err := fieldService.GetPhoto(id, uint(photoID))
+	if err != nil {
+		respondFieldError(c, err)
+		return
+	}
+
+	if c.Query("thumbnail") == "true" {
+		c.Header("Content-Type", "image/jpeg")
+		c.File(photo.ThumbnailPath)
+		return
+	}
+	c.Header("Content-Type", photo.ContentType)
+	c.File(photo.FilePath)
+}
+
+// SaveInstallationSignature сохраняет подпись клиента
+func (api *InstallationAPI) SaveInstallationSignature(c *gin.Context) {
+	id, ok := fieldInstallationID(c)
+	if !ok {
+		return
+	}
+	var req services.SignatureRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректные данные: " + err.Error()})
+		return
+	}
+
+	fieldService := services.NewInstallationFieldService(api.DB)
+	installation, err := fieldService.SaveSignature(id, req)
+	if err != nil {
+		respondFieldError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Подпись клиента сохранена", "data": installation})
+}
+
+// ScanInstallationEquipment устанавливает оборудование по отсканированному серийному номеру, IMEI или QR-коду
+func (api *InstallationAPI) ScanInstallationEquipment(c *gin.Context) {
+	id, ok := fieldInstallationID(c)
+	if !ok {
+		return
+	}
+	var req services.EquipmentScanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректные данные: " + err.Error()})
+		return
+	}
+
+	fieldService := services.NewInstallationFieldService(api.DB)
+	equipment, err := fieldService.ScanEquipment(id, req.Code)
+	if err != nil {
+		respondFieldError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Оборудование установлено", "data": equipment})
+}
+
+// CompleteFieldInstallation завершает монтаж после проверки чек-листа и подписи клиента
+func (api *InstallationAPI) CompleteFieldInstallation(c *gin.Context) {
+	id, ok := fieldInstallationID(c)
+	if !ok {
+		return
+	}
+	var req services.FieldCompleteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректные данные: " + err.Error()})
+		return
+	}
+
+	fieldService := services.NewInstallationFieldService(api.DB)
+	installation, err := fieldService.CompleteInstallation(id, req)
+	if err != nil {
+		respondFieldError(c, err)
+		return
+	}
+
+	api.publishInstallationCompleted(c, installation)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Монтаж успешно завершен", "data": installation})
+}
+
+// SyncFieldEvents принимает пакет событий, накопленных полевым приложением без связи.
+// Повторная отправка пакета безопасна: уже примененные события возвращаются со статусом duplicate.
+func (api *InstallationAPI) SyncFieldEvents(c *gin.Context) {
+	var req struct {
+		Events []services.FieldSyncEvent `json:"events" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректные данные: " + err.Error()})
+		return
+	}
+
+	fieldService := services.NewInstallationFieldService(api.DB)
+	results := fieldService.SyncFieldEvents(req.Events)
+
+	for i, result := range results {
+		if result.Status != "applied" {
+			continue
+		}
+		switch req.Events[i].Type {
+		case models.FieldEventComplete:
+			if installation, err := fieldService.GetFieldInstallation(req.Events[i].InstallationID); err == nil {
+				api.publishInstallationCompleted(c, installation)
+			}
+		case models.FieldEventStart:
+			if installation, err := fieldService.GetFieldInstallation(req.Events[i].InstallationID); err == nil {
+				api.syncServiceRequest(c, installation)
+			}
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": results})
+}
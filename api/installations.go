@@ -9,7 +9,9 @@ import (
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 
+	"backend_axenta/middleware"
 	"backend_axenta/models"
+	"backend_axenta/services"
 )
 
 // InstallationAPI представляет API для работы с монтажами
@@ -22,6 +24,15 @@ func NewInstallationAPI(db *gorm.DB) *InstallationAPI {
 	return &InstallationAPI{DB: db}
 }
 
+// newInstallationService создает сервис монтажей с часовым поясом текущей компании
+func (api *InstallationAPI) newInstallationService(c *gin.Context) *services.InstallationService {
+	installationService := services.NewInstallationService(api.DB, nil)
+	if company := middleware.GetCurrentCompany(c); company != nil {
+		installationService.Timezone = company.Timezone
+	}
+	return installationService
+}
+
 // CreateInstallation создает новый монтаж
 func (api *InstallationAPI) CreateInstallation(c *gin.Context) {
 	var installation models.Installation
@@ -44,34 +55,6 @@ func (api *InstallationAPI) CreateInstallation(c *gin.Context) {
 		return
 	}
 
-	// Проверяем доступность монтажника на указанную дату
-	if !installer.IsAvailableOnDate(installation.ScheduledAt) {
-		c.JSON(http.StatusConflict, gin.H{"error": "Монтажник недоступен на указанную дату"})
-		return
-	}
-
-	// Проверяем конфликты в расписании
-	var conflictingInstallations []models.Installation
-	startTime := installation.ScheduledAt
-	endTime := startTime.Add(time.Duration(installation.EstimatedDuration) * time.Minute)
-
-	err := api.DB.Where("installer_id = ? AND status IN ('planned', 'in_progress') AND scheduled_at BETWEEN ? AND ?",
-		installation.InstallerID, startTime.Add(-2*time.Hour), endTime.Add(2*time.Hour)).
-		Find(&conflictingInstallations).Error
-
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при проверке расписания"})
-		return
-	}
-
-	if len(conflictingInstallations) > 0 {
-		c.JSON(http.StatusConflict, gin.H{
-			"error":     "У монтажника уже есть работы в это время",
-			"conflicts": conflictingInstallations,
-		})
-		return
-	}
-
 	// Устанавливаем значения по умолчанию
 	if installation.Status == "" {
 		installation.Status = "planned"
@@ -83,6 +66,12 @@ func (api *InstallationAPI) CreateInstallation(c *gin.Context) {
 		installation.EstimatedDuration = 120 // 2 часа по умолчанию
 	}
 
+	// Проверяем рабочий день, рабочие часы, дневной лимит и конфликты в часовом поясе локации
+	if err := api.newInstallationService(c).ValidateInstallationSlot(&installation); err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+
 	// Получаем ID пользователя из контекста (добавлен middleware)
 	if userID, exists := c.Get("user_id"); exists {
 		if uid, ok := userID.(uint); ok {
@@ -289,6 +278,7 @@ func (api *InstallationAPI) StartInstallation(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при обновлении статуса монтажа"})
 		return
 	}
+	api.syncServiceRequest(c, &installation)
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Монтаж начат",
@@ -315,6 +305,12 @@ func (api *InstallationAPI) CompleteInstallation(c *gin.Context) {
 		return
 	}
 
+	// Обязательные пункты чек-листа, фотографии и подпись клиента проверяются и здесь
+	if err := services.NewInstallationFieldService(api.DB).ValidateCompletion(&installation); err != nil {
+		respondFieldError(c, err)
+		return
+	}
+
 	var completeData struct {
 		Result         string  `json:"result"`
 		Notes          string  `json:"notes"`
@@ -348,6 +344,8 @@ func (api *InstallationAPI) CompleteInstallation(c *gin.Context) {
 		return
 	}
 
+	api.publishInstallationCompleted(c, &installation)
+
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Монтаж успешно завершен",
 		"data":    installation,
@@ -389,6 +387,7 @@ func (api *InstallationAPI) CancelInstallation(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при отмене монтажа"})
 		return
 	}
+	api.syncServiceRequest(c, &installation)
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Монтаж отменен",
@@ -472,3 +471,48 @@ func (api *InstallationAPI) GetInstallationStatistics(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"data": stats})
 }
+
+// GetInstallerFreeSlots возвращает свободные слоты монтажника для работы указанной длительности
+func (api *InstallationAPI) GetInstallerFreeSlots(c *gin.Context) {
+	installerID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный ID монтажника"})
+		return
+	}
+	duration, _ := strconv.Atoi(c.DefaultQuery("duration", "120"))
+	days, _ := strconv.Atoi(c.DefaultQuery("days", "7"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	var locationID *uint
+	if value := c.Query("location_id"); value != "" {
+		id, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный ID локации"})
+			return
+		}
+		parsed := uint(id)
+		locationID = &parsed
+	}
+
+	// Дата начала - в часовом поясе компании; для сегодняшнего дня слоты начинаются с текущего момента
+	installationService := api.newInstallationService(c)
+	from := time.Now()
+	if value := c.Query("date_from"); value != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", value, models.LoadTimezone(installationService.Timezone))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректная дата начала"})
+			return
+		}
+		if parsed.After(from) {
+			from = parsed
+		}
+	}
+
+	slots, err := installationService.SuggestFreeSlots(uint(installerID), from, days, duration, locationID, limit)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": slots})
+}
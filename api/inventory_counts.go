@@ -0,0 +1,224 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"backend_axenta/services"
+)
+
+// parseInventoryCountID разбирает ID инвентаризации из пути запроса
+func parseInventoryCountID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный ID инвентаризации"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// respondWarehouseError возвращает ошибку складской операции с подходящим HTTP статусом
+func respondWarehouseError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrEquipmentConflict):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	}
+}
+
+// GetInventoryCounts возвращает список инвентаризаций
+func (api *WarehouseAPI) GetInventoryCounts(c *gin.Context) {
+	var warehouseID *uint
+	if id, err := strconv.ParseUint(c.Query("warehouse_id"), 10, 32); err == nil {
+		value := uint(id)
+		warehouseID = &value
+	}
+
+	inventoryService := services.NewInventoryCountService(api.DB)
+	counts, err := inventoryService.GetCounts(warehouseID, c.Query("status"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": counts})
+}
+
+// StartInventoryCount начинает инвентаризацию склада
+func (api *WarehouseAPI) StartInventoryCount(c *gin.Context) {
+	var req struct {
+		WarehouseID  uint   `json:"warehouse_id" binding:"required"`
+		StorageBinID *uint  `json:"storage_bin_id"`
+		Notes        string `json:"notes"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректные данные: " + err.Error()})
+		return
+	}
+
+	inventoryService := services.NewInventoryCountService(api.DB)
+	count, err := inventoryService.StartCount(req.WarehouseID, req.StorageBinID, equipmentUserID(c), req.Notes)
+	if err != nil {
+		respondWarehouseError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Инвентаризация начата",
+		"data":    count,
+	})
+}
+
+// GetInventoryCount возвращает инвентаризацию с позициями
+func (api *WarehouseAPI) GetInventoryCount(c *gin.Context) {
+	id, ok := parseInventoryCountID(c)
+	if !ok {
+		return
+	}
+
+	inventoryService := services.NewInventoryCountService(api.DB)
+	count, err := inventoryService.GetCount(id)
+	if err != nil {
+		respondWarehouseError(c, err)
+		return
+	}
+	items, err := inventoryService.GetItems(id, c.Query("result"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	count.Items = items
+
+	c.JSON(http.StatusOK, gin.H{"data": count})
+}
+
+// ScanInventoryCodes принимает отсканированные коды с мобильного клиента.
+// Коды можно передать по одному (code) или пачкой после работы без связи (codes).
+func (api *WarehouseAPI) ScanInventoryCodes(c *gin.Context) {
+	id, ok := parseInventoryCountID(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Code  string   `json:"code"`
+		Codes []string `json:"codes"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректные данные: " + err.Error()})
+		return
+	}
+	codes := req.Codes
+	if req.Code != "" {
+		codes = append(codes, req.Code)
+	}
+	if len(codes) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Не переданы коды"})
+		return
+	}
+
+	inventoryService := services.NewInventoryCountService(api.DB)
+	userID := equipmentUserID(c)
+	results := make([]gin.H, 0, len(codes))
+	for _, code := range codes {
+		item, err := inventoryService.ScanCode(id, code, userID)
+		if err != nil {
+			if len(codes) == 1 {
+				respondWarehouseError(c, err)
+				return
+			}
+			results = append(results, gin.H{"code": code, "error": err.Error()})
+			continue
+		}
+		results = append(results, gin.H{"code": code, "item": item})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": results})
+}
+
+// CompleteInventoryCount завершает пересчет и рассчитывает расхождения
+func (api *WarehouseAPI) CompleteInventoryCount(c *gin.Context) {
+	id, ok := parseInventoryCountID(c)
+	if !ok {
+		return
+	}
+
+	inventoryService := services.NewInventoryCountService(api.DB)
+	count, err := inventoryService.CompleteCount(id)
+	if err != nil {
+		respondWarehouseError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Пересчет завершен",
+		"data":    count,
+	})
+}
+
+// ApproveInventoryCount проводит расхождения инвентаризации
+func (api *WarehouseAPI) ApproveInventoryCount(c *gin.Context) {
+	id, ok := parseInventoryCountID(c)
+	if !ok {
+		return
+	}
+
+	inventoryService := services.NewInventoryCountService(api.DB)
+	count, err := inventoryService.ApproveCount(id, equipmentUserID(c))
+	if err != nil {
+		respondWarehouseError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Расхождения проведены",
+		"data":    count,
+	})
+}
+
+// CancelInventoryCount отменяет инвентаризацию
+func (api *WarehouseAPI) CancelInventoryCount(c *gin.Context) {
+	id, ok := parseInventoryCountID(c)
+	if !ok {
+		return
+	}
+
+	inventoryService := services.NewInventoryCountService(api.DB)
+	if err := inventoryService.CancelCount(id); err != nil {
+		respondWarehouseError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Инвентаризация отменена"})
+}
+
+// ExportInventoryReport выгружает отчет о расхождениях (xlsx или csv)
+func (api *WarehouseAPI) ExportInventoryReport(c *gin.Context) {
+	id, ok := parseInventoryCountID(c)
+	if !ok {
+		return
+	}
+
+	format := c.DefaultQuery("format", "xlsx")
+	inventoryService := services.NewInventoryCountService(api.DB)
+	data, fileName, err := inventoryService.ExportDiscrepancyReport(id, format)
+	if err != nil {
+		respondWarehouseError(c, err)
+		return
+	}
+
+	contentType := "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	if format == "csv" {
+		contentType = "text/csv; charset=utf-8"
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", fileName))
+	c.Data(http.StatusOK, contentType, data)
+}
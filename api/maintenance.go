@@ -0,0 +1,169 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"backend_axenta/middleware"
+	"backend_axenta/models"
+	"backend_axenta/services"
+)
+
+// newMaintenanceService создает сервис планового обслуживания с часовым поясом текущей компании
+func (api *InstallationAPI) newMaintenanceService(c *gin.Context) *services.MaintenanceService {
+	maintenanceService := services.NewMaintenanceService(api.DB)
+	if company := middleware.GetCurrentCompany(c); company != nil {
+		maintenanceService.Timezone = company.Timezone
+	}
+	return maintenanceService
+}
+
+// parseMaintenancePlanID разбирает ID плана обслуживания из пути запроса
+func parseMaintenancePlanID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный ID плана обслуживания"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// GetMaintenancePlans возвращает планы обслуживания; фильтр object_id
+func (api *InstallationAPI) GetMaintenancePlans(c *gin.Context) {
+	plans, err := api.newMaintenanceService(c).GetPlans(queryUintPointer(c, "object_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при получении планов обслуживания"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": plans})
+}
+
+// GetMaintenancePlan возвращает план обслуживания
+func (api *InstallationAPI) GetMaintenancePlan(c *gin.Context) {
+	id, ok := parseMaintenancePlanID(c)
+	if !ok {
+		return
+	}
+
+	plan, err := api.newMaintenanceService(c).GetPlan(id)
+	if err != nil {
+		respondWarehouseError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": plan})
+}
+
+// CreateMaintenancePlan создает план обслуживания объекта или категории оборудования
+func (api *InstallationAPI) CreateMaintenancePlan(c *gin.Context) {
+	var req services.MaintenancePlanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректные данные: " + err.Error()})
+		return
+	}
+
+	plan, err := api.newMaintenanceService(c).CreatePlan(req)
+	if err != nil {
+		respondWarehouseError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "План обслуживания создан", "data": plan})
+}
+
+// UpdateMaintenancePlan изменяет план обслуживания
+func (api *InstallationAPI) UpdateMaintenancePlan(c *gin.Context) {
+	id, ok := parseMaintenancePlanID(c)
+	if !ok {
+		return
+	}
+	var req services.MaintenancePlanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректные данные: " + err.Error()})
+		return
+	}
+
+	plan, err := api.newMaintenanceService(c).UpdatePlan(id, req)
+	if err != nil {
+		respondWarehouseError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "План обслуживания обновлен", "data": plan})
+}
+
+// DeleteMaintenancePlan удаляет план обслуживания
+func (api *InstallationAPI) DeleteMaintenancePlan(c *gin.Context) {
+	id, ok := parseMaintenancePlanID(c)
+	if !ok {
+		return
+	}
+
+	if err := api.newMaintenanceService(c).DeletePlan(id); err != nil {
+		respondWarehouseError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "План обслуживания удален"})
+}
+
+// GenerateMaintenance запускает проверку планов обслуживания и создание монтажей
+func (api *InstallationAPI) GenerateMaintenance(c *gin.Context) {
+	result, err := api.newMaintenanceService(c).GenerateDueMaintenance(time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Проверка планов обслуживания завершена", "data": result})
+}
+
+// GetMaintenanceVisits возвращает плановые обслуживания; фильтры plan_id, object_id, status
+func (api *InstallationAPI) GetMaintenanceVisits(c *gin.Context) {
+	visits, err := api.newMaintenanceService(c).GetVisits(queryUintPointer(c, "plan_id"), queryUintPointer(c, "object_id"), c.Query("status"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при получении обслуживаний"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": visits})
+}
+
+// GetMaintenanceCompliance возвращает соблюдение сроков обслуживания за период
+// (date_from, date_to в формате YYYY-MM-DD, по умолчанию - последние 12 месяцев)
+func (api *InstallationAPI) GetMaintenanceCompliance(c *gin.Context) {
+	maintenanceService := api.newMaintenanceService(c)
+	tz := models.LoadTimezone(maintenanceService.Timezone)
+
+	now := time.Now()
+	to := now.In(tz).AddDate(0, 0, 1)
+	from := to.AddDate(-1, 0, 0)
+	if value := c.Query("date_from"); value != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", value, tz)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный формат date_from, ожидается YYYY-MM-DD"})
+			return
+		}
+		from = parsed
+	}
+	if value := c.Query("date_to"); value != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", value, tz)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный формат date_to, ожидается YYYY-MM-DD"})
+			return
+		}
+		to = parsed.AddDate(0, 0, 1)
+	}
+
+	compliance, err := maintenanceService.GetCompliance(queryUintPointer(c, "plan_id"), from, to, now)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": compliance})
+}
@@ -0,0 +1,95 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"backend_axenta/middleware"
+	"backend_axenta/services"
+)
+
+// CreateObjectBulkJob запускает массовую операцию над объектами, заданными списком object_ids
+// или фильтром: activate, deactivate, move_contract, schedule_delete, add_tags, remove_tags,
+// apply_template. Операция выполняется в фоне, прогресс доступен по ID задания.
+func CreateObjectBulkJob(c *gin.Context) {
+	tenantDB := middleware.GetTenantDB(c)
+	if tenantDB == nil {
+		c.JSON(500, gin.H{"status": "error", "error": "Ошибка подключения к базе данных компании"})
+		return
+	}
+
+	var request services.ObjectBulkRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(400, gin.H{"status": "error", "error": "Некорректные данные: " + err.Error()})
+		return
+	}
+
+	var userID *uint
+	if uid := equipmentUserID(c); uid != 0 {
+		userID = &uid
+	}
+
+	bulkService := services.NewObjectBulkService(tenantDB)
+	job, objectIDs, err := bulkService.CreateJob(request, userID)
+	if err != nil {
+		c.JSON(400, gin.H{"status": "error", "error": err.Error()})
+		return
+	}
+
+	go func() {
+		if err := bulkService.RunJob(job.ID, objectIDs); err != nil {
+			log.Printf("Ошибка выполнения массовой операции %d: %v", job.ID, err)
+		}
+	}()
+
+	c.JSON(202, gin.H{"status": "success", "message": "Массовая операция запущена", "data": job})
+}
+
+// GetObjectBulkJobs возвращает последние задания массовых операций
+func GetObjectBulkJobs(c *gin.Context) {
+	tenantDB := middleware.GetTenantDB(c)
+	if tenantDB == nil {
+		c.JSON(500, gin.H{"status": "error", "error": "Ошибка подключения к базе данных компании"})
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	jobs, err := services.NewObjectBulkService(tenantDB).GetJobs(limit)
+	if err != nil {
+		c.JSON(500, gin.H{"status": "error", "error": "Ошибка получения заданий: " + err.Error()})
+		return
+	}
+
+	c.JSON(200, gin.H{"status": "success", "data": jobs})
+}
+
+// GetObjectBulkJob возвращает прогресс задания массовой операции и ошибки по объектам
+func GetObjectBulkJob(c *gin.Context) {
+	tenantDB := middleware.GetTenantDB(c)
+	if tenantDB == nil {
+		c.JSON(500, gin.H{"status": "error", "error": "Ошибка подключения к базе данных компании"})
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(400, gin.H{"status": "error", "error": "Некорректный ID задания"})
+		return
+	}
+
+	job, err := services.NewObjectBulkService(tenantDB).GetJob(uint(id))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(404, gin.H{"status": "error", "error": "Задание не найдено"})
+		} else {
+			c.JSON(500, gin.H{"status": "error", "error": "Ошибка получения задания: " + err.Error()})
+		}
+		return
+	}
+
+	c.JSON(200, gin.H{"status": "success", "data": job})
+}
@@ -0,0 +1,113 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/gin-gonic/gin"
+
+	"backend_axenta/middleware"
+	"backend_axenta/services"
+)
+
+const maxObjectImportFileSize = 10 << 20
+
+// ImportObjects импортирует объекты из Excel (.xlsx) или CSV файла. Поля формы contract_id,
+// template_id, location_id и type задают значения для строк, где они не указаны. При dry_run=true
+// возвращается только результат проверки; объекты создаются, только если в файле нет ошибок.
+func ImportObjects(c *gin.Context) {
+	tenantDB := middleware.GetTenantDB(c)
+	if tenantDB == nil {
+		c.JSON(500, gin.H{"status": "error", "error": "Ошибка подключения к базе данных компании"})
+		return
+	}
+
+	var defaults services.ObjectImportDefaults
+	if err := c.ShouldBind(&defaults); err != nil {
+		c.JSON(400, gin.H{"status": "error", "error": "Некорректные данные: " + err.Error()})
+		return
+	}
+	dryRun := c.DefaultPostForm("dry_run", c.Query("dry_run")) == "true"
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(400, gin.H{"status": "error", "error": "Не передан файл со списком объектов"})
+		return
+	}
+	if fileHeader.Size > maxObjectImportFileSize {
+		c.JSON(400, gin.H{"status": "error", "error": "Файл со списком объектов слишком большой"})
+		return
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(400, gin.H{"status": "error", "error": "Ошибка чтения файла"})
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		c.JSON(400, gin.H{"status": "error", "error": "Ошибка чтения файла"})
+		return
+	}
+
+	rows, err := services.ParseObjectImportFile(fileHeader.Filename, data)
+	if err != nil {
+		c.JSON(400, gin.H{"status": "error", "error": err.Error()})
+		return
+	}
+
+	result, err := services.NewObjectImportService(tenantDB).Import(rows, defaults, dryRun)
+	if errors.Is(err, services.ErrObjectImportInvalid) {
+		c.JSON(422, gin.H{"status": "error", "error": err.Error(), "data": result})
+		return
+	}
+	if err != nil {
+		c.JSON(500, gin.H{"status": "error", "error": "Ошибка импорта объектов: " + err.Error()})
+		return
+	}
+
+	if dryRun {
+		c.JSON(200, gin.H{"status": "success", "message": "Файл проверен, ошибок не найдено", "data": result})
+		return
+	}
+	c.JSON(201, gin.H{"status": "success", "message": "Объекты успешно импортированы", "data": result})
+}
+
+// ExportObjects выгружает список объектов в Excel (format=xlsx, по умолчанию) или CSV.
+// Поддерживает фильтры списка объектов: status, type, search, contract_id, template_id, location_id.
+func ExportObjects(c *gin.Context) {
+	tenantDB := middleware.GetTenantDB(c)
+	if tenantDB == nil {
+		c.JSON(500, gin.H{"status": "error", "error": "Ошибка подключения к базе данных компании"})
+		return
+	}
+
+	filter := services.ObjectFilter{
+		Status:     c.Query("status"),
+		Type:       c.Query("type"),
+		Search:     c.Query("search"),
+		ContractID: queryUintPointer(c, "contract_id"),
+		TemplateID: queryUintPointer(c, "template_id"),
+		LocationID: queryUintPointer(c, "location_id"),
+	}
+
+	format := c.DefaultQuery("format", "xlsx")
+	if format != "xlsx" && format != "csv" {
+		c.JSON(400, gin.H{"status": "error", "error": "Неподдерживаемый формат, допустимы xlsx и csv"})
+		return
+	}
+
+	data, filename, err := services.NewObjectImportService(tenantDB).ExportObjects(filter, format)
+	if err != nil {
+		c.JSON(500, gin.H{"status": "error", "error": err.Error()})
+		return
+	}
+
+	contentType := "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	if format == "csv" {
+		contentType = "text/csv; charset=utf-8"
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", filename))
+	c.Data(200, contentType, data)
+}
@@ -0,0 +1,71 @@
+package api
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"backend_axenta/middleware"
+	"backend_axenta/models"
+	"backend_axenta/services"
+)
+
+// parseTelemetryTime разбирает границу периода в формате RFC3339 или YYYY-MM-DD
+func parseTelemetryTime(value string) (time.Time, error) {
+	if parsed, err := time.Parse(time.RFC3339, value); err == nil {
+		return parsed, nil
+	}
+	return time.Parse("2006-01-02", value)
+}
+
+// GetObjectTelemetry возвращает точки телеметрии объекта за период (from, to в формате
+// RFC3339 или YYYY-MM-DD, по умолчанию - последние сутки), от новых к старым
+func GetObjectTelemetry(c *gin.Context) {
+	tenantDB := middleware.GetTenantDB(c)
+	if tenantDB == nil {
+		c.JSON(500, gin.H{"status": "error", "error": "Ошибка подключения к базе данных компании"})
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(400, gin.H{"status": "error", "error": "Некорректный ID объекта"})
+		return
+	}
+
+	var object models.Object
+	if err := tenantDB.First(&object, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(404, gin.H{"status": "error", "error": "Объект не найден"})
+		} else {
+			c.JSON(500, gin.H{"status": "error", "error": "Ошибка поиска объекта: " + err.Error()})
+		}
+		return
+	}
+
+	to := time.Now()
+	from := to.Add(-24 * time.Hour)
+	if value := c.Query("from"); value != "" {
+		if from, err = parseTelemetryTime(value); err != nil {
+			c.JSON(400, gin.H{"status": "error", "error": "Некорректный формат from. Используйте YYYY-MM-DD или RFC3339"})
+			return
+		}
+	}
+	if value := c.Query("to"); value != "" {
+		if to, err = parseTelemetryTime(value); err != nil {
+			c.JSON(400, gin.H{"status": "error", "error": "Некорректный формат to. Используйте YYYY-MM-DD или RFC3339"})
+			return
+		}
+	}
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "1000"))
+
+	points, err := services.GetObjectTelemetry(tenantDB, object.ID, from, to, limit)
+	if err != nil {
+		c.JSON(500, gin.H{"status": "error", "error": "Ошибка получения телеметрии: " + err.Error()})
+		return
+	}
+
+	c.JSON(200, gin.H{"status": "success", "data": points})
+}
@@ -8,7 +8,6 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/shopspring/decimal"
 	"gorm.io/gorm"
 )
 
@@ -200,6 +199,8 @@ func CreateObject(c *gin.Context) {
 		// }
 	}
 
+	services.PublishWebhookEvent(GetCompanyID(c), models.WebhookEventObjectCreated, object)
+
 	c.JSON(201, gin.H{"status": "success", "data": object})
 }
 
@@ -265,6 +266,17 @@ func UpdateObject(c *gin.Context) {
 	if updates.SerialNumber != existingObject.SerialNumber {
 		existingObject.SerialNumber = updates.SerialNumber
 	}
+	if updates.VIN != existingObject.VIN {
+		existingObject.VIN = updates.VIN
+	}
+	if updates.LicensePlate != existingObject.LicensePlate {
+		existingObject.LicensePlate = updates.LicensePlate
+	}
+	if updates.EngineHours != nil && (existingObject.EngineHours == nil || *updates.EngineHours != *existingObject.EngineHours) {
+		now := time.Now()
+		existingObject.EngineHours = updates.EngineHours
+		existingObject.EngineHoursUpdatedAt = &now
+	}
 	if updates.Status != "" && updates.Status != existingObject.Status {
 		existingObject.Status = updates.Status
 	}
@@ -335,6 +347,8 @@ func UpdateObject(c *gin.Context) {
 	// 	}
 	// }
 
+	services.PublishWebhookEvent(GetCompanyID(c), models.WebhookEventObjectUpdated, existingObject)
+
 	c.JSON(200, gin.H{"status": "success", "data": existingObject})
 }
 
@@ -380,6 +394,8 @@ func DeleteObject(c *gin.Context) {
 		return
 	}
 
+	services.PublishWebhookEvent(GetCompanyID(c), models.WebhookEventObjectDeleted, object)
+
 	c.JSON(200, gin.H{"status": "success", "message": "Объект успешно удален"})
 }
 
@@ -919,38 +935,5 @@ func DeleteObjectTemplate(c *gin.Context) {
 
 // applyContractTariff автоматически применяет тариф на основе договора объекта
 func applyContractTariff(db *gorm.DB, object *models.Object) error {
-	// Загружаем договор с тарифным планом
-	var contract models.Contract
-	if err := db.Preload("TariffPlan").First(&contract, object.ContractID).Error; err != nil {
-		return err
-	}
-
-	// Если у договора нет тарифного плана, пропускаем
-	if contract.TariffPlanID == 0 {
-		return nil
-	}
-
-	// Получаем количество объектов по договору
-	var totalObjects int64
-	var activeObjects int64
-	db.Model(&models.Object{}).Where("contract_id = ?", contract.ID).Count(&totalObjects)
-	db.Model(&models.Object{}).Where("contract_id = ? AND is_active = ?", contract.ID, true).Count(&activeObjects)
-	inactiveObjects := totalObjects - activeObjects
-
-	// Создаем TariffPlan для расчета стоимости
-	tariffPlan := models.TariffPlan{
-		BillingPlan:        contract.TariffPlan,
-		PricePerObject:     contract.TariffPlan.Price,
-		InactivePriceRatio: decimal.NewFromFloat(0.5), // 50% для неактивных объектов
-	}
-
-	// Рассчитываем новую стоимость договора
-	newCost := tariffPlan.CalculateObjectPrice(int(totalObjects), int(inactiveObjects))
-
-	// Обновляем стоимость договора
-	if err := db.Model(&contract).Update("total_amount", newCost).Error; err != nil {
-		return err
-	}
-
-	return nil
+	return services.ApplyContractTariff(db, object.ContractID)
 }
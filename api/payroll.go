@@ -0,0 +1,283 @@
+package api
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"backend_axenta/middleware"
+	"backend_axenta/services"
+)
+
+// newPayrollService создает сервис расчета вознаграждения с часовым поясом текущей компании
+func (api *InstallationAPI) newPayrollService(c *gin.Context) *services.PayrollService {
+	payrollService := services.NewPayrollService(api.DB)
+	if company := middleware.GetCurrentCompany(c); company != nil {
+		payrollService.Timezone = company.Timezone
+	}
+	return payrollService
+}
+
+// parsePayrollID разбирает ID из пути запроса
+func parsePayrollID(c *gin.Context, message string) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": message})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// GetPayrollRates возвращает сдельные ставки монтажников
+func (api *InstallationAPI) GetPayrollRates(c *gin.Context) {
+	rates, err := api.newPayrollService(c).GetRates(queryUintPointer(c, "installer_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при получении ставок"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": rates})
+}
+
+// CreatePayrollRate создает сдельную ставку
+func (api *InstallationAPI) CreatePayrollRate(c *gin.Context) {
+	var req services.PayrollRateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректные данные: " + err.Error()})
+		return
+	}
+
+	rate, err := api.newPayrollService(c).CreateRate(req)
+	if err != nil {
+		respondWarehouseError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Ставка создана", "data": rate})
+}
+
+// UpdatePayrollRate изменяет сдельную ставку
+func (api *InstallationAPI) UpdatePayrollRate(c *gin.Context) {
+	id, ok := parsePayrollID(c, "Неверный ID ставки")
+	if !ok {
+		return
+	}
+	var req services.PayrollRateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректные данные: " + err.Error()})
+		return
+	}
+
+	rate, err := api.newPayrollService(c).UpdateRate(id, req)
+	if err != nil {
+		respondWarehouseError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Ставка обновлена", "data": rate})
+}
+
+// DeletePayrollRate удаляет сдельную ставку
+func (api *InstallationAPI) DeletePayrollRate(c *gin.Context) {
+	id, ok := parsePayrollID(c, "Неверный ID ставки")
+	if !ok {
+		return
+	}
+
+	if err := api.newPayrollService(c).DeleteRate(id); err != nil {
+		respondWarehouseError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Ставка удалена"})
+}
+
+// GetPayrollPenaltyRules возвращает правила удержаний за качество
+func (api *InstallationAPI) GetPayrollPenaltyRules(c *gin.Context) {
+	rules, err := api.newPayrollService(c).GetPenaltyRules()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при получении правил удержаний"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": rules})
+}
+
+// CreatePayrollPenaltyRule создает правило удержания за низкую оценку качества
+func (api *InstallationAPI) CreatePayrollPenaltyRule(c *gin.Context) {
+	var req services.PayrollPenaltyRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректные данные: " + err.Error()})
+		return
+	}
+
+	rule, err := api.newPayrollService(c).CreatePenaltyRule(req)
+	if err != nil {
+		respondWarehouseError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Правило удержания создано", "data": rule})
+}
+
+// UpdatePayrollPenaltyRule изменяет правило удержания
+func (api *InstallationAPI) UpdatePayrollPenaltyRule(c *gin.Context) {
+	id, ok := parsePayrollID(c, "Неверный ID правила")
+	if !ok {
+		return
+	}
+	var req services.PayrollPenaltyRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректные данные: " + err.Error()})
+		return
+	}
+
+	rule, err := api.newPayrollService(c).UpdatePenaltyRule(id, req)
+	if err != nil {
+		respondWarehouseError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Правило удержания обновлено", "data": rule})
+}
+
+// DeletePayrollPenaltyRule удаляет правило удержания
+func (api *InstallationAPI) DeletePayrollPenaltyRule(c *gin.Context) {
+	id, ok := parsePayrollID(c, "Неверный ID правила")
+	if !ok {
+		return
+	}
+
+	if err := api.newPayrollService(c).DeletePenaltyRule(id); err != nil {
+		respondWarehouseError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Правило удержания удалено"})
+}
+
+// GeneratePayrollStatements рассчитывает расчетные листы монтажников за месяц
+func (api *InstallationAPI) GeneratePayrollStatements(c *gin.Context) {
+	var req services.PayrollGenerateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректные данные: " + err.Error()})
+		return
+	}
+
+	statements, err := api.newPayrollService(c).GenerateStatements(req)
+	if err != nil {
+		respondWarehouseError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Расчетные листы сформированы", "data": statements})
+}
+
+// GetPayrollStatements возвращает расчетные листы; фильтры month (YYYY-MM), installer_id, status
+func (api *InstallationAPI) GetPayrollStatements(c *gin.Context) {
+	statements, err := api.newPayrollService(c).GetStatements(c.Query("month"), queryUintPointer(c, "installer_id"), c.Query("status"))
+	if err != nil {
+		respondWarehouseError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": statements})
+}
+
+// GetPayrollStatement возвращает расчетный лист с начислениями по монтажам
+func (api *InstallationAPI) GetPayrollStatement(c *gin.Context) {
+	id, ok := parsePayrollID(c, "Неверный ID расчетного листа")
+	if !ok {
+		return
+	}
+
+	statement, err := api.newPayrollService(c).GetStatement(id)
+	if err != nil {
+		respondWarehouseError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": statement})
+}
+
+// ApprovePayrollStatement утверждает расчетный лист
+func (api *InstallationAPI) ApprovePayrollStatement(c *gin.Context) {
+	id, ok := parsePayrollID(c, "Неверный ID расчетного листа")
+	if !ok {
+		return
+	}
+
+	statement, err := api.newPayrollService(c).ApproveStatement(id, equipmentUserID(c))
+	if err != nil {
+		respondWarehouseError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Расчетный лист утвержден", "data": statement})
+}
+
+// ReopenPayrollStatement возвращает утвержденный расчетный лист в черновик
+func (api *InstallationAPI) ReopenPayrollStatement(c *gin.Context) {
+	id, ok := parsePayrollID(c, "Неверный ID расчетного листа")
+	if !ok {
+		return
+	}
+
+	statement, err := api.newPayrollService(c).ReopenStatement(id)
+	if err != nil {
+		respondWarehouseError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Расчетный лист возвращен в черновик", "data": statement})
+}
+
+// MarkPayrollStatementPaid отмечает выплату по расчетному листу
+func (api *InstallationAPI) MarkPayrollStatementPaid(c *gin.Context) {
+	id, ok := parsePayrollID(c, "Неверный ID расчетного листа")
+	if !ok {
+		return
+	}
+	var req struct {
+		PaidAt *time.Time `json:"paid_at"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil && err != io.EOF {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректные данные: " + err.Error()})
+		return
+	}
+
+	statement, err := api.newPayrollService(c).MarkStatementPaid(id, req.PaidAt)
+	if err != nil {
+		respondWarehouseError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Выплата отмечена", "data": statement})
+}
+
+// ExportPayrollStatements выгружает ведомость начислений за месяц (xlsx или csv)
+func (api *InstallationAPI) ExportPayrollStatements(c *gin.Context) {
+	month := c.Query("month")
+	if month == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Не указан месяц (month=YYYY-MM)"})
+		return
+	}
+
+	format := c.DefaultQuery("format", "xlsx")
+	data, fileName, err := api.newPayrollService(c).ExportStatements(month, format)
+	if err != nil {
+		respondWarehouseError(c, err)
+		return
+	}
+
+	contentType := "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	if format == "csv" {
+		contentType = "text/csv; charset=utf-8"
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", fileName))
+	c.Data(http.StatusOK, contentType, data)
+}
@@ -0,0 +1,206 @@
+package api
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"backend_axenta/services"
+)
+
+// parsePurchaseOrderID разбирает ID заказа поставщику из пути запроса
+func parsePurchaseOrderID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный ID заказа"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// GetPurchaseOrders возвращает список заказов поставщикам
+func (api *WarehouseAPI) GetPurchaseOrders(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 20
+	}
+
+	orderService := services.NewPurchaseOrderService(api.DB)
+	orders, total, err := orderService.GetPurchaseOrders(c.Query("status"), c.Query("supplier"), queryUintPointer(c, "warehouse_id"), limit, (page-1)*limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": orders,
+		"pagination": gin.H{
+			"page":  page,
+			"limit": limit,
+			"total": total,
+			"pages": (total + int64(limit) - 1) / int64(limit),
+		},
+	})
+}
+
+// CreatePurchaseOrder создает черновик заказа поставщику
+func (api *WarehouseAPI) CreatePurchaseOrder(c *gin.Context) {
+	var req services.PurchaseOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректные данные: " + err.Error()})
+		return
+	}
+	req.UserID = equipmentUserID(c)
+
+	orderService := services.NewPurchaseOrderService(api.DB)
+	order, err := orderService.CreatePurchaseOrder(req)
+	if err != nil {
+		respondWarehouseError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Заказ создан",
+		"data":    order,
+	})
+}
+
+// GetPurchaseOrder возвращает заказ поставщику с позициями и полученными партиями
+func (api *WarehouseAPI) GetPurchaseOrder(c *gin.Context) {
+	id, ok := parsePurchaseOrderID(c)
+	if !ok {
+		return
+	}
+
+	orderService := services.NewPurchaseOrderService(api.DB)
+	order, err := orderService.GetPurchaseOrder(id)
+	if err != nil {
+		respondWarehouseError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": order})
+}
+
+// SubmitPurchaseOrder отправляет заказ поставщику
+func (api *WarehouseAPI) SubmitPurchaseOrder(c *gin.Context) {
+	id, ok := parsePurchaseOrderID(c)
+	if !ok {
+		return
+	}
+	var req struct {
+		ExpectedAt *time.Time `json:"expected_at"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil && err != io.EOF {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректные данные: " + err.Error()})
+		return
+	}
+
+	orderService := services.NewPurchaseOrderService(api.DB)
+	order, err := orderService.SubmitPurchaseOrder(id, req.ExpectedAt)
+	if err != nil {
+		respondWarehouseError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Заказ отправлен поставщику",
+		"data":    order,
+	})
+}
+
+// CancelPurchaseOrder отменяет заказ или закрывает его с недопоставкой
+func (api *WarehouseAPI) CancelPurchaseOrder(c *gin.Context) {
+	id, ok := parsePurchaseOrderID(c)
+	if !ok {
+		return
+	}
+	var req struct {
+		Reason string `json:"reason"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil && err != io.EOF {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректные данные: " + err.Error()})
+		return
+	}
+
+	orderService := services.NewPurchaseOrderService(api.DB)
+	order, err := orderService.CancelPurchaseOrder(id, req.Reason)
+	if err != nil {
+		respondWarehouseError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Заказ закрыт",
+		"data":    order,
+	})
+}
+
+// GetReplenishmentPlan возвращает категории оборудования, которые пора заказать
+func (api *WarehouseAPI) GetReplenishmentPlan(c *gin.Context) {
+	horizonDays, _ := strconv.Atoi(c.Query("horizon_days"))
+
+	orderService := services.NewPurchaseOrderService(api.DB)
+	plan, err := orderService.GetReplenishmentPlan(queryUintPointer(c, "warehouse_id"), horizonDays)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": plan})
+}
+
+// CreateReplenishmentOrders формирует черновики заказов по плану пополнения
+func (api *WarehouseAPI) CreateReplenishmentOrders(c *gin.Context) {
+	var req services.ReplenishmentOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректные данные: " + err.Error()})
+		return
+	}
+	req.UserID = equipmentUserID(c)
+
+	orderService := services.NewPurchaseOrderService(api.DB)
+	orders, err := orderService.CreateReplenishmentOrders(req)
+	if err != nil {
+		respondWarehouseError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Заказы сформированы",
+		"data":    orders,
+	})
+}
+
+// UpdateCategoryReplenishment задает параметры пополнения категории оборудования
+func (api *WarehouseAPI) UpdateCategoryReplenishment(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный ID категории"})
+		return
+	}
+	var settings services.ReplenishmentSettings
+	if err := c.ShouldBindJSON(&settings); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректные данные: " + err.Error()})
+		return
+	}
+
+	orderService := services.NewPurchaseOrderService(api.DB)
+	category, err := orderService.UpdateReplenishmentSettings(uint(id), settings)
+	if err != nil {
+		respondWarehouseError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Параметры пополнения обновлены",
+		"data":    category,
+	})
+}
@@ -0,0 +1,240 @@
+package api
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"backend_axenta/middleware"
+	"backend_axenta/models"
+	"backend_axenta/services"
+)
+
+// newServiceRequestService создает сервис заявок с часовым поясом текущей компании
+func (api *InstallationAPI) newServiceRequestService(c *gin.Context) *services.ServiceRequestService {
+	requestService := services.NewServiceRequestService(api.DB)
+	if company := middleware.GetCurrentCompany(c); company != nil {
+		requestService.Timezone = company.Timezone
+	}
+	return requestService
+}
+
+// parseServiceRequestID разбирает ID заявки из пути запроса
+func parseServiceRequestID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный ID заявки"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// notifyRequester ставит в очередь уведомление заявителя о новых событиях заявки:
+// вебхук service_request.updated и комментарии в ленту сделки для заявок из Битрикс24.
+// Доставка выполняется в фоне и не задерживает ответ.
+func (api *InstallationAPI) notifyRequester(c *gin.Context, request *models.ServiceRequest) {
+	if request == nil {
+		return
+	}
+	services.NotifyServiceRequest(GetCompanyID(c), request.ID)
+}
+
+// syncServiceRequest обновляет статус заявки, по которой создан монтаж, и уведомляет заявителя
+func (api *InstallationAPI) syncServiceRequest(c *gin.Context, installation *models.Installation) {
+	if installation.ServiceRequestID == nil {
+		return
+	}
+	request, err := api.newServiceRequestService(c).SyncFromInstallation(installation.ID)
+	if err != nil {
+		log.Printf("Ошибка обновления заявки по монтажу %d: %v", installation.ID, err)
+		return
+	}
+	api.notifyRequester(c, request)
+}
+
+// GetServiceRequests возвращает заявки; фильтры status, priority, source, object_id, contract_id,
+// assigned_user_id, overdue=true, search, пагинация page и limit
+func (api *InstallationAPI) GetServiceRequests(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	filter := services.ServiceRequestFilter{
+		Status:         c.Query("status"),
+		Priority:       c.Query("priority"),
+		Source:         c.Query("source"),
+		ObjectID:       queryUintPointer(c, "object_id"),
+		ContractID:     queryUintPointer(c, "contract_id"),
+		AssignedUserID: queryUintPointer(c, "assigned_user_id"),
+		Overdue:        c.Query("overdue") == "true",
+		Search:         c.Query("search"),
+		Limit:          limit,
+		Offset:         (page - 1) * limit,
+	}
+
+	requests, total, err := api.newServiceRequestService(c).GetRequests(filter, time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": requests,
+		"pagination": gin.H{
+			"page":  page,
+			"limit": limit,
+			"total": total,
+		},
+	})
+}
+
+// GetServiceRequest возвращает заявку с монтажами и историей
+func (api *InstallationAPI) GetServiceRequest(c *gin.Context) {
+	id, ok := parseServiceRequestID(c)
+	if !ok {
+		return
+	}
+
+	request, err := api.newServiceRequestService(c).GetRequest(id)
+	if err != nil {
+		respondWarehouseError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": request})
+}
+
+// CreateServiceRequest регистрирует заявку из телефонного звонка, письма, Битрикс24 или клиентского портала.
+// Повторная отправка заявки с тем же источником и external_id возвращает существующую заявку.
+func (api *InstallationAPI) CreateServiceRequest(c *gin.Context) {
+	var req services.ServiceRequestCreate
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректные данные: " + err.Error()})
+		return
+	}
+	req.UserID = equipmentUserID(c)
+
+	request, created, err := api.newServiceRequestService(c).CreateRequest(req)
+	if err != nil {
+		respondWarehouseError(c, err)
+		return
+	}
+	if !created {
+		c.JSON(http.StatusOK, gin.H{"message": "Заявка уже зарегистрирована", "data": request})
+		return
+	}
+
+	services.PublishWebhookEvent(GetCompanyID(c), models.WebhookEventServiceRequestCreated, request)
+	api.notifyRequester(c, request)
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Заявка зарегистрирована", "data": request})
+}
+
+// TriageServiceRequest разбирает заявку: приоритет, объект, договор и ответственный
+func (api *InstallationAPI) TriageServiceRequest(c *gin.Context) {
+	id, ok := parseServiceRequestID(c)
+	if !ok {
+		return
+	}
+	var req services.ServiceRequestTriage
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректные данные: " + err.Error()})
+		return
+	}
+	req.UserID = equipmentUserID(c)
+
+	request, err := api.newServiceRequestService(c).TriageRequest(id, req)
+	if err != nil {
+		respondWarehouseError(c, err)
+		return
+	}
+	api.notifyRequester(c, request)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Заявка разобрана", "data": request})
+}
+
+// ChangeServiceRequestStatus меняет статус заявки: resolved, closed, cancelled, rejected или triaged (возврат в работу)
+func (api *InstallationAPI) ChangeServiceRequestStatus(c *gin.Context) {
+	id, ok := parseServiceRequestID(c)
+	if !ok {
+		return
+	}
+	var req struct {
+		Status  string `json:"status" binding:"required"`
+		Message string `json:"message"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректные данные: " + err.Error()})
+		return
+	}
+
+	request, err := api.newServiceRequestService(c).ChangeStatus(id, req.Status, req.Message, equipmentUserID(c))
+	if err != nil {
+		respondWarehouseError(c, err)
+		return
+	}
+	api.notifyRequester(c, request)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Статус заявки изменен", "data": request})
+}
+
+// AddServiceRequestComment добавляет комментарий к заявке; public=true - сообщить заявителю
+func (api *InstallationAPI) AddServiceRequestComment(c *gin.Context) {
+	id, ok := parseServiceRequestID(c)
+	if !ok {
+		return
+	}
+	var req struct {
+		Message string `json:"message" binding:"required"`
+		Public  bool   `json:"public"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректные данные: " + err.Error()})
+		return
+	}
+
+	requestService := api.newServiceRequestService(c)
+	event, err := requestService.AddComment(id, req.Message, req.Public, equipmentUserID(c))
+	if err != nil {
+		respondWarehouseError(c, err)
+		return
+	}
+	if req.Public {
+		if request, err := requestService.GetRequest(id); err == nil {
+			api.notifyRequester(c, request)
+		}
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Комментарий добавлен", "data": event})
+}
+
+// ConvertServiceRequest создает по заявке один или несколько монтажей
+func (api *InstallationAPI) ConvertServiceRequest(c *gin.Context) {
+	id, ok := parseServiceRequestID(c)
+	if !ok {
+		return
+	}
+	var req services.ServiceRequestConvert
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректные данные: " + err.Error()})
+		return
+	}
+	req.UserID = equipmentUserID(c)
+
+	request, err := api.newServiceRequestService(c).ConvertToInstallations(id, req)
+	if err != nil {
+		respondWarehouseError(c, err)
+		return
+	}
+	api.notifyRequester(c, request)
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Монтажи по заявке созданы", "data": request})
+}
@@ -0,0 +1,318 @@
+package api
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"backend_axenta/models"
+	"backend_axenta/services"
+)
+
+const maxOperatorChargesFileSize = 20 << 20
+
+// parseSimCardID разбирает ID SIM-карты или оператора из пути запроса
+func parseSimCardID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный ID"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// GetMobileOperators возвращает операторов связи
+func (api *WarehouseAPI) GetMobileOperators(c *gin.Context) {
+	simService := services.NewSimCardService(api.DB)
+	operators, err := simService.GetOperators(c.Query("active") == "true")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": operators})
+}
+
+// CreateMobileOperator добавляет оператора связи
+func (api *WarehouseAPI) CreateMobileOperator(c *gin.Context) {
+	var operator models.MobileOperator
+	if err := c.ShouldBindJSON(&operator); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректные данные: " + err.Error()})
+		return
+	}
+
+	simService := services.NewSimCardService(api.DB)
+	if err := simService.CreateOperator(&operator); err != nil {
+		respondWarehouseError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Оператор добавлен",
+		"data":    operator,
+	})
+}
+
+// UpdateMobileOperator обновляет настройки оператора связи
+func (api *WarehouseAPI) UpdateMobileOperator(c *gin.Context) {
+	id, ok := parseSimCardID(c)
+	if !ok {
+		return
+	}
+	var input models.MobileOperator
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректные данные: " + err.Error()})
+		return
+	}
+
+	simService := services.NewSimCardService(api.DB)
+	operator, err := simService.UpdateOperator(id, input)
+	if err != nil {
+		respondWarehouseError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Оператор обновлен",
+		"data":    operator,
+	})
+}
+
+// GetSimCards возвращает SIM-карты с фильтрацией и пагинацией
+func (api *WarehouseAPI) GetSimCards(c *gin.Context) {
+	filter := services.SimCardFilter{
+		OperatorID:  queryUintPointer(c, "operator_id"),
+		WarehouseID: queryUintPointer(c, "warehouse_id"),
+		Status:      c.Query("status"),
+		Search:      c.Query("search"),
+		Unpaired:    c.Query("unpaired") == "true",
+	}
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 {
+		limit = 20
+	}
+
+	simService := services.NewSimCardService(api.DB)
+	cards, total, err := simService.GetSimCards(filter, limit, (page-1)*limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": cards,
+		"pagination": gin.H{
+			"page":  page,
+			"limit": limit,
+			"total": total,
+			"pages": (total + int64(limit) - 1) / int64(limit),
+		},
+	})
+}
+
+// CreateSimCards оприходует партию SIM-карт на склад
+func (api *WarehouseAPI) CreateSimCards(c *gin.Context) {
+	var req services.SimCardBatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректные данные: " + err.Error()})
+		return
+	}
+
+	simService := services.NewSimCardService(api.DB)
+	cards, err := simService.CreateSimCards(req)
+	if err != nil {
+		respondWarehouseError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "SIM-карты оприходованы",
+		"data":    cards,
+	})
+}
+
+// GetSimCard возвращает SIM-карту
+func (api *WarehouseAPI) GetSimCard(c *gin.Context) {
+	id, ok := parseSimCardID(c)
+	if !ok {
+		return
+	}
+
+	simService := services.NewSimCardService(api.DB)
+	card, err := simService.GetSimCard(id)
+	if err != nil {
+		respondWarehouseError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": card})
+}
+
+// PairSimCard устанавливает SIM-карту в трекер
+func (api *WarehouseAPI) PairSimCard(c *gin.Context) {
+	id, ok := parseSimCardID(c)
+	if !ok {
+		return
+	}
+	var req struct {
+		EquipmentID uint `json:"equipment_id" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректные данные: " + err.Error()})
+		return
+	}
+
+	simService := services.NewSimCardService(api.DB)
+	card, err := simService.PairSimCard(id, req.EquipmentID, equipmentUserID(c))
+	if err != nil {
+		respondWarehouseError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "SIM-карта установлена в трекер",
+		"data":    card,
+	})
+}
+
+// UnpairSimCard извлекает SIM-карту из трекера
+func (api *WarehouseAPI) UnpairSimCard(c *gin.Context) {
+	id, ok := parseSimCardID(c)
+	if !ok {
+		return
+	}
+	var req struct {
+		WarehouseID *uint `json:"warehouse_id"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil && err != io.EOF {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректные данные: " + err.Error()})
+		return
+	}
+
+	simService := services.NewSimCardService(api.DB)
+	card, err := simService.UnpairSimCard(id, req.WarehouseID, equipmentUserID(c))
+	if err != nil {
+		respondWarehouseError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "SIM-карта извлечена из трекера",
+		"data":    card,
+	})
+}
+
+// SetSimCardStatus приостанавливает, возобновляет или расторгает SIM-карту
+func (api *WarehouseAPI) SetSimCardStatus(c *gin.Context) {
+	id, ok := parseSimCardID(c)
+	if !ok {
+		return
+	}
+	var req struct {
+		Status string `json:"status" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректные данные: " + err.Error()})
+		return
+	}
+
+	simService := services.NewSimCardService(api.DB)
+	card, err := simService.SetSimCardStatus(id, req.Status)
+	if err != nil {
+		respondWarehouseError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Статус SIM-карты изменен",
+		"data":    card,
+	})
+}
+
+// GetIdleSimCards возвращает оплачиваемые SIM-карты неактивных и удаленных объектов
+func (api *WarehouseAPI) GetIdleSimCards(c *gin.Context) {
+	simService := services.NewSimCardService(api.DB)
+	idle, err := simService.GetIdleSimCards()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": idle})
+}
+
+// ImportOperatorCharges загружает детализацию начислений оператора за месяц и возвращает сверку
+func (api *WarehouseAPI) ImportOperatorCharges(c *gin.Context) {
+	operatorID, err := strconv.ParseUint(c.PostForm("operator_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Не указан оператор"})
+		return
+	}
+	period, err := services.ParseSimChargePeriod(c.PostForm("period"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Не передан файл детализации"})
+		return
+	}
+	if fileHeader.Size > maxOperatorChargesFileSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Файл детализации слишком большой"})
+		return
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Ошибка чтения файла"})
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Ошибка чтения файла"})
+		return
+	}
+
+	simService := services.NewSimCardService(api.DB)
+	reconciliation, err := simService.ImportOperatorCharges(uint(operatorID), period, fileHeader.Filename, data, equipmentUserID(c))
+	if err != nil {
+		respondWarehouseError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Детализация загружена",
+		"data":    reconciliation,
+	})
+}
+
+// GetSimReconciliation возвращает сверку начислений оператора за месяц
+func (api *WarehouseAPI) GetSimReconciliation(c *gin.Context) {
+	operatorID := queryUintPointer(c, "operator_id")
+	if operatorID == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Не указан оператор"})
+		return
+	}
+	period, err := services.ParseSimChargePeriod(c.Query("period"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	simService := services.NewSimCardService(api.DB)
+	reconciliation, err := simService.GetReconciliation(*operatorID, period)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": reconciliation})
+}
@@ -0,0 +1,200 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"backend_axenta/middleware"
+	"backend_axenta/models"
+	"backend_axenta/services"
+)
+
+// newSLAService создает сервис SLA с часовым поясом текущей компании
+func (api *InstallationAPI) newSLAService(c *gin.Context) *services.SLAService {
+	slaService := services.NewSLAService(api.DB, services.NewNotificationService(api.DB, nil))
+	if company := middleware.GetCurrentCompany(c); company != nil {
+		slaService.Timezone = company.Timezone
+	}
+	return slaService
+}
+
+// parseSLAPolicyID разбирает ID политики SLA из пути запроса
+func parseSLAPolicyID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный ID политики SLA"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// GetSLAPolicies возвращает политики SLA; фильтр contract_id
+func (api *InstallationAPI) GetSLAPolicies(c *gin.Context) {
+	policies, err := api.newSLAService(c).GetPolicies(queryUintPointer(c, "contract_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при получении политик SLA"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": policies})
+}
+
+// GetSLAPolicy возвращает политику SLA
+func (api *InstallationAPI) GetSLAPolicy(c *gin.Context) {
+	id, ok := parseSLAPolicyID(c)
+	if !ok {
+		return
+	}
+
+	policy, err := api.newSLAService(c).GetPolicy(id)
+	if err != nil {
+		respondWarehouseError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": policy})
+}
+
+// CreateSLAPolicy создает политику SLA для договора и/или приоритета
+func (api *InstallationAPI) CreateSLAPolicy(c *gin.Context) {
+	var req services.SLAPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректные данные: " + err.Error()})
+		return
+	}
+
+	policy, err := api.newSLAService(c).CreatePolicy(req)
+	if err != nil {
+		respondWarehouseError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Политика SLA создана", "data": policy})
+}
+
+// UpdateSLAPolicy изменяет политику SLA
+func (api *InstallationAPI) UpdateSLAPolicy(c *gin.Context) {
+	id, ok := parseSLAPolicyID(c)
+	if !ok {
+		return
+	}
+	var req services.SLAPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректные данные: " + err.Error()})
+		return
+	}
+
+	policy, err := api.newSLAService(c).UpdatePolicy(id, req)
+	if err != nil {
+		respondWarehouseError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Политика SLA обновлена", "data": policy})
+}
+
+// DeleteSLAPolicy удаляет политику SLA
+func (api *InstallationAPI) DeleteSLAPolicy(c *gin.Context) {
+	id, ok := parseSLAPolicyID(c)
+	if !ok {
+		return
+	}
+
+	if err := api.newSLAService(c).DeletePolicy(id); err != nil {
+		respondWarehouseError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Политика SLA удалена"})
+}
+
+// GetInstallationSLA возвращает сроки SLA монтажа
+func (api *InstallationAPI) GetInstallationSLA(c *gin.Context) {
+	id, ok := fieldInstallationID(c)
+	if !ok {
+		return
+	}
+
+	sla, err := api.newSLAService(c).GetInstallationSLA(id, time.Now())
+	if err != nil {
+		respondWarehouseError(c, err)
+		return
+	}
+	if sla == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "К монтажу не применяется ни одна политика SLA"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": sla})
+}
+
+// GetSLAAtRisk возвращает незавершенные монтажи с нарушенными или находящимися под угрозой сроками SLA
+func (api *InstallationAPI) GetSLAAtRisk(c *gin.Context) {
+	installations, err := api.newSLAService(c).GetAtRisk(time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": installations})
+}
+
+// CheckSLAEscalations проверяет сроки SLA и эскалирует новые нарушения: уведомления контактам
+// политики и вебхуки installation.sla_at_risk / installation.sla_breached
+func (api *InstallationAPI) CheckSLAEscalations(c *gin.Context) {
+	escalations, err := api.newSLAService(c).CheckEscalations(time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	companyID := GetCompanyID(c)
+	for _, escalation := range escalations {
+		eventType := models.WebhookEventInstallationSLAAtRisk
+		if escalation.State == models.SLAStateBreached {
+			eventType = models.WebhookEventInstallationSLABreach
+		}
+		services.PublishWebhookEvent(companyID, eventType, escalation)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Проверка сроков SLA завершена", "data": escalations})
+}
+
+// GetSLACompliance возвращает соблюдение SLA по клиентам и монтажникам за период
+// (date_from, date_to в формате YYYY-MM-DD, по умолчанию - текущий месяц)
+func (api *InstallationAPI) GetSLACompliance(c *gin.Context) {
+	slaService := api.newSLAService(c)
+	tz := models.LoadTimezone(slaService.Timezone)
+
+	now := time.Now()
+	local := now.In(tz)
+	from := time.Date(local.Year(), local.Month(), 1, 0, 0, 0, 0, tz)
+	to := from.AddDate(0, 1, 0)
+	if value := c.Query("date_from"); value != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", value, tz)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный формат date_from, ожидается YYYY-MM-DD"})
+			return
+		}
+		from = parsed
+	}
+	if value := c.Query("date_to"); value != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", value, tz)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный формат date_to, ожидается YYYY-MM-DD"})
+			return
+		}
+		to = parsed.AddDate(0, 0, 1)
+	}
+
+	report, err := slaService.GetComplianceReport(from, to, now)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": report})
+}
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 	"time"
@@ -9,6 +10,7 @@ import (
 	"gorm.io/gorm"
 
 	"backend_axenta/models"
+	"backend_axenta/services"
 )
 
 // WarehouseAPI представляет API для работы со складом
@@ -249,6 +251,10 @@ func (api *WarehouseAPI) CreateStockAlert(c *gin.Context) {
 	// Загружаем связанные данные для ответа
 	api.DB.Preload("Equipment").Preload("EquipmentCategory").Preload("AssignedUser").First(&alert, alert.ID)
 
+	if alert.Type == "low_stock" {
+		services.PublishWebhookEvent(GetCompanyID(c), models.WebhookEventStockLow, alert)
+	}
+
 	c.JSON(http.StatusCreated, gin.H{
 		"message": "Уведомление успешно создано",
 		"data":    alert,
@@ -397,54 +403,37 @@ func (api *WarehouseAPI) GetWarehouseStatistics(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"data": stats})
 }
 
-// TransferEquipment переносит оборудование между локациями
+// TransferEquipment перемещает оборудование между складами и местами хранения
 func (api *WarehouseAPI) TransferEquipment(c *gin.Context) {
-	var transferData struct {
-		EquipmentID  uint   `json:"equipment_id" binding:"required"`
-		FromLocation string `json:"from_location"`
-		ToLocation   string `json:"to_location" binding:"required"`
-		Notes        string `json:"notes"`
-		UserID       uint   `json:"user_id" binding:"required"`
-	}
-
-	if err := c.ShouldBindJSON(&transferData); err != nil {
+	var req services.StockTransferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректные данные: " + err.Error()})
 		return
 	}
 
-	// Проверяем существование оборудования
-	var equipment models.Equipment
-	if err := api.DB.First(&equipment, transferData.EquipmentID).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Оборудование не найдено"})
-		return
-	}
-
-	// Создаем операцию перемещения
-	operation := models.WarehouseOperation{
-		Type:         "transfer",
-		Description:  "Перемещение оборудования",
-		EquipmentID:  transferData.EquipmentID,
-		FromLocation: transferData.FromLocation,
-		ToLocation:   transferData.ToLocation,
-		UserID:       transferData.UserID,
-		Notes:        transferData.Notes,
-		Status:       "completed",
-	}
-
-	if err := api.DB.Create(&operation).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при создании операции: " + err.Error()})
-		return
+	// Если исполнитель не указан, операция записывается на текущего пользователя
+	if req.UserID == 0 {
+		if userID, exists := c.Get("user_id"); exists {
+			if uid, ok := userID.(uint); ok {
+				req.UserID = uid
+			}
+		}
 	}
 
-	// Обновляем местоположение оборудования
-	equipment.WarehouseLocation = transferData.ToLocation
-	if err := api.DB.Save(&equipment).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при обновлении оборудования"})
+	warehouseService := services.NewWarehouseService(api.DB, nil)
+	warehouseService.CompanyID = GetCompanyID(c)
+	operation, err := warehouseService.TransferEquipment(req)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, services.ErrEquipmentConflict) {
+			status = http.StatusConflict
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
 		return
 	}
 
 	// Загружаем связанные данные для ответа
-	api.DB.Preload("Equipment").Preload("User").First(&operation, operation.ID)
+	api.DB.Preload("Equipment").Preload("User").First(operation, operation.ID)
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Оборудование успешно перемещено",
@@ -0,0 +1,189 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"backend_axenta/models"
+	"backend_axenta/services"
+)
+
+// GetWarehouses возвращает список складов
+func (api *WarehouseAPI) GetWarehouses(c *gin.Context) {
+	warehouseService := services.NewWarehouseService(api.DB, nil)
+	warehouseService.CompanyID = GetCompanyID(c)
+	warehouses, err := warehouseService.GetWarehouses(c.Query("type"), c.Query("active") == "true")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": warehouses})
+}
+
+// CreateWarehouse создает склад
+func (api *WarehouseAPI) CreateWarehouse(c *gin.Context) {
+	var warehouse models.Warehouse
+	if err := c.ShouldBindJSON(&warehouse); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректные данные: " + err.Error()})
+		return
+	}
+	warehouse.IsActive = true
+
+	warehouseService := services.NewWarehouseService(api.DB, nil)
+	warehouseService.CompanyID = GetCompanyID(c)
+	if err := warehouseService.CreateWarehouse(&warehouse); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Склад успешно создан",
+		"data":    warehouse,
+	})
+}
+
+// UpdateWarehouse обновляет склад
+func (api *WarehouseAPI) UpdateWarehouse(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный ID склада"})
+		return
+	}
+
+	var warehouse models.Warehouse
+	if err := api.DB.First(&warehouse, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Склад не найден"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при поиске склада"})
+		}
+		return
+	}
+
+	// Поля, не переданные в запросе, сохраняют текущие значения
+	if err := c.ShouldBindJSON(&warehouse); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректные данные: " + err.Error()})
+		return
+	}
+
+	warehouseService := services.NewWarehouseService(api.DB, nil)
+	warehouseService.CompanyID = GetCompanyID(c)
+	updated, err := warehouseService.UpdateWarehouse(uint(id), warehouse)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Склад успешно обновлен",
+		"data":    updated,
+	})
+}
+
+// GetStorageBins возвращает дерево мест хранения склада
+func (api *WarehouseAPI) GetStorageBins(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный ID склада"})
+		return
+	}
+
+	warehouseService := services.NewWarehouseService(api.DB, nil)
+	warehouseService.CompanyID = GetCompanyID(c)
+	bins, err := warehouseService.GetStorageBins(uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": bins})
+}
+
+// CreateStorageBin создает зону, стеллаж или ячейку на складе
+func (api *WarehouseAPI) CreateStorageBin(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный ID склада"})
+		return
+	}
+
+	var bin models.StorageBin
+	if err := c.ShouldBindJSON(&bin); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Некорректные данные: " + err.Error()})
+		return
+	}
+	bin.WarehouseID = uint(id)
+	bin.IsActive = true
+
+	warehouseService := services.NewWarehouseService(api.DB, nil)
+	warehouseService.CompanyID = GetCompanyID(c)
+	if err := warehouseService.CreateStorageBin(&bin); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Место хранения успешно создано",
+		"data":    bin,
+	})
+}
+
+// GetStockOnHand возвращает остатки оборудования по складам и категориям
+func (api *WarehouseAPI) GetStockOnHand(c *gin.Context) {
+	filter := services.StockOnHandFilter{Type: c.Query("type")}
+	if warehouseID, err := strconv.ParseUint(c.Query("warehouse_id"), 10, 32); err == nil {
+		id := uint(warehouseID)
+		filter.WarehouseID = &id
+	}
+	if categoryID, err := strconv.ParseUint(c.Query("category_id"), 10, 32); err == nil {
+		id := uint(categoryID)
+		filter.CategoryID = &id
+	}
+
+	warehouseService := services.NewWarehouseService(api.DB, nil)
+	warehouseService.CompanyID = GetCompanyID(c)
+	rows, err := warehouseService.GetStockOnHand(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": rows})
+}
+
+// GetInstallerStock возвращает мобильный склад монтажника и его остатки
+func (api *WarehouseAPI) GetInstallerStock(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Неверный ID монтажника"})
+		return
+	}
+
+	warehouseService := services.NewWarehouseService(api.DB, nil)
+	warehouseService.CompanyID = GetCompanyID(c)
+	warehouse, err := warehouseService.GetInstallerWarehouse(uint(id))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var equipment []models.Equipment
+	if err := api.DB.Preload("Category").
+		Where("warehouse_id = ? AND status IN ?", warehouse.ID, []string{"in_stock", "reserved"}).
+		Order("type, model").
+		Find(&equipment).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Ошибка при получении оборудования"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": gin.H{
+			"warehouse": warehouse,
+			"equipment": equipment,
+		},
+	})
+}
@@ -0,0 +1,327 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"backend_axenta/models"
+	"backend_axenta/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WebhooksAPI API управления подписками на исходящие вебхуки
+type WebhooksAPI struct {
+	webhookService *services.WebhookService
+}
+
+// NewWebhooksAPI создает новый API вебхуков
+func NewWebhooksAPI(webhookService *services.WebhookService) *WebhooksAPI {
+	return &WebhooksAPI{webhookService: webhookService}
+}
+
+// RegisterRoutes регистрирует маршруты API вебхуков
+func (api *WebhooksAPI) RegisterRoutes(r *gin.RouterGroup) {
+	webhooks := r.Group("/webhooks")
+	{
+		webhooks.GET("/events", api.GetEventTypes)
+		webhooks.GET("", api.GetSubscriptions)
+		webhooks.POST("", api.CreateSubscription)
+		webhooks.GET("/:id", api.GetSubscription)
+		webhooks.PUT("/:id", api.UpdateSubscription)
+		webhooks.DELETE("/:id", api.DeleteSubscription)
+		webhooks.POST("/:id/rotate-secret", api.RotateSecret)
+		webhooks.POST("/:id/test", api.SendTestEvent)
+		webhooks.GET("/:id/deliveries", api.GetDeliveries)
+		webhooks.POST("/:id/deliveries/:delivery_id/redeliver", api.Redeliver)
+	}
+}
+
+// webhookSubscriptionRequest данные для создания и изменения подписки
+type webhookSubscriptionRequest struct {
+	Name           string   `json:"name"`
+	URL            string   `json:"url"`
+	EventTypes     []string `json:"event_types"`
+	Description    string   `json:"description"`
+	IsActive       *bool    `json:"is_active"`
+	MaxAttempts    int      `json:"max_attempts"`
+	TimeoutSeconds int      `json:"timeout_seconds"`
+}
+
+// apply переносит данные запроса в подписку
+func (req *webhookSubscriptionRequest) apply(subscription *models.WebhookSubscription) {
+	if req.Name != "" {
+		subscription.Name = req.Name
+	}
+	if req.URL != "" {
+		subscription.URL = req.URL
+	}
+	if req.EventTypes != nil {
+		subscription.SetEvents(req.EventTypes)
+	}
+	if req.Description != "" {
+		subscription.Description = req.Description
+	}
+	if req.IsActive != nil {
+		subscription.IsActive = *req.IsActive
+	}
+	if req.MaxAttempts > 0 {
+		subscription.MaxAttempts = req.MaxAttempts
+	}
+	if req.TimeoutSeconds > 0 {
+		subscription.TimeoutSeconds = req.TimeoutSeconds
+	}
+}
+
+// GetEventTypes возвращает список событий, на которые можно подписаться
+func (api *WebhooksAPI) GetEventTypes(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   models.WebhookEventTypes,
+	})
+}
+
+// GetSubscriptions возвращает подписки компании
+func (api *WebhooksAPI) GetSubscriptions(c *gin.Context) {
+	subscriptions, err := api.webhookService.GetSubscriptions(GetCompanyID(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status": "error",
+			"error":  err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   subscriptions,
+	})
+}
+
+// GetSubscription возвращает подписку по ID
+func (api *WebhooksAPI) GetSubscription(c *gin.Context) {
+	subscriptionID, ok := parseWebhookID(c, "id")
+	if !ok {
+		return
+	}
+
+	subscription, err := api.webhookService.GetSubscription(GetCompanyID(c), subscriptionID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status": "error",
+			"error":  "Подписка не найдена",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   subscription,
+	})
+}
+
+// CreateSubscription создает подписку. Ключ подписи возвращается только при создании.
+func (api *WebhooksAPI) CreateSubscription(c *gin.Context) {
+	var req webhookSubscriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status": "error",
+			"error":  "Неверный формат данных",
+		})
+		return
+	}
+
+	subscription := &models.WebhookSubscription{
+		CompanyID: GetCompanyID(c),
+		IsActive:  true,
+	}
+	req.apply(subscription)
+
+	if err := api.webhookService.CreateSubscription(subscription); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status": "error",
+			"error":  err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"status": "success",
+		"data":   subscription,
+		"secret": subscription.Secret,
+	})
+}
+
+// UpdateSubscription изменяет подписку
+func (api *WebhooksAPI) UpdateSubscription(c *gin.Context) {
+	subscriptionID, ok := parseWebhookID(c, "id")
+	if !ok {
+		return
+	}
+
+	subscription, err := api.webhookService.GetSubscription(GetCompanyID(c), subscriptionID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status": "error",
+			"error":  "Подписка не найдена",
+		})
+		return
+	}
+
+	var req webhookSubscriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status": "error",
+			"error":  "Неверный формат данных",
+		})
+		return
+	}
+	req.apply(subscription)
+
+	if err := api.webhookService.UpdateSubscription(subscription); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status": "error",
+			"error":  err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   subscription,
+	})
+}
+
+// DeleteSubscription удаляет подписку
+func (api *WebhooksAPI) DeleteSubscription(c *gin.Context) {
+	subscriptionID, ok := parseWebhookID(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := api.webhookService.DeleteSubscription(GetCompanyID(c), subscriptionID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status": "error",
+			"error":  err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":  "success",
+		"message": "Подписка удалена",
+	})
+}
+
+// RotateSecret генерирует новый ключ подписи
+func (api *WebhooksAPI) RotateSecret(c *gin.Context) {
+	subscriptionID, ok := parseWebhookID(c, "id")
+	if !ok {
+		return
+	}
+
+	subscription, err := api.webhookService.RotateSecret(GetCompanyID(c), subscriptionID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status": "error",
+			"error":  err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   subscription,
+		"secret": subscription.Secret,
+	})
+}
+
+// SendTestEvent отправляет тестовое событие и возвращает результат доставки
+func (api *WebhooksAPI) SendTestEvent(c *gin.Context) {
+	subscriptionID, ok := parseWebhookID(c, "id")
+	if !ok {
+		return
+	}
+
+	delivery, err := api.webhookService.SendTestEvent(GetCompanyID(c), subscriptionID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status": "error",
+			"error":  err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   delivery,
+	})
+}
+
+// GetDeliveries возвращает журнал доставки событий подписки
+func (api *WebhooksAPI) GetDeliveries(c *gin.Context) {
+	subscriptionID, ok := parseWebhookID(c, "id")
+	if !ok {
+		return
+	}
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+
+	deliveries, total, err := api.webhookService.GetDeliveries(GetCompanyID(c), subscriptionID, c.Query("status"), limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"status": "error",
+			"error":  err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data": gin.H{
+			"items":  deliveries,
+			"total":  total,
+			"limit":  limit,
+			"offset": offset,
+		},
+	})
+}
+
+// Redeliver повторно отправляет событие из журнала доставки
+func (api *WebhooksAPI) Redeliver(c *gin.Context) {
+	subscriptionID, ok := parseWebhookID(c, "id")
+	if !ok {
+		return
+	}
+	deliveryID, ok := parseWebhookID(c, "delivery_id")
+	if !ok {
+		return
+	}
+
+	delivery, err := api.webhookService.Redeliver(GetCompanyID(c), subscriptionID, deliveryID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status": "error",
+			"error":  err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   delivery,
+	})
+}
+
+// parseWebhookID разбирает числовой параметр маршрута
+func parseWebhookID(c *gin.Context, param string) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param(param), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"status": "error",
+			"error":  "Неверный формат ID",
+		})
+		return 0, false
+	}
+	return uint(id), true
+}
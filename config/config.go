@@ -39,6 +39,9 @@ type Config struct {
 
 	// Внешние сервисы
 	External ExternalConfig `json:"external"`
+
+	// Прием телеметрии трекеров
+	Telemetry TelemetryConfig `json:"telemetry"`
 }
 
 type AppConfigStruct struct {
@@ -128,6 +131,12 @@ type ExternalConfig struct {
 	GoogleMapsAPIKey string `json:"google_maps_api_key"`
 }
 
+type TelemetryConfig struct {
+	WialonIPSPort string        `json:"wialon_ips_port"` // Пусто - сервер Wialon IPS не запускается
+	EGTSPort      string        `json:"egts_port"`       // Пусто - сервер EGTS не запускается
+	IdleTimeout   time.Duration `json:"idle_timeout"`
+}
+
 type SMTPConfig struct {
 	Host     string `json:"host"`
 	Port     int    `json:"port"`
@@ -225,6 +234,11 @@ func LoadConfig() (*Config, error) {
 			WebhookSecret:    getEnv("WEBHOOK_SECRET", ""),
 			GoogleMapsAPIKey: getEnv("GOOGLE_MAPS_API_KEY", ""),
 		},
+		Telemetry: TelemetryConfig{
+			WialonIPSPort: getEnv("WIALON_IPS_PORT", ""),
+			EGTSPort:      getEnv("EGTS_PORT", ""),
+			IdleTimeout:   getEnvDuration("TELEMETRY_IDLE_TIMEOUT", 5*time.Minute),
+		},
 	}
 
 	// Валидация критически важных настроек
@@ -167,6 +167,8 @@ func autoMigrate() error {
 	globalModels := []interface{}{
 		&models.Company{},
 		&models.IntegrationError{},
+		&models.WebhookSubscription{},
+		&models.WebhookDelivery{},
 	}
 
 	for _, model := range globalModels {
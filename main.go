@@ -10,6 +10,7 @@ import (
 	// "backend_axenta/models" // Не используется в main.go, миграции в database.go
 	"log"
 	"net/http"
+	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -152,6 +153,7 @@ func main() {
 	apiGroup.POST("/objects", api.CreateObject)
 	apiGroup.PUT("/objects/:id", api.UpdateObject)
 	apiGroup.DELETE("/objects/:id", api.DeleteObject)
+	apiGroup.GET("/objects/:id/telemetry", api.GetObjectTelemetry)
 
 	// Плановое удаление объектов
 	apiGroup.PUT("/objects/:id/schedule-delete", api.ScheduleObjectDelete)
@@ -162,6 +164,15 @@ func main() {
 	apiGroup.PUT("/objects/:id/restore", api.RestoreObject)
 	apiGroup.DELETE("/objects/:id/permanent", api.PermanentDeleteObject)
 
+	// Массовый импорт и выгрузка объектов
+	apiGroup.POST("/objects-import", api.ImportObjects)
+	apiGroup.GET("/objects-export", api.ExportObjects)
+
+	// Массовые операции над объектами
+	apiGroup.POST("/objects-bulk", api.CreateObjectBulkJob)
+	apiGroup.GET("/objects-bulk", api.GetObjectBulkJobs)
+	apiGroup.GET("/objects-bulk/:id", api.GetObjectBulkJob)
+
 	// Шаблоны объектов
 	apiGroup.GET("/object-templates", api.GetObjectTemplates)
 	apiGroup.GET("/object-templates/:id", api.GetObjectTemplate)
@@ -196,6 +207,13 @@ func main() {
 	apiGroup.PUT("/user-templates/:id", api.UpdateUserTemplate)
 	apiGroup.DELETE("/user-templates/:id", api.DeleteUserTemplate)
 
+	// Справочник клиентов
+	apiGroup.GET("/clients", api.GetClients)
+	apiGroup.GET("/clients/:id", api.GetClient)
+	apiGroup.POST("/clients", api.CreateClient)
+	apiGroup.PUT("/clients/:id", api.UpdateClient)
+	apiGroup.DELETE("/clients/:id", api.DeleteClient)
+
 	// Договоры
 	apiGroup.GET("/contracts", api.GetContracts)
 	apiGroup.GET("/contracts/:id", api.GetContract)
@@ -237,6 +255,14 @@ func main() {
 	apiGroup.POST("/billing/invoices/:id/payment", api.ProcessPayment)
 	apiGroup.POST("/billing/invoices/:id/cancel", api.CancelInvoice)
 
+	// Банковские выписки и сопоставление платежей
+	apiGroup.POST("/billing/bank-statements/import", api.ImportBankStatement)
+	apiGroup.GET("/billing/bank-statements", api.GetBankStatements)
+	apiGroup.GET("/billing/bank-statements/:id", api.GetBankStatement)
+	apiGroup.GET("/billing/bank-payments/review", api.GetBankPaymentsReview)
+	apiGroup.POST("/billing/bank-payments/:id/match", api.MatchBankPayment)
+	apiGroup.POST("/billing/bank-payments/:id/ignore", api.IgnoreBankPayment)
+
 	// История и отчеты
 	apiGroup.GET("/billing/history", api.GetBillingHistory)
 	apiGroup.GET("/billing/invoices/overdue", api.GetOverdueInvoices)
@@ -260,6 +286,11 @@ func main() {
 	// apiGroup.POST("/integration/credentials", api.SetupCompanyCredentials)
 	// apiGroup.DELETE("/integration/cache", api.ClearIntegrationCache)
 
+	// OAuth приложение Битрикс24
+	bitrix24OAuthAPI := api.NewBitrix24OAuthAPI(database.DB)
+	bitrix24OAuthAPI.RegisterPublicRoutes(r)
+	bitrix24OAuthAPI.RegisterRoutes(apiGroup)
+
 	// Интеграция с Битрикс24 - временно отключено
 	// apiGroup.POST("/integration/bitrix24/setup", api.SetupBitrix24Integration)
 	// apiGroup.GET("/integration/bitrix24/health", api.CheckBitrix24Health)
@@ -323,6 +354,78 @@ func main() {
 		})
 	})
 
+	// Монтажи и сервисное обслуживание
+	installationAPI := api.NewInstallationAPI(database.DB)
+	{
+		// Автоматическое распределение монтажей и свободные слоты монтажников
+		apiGroup.POST("/installations/dispatch", installationAPI.ProposeInstallationDispatch)
+		apiGroup.POST("/installations/dispatch/apply", installationAPI.ApplyInstallationDispatch)
+		apiGroup.GET("/installers/:id/free-slots", installationAPI.GetInstallerFreeSlots)
+
+		// Полевое приложение монтажника: чек-листы, фотографии, подпись клиента, офлайн-синхронизация
+		apiGroup.GET("/installation-checklists", installationAPI.GetChecklistTemplates)
+		apiGroup.POST("/installation-checklists", installationAPI.CreateChecklistTemplate)
+		apiGroup.PUT("/installation-checklists/:id", installationAPI.UpdateChecklistTemplate)
+		apiGroup.GET("/installations/:id/field", installationAPI.GetFieldInstallation)
+		apiGroup.POST("/installations/:id/field/start", installationAPI.StartFieldInstallation)
+		apiGroup.POST("/installations/:id/field/complete", installationAPI.CompleteFieldInstallation)
+		apiGroup.PUT("/installations/:id/checklist/:item_id", installationAPI.UpdateChecklistItem)
+		apiGroup.POST("/installations/:id/photos", installationAPI.UploadInstallationPhoto)
+		apiGroup.GET("/installations/:id/photos/:photo_id", installationAPI.GetInstallationPhoto)
+		apiGroup.POST("/installations/:id/signature", installationAPI.SaveInstallationSignature)
+		apiGroup.POST("/installations/:id/equipment/scan", installationAPI.ScanInstallationEquipment)
+		apiGroup.POST("/installations/sync", installationAPI.SyncFieldEvents)
+		apiGroup.GET("/installations/:id/act", installationAPI.GetInstallationAct)
+		apiGroup.POST("/installations/:id/act", installationAPI.RegenerateInstallationAct)
+
+		// Вознаграждение монтажников: ставки, удержания, расчетные листы
+		apiGroup.GET("/payroll/rates", installationAPI.GetPayrollRates)
+		apiGroup.POST("/payroll/rates", installationAPI.CreatePayrollRate)
+		apiGroup.PUT("/payroll/rates/:id", installationAPI.UpdatePayrollRate)
+		apiGroup.DELETE("/payroll/rates/:id", installationAPI.DeletePayrollRate)
+		apiGroup.GET("/payroll/penalty-rules", installationAPI.GetPayrollPenaltyRules)
+		apiGroup.POST("/payroll/penalty-rules", installationAPI.CreatePayrollPenaltyRule)
+		apiGroup.PUT("/payroll/penalty-rules/:id", installationAPI.UpdatePayrollPenaltyRule)
+		apiGroup.DELETE("/payroll/penalty-rules/:id", installationAPI.DeletePayrollPenaltyRule)
+		apiGroup.POST("/payroll/statements/generate", installationAPI.GeneratePayrollStatements)
+		apiGroup.GET("/payroll/statements", installationAPI.GetPayrollStatements)
+		apiGroup.GET("/payroll/statements/export", installationAPI.ExportPayrollStatements)
+		apiGroup.GET("/payroll/statements/:id", installationAPI.GetPayrollStatement)
+		apiGroup.POST("/payroll/statements/:id/approve", installationAPI.ApprovePayrollStatement)
+		apiGroup.POST("/payroll/statements/:id/reopen", installationAPI.ReopenPayrollStatement)
+		apiGroup.POST("/payroll/statements/:id/paid", installationAPI.MarkPayrollStatementPaid)
+
+		// Плановое обслуживание по графику и моточасам
+		apiGroup.GET("/maintenance/plans", installationAPI.GetMaintenancePlans)
+		apiGroup.POST("/maintenance/plans", installationAPI.CreateMaintenancePlan)
+		apiGroup.GET("/maintenance/plans/:id", installationAPI.GetMaintenancePlan)
+		apiGroup.PUT("/maintenance/plans/:id", installationAPI.UpdateMaintenancePlan)
+		apiGroup.DELETE("/maintenance/plans/:id", installationAPI.DeleteMaintenancePlan)
+		apiGroup.POST("/maintenance/generate", installationAPI.GenerateMaintenance)
+		apiGroup.GET("/maintenance/visits", installationAPI.GetMaintenanceVisits)
+		apiGroup.GET("/maintenance/compliance", installationAPI.GetMaintenanceCompliance)
+
+		// Заявки клиентов на обслуживание: прием, разбор, монтажи по заявке
+		apiGroup.GET("/service-requests", installationAPI.GetServiceRequests)
+		apiGroup.POST("/service-requests", installationAPI.CreateServiceRequest)
+		apiGroup.GET("/service-requests/:id", installationAPI.GetServiceRequest)
+		apiGroup.POST("/service-requests/:id/triage", installationAPI.TriageServiceRequest)
+		apiGroup.POST("/service-requests/:id/status", installationAPI.ChangeServiceRequestStatus)
+		apiGroup.POST("/service-requests/:id/comments", installationAPI.AddServiceRequestComment)
+		apiGroup.POST("/service-requests/:id/convert", installationAPI.ConvertServiceRequest)
+
+		// SLA монтажей: политики, прогноз нарушений, эскалации, соблюдение
+		apiGroup.GET("/sla/policies", installationAPI.GetSLAPolicies)
+		apiGroup.POST("/sla/policies", installationAPI.CreateSLAPolicy)
+		apiGroup.GET("/sla/policies/:id", installationAPI.GetSLAPolicy)
+		apiGroup.PUT("/sla/policies/:id", installationAPI.UpdateSLAPolicy)
+		apiGroup.DELETE("/sla/policies/:id", installationAPI.DeleteSLAPolicy)
+		apiGroup.GET("/sla/at-risk", installationAPI.GetSLAAtRisk)
+		apiGroup.POST("/sla/check", installationAPI.CheckSLAEscalations)
+		apiGroup.GET("/sla/compliance", installationAPI.GetSLACompliance)
+		apiGroup.GET("/installations/:id/sla", installationAPI.GetInstallationSLA)
+	}
+
 	// Остальные маршруты installations временно отключены (в рамках основной apiGroup)
 	// Остальные маршруты installations временно отключены
 	/*
@@ -393,6 +496,72 @@ func main() {
 	apiGroup.GET("/warehouse/operations", warehouseAPI.GetWarehouseOperations)
 	apiGroup.POST("/warehouse/transfer", warehouseAPI.TransferEquipment)
 
+	// Склады, места хранения и остатки
+	apiGroup.GET("/warehouse/warehouses", warehouseAPI.GetWarehouses)
+	apiGroup.POST("/warehouse/warehouses", warehouseAPI.CreateWarehouse)
+	apiGroup.PUT("/warehouse/warehouses/:id", warehouseAPI.UpdateWarehouse)
+	apiGroup.GET("/warehouse/warehouses/:id/bins", warehouseAPI.GetStorageBins)
+	apiGroup.POST("/warehouse/warehouses/:id/bins", warehouseAPI.CreateStorageBin)
+	apiGroup.GET("/warehouse/stock", warehouseAPI.GetStockOnHand)
+	apiGroup.GET("/warehouse/installers/:id/stock", warehouseAPI.GetInstallerStock)
+
+	// Поступление оборудования от поставщиков
+	apiGroup.GET("/warehouse/receipts", warehouseAPI.GetGoodsReceipts)
+	apiGroup.POST("/warehouse/receipts", warehouseAPI.CreateGoodsReceipt)
+	apiGroup.POST("/warehouse/receipts/validate", warehouseAPI.ValidateGoodsReceipt)
+	apiGroup.GET("/warehouse/receipts/:id", warehouseAPI.GetGoodsReceipt)
+	apiGroup.GET("/warehouse/receipts/:id/print", warehouseAPI.PrintGoodsReceipt)
+	apiGroup.GET("/warehouse/receipts/:id/labels", warehouseAPI.PrintGoodsReceiptLabels)
+
+	// Заказы поставщикам и пополнение запасов
+	apiGroup.GET("/warehouse/purchase-orders", warehouseAPI.GetPurchaseOrders)
+	apiGroup.POST("/warehouse/purchase-orders", warehouseAPI.CreatePurchaseOrder)
+	apiGroup.GET("/warehouse/purchase-orders/:id", warehouseAPI.GetPurchaseOrder)
+	apiGroup.POST("/warehouse/purchase-orders/:id/submit", warehouseAPI.SubmitPurchaseOrder)
+	apiGroup.POST("/warehouse/purchase-orders/:id/cancel", warehouseAPI.CancelPurchaseOrder)
+	apiGroup.GET("/warehouse/replenishment", warehouseAPI.GetReplenishmentPlan)
+	apiGroup.POST("/warehouse/replenishment/orders", warehouseAPI.CreateReplenishmentOrders)
+	apiGroup.PUT("/warehouse/categories/:id/replenishment", warehouseAPI.UpdateCategoryReplenishment)
+
+	// Маркировка оборудования
+	apiGroup.GET("/warehouse/equipment/:id/code", warehouseAPI.GetEquipmentCodeImage)
+	apiGroup.POST("/warehouse/labels", warehouseAPI.PrintEquipmentLabels)
+
+	// Жизненный цикл оборудования: амортизация, обслуживание, списание, возвраты поставщику
+	apiGroup.GET("/warehouse/equipment/:id/depreciation", warehouseAPI.GetEquipmentDepreciation)
+	apiGroup.GET("/warehouse/equipment/:id/tco", warehouseAPI.GetEquipmentTCO)
+	apiGroup.POST("/warehouse/equipment/:id/maintenance", warehouseAPI.RecordEquipmentMaintenance)
+	apiGroup.POST("/warehouse/equipment/:id/dispose", warehouseAPI.DisposeEquipment)
+	apiGroup.GET("/warehouse/depreciation", warehouseAPI.GetDepreciationReport)
+	apiGroup.GET("/warehouse/rma", warehouseAPI.GetEquipmentRMAs)
+	apiGroup.POST("/warehouse/rma", warehouseAPI.CreateEquipmentRMA)
+	apiGroup.GET("/warehouse/rma/:id", warehouseAPI.GetEquipmentRMA)
+	apiGroup.POST("/warehouse/rma/:id/resolve", warehouseAPI.ResolveEquipmentRMA)
+
+	// SIM-карты и операторы связи
+	apiGroup.GET("/warehouse/operators", warehouseAPI.GetMobileOperators)
+	apiGroup.POST("/warehouse/operators", warehouseAPI.CreateMobileOperator)
+	apiGroup.PUT("/warehouse/operators/:id", warehouseAPI.UpdateMobileOperator)
+	apiGroup.GET("/warehouse/sims", warehouseAPI.GetSimCards)
+	apiGroup.POST("/warehouse/sims", warehouseAPI.CreateSimCards)
+	apiGroup.GET("/warehouse/sims/idle", warehouseAPI.GetIdleSimCards)
+	apiGroup.POST("/warehouse/sims/charges/import", warehouseAPI.ImportOperatorCharges)
+	apiGroup.GET("/warehouse/sims/charges/reconciliation", warehouseAPI.GetSimReconciliation)
+	apiGroup.GET("/warehouse/sims/:id", warehouseAPI.GetSimCard)
+	apiGroup.POST("/warehouse/sims/:id/pair", warehouseAPI.PairSimCard)
+	apiGroup.POST("/warehouse/sims/:id/unpair", warehouseAPI.UnpairSimCard)
+	apiGroup.POST("/warehouse/sims/:id/status", warehouseAPI.SetSimCardStatus)
+
+	// Инвентаризация
+	apiGroup.GET("/warehouse/inventory", warehouseAPI.GetInventoryCounts)
+	apiGroup.POST("/warehouse/inventory", warehouseAPI.StartInventoryCount)
+	apiGroup.GET("/warehouse/inventory/:id", warehouseAPI.GetInventoryCount)
+	apiGroup.POST("/warehouse/inventory/:id/scan", warehouseAPI.ScanInventoryCodes)
+	apiGroup.POST("/warehouse/inventory/:id/complete", warehouseAPI.CompleteInventoryCount)
+	apiGroup.POST("/warehouse/inventory/:id/approve", warehouseAPI.ApproveInventoryCount)
+	apiGroup.POST("/warehouse/inventory/:id/cancel", warehouseAPI.CancelInventoryCount)
+	apiGroup.GET("/warehouse/inventory/:id/report", warehouseAPI.ExportInventoryReport)
+
 	// Категории оборудования - временно отключено
 	/*
 		apiGroup.GET("/equipment/categories", warehouseAPI.GetEquipmentCategories)
@@ -414,6 +583,46 @@ func main() {
 	oneCAPI := api.NewOneCIntegrationAPI()
 	oneCAPI.RegisterRoutes(apiGroup)
 
+	// Исходящие вебхуки
+	webhookService := services.NewWebhookService(database.DB)
+	services.SetWebhookService(webhookService)
+	webhooksAPI := api.NewWebhooksAPI(webhookService)
+	webhooksAPI.RegisterRoutes(apiGroup)
+
+	// Запускаем доставку вебхуков
+	go webhookService.Start(30 * time.Second)
+
+	// Уведомления заявителей о событиях заявок доставляются в фоне
+	serviceRequestNotifier := services.NewServiceRequestNotifier(database.DB)
+	services.SetServiceRequestNotifier(serviceRequestNotifier)
+	go serviceRequestNotifier.Start()
+
+	// Периодические проверки по всем компаниям: низкие остатки (stock.low), неиспользуемые SIM-карты,
+	// плановое обслуживание и эскалации SLA монтажей
+	warehouseScheduler := services.NewWarehouseScheduler(database.DB, nil, true)
+	go warehouseScheduler.Start(time.Hour)
+
+	// Прием телеметрии трекеров по протоколам Wialon IPS и EGTS
+	telemetryService := services.NewTelemetryService(database.DB, true)
+	if cfg.Telemetry.WialonIPSPort != "" {
+		wialonServer := services.NewWialonIPSServer(telemetryService)
+		wialonServer.IdleTimeout = cfg.Telemetry.IdleTimeout
+		go func() {
+			if err := wialonServer.ListenAndServe(":" + cfg.Telemetry.WialonIPSPort); err != nil {
+				log.Printf("Failed to start Wialon IPS server: %v", err)
+			}
+		}()
+	}
+	if cfg.Telemetry.EGTSPort != "" {
+		egtsServer := services.NewEGTSServer(telemetryService)
+		egtsServer.IdleTimeout = cfg.Telemetry.IdleTimeout
+		go func() {
+			if err := egtsServer.ListenAndServe(":" + cfg.Telemetry.EGTSPort); err != nil {
+				log.Printf("Failed to start EGTS server: %v", err)
+			}
+		}()
+	}
+
 	// Система отчетности
 	reportService := services.NewReportService(database.DB)
 	reportSchedulerService := services.NewReportSchedulerService(database.DB, reportService, nil) // notificationService временно отключен
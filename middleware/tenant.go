@@ -403,21 +403,65 @@ func (tm *TenantMiddleware) runTenantMigrations(tenantDB *gorm.DB) error {
 		&models.MonitoringTemplate{},
 		&models.NotificationTemplate{},
 		&models.Object{},
+		&models.ObjectBulkJob{},
+		&models.ObjectBulkJobError{},
+		&models.TelemetryPoint{},
 
 		// Локации и монтажники
 		&models.Location{},
 		&models.Installer{},
 		&models.Installation{},
-
-		// Оборудование
+		&models.InstallationChecklistTemplate{},
+		&models.InstallationChecklistTemplateItem{},
+		&models.InstallationChecklistItem{},
+		&models.InstallationPhoto{},
+		&models.InstallationSyncEvent{},
+
+		// Оборудование и склады
+		&models.Warehouse{},
+		&models.StorageBin{},
 		&models.Equipment{},
+		&models.EquipmentCategory{},
+		&models.WarehouseOperation{},
+		&models.StockAlert{},
+		&models.GoodsReceipt{},
+		&models.GoodsReceiptItem{},
+		&models.InventoryCount{},
+		&models.InventoryCountItem{},
+		&models.EquipmentRMA{},
+		&models.MobileOperator{},
+		&models.SimCard{},
+		&models.SimOperatorCharge{},
+		&models.PurchaseOrder{},
+		&models.PurchaseOrderItem{},
+		&models.PayrollRate{},
+		&models.PayrollPenaltyRule{},
+		&models.PayrollStatement{},
+		&models.PayrollStatementLine{},
+		&models.MaintenancePlan{},
+		&models.MaintenanceVisit{},
+		&models.ServiceRequest{},
+		&models.ServiceRequestEvent{},
+		&models.SLAPolicy{},
+		&models.SLAEscalation{},
 
 		// Договоры и тарифы
 		&models.BillingPlan{},
 		&models.TariffPlan{},
+		&models.Client{},
 		&models.Contract{},
 		&models.ContractAppendix{},
 		&models.Subscription{},
+
+		// Банковские выписки
+		&models.BankStatement{},
+		&models.BankStatementPayment{},
+
+		// Синхронизация с 1С
+		&models.OneCSyncMapping{},
+
+		// Аудит действий пользователей
+		&models.AuditLog{},
 	}
 
 	for _, model := range models {
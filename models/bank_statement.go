@@ -0,0 +1,109 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// BankStatementFormat представляет формат файла банковской выписки
+type BankStatementFormat string
+
+const (
+	BankStatementFormat1C  BankStatementFormat = "1c"  // 1CClientBankExchange
+	BankStatementFormatCSV BankStatementFormat = "csv" // CSV выгрузка из интернет-банка
+)
+
+// BankPaymentStatus представляет статус сопоставления платежа из выписки
+type BankPaymentStatus string
+
+const (
+	BankPaymentStatusMatched   BankPaymentStatus = "matched"   // Платеж проведен по счету
+	BankPaymentStatusReview    BankPaymentStatus = "review"    // Требуется ручная проверка
+	BankPaymentStatusUnmatched BankPaymentStatus = "unmatched" // Подходящий счет не найден
+	BankPaymentStatusIgnored   BankPaymentStatus = "ignored"   // Платеж исключен вручную
+	BankPaymentStatusDuplicate BankPaymentStatus = "duplicate" // Платеж уже загружался ранее
+)
+
+// BankStatement представляет загруженную банковскую выписку
+type BankStatement struct {
+	ID        uint           `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+
+	CompanyID uuid.UUID `json:"company_id" gorm:"type:uuid;not null;index"`
+
+	// Информация о файле
+	FileName      string              `json:"file_name" gorm:"type:varchar(255)"`
+	Format        BankStatementFormat `json:"format" gorm:"not null;type:varchar(10)"`
+	AccountNumber string              `json:"account_number" gorm:"type:varchar(30)"` // Расчетный счет компании
+	PeriodStart   *time.Time          `json:"period_start"`
+	PeriodEnd     *time.Time          `json:"period_end"`
+
+	// Итоги сопоставления
+	TotalCount     int             `json:"total_count"`
+	MatchedCount   int             `json:"matched_count"`
+	ReviewCount    int             `json:"review_count"`
+	UnmatchedCount int             `json:"unmatched_count"`
+	DuplicateCount int             `json:"duplicate_count"`
+	TotalAmount    decimal.Decimal `json:"total_amount" gorm:"type:decimal(15,2)"`
+
+	ImportedByID *uint `json:"imported_by_id"`
+
+	Payments []BankStatementPayment `json:"payments,omitempty" gorm:"foreignKey:StatementID"`
+}
+
+// TableName задает имя таблицы для модели BankStatement
+func (BankStatement) TableName() string {
+	return "bank_statements"
+}
+
+// BankStatementPayment представляет входящий платеж из банковской выписки
+type BankStatementPayment struct {
+	ID        uint           `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+
+	CompanyID   uuid.UUID `json:"company_id" gorm:"type:uuid;not null;index;uniqueIndex:idx_bank_payments_fingerprint,priority:1"`
+	StatementID uint      `json:"statement_id" gorm:"not null;index"`
+
+	// Реквизиты платежного поручения
+	DocumentNumber string          `json:"document_number" gorm:"type:varchar(50)"`
+	DocumentDate   time.Time       `json:"document_date"`
+	Amount         decimal.Decimal `json:"amount" gorm:"type:decimal(15,2);not null"`
+	PayerName      string          `json:"payer_name" gorm:"type:varchar(300)"`
+	PayerINN       string          `json:"payer_inn" gorm:"type:varchar(20);index"`
+	PayerKPP       string          `json:"payer_kpp" gorm:"type:varchar(20)"`
+	PayerAccount   string          `json:"payer_account" gorm:"type:varchar(30)"`
+	PayerBank      string          `json:"payer_bank" gorm:"type:varchar(300)"`
+	Purpose        string          `json:"purpose" gorm:"type:text"` // Назначение платежа
+
+	// Ключ для обнаружения повторной загрузки платежа; уникален среди неповторных платежей компании
+	Fingerprint string `json:"fingerprint" gorm:"type:varchar(64);uniqueIndex:idx_bank_payments_fingerprint,priority:2,where:status <> 'duplicate' AND deleted_at IS NULL"`
+
+	// Результат сопоставления
+	Status       BankPaymentStatus `json:"status" gorm:"not null;type:varchar(20);index"`
+	InvoiceID    *uint             `json:"invoice_id" gorm:"index"`
+	Invoice      *Invoice          `json:"invoice,omitempty" gorm:"foreignKey:InvoiceID"`
+	MatchScore   int               `json:"match_score"`
+	MatchReason  string            `json:"match_reason" gorm:"type:text"`
+	CandidateIDs string            `json:"candidate_ids" gorm:"type:text"` // ID счетов-кандидатов через запятую
+
+	// Ручная обработка
+	ProcessedAt   *time.Time `json:"processed_at"`
+	ProcessedByID *uint      `json:"processed_by_id"`
+}
+
+// TableName задает имя таблицы для модели BankStatementPayment
+func (BankStatementPayment) TableName() string {
+	return "bank_statement_payments"
+}
+
+// NeedsReview проверяет, ожидает ли платеж ручной обработки
+func (p *BankStatementPayment) NeedsReview() bool {
+	return p.Status == BankPaymentStatusReview || p.Status == BankPaymentStatusUnmatched
+}
@@ -0,0 +1,59 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Client представляет клиента (контрагента), общего для договоров и счетов
+type Client struct {
+	ID        uint           `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+
+	// Связь с компанией (мультитенантность)
+	CompanyID uuid.UUID `json:"company_id" gorm:"type:uuid;not null;index"`
+
+	// Наименование
+	Name     string `json:"name" gorm:"not null;type:varchar(200)"`
+	FullName string `json:"full_name" gorm:"type:varchar(500)"`
+
+	// Реквизиты. Клиент однозначно определяется парой ИНН+КПП
+	INN  string `json:"inn" gorm:"type:varchar(20);index:idx_clients_requisites"`
+	KPP  string `json:"kpp" gorm:"type:varchar(20);index:idx_clients_requisites"`
+	OGRN string `json:"ogrn" gorm:"type:varchar(20)"`
+
+	// Контакты
+	LegalAddress  string `json:"legal_address" gorm:"type:text"`
+	ActualAddress string `json:"actual_address" gorm:"type:text"`
+	Phone         string `json:"phone" gorm:"type:varchar(50)"`
+	Email         string `json:"email" gorm:"type:varchar(100)"`
+	ContactPerson string `json:"contact_person" gorm:"type:varchar(200)"`
+
+	// Иерархия (группы контрагентов)
+	IsFolder bool    `json:"is_folder" gorm:"default:false"`
+	ParentID *uint   `json:"parent_id" gorm:"index"`
+	Parent   *Client `json:"parent,omitempty" gorm:"foreignKey:ParentID"`
+
+	// Статус
+	IsActive bool `json:"is_active" gorm:"default:true"`
+
+	// Связь с внешними системами
+	ExternalID     string `json:"external_id" gorm:"type:varchar(100);index"` // ID во внешней системе (Ref_Key в 1С)
+	ExternalSource string `json:"external_source" gorm:"type:varchar(50)"`    // 1c, bitrix24
+
+	Notes string `json:"notes" gorm:"type:text"`
+}
+
+// TableName задает имя таблицы для модели Client
+func (Client) TableName() string {
+	return "clients"
+}
+
+// HasRequisites проверяет, заполнены ли реквизиты для поиска дубликатов
+func (c *Client) HasRequisites() bool {
+	return !c.IsFolder && c.INN != ""
+}
@@ -23,13 +23,15 @@ type Contract struct {
 	// Связь с компанией (мультитенантность)
 	CompanyID uuid.UUID `json:"company_id" gorm:"type:uuid;not null;index"`
 
-	// Клиент
-	ClientName    string `json:"client_name" gorm:"not null;type:varchar(200)"`
-	ClientINN     string `json:"client_inn" gorm:"type:varchar(20)"`
-	ClientKPP     string `json:"client_kpp" gorm:"type:varchar(20)"`
-	ClientEmail   string `json:"client_email" gorm:"type:varchar(100)"`
-	ClientPhone   string `json:"client_phone" gorm:"type:varchar(20)"`
-	ClientAddress string `json:"client_address" gorm:"type:text"`
+	// Клиент. Реквизиты копируются из справочника клиентов при привязке договора
+	ClientID      *uint   `json:"client_id" gorm:"index"`
+	Client        *Client `json:"client,omitempty" gorm:"foreignKey:ClientID"`
+	ClientName    string  `json:"client_name" gorm:"not null;type:varchar(200)"`
+	ClientINN     string  `json:"client_inn" gorm:"type:varchar(20)"`
+	ClientKPP     string  `json:"client_kpp" gorm:"type:varchar(20)"`
+	ClientEmail   string  `json:"client_email" gorm:"type:varchar(100)"`
+	ClientPhone   string  `json:"client_phone" gorm:"type:varchar(20)"`
+	ClientAddress string  `json:"client_address" gorm:"type:text"`
 
 	// Даты договора
 	StartDate time.Time  `json:"start_date" gorm:"not null"`
@@ -85,6 +87,17 @@ func (c *Contract) GetDaysUntilExpiry() int {
 	return int(duration.Hours() / 24)
 }
 
+// ApplyClient привязывает договор к клиенту и копирует его реквизиты
+func (c *Contract) ApplyClient(client *Client) {
+	c.ClientID = &client.ID
+	c.ClientName = client.Name
+	c.ClientINN = client.INN
+	c.ClientKPP = client.KPP
+	c.ClientEmail = client.Email
+	c.ClientPhone = client.Phone
+	c.ClientAddress = client.LegalAddress
+}
+
 // ContractAppendix представляет приложение к договору
 type ContractAppendix struct {
 	ID        uint           `json:"id" gorm:"primarykey"`
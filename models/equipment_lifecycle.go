@@ -0,0 +1,143 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// DefaultUsefulLifeMonths срок полезного использования, если он не задан ни у оборудования, ни у категории
+const DefaultUsefulLifeMonths = 36
+
+// EffectiveUsefulLife возвращает срок полезного использования в месяцах:
+// значение оборудования, затем категории (если загружена), затем значение по умолчанию
+func (e *Equipment) EffectiveUsefulLife() int {
+	if e.UsefulLifeMonths > 0 {
+		return e.UsefulLifeMonths
+	}
+	if e.Category != nil && e.Category.UsefulLifeMonths > 0 {
+		return e.Category.UsefulLifeMonths
+	}
+	return DefaultUsefulLifeMonths
+}
+
+// DepreciationStart возвращает дату начала амортизации: дату закупки или дату постановки на учет
+func (e *Equipment) DepreciationStart() time.Time {
+	if e.PurchaseDate != nil {
+		return *e.PurchaseDate
+	}
+	return e.CreatedAt
+}
+
+// DepreciationMonthsAt возвращает число месяцев начисленной амортизации на дату.
+// Амортизация начисляется с месяца, следующего за месяцем начала, и не дольше срока полезного использования.
+// После списания начисление прекращается.
+func (e *Equipment) DepreciationMonthsAt(date time.Time) int {
+	if e.DisposedAt != nil && date.After(*e.DisposedAt) {
+		date = *e.DisposedAt
+	}
+
+	start := e.DepreciationStart()
+	months := (date.Year()-start.Year())*12 + int(date.Month()) - int(start.Month())
+	if months < 0 {
+		return 0
+	}
+	if life := e.EffectiveUsefulLife(); months > life {
+		return life
+	}
+	return months
+}
+
+// MonthlyDepreciation возвращает ежемесячную сумму амортизации
+func (e *Equipment) MonthlyDepreciation() decimal.Decimal {
+	depreciable := e.PurchasePrice.Sub(e.SalvageValue)
+	if depreciable.IsNegative() {
+		return decimal.Zero
+	}
+	return depreciable.Div(decimal.NewFromInt(int64(e.EffectiveUsefulLife()))).Round(2)
+}
+
+// AccumulatedDepreciationAt возвращает накопленную амортизацию на дату.
+// Сумма считается от общей базы, чтобы округление не накапливалось и к концу срока база списывалась полностью.
+func (e *Equipment) AccumulatedDepreciationAt(date time.Time) decimal.Decimal {
+	depreciable := e.PurchasePrice.Sub(e.SalvageValue)
+	if depreciable.IsNegative() {
+		return decimal.Zero
+	}
+	months := decimal.NewFromInt(int64(e.DepreciationMonthsAt(date)))
+	life := decimal.NewFromInt(int64(e.EffectiveUsefulLife()))
+	return depreciable.Mul(months).Div(life).Round(2)
+}
+
+// BookValueAt возвращает балансовую (остаточную) стоимость на дату.
+// Списанное оборудование с даты списания имеет нулевую балансовую стоимость.
+func (e *Equipment) BookValueAt(date time.Time) decimal.Decimal {
+	if e.DisposedAt != nil && !date.Before(*e.DisposedAt) {
+		return decimal.Zero
+	}
+	if date.Before(e.DepreciationStart()) {
+		return decimal.Zero
+	}
+	return e.PurchasePrice.Sub(e.AccumulatedDepreciationAt(date))
+}
+
+// Статусы возврата оборудования поставщику (RMA)
+const (
+	RMAStatusSent     = "sent"     // Отправлено поставщику
+	RMAStatusRepaired = "repaired" // Отремонтировано и возвращено
+	RMAStatusReplaced = "replaced" // Заменено на новое
+	RMAStatusRefunded = "refunded" // Возвращены деньги
+	RMAStatusRejected = "rejected" // В гарантии отказано, возвращено неисправным
+)
+
+// EquipmentRMA возврат оборудования поставщику по гарантии или для ремонта
+type EquipmentRMA struct {
+	ID        uint           `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+
+	Number string `json:"number" gorm:"uniqueIndex;not null;type:varchar(50)"`
+	Status string `json:"status" gorm:"not null;default:'sent';type:varchar(20);index"`
+
+	EquipmentID uint       `json:"equipment_id" gorm:"not null;index"`
+	Equipment   *Equipment `json:"equipment,omitempty" gorm:"foreignKey:EquipmentID"`
+
+	// Поставщик: из приходного документа или указанный вручную
+	GoodsReceiptID *uint  `json:"goods_receipt_id"`
+	SupplierName   string `json:"supplier_name" gorm:"type:varchar(255)"`
+
+	Reason        string `json:"reason" gorm:"type:text"`
+	UnderWarranty bool   `json:"under_warranty"` // Гарантия действовала на дату отправки
+
+	// Откуда отправлено и куда вернуть
+	FromWarehouseID   *uint `json:"from_warehouse_id"`
+	ReturnWarehouseID *uint `json:"return_warehouse_id"`
+
+	SentAt     time.Time  `json:"sent_at"`
+	ResolvedAt *time.Time `json:"resolved_at"`
+
+	// Результат
+	Resolution             string          `json:"resolution" gorm:"type:text"`
+	RepairCost             decimal.Decimal `json:"repair_cost" gorm:"type:decimal(10,2)"`   // Платный ремонт вне гарантии
+	RefundAmount           decimal.Decimal `json:"refund_amount" gorm:"type:decimal(10,2)"` // Возвращенная поставщиком сумма
+	ReplacementEquipmentID *uint           `json:"replacement_equipment_id"`
+	ReplacementEquipment   *Equipment      `json:"replacement_equipment,omitempty" gorm:"foreignKey:ReplacementEquipmentID"`
+
+	SentByUserID     uint  `json:"sent_by_user_id"`
+	ResolvedByUserID *uint `json:"resolved_by_user_id"`
+
+	// Для мультитенантности
+	CompanyID uint `json:"company_id" gorm:"index"`
+}
+
+// TableName задает имя таблицы для модели EquipmentRMA
+func (EquipmentRMA) TableName() string {
+	return "equipment_rmas"
+}
+
+// IsOpen проверяет, ожидает ли возврат ответа поставщика
+func (r *EquipmentRMA) IsOpen() bool {
+	return r.Status == RMAStatusSent
+}
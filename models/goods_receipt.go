@@ -0,0 +1,85 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// GoodsReceipt приходный документ: поступление партии оборудования от поставщика
+type GoodsReceipt struct {
+	ID        uint           `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+
+	// Реквизиты документа
+	Number     string    `json:"number" gorm:"uniqueIndex;not null;type:varchar(50)"` // Номер приходного документа
+	ReceivedAt time.Time `json:"received_at" gorm:"not null"`                         // Дата поступления
+
+	// Поставщик и его накладная
+	SupplierName  string     `json:"supplier_name" gorm:"not null;type:varchar(255)"`
+	SupplierINN   string     `json:"supplier_inn" gorm:"type:varchar(12)"`
+	InvoiceNumber string     `json:"invoice_number" gorm:"type:varchar(50);index"` // Номер накладной (УПД) поставщика
+	InvoiceDate   *time.Time `json:"invoice_date"`
+
+	// Склад и место хранения, куда оприходовано оборудование
+	WarehouseID  uint        `json:"warehouse_id" gorm:"not null;index"`
+	Warehouse    *Warehouse  `json:"warehouse,omitempty" gorm:"foreignKey:WarehouseID"`
+	StorageBinID *uint       `json:"storage_bin_id"`
+	StorageBin   *StorageBin `json:"storage_bin,omitempty" gorm:"foreignKey:StorageBinID"`
+
+	// Общие характеристики партии
+	CategoryID     *uint              `json:"category_id"`
+	Category       *EquipmentCategory `json:"category,omitempty" gorm:"foreignKey:CategoryID"`
+	EquipmentType  string             `json:"equipment_type" gorm:"not null;type:varchar(50)"`
+	Model          string             `json:"model" gorm:"not null;type:varchar(100)"`
+	Brand          string             `json:"brand" gorm:"type:varchar(100)"`
+	UnitPrice      decimal.Decimal    `json:"unit_price" gorm:"type:decimal(10,2)"`   // Цена за единицу по умолчанию
+	WarrantyMonths int                `json:"warranty_months" gorm:"default:0"`       // Гарантия поставщика в месяцах
+	Quantity       int                `json:"quantity" gorm:"default:0"`              // Количество единиц
+	TotalAmount    decimal.Decimal    `json:"total_amount" gorm:"type:decimal(12,2)"` // Сумма документа
+
+	Notes string `json:"notes" gorm:"type:text"`
+
+	// Заказ поставщику, по которому получена партия
+	PurchaseOrderID     *uint `json:"purchase_order_id" gorm:"index"`
+	PurchaseOrderItemID *uint `json:"purchase_order_item_id" gorm:"index"`
+
+	// Кто оформил поступление
+	UserID uint  `json:"user_id" gorm:"index"`
+	User   *User `json:"user,omitempty" gorm:"foreignKey:UserID"`
+
+	// Связи
+	Items []GoodsReceiptItem `json:"items,omitempty" gorm:"foreignKey:GoodsReceiptID"`
+
+	// Для мультитенантности
+	CompanyID uint `json:"company_id" gorm:"index"`
+}
+
+// TableName задает имя таблицы для модели GoodsReceipt
+func (GoodsReceipt) TableName() string {
+	return "goods_receipts"
+}
+
+// GoodsReceiptItem строка приходного документа: единица оборудования
+type GoodsReceiptItem struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"created_at"`
+
+	GoodsReceiptID uint       `json:"goods_receipt_id" gorm:"not null;index"`
+	EquipmentID    uint       `json:"equipment_id" gorm:"not null;index"`
+	Equipment      *Equipment `json:"equipment,omitempty" gorm:"foreignKey:EquipmentID"`
+
+	LineNumber   int             `json:"line_number"`
+	SerialNumber string          `json:"serial_number" gorm:"type:varchar(100)"`
+	IMEI         string          `json:"imei" gorm:"type:varchar(20)"`
+	PhoneNumber  string          `json:"phone_number" gorm:"type:varchar(20)"`
+	Price        decimal.Decimal `json:"price" gorm:"type:decimal(10,2)"`
+}
+
+// TableName задает имя таблицы для модели GoodsReceiptItem
+func (GoodsReceiptItem) TableName() string {
+	return "goods_receipt_items"
+}
@@ -0,0 +1,130 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Типы событий офлайн-синхронизации полевого приложения монтажника
+const (
+	FieldEventStart     = "start"     // Начало работ
+	FieldEventChecklist = "checklist" // Отметка пункта чек-листа
+	FieldEventSignature = "signature" // Подпись клиента
+	FieldEventEquipment = "equipment" // Отсканированное оборудование
+	FieldEventComplete  = "complete"  // Завершение работ
+)
+
+// InstallationChecklistTemplate шаблон чек-листа для типа работ
+type InstallationChecklistTemplate struct {
+	ID        uint           `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+
+	Name             string `json:"name" gorm:"not null;type:varchar(255)"`
+	InstallationType string `json:"installation_type" gorm:"not null;type:varchar(50);index"` // монтаж, диагностика, демонтаж, обслуживание
+	RequireSignature bool   `json:"require_signature" gorm:"default:false"`                   // Без подписи клиента монтаж не завершить
+	IsActive         bool   `json:"is_active"`
+
+	Items []InstallationChecklistTemplateItem `json:"items,omitempty" gorm:"foreignKey:TemplateID"`
+
+	// Для мультитенантности
+	CompanyID uint `json:"company_id" gorm:"index"`
+}
+
+// TableName задает имя таблицы для модели InstallationChecklistTemplate
+func (InstallationChecklistTemplate) TableName() string {
+	return "installation_checklist_templates"
+}
+
+// InstallationChecklistTemplateItem пункт шаблона чек-листа
+type InstallationChecklistTemplateItem struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	TemplateID    uint   `json:"template_id" gorm:"not null;index"`
+	Position      int    `json:"position"`
+	Title         string `json:"title" gorm:"not null;type:varchar(255)"`
+	Required      bool   `json:"required"`
+	RequiresPhoto bool   `json:"requires_photo" gorm:"default:false"` // Нужна фотография к пункту
+}
+
+// TableName задает имя таблицы для модели InstallationChecklistTemplateItem
+func (InstallationChecklistTemplateItem) TableName() string {
+	return "installation_checklist_template_items"
+}
+
+// InstallationChecklistItem пункт чек-листа конкретного монтажа, копируется из шаблона
+type InstallationChecklistItem struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	InstallationID uint   `json:"installation_id" gorm:"not null;index"`
+	TemplateItemID *uint  `json:"template_item_id"`
+	Position       int    `json:"position"`
+	Title          string `json:"title" gorm:"not null;type:varchar(255)"`
+	Required       bool   `json:"required"`
+	RequiresPhoto  bool   `json:"requires_photo"`
+
+	Done    bool   `json:"done" gorm:"default:false"`
+	Value   string `json:"value" gorm:"type:varchar(255)"` // Измеренное значение, если нужно
+	Comment string `json:"comment" gorm:"type:text"`
+
+	// Момент отметки на устройстве монтажника - более старые офлайн-события не перезаписывают пункт
+	ChangedAt *time.Time `json:"changed_at"`
+
+	Photos []InstallationPhoto `json:"photos,omitempty" gorm:"foreignKey:ChecklistItemID"`
+}
+
+// TableName задает имя таблицы для модели InstallationChecklistItem
+func (InstallationChecklistItem) TableName() string {
+	return "installation_checklist_items"
+}
+
+// InstallationPhoto фотография с объекта монтажа
+type InstallationPhoto struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"created_at"`
+
+	InstallationID  uint  `json:"installation_id" gorm:"not null;index"`
+	ChecklistItemID *uint `json:"checklist_item_id" gorm:"index"`
+
+	// Идентификатор фотографии на устройстве - повторная загрузка не создает дубликат
+	ClientID string `json:"client_id" gorm:"type:varchar(64);index"`
+
+	FileName      string     `json:"file_name" gorm:"type:varchar(255)"`
+	FilePath      string     `json:"-" gorm:"type:varchar(500)"`
+	ThumbnailPath string     `json:"-" gorm:"type:varchar(500)"`
+	ContentType   string     `json:"content_type" gorm:"type:varchar(50)"`
+	Size          int64      `json:"size"`
+	Width         int        `json:"width"`
+	Height        int        `json:"height"`
+	Caption       string     `json:"caption" gorm:"type:varchar(255)"`
+	TakenAt       *time.Time `json:"taken_at"`
+}
+
+// TableName задает имя таблицы для модели InstallationPhoto
+func (InstallationPhoto) TableName() string {
+	return "installation_photos"
+}
+
+// InstallationSyncEvent примененное событие офлайн-синхронизации.
+// Повторная отправка события с тем же EventID не применяется второй раз.
+type InstallationSyncEvent struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"created_at"`
+
+	EventID        string    `json:"event_id" gorm:"uniqueIndex;not null;type:varchar(64)"`
+	InstallationID uint      `json:"installation_id" gorm:"not null;index"`
+	Type           string    `json:"type" gorm:"not null;type:varchar(20)"`
+	OccurredAt     time.Time `json:"occurred_at"`
+	Status         string    `json:"status" gorm:"type:varchar(20)"` // applied, stale
+}
+
+// TableName задает имя таблицы для модели InstallationSyncEvent
+func (InstallationSyncEvent) TableName() string {
+	return "installation_sync_events"
+}
@@ -28,6 +28,7 @@ type Integration struct {
 	LastSyncAt   *time.Time `json:"last_sync_at"`
 	LastErrorAt  *time.Time `json:"last_error_at"`
 	ErrorMessage string     `json:"error_message" gorm:"type:text"`
+	NeedsReauth  bool       `json:"needs_reauth" gorm:"default:false"` // Доступ отозван, требуется повторная авторизация
 
 	// Статистика
 	SyncCount    int `json:"sync_count" gorm:"default:0"`
@@ -45,7 +46,7 @@ func (Integration) TableName() string {
 
 // IsHealthy проверяет, работает ли интеграция нормально
 func (i *Integration) IsHealthy() bool {
-	if !i.IsActive {
+	if !i.IsActive || i.NeedsReauth {
 		return false
 	}
 
@@ -89,3 +90,13 @@ func (i *Integration) UpdateStats(success bool, errorMessage string) {
 
 	i.UpdatedAt = now
 }
+
+// MarkReauthRequired помечает интеграцию как требующую повторной авторизации
+func (i *Integration) MarkReauthRequired(reason string) {
+	now := time.Now()
+	i.NeedsReauth = true
+	i.ErrorCount++
+	i.ErrorMessage = reason
+	i.LastErrorAt = &now
+	i.UpdatedAt = now
+}
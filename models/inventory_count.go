@@ -0,0 +1,111 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Статусы инвентаризации
+const (
+	InventoryCountInProgress = "in_progress" // Идет пересчет
+	InventoryCountCompleted  = "completed"   // Пересчет завершен, расхождения рассчитаны
+	InventoryCountApproved   = "approved"    // Расхождения проведены
+	InventoryCountCancelled  = "cancelled"
+)
+
+// Результаты сверки позиции инвентаризации
+const (
+	InventoryResultPending  = "pending"  // Пересчет не завершен
+	InventoryResultMatched  = "matched"  // Числится и найдено
+	InventoryResultShortage = "shortage" // Числится, но не найдено (недостача)
+	InventoryResultSurplus  = "surplus"  // Найдено, но не числится на складе (излишек)
+	InventoryResultUnknown  = "unknown"  // Найден код, которого нет в учете
+)
+
+// InventoryCount сессия инвентаризации склада
+type InventoryCount struct {
+	ID        uint           `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+
+	Number string `json:"number" gorm:"uniqueIndex;not null;type:varchar(50)"`
+	Status string `json:"status" gorm:"not null;default:'in_progress';type:varchar(20);index"`
+
+	// Область пересчета: весь склад или место хранения с вложенными
+	WarehouseID  uint        `json:"warehouse_id" gorm:"not null;index"`
+	Warehouse    *Warehouse  `json:"warehouse,omitempty" gorm:"foreignKey:WarehouseID"`
+	StorageBinID *uint       `json:"storage_bin_id"`
+	StorageBin   *StorageBin `json:"storage_bin,omitempty" gorm:"foreignKey:StorageBinID"`
+
+	// Итоги
+	ExpectedCount int `json:"expected_count"` // Числилось на момент начала
+	ScannedCount  int `json:"scanned_count"`  // Отсканировано уникальных позиций
+	MatchedCount  int `json:"matched_count"`
+	ShortageCount int `json:"shortage_count"`
+	SurplusCount  int `json:"surplus_count"`
+	UnknownCount  int `json:"unknown_count"`
+
+	StartedAt   time.Time  `json:"started_at"`
+	CompletedAt *time.Time `json:"completed_at"`
+	ApprovedAt  *time.Time `json:"approved_at"`
+
+	CreatedByUserID  uint  `json:"created_by_user_id"`
+	ApprovedByUserID *uint `json:"approved_by_user_id"`
+
+	Notes string `json:"notes" gorm:"type:text"`
+
+	// Связи
+	Items []InventoryCountItem `json:"items,omitempty" gorm:"foreignKey:InventoryCountID"`
+
+	// Для мультитенантности
+	CompanyID uint `json:"company_id" gorm:"index"`
+}
+
+// TableName задает имя таблицы для модели InventoryCount
+func (InventoryCount) TableName() string {
+	return "inventory_counts"
+}
+
+// IsEditable проверяет, принимает ли инвентаризация результаты сканирования
+func (ic *InventoryCount) IsEditable() bool {
+	return ic.Status == InventoryCountInProgress
+}
+
+// InventoryCountItem позиция инвентаризации: числящееся или найденное оборудование
+type InventoryCountItem struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	InventoryCountID uint       `json:"inventory_count_id" gorm:"not null;index"`
+	EquipmentID      *uint      `json:"equipment_id" gorm:"index"`
+	Equipment        *Equipment `json:"equipment,omitempty" gorm:"foreignKey:EquipmentID"`
+
+	// Идентификация
+	Code         string `json:"code" gorm:"type:varchar(100);index"` // Отсканированный код или серийный номер
+	SerialNumber string `json:"serial_number" gorm:"type:varchar(100)"`
+	Description  string `json:"description" gorm:"type:varchar(255)"` // Тип и модель оборудования
+
+	// Учетные данные на момент начала инвентаризации
+	Expected         bool   `json:"expected"`
+	ExpectedLocation string `json:"expected_location" gorm:"type:varchar(100)"`
+	ExpectedStatus   string `json:"expected_status" gorm:"type:varchar(20)"`
+
+	// Результат пересчета
+	Scanned         bool       `json:"scanned"`
+	ScanCount       int        `json:"scan_count"`
+	ScannedAt       *time.Time `json:"scanned_at"`
+	ScannedByUserID *uint      `json:"scanned_by_user_id"`
+	Result          string     `json:"result" gorm:"default:'pending';type:varchar(20);index"`
+
+	// Проведение расхождения
+	AdjustmentOperationID *uint  `json:"adjustment_operation_id"`
+	AdjustmentNote        string `json:"adjustment_note" gorm:"type:varchar(255)"`
+}
+
+// TableName задает имя таблицы для модели InventoryCountItem
+func (InventoryCountItem) TableName() string {
+	return "inventory_count_items"
+}
@@ -27,6 +27,8 @@ type Invoice struct {
 	CompanyID    uuid.UUID   `json:"company_id" gorm:"type:uuid;not null;index"`
 	ContractID   *uint       `json:"contract_id" gorm:"index"` // Может быть null для общих счетов
 	Contract     *Contract   `json:"contract,omitempty" gorm:"foreignKey:ContractID"`
+	ClientID     *uint       `json:"client_id" gorm:"index"`
+	Client       *Client     `json:"client,omitempty" gorm:"foreignKey:ClientID"`
 	TariffPlanID uint        `json:"tariff_plan_id" gorm:"not null"`
 	TariffPlan   *TariffPlan `json:"tariff_plan,omitempty" gorm:"foreignKey:TariffPlanID"`
 
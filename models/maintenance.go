@@ -0,0 +1,98 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Статусы планового обслуживания
+const (
+	MaintenanceVisitScheduled  = "scheduled"  // Создан монтаж типа "обслуживание"
+	MaintenanceVisitUnassigned = "unassigned" // Нет свободного монтажника, повторная попытка при следующей проверке
+	MaintenanceVisitCompleted  = "completed"  // Обслуживание выполнено
+	MaintenanceVisitCancelled  = "cancelled"  // Монтаж отменен, будет создан новый
+)
+
+// MaintenanceInstallationType тип монтажа для планового обслуживания
+const MaintenanceInstallationType = "обслуживание"
+
+// MaintenancePlan план регулярного обслуживания объекта или всего установленного оборудования категории.
+// Обслуживание требуется по истечении интервала в месяцах или в моточасах - что наступит раньше.
+type MaintenancePlan struct {
+	ID        uint           `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+
+	Name        string `json:"name" gorm:"not null;type:varchar(255)"`
+	Description string `json:"description" gorm:"type:text"`
+
+	// Область действия: объект или категория оборудования
+	ObjectID            *uint              `json:"object_id" gorm:"index"`
+	Object              *Object            `json:"object,omitempty" gorm:"foreignKey:ObjectID"`
+	EquipmentCategoryID *uint              `json:"equipment_category_id" gorm:"index"`
+	EquipmentCategory   *EquipmentCategory `json:"equipment_category,omitempty" gorm:"foreignKey:EquipmentCategoryID"`
+
+	// Периодичность
+	IntervalMonths      int `json:"interval_months"`       // Каждые N месяцев
+	IntervalEngineHours int `json:"interval_engine_hours"` // Каждые N моточасов
+
+	// Планирование
+	LeadDays          int    `json:"lead_days"`          // За сколько дней до срока создавать монтаж
+	LeadEngineHours   int    `json:"lead_engine_hours"`  // За сколько моточасов до срока создавать монтаж
+	EstimatedDuration int    `json:"estimated_duration"` // Продолжительность обслуживания, минут
+	Priority          string `json:"priority" gorm:"type:varchar(20)"`
+	Specialization    string `json:"specialization" gorm:"type:varchar(100)"` // Требуемая специализация монтажника
+
+	IsActive bool `json:"is_active"`
+
+	// Для мультитенантности
+	CompanyID uint `json:"company_id" gorm:"index"`
+}
+
+// TableName задает имя таблицы для модели MaintenancePlan
+func (MaintenancePlan) TableName() string {
+	return "maintenance_plans"
+}
+
+// MaintenanceVisit плановое обслуживание по плану: срок, созданный монтаж и фактическое выполнение
+type MaintenanceVisit struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	PlanID      uint             `json:"plan_id" gorm:"not null;index"`
+	Plan        *MaintenancePlan `json:"plan,omitempty" gorm:"foreignKey:PlanID"`
+	ObjectID    uint             `json:"object_id" gorm:"not null;index"`
+	Object      *Object          `json:"object,omitempty" gorm:"foreignKey:ObjectID"`
+	EquipmentID *uint            `json:"equipment_id" gorm:"index"` // Для плана по категории оборудования
+
+	// Срок обслуживания
+	DueAt          time.Time `json:"due_at" gorm:"not null;index"`
+	DueEngineHours *float64  `json:"due_engine_hours"`
+	Reason         string    `json:"reason" gorm:"type:varchar(255)"` // По сроку или по моточасам
+
+	Status         string        `json:"status" gorm:"not null;type:varchar(20);index"`
+	InstallationID *uint         `json:"installation_id" gorm:"index"`
+	Installation   *Installation `json:"installation,omitempty" gorm:"foreignKey:InstallationID"`
+	Note           string        `json:"note" gorm:"type:varchar(255)"` // Причина, по которой монтаж не создан
+
+	// Фактическое выполнение
+	CompletedAt          *time.Time `json:"completed_at"`
+	CompletedEngineHours *float64   `json:"completed_engine_hours"`
+	OnTime               bool       `json:"on_time"`
+
+	// Для мультитенантности
+	CompanyID uint `json:"company_id" gorm:"index"`
+}
+
+// TableName задает имя таблицы для модели MaintenanceVisit
+func (MaintenanceVisit) TableName() string {
+	return "maintenance_visits"
+}
+
+// IsOpen проверяет, ожидает ли обслуживание выполнения
+func (v *MaintenanceVisit) IsOpen() bool {
+	return v.Status == MaintenanceVisitScheduled || v.Status == MaintenanceVisitUnassigned
+}
@@ -28,6 +28,14 @@ type Object struct {
 	PhoneNumber  string `json:"phone_number" gorm:"type:varchar(20)"`
 	SerialNumber string `json:"serial_number" gorm:"type:varchar(50)"`
 
+	// Данные транспортного средства
+	VIN          string `json:"vin" gorm:"type:varchar(17);index"`
+	LicensePlate string `json:"license_plate" gorm:"type:varchar(20);index"` // Государственный номер
+
+	// Наработка двигателя для обслуживания по моточасам
+	EngineHours          *float64   `json:"engine_hours"`
+	EngineHoursUpdatedAt *time.Time `json:"engine_hours_updated_at"`
+
 	// Статус объекта
 	Status            string     `json:"status" gorm:"default:'active';type:varchar(20)"` // active, inactive, maintenance, deleted
 	IsActive          bool       `json:"is_active" gorm:"default:true"`
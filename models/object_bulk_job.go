@@ -0,0 +1,79 @@
+package models
+
+import (
+	"time"
+)
+
+// Массовые операции над объектами
+const (
+	ObjectBulkActionActivate       = "activate"        // Активировать
+	ObjectBulkActionDeactivate     = "deactivate"      // Деактивировать
+	ObjectBulkActionMoveContract   = "move_contract"   // Перенести на другой договор
+	ObjectBulkActionScheduleDelete = "schedule_delete" // Запланировать удаление
+	ObjectBulkActionAddTags        = "add_tags"        // Добавить теги
+	ObjectBulkActionRemoveTags     = "remove_tags"     // Удалить теги
+	ObjectBulkActionApplyTemplate  = "apply_template"  // Применить шаблон и его настройки по умолчанию
+)
+
+// Статусы задания массовой операции
+const (
+	ObjectBulkJobStatusPending             = "pending"               // Создано, ожидает запуска
+	ObjectBulkJobStatusRunning             = "running"               // Выполняется
+	ObjectBulkJobStatusCompleted           = "completed"             // Выполнено для всех объектов
+	ObjectBulkJobStatusCompletedWithErrors = "completed_with_errors" // Выполнено, часть объектов с ошибками
+	ObjectBulkJobStatusFailed              = "failed"                // Не выполнено ни для одного объекта
+)
+
+// ObjectBulkJob задание массовой операции над объектами, выполняется в фоне
+type ObjectBulkJob struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	Action     string `json:"action" gorm:"not null;type:varchar(30)"`
+	Parameters string `json:"parameters" gorm:"type:text"` // JSON параметров операции и фильтра
+	Status     string `json:"status" gorm:"not null;type:varchar(30);index"`
+
+	// Прогресс
+	Total     int `json:"total"`
+	Processed int `json:"processed"`
+	Succeeded int `json:"succeeded"`
+	Failed    int `json:"failed"`
+
+	ErrorMsg string `json:"error_msg" gorm:"type:text"` // Ошибка, не относящаяся к отдельному объекту
+
+	// Объекты, на которых операция не выполнена
+	Errors []ObjectBulkJobError `json:"errors,omitempty" gorm:"foreignKey:JobID"`
+
+	UserID      *uint      `json:"user_id"`
+	StartedAt   *time.Time `json:"started_at"`
+	CompletedAt *time.Time `json:"completed_at"`
+
+	// Для мультитенантности
+	CompanyID uint `json:"company_id" gorm:"index"`
+}
+
+// TableName задает имя таблицы для модели ObjectBulkJob
+func (ObjectBulkJob) TableName() string {
+	return "object_bulk_jobs"
+}
+
+// IsFinished проверяет, завершено ли задание
+func (j *ObjectBulkJob) IsFinished() bool {
+	return j.Status != ObjectBulkJobStatusPending && j.Status != ObjectBulkJobStatusRunning
+}
+
+// ObjectBulkJobError ошибка массовой операции для отдельного объекта
+type ObjectBulkJobError struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"created_at"`
+
+	JobID    uint   `json:"job_id" gorm:"not null;index"`
+	ObjectID uint   `json:"object_id" gorm:"index"`
+	Message  string `json:"message" gorm:"type:text"`
+}
+
+// TableName задает имя таблицы для модели ObjectBulkJobError
+func (ObjectBulkJobError) TableName() string {
+	return "object_bulk_job_errors"
+}
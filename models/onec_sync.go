@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OneCSyncMapping связь локальной сущности с объектом в 1С
+type OneCSyncMapping struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	CompanyID  uuid.UUID `json:"company_id" gorm:"type:uuid;not null;index"`
+	EntityType string    `json:"entity_type" gorm:"not null;type:varchar(50);index"` // contract, invoice, payroll_statement
+	EntityID   uint      `json:"entity_id" gorm:"not null;index"`
+	ExternalID string    `json:"external_id" gorm:"not null;type:varchar(100)"` // Ref_Key в 1С
+	Checksum   string    `json:"checksum" gorm:"type:varchar(64)"`              // Контрольная сумма выгруженных данных
+	SyncedAt   time.Time `json:"synced_at"`
+}
+
+// TableName задает имя таблицы для модели OneCSyncMapping
+func (OneCSyncMapping) TableName() string {
+	return "1c_sync_mappings"
+}
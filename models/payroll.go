@@ -0,0 +1,149 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// Статусы расчетного листа монтажника
+const (
+	PayrollStatusDraft    = "draft"    // Рассчитан, можно пересчитать
+	PayrollStatusApproved = "approved" // Утвержден, пересчет невозможен
+	PayrollStatusPaid     = "paid"     // Выплачен
+)
+
+// PayrollRate сдельная ставка за монтаж. Ставка может быть общей, для типа монтажника
+// или для конкретного подрядчика и действует для указанного вида работ или для всех видов.
+type PayrollRate struct {
+	ID        uint           `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+
+	Name             string `json:"name" gorm:"type:varchar(255)"`
+	InstallationType string `json:"installation_type" gorm:"type:varchar(50);index"` // Пусто - любой вид работ
+	InstallerType    string `json:"installer_type" gorm:"type:varchar(20)"`          // staff, contractor, partner; пусто - любой
+	InstallerID      *uint  `json:"installer_id" gorm:"index"`                       // Ставка конкретного монтажника
+
+	PieceRate   decimal.Decimal `json:"piece_rate" gorm:"type:decimal(10,2)"`  // Оплата за выполненный монтаж
+	PayHourly   bool            `json:"pay_hourly"`                            // Дополнительно оплачивать фактическое время по часовой ставке монтажника
+	TravelRate  decimal.Decimal `json:"travel_rate" gorm:"type:decimal(10,2)"` // Компенсация за час в пути
+	TravelFixed decimal.Decimal `json:"travel_fixed" gorm:"type:decimal(10,2)"`
+
+	ValidFrom *time.Time `json:"valid_from"`
+	ValidTo   *time.Time `json:"valid_to"`
+	IsActive  bool       `json:"is_active"`
+
+	// Для мультитенантности
+	CompanyID uint `json:"company_id" gorm:"index"`
+}
+
+// TableName задает имя таблицы для модели PayrollRate
+func (PayrollRate) TableName() string {
+	return "payroll_rates"
+}
+
+// PayrollPenaltyRule удержание за низкую оценку качества монтажа
+type PayrollPenaltyRule struct {
+	ID        uint           `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+
+	Name          string          `json:"name" gorm:"type:varchar(255)"`
+	RatingBelow   float32         `json:"rating_below" gorm:"not null"`             // Правило действует при оценке ниже порога
+	PenaltyPct    decimal.Decimal `json:"penalty_pct" gorm:"type:decimal(5,2)"`     // Процент от начисления за монтаж
+	PenaltyAmount decimal.Decimal `json:"penalty_amount" gorm:"type:decimal(10,2)"` // Фиксированная сумма удержания
+	IsActive      bool            `json:"is_active"`
+
+	// Для мультитенантности
+	CompanyID uint `json:"company_id" gorm:"index"`
+}
+
+// TableName задает имя таблицы для модели PayrollPenaltyRule
+func (PayrollPenaltyRule) TableName() string {
+	return "payroll_penalty_rules"
+}
+
+// PayrollStatement расчетный лист монтажника за месяц
+type PayrollStatement struct {
+	ID        uint           `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+
+	Number string `json:"number" gorm:"uniqueIndex;not null;type:varchar(50)"` // РЛ-202403-0007
+	Status string `json:"status" gorm:"not null;default:'draft';type:varchar(20);index"`
+
+	InstallerID   uint       `json:"installer_id" gorm:"not null;index"`
+	Installer     *Installer `json:"installer,omitempty" gorm:"foreignKey:InstallerID"`
+	InstallerName string     `json:"installer_name" gorm:"type:varchar(255)"`
+	InstallerType string     `json:"installer_type" gorm:"type:varchar(20)"`
+
+	PeriodStart time.Time `json:"period_start" gorm:"not null;index"`
+	PeriodEnd   time.Time `json:"period_end" gorm:"not null"`
+
+	InstallationsCount int `json:"installations_count"`
+	WorkMinutes        int `json:"work_minutes"`
+	TravelMinutes      int `json:"travel_minutes"`
+	ReviewCount        int `json:"review_count"` // Строки, рассчитанные без ставки
+
+	PieceAmount   decimal.Decimal `json:"piece_amount" gorm:"type:decimal(12,2)"`
+	HourlyAmount  decimal.Decimal `json:"hourly_amount" gorm:"type:decimal(12,2)"`
+	TravelAmount  decimal.Decimal `json:"travel_amount" gorm:"type:decimal(12,2)"`
+	PenaltyAmount decimal.Decimal `json:"penalty_amount" gorm:"type:decimal(12,2)"`
+	TotalAmount   decimal.Decimal `json:"total_amount" gorm:"type:decimal(12,2)"`
+
+	CalculatedAt     time.Time  `json:"calculated_at"`
+	ApprovedByUserID *uint      `json:"approved_by_user_id"`
+	ApprovedAt       *time.Time `json:"approved_at"`
+	PaidAt           *time.Time `json:"paid_at"`
+	ExternalID       string     `json:"external_id" gorm:"type:varchar(100)"` // Ref_Key документа в 1С
+	Notes            string     `json:"notes" gorm:"type:text"`
+
+	Lines []PayrollStatementLine `json:"lines,omitempty" gorm:"foreignKey:StatementID"`
+
+	// Для мультитенантности
+	CompanyID uint `json:"company_id" gorm:"index"`
+}
+
+// TableName задает имя таблицы для модели PayrollStatement
+func (PayrollStatement) TableName() string {
+	return "payroll_statements"
+}
+
+// IsEditable проверяет, можно ли пересчитать расчетный лист
+func (ps *PayrollStatement) IsEditable() bool {
+	return ps.Status == PayrollStatusDraft
+}
+
+// PayrollStatementLine начисление за один монтаж в расчетном листе
+type PayrollStatementLine struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"created_at"`
+
+	StatementID    uint       `json:"statement_id" gorm:"not null;index"`
+	InstallationID uint       `json:"installation_id" gorm:"not null;index"`
+	RateID         *uint      `json:"rate_id"`
+	Description    string     `json:"description" gorm:"type:varchar(255)"`
+	CompletedAt    *time.Time `json:"completed_at"`
+
+	ActualDuration int      `json:"actual_duration"` // Минуты
+	TravelTime     int      `json:"travel_time"`     // Минуты
+	QualityRating  *float32 `json:"quality_rating"`
+
+	PieceAmount   decimal.Decimal `json:"piece_amount" gorm:"type:decimal(10,2)"`
+	HourlyAmount  decimal.Decimal `json:"hourly_amount" gorm:"type:decimal(10,2)"`
+	TravelAmount  decimal.Decimal `json:"travel_amount" gorm:"type:decimal(10,2)"`
+	PenaltyAmount decimal.Decimal `json:"penalty_amount" gorm:"type:decimal(10,2)"`
+	TotalAmount   decimal.Decimal `json:"total_amount" gorm:"type:decimal(10,2)"`
+	NeedsReview   bool            `json:"needs_review"` // Ставка не найдена, начисление требует проверки
+	Note          string          `json:"note" gorm:"type:varchar(255)"`
+}
+
+// TableName задает имя таблицы для модели PayrollStatementLine
+func (PayrollStatementLine) TableName() string {
+	return "payroll_statement_lines"
+}
@@ -0,0 +1,104 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// Статусы заказа поставщику
+const (
+	PurchaseOrderStatusDraft             = "draft"              // Черновик, в том числе сформированный по плану пополнения
+	PurchaseOrderStatusOrdered           = "ordered"            // Отправлен поставщику
+	PurchaseOrderStatusPartiallyReceived = "partially_received" // Получена часть поставки
+	PurchaseOrderStatusReceived          = "received"           // Поставка получена полностью
+	PurchaseOrderStatusClosed            = "closed"             // Закрыт с недопоставкой
+	PurchaseOrderStatusCancelled         = "cancelled"          // Отменен
+)
+
+// PurchaseOrder заказ оборудования у поставщика
+type PurchaseOrder struct {
+	ID        uint           `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+
+	Number string `json:"number" gorm:"uniqueIndex;not null;type:varchar(50)"` // PO-20260115-001
+	Status string `json:"status" gorm:"not null;default:'draft';type:varchar(20);index"`
+
+	// Поставщик
+	SupplierName string `json:"supplier_name" gorm:"not null;type:varchar(255)"`
+	SupplierINN  string `json:"supplier_inn" gorm:"type:varchar(12)"`
+
+	// Склад поставки
+	WarehouseID uint       `json:"warehouse_id" gorm:"not null;index"`
+	Warehouse   *Warehouse `json:"warehouse,omitempty" gorm:"foreignKey:WarehouseID"`
+
+	// Даты
+	OrderedAt  *time.Time `json:"ordered_at"`
+	ExpectedAt *time.Time `json:"expected_at"` // Ожидаемая дата поставки
+	ClosedAt   *time.Time `json:"closed_at"`   // Получение, закрытие или отмена
+
+	TotalAmount decimal.Decimal `json:"total_amount" gorm:"type:decimal(12,2)"`
+	Notes       string          `json:"notes" gorm:"type:text"`
+
+	// Заказ сформирован по плану пополнения
+	FromReplenishment bool `json:"from_replenishment" gorm:"default:false"`
+
+	UserID uint  `json:"user_id" gorm:"index"`
+	User   *User `json:"user,omitempty" gorm:"foreignKey:UserID"`
+
+	// Связи
+	Items    []PurchaseOrderItem `json:"items,omitempty" gorm:"foreignKey:PurchaseOrderID"`
+	Receipts []GoodsReceipt      `json:"receipts,omitempty" gorm:"foreignKey:PurchaseOrderID"`
+
+	// Для мультитенантности
+	CompanyID uint `json:"company_id" gorm:"index"`
+}
+
+// TableName задает имя таблицы для модели PurchaseOrder
+func (PurchaseOrder) TableName() string {
+	return "purchase_orders"
+}
+
+// IsOpen проверяет, ожидается ли еще поставка по заказу
+func (po *PurchaseOrder) IsOpen() bool {
+	return po.Status == PurchaseOrderStatusDraft ||
+		po.Status == PurchaseOrderStatusOrdered ||
+		po.Status == PurchaseOrderStatusPartiallyReceived
+}
+
+// PurchaseOrderItem строка заказа поставщику
+type PurchaseOrderItem struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	PurchaseOrderID uint `json:"purchase_order_id" gorm:"not null;index"`
+	LineNumber      int  `json:"line_number"`
+
+	CategoryID    *uint              `json:"category_id" gorm:"index"`
+	Category      *EquipmentCategory `json:"category,omitempty" gorm:"foreignKey:CategoryID"`
+	EquipmentType string             `json:"equipment_type" gorm:"not null;type:varchar(50)"`
+	Model         string             `json:"model" gorm:"not null;type:varchar(100)"`
+	Brand         string             `json:"brand" gorm:"type:varchar(100)"`
+
+	Quantity         int             `json:"quantity"`
+	ReceivedQuantity int             `json:"received_quantity" gorm:"default:0"`
+	UnitPrice        decimal.Decimal `json:"unit_price" gorm:"type:decimal(10,2)"`
+	Amount           decimal.Decimal `json:"amount" gorm:"type:decimal(12,2)"`
+}
+
+// TableName задает имя таблицы для модели PurchaseOrderItem
+func (PurchaseOrderItem) TableName() string {
+	return "purchase_order_items"
+}
+
+// RemainingQuantity количество, которое еще ожидается к поставке
+func (item *PurchaseOrderItem) RemainingQuantity() int {
+	if item.ReceivedQuantity >= item.Quantity {
+		return 0
+	}
+	return item.Quantity - item.ReceivedQuantity
+}
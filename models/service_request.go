@@ -0,0 +1,157 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Источники заявок на обслуживание
+const (
+	ServiceRequestSourcePhone    = "phone"
+	ServiceRequestSourceEmail    = "email"
+	ServiceRequestSourceBitrix24 = "bitrix24"
+	ServiceRequestSourcePortal   = "portal"
+)
+
+// Статусы заявки на обслуживание
+const (
+	ServiceRequestStatusNew        = "new"         // Поступила, не разобрана
+	ServiceRequestStatusTriaged    = "triaged"     // Разобрана, определены объект и приоритет
+	ServiceRequestStatusScheduled  = "scheduled"   // Созданы монтажи
+	ServiceRequestStatusInProgress = "in_progress" // Монтажник на объекте
+	ServiceRequestStatusResolved   = "resolved"    // Работы выполнены
+	ServiceRequestStatusClosed     = "closed"      // Закрыта после подтверждения клиентом
+	ServiceRequestStatusCancelled  = "cancelled"   // Отозвана клиентом
+	ServiceRequestStatusRejected   = "rejected"    // Отклонена (дубликат, не наш объект и т.п.)
+)
+
+// ServiceRequestTransitions допустимые переходы статусов заявки
+var ServiceRequestTransitions = map[string][]string{
+	ServiceRequestStatusNew:        {ServiceRequestStatusTriaged, ServiceRequestStatusScheduled, ServiceRequestStatusCancelled, ServiceRequestStatusRejected},
+	ServiceRequestStatusTriaged:    {ServiceRequestStatusScheduled, ServiceRequestStatusResolved, ServiceRequestStatusCancelled, ServiceRequestStatusRejected},
+	ServiceRequestStatusScheduled:  {ServiceRequestStatusTriaged, ServiceRequestStatusInProgress, ServiceRequestStatusResolved, ServiceRequestStatusCancelled},
+	ServiceRequestStatusInProgress: {ServiceRequestStatusScheduled, ServiceRequestStatusResolved, ServiceRequestStatusCancelled},
+	ServiceRequestStatusResolved:   {ServiceRequestStatusClosed, ServiceRequestStatusTriaged},
+	ServiceRequestStatusClosed:     {},
+	ServiceRequestStatusCancelled:  {},
+	ServiceRequestStatusRejected:   {},
+}
+
+// CanTransitionServiceRequest проверяет, допустим ли переход заявки из статуса from в статус to
+func CanTransitionServiceRequest(from, to string) bool {
+	for _, allowed := range ServiceRequestTransitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// ServiceRequest заявка клиента на обслуживание ("трекер не на связи, приезжайте")
+type ServiceRequest struct {
+	ID        uint           `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+
+	Number     string `json:"number" gorm:"type:varchar(50);index"`            // ЗВ-000123
+	Source     string `json:"source" gorm:"not null;type:varchar(20);index"`   // phone, email, bitrix24, portal
+	ExternalID string `json:"external_id" gorm:"type:varchar(100);index"`      // ID сделки Битрикс24, письма и т.п.
+	Status     string `json:"status" gorm:"not null;type:varchar(20);index"`   // new, triaged, scheduled, in_progress, resolved, closed, cancelled, rejected
+	Priority   string `json:"priority" gorm:"not null;type:varchar(20);index"` // low, normal, high, urgent
+	Category   string `json:"category" gorm:"type:varchar(50)"`                // Вид работ, становится типом монтажа
+	Subject    string `json:"subject" gorm:"not null;type:varchar(255)"`       // Кратко: "Трекер не на связи"
+	Details    string `json:"details" gorm:"type:text"`                        // Описание со слов клиента
+	Address    string `json:"address" gorm:"type:text"`                        // Где находится транспорт
+	Resolution string `json:"resolution" gorm:"type:text"`                     // Итог работ для клиента
+
+	// Заявитель
+	RequesterName  string `json:"requester_name" gorm:"type:varchar(255)"`
+	RequesterPhone string `json:"requester_phone" gorm:"type:varchar(20)"`
+	RequesterEmail string `json:"requester_email" gorm:"type:varchar(100)"`
+
+	// Связи
+	ObjectID   *uint     `json:"object_id" gorm:"index"`
+	Object     *Object   `json:"object,omitempty" gorm:"foreignKey:ObjectID"`
+	ContractID *uint     `json:"contract_id" gorm:"index"`
+	Contract   *Contract `json:"contract,omitempty" gorm:"foreignKey:ContractID"`
+
+	// Ответственные
+	CreatedByUserID *uint `json:"created_by_user_id"`
+	AssignedUserID  *uint `json:"assigned_user_id" gorm:"index"`
+	TriagedByUserID *uint `json:"triaged_by_user_id"`
+
+	// Сроки реакции и решения
+	ResponseDueAt   time.Time  `json:"response_due_at" gorm:"index"`
+	ResolutionDueAt time.Time  `json:"resolution_due_at" gorm:"index"`
+	RespondedAt     *time.Time `json:"responded_at"` // Первый разбор заявки
+	ResolvedAt      *time.Time `json:"resolved_at"`
+	ClosedAt        *time.Time `json:"closed_at"`
+
+	Installations []Installation        `json:"installations,omitempty" gorm:"foreignKey:ServiceRequestID"`
+	Events        []ServiceRequestEvent `json:"events,omitempty" gorm:"foreignKey:RequestID"`
+
+	// Для мультитенантности
+	CompanyID uint `json:"company_id" gorm:"index"`
+}
+
+// TableName задает имя таблицы для модели ServiceRequest
+func (ServiceRequest) TableName() string {
+	return "service_requests"
+}
+
+// IsOpen проверяет, ожидает ли заявка решения
+func (r *ServiceRequest) IsOpen() bool {
+	switch r.Status {
+	case ServiceRequestStatusResolved, ServiceRequestStatusClosed, ServiceRequestStatusCancelled, ServiceRequestStatusRejected:
+		return false
+	}
+	return true
+}
+
+// IsResponseOverdue проверяет, нарушен ли срок реакции на заявку
+func (r *ServiceRequest) IsResponseOverdue(now time.Time) bool {
+	if r.RespondedAt != nil {
+		return r.RespondedAt.After(r.ResponseDueAt)
+	}
+	return r.IsOpen() && now.After(r.ResponseDueAt)
+}
+
+// IsResolutionOverdue проверяет, нарушен ли срок решения заявки
+func (r *ServiceRequest) IsResolutionOverdue(now time.Time) bool {
+	if r.ResolvedAt != nil {
+		return r.ResolvedAt.After(r.ResolutionDueAt)
+	}
+	return r.IsOpen() && now.After(r.ResolutionDueAt)
+}
+
+// Типы событий заявки
+const (
+	ServiceRequestEventCreated      = "created"
+	ServiceRequestEventStatus       = "status"
+	ServiceRequestEventComment      = "comment"
+	ServiceRequestEventInstallation = "installation"
+)
+
+// ServiceRequestEvent событие в истории заявки. Публичные события сообщаются заявителю.
+type ServiceRequestEvent struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"created_at"`
+
+	RequestID          uint       `json:"request_id" gorm:"not null;index"`
+	Type               string     `json:"type" gorm:"not null;type:varchar(20)"`
+	FromStatus         string     `json:"from_status" gorm:"type:varchar(20)"`
+	ToStatus           string     `json:"to_status" gorm:"type:varchar(20)"`
+	Message            string     `json:"message" gorm:"type:text"`
+	Public             bool       `json:"public"` // Видно заявителю
+	InstallationID     *uint      `json:"installation_id"`
+	UserID             *uint      `json:"user_id"`
+	NotifiedAt         *time.Time `json:"notified_at"`          // Когда заявитель получил уведомление
+	ExternalNotifiedAt *time.Time `json:"external_notified_at"` // Когда событие добавлено в ленту сделки Битрикс24
+}
+
+// TableName задает имя таблицы для модели ServiceRequestEvent
+func (ServiceRequestEvent) TableName() string {
+	return "service_request_events"
+}
@@ -0,0 +1,140 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// Статусы SIM-карты
+const (
+	SimStatusInStock    = "in_stock"   // На складе, не установлена
+	SimStatusActive     = "active"     // Установлена в трекер
+	SimStatusSuspended  = "suspended"  // Приостановлена у оператора (блокировка)
+	SimStatusTerminated = "terminated" // Договор с оператором расторгнут
+)
+
+// Результаты сверки начислений оператора
+const (
+	SimChargeMatched        = "matched"         // Начисление совпадает с тарифом
+	SimChargeCostMismatch   = "cost_mismatch"   // Сумма отличается от абонентской платы по тарифу
+	SimChargeUnknownSim     = "unknown_sim"     // SIM-карты нет в учете
+	SimChargeTerminated     = "terminated"      // Начисление по расторгнутой SIM-карте
+	SimChargeInactiveObject = "inactive_object" // SIM-карта в трекере неактивного или удаленного объекта
+)
+
+// MobileOperator оператор сотовой связи
+type MobileOperator struct {
+	ID        uint           `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+
+	Name string `json:"name" gorm:"not null;type:varchar(100)"`
+	Code string `json:"code" gorm:"uniqueIndex;type:varchar(20)"` // mts, megafon, beeline, tele2
+
+	// Настройки передачи данных для конфигурации трекеров
+	APN         string `json:"apn" gorm:"column:apn;type:varchar(100)"`
+	APNUser     string `json:"apn_user" gorm:"column:apn_user;type:varchar(50)"`
+	APNPassword string `json:"apn_password" gorm:"column:apn_password;type:varchar(50)"`
+
+	// Договор и поддержка
+	ContractNumber string `json:"contract_number" gorm:"type:varchar(50)"`
+	SupportPhone   string `json:"support_phone" gorm:"type:varchar(20)"`
+	ManagerContact string `json:"manager_contact" gorm:"type:varchar(255)"`
+
+	// Тариф по умолчанию для новых SIM-карт
+	DefaultTariff      string          `json:"default_tariff" gorm:"type:varchar(100)"`
+	DefaultMonthlyCost decimal.Decimal `json:"default_monthly_cost" gorm:"type:decimal(10,2)"`
+
+	IsActive bool   `json:"is_active" gorm:"default:true"`
+	Notes    string `json:"notes" gorm:"type:text"`
+
+	// Для мультитенантности
+	CompanyID uint `json:"company_id" gorm:"index"`
+}
+
+// TableName задает имя таблицы для модели MobileOperator
+func (MobileOperator) TableName() string {
+	return "mobile_operators"
+}
+
+// SimCard SIM-карта как складская единица
+type SimCard struct {
+	ID        uint           `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+
+	// Идентификаторы
+	ICCID  string `json:"iccid" gorm:"column:iccid;uniqueIndex;not null;type:varchar(22)"` // Номер SIM-карты (18-20 цифр)
+	MSISDN string `json:"msisdn" gorm:"column:msisdn;index;type:varchar(20)"`              // Абонентский номер
+
+	// Оператор и тариф
+	OperatorID  uint            `json:"operator_id" gorm:"not null;index"`
+	Operator    *MobileOperator `json:"operator,omitempty" gorm:"foreignKey:OperatorID"`
+	TariffName  string          `json:"tariff_name" gorm:"type:varchar(100)"`
+	MonthlyCost decimal.Decimal `json:"monthly_cost" gorm:"type:decimal(10,2)"` // Абонентская плата в месяц
+
+	Status string `json:"status" gorm:"not null;default:'in_stock';type:varchar(20);index"`
+
+	// Местоположение: склад или трекер
+	WarehouseID *uint      `json:"warehouse_id" gorm:"index"`
+	Warehouse   *Warehouse `json:"warehouse,omitempty" gorm:"foreignKey:WarehouseID"`
+	EquipmentID *uint      `json:"equipment_id" gorm:"index"`
+	Equipment   *Equipment `json:"equipment,omitempty" gorm:"foreignKey:EquipmentID"`
+
+	// Даты жизненного цикла
+	ActivatedAt  *time.Time `json:"activated_at"`
+	PairedAt     *time.Time `json:"paired_at"`
+	SuspendedAt  *time.Time `json:"suspended_at"`
+	TerminatedAt *time.Time `json:"terminated_at"`
+
+	Notes string `json:"notes" gorm:"type:text"`
+
+	// Для мультитенантности
+	CompanyID uint `json:"company_id" gorm:"index"`
+}
+
+// TableName задает имя таблицы для модели SimCard
+func (SimCard) TableName() string {
+	return "sim_cards"
+}
+
+// IsBillable проверяет, начисляет ли оператор абонентскую плату за SIM-карту
+func (s *SimCard) IsBillable() bool {
+	return s.Status == SimStatusActive || s.Status == SimStatusInStock
+}
+
+// SimOperatorCharge строка детализации начислений оператора за месяц
+type SimOperatorCharge struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"created_at"`
+
+	OperatorID uint      `json:"operator_id" gorm:"not null;index:idx_sim_charge_period"`
+	Period     time.Time `json:"period" gorm:"not null;index:idx_sim_charge_period"` // Первое число месяца начислений
+
+	// Данные оператора
+	LineNumber int             `json:"line_number"`
+	ICCID      string          `json:"iccid" gorm:"column:iccid;type:varchar(22)"`
+	MSISDN     string          `json:"msisdn" gorm:"column:msisdn;type:varchar(20)"`
+	Amount     decimal.Decimal `json:"amount" gorm:"type:decimal(10,2)"`
+
+	// Результат сверки
+	SimCardID      *uint           `json:"sim_card_id" gorm:"index"`
+	SimCard        *SimCard        `json:"sim_card,omitempty" gorm:"foreignKey:SimCardID"`
+	ExpectedAmount decimal.Decimal `json:"expected_amount" gorm:"type:decimal(10,2)"`
+	Result         string          `json:"result" gorm:"type:varchar(20);index"`
+	Comment        string          `json:"comment" gorm:"type:varchar(255)"`
+
+	ImportedByUserID uint `json:"imported_by_user_id"`
+
+	// Для мультитенантности
+	CompanyID uint `json:"company_id" gorm:"index"`
+}
+
+// TableName задает имя таблицы для модели SimOperatorCharge
+func (SimOperatorCharge) TableName() string {
+	return "sim_operator_charges"
+}
@@ -0,0 +1,87 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Контролируемые сроки SLA монтажа
+const (
+	SLAMetricSchedule   = "schedule"   // Монтаж поставлен в расписание
+	SLAMetricArrival    = "arrival"    // Монтажник приступил к работам на объекте
+	SLAMetricCompletion = "completion" // Работы завершены
+)
+
+// Состояния срока SLA
+const (
+	SLAStateOnTrack  = "on_track" // Срок не наступил, нарушение не прогнозируется
+	SLAStateAtRisk   = "at_risk"  // Прогнозируется нарушение срока
+	SLAStateBreached = "breached" // Срок нарушен
+	SLAStateMet      = "met"      // Выполнено в срок
+	SLAStateNone     = "none"     // Срок не задан в политике
+)
+
+// SLAPolicy политика SLA для договора и/или приоритета монтажа. Сроки отсчитываются
+// в рабочих часах от поступления заявки клиента (или создания монтажа без заявки).
+type SLAPolicy struct {
+	ID        uint           `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+
+	Name string `json:"name" gorm:"not null;type:varchar(255)"`
+
+	// Область действия: пустые значения означают любой договор и любой приоритет
+	ContractID *uint     `json:"contract_id" gorm:"index"`
+	Contract   *Contract `json:"contract,omitempty" gorm:"foreignKey:ContractID"`
+	Priority   string    `json:"priority" gorm:"type:varchar(20)"` // low, normal, high, urgent
+
+	// Сроки в рабочих часах, 0 - срок не контролируется
+	ScheduleHours   float64 `json:"schedule_hours"`
+	ArrivalHours    float64 `json:"arrival_hours"`
+	CompletionHours float64 `json:"completion_hours"`
+
+	// Рабочий календарь
+	BusinessHoursStart string `json:"business_hours_start" gorm:"type:varchar(5)"` // 09:00
+	BusinessHoursEnd   string `json:"business_hours_end" gorm:"type:varchar(5)"`   // 18:00
+	BusinessDays       string `json:"business_days" gorm:"type:varchar(20)"`       // 1,2,3,4,5 (1 = понедельник)
+	AroundTheClock     bool   `json:"around_the_clock"`                            // Круглосуточно, без выходных
+
+	// Эскалация
+	WarningPercent   int    `json:"warning_percent"`                    // Предупреждать, когда израсходовано N% срока
+	EscalationEmails string `json:"escalation_emails" gorm:"type:text"` // Адреса через запятую
+	EscalationPhones string `json:"escalation_phones" gorm:"type:text"` // Телефоны для SMS через запятую
+
+	IsActive bool `json:"is_active"`
+
+	// Для мультитенантности
+	CompanyID uint `json:"company_id" gorm:"index"`
+}
+
+// TableName задает имя таблицы для модели SLAPolicy
+func (SLAPolicy) TableName() string {
+	return "sla_policies"
+}
+
+// SLAEscalation отправленная эскалация по сроку SLA монтажа. Каждый уровень
+// (прогноз нарушения, нарушение) по каждому сроку эскалируется один раз.
+type SLAEscalation struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"created_at"`
+
+	InstallationID uint      `json:"installation_id" gorm:"not null;index"`
+	PolicyID       uint      `json:"policy_id" gorm:"not null;index"`
+	Metric         string    `json:"metric" gorm:"not null;type:varchar(20)"`
+	State          string    `json:"state" gorm:"not null;type:varchar(20)"` // at_risk, breached
+	Deadline       time.Time `json:"deadline"`
+	Message        string    `json:"message" gorm:"type:text"`
+
+	// Для мультитенантности
+	CompanyID uint `json:"company_id" gorm:"index"`
+}
+
+// TableName задает имя таблицы для модели SLAEscalation
+func (SLAEscalation) TableName() string {
+	return "sla_escalations"
+}
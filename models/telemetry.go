@@ -0,0 +1,49 @@
+package models
+
+import (
+	"time"
+)
+
+// Протоколы, по которым трекеры передают телеметрию
+const (
+	TelemetryProtocolWialonIPS = "wialon_ips"
+	TelemetryProtocolEGTS      = "egts"
+)
+
+// TelemetryPoint точка телеметрии, полученная от трекера объекта.
+// Неизвестные устройству значения (NA) хранятся как NULL.
+type TelemetryPoint struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"created_at"` // Время приема сервером
+
+	ObjectID   uint      `json:"object_id" gorm:"not null;index:idx_telemetry_object_time,priority:1"`
+	Protocol   string    `json:"protocol" gorm:"not null;type:varchar(20)"`
+	RecordedAt time.Time `json:"recorded_at" gorm:"not null;index:idx_telemetry_object_time,priority:2"` // Время фиксации на устройстве (UTC)
+
+	// Навигационные данные
+	Latitude   *float64 `json:"latitude"`
+	Longitude  *float64 `json:"longitude"`
+	Speed      *float64 `json:"speed"`    // км/ч
+	Course     *int     `json:"course"`   // Градусы, 0 - север
+	Altitude   *float64 `json:"altitude"` // Метры
+	Satellites *int     `json:"satellites"`
+	HDOP       *float64 `json:"hdop"`
+
+	// Датчики
+	Inputs  *int   `json:"inputs"`                  // Битовая маска дискретных входов
+	Outputs *int   `json:"outputs"`                 // Битовая маска дискретных выходов
+	Params  string `json:"params" gorm:"type:text"` // JSON: АЦП, iButton и дополнительные параметры
+
+	// Для мультитенантности
+	CompanyID uint `json:"company_id" gorm:"index"`
+}
+
+// TableName задает имя таблицы для модели TelemetryPoint
+func (TelemetryPoint) TableName() string {
+	return "telemetry_points"
+}
+
+// HasPosition проверяет, содержит ли точка координаты
+func (p *TelemetryPoint) HasPosition() bool {
+	return p.Latitude != nil && p.Longitude != nil
+}
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+	// Встроенная база часовых поясов: образ может не содержать zoneinfo
+	_ "time/tzdata"
+)
+
+// DefaultTimezone часовой пояс по умолчанию для компаний и локаций
+const DefaultTimezone = "Europe/Moscow"
+
+// LoadTimezone возвращает часовой пояс по имени IANA; пустое или неизвестное имя - часовой пояс по умолчанию
+func LoadTimezone(name string) *time.Location {
+	if name != "" {
+		if location, err := time.LoadLocation(name); err == nil {
+			return location
+		}
+	}
+	location, err := time.LoadLocation(DefaultTimezone)
+	if err != nil {
+		return time.FixedZone("MSK", 3*60*60)
+	}
+	return location
+}
+
+// TimeLocation возвращает часовой пояс локации
+func (l *Location) TimeLocation() *time.Location {
+	return LoadTimezone(l.Timezone)
+}
+
+// TimeLocation возвращает часовой пояс компании
+func (c *Company) TimeLocation() *time.Location {
+	return LoadTimezone(c.Timezone)
+}
@@ -7,6 +7,38 @@ import (
 	"gorm.io/gorm"
 )
 
+// Статусы оборудования
+const (
+	EquipmentStatusInStock     = "in_stock"
+	EquipmentStatusReserved    = "reserved"
+	EquipmentStatusInstalled   = "installed"
+	EquipmentStatusMaintenance = "maintenance"
+	EquipmentStatusBroken      = "broken"
+	EquipmentStatusDisposed    = "disposed"
+	EquipmentStatusRMA         = "rma" // Отправлено поставщику по гарантии (RMA)
+)
+
+// equipmentStatusTransitions допустимые переходы между статусами оборудования
+var equipmentStatusTransitions = map[string][]string{
+	EquipmentStatusInStock:     {EquipmentStatusReserved, EquipmentStatusInstalled, EquipmentStatusMaintenance, EquipmentStatusBroken, EquipmentStatusDisposed, EquipmentStatusRMA},
+	EquipmentStatusReserved:    {EquipmentStatusInStock, EquipmentStatusInstalled},
+	EquipmentStatusInstalled:   {EquipmentStatusInStock, EquipmentStatusMaintenance, EquipmentStatusBroken},
+	EquipmentStatusMaintenance: {EquipmentStatusInStock, EquipmentStatusBroken, EquipmentStatusDisposed, EquipmentStatusRMA},
+	EquipmentStatusBroken:      {EquipmentStatusMaintenance, EquipmentStatusDisposed, EquipmentStatusRMA},
+	EquipmentStatusRMA:         {EquipmentStatusInStock, EquipmentStatusBroken, EquipmentStatusDisposed},
+	EquipmentStatusDisposed:    {},
+}
+
+// CanTransitionEquipmentStatus проверяет, допустим ли переход оборудования из одного статуса в другой
+func CanTransitionEquipmentStatus(from, to string) bool {
+	for _, allowed := range equipmentStatusTransitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
 // Equipment представляет оборудование на складе
 type Equipment struct {
 	ID        uint           `json:"id" gorm:"primarykey"`
@@ -27,9 +59,12 @@ type Equipment struct {
 	QRCode      string `json:"qr_code" gorm:"uniqueIndex;type:varchar(100)"` // QR код для быстрого поиска
 
 	// Статус оборудования
-	Status    string `json:"status" gorm:"default:'in_stock';type:varchar(20)"` // in_stock, reserved, installed, maintenance, broken, disposed
+	Status    string `json:"status" gorm:"default:'in_stock';type:varchar(20)"` // in_stock, reserved, installed, maintenance, broken, rma, disposed
 	Condition string `json:"condition" gorm:"default:'new';type:varchar(20)"`   // new, used, refurbished, damaged
 
+	// Версия записи для оптимистичной блокировки, увеличивается при каждом складском движении
+	Version uint `json:"version" gorm:"not null;default:1"`
+
 	// Связь с объектом (если установлено)
 	ObjectID *uint   `json:"object_id"`
 	Object   *Object `json:"object,omitempty" gorm:"foreignKey:ObjectID"`
@@ -39,13 +74,27 @@ type Equipment struct {
 	Category   *EquipmentCategory `json:"category,omitempty" gorm:"foreignKey:CategoryID"`
 
 	// Местоположение на складе
-	WarehouseLocation string `json:"warehouse_location" gorm:"type:varchar(100)"` // Полка, ячейка и т.д.
+	WarehouseID       *uint       `json:"warehouse_id" gorm:"index"`
+	Warehouse         *Warehouse  `json:"warehouse,omitempty" gorm:"foreignKey:WarehouseID"`
+	StorageBinID      *uint       `json:"storage_bin_id" gorm:"index"`
+	StorageBin        *StorageBin `json:"storage_bin,omitempty" gorm:"foreignKey:StorageBinID"`
+	WarehouseLocation string      `json:"warehouse_location" gorm:"type:varchar(100)"` // Адрес места хранения: склад/ячейка
 
 	// Финансовая информация
 	PurchasePrice decimal.Decimal `json:"purchase_price" gorm:"type:decimal(10,2)"` // Закупочная цена
 	PurchaseDate  *time.Time      `json:"purchase_date"`                            // Дата закупки
 	WarrantyUntil *time.Time      `json:"warranty_until"`                           // Гарантия до
 
+	// Амортизация (линейный способ)
+	UsefulLifeMonths int             `json:"useful_life_months"`                      // Срок полезного использования, мес. (0 - из категории)
+	SalvageValue     decimal.Decimal `json:"salvage_value" gorm:"type:decimal(10,2)"` // Ликвидационная стоимость
+
+	// Списание
+	DisposedAt        *time.Time      `json:"disposed_at"`
+	DisposalReason    string          `json:"disposal_reason" gorm:"type:varchar(255)"`
+	DisposalValue     decimal.Decimal `json:"disposal_value" gorm:"type:decimal(10,2)"`      // Остаточная стоимость при списании (реализация, возврат)
+	DisposalBookValue decimal.Decimal `json:"disposal_book_value" gorm:"type:decimal(10,2)"` // Балансовая стоимость на дату списания
+
 	// Технические характеристики (JSON)
 	Specifications string `json:"specifications" gorm:"type:jsonb"` // Технические характеристики
 
@@ -67,6 +116,11 @@ func (e *Equipment) IsAvailable() bool {
 	return e.Status == "in_stock" && e.Condition != "damaged" && e.Condition != "broken"
 }
 
+// CanTransitionTo проверяет, может ли оборудование перейти в указанный статус
+func (e *Equipment) CanTransitionTo(status string) bool {
+	return CanTransitionEquipmentStatus(e.Status, status)
+}
+
 // NeedsAttention проверяет, требует ли оборудование внимания
 func (e *Equipment) NeedsAttention() bool {
 	// Проверяем состояние
@@ -192,7 +246,9 @@ func (i *Installer) GetDisplayName() string {
 	return i.FirstName + " " + i.LastName
 }
 
-// IsAvailableOnDate проверяет доступность монтажника на определенную дату
+// IsAvailableOnDate проверяет доступность монтажника на определенную дату.
+// День недели берется в часовом поясе переданного времени - его нужно
+// предварительно перевести в часовой пояс локации монтажа.
 func (i *Installer) IsAvailableOnDate(date time.Time) bool {
 	if !i.IsActive || i.Status != "available" {
 		return false
@@ -212,6 +268,27 @@ func (i *Installer) IsAvailableOnDate(date time.Time) bool {
 	return false
 }
 
+// WorkingHoursOn возвращает начало и конец рабочего дня монтажника в день date
+// в часовом поясе date. Если часы не заданы или заданы неверно, используется 09:00-18:00.
+func (i *Installer) WorkingHoursOn(date time.Time) (time.Time, time.Time) {
+	clock := func(value string, fallback int) time.Time {
+		minutes := fallback * 60
+		if parsed, err := time.Parse("15:04", value); err == nil {
+			minutes = parsed.Hour()*60 + parsed.Minute()
+		}
+		return time.Date(date.Year(), date.Month(), date.Day(), minutes/60, minutes%60, 0, 0, date.Location())
+	}
+	return clock(i.WorkingHoursStart, 9), clock(i.WorkingHoursEnd, 18)
+}
+
+// IsWithinWorkingHours проверяет, что работа длительностью duration минут
+// целиком укладывается в рабочее время монтажника (в часовом поясе start)
+func (i *Installer) IsWithinWorkingHours(start time.Time, duration int) bool {
+	workStart, workEnd := i.WorkingHoursOn(start)
+	end := start.Add(time.Duration(duration) * time.Minute)
+	return !start.Before(workStart) && !end.After(workEnd)
+}
+
 // CanWorkInLocation проверяет, может ли монтажник работать в указанной локации
 func (i *Installer) CanWorkInLocation(locationID uint) bool {
 	for _, id := range i.LocationIDs {
@@ -261,6 +338,9 @@ type Installation struct {
 	LocationID *uint     `json:"location_id" gorm:"index"`
 	Location   *Location `json:"location,omitempty" gorm:"foreignKey:LocationID"`
 
+	// Заявка клиента, по которой создан монтаж
+	ServiceRequestID *uint `json:"service_request_id" gorm:"index"`
+
 	// Оборудование для монтажа
 	Equipment []Equipment `json:"equipment,omitempty" gorm:"many2many:installation_equipment;"`
 
@@ -291,6 +371,20 @@ type Installation struct {
 	Issues         string   `json:"issues" gorm:"type:text"`   // Проблемы, возникшие при работе
 	Photos         []string `json:"photos" gorm:"type:text[]"` // Пути к фотографиям
 
+	// Подпись клиента, полученная на устройстве монтажника
+	ClientSignaturePath string     `json:"-" gorm:"type:varchar(500)"`
+	ClientSignedBy      string     `json:"client_signed_by" gorm:"type:varchar(255)"`
+	ClientSignedAt      *time.Time `json:"client_signed_at"`
+
+	// Чек-лист и фотографии полевого приложения
+	Checklist   []InstallationChecklistItem `json:"checklist,omitempty" gorm:"foreignKey:InstallationID"`
+	FieldPhotos []InstallationPhoto         `json:"field_photos,omitempty" gorm:"foreignKey:InstallationID"`
+
+	// Акт выполненных работ, формируется после завершения монтажа
+	ActNumber      string     `json:"act_number" gorm:"type:varchar(50)"`
+	ActPath        string     `json:"-" gorm:"type:varchar(500)"`
+	ActGeneratedAt *time.Time `json:"act_generated_at"`
+
 	// Стоимость (из старой модели)
 	Cost       decimal.Decimal `json:"cost" gorm:"type:decimal(10,2)"`  // Стоимость работы
 	IsBillable bool            `json:"is_billable" gorm:"default:true"` // Оплачиваемая ли работа
@@ -393,16 +487,21 @@ type WarehouseOperation struct {
 	Quantity int `json:"quantity" gorm:"default:1"`
 
 	// Местоположение
-	FromLocation string `json:"from_location" gorm:"type:varchar(100)"` // Откуда
-	ToLocation   string `json:"to_location" gorm:"type:varchar(100)"`   // Куда
+	FromLocation    string `json:"from_location" gorm:"type:varchar(100)"` // Откуда
+	ToLocation      string `json:"to_location" gorm:"type:varchar(100)"`   // Куда
+	FromWarehouseID *uint  `json:"from_warehouse_id" gorm:"index"`
+	ToWarehouseID   *uint  `json:"to_warehouse_id" gorm:"index"`
+	FromBinID       *uint  `json:"from_bin_id"`
+	ToBinID         *uint  `json:"to_bin_id"`
 
 	// Ответственное лицо
 	UserID uint  `json:"user_id" gorm:"index"`
 	User   *User `json:"user,omitempty" gorm:"foreignKey:UserID"`
 
 	// Документы и заметки
-	DocumentNumber string `json:"document_number" gorm:"type:varchar(50)"` // Номер документа
-	Notes          string `json:"notes" gorm:"type:text"`
+	DocumentNumber string          `json:"document_number" gorm:"type:varchar(50)"` // Номер документа
+	Cost           decimal.Decimal `json:"cost" gorm:"type:decimal(10,2)"`          // Затраты по операции (обслуживание, ремонт)
+	Notes          string          `json:"notes" gorm:"type:text"`
 
 	// Связанная установка (если операция связана с монтажом)
 	InstallationID *uint         `json:"installation_id"`
@@ -446,8 +545,16 @@ type EquipmentCategory struct {
 	Code        string `json:"code" gorm:"uniqueIndex;type:varchar(20)"` // Код категории
 
 	// Настройки
-	MinStockLevel int  `json:"min_stock_level" gorm:"default:5"` // Минимальный остаток для уведомлений
-	IsActive      bool `json:"is_active" gorm:"default:true"`
+	MinStockLevel    int  `json:"min_stock_level" gorm:"default:5"` // Минимальный остаток для уведомлений
+	UsefulLifeMonths int  `json:"useful_life_months"`               // Срок полезного использования по умолчанию, мес.
+	IsActive         bool `json:"is_active" gorm:"default:true"`
+
+	// Пополнение запасов
+	ReorderPoint          int    `json:"reorder_point"`                                    // Точка заказа; 0 - используется минимальный остаток
+	ReorderQuantity       int    `json:"reorder_quantity"`                                 // Размер партии заказа
+	LeadTimeDays          int    `json:"lead_time_days"`                                   // Срок поставки, дней
+	PreferredSupplierName string `json:"preferred_supplier_name" gorm:"type:varchar(255)"` // Основной поставщик
+	PreferredSupplierINN  string `json:"preferred_supplier_inn" gorm:"type:varchar(12)"`
 
 	// Связи
 	Equipment []Equipment `json:"equipment,omitempty" gorm:"foreignKey:CategoryID"`
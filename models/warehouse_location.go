@@ -0,0 +1,139 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Типы складов
+const (
+	WarehouseTypeStock = "warehouse" // Стационарный склад
+	WarehouseTypeVan   = "van"       // Мобильный склад монтажника (автомобиль)
+)
+
+// Уровни мест хранения внутри склада: склад → зона → стеллаж → ячейка
+const (
+	StorageBinZone  = "zone"
+	StorageBinShelf = "shelf"
+	StorageBinBin   = "bin"
+)
+
+// storageBinParentType допустимый тип родительского места хранения
+var storageBinParentType = map[string]string{
+	StorageBinZone:  "",
+	StorageBinShelf: StorageBinZone,
+	StorageBinBin:   StorageBinShelf,
+}
+
+// Warehouse представляет склад или мобильный склад монтажника
+type Warehouse struct {
+	ID        uint           `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+
+	// Основная информация
+	Name    string `json:"name" gorm:"not null;type:varchar(100)"`
+	Code    string `json:"code" gorm:"uniqueIndex;not null;type:varchar(20)"`
+	Type    string `json:"type" gorm:"not null;default:'warehouse';type:varchar(20)"` // warehouse, van
+	Address string `json:"address" gorm:"type:text"`
+
+	// Город, в котором находится склад
+	LocationID *uint     `json:"location_id" gorm:"index"`
+	Location   *Location `json:"location,omitempty" gorm:"foreignKey:LocationID"`
+
+	// Монтажник, за которым закреплен мобильный склад
+	InstallerID *uint      `json:"installer_id" gorm:"index"`
+	Installer   *Installer `json:"installer,omitempty" gorm:"foreignKey:InstallerID"`
+
+	// Ответственный за склад
+	ResponsibleUserID *uint `json:"responsible_user_id"`
+	ResponsibleUser   *User `json:"responsible_user,omitempty" gorm:"foreignKey:ResponsibleUserID"`
+
+	IsActive bool   `json:"is_active" gorm:"default:true"`
+	Notes    string `json:"notes" gorm:"type:text"`
+
+	// Связи
+	Bins []StorageBin `json:"bins,omitempty" gorm:"foreignKey:WarehouseID"`
+
+	// Для мультитенантности
+	CompanyID uint `json:"company_id" gorm:"index"`
+}
+
+// TableName задает имя таблицы для модели Warehouse
+func (Warehouse) TableName() string {
+	return "warehouses"
+}
+
+// IsMobile проверяет, является ли склад мобильным складом монтажника
+func (w *Warehouse) IsMobile() bool {
+	return w.Type == WarehouseTypeVan
+}
+
+// StorageBin представляет место хранения на складе (зона, стеллаж или ячейка)
+type StorageBin struct {
+	ID        uint           `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+
+	WarehouseID uint       `json:"warehouse_id" gorm:"not null;index"`
+	Warehouse   *Warehouse `json:"warehouse,omitempty" gorm:"foreignKey:WarehouseID"`
+
+	// Иерархия мест хранения
+	ParentID *uint        `json:"parent_id" gorm:"index"`
+	Parent   *StorageBin  `json:"parent,omitempty" gorm:"foreignKey:ParentID"`
+	Children []StorageBin `json:"children,omitempty" gorm:"foreignKey:ParentID"`
+
+	Type string `json:"type" gorm:"not null;type:varchar(20)"` // zone, shelf, bin
+	Code string `json:"code" gorm:"not null;type:varchar(20)"` // Код внутри родителя: A, 03, 12
+	Name string `json:"name" gorm:"type:varchar(100)"`
+	Path string `json:"path" gorm:"type:varchar(100);index"` // Полный адрес: A-03-12
+
+	IsActive bool `json:"is_active" gorm:"default:true"`
+}
+
+// TableName задает имя таблицы для модели StorageBin
+func (StorageBin) TableName() string {
+	return "storage_bins"
+}
+
+// IsValidStorageBinType проверяет тип места хранения
+func IsValidStorageBinType(binType string) bool {
+	_, ok := storageBinParentType[binType]
+	return ok
+}
+
+// CanBeChildOf проверяет, может ли место хранения располагаться внутри родителя
+func (b *StorageBin) CanBeChildOf(parent *StorageBin) bool {
+	expected, ok := storageBinParentType[b.Type]
+	if !ok {
+		return false
+	}
+	if parent == nil {
+		return expected == ""
+	}
+	return parent.Type == expected && parent.WarehouseID == b.WarehouseID
+}
+
+// BuildPath формирует полный адрес места хранения по адресу родителя
+func (b *StorageBin) BuildPath(parent *StorageBin) string {
+	code := strings.ToUpper(strings.TrimSpace(b.Code))
+	if parent == nil {
+		return code
+	}
+	return parent.Path + "-" + code
+}
+
+// StockLocationLabel возвращает текстовое описание места хранения
+func StockLocationLabel(warehouse *Warehouse, bin *StorageBin) string {
+	if warehouse == nil {
+		return ""
+	}
+	if bin == nil {
+		return warehouse.Code
+	}
+	return warehouse.Code + "/" + bin.Path
+}
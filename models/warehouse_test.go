@@ -454,6 +454,39 @@ func TestInstallerModel(t *testing.T) {
 		assert.False(t, installer.CanWorkInLocation(1))
 	})
 
+	t.Run("Рабочие дни и часы в часовом поясе локации", func(t *testing.T) {
+		installer := Installer{
+			IsActive:          true,
+			Status:            "available",
+			WorkingDays:       []int{1, 2, 3, 4, 5},
+			WorkingHoursStart: "09:00",
+			WorkingHoursEnd:   "18:00",
+		}
+		vladivostok := (&Location{Timezone: "Asia/Vladivostok"}).TimeLocation()
+		moscow := (&Location{Timezone: "Europe/Moscow"}).TimeLocation()
+
+		// Воскресенье 20:00 UTC - в Москве еще выходной, во Владивостоке уже понедельник
+		evening := time.Date(2024, 2, 11, 20, 0, 0, 0, time.UTC)
+		assert.False(t, installer.IsAvailableOnDate(evening.In(moscow)))
+		assert.True(t, installer.IsAvailableOnDate(evening.In(vladivostok)))
+
+		// 23:30 UTC - 09:30 во Владивостоке, но 02:30 ночи в Москве
+		start := time.Date(2024, 2, 11, 23, 30, 0, 0, time.UTC)
+		assert.True(t, installer.IsWithinWorkingHours(start.In(vladivostok), 120))
+		assert.False(t, installer.IsWithinWorkingHours(start.In(vladivostok), 9*60))
+		assert.False(t, installer.IsWithinWorkingHours(start.In(moscow), 120))
+
+		workStart, workEnd := installer.WorkingHoursOn(start.In(vladivostok))
+		assert.Equal(t, time.Date(2024, 2, 11, 23, 0, 0, 0, time.UTC), workStart.UTC())
+		assert.Equal(t, time.Date(2024, 2, 12, 8, 0, 0, 0, time.UTC), workEnd.UTC())
+
+		// Неизвестный часовой пояс и пустые часы - значения по умолчанию
+		assert.Equal(t, DefaultTimezone, LoadTimezone("Mars/Olympus").String())
+		installer.WorkingHoursStart, installer.WorkingHoursEnd = "", ""
+		workStart, _ = installer.WorkingHoursOn(start.In(moscow))
+		assert.Equal(t, 9, workStart.Hour())
+	})
+
 	t.Run("Метод HasSpecialization", func(t *testing.T) {
 		installer := Installer{
 			FirstName:      "Сергей",
@@ -0,0 +1,158 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Типы событий, на которые можно подписаться
+const (
+	WebhookEventObjectCreated         = "object.created"
+	WebhookEventObjectUpdated         = "object.updated"
+	WebhookEventObjectDeleted         = "object.deleted"
+	WebhookEventContractCreated       = "contract.created"
+	WebhookEventContractUpdated       = "contract.updated"
+	WebhookEventInvoiceCreated        = "invoice.created"
+	WebhookEventInvoicePaid           = "invoice.paid"
+	WebhookEventInvoiceCancelled      = "invoice.cancelled"
+	WebhookEventInstallationCompleted = "installation.completed"
+	WebhookEventInstallationSLAAtRisk = "installation.sla_at_risk"
+	WebhookEventInstallationSLABreach = "installation.sla_breached"
+	WebhookEventServiceRequestCreated = "service_request.created"
+	WebhookEventServiceRequestUpdated = "service_request.updated"
+	WebhookEventStockLow              = "stock.low"
+	WebhookEventTest                  = "webhook.test"
+
+	// WebhookEventAll подписка на все события
+	WebhookEventAll = "*"
+)
+
+// WebhookEventTypes список поддерживаемых событий
+var WebhookEventTypes = []string{
+	WebhookEventObjectCreated,
+	WebhookEventObjectUpdated,
+	WebhookEventObjectDeleted,
+	WebhookEventContractCreated,
+	WebhookEventContractUpdated,
+	WebhookEventInvoiceCreated,
+	WebhookEventInvoicePaid,
+	WebhookEventInvoiceCancelled,
+	WebhookEventInstallationCompleted,
+	WebhookEventInstallationSLAAtRisk,
+	WebhookEventInstallationSLABreach,
+	WebhookEventServiceRequestCreated,
+	WebhookEventServiceRequestUpdated,
+	WebhookEventStockLow,
+}
+
+// IsValidWebhookEvent проверяет, поддерживается ли тип события
+func IsValidWebhookEvent(eventType string) bool {
+	if eventType == WebhookEventAll {
+		return true
+	}
+	for _, known := range WebhookEventTypes {
+		if known == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// Статусы доставки вебхука
+const (
+	WebhookDeliveryPending = "pending" // Ожидает отправки или повторной попытки
+	WebhookDeliverySuccess = "success" // Получатель ответил 2xx
+	WebhookDeliveryFailed  = "failed"  // Исчерпаны попытки доставки
+)
+
+// WebhookSubscription подписка компании на события для внешней системы
+type WebhookSubscription struct {
+	ID        uint           `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+
+	CompanyID uuid.UUID `json:"company_id" gorm:"type:uuid;not null;index"`
+
+	Name        string `json:"name" gorm:"not null;type:varchar(200)"`
+	URL         string `json:"url" gorm:"not null;type:varchar(500)"`
+	Secret      string `json:"-" gorm:"not null;type:varchar(100)"` // Ключ подписи HMAC-SHA256
+	EventTypes  string `json:"event_types" gorm:"type:text"`        // Типы событий через запятую, * - все
+	Description string `json:"description" gorm:"type:text"`
+	IsActive    bool   `json:"is_active" gorm:"default:true"`
+
+	// Параметры доставки
+	MaxAttempts    int `json:"max_attempts" gorm:"default:6"`
+	TimeoutSeconds int `json:"timeout_seconds" gorm:"default:10"`
+
+	// Состояние доставки
+	LastDeliveryAt      *time.Time `json:"last_delivery_at"`
+	LastSuccessAt       *time.Time `json:"last_success_at"`
+	LastError           string     `json:"last_error" gorm:"type:text"`
+	ConsecutiveFailures int        `json:"consecutive_failures" gorm:"default:0"`
+}
+
+// TableName задает имя таблицы для модели WebhookSubscription
+func (WebhookSubscription) TableName() string {
+	return "webhook_subscriptions"
+}
+
+// Events возвращает список событий подписки
+func (s *WebhookSubscription) Events() []string {
+	var events []string
+	for _, event := range strings.Split(s.EventTypes, ",") {
+		if event = strings.TrimSpace(event); event != "" {
+			events = append(events, event)
+		}
+	}
+	return events
+}
+
+// SetEvents сохраняет список событий подписки
+func (s *WebhookSubscription) SetEvents(events []string) {
+	s.EventTypes = strings.Join(events, ",")
+}
+
+// HandlesEvent проверяет, подписана ли подписка на событие
+func (s *WebhookSubscription) HandlesEvent(eventType string) bool {
+	for _, event := range s.Events() {
+		if event == WebhookEventAll || event == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDelivery попытка доставки события подписчику (журнал доставки)
+type WebhookDelivery struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	CompanyID      uuid.UUID            `json:"company_id" gorm:"type:uuid;not null;index"`
+	SubscriptionID uint                 `json:"subscription_id" gorm:"not null;index"`
+	Subscription   *WebhookSubscription `json:"subscription,omitempty" gorm:"foreignKey:SubscriptionID"`
+
+	EventID   string `json:"event_id" gorm:"type:varchar(36);not null;index"`
+	EventType string `json:"event_type" gorm:"type:varchar(50);not null;index"`
+	Payload   string `json:"payload" gorm:"type:text"`
+
+	Status        string     `json:"status" gorm:"type:varchar(20);not null;default:'pending';index"`
+	Attempts      int        `json:"attempts" gorm:"default:0"`
+	NextAttemptAt *time.Time `json:"next_attempt_at" gorm:"index"`
+	DeliveredAt   *time.Time `json:"delivered_at"`
+
+	// Результат последней попытки
+	ResponseStatus int    `json:"response_status"`
+	ResponseBody   string `json:"response_body" gorm:"type:text"`
+	Error          string `json:"error" gorm:"type:text"`
+	DurationMs     int64  `json:"duration_ms"`
+}
+
+// TableName задает имя таблицы для модели WebhookDelivery
+func (WebhookDelivery) TableName() string {
+	return "webhook_deliveries"
+}
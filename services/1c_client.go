@@ -45,6 +45,7 @@ type OneCCounterparty struct {
 	IsFolder      bool   `json:"IsFolder"`      // Является ли папкой
 	Parent        string `json:"Parent_Key"`    // Ссылка на родителя
 	IsActive      bool   `json:"IsActive"`      // Активность
+	DeletionMark  bool   `json:"DeletionMark"`  // Пометка удаления
 }
 
 // OneCPayment платеж в 1С
@@ -85,6 +86,60 @@ type OneCContract struct {
 	ExternalID   string    `json:"ExternalID"`       // Внешний идентификатор
 }
 
+// OneCInvoice документ "Счет на оплату покупателю" в 1С
+type OneCInvoice struct {
+	ID                string            `json:"Ref_Key"`           // Уникальный ключ ссылки
+	Number            string            `json:"Number"`            // Номер документа
+	Date              time.Time         `json:"Date"`              // Дата документа
+	Organization      string            `json:"Organization_Key"`  // Организация
+	Counterparty      string            `json:"Counterparty_Key"`  // Контрагент
+	Contract          string            `json:"Contract_Key"`      // Договор
+	BankAccount       string            `json:"BankAccount_Key"`   // Банковский счет организации
+	Currency          string            `json:"Currency_Key"`      // Валюта
+	Amount            float64           `json:"Amount"`            // Сумма документа
+	VATAmount         float64           `json:"VATAmount"`         // Сумма НДС
+	AmountIncludesVAT bool              `json:"AmountIncludesVAT"` // Сумма включает НДС
+	PaymentDueDate    time.Time         `json:"PaymentDueDate"`    // Срок оплаты
+	Comment           string            `json:"Comment"`           // Комментарий
+	ExternalID        string            `json:"ExternalID"`        // Внешний идентификатор
+	Items             []OneCInvoiceItem `json:"Items"`             // Табличная часть "Товары/Услуги"
+}
+
+// OneCInvoiceItem строка табличной части счета в 1С
+type OneCInvoiceItem struct {
+	LineNumber int     `json:"LineNumber"` // Номер строки
+	Content    string  `json:"Content"`    // Содержание услуги
+	Quantity   float64 `json:"Quantity"`   // Количество
+	Price      float64 `json:"Price"`      // Цена
+	Amount     float64 `json:"Amount"`     // Сумма
+	VATRate    string  `json:"VATRate"`    // Ставка НДС (НДС20, НДС10, БезНДС)
+	VATAmount  float64 `json:"VATAmount"`  // Сумма НДС
+}
+
+// OneCPayrollStatement документ начисления вознаграждения монтажнику в 1С
+type OneCPayrollStatement struct {
+	ID            string            `json:"Ref_Key"`          // Уникальный ключ ссылки
+	Number        string            `json:"Number"`           // Номер документа
+	Date          time.Time         `json:"Date"`             // Дата документа
+	Organization  string            `json:"Organization_Key"` // Организация
+	DocumentKind  string            `json:"DocumentKind"`     // Salary - штатный сотрудник, ContractorAct - подрядчик
+	Employee      string            `json:"Employee"`         // ФИО монтажника
+	EmployeeID    string            `json:"EmployeeID"`       // Идентификатор монтажника в системе
+	Period        OneCPeriod        `json:"Period"`           // Расчетный период
+	Amount        float64           `json:"Amount"`           // Сумма к выплате
+	PenaltyAmount float64           `json:"PenaltyAmount"`    // Сумма удержаний
+	Comment       string            `json:"Comment"`          // Комментарий
+	ExternalID    string            `json:"ExternalID"`       // Внешний идентификатор
+	Items         []OneCPayrollItem `json:"Items"`            // Начисления по видам
+}
+
+// OneCPayrollItem строка начисления в документе 1С
+type OneCPayrollItem struct {
+	LineNumber int     `json:"LineNumber"` // Номер строки
+	Content    string  `json:"Content"`    // Вид начисления или удержания
+	Amount     float64 `json:"Amount"`     // Сумма, удержания - с минусом
+}
+
 // OneCPaymentRegistry реестр платежей для экспорта в 1С
 type OneCPaymentRegistry struct {
 	RegistryNumber string        `json:"RegistryNumber"` // Номер реестра
@@ -250,12 +305,9 @@ func (c *OneCClient) GetCounterparties(ctx context.Context, credentials *OneCCre
 	params := map[string]interface{}{
 		"limit":  limit,
 		"offset": offset,
-		"filter": map[string]interface{}{
-			"IsFolder": false, // Исключаем папки
-			"IsActive": true,  // Только активные
-		},
 	}
 
+	// Запрашиваем и папки, и неактивных контрагентов: они нужны для иерархии и деактивации клиентов
 	resp, err := c.CallMethod(ctx, credentials, "counterparties", params)
 	if err != nil {
 		return nil, 0, fmt.Errorf("ошибка получения списка контрагентов: %w", err)
@@ -296,9 +348,24 @@ func (c *OneCClient) GetCounterparties(ctx context.Context, credentials *OneCCre
 				if email, ok := itemData["Email"].(string); ok {
 					counterparty.Email = email
 				}
+				if legalAddress, ok := itemData["LegalAddress"].(string); ok {
+					counterparty.LegalAddress = legalAddress
+				}
+				if actualAddress, ok := itemData["ActualAddress"].(string); ok {
+					counterparty.ActualAddress = actualAddress
+				}
+				if isFolder, ok := itemData["IsFolder"].(bool); ok {
+					counterparty.IsFolder = isFolder
+				}
+				if parent, ok := itemData["Parent_Key"].(string); ok {
+					counterparty.Parent = parent
+				}
 				if isActive, ok := itemData["IsActive"].(bool); ok {
 					counterparty.IsActive = isActive
 				}
+				if deletionMark, ok := itemData["DeletionMark"].(bool); ok {
+					counterparty.DeletionMark = deletionMark
+				}
 
 				counterparties = append(counterparties, counterparty)
 			}
@@ -340,6 +407,194 @@ func (c *OneCClient) CreateCounterparty(ctx context.Context, credentials *OneCCr
 	return "", fmt.Errorf("неожиданный формат ответа при создании контрагента")
 }
 
+// FindCounterpartyByINN ищет контрагента в 1С по ИНН и КПП. Возвращает nil, если контрагент не найден
+func (c *OneCClient) FindCounterpartyByINN(ctx context.Context, credentials *OneCCredentials, inn, kpp string) (*OneCCounterparty, error) {
+	params := map[string]interface{}{
+		"INN": inn,
+	}
+	if kpp != "" {
+		params["KPP"] = kpp
+	}
+
+	resp, err := c.CallMethod(ctx, credentials, "counterparties/find", params)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка поиска контрагента: %w", err)
+	}
+
+	itemData, ok := resp.Data.(map[string]interface{})
+	if !ok {
+		// Поиск может вернуть список совпадений - берем первое
+		if dataList, ok := resp.Data.([]interface{}); ok && len(dataList) > 0 {
+			itemData, _ = dataList[0].(map[string]interface{})
+		}
+	}
+	if itemData == nil {
+		return nil, nil
+	}
+
+	counterparty := &OneCCounterparty{}
+	if id, ok := itemData["Ref_Key"].(string); ok {
+		counterparty.ID = id
+	}
+	if code, ok := itemData["Code"].(string); ok {
+		counterparty.Code = code
+	}
+	if desc, ok := itemData["Description"].(string); ok {
+		counterparty.Description = desc
+	}
+	if inn, ok := itemData["INN"].(string); ok {
+		counterparty.INN = inn
+	}
+	if kpp, ok := itemData["KPP"].(string); ok {
+		counterparty.KPP = kpp
+	}
+
+	if counterparty.ID == "" {
+		return nil, nil
+	}
+
+	return counterparty, nil
+}
+
+// SaveContract создает или обновляет договор контрагента в 1С и возвращает его Ref_Key
+func (c *OneCClient) SaveContract(ctx context.Context, credentials *OneCCredentials, contract *OneCContract) (string, error) {
+	params := map[string]interface{}{
+		"Code":             contract.Code,
+		"Description":      contract.Description,
+		"Organization_Key": contract.Organization,
+		"Counterparty_Key": contract.Counterparty,
+		"Currency_Key":     contract.Currency,
+		"ContractType":     contract.ContractType,
+		"StartDate":        contract.StartDate.Format("2006-01-02T15:04:05"),
+		"EndDate":          contract.EndDate.Format("2006-01-02T15:04:05"),
+		"Amount":           contract.Amount,
+		"IsActive":         contract.IsActive,
+		"Comment":          contract.Comment,
+		"ExternalID":       contract.ExternalID,
+	}
+	if contract.ID != "" {
+		params["Ref_Key"] = contract.ID
+	}
+
+	resp, err := c.CallMethod(ctx, credentials, "contracts/save", params)
+	if err != nil {
+		return "", fmt.Errorf("ошибка сохранения договора: %w", err)
+	}
+
+	refKey, err := extractOneCRefKey(resp)
+	if err != nil {
+		return "", fmt.Errorf("неожиданный формат ответа при сохранении договора: %w", err)
+	}
+
+	c.Logger.Printf("Договор сохранен в 1С: %s (%s)", contract.Code, refKey)
+	return refKey, nil
+}
+
+// SaveInvoice создает или обновляет документ "Счет на оплату покупателю" в 1С и возвращает его Ref_Key
+func (c *OneCClient) SaveInvoice(ctx context.Context, credentials *OneCCredentials, invoice *OneCInvoice) (string, error) {
+	items := make([]map[string]interface{}, 0, len(invoice.Items))
+	for _, item := range invoice.Items {
+		items = append(items, map[string]interface{}{
+			"LineNumber": item.LineNumber,
+			"Content":    item.Content,
+			"Quantity":   item.Quantity,
+			"Price":      item.Price,
+			"Amount":     item.Amount,
+			"VATRate":    item.VATRate,
+			"VATAmount":  item.VATAmount,
+		})
+	}
+
+	params := map[string]interface{}{
+		"Number":            invoice.Number,
+		"Date":              invoice.Date.Format("2006-01-02T15:04:05"),
+		"Organization_Key":  invoice.Organization,
+		"Counterparty_Key":  invoice.Counterparty,
+		"Contract_Key":      invoice.Contract,
+		"BankAccount_Key":   invoice.BankAccount,
+		"Currency_Key":      invoice.Currency,
+		"Amount":            invoice.Amount,
+		"VATAmount":         invoice.VATAmount,
+		"AmountIncludesVAT": invoice.AmountIncludesVAT,
+		"PaymentDueDate":    invoice.PaymentDueDate.Format("2006-01-02T15:04:05"),
+		"Comment":           invoice.Comment,
+		"ExternalID":        invoice.ExternalID,
+		"Items":             items,
+	}
+	if invoice.ID != "" {
+		params["Ref_Key"] = invoice.ID
+	}
+
+	resp, err := c.CallMethod(ctx, credentials, "invoices/save", params)
+	if err != nil {
+		return "", fmt.Errorf("ошибка сохранения счета: %w", err)
+	}
+
+	refKey, err := extractOneCRefKey(resp)
+	if err != nil {
+		return "", fmt.Errorf("неожиданный формат ответа при сохранении счета: %w", err)
+	}
+
+	c.Logger.Printf("Счет на оплату сохранен в 1С: %s (%s)", invoice.Number, refKey)
+	return refKey, nil
+}
+
+// SavePayrollStatement создает или обновляет документ начисления монтажнику в 1С и возвращает его Ref_Key
+func (c *OneCClient) SavePayrollStatement(ctx context.Context, credentials *OneCCredentials, statement *OneCPayrollStatement) (string, error) {
+	items := make([]map[string]interface{}, 0, len(statement.Items))
+	for _, item := range statement.Items {
+		items = append(items, map[string]interface{}{
+			"LineNumber": item.LineNumber,
+			"Content":    item.Content,
+			"Amount":     item.Amount,
+		})
+	}
+
+	params := map[string]interface{}{
+		"Number":           statement.Number,
+		"Date":             statement.Date.Format("2006-01-02T15:04:05"),
+		"Organization_Key": statement.Organization,
+		"DocumentKind":     statement.DocumentKind,
+		"Employee":         statement.Employee,
+		"EmployeeID":       statement.EmployeeID,
+		"Period": map[string]interface{}{
+			"StartDate": statement.Period.StartDate.Format("2006-01-02T15:04:05"),
+			"EndDate":   statement.Period.EndDate.Format("2006-01-02T15:04:05"),
+		},
+		"Amount":        statement.Amount,
+		"PenaltyAmount": statement.PenaltyAmount,
+		"Comment":       statement.Comment,
+		"ExternalID":    statement.ExternalID,
+		"Items":         items,
+	}
+	if statement.ID != "" {
+		params["Ref_Key"] = statement.ID
+	}
+
+	resp, err := c.CallMethod(ctx, credentials, "payroll/save", params)
+	if err != nil {
+		return "", fmt.Errorf("ошибка сохранения начисления: %w", err)
+	}
+
+	refKey, err := extractOneCRefKey(resp)
+	if err != nil {
+		return "", fmt.Errorf("неожиданный формат ответа при сохранении начисления: %w", err)
+	}
+
+	c.Logger.Printf("Начисление монтажнику сохранено в 1С: %s (%s)", statement.Number, refKey)
+	return refKey, nil
+}
+
+// extractOneCRefKey извлекает Ref_Key созданного или обновленного объекта из ответа 1С
+func extractOneCRefKey(resp *OneCResponse) (string, error) {
+	if resultData, ok := resp.Data.(map[string]interface{}); ok {
+		if refKey, ok := resultData["Ref_Key"].(string); ok && refKey != "" {
+			return refKey, nil
+		}
+	}
+	return "", fmt.Errorf("в ответе отсутствует Ref_Key")
+}
+
 // ExportPaymentRegistry экспортирует реестр платежей в 1С
 func (c *OneCClient) ExportPaymentRegistry(ctx context.Context, credentials *OneCCredentials, registry *OneCPaymentRegistry) error {
 	params := map[string]interface{}{
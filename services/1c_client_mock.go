@@ -392,15 +392,7 @@ func (m *OneCClientMock) GetCounterparties(ctx context.Context, credentials *One
 		return nil, 0, fmt.Errorf("мок ошибка: %s", m.FailureMessage)
 	}
 
-	// Фильтруем активные контрагенты
-	var activeCounterparties []OneCCounterparty
-	for _, cp := range m.Counterparties {
-		if cp.IsActive {
-			activeCounterparties = append(activeCounterparties, cp)
-		}
-	}
-
-	total := len(activeCounterparties)
+	total := len(m.Counterparties)
 	end := offset + limit
 	if end > total {
 		end = total
@@ -410,7 +402,7 @@ func (m *OneCClientMock) GetCounterparties(ctx context.Context, credentials *One
 		return []OneCCounterparty{}, total, nil
 	}
 
-	return activeCounterparties[offset:end], total, nil
+	return m.Counterparties[offset:end], total, nil
 }
 
 // CreateCounterparty создает контрагента (мок)
@@ -434,6 +426,46 @@ func (m *OneCClientMock) CreateCounterparty(ctx context.Context, credentials *On
 	return newID, nil
 }
 
+// FindCounterpartyByINN ищет контрагента по ИНН и КПП (мок)
+func (m *OneCClientMock) FindCounterpartyByINN(ctx context.Context, credentials *OneCCredentials, inn, kpp string) (*OneCCounterparty, error) {
+	if m.ShouldFail {
+		return nil, fmt.Errorf("мок ошибка: %s", m.FailureMessage)
+	}
+
+	for i := range m.Counterparties {
+		cp := &m.Counterparties[i]
+		if cp.INN == inn && (kpp == "" || cp.KPP == kpp) {
+			return cp, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// SaveContract сохраняет договор (мок)
+func (m *OneCClientMock) SaveContract(ctx context.Context, credentials *OneCCredentials, contract *OneCContract) (string, error) {
+	if m.ShouldFail {
+		return "", fmt.Errorf("мок ошибка: %s", m.FailureMessage)
+	}
+
+	if contract.ID != "" {
+		return contract.ID, nil
+	}
+	return fmt.Sprintf("contract-%s", contract.ExternalID), nil
+}
+
+// SaveInvoice сохраняет счет на оплату (мок)
+func (m *OneCClientMock) SaveInvoice(ctx context.Context, credentials *OneCCredentials, invoice *OneCInvoice) (string, error) {
+	if m.ShouldFail {
+		return "", fmt.Errorf("мок ошибка: %s", m.FailureMessage)
+	}
+
+	if invoice.ID != "" {
+		return invoice.ID, nil
+	}
+	return fmt.Sprintf("invoice-%s", invoice.ExternalID), nil
+}
+
 // ExportPaymentRegistry экспортирует реестр платежей (мок)
 func (m *OneCClientMock) ExportPaymentRegistry(ctx context.Context, credentials *OneCCredentials, registry *OneCPaymentRegistry) error {
 	if m.ShouldFail {
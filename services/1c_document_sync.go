@@ -0,0 +1,475 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"backend_axenta/models"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// Типы сущностей, выгружаемых в 1С
+const (
+	OneCEntityContract = "contract"
+	OneCEntityInvoice  = "invoice"
+	OneCEntityPayroll  = "payroll_statement"
+)
+
+// OneCDocumentSyncResult итоги выгрузки документов в 1С
+type OneCDocumentSyncResult struct {
+	ContractsExported int      `json:"contracts_exported"`
+	InvoicesExported  int      `json:"invoices_exported"`
+	Skipped           int      `json:"skipped"` // Не изменились с последней выгрузки
+	Errors            []string `json:"errors"`
+}
+
+// ExportContract выгружает договор в 1С, привязывая его к контрагенту по ИНН/КПП.
+// Без force повторная выгрузка выполняется только если данные договора изменились.
+func (s *OneCIntegrationService) ExportContract(ctx context.Context, companyID uuid.UUID, contract *models.Contract, force bool) (string, error) {
+	externalID, _, err := s.exportContract(ctx, companyID, contract, force)
+	return externalID, err
+}
+
+// exportContract выгружает договор и сообщает, был ли выполнен вызов 1С
+func (s *OneCIntegrationService) exportContract(ctx context.Context, companyID uuid.UUID, contract *models.Contract, force bool) (string, bool, error) {
+	credentials, err := s.GetCredentials(ctx, companyID)
+	if err != nil {
+		return "", false, err
+	}
+
+	config, err := s.getConfig(ctx, companyID)
+	if err != nil {
+		return "", false, err
+	}
+
+	counterpartyKey, err := s.resolveCounterparty(ctx, credentials, contract)
+	if err != nil {
+		s.logError(ctx, companyID, "export_contract", OneCEntityContract, fmt.Sprint(contract.ID), "COUNTERPARTY_ERROR", err.Error(), nil, nil)
+		return "", false, err
+	}
+
+	oneCContract := &OneCContract{
+		Code:         contract.Number,
+		Description:  fmt.Sprintf("Договор № %s от %s", contract.Number, contract.StartDate.Format("02.01.2006")),
+		Organization: config.OrganizationCode,
+		Counterparty: counterpartyKey,
+		Currency:     oneCCurrency(contract.Currency, config.CurrencyCode),
+		ContractType: config.ContractTypeCode,
+		StartDate:    contract.StartDate,
+		EndDate:      contract.EndDate,
+		Amount:       contract.TotalAmount.InexactFloat64(),
+		IsActive:     contract.IsActive && contract.Status != "cancelled",
+		Comment:      contract.Title,
+		ExternalID:   fmt.Sprintf("contract_%d", contract.ID),
+	}
+
+	mapping, err := s.getSyncMapping(companyID, OneCEntityContract, contract.ID)
+	if err != nil {
+		return "", false, err
+	}
+
+	checksum := oneCChecksum(oneCContract)
+	if mapping != nil {
+		if !force && mapping.Checksum == checksum {
+			return mapping.ExternalID, false, nil
+		}
+		oneCContract.ID = mapping.ExternalID
+	}
+
+	refKey, err := s.oneCClient.SaveContract(ctx, credentials, oneCContract)
+	if err != nil {
+		s.logError(ctx, companyID, "export_contract", OneCEntityContract, fmt.Sprint(contract.ID), "EXPORT_ERROR", err.Error(), oneCContract, nil)
+		return "", false, fmt.Errorf("ошибка выгрузки договора %s в 1С: %w", contract.Number, err)
+	}
+
+	if err := s.saveSyncMapping(companyID, OneCEntityContract, contract.ID, refKey, checksum, mapping); err != nil {
+		return "", false, err
+	}
+
+	if contract.ExternalID != refKey {
+		contract.ExternalID = refKey
+		if err := s.db.Model(contract).UpdateColumn("external_id", refKey).Error; err != nil {
+			s.logger.Printf("Ошибка сохранения внешнего ID договора %s: %v", contract.Number, err)
+		}
+	}
+
+	s.logger.Printf("Договор %s выгружен в 1С: %s", contract.Number, refKey)
+	return refKey, true, nil
+}
+
+// ExportInvoice выгружает счет в 1С как документ "Счет на оплату покупателю" с позициями.
+// Договор счета выгружается предварительно, если он еще не был выгружен или изменился.
+func (s *OneCIntegrationService) ExportInvoice(ctx context.Context, companyID uuid.UUID, invoice *models.Invoice, force bool) (string, error) {
+	externalID, _, err := s.exportInvoice(ctx, companyID, invoice, force)
+	return externalID, err
+}
+
+// exportInvoice выгружает счет и сообщает, был ли выполнен вызов 1С
+func (s *OneCIntegrationService) exportInvoice(ctx context.Context, companyID uuid.UUID, invoice *models.Invoice, force bool) (string, bool, error) {
+	if invoice.ContractID == nil {
+		return "", false, fmt.Errorf("счет %s не привязан к договору, невозможно определить контрагента", invoice.Number)
+	}
+
+	var contract models.Contract
+	if err := s.db.First(&contract, *invoice.ContractID).Error; err != nil {
+		return "", false, fmt.Errorf("договор счета %s не найден: %w", invoice.Number, err)
+	}
+
+	contractKey, _, err := s.exportContract(ctx, companyID, &contract, false)
+	if err != nil {
+		return "", false, err
+	}
+
+	credentials, err := s.GetCredentials(ctx, companyID)
+	if err != nil {
+		return "", false, err
+	}
+
+	config, err := s.getConfig(ctx, companyID)
+	if err != nil {
+		return "", false, err
+	}
+
+	counterpartyKey, err := s.resolveCounterparty(ctx, credentials, &contract)
+	if err != nil {
+		return "", false, err
+	}
+
+	items := invoice.Items
+	if len(items) == 0 {
+		if err := s.db.Where("invoice_id = ?", invoice.ID).Order("id").Find(&items).Error; err != nil {
+			return "", false, fmt.Errorf("ошибка получения позиций счета: %w", err)
+		}
+	}
+
+	oneCInvoice := &OneCInvoice{
+		Number:         invoice.Number,
+		Date:           invoice.InvoiceDate,
+		Organization:   config.OrganizationCode,
+		Counterparty:   counterpartyKey,
+		Contract:       contractKey,
+		BankAccount:    config.BankAccountCode,
+		Currency:       oneCCurrency(invoice.Currency, config.CurrencyCode),
+		Amount:         invoice.TotalAmount.InexactFloat64(),
+		VATAmount:      invoice.TaxAmount.InexactFloat64(),
+		PaymentDueDate: invoice.DueDate,
+		Comment:        invoice.Title,
+		ExternalID:     fmt.Sprintf("invoice_%d", invoice.ID),
+	}
+
+	vatRate := oneCVATRate(invoice.TaxRate.IntPart())
+	for i, item := range items {
+		amount := item.Amount.InexactFloat64()
+		var vatAmount float64
+		if invoice.TaxRate.IsPositive() {
+			vatAmount = item.Amount.Mul(invoice.TaxRate).Div(decimal.NewFromInt(100)).Round(2).InexactFloat64()
+		}
+
+		oneCInvoice.Items = append(oneCInvoice.Items, OneCInvoiceItem{
+			LineNumber: i + 1,
+			Content:    item.Name,
+			Quantity:   item.Quantity.InexactFloat64(),
+			Price:      item.UnitPrice.InexactFloat64(),
+			Amount:     amount,
+			VATRate:    vatRate,
+			VATAmount:  vatAmount,
+		})
+	}
+
+	mapping, err := s.getSyncMapping(companyID, OneCEntityInvoice, invoice.ID)
+	if err != nil {
+		return "", false, err
+	}
+
+	checksum := oneCChecksum(oneCInvoice)
+	if mapping != nil {
+		if !force && mapping.Checksum == checksum {
+			return mapping.ExternalID, false, nil
+		}
+		oneCInvoice.ID = mapping.ExternalID
+	}
+
+	refKey, err := s.oneCClient.SaveInvoice(ctx, credentials, oneCInvoice)
+	if err != nil {
+		s.logError(ctx, companyID, "export_invoice", OneCEntityInvoice, fmt.Sprint(invoice.ID), "EXPORT_ERROR", err.Error(), oneCInvoice, nil)
+		return "", false, fmt.Errorf("ошибка выгрузки счета %s в 1С: %w", invoice.Number, err)
+	}
+
+	if err := s.saveSyncMapping(companyID, OneCEntityInvoice, invoice.ID, refKey, checksum, mapping); err != nil {
+		return "", false, err
+	}
+
+	if invoice.ExternalID != refKey {
+		invoice.ExternalID = refKey
+		if err := s.db.Model(invoice).UpdateColumn("external_id", refKey).Error; err != nil {
+			s.logger.Printf("Ошибка сохранения внешнего ID счета %s: %v", invoice.Number, err)
+		}
+	}
+
+	s.logger.Printf("Счет %s выгружен в 1С: %s", invoice.Number, refKey)
+	return refKey, true, nil
+}
+
+// ExportPayrollStatement выгружает утвержденный расчетный лист монтажника в 1С: штатным сотрудникам -
+// как начисление зарплаты, подрядчикам и партнерам - как акт выполненных работ подрядчика.
+// Без force повторная выгрузка выполняется только если расчетный лист изменился.
+func (s *OneCIntegrationService) ExportPayrollStatement(ctx context.Context, companyID uuid.UUID, statement *models.PayrollStatement, force bool) (string, error) {
+	if statement.Status != models.PayrollStatusApproved && statement.Status != models.PayrollStatusPaid {
+		return "", fmt.Errorf("в 1С выгружаются только утвержденные расчетные листы")
+	}
+
+	credentials, err := s.GetCredentials(ctx, companyID)
+	if err != nil {
+		return "", err
+	}
+
+	config, err := s.getConfig(ctx, companyID)
+	if err != nil {
+		return "", err
+	}
+
+	documentKind := "ContractorAct"
+	if statement.InstallerType == "staff" {
+		documentKind = "Salary"
+	}
+	date := statement.PeriodEnd.AddDate(0, 0, -1)
+	if statement.ApprovedAt != nil {
+		date = *statement.ApprovedAt
+	}
+
+	oneCStatement := &OneCPayrollStatement{
+		Number:        statement.Number,
+		Date:          date,
+		Organization:  config.OrganizationCode,
+		DocumentKind:  documentKind,
+		Employee:      statement.InstallerName,
+		EmployeeID:    fmt.Sprintf("installer_%d", statement.InstallerID),
+		Period:        OneCPeriod{StartDate: statement.PeriodStart, EndDate: statement.PeriodEnd.AddDate(0, 0, -1)},
+		Amount:        statement.TotalAmount.InexactFloat64(),
+		PenaltyAmount: statement.PenaltyAmount.InexactFloat64(),
+		Comment:       fmt.Sprintf("Монтажей: %d", statement.InstallationsCount),
+		ExternalID:    fmt.Sprintf("payroll_%d", statement.ID),
+	}
+	for _, item := range []struct {
+		content string
+		amount  decimal.Decimal
+	}{
+		{"Сдельная оплата", statement.PieceAmount},
+		{"Повременная оплата", statement.HourlyAmount},
+		{"Компенсация времени в пути", statement.TravelAmount},
+		{"Удержание за качество работ", statement.PenaltyAmount.Neg()},
+	} {
+		if item.amount.IsZero() {
+			continue
+		}
+		oneCStatement.Items = append(oneCStatement.Items, OneCPayrollItem{
+			LineNumber: len(oneCStatement.Items) + 1,
+			Content:    item.content,
+			Amount:     item.amount.InexactFloat64(),
+		})
+	}
+
+	mapping, err := s.getSyncMapping(companyID, OneCEntityPayroll, statement.ID)
+	if err != nil {
+		return "", err
+	}
+
+	checksum := oneCChecksum(oneCStatement)
+	if mapping != nil {
+		if !force && mapping.Checksum == checksum {
+			return mapping.ExternalID, nil
+		}
+		oneCStatement.ID = mapping.ExternalID
+	}
+
+	refKey, err := s.oneCClient.SavePayrollStatement(ctx, credentials, oneCStatement)
+	if err != nil {
+		s.logError(ctx, companyID, "export_payroll", OneCEntityPayroll, fmt.Sprint(statement.ID), "EXPORT_ERROR", err.Error(), oneCStatement, nil)
+		return "", fmt.Errorf("ошибка выгрузки расчетного листа %s в 1С: %w", statement.Number, err)
+	}
+
+	if err := s.saveSyncMapping(companyID, OneCEntityPayroll, statement.ID, refKey, checksum, mapping); err != nil {
+		return "", err
+	}
+
+	if statement.ExternalID != refKey {
+		statement.ExternalID = refKey
+		if err := s.db.Model(statement).UpdateColumn("external_id", refKey).Error; err != nil {
+			s.logger.Printf("Ошибка сохранения внешнего ID расчетного листа %s: %v", statement.Number, err)
+		}
+	}
+
+	s.logger.Printf("Расчетный лист %s выгружен в 1С: %s", statement.Number, refKey)
+	return refKey, nil
+}
+
+// SyncDocuments выгружает в 1С все договоры и выставленные счета компании,
+// изменившиеся с момента последней выгрузки
+func (s *OneCIntegrationService) SyncDocuments(ctx context.Context, companyID uuid.UUID) (*OneCDocumentSyncResult, error) {
+	result := &OneCDocumentSyncResult{}
+
+	var contracts []models.Contract
+	if err := s.db.Where("company_id = ? AND status <> ?", companyID, "draft").Find(&contracts).Error; err != nil {
+		return nil, fmt.Errorf("ошибка получения договоров: %w", err)
+	}
+
+	for i := range contracts {
+		_, exported, err := s.exportContract(ctx, companyID, &contracts[i], false)
+		switch {
+		case err != nil:
+			result.Errors = append(result.Errors, err.Error())
+		case exported:
+			result.ContractsExported++
+		default:
+			result.Skipped++
+		}
+	}
+
+	var invoices []models.Invoice
+	if err := s.db.Preload("Items").
+		Where("company_id = ? AND status NOT IN (?) AND contract_id IS NOT NULL", companyID, []string{"draft", "cancelled"}).
+		Find(&invoices).Error; err != nil {
+		return nil, fmt.Errorf("ошибка получения счетов: %w", err)
+	}
+
+	for i := range invoices {
+		_, exported, err := s.exportInvoice(ctx, companyID, &invoices[i], false)
+		switch {
+		case err != nil:
+			result.Errors = append(result.Errors, err.Error())
+		case exported:
+			result.InvoicesExported++
+		default:
+			result.Skipped++
+		}
+	}
+
+	s.logger.Printf("Выгрузка документов в 1С для компании %s: договоров %d, счетов %d, без изменений %d, ошибок %d",
+		companyID.String(), result.ContractsExported, result.InvoicesExported, result.Skipped, len(result.Errors))
+
+	return result, nil
+}
+
+// resolveCounterparty находит контрагента договора в 1С по ИНН/КПП или создает нового.
+// Для клиентов, импортированных из 1С, используется сохраненная ссылка на контрагента.
+func (s *OneCIntegrationService) resolveCounterparty(ctx context.Context, credentials *OneCCredentials, contract *models.Contract) (string, error) {
+	var client *models.Client
+	if contract.ClientID != nil {
+		var linked models.Client
+		if err := s.db.First(&linked, *contract.ClientID).Error; err == nil {
+			if linked.ExternalSource == "1c" && linked.ExternalID != "" {
+				return linked.ExternalID, nil
+			}
+			client = &linked
+		}
+	}
+
+	if contract.ClientINN == "" {
+		return "", fmt.Errorf("у клиента договора %s не указан ИНН", contract.Number)
+	}
+
+	counterparty, err := s.oneCClient.FindCounterpartyByINN(ctx, credentials, contract.ClientINN, contract.ClientKPP)
+	if err != nil {
+		return "", err
+	}
+	if counterparty != nil {
+		s.linkClientToOneC(client, counterparty.ID)
+		return counterparty.ID, nil
+	}
+
+	refKey, err := s.oneCClient.CreateCounterparty(ctx, credentials, &OneCCounterparty{
+		Description:  contract.ClientName,
+		FullName:     contract.ClientName,
+		INN:          contract.ClientINN,
+		KPP:          contract.ClientKPP,
+		LegalAddress: contract.ClientAddress,
+		Phone:        contract.ClientPhone,
+		Email:        contract.ClientEmail,
+		IsActive:     true,
+	})
+	if err != nil {
+		return "", fmt.Errorf("ошибка создания контрагента %s в 1С: %w", contract.ClientName, err)
+	}
+
+	s.linkClientToOneC(client, refKey)
+	return refKey, nil
+}
+
+// linkClientToOneC запоминает ссылку на контрагента 1С у клиента без внешней привязки
+func (s *OneCIntegrationService) linkClientToOneC(client *models.Client, refKey string) {
+	if client == nil || client.ExternalID != "" {
+		return
+	}
+
+	if err := s.db.Model(client).Updates(map[string]interface{}{
+		"external_id":     refKey,
+		"external_source": "1c",
+	}).Error; err != nil {
+		s.logger.Printf("Ошибка сохранения ссылки на контрагента 1С для клиента %s: %v", client.Name, err)
+	}
+}
+
+// getSyncMapping возвращает связь сущности с 1С или nil, если сущность еще не выгружалась
+func (s *OneCIntegrationService) getSyncMapping(companyID uuid.UUID, entityType string, entityID uint) (*models.OneCSyncMapping, error) {
+	var mapping models.OneCSyncMapping
+	err := s.db.Where("company_id = ? AND entity_type = ? AND entity_id = ?", companyID, entityType, entityID).
+		First(&mapping).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения связи с 1С: %w", err)
+	}
+	return &mapping, nil
+}
+
+// saveSyncMapping создает или обновляет связь сущности с 1С
+func (s *OneCIntegrationService) saveSyncMapping(companyID uuid.UUID, entityType string, entityID uint, externalID, checksum string, mapping *models.OneCSyncMapping) error {
+	if mapping == nil {
+		mapping = &models.OneCSyncMapping{
+			CompanyID:  companyID,
+			EntityType: entityType,
+			EntityID:   entityID,
+		}
+	}
+
+	mapping.ExternalID = externalID
+	mapping.Checksum = checksum
+	mapping.SyncedAt = time.Now()
+
+	if err := s.db.Save(mapping).Error; err != nil {
+		return fmt.Errorf("ошибка сохранения связи с 1С: %w", err)
+	}
+	return nil
+}
+
+// oneCChecksum вычисляет контрольную сумму выгружаемых данных для обнаружения изменений
+func oneCChecksum(payload interface{}) string {
+	data, _ := json.Marshal(payload)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// oneCCurrency возвращает код валюты документа или валюту из настроек интеграции
+func oneCCurrency(currency, defaultCurrency string) string {
+	if currency != "" {
+		return currency
+	}
+	return defaultCurrency
+}
+
+// oneCVATRate преобразует ставку НДС в процентах в значение перечисления 1С
+func oneCVATRate(rate int64) string {
+	if rate <= 0 {
+		return "БезНДС"
+	}
+	return fmt.Sprintf("НДС%d", rate)
+}
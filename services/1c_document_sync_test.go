@@ -0,0 +1,233 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"backend_axenta/models"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// fakeOneCServer HTTP-сервис 1С, запоминающий выгруженные документы
+type fakeOneCServer struct {
+	mu             sync.Mutex
+	calls          map[string]int
+	counterparties []map[string]interface{}
+	contracts      []map[string]interface{}
+	invoices       []map[string]interface{}
+	payroll        []map[string]interface{}
+}
+
+func (f *fakeOneCServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var params map[string]interface{}
+	json.NewDecoder(r.Body).Decode(&params)
+
+	method := r.URL.Path[strings.Index(r.URL.Path, "/hs/api/v1/")+len("/hs/api/v1/"):]
+	f.calls[method]++
+
+	var data interface{}
+	total := 0
+	switch method {
+	case "counterparties":
+		data, total = f.counterparties, len(f.counterparties)
+	case "counterparties/find":
+		if params["INN"] == "7701234567" {
+			data = map[string]interface{}{"Ref_Key": "cp-ref-1", "INN": "7701234567", "KPP": "770101001"}
+		}
+	case "contracts/save":
+		f.contracts = append(f.contracts, params)
+		data = map[string]interface{}{"Ref_Key": "contract-ref-1"}
+	case "invoices/save":
+		f.invoices = append(f.invoices, params)
+		data = map[string]interface{}{"Ref_Key": "invoice-ref-1"}
+	case "payroll/save":
+		f.payroll = append(f.payroll, params)
+		data = map[string]interface{}{"Ref_Key": "payroll-ref-1"}
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":  true,
+		"data":     data,
+		"metadata": map[string]interface{}{"total": total},
+	})
+}
+
+func setupOneCDocumentSyncTest(t *testing.T) (*gorm.DB, *OneCIntegrationService, *fakeOneCServer, uuid.UUID) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+
+	// Таблица компаний создается вручную: значение по умолчанию для UUID не поддерживается SQLite
+	require.NoError(t, db.Exec(`CREATE TABLE companies (id TEXT PRIMARY KEY, name TEXT)`).Error)
+	require.NoError(t, db.AutoMigrate(&models.Integration{}, &models.Client{}, &models.Contract{}, &models.Invoice{}, &models.InvoiceItem{}, &models.OneCSyncMapping{}, &OneCIntegrationError{}))
+
+	fake := &fakeOneCServer{calls: map[string]int{}}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	companyID := uuid.New()
+	settings, _ := json.Marshal(OneCIntegrationConfig{
+		CompanyID:        companyID,
+		BaseURL:          server.URL,
+		Database:         "testdb",
+		APIVersion:       "v1",
+		OrganizationCode: "ORG001",
+		BankAccountCode:  "BANK001",
+		ContractTypeCode: "СПокупателем",
+		CurrencyCode:     "RUB",
+	})
+	require.NoError(t, db.Create(&models.Integration{
+		CompanyID:       companyID,
+		IntegrationType: "1c",
+		Name:            "1С",
+		Settings:        string(settings),
+		IsActive:        true,
+	}).Error)
+
+	logger := log.New(os.Stdout, "TEST: ", log.LstdFlags)
+	service := NewOneCIntegrationService(db, NewOneCClient(logger), nil, logger)
+
+	return db, service, fake, companyID
+}
+
+func TestOneCIntegrationService_ExportInvoice(t *testing.T) {
+	db, service, fake, companyID := setupOneCDocumentSyncTest(t)
+	ctx := context.Background()
+
+	contract := &models.Contract{
+		Number:     "Д-001",
+		Title:      "Мониторинг транспорта",
+		CompanyID:  companyID,
+		ClientName: "ООО Ромашка",
+		ClientINN:  "7701234567",
+		ClientKPP:  "770101001",
+		StartDate:  time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+		EndDate:    time.Date(2026, 12, 31, 0, 0, 0, 0, time.UTC),
+		Status:     "active",
+		IsActive:   true,
+	}
+	require.NoError(t, db.Create(contract).Error)
+
+	invoice := &models.Invoice{
+		Number:         "INV-001",
+		Title:          "Абонентская плата",
+		InvoiceDate:    time.Now(),
+		DueDate:        time.Now().AddDate(0, 0, 10),
+		CompanyID:      companyID,
+		ContractID:     &contract.ID,
+		SubtotalAmount: decimal.NewFromInt(1000),
+		TaxRate:        decimal.NewFromInt(20),
+		TaxAmount:      decimal.NewFromInt(200),
+		TotalAmount:    decimal.NewFromInt(1200),
+		Status:         "sent",
+		Items: []models.InvoiceItem{
+			{Name: "Мониторинг", ItemType: "subscription", Quantity: decimal.NewFromInt(2), UnitPrice: decimal.NewFromInt(500), Amount: decimal.NewFromInt(1000)},
+		},
+	}
+	require.NoError(t, db.Create(invoice).Error)
+
+	refKey, err := service.ExportInvoice(ctx, companyID, invoice, false)
+	require.NoError(t, err)
+	assert.Equal(t, "invoice-ref-1", refKey)
+
+	// Договор выгружен и привязан к найденному по ИНН контрагенту
+	require.Len(t, fake.contracts, 1)
+	assert.Equal(t, "cp-ref-1", fake.contracts[0]["Counterparty_Key"])
+
+	require.Len(t, fake.invoices, 1)
+	assert.Equal(t, "contract-ref-1", fake.invoices[0]["Contract_Key"])
+	items := fake.invoices[0]["Items"].([]interface{})
+	require.Len(t, items, 1)
+	assert.Equal(t, "НДС20", items[0].(map[string]interface{})["VATRate"])
+
+	var stored models.Invoice
+	require.NoError(t, db.First(&stored, invoice.ID).Error)
+	assert.Equal(t, "invoice-ref-1", stored.ExternalID)
+
+	var mappings int64
+	db.Model(&models.OneCSyncMapping{}).Where("company_id = ?", companyID).Count(&mappings)
+	assert.Equal(t, int64(2), mappings)
+
+	// Повторная синхронизация без изменений ничего не выгружает
+	result, err := service.SyncDocuments(ctx, companyID)
+	require.NoError(t, err)
+	assert.Equal(t, 0, result.ContractsExported)
+	assert.Equal(t, 0, result.InvoicesExported)
+	assert.Equal(t, 2, result.Skipped)
+
+	// Изменение счета приводит к повторной выгрузке с тем же Ref_Key
+	require.NoError(t, db.Model(&models.Invoice{}).Where("id = ?", invoice.ID).Update("title", "Абонентская плата за март").Error)
+	result, err = service.SyncDocuments(ctx, companyID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.InvoicesExported)
+	require.Len(t, fake.invoices, 2)
+	assert.Equal(t, "invoice-ref-1", fake.invoices[1]["Ref_Key"])
+}
+
+func TestOneCIntegrationService_ExportPayrollStatement(t *testing.T) {
+	db, service, fake, companyID := setupOneCDocumentSyncTest(t)
+	require.NoError(t, db.AutoMigrate(&models.PayrollStatement{}))
+	ctx := context.Background()
+
+	statement := &models.PayrollStatement{
+		Number:             "РЛ-202603-0007",
+		Status:             models.PayrollStatusDraft,
+		InstallerID:        7,
+		InstallerName:      "Иванов Петр",
+		InstallerType:      "contractor",
+		PeriodStart:        time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC),
+		PeriodEnd:          time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC),
+		InstallationsCount: 3,
+		PieceAmount:        decimal.NewFromInt(4500),
+		TravelAmount:       decimal.NewFromInt(600),
+		PenaltyAmount:      decimal.NewFromInt(300),
+		TotalAmount:        decimal.NewFromInt(4800),
+	}
+	require.NoError(t, db.Create(statement).Error)
+
+	// Черновик в 1С не выгружается
+	_, err := service.ExportPayrollStatement(ctx, companyID, statement, false)
+	require.Error(t, err)
+	assert.Empty(t, fake.payroll)
+
+	statement.Status = models.PayrollStatusApproved
+	refKey, err := service.ExportPayrollStatement(ctx, companyID, statement, false)
+	require.NoError(t, err)
+	assert.Equal(t, "payroll-ref-1", refKey)
+
+	require.Len(t, fake.payroll, 1)
+	assert.Equal(t, "ContractorAct", fake.payroll[0]["DocumentKind"])
+	assert.Equal(t, "ORG001", fake.payroll[0]["Organization_Key"])
+	assert.Equal(t, 4800.0, fake.payroll[0]["Amount"])
+	items := fake.payroll[0]["Items"].([]interface{})
+	require.Len(t, items, 3)
+	assert.Equal(t, -300.0, items[2].(map[string]interface{})["Amount"])
+
+	var stored models.PayrollStatement
+	require.NoError(t, db.First(&stored, statement.ID).Error)
+	assert.Equal(t, "payroll-ref-1", stored.ExternalID)
+
+	// Повторная выгрузка без изменений не обращается к 1С
+	_, err = service.ExportPayrollStatement(ctx, companyID, statement, false)
+	require.NoError(t, err)
+	assert.Len(t, fake.payroll, 1)
+}
@@ -5,7 +5,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
-	"strconv"
 	"time"
 
 	"backend_axenta/models"
@@ -201,7 +200,8 @@ func (s *OneCIntegrationService) ExportPaymentRegistry(ctx context.Context, comp
 	return nil
 }
 
-// ImportCounterparties импортирует контрагентов из 1С
+// ImportCounterparties импортирует контрагентов из 1С в справочник клиентов.
+// Папки 1С становятся группами клиентов, неактивные и помеченные на удаление контрагенты деактивируются.
 func (s *OneCIntegrationService) ImportCounterparties(ctx context.Context, companyID uuid.UUID) error {
 	credentials, err := s.GetCredentials(ctx, companyID)
 	if err != nil {
@@ -211,24 +211,15 @@ func (s *OneCIntegrationService) ImportCounterparties(ctx context.Context, compa
 	// Получаем контрагентов из 1С
 	limit := 100
 	offset := 0
-	totalImported := 0
+	var counterparties []OneCCounterparty
 
 	for {
-		counterparties, total, err := s.oneCClient.GetCounterparties(ctx, credentials, limit, offset)
+		page, total, err := s.oneCClient.GetCounterparties(ctx, credentials, limit, offset)
 		if err != nil {
 			s.logError(ctx, companyID, "import_counterparty", "counterparties", "", "IMPORT_ERROR", err.Error(), nil, nil)
 			return fmt.Errorf("ошибка получения контрагентов из 1С: %w", err)
 		}
-
-		// Импортируем каждого контрагента
-		for _, cp := range counterparties {
-			if err := s.importSingleCounterparty(ctx, companyID, &cp); err != nil {
-				s.logger.Printf("Ошибка импорта контрагента %s: %v", cp.Code, err)
-				s.logError(ctx, companyID, "import_counterparty", "counterparty", cp.ID, "IMPORT_SINGLE_ERROR", err.Error(), cp, nil)
-				continue
-			}
-			totalImported++
-		}
+		counterparties = append(counterparties, page...)
 
 		// Проверяем, есть ли еще данные
 		if offset+limit >= total {
@@ -237,57 +228,82 @@ func (s *OneCIntegrationService) ImportCounterparties(ctx context.Context, compa
 		offset += limit
 	}
 
-	s.logger.Printf("Импорт контрагентов завершен: %d из 1С в компанию %s", totalImported, companyID.String())
-	return nil
-}
+	// Импортируем каждого контрагента. Родительские папки могут идти после вложенных
+	// элементов, поэтому иерархия восстанавливается отдельным проходом.
+	clientService := NewClientService(s.db)
+	clientIDs := make(map[string]uint, len(counterparties))
+	created, updated := 0, 0
 
-// importSingleCounterparty импортирует одного контрагента
-func (s *OneCIntegrationService) importSingleCounterparty(ctx context.Context, companyID uuid.UUID, cp *OneCCounterparty) error {
-	// Проверяем, есть ли уже такой контрагент
-	var existingUser models.User
-	err := s.db.Where("company_id = ? AND (email = ? OR phone = ?) AND external_id = ?",
-		companyID, cp.Email, cp.Phone, cp.ID).First(&existingUser).Error
-
-	if err == nil {
-		// Обновляем существующего
-		existingUser.Name = cp.Description
-		existingUser.Email = cp.Email
-		existingUser.Phone = cp.Phone
-		existingUser.UpdatedAt = time.Now()
-
-		if err := s.db.Save(&existingUser).Error; err != nil {
-			return fmt.Errorf("ошибка обновления контрагента: %w", err)
+	for i := range counterparties {
+		cp := &counterparties[i]
+		client, isNew, err := s.importSingleCounterparty(clientService, companyID, cp)
+		if err != nil {
+			s.logger.Printf("Ошибка импорта контрагента %s: %v", cp.Code, err)
+			s.logError(ctx, companyID, "import_counterparty", "counterparty", cp.ID, "IMPORT_SINGLE_ERROR", err.Error(), cp, nil)
+			continue
 		}
 
-		s.logger.Printf("Контрагент обновлен: %s", cp.Description)
-		return nil
+		clientIDs[cp.ID] = client.ID
+		if isNew {
+			created++
+		} else {
+			updated++
+		}
 	}
 
-	// Создаем нового пользователя
-	user := models.User{
-		CompanyID:      companyID,
-		Name:           cp.Description,
-		Email:          cp.Email,
-		Phone:          cp.Phone,
-		UserType:       "client",
-		IsActive:       cp.IsActive,
-		ExternalID:     cp.ID,
-		ExternalSource: "1c",
-		CreatedAt:      time.Now(),
-		UpdatedAt:      time.Now(),
-	}
+	for _, cp := range counterparties {
+		clientID, ok := clientIDs[cp.ID]
+		if !ok {
+			continue
+		}
 
-	// Создаем временный пароль
-	user.Password = "temp_password_" + strconv.FormatInt(time.Now().Unix(), 10)
+		var parentID *uint
+		if id, ok := clientIDs[cp.Parent]; ok && cp.Parent != "" {
+			parentID = &id
+		}
 
-	if err := s.db.Create(&user).Error; err != nil {
-		return fmt.Errorf("ошибка создания контрагента: %w", err)
+		if err := s.db.Model(&models.Client{}).Where("id = ?", clientID).Update("parent_id", parentID).Error; err != nil {
+			s.logger.Printf("Ошибка установки группы контрагента %s: %v", cp.Code, err)
+		}
 	}
 
-	s.logger.Printf("Новый контрагент создан: %s", cp.Description)
+	s.logger.Printf("Импорт контрагентов завершен для компании %s: создано %d, обновлено %d", companyID.String(), created, updated)
 	return nil
 }
 
+// importSingleCounterparty создает или обновляет клиента по контрагенту 1С
+func (s *OneCIntegrationService) importSingleCounterparty(clientService *ClientService, companyID uuid.UUID, cp *OneCCounterparty) (*models.Client, bool, error) {
+	name := cp.Description
+	if name == "" {
+		name = cp.FullName
+	}
+
+	client, isNew, err := clientService.UpsertExternal(companyID, "1c", &models.Client{
+		Name:          name,
+		FullName:      cp.FullName,
+		INN:           cp.INN,
+		KPP:           cp.KPP,
+		OGRN:          cp.OGRN,
+		LegalAddress:  cp.LegalAddress,
+		ActualAddress: cp.ActualAddress,
+		Phone:         cp.Phone,
+		Email:         cp.Email,
+		IsFolder:      cp.IsFolder,
+		IsActive:      cp.IsActive && !cp.DeletionMark,
+		ExternalID:    cp.ID,
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	if isNew {
+		s.logger.Printf("Новый клиент создан: %s", name)
+	} else {
+		s.logger.Printf("Клиент обновлен: %s", name)
+	}
+	return client, isNew, nil
+}
+
 // SyncPaymentStatuses синхронизирует статусы платежей с 1С
 func (s *OneCIntegrationService) SyncPaymentStatuses(ctx context.Context, companyID uuid.UUID) error {
 	credentials, err := s.GetCredentials(ctx, companyID)
@@ -441,6 +457,11 @@ func (s *OneCIntegrationService) ScheduleAutoExport(ctx context.Context, company
 		return nil // Автоэкспорт отключен
 	}
 
+	// Выгружаем новые и измененные договоры и счета
+	if _, err := s.SyncDocuments(ctx, companyID); err != nil {
+		s.logger.Printf("Ошибка выгрузки документов в 1С для компании %s: %v", companyID.String(), err)
+	}
+
 	// Получаем оплаченные счета за последний период
 	var invoices []models.Invoice
 	since := time.Now().AddDate(0, 0, -1) // За последний день
@@ -211,21 +211,24 @@ func TestOneCIntegrationService_ImportCounterparties(t *testing.T) {
 	err := service.ImportCounterparties(ctx, company.ID)
 	require.NoError(t, err)
 
-	// Проверяем, что контрагенты были созданы как пользователи
-	var users []models.User
-	db.Where("company_id = ? AND user_type = ?", company.ID, "client").Find(&users)
-
-	// В моке есть 2 активных контрагента
-	assert.Len(t, users, 2)
-
-	// Проверяем данные первого пользователя
-	user1 := users[0]
-	assert.Equal(t, "ООО Тестовая компания 1", user1.Name)
-	assert.Equal(t, "info@testcompany1.ru", user1.Email)
-	assert.Equal(t, "+7 (495) 123-45-67", user1.Phone)
-	assert.Equal(t, "counterparty-1", user1.ExternalID)
-	assert.Equal(t, "1c", user1.ExternalSource)
-	assert.True(t, user1.IsActive)
+	// Проверяем, что контрагенты попали в справочник клиентов
+	var clients []models.Client
+	db.Where("company_id = ?", company.ID).Order("id").Find(&clients)
+
+	// В моке 3 контрагента, один из них неактивный
+	require.Len(t, clients, 3)
+
+	// Проверяем данные первого клиента
+	client1 := clients[0]
+	assert.Equal(t, "ООО Тестовая компания 1", client1.Name)
+	assert.Equal(t, "1234567890", client1.INN)
+	assert.Equal(t, "123456789", client1.KPP)
+	assert.Equal(t, "info@testcompany1.ru", client1.Email)
+	assert.Equal(t, "+7 (495) 123-45-67", client1.Phone)
+	assert.Equal(t, "counterparty-1", client1.ExternalID)
+	assert.Equal(t, "1c", client1.ExternalSource)
+	assert.True(t, client1.IsActive)
+	assert.False(t, clients[2].IsActive)
 }
 
 func TestOneCIntegrationService_ImportCounterparties_UpdateExisting(t *testing.T) {
@@ -233,20 +236,16 @@ func TestOneCIntegrationService_ImportCounterparties_UpdateExisting(t *testing.T
 	company := createTestCompany(db)
 	createTestIntegration(db, company.ID)
 
-	// Создаем существующего пользователя
-	existingUser := &models.User{
-		CompanyID:      company.ID,
-		Name:           "Старое имя",
-		Email:          "info@testcompany1.ru",
-		Phone:          "+7 (495) 123-45-67",
-		UserType:       "client",
-		ExternalID:     "counterparty-1",
-		ExternalSource: "1c",
-		IsActive:       true,
-		CreatedAt:      time.Now(),
-		UpdatedAt:      time.Now(),
+	// Создаем клиента, заведенного вручную с теми же ИНН и КПП
+	existingClient := &models.Client{
+		CompanyID: company.ID,
+		Name:      "Старое имя",
+		INN:       "1234567890",
+		KPP:       "123456789",
+		Email:     "buh@testcompany1.ru",
+		IsActive:  true,
 	}
-	db.Create(existingUser)
+	db.Create(existingClient)
 
 	ctx := context.Background()
 
@@ -254,16 +253,17 @@ func TestOneCIntegrationService_ImportCounterparties_UpdateExisting(t *testing.T
 	err := service.ImportCounterparties(ctx, company.ID)
 	require.NoError(t, err)
 
-	// Проверяем, что пользователь был обновлен
-	var updatedUser models.User
-	db.Where("id = ?", existingUser.ID).First(&updatedUser)
-	assert.Equal(t, "ООО Тестовая компания 1", updatedUser.Name) // Должно обновиться
-	assert.Equal(t, "info@testcompany1.ru", updatedUser.Email)
-
-	// Проверяем общее количество пользователей (должно быть 2: 1 обновленный + 1 новый)
-	var totalUsers []models.User
-	db.Where("company_id = ? AND user_type = ?", company.ID, "client").Find(&totalUsers)
-	assert.Len(t, totalUsers, 2)
+	// Проверяем, что клиент был обновлен и привязан к 1С
+	var updatedClient models.Client
+	db.Where("id = ?", existingClient.ID).First(&updatedClient)
+	assert.Equal(t, "ООО Тестовая компания 1", updatedClient.Name) // Должно обновиться
+	assert.Equal(t, "info@testcompany1.ru", updatedClient.Email)
+	assert.Equal(t, "counterparty-1", updatedClient.ExternalID)
+
+	// Проверяем общее количество клиентов (должно быть 3: 1 обновленный + 2 новых)
+	var total int64
+	db.Model(&models.Client{}).Where("company_id = ?", company.ID).Count(&total)
+	assert.Equal(t, int64(3), total)
 }
 
 func TestOneCIntegrationService_SyncPaymentStatuses(t *testing.T) {
@@ -7,25 +7,9 @@ import (
 	"time"
 
 	"gorm.io/gorm"
-)
 
-// AuditLog модель для аудит логов
-type AuditLog struct {
-	ID         uint      `json:"id" gorm:"primaryKey"`
-	TenantID   uint      `json:"tenant_id" gorm:"not null;index"`
-	UserID     *uint     `json:"user_id" gorm:"index"`
-	Action     string    `json:"action" gorm:"not null;index"`
-	Resource   string    `json:"resource" gorm:"not null;index"`
-	ResourceID *uint     `json:"resource_id" gorm:"index"`
-	IPAddress  string    `json:"ip_address" gorm:"size:45"`
-	UserAgent  string    `json:"user_agent" gorm:"size:500"`
-	Details    string    `json:"details" gorm:"type:text"`
-	OldValues  string    `json:"old_values" gorm:"type:text"`
-	NewValues  string    `json:"new_values" gorm:"type:text"`
-	Success    bool      `json:"success" gorm:"default:true;index"`
-	ErrorMsg   string    `json:"error_message" gorm:"size:1000"`
-	CreatedAt  time.Time `json:"created_at" gorm:"index"`
-}
+	"backend_axenta/models"
+)
 
 // AuditAction типы действий для аудита
 type AuditAction string
@@ -111,7 +95,7 @@ type AuditContext struct {
 
 // Log записывает аудит лог
 func (as *AuditService) Log(ctx AuditContext) error {
-	auditLog := &AuditLog{
+	auditLog := &models.AuditLog{
 		TenantID:   ctx.TenantID,
 		UserID:     ctx.UserID,
 		Action:     string(ctx.Action),
@@ -172,7 +156,7 @@ func (as *AuditService) LogFailure(ctx AuditContext, err error) error {
 }
 
 // GetAuditLogs получает аудит логи с фильтрацией
-func (as *AuditService) GetAuditLogs(tenantID uint, filters AuditFilters) ([]AuditLog, error) {
+func (as *AuditService) GetAuditLogs(tenantID uint, filters AuditFilters) ([]models.AuditLog, error) {
 	db := as.db
 
 	query := db.Where("tenant_id = ?", tenantID)
@@ -221,7 +205,7 @@ func (as *AuditService) GetAuditLogs(tenantID uint, filters AuditFilters) ([]Aud
 		query = query.Offset(filters.Offset)
 	}
 
-	var logs []AuditLog
+	var logs []models.AuditLog
 	if err := query.Find(&logs).Error; err != nil {
 		return nil, err
 	}
@@ -268,21 +252,21 @@ func (as *AuditService) GetAuditStats(tenantID uint, period string) (*AuditStats
 	}
 
 	// Общее количество логов
-	if err := db.Model(&AuditLog{}).
+	if err := db.Model(&models.AuditLog{}).
 		Where("tenant_id = ? AND created_at >= ?", tenantID, startDate).
 		Count(&stats.TotalLogs).Error; err != nil {
 		return nil, err
 	}
 
 	// Количество успешных операций
-	if err := db.Model(&AuditLog{}).
+	if err := db.Model(&models.AuditLog{}).
 		Where("tenant_id = ? AND created_at >= ? AND success = ?", tenantID, startDate, true).
 		Count(&stats.SuccessfulLogs).Error; err != nil {
 		return nil, err
 	}
 
 	// Количество неуспешных операций
-	if err := db.Model(&AuditLog{}).
+	if err := db.Model(&models.AuditLog{}).
 		Where("tenant_id = ? AND created_at >= ? AND success = ?", tenantID, startDate, false).
 		Count(&stats.FailedLogs).Error; err != nil {
 		return nil, err
@@ -295,7 +279,7 @@ func (as *AuditService) GetAuditStats(tenantID uint, period string) (*AuditStats
 	}
 
 	var topActions []ActionCount
-	if err := db.Model(&AuditLog{}).
+	if err := db.Model(&models.AuditLog{}).
 		Select("action, COUNT(*) as count").
 		Where("tenant_id = ? AND created_at >= ?", tenantID, startDate).
 		Group("action").
@@ -317,7 +301,7 @@ func (as *AuditService) GetAuditStats(tenantID uint, period string) (*AuditStats
 	}
 
 	var topUsers []UserCount
-	if err := db.Model(&AuditLog{}).
+	if err := db.Model(&models.AuditLog{}).
 		Select("user_id, COUNT(*) as count").
 		Where("tenant_id = ? AND created_at >= ? AND user_id IS NOT NULL", tenantID, startDate).
 		Group("user_id").
@@ -339,7 +323,7 @@ func (as *AuditService) GetAuditStats(tenantID uint, period string) (*AuditStats
 	}
 
 	var hourlyActivity []HourlyActivity
-	if err := db.Model(&AuditLog{}).
+	if err := db.Model(&models.AuditLog{}).
 		Select("EXTRACT(hour FROM created_at) as hour, COUNT(*) as count").
 		Where("tenant_id = ? AND created_at >= ?", tenantID, startDate).
 		Group("hour").
@@ -376,7 +360,7 @@ func (as *AuditService) CleanupOldLogs(tenantID uint, retentionDays int) error {
 	cutoffDate := time.Now().AddDate(0, 0, -retentionDays)
 
 	result := db.Where("tenant_id = ? AND created_at < ?", tenantID, cutoffDate).
-		Delete(&AuditLog{})
+		Delete(&models.AuditLog{})
 
 	if result.Error != nil {
 		return result.Error
@@ -414,7 +398,7 @@ func (as *AuditService) GetSecurityAlerts(tenantID uint, hours int) ([]SecurityA
 	}
 
 	var failedLogins []FailedLoginCount
-	if err := db.Model(&AuditLog{}).
+	if err := db.Model(&models.AuditLog{}).
 		Select("ip_address, COUNT(*) as count").
 		Where("tenant_id = ? AND action = ? AND success = ? AND created_at >= ?",
 			tenantID, ActionUserLogin, false, startTime).
@@ -442,7 +426,7 @@ func (as *AuditService) GetSecurityAlerts(tenantID uint, hours int) ([]SecurityA
 	}
 
 	var suspiciousActivity []UserActivity
-	if err := db.Model(&AuditLog{}).
+	if err := db.Model(&models.AuditLog{}).
 		Select("user_id, COUNT(*) as count").
 		Where("tenant_id = ? AND created_at >= ? AND user_id IS NOT NULL", tenantID, startTime).
 		Group("user_id").
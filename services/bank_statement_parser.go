@@ -0,0 +1,378 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"backend_axenta/models"
+
+	"github.com/shopspring/decimal"
+	"golang.org/x/text/encoding/charmap"
+)
+
+// oneCBankExchangeHeader первая строка файла обмена с клиент-банком
+const oneCBankExchangeHeader = "1CClientBankExchange"
+
+// ParsedBankStatement результат разбора файла банковской выписки
+type ParsedBankStatement struct {
+	Format        models.BankStatementFormat `json:"format"`
+	AccountNumber string                     `json:"account_number"`
+	PeriodStart   *time.Time                 `json:"period_start"`
+	PeriodEnd     *time.Time                 `json:"period_end"`
+	Payments      []ParsedBankPayment        `json:"payments"`
+}
+
+// ParsedBankPayment платежный документ из выписки
+type ParsedBankPayment struct {
+	DocumentNumber   string          `json:"document_number"`
+	DocumentDate     time.Time       `json:"document_date"`
+	Amount           decimal.Decimal `json:"amount"`
+	PayerName        string          `json:"payer_name"`
+	PayerINN         string          `json:"payer_inn"`
+	PayerKPP         string          `json:"payer_kpp"`
+	PayerAccount     string          `json:"payer_account"`
+	PayerBank        string          `json:"payer_bank"`
+	RecipientAccount string          `json:"recipient_account"`
+	RecipientINN     string          `json:"recipient_inn"`
+	Purpose          string          `json:"purpose"`
+	Incoming         bool            `json:"incoming"` // Поступление на счет компании
+}
+
+// ParseBankStatement определяет формат выписки и разбирает ее
+func ParseBankStatement(data []byte) (*ParsedBankStatement, error) {
+	text := decodeBankStatementText(data)
+	if strings.HasPrefix(strings.TrimSpace(text), oneCBankExchangeHeader) {
+		return Parse1CClientBankExchange(text)
+	}
+	return ParseBankStatementCSV(text)
+}
+
+// decodeBankStatementText приводит текст выписки к UTF-8.
+// Банки выгружают файлы в Windows-1251 (Кодировка=Windows) или CP866 (Кодировка=DOS).
+// Любая последовательность байтов декодируется в обеих кодировках без ошибок,
+// поэтому кодировка определяется по заголовку файла до декодирования.
+func decodeBankStatementText(data []byte) string {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	if utf8.Valid(data) {
+		return string(data)
+	}
+
+	decoder := charmap.Windows1251.NewDecoder()
+	if isDOSEncodedBankStatement(data) {
+		decoder = charmap.CodePage866.NewDecoder()
+	}
+	if decoded, err := decoder.Bytes(data); err == nil {
+		return string(decoded)
+	}
+	return string(data)
+}
+
+// bankStatementEncodingKeys ключ "Кодировка" в байтах Windows-1251 и CP866
+var bankStatementEncodingKeys = [][]byte{
+	mustEncodeCharmap(charmap.Windows1251, "Кодировка"),
+	mustEncodeCharmap(charmap.CodePage866, "Кодировка"),
+}
+
+func mustEncodeCharmap(cm *charmap.Charmap, s string) []byte {
+	encoded, err := cm.NewEncoder().Bytes([]byte(s))
+	if err != nil {
+		panic(err)
+	}
+	return encoded
+}
+
+// isDOSEncodedBankStatement ищет в исходных байтах файла строку Кодировка=DOS
+func isDOSEncodedBankStatement(data []byte) bool {
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		key, value, found := bytes.Cut(bytes.TrimSpace(line), []byte("="))
+		if !found {
+			continue
+		}
+		for _, encodingKey := range bankStatementEncodingKeys {
+			if bytes.Equal(bytes.TrimSpace(key), encodingKey) {
+				return string(bytes.TrimSpace(value)) == "DOS"
+			}
+		}
+	}
+	return false
+}
+
+// Parse1CClientBankExchange разбирает выписку в формате обмена 1С с клиент-банком
+func Parse1CClientBankExchange(text string) (*ParsedBankStatement, error) {
+	statement := &ParsedBankStatement{Format: models.BankStatementFormat1C}
+
+	scanner := bufio.NewScanner(strings.NewReader(text))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var (
+		document   map[string]string
+		headerSeen bool
+		section    string
+	)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		if !headerSeen {
+			if line != oneCBankExchangeHeader {
+				return nil, fmt.Errorf("файл не является выпиской 1CClientBankExchange")
+			}
+			headerSeen = true
+			continue
+		}
+
+		key, value, _ := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+
+		switch {
+		case key == "СекцияДокумент":
+			document = map[string]string{}
+			continue
+		case key == "КонецДокумента":
+			if document != nil {
+				payment, err := parse1CBankDocument(document, statement.AccountNumber)
+				if err != nil {
+					return nil, err
+				}
+				statement.Payments = append(statement.Payments, *payment)
+			}
+			document = nil
+			continue
+		case key == "СекцияРасчСчет":
+			section = key
+			continue
+		case key == "КонецРасчСчет":
+			section = ""
+			continue
+		case key == "КонецФайла":
+			return statement, nil
+		}
+
+		if document != nil {
+			document[key] = value
+			continue
+		}
+
+		switch key {
+		case "РасчСчет":
+			if statement.AccountNumber == "" || section == "" {
+				statement.AccountNumber = value
+			}
+		case "ДатаНачала":
+			if date, err := parseBankDate(value); err == nil {
+				statement.PeriodStart = &date
+			}
+		case "ДатаКонца":
+			if date, err := parseBankDate(value); err == nil {
+				statement.PeriodEnd = &date
+			}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка чтения выписки: %w", err)
+	}
+	if !headerSeen {
+		return nil, fmt.Errorf("пустой файл выписки")
+	}
+
+	return statement, nil
+}
+
+// parse1CBankDocument преобразует секцию документа выписки 1С в платеж
+func parse1CBankDocument(fields map[string]string, accountNumber string) (*ParsedBankPayment, error) {
+	amount, err := parseBankAmount(fields["Сумма"])
+	if err != nil {
+		return nil, fmt.Errorf("документ № %s: неверная сумма %q", fields["Номер"], fields["Сумма"])
+	}
+
+	payment := &ParsedBankPayment{
+		DocumentNumber:   fields["Номер"],
+		Amount:           amount,
+		PayerINN:         fields["ПлательщикИНН"],
+		PayerKPP:         fields["ПлательщикКПП"],
+		PayerAccount:     firstNonEmptyString(fields["ПлательщикСчет"], fields["ПлательщикРасчСчет"]),
+		PayerBank:        fields["ПлательщикБанк1"],
+		RecipientAccount: firstNonEmptyString(fields["ПолучательСчет"], fields["ПолучательРасчСчет"]),
+		RecipientINN:     fields["ПолучательИНН"],
+		Purpose:          fields["НазначениеПлатежа"],
+	}
+
+	// Наименование плательщика передается в поле Плательщик1, а поле Плательщик содержит "ИНН ... Наименование"
+	payment.PayerName = fields["Плательщик1"]
+	if payment.PayerName == "" {
+		payment.PayerName = strings.TrimSpace(strings.TrimPrefix(fields["Плательщик"], "ИНН "+payment.PayerINN))
+	}
+
+	if date, err := parseBankDate(fields["Дата"]); err == nil {
+		payment.DocumentDate = date
+	} else if date, err := parseBankDate(fields["ДатаПоступило"]); err == nil {
+		payment.DocumentDate = date
+	}
+
+	switch {
+	case accountNumber != "" && payment.RecipientAccount != "":
+		payment.Incoming = payment.RecipientAccount == accountNumber
+	case fields["ДатаСписано"] != "":
+		payment.Incoming = false
+	default:
+		payment.Incoming = true
+	}
+
+	return payment, nil
+}
+
+// bankCSVColumns варианты названий колонок CSV выписок разных банков
+var bankCSVColumns = map[string][]string{
+	"date":          {"дата", "дата документа", "дата операции", "дата платежа", "date"},
+	"number":        {"номер", "номер документа", "№ документа", "№", "номер п/п", "number"},
+	"amount":        {"сумма", "сумма поступления", "поступление", "приход", "кредит", "сумма по кредиту", "amount", "credit"},
+	"payer_name":    {"плательщик", "наименование плательщика", "контрагент", "payer"},
+	"payer_inn":     {"инн плательщика", "инн контрагента", "инн", "payer_inn"},
+	"payer_kpp":     {"кпп плательщика", "кпп контрагента", "кпп", "payer_kpp"},
+	"payer_account": {"счет плательщика", "счет контрагента", "р/с плательщика", "payer_account"},
+	"payer_bank":    {"банк плательщика", "банк контрагента", "payer_bank"},
+	"purpose":       {"назначение платежа", "назначение", "purpose"},
+}
+
+// ParseBankStatementCSV разбирает CSV выписку. Отрицательные суммы считаются списаниями.
+func ParseBankStatementCSV(text string) (*ParsedBankStatement, error) {
+	reader := csv.NewReader(strings.NewReader(text))
+	reader.Comma = detectCSVDelimiter(text)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения заголовка CSV: %w", err)
+	}
+
+	columns := map[string]int{}
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		for field, aliases := range bankCSVColumns {
+			if _, found := columns[field]; found {
+				continue
+			}
+			for _, alias := range aliases {
+				if name == alias {
+					columns[field] = i
+					break
+				}
+			}
+		}
+	}
+
+	for _, required := range []string{"date", "amount", "purpose"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("в CSV выписке отсутствует обязательная колонка %q", bankCSVColumns[required][0])
+		}
+	}
+
+	statement := &ParsedBankStatement{Format: models.BankStatementFormatCSV}
+	get := func(record []string, field string) string {
+		if i, ok := columns[field]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	line := 1
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		line++
+		if err != nil {
+			return nil, fmt.Errorf("строка %d: %w", line, err)
+		}
+
+		rawAmount := get(record, "amount")
+		if rawAmount == "" {
+			continue // Строка списания
+		}
+		amount, err := parseBankAmount(rawAmount)
+		if err != nil {
+			return nil, fmt.Errorf("строка %d: неверная сумма %q", line, rawAmount)
+		}
+
+		date, err := parseBankDate(get(record, "date"))
+		if err != nil {
+			return nil, fmt.Errorf("строка %d: неверная дата %q", line, get(record, "date"))
+		}
+
+		statement.Payments = append(statement.Payments, ParsedBankPayment{
+			DocumentNumber: get(record, "number"),
+			DocumentDate:   date,
+			Amount:         amount.Abs(),
+			PayerName:      get(record, "payer_name"),
+			PayerINN:       get(record, "payer_inn"),
+			PayerKPP:       get(record, "payer_kpp"),
+			PayerAccount:   get(record, "payer_account"),
+			PayerBank:      get(record, "payer_bank"),
+			Purpose:        get(record, "purpose"),
+			Incoming:       amount.IsPositive(),
+		})
+
+		if statement.PeriodStart == nil || date.Before(*statement.PeriodStart) {
+			d := date
+			statement.PeriodStart = &d
+		}
+		if statement.PeriodEnd == nil || date.After(*statement.PeriodEnd) {
+			d := date
+			statement.PeriodEnd = &d
+		}
+	}
+
+	return statement, nil
+}
+
+// detectCSVDelimiter определяет разделитель по строке заголовка
+func detectCSVDelimiter(text string) rune {
+	header, _, _ := strings.Cut(text, "\n")
+	best, bestCount := ';', 0
+	for _, delimiter := range []rune{';', ',', '\t'} {
+		if count := strings.Count(header, string(delimiter)); count > bestCount {
+			best, bestCount = delimiter, count
+		}
+	}
+	return best
+}
+
+// parseBankAmount разбирает сумму в форматах "1 200,50", "1200.50"
+func parseBankAmount(value string) (decimal.Decimal, error) {
+	value = strings.NewReplacer(" ", "", " ", "", ",", ".").Replace(strings.TrimSpace(value))
+	return decimal.NewFromString(value)
+}
+
+// parseBankDate разбирает дату в форматах, используемых банками
+func parseBankDate(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	for _, layout := range []string{"02.01.2006", "2006-01-02", "02.01.2006 15:04:05", "02.01.2006 15:04", "2006-01-02T15:04:05", "02/01/2006"} {
+		if date, err := time.Parse(layout, value); err == nil {
+			return date, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("неизвестный формат даты: %s", value)
+}
+
+// firstNonEmptyString возвращает первое непустое значение
+func firstNonEmptyString(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
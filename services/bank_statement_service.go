@@ -0,0 +1,601 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"backend_axenta/models"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+)
+
+// Веса признаков при сопоставлении платежа со счетом
+const (
+	bankMatchScoreNumber = 50 // Номер счета найден в назначении платежа
+	bankMatchScoreINN    = 30 // ИНН плательщика совпадает с ИНН клиента
+	bankMatchScoreAmount = 20 // Сумма совпадает с остатком к оплате
+
+	// Минимальный вес для автоматического проведения платежа
+	bankMatchConfidentScore = bankMatchScoreNumber + bankMatchScoreAmount
+)
+
+// bankMatchOverpaymentTolerance допустимое превышение суммы платежа над остатком по счету
+// (округления банка); большая переплата проводится только после проверки
+var bankMatchOverpaymentTolerance = decimal.NewFromInt(1)
+
+// BankStatementService импортирует банковские выписки и сопоставляет платежи со счетами
+type BankStatementService struct {
+	db *gorm.DB
+}
+
+// NewBankStatementService создает новый сервис банковских выписок
+func NewBankStatementService(db *gorm.DB) *BankStatementService {
+	return &BankStatementService{db: db}
+}
+
+// BankPaymentCandidate счет-кандидат для платежа из выписки
+type BankPaymentCandidate struct {
+	Invoice     models.Invoice `json:"invoice"`
+	Score       int            `json:"score"`
+	NumberMatch bool           `json:"number_match"`
+	INNMatch    bool           `json:"inn_match"`
+	AmountMatch bool           `json:"amount_match"`
+}
+
+// BankPaymentReviewItem платеж, ожидающий ручной обработки, со списком кандидатов
+type BankPaymentReviewItem struct {
+	Payment    models.BankStatementPayment `json:"payment"`
+	Candidates []BankPaymentCandidate      `json:"candidates"`
+}
+
+// ImportStatement разбирает файл выписки, сохраняет входящие платежи и проводит уверенные совпадения
+func (s *BankStatementService) ImportStatement(companyID uuid.UUID, fileName string, data []byte, importedByID *uint) (*models.BankStatement, error) {
+	parsed, err := ParseBankStatement(data)
+	if err != nil {
+		return nil, err
+	}
+
+	statement := &models.BankStatement{
+		CompanyID:     companyID,
+		FileName:      fileName,
+		Format:        parsed.Format,
+		AccountNumber: parsed.AccountNumber,
+		PeriodStart:   parsed.PeriodStart,
+		PeriodEnd:     parsed.PeriodEnd,
+		ImportedByID:  importedByID,
+	}
+
+	var payments []models.BankStatementPayment
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(statement).Error; err != nil {
+			return fmt.Errorf("ошибка сохранения выписки: %w", err)
+		}
+
+		// Счета читаются в транзакции импорта: сопоставление видит только что проведенные платежи
+		invoices, err := loadOpenInvoices(tx, companyID)
+		if err != nil {
+			return err
+		}
+
+		for _, p := range parsed.Payments {
+			if !p.Incoming || !p.Amount.IsPositive() {
+				continue
+			}
+
+			payment := models.BankStatementPayment{
+				CompanyID:      companyID,
+				StatementID:    statement.ID,
+				DocumentNumber: p.DocumentNumber,
+				DocumentDate:   p.DocumentDate,
+				Amount:         p.Amount,
+				PayerName:      p.PayerName,
+				PayerINN:       p.PayerINN,
+				PayerKPP:       p.PayerKPP,
+				PayerAccount:   p.PayerAccount,
+				PayerBank:      p.PayerBank,
+				Purpose:        p.Purpose,
+				Fingerprint:    bankPaymentFingerprint(&p),
+				Status:         models.BankPaymentStatusUnmatched,
+			}
+			if err := createBankPayment(tx, &payment); err != nil {
+				return err
+			}
+
+			statement.TotalCount++
+			statement.TotalAmount = statement.TotalAmount.Add(payment.Amount)
+
+			if payment.Status != models.BankPaymentStatusDuplicate {
+				if err := s.matchPayment(tx, &payment, invoices); err != nil {
+					return err
+				}
+			}
+
+			switch payment.Status {
+			case models.BankPaymentStatusMatched:
+				statement.MatchedCount++
+			case models.BankPaymentStatusReview:
+				statement.ReviewCount++
+			case models.BankPaymentStatusDuplicate:
+				statement.DuplicateCount++
+			default:
+				statement.UnmatchedCount++
+			}
+			payments = append(payments, payment)
+		}
+
+		if err := tx.Model(statement).Updates(map[string]interface{}{
+			"total_count":     statement.TotalCount,
+			"matched_count":   statement.MatchedCount,
+			"review_count":    statement.ReviewCount,
+			"unmatched_count": statement.UnmatchedCount,
+			"duplicate_count": statement.DuplicateCount,
+			"total_amount":    statement.TotalAmount,
+		}).Error; err != nil {
+			return fmt.Errorf("ошибка обновления итогов выписки: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	statement.Payments = payments
+	return statement, nil
+}
+
+// createBankPayment сохраняет платеж из выписки. Уникальный индекс по ключу платежа среди
+// неповторных записей отклоняет повторную загрузку, в том числе из параллельного импорта:
+// такой платеж сохраняется со статусом duplicate.
+func createBankPayment(tx *gorm.DB, payment *models.BankStatementPayment) error {
+	err := tx.Transaction(func(sp *gorm.DB) error {
+		return sp.Create(payment).Error
+	})
+	if err == nil {
+		return nil
+	}
+	if !isUniqueViolation(err) {
+		return fmt.Errorf("ошибка сохранения платежа № %s: %w", payment.DocumentNumber, err)
+	}
+
+	payment.ID = 0
+	payment.Status = models.BankPaymentStatusDuplicate
+	payment.MatchReason = "Платеж уже загружен в предыдущей выписке"
+	if err := tx.Create(payment).Error; err != nil {
+		return fmt.Errorf("ошибка сохранения платежа № %s: %w", payment.DocumentNumber, err)
+	}
+	return nil
+}
+
+// matchPayment подбирает счет для платежа и проводит его при уверенном совпадении.
+// Список открытых счетов обновляется после проведения платежа.
+func (s *BankStatementService) matchPayment(tx *gorm.DB, payment *models.BankStatementPayment, invoices []models.Invoice) error {
+	candidates := rankBankPaymentCandidates(payment, invoices)
+
+	ids := make([]string, 0, len(candidates))
+	for _, c := range candidates {
+		ids = append(ids, strconv.FormatUint(uint64(c.Invoice.ID), 10))
+	}
+
+	updates := map[string]interface{}{
+		"candidate_ids": strings.Join(ids, ","),
+	}
+
+	best, confident := pickConfidentCandidate(payment, candidates)
+	switch {
+	case confident:
+		notes := fmt.Sprintf("Платежное поручение № %s от %s", payment.DocumentNumber, payment.DocumentDate.Format("02.01.2006"))
+		// Платеж проводится в точке сохранения: ошибка проведения не прерывает импорт выписки
+		err := tx.Transaction(func(sp *gorm.DB) error {
+			billing := &BillingService{db: sp}
+			return billing.ProcessPayment(best.Invoice.ID, payment.Amount, "bank_transfer", notes)
+		})
+		if err != nil {
+			payment.Status = models.BankPaymentStatusReview
+			payment.MatchReason = fmt.Sprintf("Не удалось провести платеж по счету %s: %v", best.Invoice.Number, err)
+		} else {
+			now := time.Now()
+			payment.Status = models.BankPaymentStatusMatched
+			payment.InvoiceID = &best.Invoice.ID
+			payment.ProcessedAt = &now
+			payment.MatchReason = describeBankMatch(best)
+			updates["processed_at"] = now
+
+			// Учитываем оплату, чтобы следующий платеж из выписки не сопоставился с уже оплаченным остатком
+			for i := range invoices {
+				if invoices[i].ID == best.Invoice.ID {
+					invoices[i].PaidAmount = invoices[i].PaidAmount.Add(payment.Amount)
+				}
+			}
+		}
+		payment.MatchScore = best.Score
+	case len(candidates) > 0:
+		payment.Status = models.BankPaymentStatusReview
+		payment.MatchScore = best.Score
+		payment.MatchReason = fmt.Sprintf("Найдено счетов-кандидатов: %d, лучший - %s (%s)", len(candidates), best.Invoice.Number, describeBankMatch(best))
+		if remaining := best.Invoice.GetRemainingAmount(); payment.Amount.Sub(remaining).GreaterThan(bankMatchOverpaymentTolerance) {
+			payment.MatchReason += fmt.Sprintf(". Сумма платежа превышает остаток по счету (%s)", remaining.StringFixed(2))
+		}
+	default:
+		payment.Status = models.BankPaymentStatusUnmatched
+		payment.MatchReason = "Подходящий счет не найден"
+	}
+
+	updates["status"] = payment.Status
+	updates["invoice_id"] = payment.InvoiceID
+	updates["match_score"] = payment.MatchScore
+	updates["match_reason"] = payment.MatchReason
+
+	if err := tx.Model(payment).Updates(updates).Error; err != nil {
+		return fmt.Errorf("ошибка сохранения результата сопоставления: %w", err)
+	}
+	return nil
+}
+
+// ConfirmMatch вручную проводит платеж из очереди проверки по выбранному счету.
+// Строка платежа блокируется до конца транзакции, чтобы параллельное подтверждение
+// не провело платеж повторно.
+func (s *BankStatementService) ConfirmMatch(companyID uuid.UUID, paymentID, invoiceID uint, userID *uint) (*models.BankStatementPayment, error) {
+	var statementID uint
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var payment models.BankStatementPayment
+		if err := tx.Clauses(lockingForUpdate).
+			Where("id = ? AND company_id = ?", paymentID, companyID).
+			First(&payment).Error; err != nil {
+			return fmt.Errorf("платеж не найден: %w", err)
+		}
+		if !payment.NeedsReview() {
+			return fmt.Errorf("платеж уже обработан (статус: %s)", payment.Status)
+		}
+		statementID = payment.StatementID
+
+		var invoice models.Invoice
+		if err := tx.Where("id = ? AND company_id = ?", invoiceID, companyID).First(&invoice).Error; err != nil {
+			return fmt.Errorf("счет не найден: %w", err)
+		}
+
+		notes := fmt.Sprintf("Платежное поручение № %s от %s (сопоставлено вручную)", payment.DocumentNumber, payment.DocumentDate.Format("02.01.2006"))
+		billing := &BillingService{db: tx}
+		if err := billing.ProcessPayment(invoice.ID, payment.Amount, "bank_transfer", notes); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		if err := tx.Model(&payment).Updates(map[string]interface{}{
+			"status":          models.BankPaymentStatusMatched,
+			"invoice_id":      invoice.ID,
+			"match_reason":    "Сопоставлено вручную",
+			"processed_at":    now,
+			"processed_by_id": userID,
+		}).Error; err != nil {
+			return fmt.Errorf("ошибка обновления платежа: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.refreshStatementCounters(statementID)
+	return s.getPayment(companyID, paymentID)
+}
+
+// IgnorePayment исключает платеж из очереди проверки (например, возврат или оплата не по счету)
+func (s *BankStatementService) IgnorePayment(companyID uuid.UUID, paymentID uint, reason string, userID *uint) (*models.BankStatementPayment, error) {
+	payment, err := s.getPayment(companyID, paymentID)
+	if err != nil {
+		return nil, err
+	}
+	if !payment.NeedsReview() {
+		return nil, fmt.Errorf("платеж уже обработан (статус: %s)", payment.Status)
+	}
+
+	if reason == "" {
+		reason = "Исключено вручную"
+	}
+
+	now := time.Now()
+	if err := s.db.Model(payment).Updates(map[string]interface{}{
+		"status":          models.BankPaymentStatusIgnored,
+		"match_reason":    reason,
+		"processed_at":    now,
+		"processed_by_id": userID,
+	}).Error; err != nil {
+		return nil, fmt.Errorf("ошибка обновления платежа: %w", err)
+	}
+
+	s.refreshStatementCounters(payment.StatementID)
+	return s.getPayment(companyID, paymentID)
+}
+
+// GetReviewQueue возвращает платежи, ожидающие ручного сопоставления, с подходящими счетами
+func (s *BankStatementService) GetReviewQueue(companyID uuid.UUID, limit, offset int) ([]BankPaymentReviewItem, int64, error) {
+	query := s.db.Model(&models.BankStatementPayment{}).
+		Where("company_id = ? AND status IN ?", companyID, []models.BankPaymentStatus{models.BankPaymentStatusReview, models.BankPaymentStatusUnmatched})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("ошибка подсчета платежей: %w", err)
+	}
+
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	if offset > 0 {
+		query = query.Offset(offset)
+	}
+
+	var payments []models.BankStatementPayment
+	if err := query.Order("document_date DESC, id DESC").Find(&payments).Error; err != nil {
+		return nil, 0, fmt.Errorf("ошибка получения платежей: %w", err)
+	}
+
+	invoices, err := loadOpenInvoices(s.db, companyID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	items := make([]BankPaymentReviewItem, 0, len(payments))
+	for i := range payments {
+		items = append(items, BankPaymentReviewItem{
+			Payment:    payments[i],
+			Candidates: rankBankPaymentCandidates(&payments[i], invoices),
+		})
+	}
+
+	return items, total, nil
+}
+
+// GetStatements возвращает загруженные выписки компании
+func (s *BankStatementService) GetStatements(companyID uuid.UUID, limit, offset int) ([]models.BankStatement, int64, error) {
+	query := s.db.Model(&models.BankStatement{}).Where("company_id = ?", companyID)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("ошибка подсчета выписок: %w", err)
+	}
+
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	if offset > 0 {
+		query = query.Offset(offset)
+	}
+
+	var statements []models.BankStatement
+	if err := query.Order("created_at DESC").Find(&statements).Error; err != nil {
+		return nil, 0, fmt.Errorf("ошибка получения выписок: %w", err)
+	}
+
+	return statements, total, nil
+}
+
+// GetStatement возвращает выписку с платежами
+func (s *BankStatementService) GetStatement(companyID uuid.UUID, statementID uint) (*models.BankStatement, error) {
+	var statement models.BankStatement
+	if err := s.db.Preload("Payments", func(db *gorm.DB) *gorm.DB {
+		return db.Order("document_date, id")
+	}).Preload("Payments.Invoice").
+		Where("id = ? AND company_id = ?", statementID, companyID).
+		First(&statement).Error; err != nil {
+		return nil, fmt.Errorf("выписка не найдена: %w", err)
+	}
+	return &statement, nil
+}
+
+// getPayment возвращает платеж из выписки компании
+func (s *BankStatementService) getPayment(companyID uuid.UUID, paymentID uint) (*models.BankStatementPayment, error) {
+	var payment models.BankStatementPayment
+	if err := s.db.Preload("Invoice").Where("id = ? AND company_id = ?", paymentID, companyID).First(&payment).Error; err != nil {
+		return nil, fmt.Errorf("платеж не найден: %w", err)
+	}
+	return &payment, nil
+}
+
+// loadOpenInvoices возвращает неоплаченные счета компании вместе с договорами
+func loadOpenInvoices(db *gorm.DB, companyID uuid.UUID) ([]models.Invoice, error) {
+	var invoices []models.Invoice
+	if err := db.Preload("Contract").
+		Where("company_id = ? AND status NOT IN ?", companyID, []string{"paid", "cancelled"}).
+		Find(&invoices).Error; err != nil {
+		return nil, fmt.Errorf("ошибка получения неоплаченных счетов: %w", err)
+	}
+	return invoices, nil
+}
+
+// refreshStatementCounters пересчитывает итоги выписки после ручной обработки
+func (s *BankStatementService) refreshStatementCounters(statementID uint) {
+	type statusCount struct {
+		Status models.BankPaymentStatus
+		Count  int
+	}
+
+	var counts []statusCount
+	if err := s.db.Model(&models.BankStatementPayment{}).
+		Select("status, COUNT(*) AS count").
+		Where("statement_id = ?", statementID).
+		Group("status").
+		Scan(&counts).Error; err != nil {
+		return
+	}
+
+	updates := map[string]interface{}{
+		"matched_count":   0,
+		"review_count":    0,
+		"unmatched_count": 0,
+		"duplicate_count": 0,
+	}
+	for _, c := range counts {
+		switch c.Status {
+		case models.BankPaymentStatusMatched:
+			updates["matched_count"] = c.Count
+		case models.BankPaymentStatusReview:
+			updates["review_count"] = c.Count
+		case models.BankPaymentStatusUnmatched:
+			updates["unmatched_count"] = c.Count
+		case models.BankPaymentStatusDuplicate:
+			updates["duplicate_count"] = c.Count
+		}
+	}
+
+	s.db.Model(&models.BankStatement{}).Where("id = ?", statementID).Updates(updates)
+}
+
+// rankBankPaymentCandidates оценивает открытые счета по признакам совпадения с платежом
+func rankBankPaymentCandidates(payment *models.BankStatementPayment, invoices []models.Invoice) []BankPaymentCandidate {
+	var candidates []BankPaymentCandidate
+
+	for _, invoice := range invoices {
+		if !invoice.GetRemainingAmount().IsPositive() {
+			continue // Счет уже оплачен платежом из этой же выписки
+		}
+		candidate := BankPaymentCandidate{Invoice: invoice}
+
+		if invoice.Number != "" && containsInvoiceNumber(payment.Purpose, invoice.Number) {
+			candidate.NumberMatch = true
+			candidate.Score += bankMatchScoreNumber
+		}
+		if payment.PayerINN != "" && invoice.Contract != nil && invoice.Contract.ClientINN == payment.PayerINN {
+			candidate.INNMatch = true
+			candidate.Score += bankMatchScoreINN
+		}
+		if payment.Amount.Equal(invoice.GetRemainingAmount()) {
+			candidate.AmountMatch = true
+			candidate.Score += bankMatchScoreAmount
+		}
+
+		// Совпадение только по сумме слишком ненадежно, чтобы предлагать счет
+		if candidate.NumberMatch || candidate.INNMatch {
+			candidates = append(candidates, candidate)
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Score > candidates[j].Score
+	})
+
+	return candidates
+}
+
+// pickConfidentCandidate выбирает счет для автоматического проведения.
+// Совпадение уверенное, если лучший кандидат единственный и
+// найден по номеру счета вместе с ИНН или суммой, либо является единственным счетом
+// плательщика с совпадающей суммой. ИНН плательщика, отличный от ИНН клиента,
+// и переплата сверх остатка по счету всегда требуют проверки.
+func pickConfidentCandidate(payment *models.BankStatementPayment, candidates []BankPaymentCandidate) (*BankPaymentCandidate, bool) {
+	if len(candidates) == 0 {
+		return nil, false
+	}
+
+	best := &candidates[0]
+	if len(candidates) > 1 && candidates[1].Score == best.Score {
+		return best, false
+	}
+
+	if payment.Amount.Sub(best.Invoice.GetRemainingAmount()).GreaterThan(bankMatchOverpaymentTolerance) {
+		// Платеж мог относиться к нескольким счетам или быть ошибочным
+		return best, false
+	}
+
+	if best.NumberMatch && !best.INNMatch && payment.PayerINN != "" && best.Invoice.Contract != nil && best.Invoice.Contract.ClientINN != "" {
+		// Счет оплачен сторонним плательщиком
+		return best, false
+	}
+
+	if best.Score >= bankMatchConfidentScore {
+		return best, true
+	}
+
+	if best.INNMatch && best.AmountMatch {
+		innCandidates := 0
+		for _, c := range candidates {
+			if c.INNMatch && c.AmountMatch {
+				innCandidates++
+			}
+		}
+		return best, innCandidates == 1
+	}
+
+	return best, false
+}
+
+// describeBankMatch формирует описание признаков совпадения
+func describeBankMatch(candidate *BankPaymentCandidate) string {
+	var reasons []string
+	if candidate.NumberMatch {
+		reasons = append(reasons, "номер счета в назначении платежа")
+	}
+	if candidate.INNMatch {
+		reasons = append(reasons, "ИНН плательщика")
+	}
+	if candidate.AmountMatch {
+		reasons = append(reasons, "сумма")
+	}
+	if len(reasons) == 0 {
+		return "совпадений нет"
+	}
+	return "совпадение: " + strings.Join(reasons, ", ")
+}
+
+// containsInvoiceNumber ищет номер счета в назначении платежа как отдельное слово,
+// чтобы счет INV-1 не совпадал с INV-10
+func containsInvoiceNumber(purpose, number string) bool {
+	purpose = strings.ToUpper(purpose)
+	number = strings.ToUpper(strings.TrimSpace(number))
+
+	for start := 0; start < len(purpose); {
+		idx := strings.Index(purpose[start:], number)
+		if idx < 0 {
+			return false
+		}
+		idx += start
+		end := idx + len(number)
+
+		before := idx == 0 || !isInvoiceNumberRune(lastRune(purpose[:idx]))
+		after := end == len(purpose) || !isInvoiceNumberRune(firstRune(purpose[end:]))
+		if before && after {
+			return true
+		}
+		start = idx + 1
+	}
+	return false
+}
+
+func isInvoiceNumberRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func firstRune(s string) rune {
+	for _, r := range s {
+		return r
+	}
+	return 0
+}
+
+func lastRune(s string) rune {
+	r := []rune(s)
+	if len(r) == 0 {
+		return 0
+	}
+	return r[len(r)-1]
+}
+
+// bankPaymentFingerprint вычисляет ключ платежа для обнаружения повторной загрузки выписки
+func bankPaymentFingerprint(p *ParsedBankPayment) string {
+	key := strings.Join([]string{
+		p.DocumentNumber,
+		p.DocumentDate.Format("2006-01-02"),
+		p.Amount.StringFixed(2),
+		p.PayerINN,
+		p.PayerAccount,
+	}, "|")
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
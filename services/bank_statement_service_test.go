@@ -0,0 +1,278 @@
+package services
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"backend_axenta/models"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/text/encoding/charmap"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+const testBankExchangeStatement = `1CClientBankExchange
+ВерсияФормата=1.03
+Кодировка=Windows
+ДатаНачала=01.03.2026
+ДатаКонца=31.03.2026
+РасчСчет=40702810900000000001
+СекцияРасчСчет
+РасчСчет=40702810900000000001
+КонецРасчСчет
+СекцияДокумент=Платежное поручение
+Номер=101
+Дата=05.03.2026
+Сумма=1200.00
+ПлательщикСчет=40702810100000000777
+Плательщик=ИНН 7701234567 ООО "Ромашка"
+ПлательщикИНН=7701234567
+Плательщик1=ООО "Ромашка"
+ПолучательСчет=40702810900000000001
+ДатаПоступило=05.03.2026
+НазначениеПлатежа=Оплата по счету INV-001 от 01.03.2026. В т.ч. НДС 200.00
+КонецДокумента
+СекцияДокумент=Платежное поручение
+Номер=102
+Дата=06.03.2026
+Сумма=700.00
+ПлательщикСчет=40702810100000000888
+ПлательщикИНН=7709876543
+Плательщик1=ООО "Лютик"
+ПолучательСчет=40702810900000000001
+НазначениеПлатежа=Оплата за мониторинг
+КонецДокумента
+СекцияДокумент=Платежное поручение
+Номер=55
+Дата=06.03.2026
+Сумма=5000.00
+ПлательщикСчет=40702810900000000001
+ПолучательСчет=40702810500000000999
+ДатаСписано=06.03.2026
+НазначениеПлатежа=Оплата поставщику
+КонецДокумента
+КонецФайла
+`
+
+func setupBankStatementTest(t *testing.T) (*gorm.DB, *BankStatementService, uuid.UUID) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+
+	// Таблица компаний создается вручную: значение по умолчанию для UUID не поддерживается SQLite
+	require.NoError(t, db.Exec(`CREATE TABLE companies (id TEXT PRIMARY KEY, name TEXT)`).Error)
+	require.NoError(t, db.AutoMigrate(&models.Contract{}, &models.Invoice{}, &models.BillingHistory{}, &models.BankStatement{}, &models.BankStatementPayment{}))
+
+	return db, NewBankStatementService(db), uuid.New()
+}
+
+func createBankTestInvoice(t *testing.T, db *gorm.DB, companyID uuid.UUID, number, clientINN string, total int64) *models.Invoice {
+	contract := &models.Contract{
+		Number:     "Д-" + number,
+		Title:      "Договор",
+		CompanyID:  companyID,
+		ClientName: "Клиент " + clientINN,
+		ClientINN:  clientINN,
+		StartDate:  time.Now(),
+		EndDate:    time.Now().AddDate(1, 0, 0),
+	}
+	require.NoError(t, db.Create(contract).Error)
+
+	invoice := &models.Invoice{
+		Number:         number,
+		Title:          "Счет " + number,
+		InvoiceDate:    time.Now(),
+		DueDate:        time.Now().AddDate(0, 0, 14),
+		CompanyID:      companyID,
+		ContractID:     &contract.ID,
+		SubtotalAmount: decimal.NewFromInt(total),
+		TotalAmount:    decimal.NewFromInt(total),
+		Status:         "sent",
+	}
+	require.NoError(t, db.Create(invoice).Error)
+	return invoice
+}
+
+func TestParse1CClientBankExchange(t *testing.T) {
+	encoded, err := charmap.Windows1251.NewEncoder().String(testBankExchangeStatement)
+	require.NoError(t, err)
+
+	statement, err := ParseBankStatement([]byte(encoded))
+	require.NoError(t, err)
+
+	assert.Equal(t, models.BankStatementFormat1C, statement.Format)
+	assert.Equal(t, "40702810900000000001", statement.AccountNumber)
+	require.NotNil(t, statement.PeriodStart)
+	assert.Equal(t, "2026-03-01", statement.PeriodStart.Format("2006-01-02"))
+	require.Len(t, statement.Payments, 3)
+
+	first := statement.Payments[0]
+	assert.Equal(t, "101", first.DocumentNumber)
+	assert.True(t, first.Amount.Equal(decimal.NewFromInt(1200)))
+	assert.Equal(t, "7701234567", first.PayerINN)
+	assert.Equal(t, `ООО "Ромашка"`, first.PayerName)
+	assert.True(t, first.Incoming)
+
+	assert.False(t, statement.Payments[2].Incoming)
+}
+
+func TestParse1CClientBankExchange_CP866(t *testing.T) {
+	data, err := os.ReadFile("testdata/kl_to_1c_cp866.txt")
+	require.NoError(t, err)
+	require.True(t, isDOSEncodedBankStatement(data))
+
+	statement, err := ParseBankStatement(data)
+	require.NoError(t, err)
+
+	require.Len(t, statement.Payments, 1)
+	payment := statement.Payments[0]
+	assert.Equal(t, "318", payment.DocumentNumber)
+	assert.True(t, payment.Amount.Equal(decimal.NewFromInt(2400)))
+	assert.Equal(t, `ООО "Северный ветер"`, payment.PayerName)
+	assert.Equal(t, "ПАО СБЕРБАНК", payment.PayerBank)
+	assert.Equal(t, "Оплата по счету INV-318 за мониторинг транспорта. Без НДС", payment.Purpose)
+	assert.True(t, payment.Incoming)
+
+	// Файл в Windows-1251 не принимается за DOS
+	encoded, err := charmap.Windows1251.NewEncoder().String(testBankExchangeStatement)
+	require.NoError(t, err)
+	assert.False(t, isDOSEncodedBankStatement([]byte(encoded)))
+}
+
+func TestParseBankStatementCSV(t *testing.T) {
+	csvData := "Дата;Номер документа;Сумма;Плательщик;ИНН плательщика;Назначение платежа\n" +
+		"05.03.2026;101;1 200,00;ООО Ромашка;7701234567;Оплата по счету INV-001\n" +
+		"06.03.2026;55;-5000,00;ООО Поставщик;7700000000;Оплата поставщику\n"
+
+	statement, err := ParseBankStatement([]byte(csvData))
+	require.NoError(t, err)
+
+	assert.Equal(t, models.BankStatementFormatCSV, statement.Format)
+	require.Len(t, statement.Payments, 2)
+	assert.True(t, statement.Payments[0].Amount.Equal(decimal.NewFromInt(1200)))
+	assert.True(t, statement.Payments[0].Incoming)
+	assert.False(t, statement.Payments[1].Incoming)
+}
+
+func TestContainsInvoiceNumber(t *testing.T) {
+	assert.True(t, containsInvoiceNumber("Оплата по счету inv-001 от 01.03", "INV-001"))
+	assert.True(t, containsInvoiceNumber("Оплата по сч.№INV-001", "INV-001"))
+	assert.False(t, containsInvoiceNumber("Оплата по счету INV-0010", "INV-001"))
+	assert.False(t, containsInvoiceNumber("Оплата за мониторинг", "INV-001"))
+}
+
+func TestBankStatementService_ImportStatement(t *testing.T) {
+	db, service, companyID := setupBankStatementTest(t)
+
+	paid := createBankTestInvoice(t, db, companyID, "INV-001", "7701234567", 1200)
+	// Два счета одного клиента на одинаковую сумму - платеж без номера счета требует проверки
+	createBankTestInvoice(t, db, companyID, "INV-002", "7709876543", 700)
+	createBankTestInvoice(t, db, companyID, "INV-003", "7709876543", 700)
+
+	statement, err := service.ImportStatement(companyID, "kl_to_1c.txt", []byte(testBankExchangeStatement), nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, statement.TotalCount) // Списание не загружается
+	assert.Equal(t, 1, statement.MatchedCount)
+	assert.Equal(t, 1, statement.ReviewCount)
+
+	var invoice models.Invoice
+	require.NoError(t, db.First(&invoice, paid.ID).Error)
+	assert.Equal(t, "paid", invoice.Status)
+	assert.True(t, invoice.PaidAmount.Equal(decimal.NewFromInt(1200)))
+
+	items, total, err := service.GetReviewQueue(companyID, 10, 0)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), total)
+	assert.Equal(t, "102", items[0].Payment.DocumentNumber)
+	assert.Len(t, items[0].Candidates, 2)
+
+	// Ручное сопоставление проводит платеж по выбранному счету
+	payment, err := service.ConfirmMatch(companyID, items[0].Payment.ID, items[0].Candidates[0].Invoice.ID, nil)
+	require.NoError(t, err)
+	assert.Equal(t, models.BankPaymentStatusMatched, payment.Status)
+
+	// Повторное подтверждение не проводит платеж второй раз
+	_, err = service.ConfirmMatch(companyID, items[0].Payment.ID, items[0].Candidates[0].Invoice.ID, nil)
+	assert.Error(t, err)
+	var history int64
+	require.NoError(t, db.Model(&models.BillingHistory{}).Where("invoice_id = ?", items[0].Candidates[0].Invoice.ID).Count(&history).Error)
+	assert.Equal(t, int64(1), history)
+
+	// Повторная загрузка той же выписки не проводит платежи второй раз
+	again, err := service.ImportStatement(companyID, "kl_to_1c.txt", []byte(testBankExchangeStatement), nil)
+	require.NoError(t, err)
+	assert.Equal(t, 2, again.DuplicateCount)
+	assert.Equal(t, 0, again.MatchedCount)
+
+	require.NoError(t, db.First(&invoice, paid.ID).Error)
+	assert.True(t, invoice.PaidAmount.Equal(decimal.NewFromInt(1200)))
+}
+
+func TestBankStatementService_ImportStatement_ConcurrentImport(t *testing.T) {
+	db, service, companyID := setupBankStatementTest(t)
+	paid := createBankTestInvoice(t, db, companyID, "INV-001", "7701234567", 1200)
+
+	// Параллельный импорт уже сохранил платеж № 101 из той же выписки
+	parsed, err := ParseBankStatement([]byte(testBankExchangeStatement))
+	require.NoError(t, err)
+	var fingerprint string
+	for i := range parsed.Payments {
+		if parsed.Payments[i].DocumentNumber == "101" {
+			fingerprint = bankPaymentFingerprint(&parsed.Payments[i])
+		}
+	}
+	require.NotEmpty(t, fingerprint)
+	require.NoError(t, db.Create(&models.BankStatementPayment{
+		CompanyID: companyID, StatementID: 999, DocumentNumber: "101", Amount: decimal.NewFromInt(1200),
+		Fingerprint: fingerprint, Status: models.BankPaymentStatusMatched,
+	}).Error)
+
+	// Уникальный индекс отклоняет второй неповторный платеж с тем же ключом
+	assert.Error(t, db.Create(&models.BankStatementPayment{
+		CompanyID: companyID, StatementID: 999, DocumentNumber: "101", Amount: decimal.NewFromInt(1200),
+		Fingerprint: fingerprint, Status: models.BankPaymentStatusUnmatched,
+	}).Error)
+
+	statement, err := service.ImportStatement(companyID, "kl_to_1c.txt", []byte(testBankExchangeStatement), nil)
+	require.NoError(t, err)
+	assert.Equal(t, 2, statement.TotalCount)
+	assert.Equal(t, 1, statement.DuplicateCount)
+	assert.Equal(t, 0, statement.MatchedCount)
+
+	// Счет не оплачен второй раз
+	var invoice models.Invoice
+	require.NoError(t, db.First(&invoice, paid.ID).Error)
+	assert.True(t, invoice.PaidAmount.IsZero())
+}
+
+func TestPickConfidentCandidate_Overpayment(t *testing.T) {
+	contract := &models.Contract{ClientINN: "7701234567"}
+	invoice := models.Invoice{Number: "INV-001", TotalAmount: decimal.NewFromInt(1000), Contract: contract}
+	payment := &models.BankStatementPayment{
+		Amount:   decimal.NewFromInt(1000),
+		PayerINN: "7701234567",
+		Purpose:  "Оплата по счету INV-001",
+	}
+
+	_, confident := pickConfidentCandidate(payment, rankBankPaymentCandidates(payment, []models.Invoice{invoice}))
+	assert.True(t, confident)
+
+	// Переплата в пределах допуска на округление
+	payment.Amount = decimal.RequireFromString("1000.50")
+	_, confident = pickConfidentCandidate(payment, rankBankPaymentCandidates(payment, []models.Invoice{invoice}))
+	assert.True(t, confident)
+
+	// Платеж существенно больше остатка по счету отправляется на проверку
+	payment.Amount = decimal.NewFromInt(5000)
+	best, confident := pickConfidentCandidate(payment, rankBankPaymentCandidates(payment, []models.Invoice{invoice}))
+	require.NotNil(t, best)
+	assert.False(t, confident)
+}
@@ -286,6 +286,12 @@ func (bs *BillingService) GenerateInvoiceForContract(contractID uint, periodStar
 		Status:             "draft",
 	}
 
+	// Счет выставляется клиенту договора из справочника
+	var contract models.Contract
+	if err := bs.db.Select("id", "client_id").First(&contract, calculation.ContractID).Error; err == nil {
+		invoice.ClientID = contract.ClientID
+	}
+
 	// Сохраняем счет
 	if err := bs.db.Create(invoice).Error; err != nil {
 		return nil, fmt.Errorf("ошибка создания счета: %w", err)
@@ -335,6 +341,8 @@ func (bs *BillingService) GenerateInvoiceForContract(contractID uint, periodStar
 		return nil, fmt.Errorf("ошибка загрузки созданного счета: %w", err)
 	}
 
+	PublishWebhookEvent(invoice.CompanyID, models.WebhookEventInvoiceCreated, invoice)
+
 	return invoice, nil
 }
 
@@ -376,9 +384,15 @@ func (bs *BillingService) ProcessPayment(invoiceID uint, amount decimal.Decimal,
 		updates["paid_at"] = paidAt
 	}
 
+	wasPaid := invoice.Status == "paid"
 	if err := bs.db.Model(&invoice).Updates(updates).Error; err != nil {
 		return fmt.Errorf("ошибка обновления счета: %w", err)
 	}
+	invoice.PaidAmount = newPaidAmount
+	invoice.Status = newStatus
+	if paidAt != nil {
+		invoice.PaidAt = paidAt
+	}
 
 	// Создаем запись в истории биллинга
 	history := &models.BillingHistory{
@@ -401,6 +415,10 @@ func (bs *BillingService) ProcessPayment(invoiceID uint, amount decimal.Decimal,
 		fmt.Printf("Предупреждение: ошибка создания записи в истории биллинга: %v\n", err)
 	}
 
+	if newStatus == "paid" && !wasPaid {
+		PublishWebhookEvent(invoice.CompanyID, models.WebhookEventInvoicePaid, invoice)
+	}
+
 	return nil
 }
 
@@ -477,6 +495,9 @@ func (bs *BillingService) CancelInvoice(invoiceID uint, reason string) error {
 	}).Error; err != nil {
 		return fmt.Errorf("ошибка отмены счета: %w", err)
 	}
+	invoice.Status = "cancelled"
+	invoice.Notes = reason
+	PublishWebhookEvent(invoice.CompanyID, models.WebhookEventInvoiceCancelled, invoice)
 
 	// Создаем запись в истории биллинга
 	history := &models.BillingHistory{
@@ -4,6 +4,7 @@ import (
 	"context"
 	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -12,24 +13,118 @@ import (
 	"net/url"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
+
+	"github.com/google/uuid"
 )
 
+// Bitrix24OAuthTokenURL адрес сервера авторизации Битрикс24
+const Bitrix24OAuthTokenURL = "https://oauth.bitrix.info/oauth/token/"
+
+// bitrix24TokenRefreshMargin запас времени, за который токен обновляется до истечения
+const bitrix24TokenRefreshMargin = 60 * time.Second
+
+// ErrBitrix24TokenRevoked возвращается, когда токен обновления отозван или недействителен
+var ErrBitrix24TokenRevoked = errors.New("доступ приложения к Битрикс24 отозван, требуется повторная установка")
+
 // Bitrix24Client клиент для работы с Битрикс24 API
 type Bitrix24Client struct {
 	BaseURL    string
+	OAuthURL   string // Адрес сервера авторизации (по умолчанию oauth.bitrix.info)
 	HTTPClient *http.Client
 	Logger     *log.Logger
+	TokenStore Bitrix24TokenStore // Хранилище токенов (может быть nil)
+
+	refreshMu sync.Mutex
 }
 
 // Bitrix24Credentials учетные данные для Битрикс24 API
 type Bitrix24Credentials struct {
-	WebhookURL   string // URL вебхука Битрикс24
-	ClientID     string // ID приложения (для OAuth)
-	ClientSecret string // Секрет приложения (для OAuth)
-	AccessToken  string // Токен доступа
-	RefreshToken string // Токен обновления
-	ExpiresAt    time.Time
+	CompanyID      uuid.UUID // Компания, которой принадлежат токены
+	WebhookURL     string    // URL вебхука Битрикс24
+	Domain         string    // Домен портала (example.bitrix24.ru)
+	ClientEndpoint string    // Адрес REST API портала (https://example.bitrix24.ru/rest/)
+	MemberID       string    // Уникальный идентификатор портала
+	ClientID       string    // ID приложения (для OAuth)
+	ClientSecret   string    // Секрет приложения (для OAuth)
+	AccessToken    string    // Токен доступа
+	RefreshToken   string    // Токен обновления
+	ExpiresAt      time.Time
+}
+
+// UsesOAuth проверяет, работают ли учетные данные через OAuth приложение
+func (c *Bitrix24Credentials) UsesOAuth() bool {
+	return c.WebhookURL == "" && c.AccessToken != ""
+}
+
+// NeedsRefresh проверяет, нужно ли обновить токен доступа
+func (c *Bitrix24Credentials) NeedsRefresh() bool {
+	if c.RefreshToken == "" || c.ExpiresAt.IsZero() {
+		return false
+	}
+	return time.Now().Add(bitrix24TokenRefreshMargin).After(c.ExpiresAt)
+}
+
+// restEndpoint возвращает адрес REST API портала
+func (c *Bitrix24Credentials) restEndpoint() string {
+	if c.ClientEndpoint != "" {
+		return strings.TrimRight(c.ClientEndpoint, "/") + "/"
+	}
+	return "https://" + strings.TrimRight(c.Domain, "/") + "/rest/"
+}
+
+// Bitrix24TokenStore хранилище OAuth токенов Битрикс24
+type Bitrix24TokenStore interface {
+	// SaveTokens сохраняет обновленные токены компании
+	SaveTokens(ctx context.Context, credentials *Bitrix24Credentials) error
+	// MarkRevoked помечает интеграцию компании как требующую повторной авторизации
+	MarkRevoked(ctx context.Context, credentials *Bitrix24Credentials, reason string) error
+	// LoadTokens возвращает сохраненные токены компании или nil, если токены не сохранялись
+	LoadTokens(ctx context.Context, credentials *Bitrix24Credentials) (*Bitrix24Credentials, error)
+}
+
+// Bitrix24TokenResponse ответ сервера авторизации Битрикс24
+type Bitrix24TokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	RefreshToken     string `json:"refresh_token"`
+	ExpiresIn        int    `json:"expires_in"`
+	Expires          int64  `json:"expires"`
+	Scope            string `json:"scope"`
+	Domain           string `json:"domain"`
+	ServerEndpoint   string `json:"server_endpoint"`
+	ClientEndpoint   string `json:"client_endpoint"`
+	MemberID         string `json:"member_id"`
+	UserID           int    `json:"user_id"`
+	Status           string `json:"status"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// ApplyTo переносит полученные токены в учетные данные
+func (r *Bitrix24TokenResponse) ApplyTo(credentials *Bitrix24Credentials) {
+	credentials.AccessToken = r.AccessToken
+	if r.RefreshToken != "" {
+		credentials.RefreshToken = r.RefreshToken
+	}
+	if r.Domain != "" {
+		credentials.Domain = r.Domain
+	}
+	if r.ClientEndpoint != "" {
+		credentials.ClientEndpoint = r.ClientEndpoint
+	}
+	if r.MemberID != "" {
+		credentials.MemberID = r.MemberID
+	}
+
+	switch {
+	case r.Expires > 0:
+		credentials.ExpiresAt = time.Unix(r.Expires, 0)
+	case r.ExpiresIn > 0:
+		credentials.ExpiresAt = time.Now().Add(time.Duration(r.ExpiresIn) * time.Second)
+	default:
+		credentials.ExpiresAt = time.Now().Add(time.Hour)
+	}
 }
 
 // Bitrix24Contact контакт в Битрикс24
@@ -111,6 +206,7 @@ func NewBitrix24Client(logger *log.Logger) *Bitrix24Client {
 	}
 
 	return &Bitrix24Client{
+		OAuthURL:   Bitrix24OAuthTokenURL,
 		HTTPClient: client,
 		Logger:     logger,
 	}
@@ -118,15 +214,61 @@ func NewBitrix24Client(logger *log.Logger) *Bitrix24Client {
 
 // CallMethod выполняет вызов метода Битрикс24 API
 func (c *Bitrix24Client) CallMethod(ctx context.Context, credentials *Bitrix24Credentials, method string, params map[string]interface{}) (*Bitrix24Response, error) {
-	// Формируем URL для API вызова
-	var apiURL string
 	if credentials.WebhookURL != "" {
 		// Используем вебхук
-		apiURL = credentials.WebhookURL + method
-	} else {
-		return nil, fmt.Errorf("не настроен WebhookURL для Битрикс24")
+		return c.doCall(ctx, credentials.WebhookURL+method, method, params, "")
+	}
+
+	if !credentials.UsesOAuth() {
+		return nil, fmt.Errorf("не настроен WebhookURL или OAuth авторизация для Битрикс24")
 	}
 
+	// Обновляем токен заранее, не дожидаясь ответа expired_token
+	if credentials.NeedsRefresh() {
+		if err := c.RefreshAccessToken(ctx, credentials); err != nil {
+			return nil, err
+		}
+	}
+
+	resp, err := c.doCall(ctx, credentials.restEndpoint()+method, method, params, credentials.AccessToken)
+	var apiErr *Bitrix24APIError
+	if errors.As(err, &apiErr) && apiErr.IsTokenExpired() && credentials.RefreshToken != "" {
+		// Токен истек раньше ожидаемого - обновляем и повторяем вызов один раз
+		if err := c.RefreshAccessToken(ctx, credentials); err != nil {
+			return nil, err
+		}
+		return c.doCall(ctx, credentials.restEndpoint()+method, method, params, credentials.AccessToken)
+	}
+
+	return resp, err
+}
+
+// Bitrix24APIError ошибка, возвращенная Битрикс24 API
+type Bitrix24APIError struct {
+	Code        string
+	Description string
+}
+
+func (e *Bitrix24APIError) Error() string {
+	return fmt.Sprintf("ошибка Битрикс24 API [%s]: %s", e.Code, e.Description)
+}
+
+// IsTokenExpired проверяет, связана ли ошибка с истекшим токеном доступа
+func (e *Bitrix24APIError) IsTokenExpired() bool {
+	return e.Code == "expired_token"
+}
+
+// IsTokenRevoked проверяет, связана ли ошибка с отозванным доступом приложения
+func (e *Bitrix24APIError) IsTokenRevoked() bool {
+	switch e.Code {
+	case "invalid_token", "invalid_grant", "NO_AUTH_FOUND", "wrong_client", "ERROR_OAUTH", "APPLICATION_NOT_FOUND":
+		return true
+	}
+	return false
+}
+
+// doCall выполняет HTTP запрос к REST API
+func (c *Bitrix24Client) doCall(ctx context.Context, apiURL, method string, params map[string]interface{}, accessToken string) (*Bitrix24Response, error) {
 	// Подготавливаем параметры
 	values := url.Values{}
 	for key, value := range params {
@@ -144,6 +286,9 @@ func (c *Bitrix24Client) CallMethod(ctx context.Context, credentials *Bitrix24Cr
 			values.Set(key, string(jsonData))
 		}
 	}
+	if accessToken != "" {
+		values.Set("auth", accessToken)
+	}
 
 	// Выполняем POST запрос
 	req, err := http.NewRequestWithContext(ctx, "POST", apiURL, strings.NewReader(values.Encode()))
@@ -166,6 +311,17 @@ func (c *Bitrix24Client) CallMethod(ctx context.Context, credentials *Bitrix24Cr
 		return nil, fmt.Errorf("ошибка чтения ответа: %w", err)
 	}
 
+	// Битрикс24 возвращает ошибки в плоском формате {"error": "...", "error_description": "..."}
+	var errResp struct {
+		Error            interface{} `json:"error"`
+		ErrorDescription string      `json:"error_description"`
+	}
+	if err := json.Unmarshal(body, &errResp); err == nil {
+		if code, ok := errResp.Error.(string); ok && code != "" {
+			return nil, &Bitrix24APIError{Code: code, Description: errResp.ErrorDescription}
+		}
+	}
+
 	// Парсим JSON ответ
 	var bitrixResp Bitrix24Response
 	if err := json.Unmarshal(body, &bitrixResp); err != nil {
@@ -174,13 +330,146 @@ func (c *Bitrix24Client) CallMethod(ctx context.Context, credentials *Bitrix24Cr
 
 	// Проверяем наличие ошибки в ответе
 	if bitrixResp.Error.Code != "" {
-		return nil, fmt.Errorf("ошибка Битрикс24 API [%s]: %s", bitrixResp.Error.Code, bitrixResp.Error.Description)
+		return nil, &Bitrix24APIError{Code: bitrixResp.Error.Code, Description: bitrixResp.Error.Description}
 	}
 
 	c.Logger.Printf("Успешный вызов метода %s", method)
 	return &bitrixResp, nil
 }
 
+// ExchangeAuthorizationCode обменивает код авторизации на токены доступа
+func (c *Bitrix24Client) ExchangeAuthorizationCode(ctx context.Context, clientID, clientSecret, code string) (*Bitrix24TokenResponse, error) {
+	values := url.Values{}
+	values.Set("grant_type", "authorization_code")
+	values.Set("client_id", clientID)
+	values.Set("client_secret", clientSecret)
+	values.Set("code", code)
+
+	tokenResp, err := c.requestToken(ctx, values)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка обмена кода авторизации: %w", err)
+	}
+
+	c.Logger.Printf("Получены токены Битрикс24 для портала %s", tokenResp.Domain)
+	return tokenResp, nil
+}
+
+// ExchangeRefreshToken получает новые токены по токену обновления без сохранения.
+// Сервер авторизации принимает токен обновления только от приложения, которому он выдан.
+func (c *Bitrix24Client) ExchangeRefreshToken(ctx context.Context, clientID, clientSecret, refreshToken string) (*Bitrix24TokenResponse, error) {
+	values := url.Values{}
+	values.Set("grant_type", "refresh_token")
+	values.Set("client_id", clientID)
+	values.Set("client_secret", clientSecret)
+	values.Set("refresh_token", refreshToken)
+
+	return c.requestToken(ctx, values)
+}
+
+// RefreshAccessToken обновляет токен доступа по токену обновления.
+// При отзыве доступа помечает интеграцию через TokenStore и возвращает ErrBitrix24TokenRevoked.
+func (c *Bitrix24Client) RefreshAccessToken(ctx context.Context, credentials *Bitrix24Credentials) error {
+	staleToken := credentials.AccessToken
+
+	c.refreshMu.Lock()
+	defer c.refreshMu.Unlock()
+
+	// Токен мог быть обновлен параллельным вызовом, пока мы ждали блокировку
+	if credentials.AccessToken != staleToken {
+		return nil
+	}
+
+	// Запрос с отдельно загруженными учетными данными мог уже обновить сохраненные токены:
+	// повторное обновление тем же токеном обновления будет отклонено сервером авторизации
+	if c.TokenStore != nil {
+		stored, err := c.TokenStore.LoadTokens(ctx, credentials)
+		if err != nil {
+			return fmt.Errorf("ошибка загрузки токенов Битрикс24: %w", err)
+		}
+		if stored != nil && stored.AccessToken != "" {
+			if stored.AccessToken != staleToken {
+				credentials.AccessToken = stored.AccessToken
+				credentials.RefreshToken = stored.RefreshToken
+				credentials.ExpiresAt = stored.ExpiresAt
+				return nil
+			}
+			if stored.RefreshToken != "" {
+				credentials.RefreshToken = stored.RefreshToken
+			}
+		}
+	}
+
+	if credentials.RefreshToken == "" {
+		return fmt.Errorf("отсутствует токен обновления Битрикс24")
+	}
+
+	tokenResp, err := c.ExchangeRefreshToken(ctx, credentials.ClientID, credentials.ClientSecret, credentials.RefreshToken)
+	if err != nil {
+		var apiErr *Bitrix24APIError
+		if errors.As(err, &apiErr) && apiErr.IsTokenRevoked() {
+			c.Logger.Printf("Доступ к порталу %s отозван: %s", credentials.Domain, apiErr.Description)
+			if c.TokenStore != nil {
+				if storeErr := c.TokenStore.MarkRevoked(ctx, credentials, apiErr.Error()); storeErr != nil {
+					c.Logger.Printf("Ошибка пометки интеграции как отозванной: %v", storeErr)
+				}
+			}
+			return fmt.Errorf("%w: %s", ErrBitrix24TokenRevoked, apiErr.Description)
+		}
+		return fmt.Errorf("ошибка обновления токена Битрикс24: %w", err)
+	}
+
+	tokenResp.ApplyTo(credentials)
+
+	if c.TokenStore != nil {
+		if err := c.TokenStore.SaveTokens(ctx, credentials); err != nil {
+			return fmt.Errorf("ошибка сохранения токенов Битрикс24: %w", err)
+		}
+	}
+
+	c.Logger.Printf("Токен Битрикс24 обновлен для портала %s", credentials.Domain)
+	return nil
+}
+
+// requestToken выполняет запрос к серверу авторизации
+func (c *Bitrix24Client) requestToken(ctx context.Context, values url.Values) (*Bitrix24TokenResponse, error) {
+	oauthURL := c.OAuthURL
+	if oauthURL == "" {
+		oauthURL = Bitrix24OAuthTokenURL
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", oauthURL+"?"+values.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка создания запроса: %w", err)
+	}
+	req.Header.Set("User-Agent", "AxentaCRM/1.0")
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка выполнения запроса: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения ответа: %w", err)
+	}
+
+	var tokenResp Bitrix24TokenResponse
+	if err := json.Unmarshal(body, &tokenResp); err != nil {
+		return nil, fmt.Errorf("ошибка парсинга ответа: %w, тело ответа: %s", err, string(body))
+	}
+
+	if tokenResp.Error != "" {
+		return nil, &Bitrix24APIError{Code: tokenResp.Error, Description: tokenResp.ErrorDescription}
+	}
+
+	if tokenResp.AccessToken == "" {
+		return nil, fmt.Errorf("сервер авторизации не вернул токен доступа (HTTP %d)", resp.StatusCode)
+	}
+
+	return &tokenResp, nil
+}
+
 // CreateContact создает контакт в Битрикс24
 func (c *Bitrix24Client) CreateContact(ctx context.Context, credentials *Bitrix24Credentials, contact *Bitrix24Contact) (string, error) {
 	fields := map[string]interface{}{
@@ -418,6 +707,28 @@ func (c *Bitrix24Client) UpdateDeal(ctx context.Context, credentials *Bitrix24Cr
 	return nil
 }
 
+// AddTimelineComment добавляет комментарий в ленту сущности CRM (deal, lead, contact) и возвращает его ID
+func (c *Bitrix24Client) AddTimelineComment(ctx context.Context, credentials *Bitrix24Credentials, entityType, entityID, comment string) (string, error) {
+	params := map[string]interface{}{
+		"fields": map[string]interface{}{
+			"ENTITY_ID":   entityID,
+			"ENTITY_TYPE": entityType,
+			"COMMENT":     comment,
+		},
+	}
+
+	resp, err := c.CallMethod(ctx, credentials, "crm.timeline.comment.add", params)
+	if err != nil {
+		return "", fmt.Errorf("ошибка добавления комментария: %w", err)
+	}
+
+	commentID, ok := resp.Result.(float64)
+	if !ok {
+		return "", fmt.Errorf("неожиданный формат ответа при добавлении комментария")
+	}
+	return strconv.Itoa(int(commentID)), nil
+}
+
 // GetDeal получает сделку из Битрикс24
 func (c *Bitrix24Client) GetDeal(ctx context.Context, credentials *Bitrix24Credentials, dealID string) (*Bitrix24Deal, error) {
 	params := map[string]interface{}{
@@ -0,0 +1,188 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryBitrix24TokenStore хранилище токенов в памяти для тестов
+type memoryBitrix24TokenStore struct {
+	mu      sync.Mutex
+	saved   []Bitrix24Credentials
+	revoked []string
+}
+
+func (s *memoryBitrix24TokenStore) SaveTokens(ctx context.Context, credentials *Bitrix24Credentials) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.saved = append(s.saved, *credentials)
+	return nil
+}
+
+func (s *memoryBitrix24TokenStore) MarkRevoked(ctx context.Context, credentials *Bitrix24Credentials, reason string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.revoked = append(s.revoked, reason)
+	return nil
+}
+
+func (s *memoryBitrix24TokenStore) LoadTokens(ctx context.Context, credentials *Bitrix24Credentials) (*Bitrix24Credentials, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.saved) == 0 {
+		return nil, nil
+	}
+	stored := s.saved[len(s.saved)-1]
+	return &stored, nil
+}
+
+func setupBitrix24OAuthServer(t *testing.T, tokenHandler http.HandlerFunc) (*Bitrix24Client, *memoryBitrix24TokenStore, *httptest.Server) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/oauth/token/", tokenHandler)
+	mux.HandleFunc("/rest/profile", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.Form.Get("auth") != "fresh-token" {
+			json.NewEncoder(w).Encode(map[string]string{"error": "expired_token", "error_description": "The access token provided has expired."})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"result": map[string]interface{}{"ID": "1"}})
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	store := &memoryBitrix24TokenStore{}
+	client := NewBitrix24Client(nil)
+	client.OAuthURL = server.URL + "/oauth/token/"
+	client.TokenStore = store
+
+	return client, store, server
+}
+
+func TestBitrix24Client_CallMethod_RefreshesBeforeExpiry(t *testing.T) {
+	refreshCalls := 0
+	client, store, server := setupBitrix24OAuthServer(t, func(w http.ResponseWriter, r *http.Request) {
+		refreshCalls++
+		assert.Equal(t, "refresh_token", r.URL.Query().Get("grant_type"))
+		assert.Equal(t, "old-refresh", r.URL.Query().Get("refresh_token"))
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token":  "fresh-token",
+			"refresh_token": "new-refresh",
+			"expires_in":    3600,
+		})
+	})
+
+	credentials := &Bitrix24Credentials{
+		ClientEndpoint: server.URL + "/rest/",
+		ClientID:       "app.123",
+		ClientSecret:   "secret",
+		AccessToken:    "stale-token",
+		RefreshToken:   "old-refresh",
+		ExpiresAt:      time.Now().Add(10 * time.Second),
+	}
+
+	_, err := client.CallMethod(context.Background(), credentials, "profile", nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, refreshCalls)
+	assert.Equal(t, "fresh-token", credentials.AccessToken)
+	assert.Equal(t, "new-refresh", credentials.RefreshToken)
+	assert.True(t, credentials.ExpiresAt.After(time.Now().Add(30*time.Minute)))
+	require.Len(t, store.saved, 1)
+	assert.Equal(t, "fresh-token", store.saved[0].AccessToken)
+}
+
+func TestBitrix24Client_CallMethod_RetriesOnExpiredToken(t *testing.T) {
+	client, _, server := setupBitrix24OAuthServer(t, func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token":  "fresh-token",
+			"refresh_token": "new-refresh",
+			"expires_in":    3600,
+		})
+	})
+
+	// Токен считается действующим, но портал уже его не принимает
+	credentials := &Bitrix24Credentials{
+		ClientEndpoint: server.URL + "/rest/",
+		AccessToken:    "stale-token",
+		RefreshToken:   "old-refresh",
+		ExpiresAt:      time.Now().Add(time.Hour),
+	}
+
+	_, err := client.CallMethod(context.Background(), credentials, "profile", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "fresh-token", credentials.AccessToken)
+}
+
+func TestBitrix24Client_CallMethod_RevokedToken(t *testing.T) {
+	client, store, server := setupBitrix24OAuthServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(map[string]string{
+			"error":             "invalid_grant",
+			"error_description": "Invalid refresh token",
+		})
+	})
+
+	credentials := &Bitrix24Credentials{
+		ClientEndpoint: server.URL + "/rest/",
+		AccessToken:    "stale-token",
+		RefreshToken:   "revoked-refresh",
+		ExpiresAt:      time.Now().Add(-time.Minute),
+	}
+
+	_, err := client.CallMethod(context.Background(), credentials, "profile", nil)
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrBitrix24TokenRevoked))
+	require.Len(t, store.revoked, 1)
+	assert.True(t, strings.Contains(store.revoked[0], "invalid_grant"))
+	assert.Empty(t, store.saved)
+}
+
+func TestBitrix24Client_CallMethod_Webhook(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/rest/1/abc/profile", r.URL.Path)
+		r.ParseForm()
+		assert.Empty(t, r.Form.Get("auth"))
+		json.NewEncoder(w).Encode(map[string]interface{}{"result": map[string]interface{}{"ID": "1"}})
+	}))
+	defer server.Close()
+
+	client := NewBitrix24Client(nil)
+	credentials := &Bitrix24Credentials{WebhookURL: server.URL + "/rest/1/abc/"}
+
+	_, err := client.CallMethod(context.Background(), credentials, "profile", nil)
+	assert.NoError(t, err)
+}
+
+func TestBitrix24Client_RefreshAccessToken_SkipsAlreadyRefreshed(t *testing.T) {
+	refreshCalls := 0
+	client, _, _ := setupBitrix24OAuthServer(t, func(w http.ResponseWriter, r *http.Request) {
+		refreshCalls++
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token":  "fresh-token",
+			"refresh_token": "new-refresh",
+			"expires_in":    3600,
+		})
+	})
+
+	// Два запроса загрузили учетные данные независимо друг от друга
+	first := &Bitrix24Credentials{AccessToken: "stale-token", RefreshToken: "old-refresh"}
+	second := *first
+
+	require.NoError(t, client.RefreshAccessToken(context.Background(), first))
+	require.NoError(t, client.RefreshAccessToken(context.Background(), &second))
+
+	assert.Equal(t, 1, refreshCalls)
+	assert.Equal(t, "fresh-token", second.AccessToken)
+	assert.Equal(t, "new-refresh", second.RefreshToken)
+}
@@ -0,0 +1,454 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"backend_axenta/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Bitrix24OAuthSettings настройки OAuth приложения Битрикс24, хранимые в Integration.Settings
+type Bitrix24OAuthSettings struct {
+	Domain           string    `json:"domain"`
+	ClientEndpoint   string    `json:"client_endpoint"`
+	MemberID         string    `json:"member_id"`
+	ApplicationToken string    `json:"application_token"`
+	AccessToken      string    `json:"access_token"`
+	RefreshToken     string    `json:"refresh_token"`
+	ExpiresAt        time.Time `json:"expires_at"`
+	Scope            string    `json:"scope"`
+}
+
+// Bitrix24InstallPayload данные события установки приложения в Битрикс24
+type Bitrix24InstallPayload struct {
+	AuthID           string // AUTH_ID
+	AuthExpires      int    // AUTH_EXPIRES, в секундах
+	RefreshID        string // REFRESH_ID
+	MemberID         string // member_id
+	Domain           string // DOMAIN
+	ServerEndpoint   string // SERVER_ENDPOINT
+	ApplicationToken string // auth[application_token]
+}
+
+// Bitrix24OAuthService управляет OAuth авторизацией приложения Битрикс24 для компаний
+type Bitrix24OAuthService struct {
+	db     *gorm.DB
+	client *Bitrix24Client
+	logger *log.Logger
+}
+
+// NewBitrix24OAuthService создает сервис OAuth авторизации Битрикс24
+func NewBitrix24OAuthService(db *gorm.DB, client *Bitrix24Client, logger *log.Logger) *Bitrix24OAuthService {
+	service := &Bitrix24OAuthService{
+		db:     db,
+		client: client,
+		logger: logger,
+	}
+	// Обновленные клиентом токены сохраняются через сервис
+	client.TokenStore = service
+	return service
+}
+
+// Client возвращает клиент Битрикс24, настроенный на хранение токенов через сервис
+func (s *Bitrix24OAuthService) Client() *Bitrix24Client {
+	return s.client
+}
+
+// BuildAuthorizeURL формирует адрес страницы авторизации приложения на портале
+func (s *Bitrix24OAuthService) BuildAuthorizeURL(companyID uuid.UUID, portalDomain, redirectURI string) (string, error) {
+	company, err := s.getCompany(companyID)
+	if err != nil {
+		return "", err
+	}
+
+	portalDomain = normalizeBitrix24Domain(portalDomain)
+	if portalDomain == "" {
+		return "", fmt.Errorf("не указан домен портала Битрикс24")
+	}
+
+	params := url.Values{}
+	params.Set("client_id", company.Bitrix24ClientID)
+	params.Set("response_type", "code")
+	params.Set("state", s.signState(company))
+	if redirectURI != "" {
+		params.Set("redirect_uri", redirectURI)
+	}
+
+	return fmt.Sprintf("https://%s/oauth/authorize/?%s", portalDomain, params.Encode()), nil
+}
+
+// HandleOAuthCallback обменивает код авторизации на токены и сохраняет их для компании из state
+func (s *Bitrix24OAuthService) HandleOAuthCallback(ctx context.Context, state, code string) (uuid.UUID, error) {
+	companyID, err := s.verifyState(state)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	if code == "" {
+		return uuid.Nil, fmt.Errorf("не передан код авторизации")
+	}
+
+	company, err := s.getCompany(companyID)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	tokenResp, err := s.client.ExchangeAuthorizationCode(ctx, company.Bitrix24ClientID, company.Bitrix24ClientSecret, code)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	credentials := &Bitrix24Credentials{
+		CompanyID:    companyID,
+		ClientID:     company.Bitrix24ClientID,
+		ClientSecret: company.Bitrix24ClientSecret,
+	}
+	tokenResp.ApplyTo(credentials)
+
+	if err := s.storeTokens(credentials, tokenResp.Scope, ""); err != nil {
+		return uuid.Nil, err
+	}
+
+	s.logger.Printf("Приложение Битрикс24 авторизовано для компании %s (портал %s)", companyID.String(), credentials.Domain)
+	return companyID, nil
+}
+
+// HandleInstall обрабатывает событие установки приложения на портале.
+// Маршрут установки публичный, поэтому токены из события проверяются обновлением до сохранения:
+// токен обновления, выданный не нашему приложению или другому порталу, отклоняется.
+func (s *Bitrix24OAuthService) HandleInstall(ctx context.Context, companyID uuid.UUID, payload *Bitrix24InstallPayload) error {
+	if payload.AuthID == "" || payload.RefreshID == "" {
+		return fmt.Errorf("в событии установки отсутствуют токены авторизации")
+	}
+	if payload.MemberID == "" {
+		return fmt.Errorf("в событии установки отсутствует идентификатор портала")
+	}
+
+	company, err := s.getCompany(companyID)
+	if err != nil {
+		return err
+	}
+
+	tokenResp, err := s.client.ExchangeRefreshToken(ctx, company.Bitrix24ClientID, company.Bitrix24ClientSecret, payload.RefreshID)
+	if err != nil {
+		return fmt.Errorf("не удалось подтвердить токены установки: %w", err)
+	}
+	if tokenResp.MemberID != payload.MemberID {
+		return fmt.Errorf("токены установки выданы другому порталу Битрикс24")
+	}
+
+	// Переустановка допускается только с уже привязанного портала
+	integration, err := s.getIntegration(companyID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("ошибка поиска интеграции Битрикс24: %w", err)
+	}
+	if integration != nil {
+		settings, err := parseBitrix24OAuthSettings(integration.Settings)
+		if err != nil {
+			return err
+		}
+		if settings.MemberID != "" && settings.MemberID != tokenResp.MemberID {
+			return fmt.Errorf("компания уже привязана к другому порталу Битрикс24")
+		}
+	}
+
+	credentials := &Bitrix24Credentials{
+		CompanyID:      companyID,
+		Domain:         normalizeBitrix24Domain(payload.Domain),
+		ClientEndpoint: payload.ServerEndpoint,
+		ClientID:       company.Bitrix24ClientID,
+		ClientSecret:   company.Bitrix24ClientSecret,
+	}
+	tokenResp.ApplyTo(credentials)
+	if credentials.ClientEndpoint == "" && credentials.Domain != "" {
+		credentials.ClientEndpoint = "https://" + credentials.Domain + "/rest/"
+	}
+
+	if err := s.storeTokens(credentials, tokenResp.Scope, payload.ApplicationToken); err != nil {
+		return err
+	}
+
+	s.logger.Printf("Приложение Битрикс24 установлено для компании %s (портал %s)", companyID.String(), credentials.Domain)
+	return nil
+}
+
+// HandleUninstall обрабатывает событие удаления приложения с портала.
+// Событие принимается только с токеном приложения, сохраненным при установке.
+func (s *Bitrix24OAuthService) HandleUninstall(ctx context.Context, memberID, applicationToken string) error {
+	var integrations []models.Integration
+	if err := s.db.Where("integration_type = ?", models.IntegrationServiceBitrix24).Find(&integrations).Error; err != nil {
+		return fmt.Errorf("ошибка поиска интеграций Битрикс24: %w", err)
+	}
+
+	for i := range integrations {
+		settings, err := parseBitrix24OAuthSettings(integrations[i].Settings)
+		if err != nil || settings.MemberID == "" || settings.MemberID != memberID {
+			continue
+		}
+		if settings.ApplicationToken == "" || !hmac.Equal([]byte(settings.ApplicationToken), []byte(applicationToken)) {
+			return fmt.Errorf("неверный токен приложения в событии удаления")
+		}
+
+		integrations[i].MarkReauthRequired("Приложение удалено с портала Битрикс24")
+		if err := s.db.Save(&integrations[i]).Error; err != nil {
+			return fmt.Errorf("ошибка обновления интеграции: %w", err)
+		}
+		s.logger.Printf("Приложение Битрикс24 удалено с портала %s", settings.Domain)
+		return nil
+	}
+
+	return fmt.Errorf("интеграция для портала %s не найдена", memberID)
+}
+
+// GetCredentials возвращает OAuth учетные данные компании для вызова Bitrix24Client.CallMethod
+func (s *Bitrix24OAuthService) GetCredentials(ctx context.Context, companyID uuid.UUID) (*Bitrix24Credentials, error) {
+	integration, err := s.getIntegration(companyID)
+	if err != nil {
+		return nil, fmt.Errorf("интеграция с Битрикс24 не настроена для компании %s: %w", companyID.String(), err)
+	}
+
+	if integration.NeedsReauth {
+		return nil, ErrBitrix24TokenRevoked
+	}
+
+	settings, err := parseBitrix24OAuthSettings(integration.Settings)
+	if err != nil {
+		return nil, err
+	}
+
+	company, err := s.getCompany(companyID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Bitrix24Credentials{
+		CompanyID:      companyID,
+		Domain:         settings.Domain,
+		ClientEndpoint: settings.ClientEndpoint,
+		MemberID:       settings.MemberID,
+		ClientID:       company.Bitrix24ClientID,
+		ClientSecret:   company.Bitrix24ClientSecret,
+		AccessToken:    settings.AccessToken,
+		RefreshToken:   settings.RefreshToken,
+		ExpiresAt:      settings.ExpiresAt,
+	}, nil
+}
+
+// GetStatus возвращает состояние OAuth авторизации компании
+func (s *Bitrix24OAuthService) GetStatus(companyID uuid.UUID) (map[string]interface{}, error) {
+	integration, err := s.getIntegration(companyID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return map[string]interface{}{"connected": false}, nil
+		}
+		return nil, err
+	}
+
+	settings, err := parseBitrix24OAuthSettings(integration.Settings)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"connected":     settings.AccessToken != "" && !integration.NeedsReauth,
+		"domain":        settings.Domain,
+		"expires_at":    settings.ExpiresAt,
+		"needs_reauth":  integration.NeedsReauth,
+		"is_healthy":    integration.IsHealthy(),
+		"error_message": integration.ErrorMessage,
+		"last_error_at": integration.LastErrorAt,
+	}, nil
+}
+
+// SaveTokens сохраняет обновленные токены (реализация Bitrix24TokenStore)
+func (s *Bitrix24OAuthService) SaveTokens(ctx context.Context, credentials *Bitrix24Credentials) error {
+	return s.storeTokens(credentials, "", "")
+}
+
+// LoadTokens возвращает сохраненные токены компании (реализация Bitrix24TokenStore)
+func (s *Bitrix24OAuthService) LoadTokens(ctx context.Context, credentials *Bitrix24Credentials) (*Bitrix24Credentials, error) {
+	integration, err := s.getIntegration(credentials.CompanyID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("ошибка поиска интеграции Битрикс24: %w", err)
+	}
+	if integration.NeedsReauth {
+		return nil, ErrBitrix24TokenRevoked
+	}
+
+	settings, err := parseBitrix24OAuthSettings(integration.Settings)
+	if err != nil {
+		return nil, err
+	}
+
+	stored := *credentials
+	stored.AccessToken = settings.AccessToken
+	stored.RefreshToken = settings.RefreshToken
+	stored.ExpiresAt = settings.ExpiresAt
+	return &stored, nil
+}
+
+// MarkRevoked помечает интеграцию как требующую повторной авторизации (реализация Bitrix24TokenStore)
+func (s *Bitrix24OAuthService) MarkRevoked(ctx context.Context, credentials *Bitrix24Credentials, reason string) error {
+	integration, err := s.getIntegration(credentials.CompanyID)
+	if err != nil {
+		return fmt.Errorf("интеграция с Битрикс24 не найдена: %w", err)
+	}
+
+	integration.MarkReauthRequired(reason)
+	if err := s.db.Save(integration).Error; err != nil {
+		return fmt.Errorf("ошибка обновления интеграции: %w", err)
+	}
+
+	s.logger.Printf("Интеграция Битрикс24 компании %s помечена как требующая повторной авторизации: %s",
+		credentials.CompanyID.String(), reason)
+	return nil
+}
+
+// storeTokens создает или обновляет интеграцию компании с новыми токенами
+func (s *Bitrix24OAuthService) storeTokens(credentials *Bitrix24Credentials, scope, applicationToken string) error {
+	integration, err := s.getIntegration(credentials.CompanyID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("ошибка поиска интеграции Битрикс24: %w", err)
+	}
+
+	settings := &Bitrix24OAuthSettings{}
+	if integration != nil {
+		if existing, err := parseBitrix24OAuthSettings(integration.Settings); err == nil {
+			settings = existing
+		}
+	}
+
+	settings.Domain = credentials.Domain
+	settings.ClientEndpoint = credentials.ClientEndpoint
+	settings.MemberID = credentials.MemberID
+	settings.AccessToken = credentials.AccessToken
+	settings.RefreshToken = credentials.RefreshToken
+	settings.ExpiresAt = credentials.ExpiresAt
+	if scope != "" {
+		settings.Scope = scope
+	}
+	if applicationToken != "" {
+		settings.ApplicationToken = applicationToken
+	}
+
+	settingsJSON, err := json.Marshal(settings)
+	if err != nil {
+		return fmt.Errorf("ошибка сериализации настроек Битрикс24: %w", err)
+	}
+
+	if integration == nil {
+		integration = &models.Integration{
+			CompanyID:       credentials.CompanyID,
+			IntegrationType: models.IntegrationServiceBitrix24,
+			Name:            "Интеграция с Битрикс24",
+			Description:     "OAuth приложение Битрикс24 (портал " + settings.Domain + ")",
+			Settings:        string(settingsJSON),
+			IsActive:        true,
+		}
+		if err := s.db.Create(integration).Error; err != nil {
+			return fmt.Errorf("ошибка создания интеграции Битрикс24: %w", err)
+		}
+		return nil
+	}
+
+	integration.Settings = string(settingsJSON)
+	integration.IsActive = true
+	integration.NeedsReauth = false
+	integration.ErrorMessage = ""
+	integration.LastErrorAt = nil
+	if err := s.db.Save(integration).Error; err != nil {
+		return fmt.Errorf("ошибка сохранения токенов Битрикс24: %w", err)
+	}
+
+	return nil
+}
+
+// getIntegration получает интеграцию Битрикс24 компании
+func (s *Bitrix24OAuthService) getIntegration(companyID uuid.UUID) (*models.Integration, error) {
+	var integration models.Integration
+	if err := s.db.Where("company_id = ? AND integration_type = ?", companyID, models.IntegrationServiceBitrix24).
+		First(&integration).Error; err != nil {
+		return nil, err
+	}
+	return &integration, nil
+}
+
+// getCompany получает компанию с настроенным OAuth приложением
+func (s *Bitrix24OAuthService) getCompany(companyID uuid.UUID) (*models.Company, error) {
+	var company models.Company
+	if err := s.db.Where("id = ?", companyID).First(&company).Error; err != nil {
+		return nil, fmt.Errorf("компания не найдена: %w", err)
+	}
+
+	if company.Bitrix24ClientID == "" || company.Bitrix24ClientSecret == "" {
+		return nil, fmt.Errorf("для компании не настроены client_id и client_secret приложения Битрикс24")
+	}
+
+	return &company, nil
+}
+
+// signState подписывает идентификатор компании секретом приложения
+func (s *Bitrix24OAuthService) signState(company *models.Company) string {
+	mac := hmac.New(sha256.New, []byte(company.Bitrix24ClientSecret))
+	mac.Write([]byte(company.ID.String()))
+	return company.ID.String() + "." + hex.EncodeToString(mac.Sum(nil))
+}
+
+// verifyState проверяет подпись state и возвращает ID компании
+func (s *Bitrix24OAuthService) verifyState(state string) (uuid.UUID, error) {
+	parts := strings.SplitN(state, ".", 2)
+	if len(parts) != 2 {
+		return uuid.Nil, fmt.Errorf("некорректный параметр state")
+	}
+
+	companyID, err := uuid.Parse(parts[0])
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("некорректный параметр state: %w", err)
+	}
+
+	company, err := s.getCompany(companyID)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	if !hmac.Equal([]byte(s.signState(company)), []byte(state)) {
+		return uuid.Nil, fmt.Errorf("неверная подпись параметра state")
+	}
+
+	return companyID, nil
+}
+
+// parseBitrix24OAuthSettings разбирает настройки OAuth из JSON
+func parseBitrix24OAuthSettings(data string) (*Bitrix24OAuthSettings, error) {
+	settings := &Bitrix24OAuthSettings{}
+	if data == "" {
+		return settings, nil
+	}
+	if err := json.Unmarshal([]byte(data), settings); err != nil {
+		return nil, fmt.Errorf("ошибка парсинга настроек Битрикс24: %w", err)
+	}
+	return settings, nil
+}
+
+// normalizeBitrix24Domain убирает протокол и завершающий слэш из домена портала
+func normalizeBitrix24Domain(domain string) string {
+	domain = strings.TrimSpace(domain)
+	domain = strings.TrimPrefix(domain, "https://")
+	domain = strings.TrimPrefix(domain, "http://")
+	return strings.TrimRight(domain, "/")
+}
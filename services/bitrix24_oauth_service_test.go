@@ -0,0 +1,125 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"backend_axenta/models"
+)
+
+func setupBitrix24OAuthServiceTest(t *testing.T, tokenHandler http.HandlerFunc) (*gorm.DB, *Bitrix24OAuthService, uuid.UUID) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+
+	// Таблица компаний создается вручную: значение по умолчанию для UUID не поддерживается SQLite
+	require.NoError(t, db.Exec(`CREATE TABLE companies (id TEXT PRIMARY KEY, name TEXT)`).Error)
+	require.NoError(t, db.AutoMigrate(&models.Integration{}))
+
+	company := models.Company{ID: uuid.New(), Name: "Тестовая компания", Bitrix24ClientID: "app.123", Bitrix24ClientSecret: "secret"}
+	require.NoError(t, db.Create(&company).Error)
+	companyID := company.ID
+
+	client, _, _ := setupBitrix24OAuthServer(t, tokenHandler)
+	service := NewBitrix24OAuthService(db, client, log.New(os.Stdout, "TEST: ", log.LstdFlags))
+	return db, service, companyID
+}
+
+// bitrix24RefreshHandler сервер авторизации, принимающий только токен обновления valid-refresh портала member-1
+func bitrix24RefreshHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if query.Get("client_id") != "app.123" || query.Get("refresh_token") != "valid-refresh" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant", "error_description": "Invalid refresh token"})
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"access_token":  "verified-token",
+		"refresh_token": "verified-refresh",
+		"expires_in":    3600,
+		"member_id":     "member-1",
+		"domain":        "example.bitrix24.ru",
+	})
+}
+
+func TestBitrix24OAuthService_HandleInstall(t *testing.T) {
+	db, service, companyID := setupBitrix24OAuthServiceTest(t, bitrix24RefreshHandler)
+	ctx := context.Background()
+
+	// Поддельные токены не сохраняются
+	err := service.HandleInstall(ctx, companyID, &Bitrix24InstallPayload{
+		AuthID: "forged", RefreshID: "forged-refresh", MemberID: "member-1", ApplicationToken: "app-token",
+	})
+	require.Error(t, err)
+	var count int64
+	db.Model(&models.Integration{}).Count(&count)
+	assert.Equal(t, int64(0), count)
+
+	// Токены другого портала отклоняются
+	err = service.HandleInstall(ctx, companyID, &Bitrix24InstallPayload{
+		AuthID: "token", RefreshID: "valid-refresh", MemberID: "member-2", ApplicationToken: "app-token",
+	})
+	require.Error(t, err)
+
+	require.NoError(t, service.HandleInstall(ctx, companyID, &Bitrix24InstallPayload{
+		AuthID: "token", RefreshID: "valid-refresh", MemberID: "member-1", Domain: "example.bitrix24.ru", ApplicationToken: "app-token",
+	}))
+	credentials, err := service.GetCredentials(ctx, companyID)
+	require.NoError(t, err)
+	assert.Equal(t, "verified-token", credentials.AccessToken)
+	assert.Equal(t, "verified-refresh", credentials.RefreshToken)
+	assert.Equal(t, "member-1", credentials.MemberID)
+
+	// Компания, привязанная к порталу, не переустанавливается с другого портала
+	var integration models.Integration
+	require.NoError(t, db.First(&integration).Error)
+	settings, err := parseBitrix24OAuthSettings(integration.Settings)
+	require.NoError(t, err)
+	settings.MemberID = "member-0"
+	data, _ := json.Marshal(settings)
+	require.NoError(t, db.Model(&integration).Update("settings", string(data)).Error)
+	err = service.HandleInstall(ctx, companyID, &Bitrix24InstallPayload{
+		AuthID: "token", RefreshID: "valid-refresh", MemberID: "member-1", ApplicationToken: "app-token",
+	})
+	assert.ErrorContains(t, err, "другому порталу")
+}
+
+func TestBitrix24OAuthService_HandleUninstall(t *testing.T) {
+	db, service, companyID := setupBitrix24OAuthServiceTest(t, bitrix24RefreshHandler)
+	ctx := context.Background()
+
+	require.NoError(t, service.HandleInstall(ctx, companyID, &Bitrix24InstallPayload{
+		AuthID: "token", RefreshID: "valid-refresh", MemberID: "member-1",
+	}))
+
+	// Без сохраненного токена приложения событие удаления не принимается
+	assert.Error(t, service.HandleUninstall(ctx, "member-1", ""))
+	assert.Error(t, service.HandleUninstall(ctx, "member-1", "forged"))
+
+	var integration models.Integration
+	require.NoError(t, db.First(&integration).Error)
+	assert.False(t, integration.NeedsReauth)
+
+	settings, err := parseBitrix24OAuthSettings(integration.Settings)
+	require.NoError(t, err)
+	settings.ApplicationToken = "app-token"
+	data, _ := json.Marshal(settings)
+	require.NoError(t, db.Model(&integration).Update("settings", string(data)).Error)
+
+	assert.Error(t, service.HandleUninstall(ctx, "member-1", "forged"))
+	require.NoError(t, service.HandleUninstall(ctx, "member-1", "app-token"))
+	require.NoError(t, db.First(&integration, integration.ID).Error)
+	assert.True(t, integration.NeedsReauth)
+}
@@ -0,0 +1,279 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"backend_axenta/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ErrClientDuplicate клиент с такими ИНН и КПП уже существует
+var ErrClientDuplicate = errors.New("клиент с такими ИНН и КПП уже существует")
+
+// ClientService предоставляет функции для работы со справочником клиентов
+type ClientService struct {
+	db *gorm.DB
+}
+
+// NewClientService создает новый сервис клиентов
+func NewClientService(db *gorm.DB) *ClientService {
+	return &ClientService{db: db}
+}
+
+// FindByRequisites ищет клиента компании по ИНН и КПП.
+// У индивидуальных предпринимателей КПП отсутствует, поэтому пустой КПП сравнивается как значение.
+func (s *ClientService) FindByRequisites(companyID uuid.UUID, inn, kpp string) (*models.Client, error) {
+	if inn == "" {
+		return nil, nil
+	}
+
+	var client models.Client
+	err := s.db.Where("company_id = ? AND inn = ? AND kpp = ? AND is_folder = ?", companyID, inn, kpp, false).
+		Order("id").First(&client).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка поиска клиента: %w", err)
+	}
+	return &client, nil
+}
+
+// FindByExternalID ищет клиента по идентификатору во внешней системе
+func (s *ClientService) FindByExternalID(companyID uuid.UUID, source, externalID string) (*models.Client, error) {
+	if externalID == "" {
+		return nil, nil
+	}
+
+	var client models.Client
+	err := s.db.Where("company_id = ? AND external_source = ? AND external_id = ?", companyID, source, externalID).
+		First(&client).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка поиска клиента: %w", err)
+	}
+	return &client, nil
+}
+
+// GetClient возвращает клиента компании по ID
+func (s *ClientService) GetClient(companyID uuid.UUID, clientID uint) (*models.Client, error) {
+	var client models.Client
+	if err := s.db.Preload("Parent").Where("id = ? AND company_id = ?", clientID, companyID).First(&client).Error; err != nil {
+		return nil, fmt.Errorf("клиент не найден: %w", err)
+	}
+	return &client, nil
+}
+
+// CreateClient создает клиента, не допуская дубликатов по ИНН+КПП
+func (s *ClientService) CreateClient(client *models.Client) error {
+	if client.HasRequisites() {
+		existing, err := s.FindByRequisites(client.CompanyID, client.INN, client.KPP)
+		if err != nil {
+			return err
+		}
+		if existing != nil {
+			return ErrClientDuplicate
+		}
+	}
+
+	isActive := client.IsActive
+	if err := s.db.Create(client).Error; err != nil {
+		return fmt.Errorf("ошибка создания клиента: %w", err)
+	}
+	return persistClientStatus(s.db, client, isActive)
+}
+
+// UpdateClient сохраняет изменения клиента и обновляет реквизиты в связанных договорах
+func (s *ClientService) UpdateClient(client *models.Client) error {
+	if client.HasRequisites() {
+		existing, err := s.FindByRequisites(client.CompanyID, client.INN, client.KPP)
+		if err != nil {
+			return err
+		}
+		if existing != nil && existing.ID != client.ID {
+			return ErrClientDuplicate
+		}
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(client).Error; err != nil {
+			return fmt.Errorf("ошибка обновления клиента: %w", err)
+		}
+		return syncClientContracts(tx, client)
+	})
+}
+
+// DeleteClient удаляет клиента, если на него не ссылаются договоры, счета или вложенные клиенты
+func (s *ClientService) DeleteClient(companyID uuid.UUID, clientID uint) error {
+	client, err := s.GetClient(companyID, clientID)
+	if err != nil {
+		return err
+	}
+
+	var contracts, invoices, children int64
+	s.db.Model(&models.Contract{}).Where("client_id = ?", client.ID).Count(&contracts)
+	s.db.Model(&models.Invoice{}).Where("client_id = ?", client.ID).Count(&invoices)
+	s.db.Model(&models.Client{}).Where("parent_id = ?", client.ID).Count(&children)
+	if contracts > 0 || invoices > 0 || children > 0 {
+		return fmt.Errorf("клиент используется: договоров %d, счетов %d, вложенных клиентов %d", contracts, invoices, children)
+	}
+
+	if err := s.db.Delete(client).Error; err != nil {
+		return fmt.Errorf("ошибка удаления клиента: %w", err)
+	}
+	return nil
+}
+
+// FindOrCreate возвращает существующего клиента с теми же ИНН+КПП или создает нового
+func (s *ClientService) FindOrCreate(client *models.Client) (*models.Client, bool, error) {
+	if client.HasRequisites() {
+		existing, err := s.FindByRequisites(client.CompanyID, client.INN, client.KPP)
+		if err != nil {
+			return nil, false, err
+		}
+		if existing != nil {
+			return existing, false, nil
+		}
+	}
+
+	if err := s.db.Create(client).Error; err != nil {
+		return nil, false, fmt.Errorf("ошибка создания клиента: %w", err)
+	}
+	return client, true, nil
+}
+
+// ResolveContractClient привязывает договор к клиенту из справочника.
+// Если клиент не указан явно, он ищется по ИНН+КПП договора и создается при отсутствии.
+func (s *ClientService) ResolveContractClient(contract *models.Contract) error {
+	if contract.ClientID != nil {
+		client, err := s.GetClient(contract.CompanyID, *contract.ClientID)
+		if err != nil {
+			return err
+		}
+		if client.IsFolder {
+			return fmt.Errorf("нельзя привязать договор к группе клиентов")
+		}
+		contract.ApplyClient(client)
+		return nil
+	}
+
+	if contract.ClientINN == "" || contract.ClientName == "" {
+		return nil // Клиент без реквизитов остается только в договоре
+	}
+
+	client, _, err := s.FindOrCreate(&models.Client{
+		CompanyID:    contract.CompanyID,
+		Name:         contract.ClientName,
+		INN:          contract.ClientINN,
+		KPP:          contract.ClientKPP,
+		Email:        contract.ClientEmail,
+		Phone:        contract.ClientPhone,
+		LegalAddress: contract.ClientAddress,
+		IsActive:     true,
+	})
+	if err != nil {
+		return err
+	}
+
+	contract.ClientID = &client.ID
+	return nil
+}
+
+// UpsertExternal создает или обновляет клиента, полученного из внешней системы.
+// Клиент ищется по внешнему ID, а затем по ИНН+КПП, чтобы не создавать дубликат
+// уже заведенного вручную клиента.
+func (s *ClientService) UpsertExternal(companyID uuid.UUID, source string, incoming *models.Client) (*models.Client, bool, error) {
+	client, err := s.FindByExternalID(companyID, source, incoming.ExternalID)
+	if err != nil {
+		return nil, false, err
+	}
+	if client == nil && incoming.HasRequisites() {
+		if client, err = s.FindByRequisites(companyID, incoming.INN, incoming.KPP); err != nil {
+			return nil, false, err
+		}
+	}
+
+	if client == nil {
+		incoming.CompanyID = companyID
+		incoming.ExternalSource = source
+		isActive := incoming.IsActive
+		if err := s.db.Create(incoming).Error; err != nil {
+			return nil, false, fmt.Errorf("ошибка создания клиента: %w", err)
+		}
+		if err := persistClientStatus(s.db, incoming, isActive); err != nil {
+			return nil, false, err
+		}
+		return incoming, true, nil
+	}
+
+	client.Name = incoming.Name
+	client.IsFolder = incoming.IsFolder
+	client.IsActive = incoming.IsActive
+	client.ExternalID = incoming.ExternalID
+	client.ExternalSource = source
+	for _, field := range []struct {
+		dst *string
+		src string
+	}{
+		{&client.FullName, incoming.FullName},
+		{&client.INN, incoming.INN},
+		{&client.KPP, incoming.KPP},
+		{&client.OGRN, incoming.OGRN},
+		{&client.LegalAddress, incoming.LegalAddress},
+		{&client.ActualAddress, incoming.ActualAddress},
+		{&client.Phone, incoming.Phone},
+		{&client.Email, incoming.Email},
+	} {
+		// Пустые значения из внешней системы не затирают заполненные вручную данные
+		if field.src != "" {
+			*field.dst = field.src
+		}
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(client).Error; err != nil {
+			return fmt.Errorf("ошибка обновления клиента: %w", err)
+		}
+		return syncClientContracts(tx, client)
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return client, false, nil
+}
+
+// persistClientStatus сохраняет признак неактивности нового клиента:
+// при создании gorm подставляет значение по умолчанию вместо false
+func persistClientStatus(db *gorm.DB, client *models.Client, isActive bool) error {
+	if isActive || !client.IsActive {
+		return nil
+	}
+	if err := db.Model(client).Update("is_active", false).Error; err != nil {
+		return fmt.Errorf("ошибка обновления статуса клиента: %w", err)
+	}
+	return nil
+}
+
+// syncClientContracts обновляет реквизиты клиента в привязанных к нему договорах
+func syncClientContracts(tx *gorm.DB, client *models.Client) error {
+	if client.IsFolder {
+		return nil
+	}
+
+	if err := tx.Model(&models.Contract{}).Where("client_id = ?", client.ID).Updates(map[string]interface{}{
+		"client_name":    client.Name,
+		"client_inn":     client.INN,
+		"client_kpp":     client.KPP,
+		"client_email":   client.Email,
+		"client_phone":   client.Phone,
+		"client_address": client.LegalAddress,
+	}).Error; err != nil {
+		return fmt.Errorf("ошибка обновления реквизитов клиента в договорах: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"backend_axenta/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func setupClientServiceTest(t *testing.T) (*gorm.DB, *ClientService, uuid.UUID) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+
+	// Таблица компаний создается вручную: значение по умолчанию для UUID не поддерживается SQLite
+	require.NoError(t, db.Exec(`CREATE TABLE companies (id TEXT PRIMARY KEY, name TEXT)`).Error)
+	require.NoError(t, db.AutoMigrate(&models.Client{}, &models.Contract{}, &models.Invoice{}))
+
+	return db, NewClientService(db), uuid.New()
+}
+
+func TestClientService_CreateClientRejectsDuplicate(t *testing.T) {
+	_, service, companyID := setupClientServiceTest(t)
+
+	require.NoError(t, service.CreateClient(&models.Client{CompanyID: companyID, Name: "ООО Ромашка", INN: "7701234567", KPP: "770101001"}))
+
+	err := service.CreateClient(&models.Client{CompanyID: companyID, Name: "Ромашка", INN: "7701234567", KPP: "770101001"})
+	assert.ErrorIs(t, err, ErrClientDuplicate)
+
+	// Обособленное подразделение с другим КПП - отдельный клиент
+	assert.NoError(t, service.CreateClient(&models.Client{CompanyID: companyID, Name: "ООО Ромашка (филиал)", INN: "7701234567", KPP: "770201001"}))
+}
+
+func TestClientService_ResolveContractClient(t *testing.T) {
+	db, service, companyID := setupClientServiceTest(t)
+
+	newContract := func() *models.Contract {
+		return &models.Contract{
+			Number:     "Д-" + uuid.NewString()[:8],
+			Title:      "Мониторинг",
+			CompanyID:  companyID,
+			ClientName: "ООО Ромашка",
+			ClientINN:  "7701234567",
+			StartDate:  time.Now(),
+			EndDate:    time.Now().AddDate(1, 0, 0),
+		}
+	}
+
+	// Два договора с одним ИНН ссылаются на одного клиента
+	first, second := newContract(), newContract()
+	require.NoError(t, service.ResolveContractClient(first))
+	require.NoError(t, service.ResolveContractClient(second))
+	require.NotNil(t, first.ClientID)
+	assert.Equal(t, *first.ClientID, *second.ClientID)
+	require.NoError(t, db.Create(first).Error)
+
+	// Изменение реквизитов клиента обновляет связанные договоры
+	client, err := service.GetClient(companyID, *first.ClientID)
+	require.NoError(t, err)
+	client.Name = "ООО Ромашка Плюс"
+	client.Email = "info@romashka.ru"
+	require.NoError(t, service.UpdateClient(client))
+
+	var contract models.Contract
+	require.NoError(t, db.First(&contract, first.ID).Error)
+	assert.Equal(t, "ООО Ромашка Плюс", contract.ClientName)
+	assert.Equal(t, "info@romashka.ru", contract.ClientEmail)
+
+	// Клиент с договорами не удаляется
+	assert.Error(t, service.DeleteClient(companyID, client.ID))
+}
+
+func TestOneCIntegrationService_ImportCounterpartiesHierarchy(t *testing.T) {
+	db, service, fake, companyID := setupOneCDocumentSyncTest(t)
+
+	// Клиент, заведенный вручную до подключения 1С
+	manual := &models.Client{CompanyID: companyID, Name: "Ромашка", INN: "7701234567", KPP: "770101001", Phone: "+7 900 000-00-00", IsActive: true}
+	require.NoError(t, db.Create(manual).Error)
+
+	fake.counterparties = []map[string]interface{}{
+		{"Ref_Key": "cp-1", "Description": "ООО Ромашка", "INN": "7701234567", "KPP": "770101001", "Parent_Key": "folder-1", "IsActive": true},
+		{"Ref_Key": "cp-2", "Description": "ООО Лютик", "INN": "7709876543", "Parent_Key": "folder-1", "IsActive": true, "DeletionMark": true},
+		{"Ref_Key": "folder-1", "Description": "Покупатели", "IsFolder": true, "IsActive": true},
+	}
+
+	require.NoError(t, service.ImportCounterparties(context.Background(), companyID))
+
+	var clients []models.Client
+	require.NoError(t, db.Where("company_id = ?", companyID).Order("id").Find(&clients).Error)
+	require.Len(t, clients, 3)
+
+	// Существующий клиент не дублируется, а привязывается к 1С
+	romashka := clients[0]
+	assert.Equal(t, manual.ID, romashka.ID)
+	assert.Equal(t, "cp-1", romashka.ExternalID)
+	assert.Equal(t, "ООО Ромашка", romashka.Name)
+	assert.Equal(t, "+7 900 000-00-00", romashka.Phone)
+
+	folder := clients[2]
+	assert.True(t, folder.IsFolder)
+	require.NotNil(t, romashka.ParentID)
+	assert.Equal(t, folder.ID, *romashka.ParentID)
+
+	// Помеченный на удаление контрагент импортируется неактивным
+	assert.Equal(t, "cp-2", clients[1].ExternalID)
+	assert.False(t, clients[1].IsActive)
+
+	// Повторный импорт не создает новых клиентов
+	require.NoError(t, service.ImportCounterparties(context.Background(), companyID))
+	var total int64
+	db.Model(&models.Client{}).Where("company_id = ?", companyID).Count(&total)
+	assert.Equal(t, int64(3), total)
+}
@@ -0,0 +1,303 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"backend_axenta/models"
+)
+
+// DispatchJobRequest заявка на монтаж для автоматического распределения
+type DispatchJobRequest struct {
+	ObjectID       uint   `json:"object_id"`
+	Type           string `json:"type"` // По умолчанию "монтаж"
+	Description    string `json:"description"`
+	ClientContact  string `json:"client_contact"`
+	Address        string `json:"address"`
+	Specialization string `json:"specialization"`
+	SkillLevel     string `json:"skill_level"`
+	Priority       string `json:"priority"`
+	Duration       int    `json:"duration"`     // Минуты, по умолчанию 120
+	WindowStart    string `json:"window_start"` // HH:MM - не раньше
+	WindowEnd      string `json:"window_end"`   // HH:MM - начать не позже
+}
+
+// DispatchRequest запрос на распределение заявок на день
+type DispatchRequest struct {
+	Date         string               `json:"date"` // YYYY-MM-DD
+	Jobs         []DispatchJobRequest `json:"jobs"`
+	InstallerIDs []uint               `json:"installer_ids"` // Пусто - все доступные монтажники
+	Options      DispatchOptions      `json:"options"`
+}
+
+// ProposeDispatch предлагает распределение заявок между монтажниками и маршруты на день.
+// Уже запланированные на этот день монтажи учитываются как занятое время.
+func (s *InstallationService) ProposeDispatch(req DispatchRequest) (*DispatchPlan, error) {
+	return s.proposeDispatch(&req)
+}
+
+// proposeDispatch строит план, дополняя заявки значениями по умолчанию
+func (s *InstallationService) proposeDispatch(req *DispatchRequest) (*DispatchPlan, error) {
+	date, jobs, installers, err := s.loadDispatchInput(req)
+	if err != nil {
+		return nil, err
+	}
+	return SolveDispatch(date, jobs, installers, req.Options), nil
+}
+
+// ApplyDispatch распределяет заявки и создает запланированные монтажи по полученным маршрутам.
+// Нераспределенные заявки возвращаются в плане и не создаются.
+func (s *InstallationService) ApplyDispatch(req DispatchRequest, userID uint) (*DispatchPlan, []models.Installation, error) {
+	plan, err := s.proposeDispatch(&req)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var objects []models.Object
+	objectIDs := make([]uint, 0, len(req.Jobs))
+	for _, job := range req.Jobs {
+		objectIDs = append(objectIDs, job.ObjectID)
+	}
+	if err := s.DB.Where("id IN ?", objectIDs).Find(&objects).Error; err != nil {
+		return nil, nil, fmt.Errorf("ошибка при получении объектов: %w", err)
+	}
+	objectsByID := make(map[uint]models.Object, len(objects))
+	for _, object := range objects {
+		objectsByID[object.ID] = object
+	}
+
+	var created []models.Installation
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		for r := range plan.Routes {
+			route := &plan.Routes[r]
+			for i := range route.Stops {
+				stop := &route.Stops[i]
+				if stop.JobIndex == nil {
+					continue
+				}
+				job := req.Jobs[*stop.JobIndex]
+				object := objectsByID[job.ObjectID]
+
+				installation := models.Installation{
+					Type:              job.Type,
+					Status:            "planned",
+					Priority:          job.Priority,
+					Description:       job.Description,
+					ScheduledAt:       stop.Start,
+					EstimatedDuration: job.Duration,
+					ObjectID:          job.ObjectID,
+					InstallerID:       route.InstallerID,
+					ClientContact:     job.ClientContact,
+					Address:           job.Address,
+					TravelTime:        stop.TravelMinutes,
+					CreatedByUserID:   userID,
+				}
+				if installation.Address == "" {
+					installation.Address = object.Address
+				}
+				if object.LocationID != 0 {
+					locationID := object.LocationID
+					installation.LocationID = &locationID
+				}
+				if err := tx.Create(&installation).Error; err != nil {
+					return fmt.Errorf("ошибка при создании монтажа для объекта %d: %w", job.ObjectID, err)
+				}
+
+				installationID := installation.ID
+				stop.InstallationID = &installationID
+				created = append(created, installation)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if s.NotificationService != nil {
+		for i := range created {
+			go s.sendInstallationNotifications(&created[i], "created")
+		}
+	}
+
+	return plan, created, nil
+}
+
+// loadDispatchInput загружает объекты заявок, доступных монтажников и их занятость на день
+func (s *InstallationService) loadDispatchInput(req *DispatchRequest) (time.Time, []DispatchJob, []DispatchInstaller, error) {
+	date, err := time.ParseInLocation("2006-01-02", req.Date, models.LoadTimezone(s.Timezone))
+	if err != nil {
+		return time.Time{}, nil, nil, fmt.Errorf("неверная дата %q, ожидается YYYY-MM-DD", req.Date)
+	}
+	if len(req.Jobs) == 0 {
+		return time.Time{}, nil, nil, fmt.Errorf("не переданы заявки")
+	}
+
+	locations := make(map[uint]*models.Location)
+	locationPoint := func(id uint) (*DispatchPoint, error) {
+		if id == 0 {
+			return nil, nil
+		}
+		location, ok := locations[id]
+		if !ok {
+			location = &models.Location{}
+			if err := s.DB.Find(location, id).Error; err != nil {
+				return nil, fmt.Errorf("ошибка при получении локации: %w", err)
+			}
+			locations[id] = location
+		}
+		return dispatchPoint(location.Latitude, location.Longitude), nil
+	}
+	objectPoint := func(object *models.Object) (*DispatchPoint, error) {
+		if point := dispatchPoint(object.Latitude, object.Longitude); point != nil {
+			return point, nil
+		}
+		return locationPoint(object.LocationID)
+	}
+
+	jobs := make([]DispatchJob, 0, len(req.Jobs))
+	for i := range req.Jobs {
+		job := &req.Jobs[i]
+		if job.Type == "" {
+			job.Type = "монтаж"
+		}
+		if job.Priority == "" {
+			job.Priority = "normal"
+		}
+		if job.Duration <= 0 {
+			job.Duration = 120
+		}
+
+		var object models.Object
+		if err := s.DB.First(&object, job.ObjectID).Error; err != nil {
+			return time.Time{}, nil, nil, fmt.Errorf("заявка %d: объект не найден: %w", i+1, err)
+		}
+		point, err := objectPoint(&object)
+		if err != nil {
+			return time.Time{}, nil, nil, err
+		}
+		// Окна визита заданы в местном времени объекта
+		objectDate := dispatchLocalDate(date, s.scheduleTimezone(&object.LocationID, 0, nil))
+		dispatchJob := DispatchJob{
+			Index:          i,
+			ObjectID:       object.ID,
+			LocationID:     object.LocationID,
+			Point:          point,
+			Specialization: job.Specialization,
+			SkillLevel:     job.SkillLevel,
+			Priority:       job.Priority,
+			Duration:       job.Duration,
+		}
+		if dispatchJob.WindowStart, err = parseDispatchClock(objectDate, job.WindowStart); err != nil {
+			return time.Time{}, nil, nil, fmt.Errorf("заявка %d: %w", i+1, err)
+		}
+		if dispatchJob.WindowEnd, err = parseDispatchClock(objectDate, job.WindowEnd); err != nil {
+			return time.Time{}, nil, nil, fmt.Errorf("заявка %d: %w", i+1, err)
+		}
+		jobs = append(jobs, dispatchJob)
+	}
+
+	query := s.DB.Where("is_active = ? AND status = ?", true, "available")
+	if len(req.InstallerIDs) > 0 {
+		query = query.Where("id IN ?", req.InstallerIDs)
+	}
+	var installers []models.Installer
+	if err := query.Order("id").Find(&installers).Error; err != nil {
+		return time.Time{}, nil, nil, fmt.Errorf("ошибка при получении монтажников: %w", err)
+	}
+
+	result := make([]DispatchInstaller, 0, len(installers))
+	for _, installer := range installers {
+		// Рабочий день и часы монтажника - в часовом поясе его локации
+		localDate := dispatchLocalDate(date, s.scheduleTimezone(nil, 0, &installer))
+		if !installer.IsAvailableOnDate(localDate) {
+			continue
+		}
+		workStart, workEnd := installer.WorkingHoursOn(localDate)
+		dayStart, dayEnd := localDayBounds(localDate)
+
+		dispatchInstaller := DispatchInstaller{
+			ID:                    installer.ID,
+			Name:                  installer.GetDisplayName(),
+			SkillLevel:            installer.SkillLevel,
+			Specialization:        installer.Specialization,
+			LocationIDs:           installer.LocationIDs,
+			Rating:                installer.Rating,
+			MaxDailyInstallations: installer.MaxDailyInstallations,
+			WorkStart:             workStart,
+			WorkEnd:               workEnd,
+		}
+		for _, locationID := range installer.LocationIDs {
+			point, err := locationPoint(locationID)
+			if err != nil {
+				return time.Time{}, nil, nil, err
+			}
+			if point != nil {
+				dispatchInstaller.Start = point
+				break
+			}
+		}
+
+		var planned []models.Installation
+		if err := s.DB.Preload("Object").
+			Where("installer_id = ? AND status IN ? AND scheduled_at >= ? AND scheduled_at < ?",
+				installer.ID, []string{"planned", "in_progress"}, dayStart, dayEnd).
+			Order("scheduled_at").Find(&planned).Error; err != nil {
+			return time.Time{}, nil, nil, fmt.Errorf("ошибка при получении расписания монтажника: %w", err)
+		}
+		for _, installation := range planned {
+			duration := installation.EstimatedDuration
+			if duration <= 0 {
+				duration = 120
+			}
+			slot := DispatchBusySlot{
+				InstallationID: installation.ID,
+				ObjectID:       installation.ObjectID,
+				Start:          installation.ScheduledAt,
+				End:            installation.ScheduledAt.Add(time.Duration(duration) * time.Minute),
+			}
+			if installation.Object != nil {
+				if slot.Point, err = objectPoint(installation.Object); err != nil {
+					return time.Time{}, nil, nil, err
+				}
+			}
+			dispatchInstaller.Busy = append(dispatchInstaller.Busy, slot)
+		}
+
+		result = append(result, dispatchInstaller)
+	}
+	if len(result) == 0 {
+		return time.Time{}, nil, nil, fmt.Errorf("нет монтажников, работающих %s", date.Format("02.01.2006"))
+	}
+
+	return date, jobs, result, nil
+}
+
+// dispatchPoint возвращает точку, если известны обе координаты
+func dispatchPoint(latitude, longitude *float64) *DispatchPoint {
+	if latitude == nil || longitude == nil {
+		return nil
+	}
+	return &DispatchPoint{Latitude: *latitude, Longitude: *longitude}
+}
+
+// dispatchLocalDate возвращает начало того же календарного дня в часовом поясе tz
+func dispatchLocalDate(date time.Time, tz *time.Location) time.Time {
+	return time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, tz)
+}
+
+// parseDispatchClock переводит время HH:MM в момент указанного дня; пустая строка - нулевое время
+func parseDispatchClock(date time.Time, value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	clock, err := time.Parse("15:04", value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("неверное время %q, ожидается HH:MM", value)
+	}
+	return time.Date(date.Year(), date.Month(), date.Day(), clock.Hour(), clock.Minute(), 0, 0, date.Location()), nil
+}
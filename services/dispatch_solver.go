@@ -0,0 +1,408 @@
+package services
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// Параметры расчета времени в пути по умолчанию
+const (
+	DefaultDispatchSpeedKmh      = 40.0 // Средняя скорость в городе
+	DefaultDispatchRoadFactor    = 1.3  // Отношение длины дороги к расстоянию по прямой
+	DefaultDispatchTravelMinutes = 30   // Время в пути, если координаты неизвестны
+)
+
+// Штрафы и бонусы целевой функции распределения, в минутах пути
+const (
+	dispatchOverqualificationPenalty = 15.0 // За каждый уровень квалификации сверх требуемого
+	dispatchRatingBonus              = 10.0 // За каждый балл рейтинга выше 3
+	dispatchLoadPenalty              = 5.0  // За каждую уже назначенную на день работу
+)
+
+// dispatchSkillLevels порядок уровней квалификации монтажников
+var dispatchSkillLevels = map[string]int{"junior": 1, "middle": 2, "senior": 3}
+
+// dispatchPriorities порядок приоритетов монтажей
+var dispatchPriorities = map[string]int{"urgent": 4, "high": 3, "normal": 2, "low": 1}
+
+// DispatchPoint координаты точки маршрута
+type DispatchPoint struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+// DispatchJob заявка на монтаж, которую нужно распределить
+type DispatchJob struct {
+	Index          int            `json:"index"` // Порядковый номер заявки в запросе
+	ObjectID       uint           `json:"object_id"`
+	LocationID     uint           `json:"location_id"`
+	Point          *DispatchPoint `json:"point"`
+	Specialization string         `json:"specialization"`
+	SkillLevel     string         `json:"skill_level"` // Минимальная квалификация
+	Priority       string         `json:"priority"`
+	Duration       int            `json:"duration"`     // Минуты
+	WindowStart    time.Time      `json:"window_start"` // Не раньше; пусто - без ограничения
+	WindowEnd      time.Time      `json:"window_end"`   // Начать не позже; пусто - без ограничения
+}
+
+// DispatchBusySlot уже запланированная работа монтажника
+type DispatchBusySlot struct {
+	InstallationID uint           `json:"installation_id"`
+	ObjectID       uint           `json:"object_id"`
+	Point          *DispatchPoint `json:"point"`
+	Start          time.Time      `json:"start"`
+	End            time.Time      `json:"end"`
+}
+
+// DispatchInstaller монтажник с рабочим днем, квалификацией и уже запланированными работами
+type DispatchInstaller struct {
+	ID                    uint               `json:"id"`
+	Name                  string             `json:"name"`
+	SkillLevel            string             `json:"skill_level"`
+	Specialization        []string           `json:"specialization"`
+	LocationIDs           []uint             `json:"location_ids"`
+	Rating                float32            `json:"rating"`
+	MaxDailyInstallations int                `json:"max_daily_installations"`
+	WorkStart             time.Time          `json:"work_start"`
+	WorkEnd               time.Time          `json:"work_end"`
+	Start                 *DispatchPoint     `json:"start"` // Откуда монтажник начинает день
+	Busy                  []DispatchBusySlot `json:"busy"`
+}
+
+// DispatchOptions параметры расчета времени в пути
+type DispatchOptions struct {
+	SpeedKmh      float64 `json:"speed_kmh"`
+	RoadFactor    float64 `json:"road_factor"`
+	UnknownTravel int     `json:"unknown_travel_minutes"`
+}
+
+// DispatchStop остановка маршрута монтажника
+type DispatchStop struct {
+	JobIndex       *int           `json:"job_index,omitempty"`       // Новая заявка
+	InstallationID *uint          `json:"installation_id,omitempty"` // Уже запланированный монтаж
+	ObjectID       uint           `json:"object_id"`
+	Point          *DispatchPoint `json:"point,omitempty"`
+	Arrival        time.Time      `json:"arrival"`
+	Start          time.Time      `json:"start"`
+	End            time.Time      `json:"end"`
+	TravelMinutes  int            `json:"travel_minutes"`
+	DistanceKm     float64        `json:"distance_km"`
+	Fixed          bool           `json:"fixed"`
+}
+
+// DispatchRoute маршрут монтажника на день
+type DispatchRoute struct {
+	InstallerID   uint           `json:"installer_id"`
+	InstallerName string         `json:"installer_name"`
+	Stops         []DispatchStop `json:"stops"`
+	JobCount      int            `json:"job_count"` // Новых заявок в маршруте
+	TravelMinutes int            `json:"travel_minutes"`
+	DistanceKm    float64        `json:"distance_km"`
+	WorkMinutes   int            `json:"work_minutes"`
+}
+
+// DispatchUnassigned заявка, которую не удалось распределить
+type DispatchUnassigned struct {
+	JobIndex int    `json:"job_index"`
+	ObjectID uint   `json:"object_id"`
+	Reason   string `json:"reason"`
+}
+
+// DispatchPlan результат автоматического распределения
+type DispatchPlan struct {
+	Date               time.Time            `json:"date"`
+	Routes             []DispatchRoute      `json:"routes"`
+	Unassigned         []DispatchUnassigned `json:"unassigned"`
+	AssignedCount      int                  `json:"assigned_count"`
+	TotalTravelMinutes int                  `json:"total_travel_minutes"`
+	TotalDistanceKm    float64              `json:"total_distance_km"`
+}
+
+// normalize подставляет значения по умолчанию
+func (o DispatchOptions) normalize() DispatchOptions {
+	if o.SpeedKmh <= 0 {
+		o.SpeedKmh = DefaultDispatchSpeedKmh
+	}
+	if o.RoadFactor < 1 {
+		o.RoadFactor = DefaultDispatchRoadFactor
+	}
+	if o.UnknownTravel <= 0 {
+		o.UnknownTravel = DefaultDispatchTravelMinutes
+	}
+	return o
+}
+
+// HaversineKm расстояние между точками по поверхности Земли в километрах
+func HaversineKm(a, b DispatchPoint) float64 {
+	const earthRadiusKm = 6371.0
+	toRad := func(deg float64) float64 { return deg * math.Pi / 180 }
+	dLat := toRad(b.Latitude - a.Latitude)
+	dLon := toRad(b.Longitude - a.Longitude)
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRad(a.Latitude))*math.Cos(toRad(b.Latitude))*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(h))
+}
+
+// travel возвращает расстояние по дорогам и время в пути между точками
+func (o DispatchOptions) travel(from, to *DispatchPoint) (float64, int) {
+	if from == nil || to == nil {
+		return 0, o.UnknownTravel
+	}
+	km := HaversineKm(*from, *to) * o.RoadFactor
+	return km, int(math.Ceil(km / o.SpeedKmh * 60))
+}
+
+// dispatchSolver распределяет заявки методом наименьшей вставки: заявки по убыванию
+// приоритета и срочности вставляются в то место маршрута того монтажника, где это
+// дает наименьший прирост пути с учетом квалификации, рейтинга и загрузки.
+type dispatchSolver struct {
+	options    DispatchOptions
+	installers []DispatchInstaller
+	jobs       map[int]DispatchJob
+	routes     [][]int // Индексы заявок в порядке посещения для каждого монтажника
+}
+
+// SolveDispatch строит распределение заявок между монтажниками. Результат детерминирован:
+// при равной стоимости выбирается монтажник с меньшим ID и более ранняя позиция в маршруте.
+func SolveDispatch(date time.Time, jobs []DispatchJob, installers []DispatchInstaller, options DispatchOptions) *DispatchPlan {
+	solver := &dispatchSolver{
+		options:    options.normalize(),
+		installers: append([]DispatchInstaller(nil), installers...),
+		jobs:       make(map[int]DispatchJob, len(jobs)),
+	}
+	sort.Slice(solver.installers, func(i, j int) bool { return solver.installers[i].ID < solver.installers[j].ID })
+	for i := range solver.installers {
+		busy := append([]DispatchBusySlot(nil), solver.installers[i].Busy...)
+		sort.Slice(busy, func(a, b int) bool { return busy[a].Start.Before(busy[b].Start) })
+		solver.installers[i].Busy = busy
+	}
+	solver.routes = make([][]int, len(solver.installers))
+
+	ordered := append([]DispatchJob(nil), jobs...)
+	for _, job := range ordered {
+		solver.jobs[job.Index] = job
+	}
+	sort.SliceStable(ordered, func(i, j int) bool {
+		a, b := ordered[i], ordered[j]
+		if pa, pb := dispatchPriorities[a.Priority], dispatchPriorities[b.Priority]; pa != pb {
+			return pa > pb
+		}
+		if a.WindowEnd.IsZero() != b.WindowEnd.IsZero() {
+			return !a.WindowEnd.IsZero()
+		}
+		if !a.WindowEnd.Equal(b.WindowEnd) {
+			return a.WindowEnd.Before(b.WindowEnd)
+		}
+		if a.Duration != b.Duration {
+			return a.Duration > b.Duration
+		}
+		return a.Index < b.Index
+	})
+
+	plan := &DispatchPlan{Date: date, Routes: []DispatchRoute{}, Unassigned: []DispatchUnassigned{}}
+	for _, job := range ordered {
+		if reason := solver.insert(job); reason != "" {
+			plan.Unassigned = append(plan.Unassigned, DispatchUnassigned{JobIndex: job.Index, ObjectID: job.ObjectID, Reason: reason})
+		}
+	}
+	sort.Slice(plan.Unassigned, func(i, j int) bool { return plan.Unassigned[i].JobIndex < plan.Unassigned[j].JobIndex })
+
+	for i, installer := range solver.installers {
+		if len(solver.routes[i]) == 0 {
+			continue
+		}
+		route, _ := solver.simulate(installer, solver.routes[i])
+		route.InstallerID = installer.ID
+		route.InstallerName = installer.Name
+		plan.Routes = append(plan.Routes, *route)
+		plan.AssignedCount += route.JobCount
+		plan.TotalTravelMinutes += route.TravelMinutes
+		plan.TotalDistanceKm += route.DistanceKm
+	}
+	plan.TotalDistanceKm = math.Round(plan.TotalDistanceKm*10) / 10
+	return plan
+}
+
+// insert вставляет заявку в лучший маршрут; возвращает причину, если это невозможно.
+// Причиной считается проверка, дальше всего пройденная хотя бы одним монтажником.
+func (s *dispatchSolver) insert(job DispatchJob) string {
+	bestInstaller, bestPosition := -1, -1
+	bestScore := math.Inf(1)
+	reason, reasonStage := "нет доступных монтажников", 0
+	reject := func(stage int, why string) {
+		if stage > reasonStage {
+			reason, reasonStage = why, stage
+		}
+	}
+
+	for i, installer := range s.installers {
+		if stage, why := s.eligible(installer, job); why != "" {
+			reject(stage, why)
+			continue
+		}
+		if installer.MaxDailyInstallations > 0 && len(s.routes[i])+len(installer.Busy) >= installer.MaxDailyInstallations {
+			reject(4, "у подходящих монтажников нет свободных слотов на день")
+			continue
+		}
+
+		current, _ := s.simulate(installer, s.routes[i])
+		for position := 0; position <= len(s.routes[i]); position++ {
+			candidate := make([]int, 0, len(s.routes[i])+1)
+			candidate = append(candidate, s.routes[i][:position]...)
+			candidate = append(candidate, job.Index)
+			candidate = append(candidate, s.routes[i][position:]...)
+
+			route, ok := s.simulate(installer, candidate)
+			if !ok {
+				reject(5, "не укладывается в рабочее время и окна визитов подходящих монтажников")
+				continue
+			}
+			score := float64(route.TravelMinutes-current.TravelMinutes) + s.preference(installer, job, len(s.routes[i]))
+			if score < bestScore {
+				bestScore, bestInstaller, bestPosition = score, i, position
+			}
+		}
+	}
+
+	if bestInstaller < 0 {
+		return reason
+	}
+	route := s.routes[bestInstaller]
+	route = append(route[:bestPosition], append([]int{job.Index}, route[bestPosition:]...)...)
+	s.routes[bestInstaller] = route
+	return ""
+}
+
+// eligible проверяет квалификацию, специализацию и зону работы монтажника;
+// возвращает номер непройденной проверки и причину
+func (s *dispatchSolver) eligible(installer DispatchInstaller, job DispatchJob) (int, string) {
+	if dispatchSkillLevels[installer.SkillLevel] < dispatchSkillLevels[job.SkillLevel] {
+		return 1, "нет монтажников требуемой квалификации"
+	}
+	if job.Specialization != "" {
+		found := false
+		for _, spec := range installer.Specialization {
+			if spec == job.Specialization {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return 2, "нет монтажников со специализацией " + job.Specialization
+		}
+	}
+	if job.LocationID != 0 && len(installer.LocationIDs) > 0 {
+		found := false
+		for _, id := range installer.LocationIDs {
+			if id == job.LocationID {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return 3, "нет монтажников, работающих в локации объекта"
+		}
+	}
+	return 0, ""
+}
+
+// preference поправка к стоимости вставки: избыточная квалификация, рейтинг и загрузка
+func (s *dispatchSolver) preference(installer DispatchInstaller, job DispatchJob, assigned int) float64 {
+	required := dispatchSkillLevels[job.SkillLevel]
+	if required == 0 {
+		required = 1
+	}
+	score := float64(dispatchSkillLevels[installer.SkillLevel]-required) * dispatchOverqualificationPenalty
+	score -= (float64(installer.Rating) - 3) * dispatchRatingBonus
+	score += float64(assigned+len(installer.Busy)) * dispatchLoadPenalty
+	return score
+}
+
+// simulate проходит маршрут монтажника по времени с учетом уже запланированных работ.
+// Маршрут недопустим, если визит выходит за окно заявки, рабочий день
+// или мешает успеть на запланированный монтаж.
+func (s *dispatchSolver) simulate(installer DispatchInstaller, sequence []int) (*DispatchRoute, bool) {
+	route := &DispatchRoute{Stops: make([]DispatchStop, 0, len(sequence)+len(installer.Busy))}
+	clock := installer.WorkStart
+	position := installer.Start
+	busy := installer.Busy
+	ok := true
+	afterJob := false
+
+	visitBusy := func(slot DispatchBusySlot) {
+		km, minutes := s.options.travel(position, slot.Point)
+		arrival := clock.Add(time.Duration(minutes) * time.Minute)
+		// Опоздание, заложенное в расписание до распределения, новым заявкам не мешает
+		if arrival.After(slot.Start) && afterJob {
+			ok = false
+		}
+		afterJob = false
+		installationID := slot.InstallationID
+		route.Stops = append(route.Stops, DispatchStop{
+			InstallationID: &installationID, ObjectID: slot.ObjectID, Point: slot.Point,
+			Arrival: arrival, Start: slot.Start, End: slot.End,
+			TravelMinutes: minutes, DistanceKm: math.Round(km*10) / 10, Fixed: true,
+		})
+		route.TravelMinutes += minutes
+		route.DistanceKm += km
+		if slot.End.After(clock) {
+			clock = slot.End
+		}
+		position = slot.Point
+	}
+
+	for _, index := range sequence {
+		job := s.jobs[index]
+		var km float64
+		var minutes int
+		var arrival, start, end time.Time
+		for {
+			km, minutes = s.options.travel(position, job.Point)
+			arrival = clock.Add(time.Duration(minutes) * time.Minute)
+			start = arrival
+			if !job.WindowStart.IsZero() && start.Before(job.WindowStart) {
+				start = job.WindowStart
+			}
+			end = start.Add(time.Duration(job.Duration) * time.Minute)
+
+			// Если после заявки не успеть на запланированный монтаж, сначала выполняется он
+			if len(busy) == 0 {
+				break
+			}
+			_, toBusy := s.options.travel(job.Point, busy[0].Point)
+			if !end.Add(time.Duration(toBusy) * time.Minute).After(busy[0].Start) {
+				break
+			}
+			visitBusy(busy[0])
+			busy = busy[1:]
+		}
+
+		if !job.WindowEnd.IsZero() && start.After(job.WindowEnd) {
+			ok = false
+		}
+		if end.After(installer.WorkEnd) {
+			ok = false
+		}
+
+		jobIndex := index
+		route.Stops = append(route.Stops, DispatchStop{
+			JobIndex: &jobIndex, ObjectID: job.ObjectID, Point: job.Point,
+			Arrival: arrival, Start: start, End: end,
+			TravelMinutes: minutes, DistanceKm: math.Round(km*10) / 10,
+		})
+		route.JobCount++
+		route.TravelMinutes += minutes
+		route.DistanceKm += km
+		route.WorkMinutes += job.Duration
+		clock = end
+		position = job.Point
+		afterJob = true
+	}
+	for _, slot := range busy {
+		visitBusy(slot)
+	}
+
+	route.DistanceKm = math.Round(route.DistanceKm*10) / 10
+	return route, ok
+}
@@ -0,0 +1,188 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var dispatchTestDate = time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC)
+
+func dispatchTestClock(hour, minute int) time.Time {
+	return dispatchTestDate.Add(time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute)
+}
+
+func dispatchTestInstaller(id uint, skill string, start DispatchPoint) DispatchInstaller {
+	return DispatchInstaller{
+		ID: id, Name: "Монтажник", SkillLevel: skill, Specialization: []string{"GPS"},
+		Rating: 3, MaxDailyInstallations: 5,
+		WorkStart: dispatchTestClock(9, 0), WorkEnd: dispatchTestClock(18, 0), Start: &start,
+	}
+}
+
+func dispatchTestJob(index int, point DispatchPoint) DispatchJob {
+	return DispatchJob{Index: index, ObjectID: uint(index + 100), Point: &point, Priority: "normal", Duration: 60}
+}
+
+// stopJobs возвращает индексы новых заявок маршрута в порядке посещения
+func stopJobs(route DispatchRoute) []int {
+	var result []int
+	for _, stop := range route.Stops {
+		if stop.JobIndex != nil {
+			result = append(result, *stop.JobIndex)
+		}
+	}
+	return result
+}
+
+func TestSolveDispatch_ClustersJobsByDistance(t *testing.T) {
+	north := DispatchPoint{Latitude: 55.85, Longitude: 37.60}
+	south := DispatchPoint{Latitude: 55.60, Longitude: 37.60}
+	installers := []DispatchInstaller{
+		dispatchTestInstaller(2, "middle", south),
+		dispatchTestInstaller(1, "middle", north),
+	}
+	jobs := []DispatchJob{
+		dispatchTestJob(0, DispatchPoint{Latitude: 55.61, Longitude: 37.61}),
+		dispatchTestJob(1, DispatchPoint{Latitude: 55.86, Longitude: 37.61}),
+		dispatchTestJob(2, DispatchPoint{Latitude: 55.62, Longitude: 37.62}),
+		dispatchTestJob(3, DispatchPoint{Latitude: 55.87, Longitude: 37.62}),
+	}
+
+	plan := SolveDispatch(dispatchTestDate, jobs, installers, DispatchOptions{})
+	require.Empty(t, plan.Unassigned)
+	require.Len(t, plan.Routes, 2)
+	assert.Equal(t, 4, plan.AssignedCount)
+
+	assert.Equal(t, uint(1), plan.Routes[0].InstallerID)
+	assert.Equal(t, []int{1, 3}, stopJobs(plan.Routes[0]))
+	assert.Equal(t, uint(2), plan.Routes[1].InstallerID)
+	assert.Equal(t, []int{0, 2}, stopJobs(plan.Routes[1]))
+
+	// Визиты идут подряд без пересечений, время в пути учтено
+	route := plan.Routes[0]
+	assert.Equal(t, route.Stops[0].Arrival, route.Stops[0].Start)
+	assert.True(t, route.Stops[0].Start.After(dispatchTestClock(9, 0)))
+	assert.False(t, route.Stops[1].Start.Before(route.Stops[0].End))
+	assert.Equal(t, route.Stops[0].TravelMinutes+route.Stops[1].TravelMinutes, route.TravelMinutes)
+	assert.Equal(t, 120, route.WorkMinutes)
+
+	// Повторный запуск дает тот же результат
+	assert.Equal(t, plan, SolveDispatch(dispatchTestDate, jobs, installers, DispatchOptions{}))
+}
+
+func TestSolveDispatch_SkillsAndPreferences(t *testing.T) {
+	office := DispatchPoint{Latitude: 55.75, Longitude: 37.62}
+	junior := dispatchTestInstaller(1, "junior", office)
+	senior := dispatchTestInstaller(2, "senior", office)
+	senior.Specialization = []string{"GPS", "CAN"}
+	senior.LocationIDs = []uint{5}
+	remote := dispatchTestInstaller(3, "senior", office)
+	remote.LocationIDs = []uint{7}
+
+	jobs := []DispatchJob{
+		dispatchTestJob(0, office),
+		dispatchTestJob(1, office),
+		dispatchTestJob(2, office),
+		dispatchTestJob(3, office),
+		dispatchTestJob(4, office),
+	}
+	jobs[1].SkillLevel = "senior"
+	jobs[1].LocationID = 5
+	jobs[2].Specialization = "CAN"
+	jobs[3].Specialization = "тахографы"
+	jobs[4].LocationID = 9
+	jobs[4].Specialization = "CAN"
+
+	plan := SolveDispatch(dispatchTestDate, jobs, []DispatchInstaller{junior, senior, remote}, DispatchOptions{})
+
+	// Простая заявка достается младшему монтажнику, сложные - старшему
+	require.Len(t, plan.Routes, 2)
+	assert.Equal(t, uint(1), plan.Routes[0].InstallerID)
+	assert.Equal(t, []int{0}, stopJobs(plan.Routes[0]))
+	assert.Equal(t, uint(2), plan.Routes[1].InstallerID)
+	assert.ElementsMatch(t, []int{1, 2}, stopJobs(plan.Routes[1]))
+
+	require.Len(t, plan.Unassigned, 2)
+	assert.Equal(t, 3, plan.Unassigned[0].JobIndex)
+	assert.Equal(t, "нет монтажников со специализацией тахографы", plan.Unassigned[0].Reason)
+	assert.Equal(t, 4, plan.Unassigned[1].JobIndex)
+	assert.Equal(t, "нет монтажников, работающих в локации объекта", plan.Unassigned[1].Reason)
+
+	// При равном пути выигрывает монтажник с более высоким рейтингом
+	first := dispatchTestInstaller(1, "middle", office)
+	second := dispatchTestInstaller(2, "middle", office)
+	second.Rating = 4.8
+	plan = SolveDispatch(dispatchTestDate, jobs[:1], []DispatchInstaller{first, second}, DispatchOptions{})
+	require.Len(t, plan.Routes, 1)
+	assert.Equal(t, uint(2), plan.Routes[0].InstallerID)
+}
+
+func TestSolveDispatch_CapacityAndWorkingHours(t *testing.T) {
+	office := DispatchPoint{Latitude: 55.75, Longitude: 37.62}
+	installer := dispatchTestInstaller(1, "middle", office)
+	installer.WorkEnd = dispatchTestClock(12, 0)
+
+	jobs := []DispatchJob{dispatchTestJob(0, office), dispatchTestJob(1, office), dispatchTestJob(2, office)}
+	jobs[0].Duration = 120
+	jobs[1].Duration = 90
+	jobs[2].Duration = 60
+
+	plan := SolveDispatch(dispatchTestDate, jobs, []DispatchInstaller{installer}, DispatchOptions{})
+	require.Len(t, plan.Routes, 1)
+	assert.ElementsMatch(t, []int{0, 2}, stopJobs(plan.Routes[0]))
+	require.Len(t, plan.Unassigned, 1)
+	assert.Equal(t, 1, plan.Unassigned[0].JobIndex)
+	assert.Equal(t, "не укладывается в рабочее время и окна визитов подходящих монтажников", plan.Unassigned[0].Reason)
+
+	installer.WorkEnd = dispatchTestClock(18, 0)
+	installer.MaxDailyInstallations = 2
+	plan = SolveDispatch(dispatchTestDate, jobs, []DispatchInstaller{installer}, DispatchOptions{})
+	assert.Equal(t, 2, plan.AssignedCount)
+	require.Len(t, plan.Unassigned, 1)
+	assert.Equal(t, "у подходящих монтажников нет свободных слотов на день", plan.Unassigned[0].Reason)
+}
+
+func TestSolveDispatch_TimeWindowsAndBusySlots(t *testing.T) {
+	office := DispatchPoint{Latitude: 55.75, Longitude: 37.62}
+	installer := dispatchTestInstaller(1, "middle", office)
+	installer.Busy = []DispatchBusySlot{{
+		InstallationID: 50, ObjectID: 500, Point: &office,
+		Start: dispatchTestClock(11, 0), End: dispatchTestClock(13, 0),
+	}}
+
+	jobs := []DispatchJob{dispatchTestJob(0, office), dispatchTestJob(1, office), dispatchTestJob(2, office)}
+	jobs[0].Duration = 120
+	jobs[1].Duration = 120
+	// Клиент ждет только после обеда
+	jobs[2].WindowStart = dispatchTestClock(15, 30)
+	jobs[2].WindowEnd = dispatchTestClock(16, 0)
+
+	plan := SolveDispatch(dispatchTestDate, jobs, []DispatchInstaller{installer}, DispatchOptions{})
+	require.Empty(t, plan.Unassigned)
+	require.Len(t, plan.Routes, 1)
+	stops := plan.Routes[0].Stops
+	require.Len(t, stops, 4)
+
+	assert.Equal(t, dispatchTestClock(9, 0), stops[0].Start)
+	assert.Equal(t, dispatchTestClock(11, 0), stops[0].End)
+	assert.True(t, stops[1].Fixed)
+	require.NotNil(t, stops[1].InstallationID)
+	assert.Equal(t, uint(50), *stops[1].InstallationID)
+	assert.Equal(t, dispatchTestClock(13, 0), stops[2].Start)
+	require.NotNil(t, stops[3].JobIndex)
+	assert.Equal(t, 2, *stops[3].JobIndex)
+	assert.Equal(t, dispatchTestClock(15, 30), stops[3].Start)
+
+	// Утренний визит сдвинул бы остальные работы за конец рабочего дня
+	jobs[2].WindowStart = time.Time{}
+	jobs[2].WindowEnd = dispatchTestClock(9, 30)
+	jobs[2].Priority = "low"
+	installer.WorkEnd = dispatchTestClock(16, 0)
+	plan = SolveDispatch(dispatchTestDate, jobs, []DispatchInstaller{installer}, DispatchOptions{})
+	assert.ElementsMatch(t, []int{0, 1}, stopJobs(plan.Routes[0]))
+	require.Len(t, plan.Unassigned, 1)
+	assert.Equal(t, 2, plan.Unassigned[0].JobIndex)
+}
@@ -0,0 +1,611 @@
+package services
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"time"
+
+	"backend_axenta/models"
+)
+
+// Константы транспортного уровня EGTS (ГОСТ Р 54619, ГОСТ 33472)
+const (
+	egtsProtocolVersion    = 0x01
+	egtsHeaderLength       = 11 // Заголовок без полей маршрутизации
+	egtsRoutedHeaderLength = 16 // Заголовок с полями PRA, RCA, TTL
+	egtsHeaderEncoding     = 0x00
+
+	egtsPacketResponse      = 0
+	egtsPacketAppData       = 1
+	egtsPacketSignedAppData = 2
+)
+
+// Сервисы и типы подзаписей EGTS
+const (
+	egtsAuthService     = 1
+	egtsTeledataService = 2
+
+	egtsSubrecordResponse     = 0  // EGTS_SR_RECORD_RESPONSE
+	egtsSubrecordTermIdentity = 1  // EGTS_SR_TERM_IDENTITY
+	egtsSubrecordResultCode   = 9  // EGTS_SR_RESULT_CODE
+	egtsSubrecordPosData      = 16 // EGTS_SR_POS_DATA
+	egtsSubrecordExtPosData   = 17 // EGTS_SR_EXT_POS_DATA
+	egtsSubrecordADSensors    = 18 // EGTS_SR_AD_SENSORS_DATA
+	egtsSubrecordCounters     = 19 // EGTS_SR_COUNTERS_DATA
+	egtsSubrecordState        = 20 // EGTS_SR_STATE_DATA
+	egtsSubrecordAbsAnalog    = 24 // EGTS_SR_ABS_AN_SENS_DATA
+	egtsSubrecordAbsCounter   = 25 // EGTS_SR_ABS_CNTR_DATA
+)
+
+// Коды результата обработки EGTS
+const (
+	egtsResultOK             = 0
+	egtsResultUnsProtocol    = 128 // Неподдерживаемая версия протокола
+	egtsResultIncHeaderForm  = 131 // Неверный формат заголовка
+	egtsResultIncDataForm    = 132 // Неверный формат данных
+	egtsResultHeaderCRCError = 137 // Ошибка контрольной суммы заголовка
+	egtsResultDataCRCError   = 138 // Ошибка контрольной суммы данных
+	egtsResultAuthDenied     = 151 // В авторизации отказано
+	egtsResultIDNotFound     = 153 // Идентификатор не найден
+)
+
+// egtsEpoch начало отсчета времени EGTS
+var egtsEpoch = time.Date(2010, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// egtsPacketError ошибка разбора пакета с кодом результата протокола
+type egtsPacketError struct {
+	Code    uint8
+	Message string
+}
+
+func (e *egtsPacketError) Error() string {
+	return e.Message
+}
+
+func egtsError(code uint8, format string, args ...interface{}) error {
+	return &egtsPacketError{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// egtsCRC8 контрольная сумма заголовка пакета (CRC-8, полином 0x31, начальное значение 0xFF)
+func egtsCRC8(data []byte) uint8 {
+	crc := uint8(0xFF)
+	for _, b := range data {
+		crc ^= b
+		for bit := 0; bit < 8; bit++ {
+			if crc&0x80 != 0 {
+				crc = crc<<1 ^ 0x31
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
+
+// egtsCRC16 контрольная сумма данных пакета (CRC-16/CCITT-FALSE)
+func egtsCRC16(data []byte) uint16 {
+	crc := uint16(0xFFFF)
+	for _, b := range data {
+		crc ^= uint16(b) << 8
+		for bit := 0; bit < 8; bit++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
+
+// EGTSPacket пакет транспортного уровня EGTS
+type EGTSPacket struct {
+	PacketID uint16
+	Type     uint8
+
+	// Только для пакета подтверждения (EGTS_PT_RESPONSE)
+	ResponsePacketID uint16
+	ProcessingResult uint8
+
+	Records []EGTSRecord
+}
+
+// EGTSRecord запись уровня поддержки услуг
+type EGTSRecord struct {
+	Number           uint16
+	ObjectID         *uint32 // OID, идентификатор терминала
+	Time             *time.Time
+	SourceService    uint8
+	RecipientService uint8
+	Subrecords       []EGTSSubrecord
+}
+
+// EGTSSubrecord подзапись сервиса
+type EGTSSubrecord struct {
+	Type uint8
+	Data []byte
+}
+
+// ReadEGTSPacket читает из потока один пакет целиком: заголовок, данные и их контрольную сумму.
+// Содержимое пакета не проверяется, кроме полей, необходимых для определения его длины.
+func ReadEGTSPacket(r io.Reader) ([]byte, error) {
+	head := make([]byte, 4)
+	if _, err := io.ReadFull(r, head); err != nil {
+		return nil, err
+	}
+	if head[0] != egtsProtocolVersion {
+		return nil, egtsError(egtsResultUnsProtocol, "неподдерживаемая версия протокола %d", head[0])
+	}
+	headerLength := int(head[3])
+	if headerLength != egtsHeaderLength && headerLength != egtsRoutedHeaderLength {
+		return nil, egtsError(egtsResultIncHeaderForm, "неверная длина заголовка %d", headerLength)
+	}
+
+	header := make([]byte, headerLength)
+	copy(header, head)
+	if _, err := io.ReadFull(r, header[4:]); err != nil {
+		return nil, err
+	}
+	dataLength := int(binary.LittleEndian.Uint16(header[5:7]))
+	if dataLength == 0 {
+		return header, nil
+	}
+
+	frame := make([]byte, headerLength+dataLength+2)
+	copy(frame, header)
+	if _, err := io.ReadFull(r, frame[headerLength:]); err != nil {
+		return nil, err
+	}
+	return frame, nil
+}
+
+// ParseEGTSPacket разбирает пакет транспортного уровня и записи в нем.
+// Если заголовок прочитан, пакет возвращается вместе с ошибкой, чтобы на него можно было ответить.
+func ParseEGTSPacket(frame []byte) (*EGTSPacket, error) {
+	if len(frame) < egtsHeaderLength {
+		return nil, egtsError(egtsResultIncHeaderForm, "пакет короче заголовка")
+	}
+	if frame[0] != egtsProtocolVersion {
+		return nil, egtsError(egtsResultUnsProtocol, "неподдерживаемая версия протокола %d", frame[0])
+	}
+	headerLength := int(frame[3])
+	if (headerLength != egtsHeaderLength && headerLength != egtsRoutedHeaderLength) || len(frame) < headerLength {
+		return nil, egtsError(egtsResultIncHeaderForm, "неверная длина заголовка %d", headerLength)
+	}
+
+	packet := &EGTSPacket{
+		PacketID: binary.LittleEndian.Uint16(frame[7:9]),
+		Type:     frame[9],
+	}
+	if egtsCRC8(frame[:headerLength-1]) != frame[headerLength-1] {
+		return packet, egtsError(egtsResultHeaderCRCError, "неверная контрольная сумма заголовка")
+	}
+	if frame[4] != egtsHeaderEncoding || frame[2]&0x18 != 0 || frame[2]&0x04 != 0 {
+		return packet, egtsError(egtsResultIncHeaderForm, "шифрование и сжатие данных не поддерживаются")
+	}
+
+	dataLength := int(binary.LittleEndian.Uint16(frame[5:7]))
+	if dataLength == 0 {
+		return packet, nil
+	}
+	if len(frame) != headerLength+dataLength+2 {
+		return packet, egtsError(egtsResultIncDataForm, "длина пакета не соответствует заголовку")
+	}
+	data := frame[headerLength : headerLength+dataLength]
+	if egtsCRC16(data) != binary.LittleEndian.Uint16(frame[headerLength+dataLength:]) {
+		return packet, egtsError(egtsResultDataCRCError, "неверная контрольная сумма данных")
+	}
+
+	switch packet.Type {
+	case egtsPacketResponse:
+		if len(data) < 3 {
+			return packet, egtsError(egtsResultIncDataForm, "неверный формат пакета подтверждения")
+		}
+		packet.ResponsePacketID = binary.LittleEndian.Uint16(data[0:2])
+		packet.ProcessingResult = data[2]
+		data = data[3:]
+	case egtsPacketAppData:
+	case egtsPacketSignedAppData:
+		// Цифровая подпись не проверяется
+		if len(data) < 2 || len(data) < 2+int(binary.LittleEndian.Uint16(data[0:2])) {
+			return packet, egtsError(egtsResultIncDataForm, "неверный формат подписи")
+		}
+		data = data[2+int(binary.LittleEndian.Uint16(data[0:2])):]
+	default:
+		return packet, egtsError(egtsResultIncHeaderForm, "неизвестный тип пакета %d", packet.Type)
+	}
+
+	records, err := parseEGTSRecords(data)
+	if err != nil {
+		return packet, err
+	}
+	packet.Records = records
+	return packet, nil
+}
+
+// parseEGTSRecords разбирает записи уровня поддержки услуг
+func parseEGTSRecords(data []byte) ([]EGTSRecord, error) {
+	var records []EGTSRecord
+	for len(data) > 0 {
+		if len(data) < 7 {
+			return nil, egtsError(egtsResultIncDataForm, "неверный формат записи")
+		}
+		length := int(binary.LittleEndian.Uint16(data[0:2]))
+		record := EGTSRecord{Number: binary.LittleEndian.Uint16(data[2:4])}
+		flags := data[4]
+		offset := 5
+
+		optional := 0
+		for _, present := range []bool{flags&0x01 != 0, flags&0x02 != 0, flags&0x04 != 0} {
+			if present {
+				optional += 4
+			}
+		}
+		if len(data) < offset+optional+2+length {
+			return nil, egtsError(egtsResultIncDataForm, "длина записи %d превышает размер пакета", record.Number)
+		}
+		if flags&0x01 != 0 {
+			oid := binary.LittleEndian.Uint32(data[offset:])
+			record.ObjectID = &oid
+			offset += 4
+		}
+		if flags&0x02 != 0 {
+			offset += 4 // Идентификатор события не используется
+		}
+		if flags&0x04 != 0 {
+			recordTime := egtsTime(binary.LittleEndian.Uint32(data[offset:]))
+			record.Time = &recordTime
+			offset += 4
+		}
+		record.SourceService = data[offset]
+		record.RecipientService = data[offset+1]
+		offset += 2
+
+		body := data[offset : offset+length]
+		for len(body) > 0 {
+			if len(body) < 3 {
+				return nil, egtsError(egtsResultIncDataForm, "неверный формат подзаписи в записи %d", record.Number)
+			}
+			subLength := int(binary.LittleEndian.Uint16(body[1:3]))
+			if len(body) < 3+subLength {
+				return nil, egtsError(egtsResultIncDataForm, "длина подзаписи превышает размер записи %d", record.Number)
+			}
+			record.Subrecords = append(record.Subrecords, EGTSSubrecord{Type: body[0], Data: body[3 : 3+subLength]})
+			body = body[3+subLength:]
+		}
+
+		records = append(records, record)
+		data = data[offset+length:]
+	}
+	return records, nil
+}
+
+// encodeEGTSPacket формирует пакет транспортного уровня без маршрутизации
+func encodeEGTSPacket(packetID uint16, packetType uint8, data []byte) []byte {
+	frame := make([]byte, egtsHeaderLength, egtsHeaderLength+len(data)+2)
+	frame[0] = egtsProtocolVersion
+	frame[3] = egtsHeaderLength
+	frame[4] = egtsHeaderEncoding
+	binary.LittleEndian.PutUint16(frame[5:7], uint16(len(data)))
+	binary.LittleEndian.PutUint16(frame[7:9], packetID)
+	frame[9] = packetType
+	frame[10] = egtsCRC8(frame[:10])
+	if len(data) == 0 {
+		return frame
+	}
+	frame = append(frame, data...)
+	return binary.LittleEndian.AppendUint16(frame, egtsCRC16(data))
+}
+
+// encodeEGTSRecord формирует запись, отправляемую сервером сервису терминала
+func encodeEGTSRecord(number uint16, service uint8, subrecords ...EGTSSubrecord) []byte {
+	var body []byte
+	for _, subrecord := range subrecords {
+		body = append(body, subrecord.Type)
+		body = binary.LittleEndian.AppendUint16(body, uint16(len(subrecord.Data)))
+		body = append(body, subrecord.Data...)
+	}
+
+	record := binary.LittleEndian.AppendUint16(nil, uint16(len(body)))
+	record = binary.LittleEndian.AppendUint16(record, number)
+	record = append(record, 0x40, service, service) // RSOD: сервис-получатель на стороне терминала
+	return append(record, body...)
+}
+
+// egtsRecordResult результат обработки записи терминала
+type egtsRecordResult struct {
+	Number  uint16
+	Service uint8
+	Result  uint8
+}
+
+// encodeEGTSResponse формирует пакет подтверждения EGTS_PT_RESPONSE. На каждую
+// обработанную запись добавляется запись с подзаписью EGTS_SR_RECORD_RESPONSE,
+// номера записей ответа начинаются с firstNumber.
+func encodeEGTSResponse(packetID, responsePacketID uint16, result uint8, firstNumber uint16, records []egtsRecordResult) []byte {
+	data := binary.LittleEndian.AppendUint16(nil, responsePacketID)
+	data = append(data, result)
+	for i, record := range records {
+		confirmation := binary.LittleEndian.AppendUint16(nil, record.Number)
+		confirmation = append(confirmation, record.Result)
+		data = append(data, encodeEGTSRecord(firstNumber+uint16(i), record.Service,
+			EGTSSubrecord{Type: egtsSubrecordResponse, Data: confirmation})...)
+	}
+	return encodeEGTSPacket(packetID, egtsPacketResponse, data)
+}
+
+// encodeEGTSResultCode формирует пакет с результатом авторизации терминала (EGTS_SR_RESULT_CODE)
+func encodeEGTSResultCode(packetID, recordNumber uint16, code uint8) []byte {
+	record := encodeEGTSRecord(recordNumber, egtsAuthService, EGTSSubrecord{Type: egtsSubrecordResultCode, Data: []byte{code}})
+	return encodeEGTSPacket(packetID, egtsPacketAppData, record)
+}
+
+// EGTSTermIdentity данные подзаписи EGTS_SR_TERM_IDENTITY
+type EGTSTermIdentity struct {
+	TerminalID uint32
+	IMEI       string
+	IMSI       string
+	MSISDN     string
+}
+
+// ParseEGTSTermIdentity разбирает подзапись авторизации терминала
+func ParseEGTSTermIdentity(data []byte) (*EGTSTermIdentity, error) {
+	if len(data) < 5 {
+		return nil, egtsError(egtsResultIncDataForm, "неверный формат подзаписи авторизации")
+	}
+	identity := &EGTSTermIdentity{TerminalID: binary.LittleEndian.Uint32(data[0:4])}
+	flags := data[4]
+	offset := 5
+
+	// Поля следуют в порядке HDID, IMEI, IMSI, LNGC, NID, BS, MSISDN
+	fields := []struct {
+		bit    uint8
+		length int
+		value  *string
+	}{
+		{0x01, 2, nil},
+		{0x02, 15, &identity.IMEI},
+		{0x04, 16, &identity.IMSI},
+		{0x08, 3, nil},
+		{0x20, 3, nil},
+		{0x40, 2, nil},
+		{0x80, 15, &identity.MSISDN},
+	}
+	for _, field := range fields {
+		if flags&field.bit == 0 {
+			continue
+		}
+		if len(data) < offset+field.length {
+			return nil, egtsError(egtsResultIncDataForm, "неверный формат подзаписи авторизации")
+		}
+		if field.value != nil {
+			*field.value = string(data[offset : offset+field.length])
+		}
+		offset += field.length
+	}
+	return identity, nil
+}
+
+// ParseEGTSTeledata преобразует запись сервиса телеметрии в точку. Время точки берется
+// из навигационных данных, затем из записи, иначе используется время приема.
+// Если запись не содержит известных подзаписей, возвращается nil.
+func ParseEGTSTeledata(record EGTSRecord, now time.Time) (*models.TelemetryPoint, error) {
+	point := &models.TelemetryPoint{Protocol: models.TelemetryProtocolEGTS, RecordedAt: now.UTC()}
+	if record.Time != nil {
+		point.RecordedAt = *record.Time
+	}
+
+	params := map[string]interface{}{}
+	known := false
+	for _, subrecord := range record.Subrecords {
+		var err error
+		switch subrecord.Type {
+		case egtsSubrecordPosData:
+			err = parseEGTSPosData(subrecord.Data, point, params)
+		case egtsSubrecordExtPosData:
+			err = parseEGTSExtPosData(subrecord.Data, point, params)
+		case egtsSubrecordADSensors:
+			err = parseEGTSADSensors(subrecord.Data, point, params)
+		case egtsSubrecordCounters:
+			err = parseEGTSCounters(subrecord.Data, params)
+		case egtsSubrecordState:
+			err = parseEGTSState(subrecord.Data, params)
+		case egtsSubrecordAbsAnalog, egtsSubrecordAbsCounter:
+			if len(subrecord.Data) < 4 {
+				err = egtsError(egtsResultIncDataForm, "неверный формат подзаписи %d", subrecord.Type)
+				break
+			}
+			prefix := "ain"
+			if subrecord.Type == egtsSubrecordAbsCounter {
+				prefix = "cnt"
+			}
+			params[fmt.Sprintf("%s%d", prefix, subrecord.Data[0])] = egtsUint24(subrecord.Data[1:])
+		default:
+			// Неподдерживаемые подзаписи пропускаются
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		known = true
+	}
+	if !known {
+		return nil, nil
+	}
+
+	if len(params) > 0 {
+		data, _ := json.Marshal(params)
+		point.Params = string(data)
+	}
+	return point, nil
+}
+
+// parseEGTSPosData разбирает основные навигационные данные EGTS_SR_POS_DATA
+func parseEGTSPosData(data []byte, point *models.TelemetryPoint, params map[string]interface{}) error {
+	if len(data) < 21 {
+		return egtsError(egtsResultIncDataForm, "неверный формат навигационных данных")
+	}
+	point.RecordedAt = egtsTime(binary.LittleEndian.Uint32(data[0:4]))
+	flags := data[12]
+	speed := binary.LittleEndian.Uint16(data[13:15])
+
+	// Координаты передаются только при достоверной навигации (VLD)
+	if flags&0x01 != 0 {
+		latitude := float64(binary.LittleEndian.Uint32(data[4:8])) / math.MaxUint32 * 90
+		longitude := float64(binary.LittleEndian.Uint32(data[8:12])) / math.MaxUint32 * 180
+		if flags&0x20 != 0 {
+			latitude = -latitude
+		}
+		if flags&0x40 != 0 {
+			longitude = -longitude
+		}
+		point.Latitude = &latitude
+		point.Longitude = &longitude
+	}
+
+	kmh := float64(speed&0x3FFF) / 10
+	course := int(data[15]) | int(speed>>15)<<8
+	point.Speed = &kmh
+	point.Course = &course
+
+	inputs := int(data[19])
+	point.Inputs = &inputs
+	params["odometer"] = float64(egtsUint24(data[16:19])) / 10
+
+	if flags&0x80 != 0 {
+		if len(data) < 24 {
+			return egtsError(egtsResultIncDataForm, "неверный формат навигационных данных")
+		}
+		altitude := float64(egtsUint24(data[21:24]))
+		if speed&0x4000 != 0 {
+			altitude = -altitude
+		}
+		point.Altitude = &altitude
+	}
+	return nil
+}
+
+// parseEGTSExtPosData разбирает дополнительные навигационные данные EGTS_SR_EXT_POS_DATA
+func parseEGTSExtPosData(data []byte, point *models.TelemetryPoint, params map[string]interface{}) error {
+	if len(data) < 1 {
+		return egtsError(egtsResultIncDataForm, "неверный формат дополнительных навигационных данных")
+	}
+	flags := data[0]
+	offset := 1
+	read := func(size int) (uint32, bool) {
+		if len(data) < offset+size {
+			return 0, false
+		}
+		var value uint32
+		for i := size - 1; i >= 0; i-- {
+			value = value<<8 | uint32(data[offset+i])
+		}
+		offset += size
+		return value, true
+	}
+
+	// Поля следуют в порядке VDOP, HDOP, PDOP, SAT, NS
+	for _, field := range []struct {
+		bit  uint8
+		size int
+		name string
+	}{{0x01, 2, "vdop"}, {0x02, 2, "hdop"}, {0x04, 2, "pdop"}, {0x08, 1, "sat"}, {0x10, 2, "ns"}} {
+		if flags&field.bit == 0 {
+			continue
+		}
+		value, ok := read(field.size)
+		if !ok {
+			return egtsError(egtsResultIncDataForm, "неверный формат дополнительных навигационных данных")
+		}
+		switch field.name {
+		case "hdop":
+			hdop := float64(value) / 10
+			point.HDOP = &hdop
+		case "sat":
+			satellites := int(value)
+			point.Satellites = &satellites
+		case "ns":
+			params["nav_systems"] = value
+		default:
+			params[field.name] = float64(value) / 10
+		}
+	}
+	return nil
+}
+
+// parseEGTSADSensors разбирает дискретные и аналоговые датчики EGTS_SR_AD_SENSORS_DATA
+func parseEGTSADSensors(data []byte, point *models.TelemetryPoint, params map[string]interface{}) error {
+	if len(data) < 3 {
+		return egtsError(egtsResultIncDataForm, "неверный формат данных датчиков")
+	}
+	inputsMask, analogMask := data[0], data[2]
+	outputs := int(data[1])
+	point.Outputs = &outputs
+
+	offset := 3
+	for i := 0; i < 8; i++ {
+		if inputsMask&(1<<i) == 0 {
+			continue
+		}
+		if len(data) < offset+1 {
+			return egtsError(egtsResultIncDataForm, "неверный формат данных датчиков")
+		}
+		params[fmt.Sprintf("din%d", i+1)] = data[offset]
+		offset++
+	}
+	for i := 0; i < 8; i++ {
+		if analogMask&(1<<i) == 0 {
+			continue
+		}
+		if len(data) < offset+3 {
+			return egtsError(egtsResultIncDataForm, "неверный формат данных датчиков")
+		}
+		params[fmt.Sprintf("ain%d", i+1)] = egtsUint24(data[offset:])
+		offset += 3
+	}
+	return nil
+}
+
+// parseEGTSCounters разбирает счетные входы EGTS_SR_COUNTERS_DATA
+func parseEGTSCounters(data []byte, params map[string]interface{}) error {
+	if len(data) < 1 {
+		return egtsError(egtsResultIncDataForm, "неверный формат данных счетчиков")
+	}
+	offset := 1
+	for i := 0; i < 8; i++ {
+		if data[0]&(1<<i) == 0 {
+			continue
+		}
+		if len(data) < offset+3 {
+			return egtsError(egtsResultIncDataForm, "неверный формат данных счетчиков")
+		}
+		params[fmt.Sprintf("cnt%d", i+1)] = egtsUint24(data[offset:])
+		offset += 3
+	}
+	return nil
+}
+
+// parseEGTSState разбирает состояние терминала EGTS_SR_STATE_DATA, напряжения в вольтах
+func parseEGTSState(data []byte, params map[string]interface{}) error {
+	if len(data) < 5 {
+		return egtsError(egtsResultIncDataForm, "неверный формат состояния терминала")
+	}
+	params["state"] = data[0]
+	params["power_voltage"] = float64(data[1]) / 10
+	params["backup_voltage"] = float64(data[2]) / 10
+	params["internal_voltage"] = float64(data[3]) / 10
+	return nil
+}
+
+// egtsUint24 читает трехбайтовое беззнаковое число (little-endian)
+func egtsUint24(data []byte) uint32 {
+	return uint32(data[0]) | uint32(data[1])<<8 | uint32(data[2])<<16
+}
+
+// egtsTime переводит время EGTS (секунды с 01.01.2010 UTC) в time.Time
+func egtsTime(seconds uint32) time.Time {
+	return egtsEpoch.Add(time.Duration(seconds) * time.Second)
+}
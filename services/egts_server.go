@@ -0,0 +1,182 @@
+package services
+
+import (
+	"bufio"
+	"errors"
+	"log"
+	"net"
+	"time"
+
+	"backend_axenta/models"
+)
+
+// EGTSServer TCP сервер приема телеметрии по протоколу EGTS (ЭРА-ГЛОНАСС).
+// Терминал авторизуется подзаписью EGTS_SR_TERM_IDENTITY по IMEI объекта,
+// после чего передает записи сервиса телеметрии.
+type EGTSServer struct {
+	telemetryListener
+
+	Telemetry   *TelemetryService
+	IdleTimeout time.Duration
+}
+
+// NewEGTSServer создает новый экземпляр EGTSServer
+func NewEGTSServer(telemetry *TelemetryService) *EGTSServer {
+	return &EGTSServer{
+		telemetryListener: telemetryListener{name: "EGTS"},
+		Telemetry:         telemetry,
+		IdleTimeout:       DefaultTelemetryIdleTimeout,
+	}
+}
+
+// ListenAndServe начинает прием соединений терминалов на адресе addr
+func (s *EGTSServer) ListenAndServe(addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(listener)
+}
+
+// Serve принимает соединения терминалов, пока сервер не будет остановлен
+func (s *EGTSServer) Serve(listener net.Listener) error {
+	return s.serve(listener, s.handleConn)
+}
+
+// handleConn обслуживает соединение одного терминала
+func (s *EGTSServer) handleConn(conn net.Conn) {
+	reader := bufio.NewReader(conn)
+	session := &egtsSession{telemetry: s.Telemetry}
+	for {
+		conn.SetReadDeadline(time.Now().Add(s.IdleTimeout))
+		frame, err := ReadEGTSPacket(reader)
+		if err != nil {
+			return
+		}
+
+		replies, keep := session.handle(frame, time.Now())
+		for _, reply := range replies {
+			conn.SetWriteDeadline(time.Now().Add(s.IdleTimeout))
+			if _, err := conn.Write(reply); err != nil {
+				return
+			}
+		}
+		if !keep {
+			return
+		}
+	}
+}
+
+// egtsSession состояние соединения терминала
+type egtsSession struct {
+	telemetry    *TelemetryService
+	device       *TelemetryDevice
+	packetID     uint16 // Номер последнего отправленного пакета
+	recordNumber uint16 // Номер последней отправленной записи
+}
+
+// nextPacketID возвращает номер следующего пакета сервера
+func (s *egtsSession) nextPacketID() uint16 {
+	s.packetID++
+	return s.packetID
+}
+
+// response формирует подтверждение пакета терминала
+func (s *egtsSession) response(packetID uint16, result uint8, records []egtsRecordResult) []byte {
+	firstNumber := s.recordNumber + 1
+	s.recordNumber += uint16(len(records))
+	return encodeEGTSResponse(s.nextPacketID(), packetID, result, firstNumber, records)
+}
+
+// handle обрабатывает пакет и возвращает ответы терминалу и признак продолжения соединения
+func (s *egtsSession) handle(frame []byte, now time.Time) ([][]byte, bool) {
+	packet, err := ParseEGTSPacket(frame)
+	if err != nil {
+		var packetErr *egtsPacketError
+		if packet == nil || !errors.As(err, &packetErr) {
+			return nil, false
+		}
+		// При ошибке заголовка границы следующих пакетов недостоверны
+		keep := packetErr.Code != egtsResultHeaderCRCError && packetErr.Code != egtsResultIncHeaderForm
+		return [][]byte{s.response(packet.PacketID, packetErr.Code, nil)}, keep
+	}
+	// Подтверждения терминала на пакеты сервера не требуют ответа
+	if packet.Type == egtsPacketResponse {
+		return nil, true
+	}
+
+	results := make([]egtsRecordResult, len(packet.Records))
+	var points []models.TelemetryPoint
+	var authResult *uint8
+	denied := false
+	for i, record := range packet.Records {
+		results[i] = egtsRecordResult{Number: record.Number, Service: record.RecipientService, Result: egtsResultOK}
+		switch record.RecipientService {
+		case egtsAuthService:
+			for _, subrecord := range record.Subrecords {
+				if subrecord.Type != egtsSubrecordTermIdentity {
+					continue
+				}
+				code := s.authenticate(subrecord.Data)
+				results[i].Result = code
+				authResult = &code
+			}
+		case egtsTeledataService:
+			// До авторизации данные не принимаются
+			if s.device == nil {
+				results[i].Result = egtsResultAuthDenied
+				denied = true
+				continue
+			}
+			point, err := ParseEGTSTeledata(record, now)
+			if err != nil {
+				var packetErr *egtsPacketError
+				if errors.As(err, &packetErr) {
+					results[i].Result = packetErr.Code
+				}
+				continue
+			}
+			if point != nil {
+				points = append(points, *point)
+			}
+		}
+	}
+
+	if err := s.telemetry.Store(s.device, points); err != nil {
+		// Без подтверждения терминал повторит отправку
+		log.Printf("EGTS: %v", err)
+		return nil, false
+	}
+
+	replies := [][]byte{s.response(packet.PacketID, egtsResultOK, results)}
+	if authResult != nil {
+		s.recordNumber++
+		replies = append(replies, encodeEGTSResultCode(s.nextPacketID(), s.recordNumber, *authResult))
+		if *authResult != egtsResultOK {
+			return replies, false
+		}
+	}
+	return replies, !denied
+}
+
+// authenticate авторизует терминал по IMEI и возвращает код результата
+func (s *egtsSession) authenticate(data []byte) uint8 {
+	identity, err := ParseEGTSTermIdentity(data)
+	if err != nil {
+		return egtsResultIncDataForm
+	}
+	if identity.IMEI == "" {
+		return egtsResultAuthDenied
+	}
+
+	device, err := s.telemetry.Authenticate(identity.IMEI)
+	if err != nil {
+		if !errors.Is(err, ErrTelemetryUnknownDevice) {
+			log.Printf("EGTS: ошибка авторизации %s: %v", identity.IMEI, err)
+			return egtsResultAuthDenied
+		}
+		return egtsResultIDNotFound
+	}
+	s.device = device
+	return egtsResultOK
+}
@@ -0,0 +1,239 @@
+package services
+
+import (
+	"bufio"
+	"encoding/hex"
+	"encoding/json"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"backend_axenta/models"
+)
+
+// Эталонные пакеты собраны по ГОСТ 33472, контрольные суммы рассчитаны независимо
+const (
+	// Авторизация: запись 1 с OID 12345, EGTS_SR_TERM_IDENTITY с TID 12345 и IMEI 356938035643809
+	egtsAuthPacket = "0100000B0022000100010C1700010001393000000101011400393000000233353639333830333536343338303927B6"
+	// Телеметрия: запись 2 с POS_DATA, EXT_POS_DATA, AD_SENSORS_DATA и ABS_CNTR_DATA
+	// и запись 3 с POS_DATA без достоверной навигации
+	egtsTeledataPacket = "0100000B006700020001CB320002000539300000A756971F0202101800A756971F41168F9EF9179035936F821C" +
+		"39300005009C00001104000A09000C120600000201D43000190400010903001B00030001393000000202101800904F971F" +
+		"00000000000000008000400000000000000C0000DA4A"
+	// Ответ сервера на пакет авторизации и результат авторизации
+	egtsAuthResponse   = "0100000B0010000100002E0100000600010040010100030001000085EC"
+	egtsAuthResultCode = "0100000B000B00020001D3040002004001010901000075B5"
+)
+
+func egtsFrame(t *testing.T, dump string) []byte {
+	frame, err := hex.DecodeString(dump)
+	require.NoError(t, err)
+	return frame
+}
+
+func TestEGTSParsing(t *testing.T) {
+	assert.Equal(t, uint8(0xF7), egtsCRC8([]byte("123456789")))
+	assert.Equal(t, uint16(0x29B1), egtsCRC16([]byte("123456789")))
+
+	packet, err := ParseEGTSPacket(egtsFrame(t, egtsAuthPacket))
+	require.NoError(t, err)
+	assert.Equal(t, uint16(1), packet.PacketID)
+	assert.Equal(t, uint8(egtsPacketAppData), packet.Type)
+	require.Len(t, packet.Records, 1)
+	record := packet.Records[0]
+	require.NotNil(t, record.ObjectID)
+	assert.Equal(t, uint32(12345), *record.ObjectID)
+	assert.Equal(t, uint8(egtsAuthService), record.RecipientService)
+	require.Len(t, record.Subrecords, 1)
+	identity, err := ParseEGTSTermIdentity(record.Subrecords[0].Data)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(12345), identity.TerminalID)
+	assert.Equal(t, "356938035643809", identity.IMEI)
+
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	packet, err = ParseEGTSPacket(egtsFrame(t, egtsTeledataPacket))
+	require.NoError(t, err)
+	require.Len(t, packet.Records, 2)
+	require.NotNil(t, packet.Records[0].Time)
+	assert.Len(t, packet.Records[0].Subrecords, 4)
+
+	point, err := ParseEGTSTeledata(packet.Records[0], now)
+	require.NoError(t, err)
+	assert.Equal(t, models.TelemetryProtocolEGTS, point.Protocol)
+	assert.Equal(t, time.Date(2026, 10, 18, 9, 30, 15, 0, time.UTC), point.RecordedAt)
+	require.True(t, point.HasPosition())
+	assert.InDelta(t, 55.743375, *point.Latitude, 1e-6)
+	assert.InDelta(t, 37.66139, *point.Longitude, 1e-6)
+	assert.InDelta(t, 62.3, *point.Speed, 1e-9)
+	assert.Equal(t, 284, *point.Course)
+	assert.Equal(t, 156.0, *point.Altitude)
+	assert.Equal(t, 5, *point.Inputs)
+	assert.Equal(t, 2, *point.Outputs)
+	assert.InDelta(t, 0.9, *point.HDOP, 1e-9)
+	assert.Equal(t, 12, *point.Satellites)
+	var params map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(point.Params), &params))
+	assert.Equal(t, 1234.5, params["odometer"])
+	assert.Equal(t, float64(12500), params["ain1"])
+	assert.Equal(t, float64(777), params["cnt1"])
+
+	// Без достоверной навигации координаты не сохраняются, высота ниже уровня моря
+	point, err = ParseEGTSTeledata(packet.Records[1], now)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC), point.RecordedAt)
+	assert.False(t, point.HasPosition())
+	assert.Equal(t, -12.0, *point.Altitude)
+	assert.Equal(t, 0.0, *point.Speed)
+
+	// Ответы сервера
+	assert.Equal(t, egtsFrame(t, egtsAuthResponse), encodeEGTSResponse(1, 1, egtsResultOK, 1,
+		[]egtsRecordResult{{Number: 1, Service: egtsAuthService, Result: egtsResultOK}}))
+	assert.Equal(t, egtsFrame(t, egtsAuthResultCode), encodeEGTSResultCode(2, 2, egtsResultOK))
+
+	// Ошибки контрольных сумм и формата
+	frame := egtsFrame(t, egtsAuthPacket)
+	frame[10] ^= 0xFF
+	packet, err = ParseEGTSPacket(frame)
+	require.NotNil(t, packet)
+	assert.Equal(t, uint16(1), packet.PacketID)
+	assert.Equal(t, uint8(egtsResultHeaderCRCError), err.(*egtsPacketError).Code)
+
+	frame = egtsFrame(t, egtsAuthPacket)
+	frame[len(frame)-3] ^= 0xFF
+	_, err = ParseEGTSPacket(frame)
+	assert.Equal(t, uint8(egtsResultDataCRCError), err.(*egtsPacketError).Code)
+
+	frame = egtsFrame(t, egtsAuthPacket)
+	frame[0] = 0x02
+	_, err = ParseEGTSPacket(frame)
+	assert.Equal(t, uint8(egtsResultUnsProtocol), err.(*egtsPacketError).Code)
+
+	_, err = ParseEGTSTermIdentity([]byte{0x39, 0x30, 0x00, 0x00, 0x02, '3', '5'})
+	assert.Equal(t, uint8(egtsResultIncDataForm), err.(*egtsPacketError).Code)
+}
+
+// egtsTerminal терминал, подключенный к серверу EGTS
+type egtsTerminal struct {
+	t      *testing.T
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+func dialEGTSTerminal(t *testing.T, addr string) *egtsTerminal {
+	conn, err := net.DialTimeout("tcp", addr, time.Second)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return &egtsTerminal{t: t, conn: conn, reader: bufio.NewReader(conn)}
+}
+
+func (d *egtsTerminal) send(frame []byte) {
+	d.conn.SetDeadline(time.Now().Add(2 * time.Second))
+	_, err := d.conn.Write(frame)
+	require.NoError(d.t, err)
+}
+
+// receive читает пакет сервера; nil - соединение закрыто
+func (d *egtsTerminal) receive() *EGTSPacket {
+	frame, err := ReadEGTSPacket(d.reader)
+	if err != nil {
+		return nil
+	}
+	packet, err := ParseEGTSPacket(frame)
+	require.NoError(d.t, err)
+	return packet
+}
+
+// egtsRecordResults возвращает результаты обработки записей из подтверждения
+func egtsRecordResults(t *testing.T, packet *EGTSPacket) map[uint16]uint8 {
+	results := map[uint16]uint8{}
+	for _, record := range packet.Records {
+		for _, subrecord := range record.Subrecords {
+			require.Equal(t, uint8(egtsSubrecordResponse), subrecord.Type)
+			results[uint16(subrecord.Data[0])|uint16(subrecord.Data[1])<<8] = subrecord.Data[2]
+		}
+	}
+	return results
+}
+
+func TestEGTSServer_SimulatedTerminal(t *testing.T) {
+	db := setupTelemetryTestDB(t)
+	server := NewEGTSServer(NewTelemetryService(db, false))
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go server.Serve(listener)
+	defer server.Close()
+	addr := listener.Addr().String()
+
+	// Неизвестный IMEI
+	terminal := dialEGTSTerminal(t, addr)
+	terminal.send(egtsFrame(t, egtsAuthPacket))
+	response := terminal.receive()
+	require.NotNil(t, response)
+	assert.Equal(t, map[uint16]uint8{1: egtsResultIDNotFound}, egtsRecordResults(t, response))
+	result := terminal.receive()
+	require.NotNil(t, result)
+	assert.Equal(t, []byte{egtsResultIDNotFound}, result.Records[0].Subrecords[0].Data)
+	assert.Nil(t, terminal.receive())
+
+	truck := models.Object{Name: "КамАЗ", Type: "vehicle", IMEI: "356938035643809", ContractID: 1, Status: "active", IsActive: true}
+	require.NoError(t, db.Create(&truck).Error)
+
+	// Данные без авторизации отклоняются
+	terminal = dialEGTSTerminal(t, addr)
+	terminal.send(egtsFrame(t, egtsTeledataPacket))
+	response = terminal.receive()
+	require.NotNil(t, response)
+	assert.Equal(t, map[uint16]uint8{2: egtsResultAuthDenied, 3: egtsResultAuthDenied}, egtsRecordResults(t, response))
+	assert.Nil(t, terminal.receive())
+
+	terminal = dialEGTSTerminal(t, addr)
+	terminal.send(egtsFrame(t, egtsAuthPacket))
+	raw, err := ReadEGTSPacket(terminal.reader)
+	require.NoError(t, err)
+	assert.Equal(t, egtsFrame(t, egtsAuthResponse), raw)
+	raw, err = ReadEGTSPacket(terminal.reader)
+	require.NoError(t, err)
+	assert.Equal(t, egtsFrame(t, egtsAuthResultCode), raw)
+	// Подтверждение терминала на результат авторизации остается без ответа
+	terminal.send(encodeEGTSResponse(3, 2, egtsResultOK, 3, nil))
+
+	terminal.send(egtsFrame(t, egtsTeledataPacket))
+	response = terminal.receive()
+	require.NotNil(t, response)
+	assert.Equal(t, uint8(egtsPacketResponse), response.Type)
+	assert.Equal(t, uint16(2), response.ResponsePacketID)
+	assert.Equal(t, uint8(egtsResultOK), response.ProcessingResult)
+	assert.Equal(t, map[uint16]uint8{2: egtsResultOK, 3: egtsResultOK}, egtsRecordResults(t, response))
+
+	// Ошибка контрольной суммы данных не разрывает соединение, ошибка заголовка - разрывает
+	frame := egtsFrame(t, egtsTeledataPacket)
+	frame[len(frame)-1] ^= 0xFF
+	terminal.send(frame)
+	response = terminal.receive()
+	require.NotNil(t, response)
+	assert.Equal(t, uint8(egtsResultDataCRCError), response.ProcessingResult)
+
+	frame = egtsFrame(t, egtsTeledataPacket)
+	frame[10] ^= 0xFF
+	terminal.send(frame)
+	response = terminal.receive()
+	require.NotNil(t, response)
+	assert.Equal(t, uint8(egtsResultHeaderCRCError), response.ProcessingResult)
+	assert.Nil(t, terminal.receive())
+
+	var points []models.TelemetryPoint
+	require.NoError(t, db.Order("recorded_at").Find(&points).Error)
+	require.Len(t, points, 2)
+	for _, point := range points {
+		assert.Equal(t, truck.ID, point.ObjectID)
+		assert.Equal(t, models.TelemetryProtocolEGTS, point.Protocol)
+	}
+
+	require.NoError(t, db.First(&truck, truck.ID).Error)
+	require.NotNil(t, truck.LastActivityAt)
+	assert.Equal(t, time.Date(2026, 10, 18, 9, 30, 15, 0, time.UTC), truck.LastActivityAt.UTC())
+	assert.InDelta(t, 55.743375, *truck.Latitude, 1e-6)
+}
@@ -0,0 +1,406 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"image/png"
+	"strings"
+
+	"github.com/boombuler/barcode"
+	"github.com/boombuler/barcode/code128"
+	"github.com/boombuler/barcode/qr"
+	"github.com/jung-kurt/gofpdf"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goregular"
+	"gorm.io/gorm"
+
+	"backend_axenta/models"
+)
+
+// Типы кодов на этикетке оборудования
+const (
+	LabelCodeQR      = "qr"
+	LabelCodeCode128 = "code128"
+)
+
+// labelFontFamily встроенный в PDF шрифт с кириллицей для подписей этикеток
+const labelFontFamily = "GoFont"
+
+// Форматы изображения кода
+const (
+	LabelFormatPNG = "png"
+	LabelFormatSVG = "svg"
+)
+
+// Ограничения размеров этикетки в миллиметрах
+const (
+	minLabelSize = 15.0
+	maxLabelSize = 200.0
+)
+
+// MaxLabelCodeSize наибольшая ширина изображения кода в пикселях
+const MaxLabelCodeSize = 2048
+
+// EquipmentLabelService формирует изображения кодов и листы этикеток для маркировки оборудования
+type EquipmentLabelService struct {
+	DB *gorm.DB
+}
+
+// NewEquipmentLabelService создает новый экземпляр EquipmentLabelService
+func NewEquipmentLabelService(db *gorm.DB) *EquipmentLabelService {
+	return &EquipmentLabelService{DB: db}
+}
+
+// LabelSheetOptions параметры листа этикеток
+type LabelSheetOptions struct {
+	CodeType    string  `json:"code_type" form:"code_type"`       // qr или code128
+	PageSize    string  `json:"page_size" form:"page_size"`       // A4 или roll (одна этикетка на странице для термопринтера)
+	LabelWidth  float64 `json:"label_width" form:"label_width"`   // Ширина этикетки, мм
+	LabelHeight float64 `json:"label_height" form:"label_height"` // Высота этикетки, мм
+	Margin      float64 `json:"margin" form:"margin"`             // Поля листа A4, мм
+	Gap         float64 `json:"gap" form:"gap"`                   // Промежуток между этикетками, мм
+	Border      bool    `json:"border" form:"border"`             // Печатать рамку для резки
+}
+
+// normalize заполняет значения по умолчанию и проверяет размеры
+func (o *LabelSheetOptions) normalize() error {
+	if o.CodeType == "" {
+		o.CodeType = LabelCodeQR
+	}
+	if o.CodeType != LabelCodeQR && o.CodeType != LabelCodeCode128 {
+		return fmt.Errorf("неизвестный тип кода: %s", o.CodeType)
+	}
+	if o.PageSize == "" {
+		o.PageSize = "A4"
+	}
+	if o.PageSize != "A4" && o.PageSize != "roll" {
+		return fmt.Errorf("неизвестный формат листа: %s", o.PageSize)
+	}
+	if o.LabelWidth == 0 {
+		o.LabelWidth = 58
+	}
+	if o.LabelHeight == 0 {
+		o.LabelHeight = 40
+	}
+	if o.LabelWidth < minLabelSize || o.LabelWidth > maxLabelSize || o.LabelHeight < minLabelSize || o.LabelHeight > maxLabelSize {
+		return fmt.Errorf("размер этикетки должен быть от %.0f до %.0f мм", minLabelSize, maxLabelSize)
+	}
+	if o.Margin <= 0 {
+		o.Margin = 10
+	}
+	if o.Gap < 0 {
+		o.Gap = 0
+	}
+	return nil
+}
+
+// EncodeLabelCode кодирует строку в QR код или штрихкод Code128
+func EncodeLabelCode(content, codeType string) (barcode.Barcode, error) {
+	if content == "" {
+		return nil, fmt.Errorf("пустое содержимое кода")
+	}
+
+	switch codeType {
+	case LabelCodeQR, "":
+		return qr.Encode(content, qr.M, qr.Auto)
+	case LabelCodeCode128:
+		return code128.Encode(content)
+	default:
+		return nil, fmt.Errorf("неизвестный тип кода: %s", codeType)
+	}
+}
+
+// RenderLabelCode отрисовывает код в PNG или SVG.
+// size - ширина изображения в пикселях; для штрихкода высота составляет треть ширины.
+func RenderLabelCode(content, codeType, format string, size int) ([]byte, string, error) {
+	code, err := EncodeLabelCode(content, codeType)
+	if err != nil {
+		return nil, "", err
+	}
+
+	columns := code.Bounds().Dx()
+	if size <= 0 {
+		size = 256
+	}
+	if size > MaxLabelCodeSize {
+		return nil, "", fmt.Errorf("размер изображения не может превышать %d пикселей", MaxLabelCodeSize)
+	}
+	if size < columns {
+		size = columns
+	}
+	// Целое число пикселей на модуль, чтобы код оставался читаемым для сканера
+	size = size / columns * columns
+	height := size
+	if codeType == LabelCodeCode128 {
+		height = size / 3
+	}
+
+	switch format {
+	case LabelFormatSVG, "":
+		return renderBarcodeSVG(code, size, height), "image/svg+xml", nil
+	case LabelFormatPNG:
+		scaled, err := barcode.Scale(code, size, height)
+		if err != nil {
+			return nil, "", fmt.Errorf("ошибка масштабирования кода: %w", err)
+		}
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, scaled); err != nil {
+			return nil, "", fmt.Errorf("ошибка кодирования PNG: %w", err)
+		}
+		return buf.Bytes(), "image/png", nil
+	default:
+		return nil, "", fmt.Errorf("неизвестный формат изображения: %s", format)
+	}
+}
+
+// renderBarcodeSVG строит SVG из темных модулей кода.
+// Для одномерного штрихкода соседние модули объединяются в полосы.
+func renderBarcodeSVG(code barcode.Barcode, width, height int) []byte {
+	bounds := code.Bounds()
+	columns, rows := bounds.Dx(), bounds.Dy()
+
+	// Для штрихкода единственная строка модулей растягивается на всю высоту
+	aspect := ""
+	if rows == 1 {
+		aspect = ` preserveAspectRatio="none"`
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d"%s shape-rendering="crispEdges">`,
+		width, height, columns, rows, aspect)
+	fmt.Fprintf(&buf, `<rect width="%d" height="%d" fill="#fff"/><path fill="#000" d="`, columns, rows)
+	forEachBarcodeRun(code, func(x, y, length int) {
+		fmt.Fprintf(&buf, "M%d %dh%dv1h-%dz", x, y, length, length)
+	})
+	buf.WriteString(`"/></svg>`)
+	return buf.Bytes()
+}
+
+// forEachBarcodeRun перебирает горизонтальные отрезки темных модулей кода
+func forEachBarcodeRun(code barcode.Barcode, fn func(x, y, length int)) {
+	bounds := code.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		start := -1
+		for x := bounds.Min.X; x <= bounds.Max.X; x++ {
+			dark := false
+			if x < bounds.Max.X {
+				r, _, _, _ := code.At(x, y).RGBA()
+				dark = r < 0x8000
+			}
+			switch {
+			case dark && start < 0:
+				start = x
+			case !dark && start >= 0:
+				fn(start-bounds.Min.X, y-bounds.Min.Y, x-start)
+				start = -1
+			}
+		}
+	}
+}
+
+// GetEquipmentCode возвращает содержимое кода оборудования, при необходимости назначая QR код
+func (s *EquipmentLabelService) GetEquipmentCode(equipmentID uint) (string, error) {
+	return NewWarehouseService(s.DB, nil).GenerateQRCode(equipmentID)
+}
+
+// GetLabelEquipment загружает оборудование для печати этикеток по списку ID или по приходному документу
+func (s *EquipmentLabelService) GetLabelEquipment(equipmentIDs []uint, receiptID *uint) ([]models.Equipment, error) {
+	ids := equipmentIDs
+	if receiptID != nil {
+		var receiptIDs []uint
+		if err := s.DB.Model(&models.GoodsReceiptItem{}).
+			Where("goods_receipt_id = ?", *receiptID).
+			Order("line_number").
+			Pluck("equipment_id", &receiptIDs).Error; err != nil {
+			return nil, fmt.Errorf("ошибка при получении позиций документа: %w", err)
+		}
+		if len(receiptIDs) == 0 {
+			return nil, fmt.Errorf("в приходном документе нет оборудования")
+		}
+		ids = append(ids, receiptIDs...)
+	}
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("не выбрано оборудование для печати")
+	}
+
+	var found []models.Equipment
+	if err := s.DB.Where("id IN ?", ids).Find(&found).Error; err != nil {
+		return nil, fmt.Errorf("ошибка при получении оборудования: %w", err)
+	}
+	byID := make(map[uint]models.Equipment, len(found))
+	for _, equipment := range found {
+		byID[equipment.ID] = equipment
+	}
+
+	// Сохраняем порядок выбора, повторные ID печатаются один раз
+	equipment := make([]models.Equipment, 0, len(found))
+	seen := make(map[uint]bool, len(ids))
+	for _, id := range ids {
+		item, ok := byID[id]
+		if !ok {
+			return nil, fmt.Errorf("оборудование %d не найдено", id)
+		}
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		if item.QRCode == "" {
+			code, err := s.GetEquipmentCode(item.ID)
+			if err != nil {
+				return nil, err
+			}
+			item.QRCode = code
+		}
+		equipment = append(equipment, item)
+	}
+
+	return equipment, nil
+}
+
+// GenerateLabelSheet раскладывает этикетки выбранного оборудования в PDF
+func (s *EquipmentLabelService) GenerateLabelSheet(equipmentIDs []uint, receiptID *uint, opts LabelSheetOptions) ([]byte, error) {
+	if err := opts.normalize(); err != nil {
+		return nil, err
+	}
+
+	equipment, err := s.GetLabelEquipment(equipmentIDs, receiptID)
+	if err != nil {
+		return nil, err
+	}
+
+	return RenderLabelSheetPDF(equipment, opts)
+}
+
+// RenderLabelSheetPDF формирует PDF с этикетками: код, модель, серийный номер и IMEI
+func RenderLabelSheetPDF(equipment []models.Equipment, opts LabelSheetOptions) ([]byte, error) {
+	if err := opts.normalize(); err != nil {
+		return nil, err
+	}
+
+	var pdf *gofpdf.Fpdf
+	columns, rows := 1, 1
+	if opts.PageSize == "roll" {
+		orientation := "P"
+		if opts.LabelWidth > opts.LabelHeight {
+			orientation = "L"
+		}
+		pdf = gofpdf.NewCustom(&gofpdf.InitType{
+			OrientationStr: orientation,
+			UnitStr:        "mm",
+			Size:           gofpdf.SizeType{Wd: opts.LabelWidth, Ht: opts.LabelHeight},
+		})
+		opts.Margin = 0
+		opts.Gap = 0
+	} else {
+		pdf = gofpdf.New("P", "mm", "A4", "")
+		pageWidth, pageHeight := pdf.GetPageSize()
+		columns = int((pageWidth - 2*opts.Margin + opts.Gap) / (opts.LabelWidth + opts.Gap))
+		rows = int((pageHeight - 2*opts.Margin + opts.Gap) / (opts.LabelHeight + opts.Gap))
+		if columns < 1 || rows < 1 {
+			return nil, fmt.Errorf("этикетка %.0fx%.0f мм не помещается на лист A4", opts.LabelWidth, opts.LabelHeight)
+		}
+	}
+	pdf.AddUTF8FontFromBytes(labelFontFamily, "", goregular.TTF)
+	pdf.AddUTF8FontFromBytes(labelFontFamily, "B", gobold.TTF)
+	pdf.SetMargins(0, 0, 0)
+	pdf.SetAutoPageBreak(false, 0)
+	pdf.SetFillColor(0, 0, 0)
+	pdf.SetDrawColor(160, 160, 160)
+
+	perPage := columns * rows
+	for i, item := range equipment {
+		if i%perPage == 0 {
+			pdf.AddPage()
+		}
+		position := i % perPage
+		x := opts.Margin + float64(position%columns)*(opts.LabelWidth+opts.Gap)
+		y := opts.Margin + float64(position/columns)*(opts.LabelHeight+opts.Gap)
+
+		if err := drawEquipmentLabel(pdf, item, x, y, opts); err != nil {
+			return nil, err
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, fmt.Errorf("ошибка формирования PDF: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// drawEquipmentLabel рисует одну этикетку с левым верхним углом в точке (x, y)
+func drawEquipmentLabel(pdf *gofpdf.Fpdf, equipment models.Equipment, x, y float64, opts LabelSheetOptions) error {
+	code, err := EncodeLabelCode(equipment.QRCode, opts.CodeType)
+	if err != nil {
+		return fmt.Errorf("оборудование %s: %w", equipment.SerialNumber, err)
+	}
+
+	if opts.Border {
+		pdf.Rect(x, y, opts.LabelWidth, opts.LabelHeight, "D")
+	}
+
+	const padding = 2.0
+	const lineHeight = 3.2
+	lines := labelTextLines(equipment)
+	textHeight := float64(len(lines)) * lineHeight
+
+	innerWidth := opts.LabelWidth - 2*padding
+	codeHeight := opts.LabelHeight - 2*padding - textHeight - 1
+	if codeHeight < 5 {
+		return fmt.Errorf("этикетка %.0fx%.0f мм слишком мала для кода и подписи", opts.LabelWidth, opts.LabelHeight)
+	}
+
+	codeWidth := innerWidth
+	if opts.CodeType == LabelCodeQR {
+		if codeWidth > codeHeight {
+			codeWidth = codeHeight
+		}
+		codeHeight = codeWidth
+	}
+	codeX := x + (opts.LabelWidth-codeWidth)/2
+	drawBarcodePDF(pdf, code, codeX, y+padding, codeWidth, codeHeight)
+
+	textY := y + padding + codeHeight + 1
+	for i, line := range lines {
+		if i == 0 {
+			pdf.SetFont(labelFontFamily, "B", 7)
+		} else {
+			pdf.SetFont(labelFontFamily, "", 7)
+		}
+		pdf.SetXY(x+padding, textY+float64(i)*lineHeight)
+		pdf.CellFormat(innerWidth, lineHeight, fitLabelText(pdf, line, innerWidth), "", 0, "C", false, 0, "")
+	}
+
+	return pdf.Error()
+}
+
+// labelTextLines подпись под кодом: модель, серийный номер и IMEI
+func labelTextLines(equipment models.Equipment) []string {
+	title := strings.TrimSpace(equipment.Brand + " " + equipment.Model)
+	lines := []string{title, "S/N: " + equipment.SerialNumber}
+	if equipment.IMEI != "" && equipment.IMEI != equipment.SerialNumber {
+		lines = append(lines, "IMEI: "+equipment.IMEI)
+	}
+	return lines
+}
+
+// fitLabelText обрезает строку под ширину этикетки по целым символам
+func fitLabelText(pdf *gofpdf.Fpdf, text string, width float64) string {
+	runes := []rune(text)
+	for len(runes) > 0 && pdf.GetStringWidth(string(runes)) > width {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes)
+}
+
+// drawBarcodePDF рисует модули кода векторными прямоугольниками
+func drawBarcodePDF(pdf *gofpdf.Fpdf, code barcode.Barcode, x, y, width, height float64) {
+	bounds := code.Bounds()
+	moduleWidth := width / float64(bounds.Dx())
+	moduleHeight := height / float64(bounds.Dy())
+
+	forEachBarcodeRun(code, func(col, row, length int) {
+		pdf.Rect(x+float64(col)*moduleWidth, y+float64(row)*moduleHeight, float64(length)*moduleWidth, moduleHeight, "F")
+	})
+}
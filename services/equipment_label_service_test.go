@@ -0,0 +1,105 @@
+package services
+
+import (
+	"bytes"
+	"image/png"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/jung-kurt/gofpdf"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/image/font/gofont/goregular"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"backend_axenta/models"
+)
+
+func TestRenderLabelCode(t *testing.T) {
+	data, contentType, err := RenderLabelCode("EQ-12-SN123", LabelCodeQR, LabelFormatPNG, 200)
+	require.NoError(t, err)
+	assert.Equal(t, "image/png", contentType)
+	img, err := png.Decode(bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.Equal(t, img.Bounds().Dx(), img.Bounds().Dy())
+	assert.LessOrEqual(t, img.Bounds().Dx(), 200)
+
+	// Левый верхний угол QR кода - темный модуль поискового узора
+	r, _, _, _ := img.At(0, 0).RGBA()
+	assert.Less(t, r, uint32(0x8000))
+
+	svg, contentType, err := RenderLabelCode("EQ-12-SN123", LabelCodeCode128, LabelFormatSVG, 400)
+	require.NoError(t, err)
+	assert.Equal(t, "image/svg+xml", contentType)
+	assert.True(t, strings.HasPrefix(string(svg), "<svg"))
+	assert.Contains(t, string(svg), `preserveAspectRatio="none"`)
+
+	_, _, err = RenderLabelCode("EQ-12-SN123", "datamatrix", LabelFormatPNG, 200)
+	assert.Error(t, err)
+
+	// Размер изображения ограничен, чтобы запрос не занимал процессор
+	_, _, err = RenderLabelCode("EQ-12-SN123", LabelCodeQR, LabelFormatPNG, 200000)
+	assert.Error(t, err)
+	data, _, err = RenderLabelCode("EQ-12-SN123", LabelCodeQR, LabelFormatPNG, MaxLabelCodeSize)
+	require.NoError(t, err)
+	img, err = png.Decode(bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.LessOrEqual(t, img.Bounds().Dx(), MaxLabelCodeSize)
+	_, _, err = RenderLabelCode("", LabelCodeQR, LabelFormatPNG, 200)
+	assert.Error(t, err)
+}
+
+func TestEquipmentLabelService_GenerateLabelSheet(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.Equipment{}, &models.GoodsReceipt{}, &models.GoodsReceiptItem{}))
+
+	withCode := models.Equipment{Type: "GPS-tracker", Brand: "Навтелеком", Model: "Смарт S-2435", SerialNumber: "SN-1", IMEI: "350000000000001", QRCode: "EQ-1-SN-1", Status: "in_stock"}
+	require.NoError(t, db.Create(&withCode).Error)
+	withoutCode := models.Equipment{Type: "GPS-tracker", Model: "GT06N", SerialNumber: "SN-2", QRCode: "tmp", Status: "in_stock"}
+	require.NoError(t, db.Create(&withoutCode).Error)
+	require.NoError(t, db.Model(&models.Equipment{}).Where("id = ?", withoutCode.ID).Update("qr_code", "").Error)
+
+	receipt := models.GoodsReceipt{Number: "GR-20260101-001", WarehouseID: 1, EquipmentType: "GPS-tracker", Model: "GT06N", Quantity: 2}
+	require.NoError(t, db.Create(&receipt).Error)
+	for i, id := range []uint{withCode.ID, withoutCode.ID} {
+		require.NoError(t, db.Create(&models.GoodsReceiptItem{GoodsReceiptID: receipt.ID, EquipmentID: id, LineNumber: i + 1}).Error)
+	}
+
+	service := NewEquipmentLabelService(db)
+
+	pdfData, err := service.GenerateLabelSheet(nil, &receipt.ID, LabelSheetOptions{CodeType: LabelCodeCode128, LabelWidth: 70, LabelHeight: 30})
+	require.NoError(t, err)
+	assert.True(t, bytes.HasPrefix(pdfData, []byte("%PDF")))
+	// Кириллица выводится встроенным TrueType шрифтом, а не Arial в cp1252
+	assert.Contains(t, string(pdfData), "/FontFile2")
+	assert.NotContains(t, string(pdfData), "/BaseFont /Helvetica")
+
+	// Оборудованию без кода назначается QR код при печати
+	var updated models.Equipment
+	require.NoError(t, db.First(&updated, withoutCode.ID).Error)
+	assert.Equal(t, "EQ-2-SN-2", updated.QRCode)
+
+	// Термопринтер: одна этикетка на странице
+	rollData, err := service.GenerateLabelSheet([]uint{withCode.ID, withoutCode.ID, withCode.ID}, nil, LabelSheetOptions{PageSize: "roll"})
+	require.NoError(t, err)
+	assert.Equal(t, 2, bytes.Count(rollData, []byte("/Type /Page\n")))
+
+	// Длинная подпись обрезается по целым символам
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.AddUTF8FontFromBytes(labelFontFamily, "", goregular.TTF)
+	pdf.SetFont(labelFontFamily, "", 7)
+	fitted := fitLabelText(pdf, strings.Repeat("Трекер ", 20), 30)
+	assert.True(t, utf8.ValidString(fitted))
+	assert.Less(t, len([]rune(fitted)), 140)
+
+	_, err = service.GenerateLabelSheet([]uint{999}, nil, LabelSheetOptions{})
+	assert.Error(t, err)
+	_, err = service.GenerateLabelSheet([]uint{withCode.ID}, nil, LabelSheetOptions{LabelWidth: 300})
+	assert.Error(t, err)
+}
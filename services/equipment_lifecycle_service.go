@@ -0,0 +1,807 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+
+	"backend_axenta/models"
+)
+
+// EquipmentLifecycleService ведет финансовый жизненный цикл оборудования:
+// амортизацию, обслуживание, возвраты поставщику, списание и совокупную стоимость владения
+type EquipmentLifecycleService struct {
+	DB *gorm.DB
+}
+
+// NewEquipmentLifecycleService создает новый экземпляр EquipmentLifecycleService
+func NewEquipmentLifecycleService(db *gorm.DB) *EquipmentLifecycleService {
+	return &EquipmentLifecycleService{DB: db}
+}
+
+// DisposalRequest данные для списания оборудования
+type DisposalRequest struct {
+	Reason        string          `json:"reason"`
+	ResidualValue decimal.Decimal `json:"residual_value"` // Выручка от реализации или стоимость лома
+	DisposedAt    *time.Time      `json:"disposed_at"`
+	Notes         string          `json:"notes"`
+	UserID        uint            `json:"-"`
+}
+
+// MaintenanceRequest данные о выполненном обслуживании
+type MaintenanceRequest struct {
+	Cost        decimal.Decimal `json:"cost"`
+	Description string          `json:"description"`
+	PerformedAt *time.Time      `json:"performed_at"`
+	UserID      uint            `json:"-"`
+}
+
+// RMARequest данные для отправки оборудования поставщику
+type RMARequest struct {
+	EquipmentID  uint   `json:"equipment_id"`
+	Reason       string `json:"reason"`
+	SupplierName string `json:"supplier_name"`
+	UserID       uint   `json:"-"`
+}
+
+// RMAResolveRequest ответ поставщика по возврату
+type RMAResolveRequest struct {
+	Status                  string          `json:"status"` // repaired, replaced, refunded, rejected
+	Resolution              string          `json:"resolution"`
+	WarehouseID             *uint           `json:"warehouse_id"` // Склад, на который возвращается оборудование
+	RepairCost              decimal.Decimal `json:"repair_cost"`
+	RefundAmount            decimal.Decimal `json:"refund_amount"`
+	ReplacementSerialNumber string          `json:"replacement_serial_number"`
+	ReplacementIMEI         string          `json:"replacement_imei"`
+	UserID                  uint            `json:"-"`
+}
+
+// DepreciationScheduleRow строка графика амортизации
+type DepreciationScheduleRow struct {
+	Month        string          `json:"month"` // 2026-01
+	Depreciation decimal.Decimal `json:"depreciation"`
+	Accumulated  decimal.Decimal `json:"accumulated"`
+	BookValue    decimal.Decimal `json:"book_value"`
+}
+
+// DepreciationInfo амортизация оборудования на дату
+type DepreciationInfo struct {
+	EquipmentID             uint                      `json:"equipment_id"`
+	Date                    time.Time                 `json:"date"`
+	PurchasePrice           decimal.Decimal           `json:"purchase_price"`
+	SalvageValue            decimal.Decimal           `json:"salvage_value"`
+	UsefulLifeMonths        int                       `json:"useful_life_months"`
+	StartDate               time.Time                 `json:"start_date"`
+	MonthlyDepreciation     decimal.Decimal           `json:"monthly_depreciation"`
+	MonthsDepreciated       int                       `json:"months_depreciated"`
+	AccumulatedDepreciation decimal.Decimal           `json:"accumulated_depreciation"`
+	BookValue               decimal.Decimal           `json:"book_value"`
+	FullyDepreciated        bool                      `json:"fully_depreciated"`
+	Disposed                bool                      `json:"disposed"`
+	Schedule                []DepreciationScheduleRow `json:"schedule,omitempty"`
+}
+
+// DepreciationReportRow строка ведомости амортизации
+type DepreciationReportRow struct {
+	EquipmentID             uint            `json:"equipment_id"`
+	SerialNumber            string          `json:"serial_number"`
+	Model                   string          `json:"model"`
+	Status                  string          `json:"status"`
+	PurchaseDate            time.Time       `json:"purchase_date"`
+	PurchasePrice           decimal.Decimal `json:"purchase_price"`
+	AccumulatedDepreciation decimal.Decimal `json:"accumulated_depreciation"`
+	BookValue               decimal.Decimal `json:"book_value"`
+}
+
+// DepreciationReport ведомость балансовой стоимости оборудования на дату
+type DepreciationReport struct {
+	Date                  time.Time               `json:"date"`
+	Rows                  []DepreciationReportRow `json:"rows"`
+	TotalPurchasePrice    decimal.Decimal         `json:"total_purchase_price"`
+	TotalDepreciation     decimal.Decimal         `json:"total_depreciation"`
+	TotalBookValue        decimal.Decimal         `json:"total_book_value"`
+	DepreciationForPeriod decimal.Decimal         `json:"depreciation_for_period"` // Начислено за месяц даты отчета
+	FullyDepreciatedCount int                     `json:"fully_depreciated_count"`
+	EquipmentCount        int                     `json:"equipment_count"`
+}
+
+// TCOInstallationCost доля затрат монтажа, отнесенная на оборудование
+type TCOInstallationCost struct {
+	InstallationID uint            `json:"installation_id"`
+	Type           string          `json:"type"`
+	CompletedAt    *time.Time      `json:"completed_at"`
+	EquipmentCount int             `json:"equipment_count"` // Сколько единиц оборудования в монтаже делят затраты
+	MaterialsCost  decimal.Decimal `json:"materials_cost"`
+	LaborCost      decimal.Decimal `json:"labor_cost"`
+}
+
+// EquipmentTCO совокупная стоимость владения оборудованием
+type EquipmentTCO struct {
+	EquipmentID        uint                  `json:"equipment_id"`
+	PurchaseCost       decimal.Decimal       `json:"purchase_cost"`
+	MaintenanceCost    decimal.Decimal       `json:"maintenance_cost"` // Обслуживание и ремонт, включая платный ремонт по RMA
+	MaterialsCost      decimal.Decimal       `json:"materials_cost"`
+	LaborCost          decimal.Decimal       `json:"labor_cost"`
+	RecoveredValue     decimal.Decimal       `json:"recovered_value"` // Выручка при списании, возврат денег поставщиком
+	Total              decimal.Decimal       `json:"total"`
+	OwnershipMonths    int                   `json:"ownership_months"`
+	CostPerMonth       decimal.Decimal       `json:"cost_per_month"`
+	BookValue          decimal.Decimal       `json:"book_value"`
+	MaintenanceCount   int                   `json:"maintenance_count"`
+	Installations      []TCOInstallationCost `json:"installations"`
+	InstallationsCount int                   `json:"installations_count"`
+}
+
+// loadEquipment загружает оборудование с категорией, от которой зависит срок полезного использования
+func (s *EquipmentLifecycleService) loadEquipment(db *gorm.DB, equipmentID uint) (*models.Equipment, error) {
+	var equipment models.Equipment
+	if err := db.Preload("Category").First(&equipment, equipmentID).Error; err != nil {
+		return nil, fmt.Errorf("оборудование не найдено: %w", err)
+	}
+	return &equipment, nil
+}
+
+// GetDepreciation рассчитывает амортизацию и балансовую стоимость оборудования на дату
+func (s *EquipmentLifecycleService) GetDepreciation(equipmentID uint, date time.Time, withSchedule bool) (*DepreciationInfo, error) {
+	equipment, err := s.loadEquipment(s.DB, equipmentID)
+	if err != nil {
+		return nil, err
+	}
+
+	life := equipment.EffectiveUsefulLife()
+	months := equipment.DepreciationMonthsAt(date)
+	info := &DepreciationInfo{
+		EquipmentID:             equipment.ID,
+		Date:                    date,
+		PurchasePrice:           equipment.PurchasePrice,
+		SalvageValue:            equipment.SalvageValue,
+		UsefulLifeMonths:        life,
+		StartDate:               equipment.DepreciationStart(),
+		MonthlyDepreciation:     equipment.MonthlyDepreciation(),
+		MonthsDepreciated:       months,
+		AccumulatedDepreciation: equipment.AccumulatedDepreciationAt(date),
+		BookValue:               equipment.BookValueAt(date),
+		FullyDepreciated:        months >= life,
+		Disposed:                equipment.DisposedAt != nil && !date.Before(*equipment.DisposedAt),
+	}
+
+	if withSchedule {
+		start := info.StartDate
+		firstMonth := time.Date(start.Year(), start.Month(), 1, 0, 0, 0, 0, start.Location())
+		previous := decimal.Zero
+		for i := 1; i <= life; i++ {
+			month := firstMonth.AddDate(0, i, 0)
+			if equipment.DisposedAt != nil && month.After(*equipment.DisposedAt) {
+				break
+			}
+			accumulated := equipment.AccumulatedDepreciationAt(month)
+			info.Schedule = append(info.Schedule, DepreciationScheduleRow{
+				Month:        month.Format("2006-01"),
+				Depreciation: accumulated.Sub(previous),
+				Accumulated:  accumulated,
+				BookValue:    equipment.PurchasePrice.Sub(accumulated),
+			})
+			previous = accumulated
+		}
+	}
+
+	return info, nil
+}
+
+// GetDepreciationReport формирует ведомость балансовой стоимости оборудования, числящегося на дату
+func (s *EquipmentLifecycleService) GetDepreciationReport(date time.Time, categoryID *uint, warehouseID *uint) (*DepreciationReport, error) {
+	query := s.DB.Preload("Category").
+		Where("purchase_price > 0").
+		Where("disposed_at IS NULL OR disposed_at > ?", date).
+		Where("COALESCE(purchase_date, created_at) <= ?", date)
+	if categoryID != nil {
+		query = query.Where("category_id = ?", *categoryID)
+	}
+	if warehouseID != nil {
+		query = query.Where("warehouse_id = ?", *warehouseID)
+	}
+
+	var equipment []models.Equipment
+	if err := query.Order("id").Find(&equipment).Error; err != nil {
+		return nil, fmt.Errorf("ошибка при получении оборудования: %w", err)
+	}
+
+	report := &DepreciationReport{
+		Date:                  date,
+		Rows:                  make([]DepreciationReportRow, 0, len(equipment)),
+		TotalPurchasePrice:    decimal.Zero,
+		TotalDepreciation:     decimal.Zero,
+		TotalBookValue:        decimal.Zero,
+		DepreciationForPeriod: decimal.Zero,
+	}
+	previousMonth := date.AddDate(0, -1, 0)
+	for i := range equipment {
+		item := &equipment[i]
+		accumulated := item.AccumulatedDepreciationAt(date)
+		row := DepreciationReportRow{
+			EquipmentID:             item.ID,
+			SerialNumber:            item.SerialNumber,
+			Model:                   item.Model,
+			Status:                  item.Status,
+			PurchaseDate:            item.DepreciationStart(),
+			PurchasePrice:           item.PurchasePrice,
+			AccumulatedDepreciation: accumulated,
+			BookValue:               item.BookValueAt(date),
+		}
+		report.Rows = append(report.Rows, row)
+		report.TotalPurchasePrice = report.TotalPurchasePrice.Add(row.PurchasePrice)
+		report.TotalDepreciation = report.TotalDepreciation.Add(row.AccumulatedDepreciation)
+		report.TotalBookValue = report.TotalBookValue.Add(row.BookValue)
+		report.DepreciationForPeriod = report.DepreciationForPeriod.Add(accumulated.Sub(item.AccumulatedDepreciationAt(previousMonth)))
+		if item.DepreciationMonthsAt(date) >= item.EffectiveUsefulLife() {
+			report.FullyDepreciatedCount++
+		}
+	}
+	report.EquipmentCount = len(report.Rows)
+
+	return report, nil
+}
+
+// DisposeEquipment списывает оборудование с фиксацией причины, остаточной и балансовой стоимости
+func (s *EquipmentLifecycleService) DisposeEquipment(equipmentID uint, req DisposalRequest) (*models.Equipment, error) {
+	req.Reason = strings.TrimSpace(req.Reason)
+	if req.Reason == "" {
+		return nil, fmt.Errorf("не указана причина списания")
+	}
+	if req.ResidualValue.IsNegative() {
+		return nil, fmt.Errorf("остаточная стоимость не может быть отрицательной")
+	}
+	disposedAt := time.Now()
+	if req.DisposedAt != nil {
+		disposedAt = *req.DisposedAt
+	}
+
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		equipment, err := lockEquipment(tx, equipmentID)
+		if err != nil {
+			return err
+		}
+		if equipment.Status == models.EquipmentStatusRMA {
+			return fmt.Errorf("оборудование находится у поставщика, закройте возврат")
+		}
+		operation, err := writeOffEquipment(tx, equipment, disposedAt, req.Reason, req.ResidualValue, "", req.UserID)
+		if err != nil {
+			return err
+		}
+		if req.Notes != "" {
+			return tx.Model(operation).Update("notes", operation.Notes+"\n"+req.Notes).Error
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.loadEquipment(s.DB, equipmentID)
+}
+
+// loadEquipmentCategory подгружает категорию заблокированного оборудования для расчета амортизации
+func loadEquipmentCategory(tx *gorm.DB, equipment *models.Equipment) {
+	if equipment.CategoryID == nil || equipment.Category != nil {
+		return
+	}
+	var category models.EquipmentCategory
+	if err := tx.First(&category, *equipment.CategoryID).Error; err == nil {
+		equipment.Category = &category
+	}
+}
+
+// writeOffEquipment переводит заблокированное оборудование в статус disposed и создает операцию списания.
+// Балансовая стоимость фиксируется на дату списания, разница с остаточной стоимостью попадает в заметку операции.
+func writeOffEquipment(tx *gorm.DB, equipment *models.Equipment, disposedAt time.Time, reason string,
+	residualValue decimal.Decimal, documentNumber string, userID uint) (*models.WarehouseOperation, error) {
+	loadEquipmentCategory(tx, equipment)
+	bookValue := equipment.BookValueAt(disposedAt)
+	fromWarehouseID, fromBinID, fromLocation := equipment.WarehouseID, equipment.StorageBinID, equipment.WarehouseLocation
+
+	if err := updateEquipmentState(tx, equipment, map[string]interface{}{
+		"status":              models.EquipmentStatusDisposed,
+		"warehouse_id":        nil,
+		"storage_bin_id":      nil,
+		"warehouse_location":  "",
+		"disposed_at":         disposedAt,
+		"disposal_reason":     reason,
+		"disposal_value":      residualValue,
+		"disposal_book_value": bookValue,
+	}); err != nil {
+		return nil, err
+	}
+
+	var companyID uint
+	if fromWarehouseID != nil {
+		tx.Model(&models.Warehouse{}).Where("id = ?", *fromWarehouseID).Pluck("company_id", &companyID)
+	}
+	operation := &models.WarehouseOperation{
+		Type:            "disposal",
+		Description:     "Списание: " + reason,
+		Status:          "completed",
+		EquipmentID:     equipment.ID,
+		Quantity:        1,
+		FromLocation:    fromLocation,
+		ToLocation:      "Списано",
+		FromWarehouseID: fromWarehouseID,
+		FromBinID:       fromBinID,
+		UserID:          userID,
+		DocumentNumber:  documentNumber,
+		Notes: fmt.Sprintf("Балансовая стоимость %s, остаточная стоимость %s, убыток от списания %s",
+			bookValue.StringFixed(2), residualValue.StringFixed(2), bookValue.Sub(residualValue).StringFixed(2)),
+		CompanyID: companyID,
+	}
+	if err := tx.Create(operation).Error; err != nil {
+		return nil, fmt.Errorf("ошибка при создании операции списания: %w", err)
+	}
+	return operation, nil
+}
+
+// RecordMaintenance регистрирует обслуживание оборудования и его стоимость
+func (s *EquipmentLifecycleService) RecordMaintenance(equipmentID uint, req MaintenanceRequest) (*models.WarehouseOperation, error) {
+	if req.Cost.IsNegative() {
+		return nil, fmt.Errorf("стоимость обслуживания не может быть отрицательной")
+	}
+	performedAt := time.Now()
+	if req.PerformedAt != nil {
+		performedAt = *req.PerformedAt
+	}
+	description := strings.TrimSpace(req.Description)
+	if description == "" {
+		description = "Техническое обслуживание"
+	}
+
+	var operation *models.WarehouseOperation
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		equipment, err := lockEquipment(tx, equipmentID)
+		if err != nil {
+			return err
+		}
+		if equipment.Status == models.EquipmentStatusDisposed {
+			return fmt.Errorf("оборудование списано")
+		}
+
+		updates := map[string]interface{}{}
+		if equipment.LastMaintenanceAt == nil || performedAt.After(*equipment.LastMaintenanceAt) {
+			updates["last_maintenance_at"] = performedAt
+		}
+		if err := updateEquipmentState(tx, equipment, updates); err != nil {
+			return err
+		}
+
+		operation = &models.WarehouseOperation{
+			Type:         "maintenance",
+			Description:  description,
+			Status:       "completed",
+			EquipmentID:  equipment.ID,
+			Quantity:     1,
+			FromLocation: equipment.WarehouseLocation,
+			ToLocation:   equipment.WarehouseLocation,
+			UserID:       req.UserID,
+			Cost:         req.Cost,
+			Notes:        "Дата обслуживания: " + performedAt.Format("02.01.2006"),
+		}
+		if err := tx.Create(operation).Error; err != nil {
+			return fmt.Errorf("ошибка при создании операции обслуживания: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return operation, nil
+}
+
+// SendToRMA отправляет оборудование поставщику по гарантии или в ремонт.
+// Поставщик берется из приходного документа, если не указан явно.
+func (s *EquipmentLifecycleService) SendToRMA(req RMARequest) (*models.EquipmentRMA, error) {
+	req.Reason = strings.TrimSpace(req.Reason)
+	if req.Reason == "" {
+		return nil, fmt.Errorf("не указана причина возврата")
+	}
+
+	var rma *models.EquipmentRMA
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		equipment, err := lockEquipment(tx, req.EquipmentID)
+		if err != nil {
+			return err
+		}
+		if !equipment.CanTransitionTo(models.EquipmentStatusRMA) {
+			return fmt.Errorf("оборудование в статусе %s нельзя вернуть поставщику", equipment.Status)
+		}
+
+		now := time.Now()
+		rma = &models.EquipmentRMA{
+			Status:          models.RMAStatusSent,
+			EquipmentID:     equipment.ID,
+			SupplierName:    strings.TrimSpace(req.SupplierName),
+			Reason:          req.Reason,
+			UnderWarranty:   equipment.WarrantyUntil != nil && !now.After(*equipment.WarrantyUntil),
+			FromWarehouseID: equipment.WarehouseID,
+			SentAt:          now,
+			SentByUserID:    req.UserID,
+		}
+
+		var receipt models.GoodsReceipt
+		if err := tx.Joins("JOIN goods_receipt_items ON goods_receipt_items.goods_receipt_id = goods_receipts.id").
+			Where("goods_receipt_items.equipment_id = ?", equipment.ID).
+			First(&receipt).Error; err == nil {
+			rma.GoodsReceiptID = &receipt.ID
+			if rma.SupplierName == "" {
+				rma.SupplierName = receipt.SupplierName
+			}
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("ошибка при поиске приходного документа: %w", err)
+		}
+		if rma.SupplierName == "" {
+			return fmt.Errorf("не указан поставщик")
+		}
+		if equipment.WarehouseID != nil {
+			tx.Model(&models.Warehouse{}).Where("id = ?", *equipment.WarehouseID).Pluck("company_id", &rma.CompanyID)
+		}
+		if err := createNumberedDocument(tx, rma, "RMA", now, func(number string) { rma.Number = number }); err != nil {
+			return fmt.Errorf("ошибка при создании возврата: %w", err)
+		}
+
+		fromLocation := equipment.WarehouseLocation
+		fromBinID := equipment.StorageBinID
+		if err := updateEquipmentState(tx, equipment, map[string]interface{}{
+			"status":             models.EquipmentStatusRMA,
+			"warehouse_id":       nil,
+			"storage_bin_id":     nil,
+			"warehouse_location": "",
+		}); err != nil {
+			return err
+		}
+
+		operation := models.WarehouseOperation{
+			Type:            "rma",
+			Description:     fmt.Sprintf("Возврат поставщику %s: %s", rma.SupplierName, rma.Reason),
+			Status:          "completed",
+			EquipmentID:     equipment.ID,
+			Quantity:        1,
+			FromLocation:    fromLocation,
+			ToLocation:      rma.SupplierName,
+			FromWarehouseID: rma.FromWarehouseID,
+			FromBinID:       fromBinID,
+			UserID:          req.UserID,
+			DocumentNumber:  rma.Number,
+			CompanyID:       rma.CompanyID,
+		}
+		if err := tx.Create(&operation).Error; err != nil {
+			return fmt.Errorf("ошибка при создании операции: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetRMA(rma.ID)
+}
+
+// ResolveRMA закрывает возврат по ответу поставщика:
+// repaired - оборудование возвращается на склад, rejected - возвращается неисправным,
+// replaced - исходное списывается, на склад приходуется замена, refunded - исходное списывается с возмещением
+func (s *EquipmentLifecycleService) ResolveRMA(rmaID uint, req RMAResolveRequest) (*models.EquipmentRMA, error) {
+	if req.RepairCost.IsNegative() || req.RefundAmount.IsNegative() {
+		return nil, fmt.Errorf("суммы не могут быть отрицательными")
+	}
+
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		var rma models.EquipmentRMA
+		if err := tx.Clauses(lockingForUpdate).First(&rma, rmaID).Error; err != nil {
+			return fmt.Errorf("возврат не найден: %w", err)
+		}
+		if !rma.IsOpen() {
+			return fmt.Errorf("возврат %s уже закрыт", rma.Number)
+		}
+
+		equipment, err := lockEquipment(tx, rma.EquipmentID)
+		if err != nil {
+			return err
+		}
+		if equipment.Status != models.EquipmentStatusRMA {
+			return ErrEquipmentConflict
+		}
+
+		warehouseID := req.WarehouseID
+		if warehouseID == nil {
+			warehouseID = rma.FromWarehouseID
+		}
+		var warehouse *models.Warehouse
+		if req.Status != models.RMAStatusRefunded {
+			if warehouseID == nil {
+				return fmt.Errorf("не указан склад для возврата оборудования")
+			}
+			warehouse = &models.Warehouse{}
+			if err := tx.First(warehouse, *warehouseID).Error; err != nil {
+				return fmt.Errorf("склад не найден: %w", err)
+			}
+		}
+
+		now := time.Now()
+		switch req.Status {
+		case models.RMAStatusRepaired, models.RMAStatusRejected:
+			status := models.EquipmentStatusInStock
+			if req.Status == models.RMAStatusRejected {
+				status = models.EquipmentStatusBroken
+			}
+			if err := s.returnFromRMA(tx, &rma, equipment, warehouse, status, req); err != nil {
+				return err
+			}
+		case models.RMAStatusReplaced:
+			replacement, err := s.receiveReplacement(tx, &rma, equipment, warehouse, req)
+			if err != nil {
+				return err
+			}
+			rma.ReplacementEquipmentID = &replacement.ID
+			loadEquipmentCategory(tx, equipment)
+			// Замена принимает стоимость исходного оборудования, поэтому исходное списывается без убытка
+			if _, err := writeOffEquipment(tx, equipment, now, "замена по гарантии",
+				equipment.BookValueAt(now), rma.Number, req.UserID); err != nil {
+				return err
+			}
+		case models.RMAStatusRefunded:
+			if _, err := writeOffEquipment(tx, equipment, now, "возврат поставщику с возмещением",
+				req.RefundAmount, rma.Number, req.UserID); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("неизвестный результат возврата: %s", req.Status)
+		}
+
+		rma.Status = req.Status
+		rma.Resolution = req.Resolution
+		rma.ResolvedAt = &now
+		rma.ResolvedByUserID = &req.UserID
+		rma.RepairCost = req.RepairCost
+		rma.RefundAmount = req.RefundAmount
+		rma.ReturnWarehouseID = warehouseID
+		return tx.Omit("Equipment", "ReplacementEquipment").Save(&rma).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetRMA(rmaID)
+}
+
+// returnFromRMA возвращает оборудование от поставщика на склад
+func (s *EquipmentLifecycleService) returnFromRMA(tx *gorm.DB, rma *models.EquipmentRMA, equipment *models.Equipment,
+	warehouse *models.Warehouse, status string, req RMAResolveRequest) error {
+	location := models.StockLocationLabel(warehouse, nil)
+	if err := updateEquipmentState(tx, equipment, map[string]interface{}{
+		"status":             status,
+		"warehouse_id":       warehouse.ID,
+		"storage_bin_id":     nil,
+		"warehouse_location": location,
+	}); err != nil {
+		return err
+	}
+
+	description := "Возврат от поставщика после ремонта"
+	if status == models.EquipmentStatusBroken {
+		description = "Возврат от поставщика: в гарантии отказано"
+	}
+	operation := models.WarehouseOperation{
+		Type:           "rma",
+		Description:    description,
+		Status:         "completed",
+		EquipmentID:    equipment.ID,
+		Quantity:       1,
+		FromLocation:   rma.SupplierName,
+		ToLocation:     location,
+		ToWarehouseID:  &warehouse.ID,
+		UserID:         req.UserID,
+		DocumentNumber: rma.Number,
+		Cost:           req.RepairCost,
+		Notes:          req.Resolution,
+		CompanyID:      warehouse.CompanyID,
+	}
+	if err := tx.Create(&operation).Error; err != nil {
+		return fmt.Errorf("ошибка при создании операции: %w", err)
+	}
+	return nil
+}
+
+// receiveReplacement приходует на склад оборудование, полученное взамен возвращенного.
+// Замена наследует закупочную стоимость, дату начала амортизации и гарантию исходного оборудования.
+func (s *EquipmentLifecycleService) receiveReplacement(tx *gorm.DB, rma *models.EquipmentRMA, original *models.Equipment,
+	warehouse *models.Warehouse, req RMAResolveRequest) (*models.Equipment, error) {
+	serial := strings.TrimSpace(req.ReplacementSerialNumber)
+	imei := strings.TrimSpace(req.ReplacementIMEI)
+	if serial == "" {
+		serial = imei
+	}
+	if serial == "" {
+		return nil, fmt.Errorf("не указан серийный номер или IMEI замены")
+	}
+
+	var duplicates int64
+	query := tx.Unscoped().Model(&models.Equipment{}).Where("serial_number = ?", serial)
+	if imei != "" {
+		query = query.Or("imei = ?", imei)
+	}
+	if err := query.Count(&duplicates).Error; err != nil {
+		return nil, fmt.Errorf("ошибка при проверке серийного номера: %w", err)
+	}
+	if duplicates > 0 {
+		return nil, fmt.Errorf("оборудование с серийным номером %s или IMEI %s уже на учете", serial, imei)
+	}
+
+	location := models.StockLocationLabel(warehouse, nil)
+	replacement := models.Equipment{
+		Type:              original.Type,
+		Model:             original.Model,
+		Brand:             original.Brand,
+		SerialNumber:      serial,
+		IMEI:              imei,
+		QRCode:            "EQ-" + serial,
+		Status:            models.EquipmentStatusInStock,
+		Condition:         "new",
+		CategoryID:        original.CategoryID,
+		WarehouseID:       &warehouse.ID,
+		WarehouseLocation: location,
+		PurchasePrice:     original.PurchasePrice,
+		PurchaseDate:      original.PurchaseDate,
+		WarrantyUntil:     original.WarrantyUntil,
+		UsefulLifeMonths:  original.UsefulLifeMonths,
+		SalvageValue:      original.SalvageValue,
+	}
+	if replacement.PurchaseDate == nil {
+		purchaseDate := original.CreatedAt
+		replacement.PurchaseDate = &purchaseDate
+	}
+
+	create := tx
+	if replacement.IMEI == "" {
+		create = tx.Omit("IMEI")
+	}
+	if err := create.Create(&replacement).Error; err != nil {
+		return nil, fmt.Errorf("ошибка при создании оборудования замены: %w", err)
+	}
+	replacement.QRCode = fmt.Sprintf("EQ-%d-%s", replacement.ID, replacement.SerialNumber)
+	if err := tx.Model(&models.Equipment{}).Where("id = ?", replacement.ID).Update("qr_code", replacement.QRCode).Error; err != nil {
+		return nil, fmt.Errorf("ошибка при сохранении QR кода: %w", err)
+	}
+
+	operation := models.WarehouseOperation{
+		Type:           "receive",
+		Description:    fmt.Sprintf("Замена по гарантии взамен %s", original.SerialNumber),
+		Status:         "completed",
+		EquipmentID:    replacement.ID,
+		Quantity:       1,
+		FromLocation:   rma.SupplierName,
+		ToLocation:     location,
+		ToWarehouseID:  &warehouse.ID,
+		UserID:         req.UserID,
+		DocumentNumber: rma.Number,
+		Notes:          req.Resolution,
+		CompanyID:      warehouse.CompanyID,
+	}
+	if err := tx.Create(&operation).Error; err != nil {
+		return nil, fmt.Errorf("ошибка при создании операции: %w", err)
+	}
+
+	return &replacement, nil
+}
+
+// GetRMA возвращает возврат поставщику
+func (s *EquipmentLifecycleService) GetRMA(id uint) (*models.EquipmentRMA, error) {
+	var rma models.EquipmentRMA
+	if err := s.DB.Preload("Equipment").Preload("ReplacementEquipment").First(&rma, id).Error; err != nil {
+		return nil, fmt.Errorf("возврат не найден: %w", err)
+	}
+	return &rma, nil
+}
+
+// GetRMAs возвращает список возвратов поставщикам
+func (s *EquipmentLifecycleService) GetRMAs(status string, equipmentID *uint) ([]models.EquipmentRMA, error) {
+	query := s.DB.Preload("Equipment")
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if equipmentID != nil {
+		query = query.Where("equipment_id = ? OR replacement_equipment_id = ?", *equipmentID, *equipmentID)
+	}
+
+	var rmas []models.EquipmentRMA
+	if err := query.Order("sent_at DESC").Find(&rmas).Error; err != nil {
+		return nil, fmt.Errorf("ошибка при получении возвратов: %w", err)
+	}
+	return rmas, nil
+}
+
+// GetTCO рассчитывает совокупную стоимость владения оборудованием: закупка, обслуживание
+// и доля затрат завершенных монтажей за вычетом выручки при списании и возмещений поставщика
+func (s *EquipmentLifecycleService) GetTCO(equipmentID uint, date time.Time) (*EquipmentTCO, error) {
+	equipment, err := s.loadEquipment(s.DB, equipmentID)
+	if err != nil {
+		return nil, err
+	}
+
+	tco := &EquipmentTCO{
+		EquipmentID:    equipment.ID,
+		PurchaseCost:   equipment.PurchasePrice,
+		RecoveredValue: equipment.DisposalValue,
+		BookValue:      equipment.BookValueAt(date),
+		Installations:  []TCOInstallationCost{},
+	}
+
+	// Обслуживание и платный ремонт учитываются по операциям с указанной стоимостью
+	var maintenance []models.WarehouseOperation
+	if err := s.DB.Where("equipment_id = ? AND cost > 0 AND created_at <= ?", equipment.ID, date).
+		Find(&maintenance).Error; err != nil {
+		return nil, fmt.Errorf("ошибка при получении операций обслуживания: %w", err)
+	}
+	tco.MaintenanceCost = decimal.Zero
+	for _, operation := range maintenance {
+		tco.MaintenanceCost = tco.MaintenanceCost.Add(operation.Cost)
+	}
+	tco.MaintenanceCount = len(maintenance)
+
+	// Затраты монтажа делятся поровну между всем оборудованием монтажа
+	type installationRow struct {
+		ID             uint
+		Type           string
+		CompletedAt    *time.Time
+		MaterialsCost  float64
+		LaborCost      float64
+		EquipmentCount int
+	}
+	var rows []installationRow
+	if err := s.DB.Table("installations").
+		Select(`installations.id, installations.type, installations.completed_at,
+			installations.materials_cost, installations.labor_cost,
+			(SELECT COUNT(*) FROM installation_equipment all_equipment
+				WHERE all_equipment.installation_id = installations.id) AS equipment_count`).
+		Joins("JOIN installation_equipment ON installation_equipment.installation_id = installations.id").
+		Where("installation_equipment.equipment_id = ?", equipment.ID).
+		Where("installations.status = ? AND installations.deleted_at IS NULL", "completed").
+		Where("installations.completed_at IS NULL OR installations.completed_at <= ?", date).
+		Order("installations.completed_at").
+		Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("ошибка при получении монтажей: %w", err)
+	}
+
+	tco.MaterialsCost = decimal.Zero
+	tco.LaborCost = decimal.Zero
+	for _, row := range rows {
+		share := decimal.NewFromInt(int64(max(row.EquipmentCount, 1)))
+		cost := TCOInstallationCost{
+			InstallationID: row.ID,
+			Type:           row.Type,
+			CompletedAt:    row.CompletedAt,
+			EquipmentCount: row.EquipmentCount,
+			MaterialsCost:  decimal.NewFromFloat(row.MaterialsCost).Div(share).Round(2),
+			LaborCost:      decimal.NewFromFloat(row.LaborCost).Div(share).Round(2),
+		}
+		tco.Installations = append(tco.Installations, cost)
+		tco.MaterialsCost = tco.MaterialsCost.Add(cost.MaterialsCost)
+		tco.LaborCost = tco.LaborCost.Add(cost.LaborCost)
+	}
+	tco.InstallationsCount = len(tco.Installations)
+
+	tco.Total = tco.PurchaseCost.Add(tco.MaintenanceCost).Add(tco.MaterialsCost).Add(tco.LaborCost).Sub(tco.RecoveredValue)
+
+	// Срок владения: до списания или до даты расчета
+	end := date
+	if equipment.DisposedAt != nil && equipment.DisposedAt.Before(end) {
+		end = *equipment.DisposedAt
+	}
+	start := equipment.DepreciationStart()
+	tco.OwnershipMonths = (end.Year()-start.Year())*12 + int(end.Month()) - int(start.Month())
+	if tco.OwnershipMonths < 1 {
+		tco.OwnershipMonths = 1
+	}
+	tco.CostPerMonth = tco.Total.Div(decimal.NewFromInt(int64(tco.OwnershipMonths))).Round(2)
+
+	return tco, nil
+}
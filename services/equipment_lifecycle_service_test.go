@@ -0,0 +1,173 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"backend_axenta/models"
+)
+
+func setupEquipmentLifecycleTest(t *testing.T) (*gorm.DB, *EquipmentLifecycleService, *models.Warehouse) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(
+		&models.Warehouse{},
+		&models.StorageBin{},
+		&models.Equipment{},
+		&models.EquipmentCategory{},
+		&models.WarehouseOperation{},
+		&models.Installation{},
+		&models.GoodsReceipt{},
+		&models.GoodsReceiptItem{},
+		&models.EquipmentRMA{},
+	))
+
+	warehouse := &models.Warehouse{Name: "Основной склад", Code: "MSK", IsActive: true}
+	require.NoError(t, db.Create(warehouse).Error)
+
+	return db, NewEquipmentLifecycleService(db), warehouse
+}
+
+func createLifecycleEquipment(t *testing.T, db *gorm.DB, serial string, price int64, purchased time.Time, warehouseID uint) models.Equipment {
+	equipment := models.Equipment{
+		Type: "GPS-tracker", Model: "GT06N", SerialNumber: serial, IMEI: "35000000000" + serial, QRCode: "EQ-" + serial,
+		Status: models.EquipmentStatusInStock, Condition: "new", WarehouseID: &warehouseID,
+		PurchasePrice: decimal.NewFromInt(price), PurchaseDate: &purchased, UsefulLifeMonths: 36,
+	}
+	require.NoError(t, db.Create(&equipment).Error)
+	return equipment
+}
+
+func TestEquipment_StraightLineDepreciation(t *testing.T) {
+	purchased := time.Date(2025, 1, 15, 0, 0, 0, 0, time.UTC)
+	equipment := models.Equipment{
+		PurchasePrice:    decimal.NewFromInt(3600),
+		SalvageValue:     decimal.NewFromInt(0),
+		PurchaseDate:     &purchased,
+		UsefulLifeMonths: 36,
+	}
+
+	assert.True(t, decimal.NewFromInt(100).Equal(equipment.MonthlyDepreciation()))
+	assert.True(t, decimal.NewFromInt(3600).Equal(equipment.BookValueAt(time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC))))
+	assert.True(t, decimal.NewFromInt(2400).Equal(equipment.BookValueAt(time.Date(2026, 1, 20, 0, 0, 0, 0, time.UTC))))
+	assert.True(t, decimal.Zero.Equal(equipment.BookValueAt(time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC))))
+	assert.True(t, decimal.Zero.Equal(equipment.BookValueAt(time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC))))
+
+	// Срок из категории и ликвидационная стоимость
+	equipment.UsefulLifeMonths = 0
+	equipment.Category = &models.EquipmentCategory{UsefulLifeMonths: 12}
+	equipment.SalvageValue = decimal.NewFromInt(1200)
+	assert.Equal(t, 12, equipment.EffectiveUsefulLife())
+	assert.True(t, decimal.NewFromInt(1200).Equal(equipment.BookValueAt(time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC))))
+
+	// После списания начисление прекращается
+	disposed := time.Date(2025, 4, 10, 0, 0, 0, 0, time.UTC)
+	equipment.DisposedAt = &disposed
+	assert.Equal(t, 3, equipment.DepreciationMonthsAt(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)))
+	assert.True(t, decimal.Zero.Equal(equipment.BookValueAt(disposed)))
+}
+
+func TestEquipmentLifecycleService_DisposeAndTCO(t *testing.T) {
+	db, service, warehouse := setupEquipmentLifecycleTest(t)
+	purchased := time.Now().AddDate(0, -12, 0)
+	equipment := createLifecycleEquipment(t, db, "0001", 3600, purchased, warehouse.ID)
+	other := createLifecycleEquipment(t, db, "0002", 3600, purchased, warehouse.ID)
+
+	// Монтаж двух устройств: затраты делятся поровну
+	completedAt := time.Now().AddDate(0, -6, 0)
+	installation := models.Installation{
+		Type: "монтаж", Status: "completed", ScheduledAt: completedAt, CompletedAt: &completedAt,
+		ObjectID: 1, InstallerID: 1, MaterialsCost: 500, LaborCost: 2000,
+		Equipment: []models.Equipment{{ID: equipment.ID}, {ID: other.ID}},
+	}
+	require.NoError(t, db.Omit("Equipment.*").Create(&installation).Error)
+
+	_, err := service.RecordMaintenance(equipment.ID, MaintenanceRequest{Cost: decimal.NewFromInt(300), Description: "Замена антенны"})
+	require.NoError(t, err)
+
+	_, err = service.DisposeEquipment(equipment.ID, DisposalRequest{})
+	assert.Error(t, err, "причина обязательна")
+
+	disposed, err := service.DisposeEquipment(equipment.ID, DisposalRequest{Reason: "Физический износ", ResidualValue: decimal.NewFromInt(100)})
+	require.NoError(t, err)
+	assert.Equal(t, models.EquipmentStatusDisposed, disposed.Status)
+	assert.Nil(t, disposed.WarehouseID)
+	assert.True(t, decimal.NewFromInt(2400).Equal(disposed.DisposalBookValue))
+	assert.True(t, decimal.NewFromInt(100).Equal(disposed.DisposalValue))
+	require.NotNil(t, disposed.LastMaintenanceAt)
+
+	tco, err := service.GetTCO(equipment.ID, time.Now())
+	require.NoError(t, err)
+	assert.True(t, decimal.NewFromInt(300).Equal(tco.MaintenanceCost))
+	assert.True(t, decimal.NewFromInt(250).Equal(tco.MaterialsCost))
+	assert.True(t, decimal.NewFromInt(1000).Equal(tco.LaborCost))
+	assert.True(t, decimal.NewFromInt(3600+300+250+1000-100).Equal(tco.Total))
+	assert.Equal(t, 12, tco.OwnershipMonths)
+	require.Len(t, tco.Installations, 1)
+	assert.Equal(t, 2, tco.Installations[0].EquipmentCount)
+
+	report, err := service.GetDepreciationReport(time.Now(), nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, 1, report.EquipmentCount)
+	assert.Equal(t, other.ID, report.Rows[0].EquipmentID)
+	assert.True(t, decimal.NewFromInt(2400).Equal(report.TotalBookValue))
+	assert.True(t, decimal.NewFromInt(100).Equal(report.DepreciationForPeriod))
+}
+
+func TestEquipmentLifecycleService_RMA(t *testing.T) {
+	db, service, warehouse := setupEquipmentLifecycleTest(t)
+	purchased := time.Now().AddDate(0, -2, 0)
+	warranty := time.Now().AddDate(1, 0, 0)
+
+	equipment := createLifecycleEquipment(t, db, "0001", 3600, purchased, warehouse.ID)
+	require.NoError(t, db.Model(&models.Equipment{}).Where("id = ?", equipment.ID).Update("warranty_until", warranty).Error)
+	receipt := models.GoodsReceipt{Number: "GR-1", SupplierName: "ООО Навтелеком", WarehouseID: warehouse.ID, EquipmentType: "GPS-tracker", Model: "GT06N", Quantity: 1}
+	require.NoError(t, db.Create(&receipt).Error)
+	require.NoError(t, db.Create(&models.GoodsReceiptItem{GoodsReceiptID: receipt.ID, EquipmentID: equipment.ID, LineNumber: 1}).Error)
+
+	rma, err := service.SendToRMA(RMARequest{EquipmentID: equipment.ID, Reason: "Не включается"})
+	require.NoError(t, err)
+	assert.Equal(t, "ООО Навтелеком", rma.SupplierName)
+	assert.True(t, rma.UnderWarranty)
+	assert.Equal(t, models.EquipmentStatusRMA, rma.Equipment.Status)
+	assert.Nil(t, rma.Equipment.WarehouseID)
+
+	// Повторная отправка невозможна
+	_, err = service.SendToRMA(RMARequest{EquipmentID: equipment.ID, Reason: "Не включается"})
+	assert.Error(t, err)
+
+	rma, err = service.ResolveRMA(rma.ID, RMAResolveRequest{Status: models.RMAStatusReplaced, ReplacementSerialNumber: "0009", ReplacementIMEI: "350000000000009"})
+	require.NoError(t, err)
+	assert.Equal(t, models.RMAStatusReplaced, rma.Status)
+	require.NotNil(t, rma.ReplacementEquipment)
+	assert.Equal(t, models.EquipmentStatusInStock, rma.ReplacementEquipment.Status)
+	require.NotNil(t, rma.ReplacementEquipment.WarehouseID)
+	assert.Equal(t, warehouse.ID, *rma.ReplacementEquipment.WarehouseID)
+	assert.True(t, equipment.PurchasePrice.Equal(rma.ReplacementEquipment.PurchasePrice))
+	assert.Equal(t, models.EquipmentStatusDisposed, rma.Equipment.Status)
+
+	_, err = service.ResolveRMA(rma.ID, RMAResolveRequest{Status: models.RMAStatusRepaired})
+	assert.Error(t, err, "закрытый возврат не закрывается повторно")
+
+	// Платный ремонт попадает в затраты на обслуживание
+	broken := createLifecycleEquipment(t, db, "0002", 3600, purchased, warehouse.ID)
+	rma, err = service.SendToRMA(RMARequest{EquipmentID: broken.ID, Reason: "Разбит корпус", SupplierName: "Сервисный центр"})
+	require.NoError(t, err)
+	assert.False(t, rma.UnderWarranty)
+	rma, err = service.ResolveRMA(rma.ID, RMAResolveRequest{Status: models.RMAStatusRepaired, RepairCost: decimal.NewFromInt(700)})
+	require.NoError(t, err)
+	assert.Equal(t, models.EquipmentStatusInStock, rma.Equipment.Status)
+
+	tco, err := service.GetTCO(broken.ID, time.Now())
+	require.NoError(t, err)
+	assert.True(t, decimal.NewFromInt(700).Equal(tco.MaintenanceCost))
+}
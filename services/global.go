@@ -1,5 +1,7 @@
 package services
 
+import "github.com/google/uuid"
+
 // Временно отключено - IntegrationService недоступен
 // var GlobalIntegrationService *IntegrationService
 
@@ -12,3 +14,40 @@ func GetIntegrationService() interface{} {
 func SetIntegrationService(service interface{}) {
 	// Временно отключено
 }
+
+// globalWebhookService сервис исходящих вебхуков, запущенный приложением
+var globalWebhookService *WebhookService
+
+// SetWebhookService устанавливает глобальный сервис вебхуков
+func SetWebhookService(service *WebhookService) {
+	globalWebhookService = service
+}
+
+// PublishWebhookEvent ставит событие в очередь доставки подписчикам компании.
+// Если сервис вебхуков не запущен, событие игнорируется.
+func PublishWebhookEvent(companyID uuid.UUID, eventType string, data interface{}) {
+	if globalWebhookService == nil {
+		return
+	}
+
+	if _, err := globalWebhookService.Publish(companyID, eventType, data); err != nil {
+		globalWebhookService.logger.Printf("Ошибка публикации события %s для компании %s: %v", eventType, companyID, err)
+	}
+}
+
+// globalServiceRequestNotifier сервис уведомлений заявителей, запущенный приложением
+var globalServiceRequestNotifier *ServiceRequestNotifier
+
+// SetServiceRequestNotifier устанавливает глобальный сервис уведомлений заявителей
+func SetServiceRequestNotifier(notifier *ServiceRequestNotifier) {
+	globalServiceRequestNotifier = notifier
+}
+
+// NotifyServiceRequest ставит заявку в очередь уведомления заявителя.
+// Если сервис уведомлений не запущен, события остаются недоставленными.
+func NotifyServiceRequest(companyID uuid.UUID, requestID uint) {
+	if globalServiceRequestNotifier == nil {
+		return
+	}
+	globalServiceRequestNotifier.Enqueue(companyID, requestID)
+}
@@ -0,0 +1,621 @@
+package services
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"html/template"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/xuri/excelize/v2"
+	"gorm.io/gorm"
+
+	"backend_axenta/models"
+)
+
+// GoodsReceiptService оформляет поступление партий оборудования от поставщиков
+type GoodsReceiptService struct {
+	DB *gorm.DB
+}
+
+// NewGoodsReceiptService создает новый экземпляр GoodsReceiptService
+func NewGoodsReceiptService(db *gorm.DB) *GoodsReceiptService {
+	return &GoodsReceiptService{DB: db}
+}
+
+// GoodsReceiptLine строка приходного документа до оприходования
+type GoodsReceiptLine struct {
+	LineNumber   int              `json:"line_number"`
+	SerialNumber string           `json:"serial_number"`
+	IMEI         string           `json:"imei"`
+	PhoneNumber  string           `json:"phone_number"`
+	Price        *decimal.Decimal `json:"price"`
+}
+
+// GoodsReceiptRequest данные для оформления поступления
+type GoodsReceiptRequest struct {
+	SupplierName   string             `json:"supplier_name" form:"supplier_name"`
+	SupplierINN    string             `json:"supplier_inn" form:"supplier_inn"`
+	InvoiceNumber  string             `json:"invoice_number" form:"invoice_number"`
+	InvoiceDate    *time.Time         `json:"invoice_date" form:"invoice_date" time_format:"2006-01-02"`
+	ReceivedAt     *time.Time         `json:"received_at" form:"received_at" time_format:"2006-01-02"`
+	WarehouseID    uint               `json:"warehouse_id" form:"warehouse_id"`
+	StorageBinID   *uint              `json:"storage_bin_id" form:"storage_bin_id"`
+	CategoryID     *uint              `json:"category_id" form:"category_id"`
+	EquipmentType  string             `json:"equipment_type" form:"equipment_type"`
+	Model          string             `json:"model" form:"model"`
+	Brand          string             `json:"brand" form:"brand"`
+	UnitPrice      decimal.Decimal    `json:"unit_price" form:"unit_price"`
+	WarrantyMonths int                `json:"warranty_months" form:"warranty_months"`
+	Notes          string             `json:"notes" form:"notes"`
+	UserID         uint               `json:"-" form:"-"`
+	Items          []GoodsReceiptLine `json:"items" form:"-"`
+
+	// Позиция заказа поставщику, по которой поступила партия
+	PurchaseOrderItemID *uint `json:"purchase_order_item_id" form:"purchase_order_item_id"`
+}
+
+// GoodsReceiptProblem ошибка в строке приходного документа
+type GoodsReceiptProblem struct {
+	LineNumber   int    `json:"line_number"`
+	SerialNumber string `json:"serial_number,omitempty"`
+	IMEI         string `json:"imei,omitempty"`
+	Message      string `json:"message"`
+}
+
+// GoodsReceiptValidationError ошибка проверки строк документа: дубликаты и незаполненные идентификаторы
+type GoodsReceiptValidationError struct {
+	Problems []GoodsReceiptProblem `json:"problems"`
+}
+
+func (e *GoodsReceiptValidationError) Error() string {
+	return fmt.Sprintf("в приходном документе найдено ошибок: %d", len(e.Problems))
+}
+
+// imeiPattern IMEI состоит из 15 цифр
+var imeiPattern = regexp.MustCompile(`^\d{15}$`)
+
+// goodsReceiptColumns допустимые названия колонок файла поставщика
+var goodsReceiptColumns = map[string][]string{
+	"serial_number": {"serial_number", "serial", "sn", "s/n", "серийный номер", "серийный №", "заводской номер"},
+	"imei":          {"imei", "имей"},
+	"phone_number":  {"phone_number", "phone", "msisdn", "телефон", "номер телефона", "номер sim"},
+	"price":         {"price", "цена", "стоимость"},
+}
+
+// ParseGoodsReceiptFile разбирает список серийных номеров и IMEI из файла поставщика.
+// Поддерживаются Excel (.xlsx) и CSV с заголовком, а также выгрузка сканера штрихкодов:
+// по одному коду в строке без заголовка.
+func ParseGoodsReceiptFile(filename string, data []byte) ([]GoodsReceiptLine, error) {
+	rows, err := readSpreadsheetRows(filename, data)
+	if err != nil {
+		return nil, err
+	}
+	return parseGoodsReceiptRows(rows)
+}
+
+// readSpreadsheetRows читает строки первого листа Excel (.xlsx) или CSV файла.
+// Кодировка и разделитель CSV определяются автоматически.
+func readSpreadsheetRows(filename string, data []byte) ([][]string, error) {
+	if strings.EqualFold(filepath.Ext(filename), ".xlsx") {
+		f, err := excelize.OpenReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("ошибка чтения Excel файла: %w", err)
+		}
+		defer f.Close()
+
+		sheets := f.GetSheetList()
+		if len(sheets) == 0 {
+			return nil, fmt.Errorf("в Excel файле нет листов")
+		}
+		rows, err := f.GetRows(sheets[0])
+		if err != nil {
+			return nil, fmt.Errorf("ошибка чтения листа %s: %w", sheets[0], err)
+		}
+		return rows, nil
+	}
+
+	text := decodeBankStatementText(data)
+	reader := csv.NewReader(strings.NewReader(text))
+	reader.Comma = detectCSVDelimiter(text)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	var rows [][]string
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("ошибка чтения CSV: %w", err)
+		}
+		rows = append(rows, record)
+	}
+	return rows, nil
+}
+
+// matchSpreadsheetColumns сопоставляет колонки заголовка с полями по списку допустимых названий
+func matchSpreadsheetColumns(header []string, aliases map[string][]string) map[string]int {
+	columns := map[string]int{}
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		for field, names := range aliases {
+			if _, found := columns[field]; found {
+				continue
+			}
+			for _, alias := range names {
+				if name == alias {
+					columns[field] = i
+					break
+				}
+			}
+		}
+	}
+	return columns
+}
+
+// parseGoodsReceiptRows преобразует строки таблицы в строки приходного документа
+func parseGoodsReceiptRows(rows [][]string) ([]GoodsReceiptLine, error) {
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("файл не содержит данных")
+	}
+
+	columns := matchSpreadsheetColumns(rows[0], goodsReceiptColumns)
+
+	// Без заголовка файл считается выгрузкой сканера: код в первой колонке
+	dataRows := rows[1:]
+	firstLine := 2
+	if len(columns) == 0 {
+		dataRows = rows
+		firstLine = 1
+	} else if _, hasSerial := columns["serial_number"]; !hasSerial {
+		if _, hasIMEI := columns["imei"]; !hasIMEI {
+			return nil, fmt.Errorf("в файле отсутствует колонка с серийным номером или IMEI")
+		}
+	}
+
+	get := func(record []string, field string) string {
+		if i, ok := columns[field]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	var lines []GoodsReceiptLine
+	for i, record := range dataRows {
+		lineNumber := firstLine + i
+
+		line := GoodsReceiptLine{LineNumber: lineNumber}
+		if len(columns) == 0 {
+			if len(record) == 0 || strings.TrimSpace(record[0]) == "" {
+				continue
+			}
+			code := strings.TrimSpace(record[0])
+			if imeiPattern.MatchString(code) {
+				line.IMEI = code
+			} else {
+				line.SerialNumber = code
+			}
+		} else {
+			line.SerialNumber = get(record, "serial_number")
+			line.IMEI = get(record, "imei")
+			line.PhoneNumber = get(record, "phone_number")
+			if rawPrice := get(record, "price"); rawPrice != "" {
+				price, err := parseBankAmount(rawPrice)
+				if err != nil {
+					return nil, fmt.Errorf("строка %d: неверная цена %q", lineNumber, rawPrice)
+				}
+				line.Price = &price
+			}
+		}
+
+		if line.SerialNumber == "" && line.IMEI == "" {
+			continue // Пустая строка
+		}
+		lines = append(lines, line)
+	}
+
+	if len(lines) == 0 {
+		return nil, fmt.Errorf("в файле не найдено ни одного серийного номера или IMEI")
+	}
+	return lines, nil
+}
+
+// ValidateLines проверяет строки документа: формат IMEI, дубликаты в файле и среди оборудования на учете.
+// Если серийный номер не указан, им становится IMEI.
+func (s *GoodsReceiptService) ValidateLines(lines []GoodsReceiptLine) ([]GoodsReceiptLine, []GoodsReceiptProblem, error) {
+	var problems []GoodsReceiptProblem
+	seenSerials := map[string]int{}
+	seenIMEIs := map[string]int{}
+	var serials, imeis []string
+
+	for i := range lines {
+		line := &lines[i]
+		if line.LineNumber == 0 {
+			line.LineNumber = i + 1
+		}
+		line.SerialNumber = strings.TrimSpace(line.SerialNumber)
+		line.IMEI = strings.TrimSpace(line.IMEI)
+		if line.SerialNumber == "" {
+			line.SerialNumber = line.IMEI
+		}
+
+		if line.SerialNumber == "" {
+			problems = append(problems, GoodsReceiptProblem{LineNumber: line.LineNumber, Message: "не указан серийный номер или IMEI"})
+			continue
+		}
+		if line.IMEI != "" && !imeiPattern.MatchString(line.IMEI) {
+			problems = append(problems, GoodsReceiptProblem{LineNumber: line.LineNumber, IMEI: line.IMEI, Message: "IMEI должен состоять из 15 цифр"})
+		}
+
+		if first, ok := seenSerials[line.SerialNumber]; ok {
+			problems = append(problems, GoodsReceiptProblem{LineNumber: line.LineNumber, SerialNumber: line.SerialNumber,
+				Message: fmt.Sprintf("серийный номер повторяется в строке %d", first)})
+		} else {
+			seenSerials[line.SerialNumber] = line.LineNumber
+			serials = append(serials, line.SerialNumber)
+		}
+
+		if line.IMEI != "" {
+			if first, ok := seenIMEIs[line.IMEI]; ok {
+				problems = append(problems, GoodsReceiptProblem{LineNumber: line.LineNumber, IMEI: line.IMEI,
+					Message: fmt.Sprintf("IMEI повторяется в строке %d", first)})
+			} else {
+				seenIMEIs[line.IMEI] = line.LineNumber
+				imeis = append(imeis, line.IMEI)
+			}
+		}
+	}
+
+	// Уникальные индексы учитывают и удаленное оборудование
+	var existing []models.Equipment
+	if len(serials) > 0 || len(imeis) > 0 {
+		if err := s.DB.Unscoped().Select("id", "serial_number", "imei").
+			Where("serial_number IN ? OR imei IN ?", nonEmptyList(serials), nonEmptyList(imeis)).
+			Find(&existing).Error; err != nil {
+			return nil, nil, fmt.Errorf("ошибка при проверке дубликатов: %w", err)
+		}
+	}
+	for _, equipment := range existing {
+		if line, ok := seenSerials[equipment.SerialNumber]; ok && equipment.SerialNumber != "" {
+			problems = append(problems, GoodsReceiptProblem{LineNumber: line, SerialNumber: equipment.SerialNumber,
+				Message: fmt.Sprintf("оборудование с таким серийным номером уже на учете (ID %d)", equipment.ID)})
+		}
+		if line, ok := seenIMEIs[equipment.IMEI]; ok && equipment.IMEI != "" {
+			problems = append(problems, GoodsReceiptProblem{LineNumber: line, IMEI: equipment.IMEI,
+				Message: fmt.Sprintf("оборудование с таким IMEI уже на учете (ID %d)", equipment.ID)})
+		}
+	}
+
+	return lines, problems, nil
+}
+
+// nonEmptyList возвращает список, пригодный для условия IN
+func nonEmptyList(values []string) []string {
+	if len(values) == 0 {
+		return []string{""}
+	}
+	return values
+}
+
+// CreateReceipt оприходует партию оборудования: создает приходный документ, единицы оборудования
+// и операции поступления в одной транзакции. При дубликатах возвращается GoodsReceiptValidationError.
+func (s *GoodsReceiptService) CreateReceipt(req GoodsReceiptRequest) (*models.GoodsReceipt, error) {
+	if req.PurchaseOrderItemID != nil {
+		if err := s.applyPurchaseOrderItem(&req); err != nil {
+			return nil, err
+		}
+	}
+
+	req.SupplierName = strings.TrimSpace(req.SupplierName)
+	if req.SupplierName == "" {
+		return nil, fmt.Errorf("не указан поставщик")
+	}
+	if strings.TrimSpace(req.EquipmentType) == "" || strings.TrimSpace(req.Model) == "" {
+		return nil, fmt.Errorf("не указаны тип и модель оборудования")
+	}
+	if len(req.Items) == 0 {
+		return nil, fmt.Errorf("приходный документ не содержит оборудования")
+	}
+
+	var warehouse models.Warehouse
+	if err := s.DB.First(&warehouse, req.WarehouseID).Error; err != nil {
+		return nil, fmt.Errorf("склад не найден: %w", err)
+	}
+	if !warehouse.IsActive {
+		return nil, fmt.Errorf("склад %s отключен", warehouse.Code)
+	}
+
+	var bin *models.StorageBin
+	if req.StorageBinID != nil {
+		bin = &models.StorageBin{}
+		if err := s.DB.First(bin, *req.StorageBinID).Error; err != nil {
+			return nil, fmt.Errorf("место хранения не найдено: %w", err)
+		}
+		if bin.WarehouseID != warehouse.ID {
+			return nil, fmt.Errorf("место хранения %s не относится к складу %s", bin.Path, warehouse.Code)
+		}
+	}
+
+	if req.CategoryID != nil {
+		var category models.EquipmentCategory
+		if err := s.DB.First(&category, *req.CategoryID).Error; err != nil {
+			return nil, fmt.Errorf("категория оборудования не найдена: %w", err)
+		}
+	}
+
+	lines, problems, err := s.ValidateLines(req.Items)
+	if err != nil {
+		return nil, err
+	}
+	if len(problems) > 0 {
+		return nil, &GoodsReceiptValidationError{Problems: problems}
+	}
+
+	receivedAt := time.Now()
+	if req.ReceivedAt != nil {
+		receivedAt = *req.ReceivedAt
+	}
+	var warrantyUntil *time.Time
+	if req.WarrantyMonths > 0 {
+		until := receivedAt.AddDate(0, req.WarrantyMonths, 0)
+		warrantyUntil = &until
+	}
+	location := models.StockLocationLabel(&warehouse, bin)
+
+	receipt := &models.GoodsReceipt{
+		ReceivedAt:     receivedAt,
+		SupplierName:   req.SupplierName,
+		SupplierINN:    strings.TrimSpace(req.SupplierINN),
+		InvoiceNumber:  strings.TrimSpace(req.InvoiceNumber),
+		InvoiceDate:    req.InvoiceDate,
+		WarehouseID:    warehouse.ID,
+		StorageBinID:   req.StorageBinID,
+		CategoryID:     req.CategoryID,
+		EquipmentType:  req.EquipmentType,
+		Model:          req.Model,
+		Brand:          req.Brand,
+		UnitPrice:      req.UnitPrice,
+		WarrantyMonths: req.WarrantyMonths,
+		Quantity:       len(lines),
+		Notes:          req.Notes,
+		UserID:         req.UserID,
+		CompanyID:      warehouse.CompanyID,
+	}
+
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		if req.PurchaseOrderItemID != nil {
+			order, item, err := receivePurchaseOrderItem(tx, *req.PurchaseOrderItemID, len(lines))
+			if err != nil {
+				return err
+			}
+			receipt.PurchaseOrderID = &order.ID
+			receipt.PurchaseOrderItemID = &item.ID
+		}
+
+		if err := createNumberedDocument(tx, receipt, "GR", receivedAt, func(number string) { receipt.Number = number }); err != nil {
+			return fmt.Errorf("ошибка при создании приходного документа: %w", err)
+		}
+
+		total := decimal.Zero
+		for _, line := range lines {
+			price := req.UnitPrice
+			if line.Price != nil {
+				price = *line.Price
+			}
+			total = total.Add(price)
+
+			purchaseDate := receivedAt
+			equipment := models.Equipment{
+				Type:              req.EquipmentType,
+				Model:             req.Model,
+				Brand:             req.Brand,
+				SerialNumber:      line.SerialNumber,
+				IMEI:              line.IMEI,
+				PhoneNumber:       line.PhoneNumber,
+				QRCode:            "EQ-" + line.SerialNumber,
+				Status:            models.EquipmentStatusInStock,
+				Condition:         "new",
+				CategoryID:        req.CategoryID,
+				WarehouseID:       &warehouse.ID,
+				StorageBinID:      req.StorageBinID,
+				WarehouseLocation: location,
+				PurchasePrice:     price,
+				PurchaseDate:      &purchaseDate,
+				WarrantyUntil:     warrantyUntil,
+			}
+
+			// Пустой IMEI сохраняется как NULL, чтобы не нарушать уникальный индекс
+			create := tx
+			if equipment.IMEI == "" {
+				create = tx.Omit("IMEI")
+			}
+			if err := create.Create(&equipment).Error; err != nil {
+				return fmt.Errorf("строка %d: ошибка при создании оборудования: %w", line.LineNumber, err)
+			}
+
+			// QR код в том же формате, что и WarehouseService.GenerateQRCode
+			qrCode := fmt.Sprintf("EQ-%d-%s", equipment.ID, equipment.SerialNumber)
+			if err := tx.Model(&models.Equipment{}).Where("id = ?", equipment.ID).Update("qr_code", qrCode).Error; err != nil {
+				return fmt.Errorf("строка %d: ошибка при сохранении QR кода: %w", line.LineNumber, err)
+			}
+
+			item := models.GoodsReceiptItem{
+				GoodsReceiptID: receipt.ID,
+				EquipmentID:    equipment.ID,
+				LineNumber:     line.LineNumber,
+				SerialNumber:   line.SerialNumber,
+				IMEI:           line.IMEI,
+				PhoneNumber:    line.PhoneNumber,
+				Price:          price,
+			}
+			if err := tx.Create(&item).Error; err != nil {
+				return fmt.Errorf("строка %d: ошибка при сохранении строки документа: %w", line.LineNumber, err)
+			}
+
+			operation := models.WarehouseOperation{
+				Type:           "receive",
+				Description:    fmt.Sprintf("Поступление от поставщика %s", req.SupplierName),
+				Status:         "completed",
+				EquipmentID:    equipment.ID,
+				Quantity:       1,
+				FromLocation:   req.SupplierName,
+				ToLocation:     location,
+				ToWarehouseID:  &warehouse.ID,
+				ToBinID:        req.StorageBinID,
+				UserID:         req.UserID,
+				DocumentNumber: receipt.Number,
+				Notes:          goodsReceiptInvoiceNote(receipt),
+				CompanyID:      warehouse.CompanyID,
+			}
+			if err := tx.Create(&operation).Error; err != nil {
+				return fmt.Errorf("строка %d: ошибка при создании операции: %w", line.LineNumber, err)
+			}
+		}
+
+		receipt.TotalAmount = total
+		return tx.Model(receipt).Update("total_amount", total).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetReceipt(receipt.ID)
+}
+
+// applyPurchaseOrderItem заполняет незаданные реквизиты поступления из заказа поставщику
+func (s *GoodsReceiptService) applyPurchaseOrderItem(req *GoodsReceiptRequest) error {
+	var item models.PurchaseOrderItem
+	if err := s.DB.First(&item, *req.PurchaseOrderItemID).Error; err != nil {
+		return fmt.Errorf("позиция заказа не найдена: %w", err)
+	}
+	var order models.PurchaseOrder
+	if err := s.DB.First(&order, item.PurchaseOrderID).Error; err != nil {
+		return fmt.Errorf("заказ не найден: %w", err)
+	}
+
+	if strings.TrimSpace(req.SupplierName) == "" {
+		req.SupplierName = order.SupplierName
+		req.SupplierINN = order.SupplierINN
+	}
+	if req.WarehouseID == 0 {
+		req.WarehouseID = order.WarehouseID
+	}
+	if req.CategoryID == nil {
+		req.CategoryID = item.CategoryID
+	}
+	if strings.TrimSpace(req.EquipmentType) == "" {
+		req.EquipmentType = item.EquipmentType
+	}
+	if strings.TrimSpace(req.Model) == "" {
+		req.Model = item.Model
+		req.Brand = item.Brand
+	}
+	if req.UnitPrice.IsZero() {
+		req.UnitPrice = item.UnitPrice
+	}
+	return nil
+}
+
+// goodsReceiptInvoiceNote описание накладной поставщика для журнала операций
+func goodsReceiptInvoiceNote(receipt *models.GoodsReceipt) string {
+	if receipt.InvoiceNumber == "" {
+		return ""
+	}
+	note := "Накладная поставщика № " + receipt.InvoiceNumber
+	if receipt.InvoiceDate != nil {
+		note += " от " + receipt.InvoiceDate.Format("02.01.2006")
+	}
+	return note
+}
+
+// GetReceipt возвращает приходный документ со строками
+func (s *GoodsReceiptService) GetReceipt(id uint) (*models.GoodsReceipt, error) {
+	var receipt models.GoodsReceipt
+	if err := s.DB.Preload("Warehouse").Preload("StorageBin").Preload("Category").Preload("User").
+		Preload("Items", func(db *gorm.DB) *gorm.DB { return db.Order("line_number") }).
+		First(&receipt, id).Error; err != nil {
+		return nil, fmt.Errorf("приходный документ не найден: %w", err)
+	}
+	return &receipt, nil
+}
+
+// GetReceipts возвращает список приходных документов
+func (s *GoodsReceiptService) GetReceipts(warehouseID *uint, supplier string, limit, offset int) ([]models.GoodsReceipt, int64, error) {
+	query := s.DB.Model(&models.GoodsReceipt{})
+	if warehouseID != nil {
+		query = query.Where("warehouse_id = ?", *warehouseID)
+	}
+	if supplier != "" {
+		query = query.Where("LOWER(supplier_name) LIKE ?", "%"+strings.ToLower(supplier)+"%")
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("ошибка при подсчете документов: %w", err)
+	}
+
+	var receipts []models.GoodsReceipt
+	if err := query.Preload("Warehouse").Order("received_at DESC, id DESC").
+		Limit(limit).Offset(offset).Find(&receipts).Error; err != nil {
+		return nil, 0, fmt.Errorf("ошибка при получении документов: %w", err)
+	}
+	return receipts, total, nil
+}
+
+// goodsReceiptTemplate печатная форма приходного документа
+var goodsReceiptTemplate = template.Must(template.New("goods_receipt").Funcs(template.FuncMap{
+	"date":  func(t time.Time) string { return t.Format("02.01.2006") },
+	"money": func(d decimal.Decimal) string { return d.StringFixed(2) },
+	"inc":   func(i int) int { return i + 1 },
+}).Parse(`<!DOCTYPE html>
+<html lang="ru">
+<head>
+<meta charset="utf-8">
+<title>Приходный документ № {{.Number}}</title>
+<style>
+body { font-family: Arial, sans-serif; font-size: 12px; margin: 20px; }
+h1 { font-size: 16px; }
+table { border-collapse: collapse; width: 100%; margin-top: 12px; }
+th, td { border: 1px solid #000; padding: 4px 6px; text-align: left; }
+td.num { text-align: right; }
+.signatures { margin-top: 40px; display: flex; justify-content: space-between; }
+@media print { body { margin: 0; } }
+</style>
+</head>
+<body>
+<h1>Приходный документ № {{.Number}} от {{date .ReceivedAt}}</h1>
+<p>Поставщик: {{.SupplierName}}{{if .SupplierINN}}, ИНН {{.SupplierINN}}{{end}}</p>
+{{if .InvoiceNumber}}<p>Накладная поставщика № {{.InvoiceNumber}}{{if .InvoiceDate}} от {{date .InvoiceDate}}{{end}}</p>{{end}}
+<p>Склад: {{if .Warehouse}}{{.Warehouse.Name}} ({{.Warehouse.Code}}){{end}}{{if .StorageBin}}, место хранения {{.StorageBin.Path}}{{end}}</p>
+<p>Оборудование: {{.EquipmentType}} {{.Brand}} {{.Model}}</p>
+<table>
+<thead><tr><th>№</th><th>Серийный номер</th><th>IMEI</th><th>Номер SIM</th><th>Цена</th></tr></thead>
+<tbody>
+{{range $i, $item := .Items}}<tr><td>{{inc $i}}</td><td>{{$item.SerialNumber}}</td><td>{{$item.IMEI}}</td><td>{{$item.PhoneNumber}}</td><td class="num">{{money $item.Price}}</td></tr>
+{{end}}</tbody>
+<tfoot><tr><th colspan="4">Итого: {{.Quantity}} шт.</th><th class="num">{{money .TotalAmount}}</th></tr></tfoot>
+</table>
+{{if .Notes}}<p>Примечание: {{.Notes}}</p>{{end}}
+<div class="signatures">
+<div>Сдал: ____________________</div>
+<div>Принял: ____________________{{if .User}} ({{.User.FirstName}} {{.User.LastName}}){{end}}</div>
+</div>
+</body>
+</html>
+`))
+
+// RenderReceiptHTML формирует печатную форму приходного документа
+func (s *GoodsReceiptService) RenderReceiptHTML(receipt *models.GoodsReceipt) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := goodsReceiptTemplate.Execute(&buf, receipt); err != nil {
+		return nil, fmt.Errorf("ошибка при формировании печатной формы: %w", err)
+	}
+	return buf.Bytes(), nil
+}
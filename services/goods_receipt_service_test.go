@@ -0,0 +1,178 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xuri/excelize/v2"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"backend_axenta/models"
+)
+
+func setupGoodsReceiptTest(t *testing.T) (*gorm.DB, *GoodsReceiptService, *models.Warehouse) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(
+		&models.Warehouse{},
+		&models.StorageBin{},
+		&models.Equipment{},
+		&models.EquipmentCategory{},
+		&models.WarehouseOperation{},
+		&models.GoodsReceipt{},
+		&models.GoodsReceiptItem{},
+	))
+
+	warehouse := &models.Warehouse{Name: "Основной склад", Code: "MSK", Type: models.WarehouseTypeStock, IsActive: true}
+	require.NoError(t, db.Create(warehouse).Error)
+
+	return db, NewGoodsReceiptService(db), warehouse
+}
+
+func TestParseGoodsReceiptFile_CSVAndScanner(t *testing.T) {
+	csvData := "Серийный номер;IMEI;Цена\nSN-1;350000000000001;1500,00\nSN-2;350000000000002;\n;;\n"
+	lines, err := ParseGoodsReceiptFile("delivery.csv", []byte(csvData))
+	require.NoError(t, err)
+	require.Len(t, lines, 2)
+	assert.Equal(t, "SN-1", lines[0].SerialNumber)
+	assert.Equal(t, "350000000000001", lines[0].IMEI)
+	require.NotNil(t, lines[0].Price)
+	assert.True(t, decimal.NewFromInt(1500).Equal(*lines[0].Price))
+	assert.Nil(t, lines[1].Price)
+	assert.Equal(t, 3, lines[1].LineNumber)
+
+	// Выгрузка сканера: коды без заголовка
+	scanned, err := ParseGoodsReceiptFile("scan.txt", []byte("350000000000003\nBOX-77\n"))
+	require.NoError(t, err)
+	require.Len(t, scanned, 2)
+	assert.Equal(t, "350000000000003", scanned[0].IMEI)
+	assert.Equal(t, "BOX-77", scanned[1].SerialNumber)
+}
+
+func TestParseGoodsReceiptFile_Excel(t *testing.T) {
+	f := excelize.NewFile()
+	sheet := f.GetSheetName(0)
+	require.NoError(t, f.SetSheetRow(sheet, "A1", &[]interface{}{"IMEI", "Номер SIM"}))
+	require.NoError(t, f.SetSheetRow(sheet, "A2", &[]interface{}{"350000000000010", "+79001234567"}))
+	buf, err := f.WriteToBuffer()
+	require.NoError(t, err)
+
+	lines, err := ParseGoodsReceiptFile("delivery.xlsx", buf.Bytes())
+	require.NoError(t, err)
+	require.Len(t, lines, 1)
+	assert.Equal(t, "350000000000010", lines[0].IMEI)
+	assert.Equal(t, "+79001234567", lines[0].PhoneNumber)
+}
+
+func TestGoodsReceiptService_CreateReceipt(t *testing.T) {
+	db, service, warehouse := setupGoodsReceiptTest(t)
+
+	var items []GoodsReceiptLine
+	for i := 1; i <= 20; i++ {
+		items = append(items, GoodsReceiptLine{IMEI: fmt.Sprintf("3500000000001%02d", i)})
+	}
+	special := decimal.NewFromInt(2000)
+	items[0].Price = &special
+
+	receipt, err := service.CreateReceipt(GoodsReceiptRequest{
+		SupplierName:   "ООО Навтелеком",
+		InvoiceNumber:  "УПД-154",
+		WarehouseID:    warehouse.ID,
+		EquipmentType:  "GPS-tracker",
+		Model:          "Smart S-2435",
+		Brand:          "Навтелеком",
+		UnitPrice:      decimal.NewFromInt(1500),
+		WarrantyMonths: 12,
+		UserID:         3,
+		Items:          items,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 20, receipt.Quantity)
+	assert.Len(t, receipt.Items, 20)
+	assert.True(t, decimal.NewFromInt(30500).Equal(receipt.TotalAmount))
+	assert.Contains(t, receipt.Number, "GR-")
+
+	var equipment []models.Equipment
+	require.NoError(t, db.Where("warehouse_id = ?", warehouse.ID).Find(&equipment).Error)
+	require.Len(t, equipment, 20)
+	assert.Equal(t, models.EquipmentStatusInStock, equipment[0].Status)
+	assert.Equal(t, equipment[0].IMEI, equipment[0].SerialNumber)
+	assert.Equal(t, fmt.Sprintf("EQ-%d-%s", equipment[0].ID, equipment[0].SerialNumber), equipment[0].QRCode)
+	assert.NotNil(t, equipment[0].WarrantyUntil)
+
+	var operations int64
+	db.Model(&models.WarehouseOperation{}).Where("type = 'receive' AND document_number = ?", receipt.Number).Count(&operations)
+	assert.Equal(t, int64(20), operations)
+
+	html, err := service.RenderReceiptHTML(receipt)
+	require.NoError(t, err)
+	assert.Contains(t, string(html), receipt.Number)
+	assert.Contains(t, string(html), "ООО Навтелеком")
+	assert.Contains(t, string(html), "30500.00")
+}
+
+func TestGoodsReceiptService_CreateReceiptRejectsDuplicates(t *testing.T) {
+	db, service, warehouse := setupGoodsReceiptTest(t)
+
+	existing := models.Equipment{Type: "GPS-tracker", Model: "GT06N", SerialNumber: "SN-OLD", IMEI: "350000000000999", QRCode: "QR-OLD", Status: "installed"}
+	require.NoError(t, db.Create(&existing).Error)
+
+	_, err := service.CreateReceipt(GoodsReceiptRequest{
+		SupplierName:  "ООО Поставщик",
+		WarehouseID:   warehouse.ID,
+		EquipmentType: "GPS-tracker",
+		Model:         "GT06N",
+		Items: []GoodsReceiptLine{
+			{SerialNumber: "SN-1", IMEI: "350000000000001"},
+			{SerialNumber: "SN-2", IMEI: "350000000000001"},
+			{SerialNumber: "SN-3", IMEI: "350000000000999"},
+			{SerialNumber: "SN-4", IMEI: "12345"},
+		},
+	})
+
+	var validationErr *GoodsReceiptValidationError
+	require.True(t, errors.As(err, &validationErr))
+	assert.Len(t, validationErr.Problems, 3)
+
+	// Документ не проводится частично
+	var count int64
+	db.Model(&models.Equipment{}).Count(&count)
+	assert.Equal(t, int64(1), count)
+	db.Model(&models.GoodsReceipt{}).Count(&count)
+	assert.Equal(t, int64(0), count)
+}
+
+func TestCreateNumberedDocument(t *testing.T) {
+	db, _, warehouse := setupGoodsReceiptTest(t)
+	date := time.Date(2026, 1, 15, 10, 0, 0, 0, time.UTC)
+
+	// Номер следует за наибольшим, даже если часть документов удалена
+	for _, number := range []string{"GR-20260115-001", "GR-20260115-009"} {
+		require.NoError(t, db.Create(&models.GoodsReceipt{Number: number, WarehouseID: warehouse.ID, EquipmentType: "GPS-tracker", Model: "GT06N"}).Error)
+	}
+
+	// Номер занимает параллельно созданный документ - берется следующий
+	var assigned []string
+	receipt := &models.GoodsReceipt{WarehouseID: warehouse.ID, EquipmentType: "GPS-tracker", Model: "GT06N"}
+	err := createNumberedDocument(db, receipt, "GR", date, func(number string) {
+		if len(assigned) == 0 {
+			require.NoError(t, db.Create(&models.GoodsReceipt{Number: number, WarehouseID: warehouse.ID, EquipmentType: "GPS-tracker", Model: "GT06N"}).Error)
+		}
+		assigned = append(assigned, number)
+		receipt.Number = number
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"GR-20260115-010", "GR-20260115-011"}, assigned)
+	assert.NotZero(t, receipt.ID)
+
+	assert.False(t, isUniqueViolation(errors.New("connection refused")))
+}
@@ -0,0 +1,423 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/jung-kurt/gofpdf"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goregular"
+	"gorm.io/gorm"
+
+	"backend_axenta/models"
+)
+
+// Шрифт акта: встроенные шрифты PDF не содержат кириллицы, поэтому используются шрифты Go
+const actFontFamily = "GoFont"
+
+// InstallationActService формирует акты выполненных работ по завершенным монтажам
+type InstallationActService struct {
+	DB         *gorm.DB
+	StorageDir string
+	Timezone   string // Часовой пояс компании, если у локаций он не указан
+}
+
+// NewInstallationActService создает новый экземпляр InstallationActService
+func NewInstallationActService(db *gorm.DB) *InstallationActService {
+	return &InstallationActService{DB: db, StorageDir: DefaultInstallationFilesDir}
+}
+
+// ActImage изображение в акте
+type ActImage struct {
+	Path    string
+	Caption string
+}
+
+// InstallationAct данные акта выполненных работ
+type InstallationAct struct {
+	Number   string
+	Date     time.Time
+	Timezone *time.Location
+
+	InstallationType string
+	ObjectName       string
+	ObjectIMEI       string
+	VIN              string
+	LicensePlate     string
+	Address          string
+	ClientContact    string
+
+	InstallerName string
+	StartedAt     *time.Time
+	CompletedAt   *time.Time
+
+	Equipment []models.Equipment
+	Result    string
+	Issues    string
+	Notes     string
+
+	Photos        []ActImage
+	SignaturePath string
+	SignedBy      string
+	SignedAt      *time.Time
+}
+
+// InstallationActNumber номер акта выполненных работ по монтажу
+func InstallationActNumber(installationID uint) string {
+	return fmt.Sprintf("АМ-%05d", installationID)
+}
+
+// BuildAct собирает данные акта по завершенному монтажу
+func (s *InstallationActService) BuildAct(installationID uint) (*InstallationAct, *models.Installation, error) {
+	var installation models.Installation
+	if err := s.DB.Preload("Object").Preload("Equipment").
+		Preload("FieldPhotos", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+		First(&installation, installationID).Error; err != nil {
+		return nil, nil, err
+	}
+	if installation.Status != "completed" {
+		return nil, nil, fmt.Errorf("акт формируется только по завершенному монтажу")
+	}
+
+	var installer *models.Installer
+	var loaded models.Installer
+	if err := s.DB.First(&loaded, installation.InstallerID).Error; err == nil {
+		installer = &loaded
+	}
+
+	schedule := &InstallationService{DB: s.DB, Timezone: s.Timezone}
+	act := &InstallationAct{
+		Number:           InstallationActNumber(installation.ID),
+		Date:             time.Now(),
+		Timezone:         schedule.scheduleTimezone(installation.LocationID, installation.ObjectID, installer),
+		InstallationType: installation.Type,
+		Address:          installation.Address,
+		ClientContact:    installation.ClientContact,
+		StartedAt:        installation.StartedAt,
+		CompletedAt:      installation.CompletedAt,
+		Equipment:        installation.Equipment,
+		Result:           installation.Result,
+		Issues:           installation.Issues,
+		Notes:            installation.Notes,
+		SignaturePath:    installation.ClientSignaturePath,
+		SignedBy:         installation.ClientSignedBy,
+		SignedAt:         installation.ClientSignedAt,
+	}
+	if installation.CompletedAt != nil {
+		act.Date = *installation.CompletedAt
+	}
+	if installer != nil {
+		act.InstallerName = installer.GetFullName()
+	}
+	if object := installation.Object; object != nil {
+		act.ObjectName = object.Name
+		act.ObjectIMEI = object.IMEI
+		act.VIN = object.VIN
+		act.LicensePlate = object.LicensePlate
+		if act.Address == "" {
+			act.Address = object.Address
+		}
+	}
+	for _, photo := range installation.FieldPhotos {
+		act.Photos = append(act.Photos, ActImage{Path: photo.FilePath, Caption: photo.Caption})
+	}
+
+	return act, &installation, nil
+}
+
+// GenerateAct формирует PDF-акт по завершенному монтажу и сохраняет его рядом с файлами монтажа.
+// Повторное формирование заменяет предыдущий акт.
+func (s *InstallationActService) GenerateAct(installationID uint) (*models.Installation, error) {
+	act, installation, err := s.BuildAct(installationID)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := RenderInstallationActPDF(act)
+	if err != nil {
+		return nil, err
+	}
+
+	dir := s.StorageDir
+	if dir == "" {
+		dir = DefaultInstallationFilesDir
+	}
+	dir = filepath.Join(dir, fmt.Sprintf("%d", installationID))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("ошибка при сохранении акта: %w", err)
+	}
+	path := filepath.Join(dir, "act.pdf")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return nil, fmt.Errorf("ошибка при сохранении акта: %w", err)
+	}
+
+	now := time.Now()
+	if err := s.DB.Model(&models.Installation{}).Where("id = ?", installationID).Updates(map[string]interface{}{
+		"act_number":       act.Number,
+		"act_path":         path,
+		"act_generated_at": now,
+	}).Error; err != nil {
+		return nil, fmt.Errorf("ошибка при сохранении акта: %w", err)
+	}
+
+	installation.ActNumber = act.Number
+	installation.ActPath = path
+	installation.ActGeneratedAt = &now
+	return installation, nil
+}
+
+// GetActFile возвращает путь к PDF-акту монтажа, формируя его при первом обращении
+func (s *InstallationActService) GetActFile(installationID uint) (string, error) {
+	var installation models.Installation
+	if err := s.DB.Select("id", "act_path").First(&installation, installationID).Error; err != nil {
+		return "", err
+	}
+	if installation.ActPath != "" {
+		if _, err := os.Stat(installation.ActPath); err == nil {
+			return installation.ActPath, nil
+		}
+	}
+
+	generated, err := s.GenerateAct(installationID)
+	if err != nil {
+		return "", err
+	}
+	return generated.ActPath, nil
+}
+
+// RenderInstallationActPDF формирует PDF акта выполненных работ
+func RenderInstallationActPDF(act *InstallationAct) ([]byte, error) {
+	tz := act.Timezone
+	if tz == nil {
+		tz = models.LoadTimezone("")
+	}
+	formatTime := func(t *time.Time) string {
+		if t == nil {
+			return "-"
+		}
+		return t.In(tz).Format("02.01.2006 15:04")
+	}
+
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.AddUTF8FontFromBytes(actFontFamily, "", goregular.TTF)
+	pdf.AddUTF8FontFromBytes(actFontFamily, "B", gobold.TTF)
+	pdf.SetMargins(15, 15, 15)
+	pdf.SetAutoPageBreak(true, 15)
+	pdf.AliasNbPages("")
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-12)
+		pdf.SetFont(actFontFamily, "", 8)
+		pdf.CellFormat(0, 5, fmt.Sprintf("Акт № %s, стр. %d из {nb}", act.Number, pdf.PageNo()), "", 0, "R", false, 0, "")
+	})
+	pdf.AddPage()
+	pageWidth, _ := pdf.GetPageSize()
+	contentWidth := pageWidth - 30
+
+	pdf.SetFont(actFontFamily, "B", 14)
+	pdf.CellFormat(contentWidth, 8, "АКТ № "+act.Number, "", 1, "C", false, 0, "")
+	pdf.SetFont(actFontFamily, "", 11)
+	pdf.CellFormat(contentWidth, 6, "о выполнении работ по монтажу оборудования", "", 1, "C", false, 0, "")
+	pdf.CellFormat(contentWidth, 6, "от "+act.Date.In(tz).Format("02.01.2006"), "", 1, "C", false, 0, "")
+	pdf.Ln(4)
+
+	section := func(title string) {
+		pdf.Ln(2)
+		pdf.SetFont(actFontFamily, "B", 11)
+		pdf.CellFormat(contentWidth, 7, title, "", 1, "L", false, 0, "")
+		pdf.SetFont(actFontFamily, "", 10)
+	}
+	field := func(label, value string) {
+		if value == "" {
+			value = "-"
+		}
+		pdf.SetFont(actFontFamily, "B", 10)
+		pdf.CellFormat(50, 6, label, "", 0, "L", false, 0, "")
+		pdf.SetFont(actFontFamily, "", 10)
+		pdf.MultiCell(contentWidth-50, 6, value, "", "L", false)
+	}
+
+	section("Объект")
+	field("Наименование", act.ObjectName)
+	field("IMEI", act.ObjectIMEI)
+	field("VIN", act.VIN)
+	field("Гос. номер", act.LicensePlate)
+	field("Адрес", act.Address)
+	if act.ClientContact != "" {
+		field("Контакт клиента", act.ClientContact)
+	}
+
+	section("Работы")
+	field("Вид работ", act.InstallationType)
+	field("Монтажник", act.InstallerName)
+	field("Начало работ", formatTime(act.StartedAt))
+	field("Окончание работ", formatTime(act.CompletedAt))
+	if act.Result != "" {
+		field("Результат", act.Result)
+	}
+	if act.Issues != "" {
+		field("Замечания", act.Issues)
+	}
+	if act.Notes != "" {
+		field("Примечания", act.Notes)
+	}
+
+	section("Установленное оборудование")
+	if len(act.Equipment) == 0 {
+		pdf.CellFormat(contentWidth, 6, "Оборудование не устанавливалось", "", 1, "L", false, 0, "")
+	} else {
+		widths := []float64{10, 70, 50, contentWidth - 130}
+		pdf.SetFont(actFontFamily, "B", 9)
+		for i, header := range []string{"№", "Оборудование", "Серийный номер", "IMEI"} {
+			pdf.CellFormat(widths[i], 7, header, "1", 0, "C", false, 0, "")
+		}
+		pdf.Ln(-1)
+		pdf.SetFont(actFontFamily, "", 9)
+		for i, equipment := range act.Equipment {
+			title := strings.TrimSpace(equipment.Brand + " " + equipment.Model)
+			if title == "" {
+				title = equipment.Type
+			}
+			row := []string{fmt.Sprintf("%d", i+1), title, equipment.SerialNumber, equipment.IMEI}
+			for j, value := range row {
+				align := "L"
+				if j == 0 {
+					align = "C"
+				}
+				pdf.CellFormat(widths[j], 6, value, "1", 0, align, false, 0, "")
+			}
+			pdf.Ln(-1)
+		}
+	}
+
+	if err := drawActPhotos(pdf, act.Photos, contentWidth); err != nil {
+		return nil, err
+	}
+	if err := drawActSignatures(pdf, act, contentWidth, formatTime); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, fmt.Errorf("ошибка формирования PDF: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// registerActImage загружает изображение в PDF; отсутствующие и поврежденные файлы пропускаются
+func registerActImage(pdf *gofpdf.Fpdf, path string) (*gofpdf.ImageInfoType, bool) {
+	data, err := os.ReadFile(path)
+	if err != nil || len(data) == 0 {
+		return nil, false
+	}
+	imageType := "JPG"
+	if bytes.HasPrefix(data, []byte("\x89PNG")) {
+		imageType = "PNG"
+	}
+	info := pdf.RegisterImageOptionsReader(path, gofpdf.ImageOptions{ImageType: imageType}, bytes.NewReader(data))
+	if pdf.Err() {
+		pdf.ClearError()
+		return nil, false
+	}
+	return info, info != nil
+}
+
+// drawActPhotos выводит фотографии с монтажа по две в ряд
+func drawActPhotos(pdf *gofpdf.Fpdf, photos []ActImage, contentWidth float64) error {
+	if len(photos) == 0 {
+		return nil
+	}
+	pdf.AddPage()
+	pdf.SetFont(actFontFamily, "B", 11)
+	pdf.CellFormat(contentWidth, 7, "Фотографии с объекта", "", 1, "L", false, 0, "")
+
+	const gap = 6.0
+	const maxHeight = 70.0
+	cellWidth := (contentWidth - gap) / 2
+	_, pageHeight := pdf.GetPageSize()
+	left, _, _, bottom := pdf.GetMargins()
+
+	column := 0
+	rowY := pdf.GetY() + 2
+	for _, photo := range photos {
+		info, ok := registerActImage(pdf, photo.Path)
+		if !ok {
+			continue
+		}
+		width, height := cellWidth, cellWidth*info.Height()/info.Width()
+		if height > maxHeight {
+			width, height = maxHeight*info.Width()/info.Height(), maxHeight
+		}
+
+		if column == 0 && rowY+maxHeight+8 > pageHeight-bottom {
+			pdf.AddPage()
+			rowY = pdf.GetY()
+		}
+		x := left + float64(column)*(cellWidth+gap)
+		pdf.ImageOptions(photo.Path, x+(cellWidth-width)/2, rowY, width, height, false, gofpdf.ImageOptions{}, 0, "")
+		if photo.Caption != "" {
+			pdf.SetFont(actFontFamily, "", 8)
+			pdf.SetXY(x, rowY+height+1)
+			pdf.CellFormat(cellWidth, 4, photo.Caption, "", 0, "C", false, 0, "")
+		}
+
+		column++
+		if column == 2 {
+			column = 0
+			rowY += maxHeight + 8
+		}
+	}
+	pdf.SetY(rowY + maxHeight + 8)
+	return pdf.Error()
+}
+
+// drawActSignatures выводит подписи монтажника и клиента
+func drawActSignatures(pdf *gofpdf.Fpdf, act *InstallationAct, contentWidth float64, formatTime func(*time.Time) string) error {
+	_, pageHeight := pdf.GetPageSize()
+	_, _, _, bottom := pdf.GetMargins()
+	if pdf.GetY()+50 > pageHeight-bottom {
+		pdf.AddPage()
+	}
+	pdf.Ln(6)
+	pdf.SetFont(actFontFamily, "", 10)
+	pdf.MultiCell(contentWidth, 5, "Работы выполнены в полном объеме. Заказчик претензий по объему, качеству и срокам выполнения работ не имеет.", "", "L", false)
+	pdf.Ln(6)
+
+	left, _, _, _ := pdf.GetMargins()
+	half := contentWidth / 2
+	top := pdf.GetY()
+
+	pdf.SetFont(actFontFamily, "B", 10)
+	pdf.SetXY(left, top)
+	pdf.CellFormat(half, 6, "Работы сдал (исполнитель)", "", 0, "L", false, 0, "")
+	pdf.CellFormat(half, 6, "Работы принял (заказчик)", "", 1, "L", false, 0, "")
+
+	signatureTop := top + 7
+	if act.SignaturePath != "" {
+		if info, ok := registerActImage(pdf, act.SignaturePath); ok {
+			height := 20.0
+			width := height * info.Width() / info.Height()
+			if width > half-10 {
+				width, height = half-10, (half-10)*info.Height()/info.Width()
+			}
+			pdf.ImageOptions(act.SignaturePath, left+half, signatureTop, width, height, false, gofpdf.ImageOptions{}, 0, "")
+		}
+	}
+
+	lineY := signatureTop + 22
+	pdf.SetFont(actFontFamily, "", 10)
+	pdf.Line(left, lineY, left+half-10, lineY)
+	pdf.Line(left+half, lineY, left+contentWidth-10, lineY)
+	pdf.SetXY(left, lineY+1)
+	pdf.CellFormat(half, 5, act.InstallerName, "", 0, "L", false, 0, "")
+	pdf.CellFormat(half, 5, act.SignedBy, "", 1, "L", false, 0, "")
+	if act.SignedAt != nil {
+		pdf.SetX(left + half)
+		pdf.SetFont(actFontFamily, "", 8)
+		pdf.CellFormat(half, 5, "Подписано: "+formatTime(act.SignedAt), "", 1, "L", false, 0, "")
+	}
+	return pdf.Error()
+}
@@ -0,0 +1,108 @@
+package services
+
+import (
+	"bytes"
+	"encoding/base64"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"backend_axenta/models"
+)
+
+func TestRenderInstallationActPDF(t *testing.T) {
+	dir := t.TempDir()
+	photoPath := dir + "/photo.png"
+	require.NoError(t, os.WriteFile(photoPath, testPNG(t, 800, 600), 0644))
+
+	started := time.Date(2024, 3, 1, 6, 0, 0, 0, time.UTC)
+	completed := started.Add(95 * time.Minute)
+	act := &InstallationAct{
+		Number:        InstallationActNumber(42),
+		Date:          completed,
+		Timezone:      models.LoadTimezone("Europe/Samara"),
+		ObjectName:    "Газель А123ВС 64",
+		ObjectIMEI:    "356938035643809",
+		VIN:           "X96330200K2745612",
+		LicensePlate:  "А123ВС64",
+		InstallerName: "Иванов Петр Сергеевич",
+		StartedAt:     &started,
+		CompletedAt:   &completed,
+		Equipment: []models.Equipment{
+			{Brand: "Teltonika", Model: "FMB920", SerialNumber: "FMB-0001", IMEI: "352093081234567"},
+			{Type: "Датчик топлива", SerialNumber: "DUT-17"},
+		},
+		Photos: []ActImage{
+			{Path: photoPath, Caption: "Подключение питания"},
+			{Path: dir + "/missing.jpg", Caption: "Файл удален"},
+		},
+		SignaturePath: photoPath,
+		SignedBy:      "Петров П.П.",
+		SignedAt:      &completed,
+	}
+
+	data, err := RenderInstallationActPDF(act)
+	require.NoError(t, err)
+	assert.True(t, bytes.HasPrefix(data, []byte("%PDF")))
+	assert.Equal(t, "АМ-00042", act.Number)
+	// Первая страница - акт, вторая - фотографии
+	assert.Equal(t, 2, bytes.Count(data, []byte("/Type /Page\n")))
+
+	// Без фотографий и подписи акт помещается на одну страницу
+	act.Photos, act.SignaturePath = nil, ""
+	data, err = RenderInstallationActPDF(act)
+	require.NoError(t, err)
+	assert.Equal(t, 1, bytes.Count(data, []byte("/Type /Page\n")))
+}
+
+func TestInstallationActService_GenerateAct(t *testing.T) {
+	db, fieldService, installation := setupInstallationFieldTest(t)
+	require.NoError(t, db.Model(&models.Object{}).Where("id = ?", installation.ObjectID).
+		Updates(map[string]interface{}{"vin": "X96330200K2745612", "license_plate": "А123ВС64"}).Error)
+
+	actService := NewInstallationActService(db)
+	actService.StorageDir = fieldService.StorageDir
+
+	// Акт формируется только по завершенному монтажу
+	_, err := actService.GenerateAct(installation.ID)
+	require.Error(t, err)
+
+	field, err := fieldService.StartInstallation(installation.ID, nil)
+	require.NoError(t, err)
+	for _, item := range field.Checklist {
+		_, _, err := fieldService.UpdateChecklistItem(installation.ID, item.ID, ChecklistItemUpdate{Done: true})
+		require.NoError(t, err)
+	}
+	_, err = fieldService.UploadPhoto(installation.ID, PhotoUpload{
+		ChecklistItemID: &field.Checklist[0].ID, Caption: "Питание", Data: testPNG(t, 640, 480),
+	})
+	require.NoError(t, err)
+	_, err = fieldService.SaveSignature(installation.ID, SignatureRequest{
+		SignerName: "Петров П.П.", Image: base64.StdEncoding.EncodeToString(testPNG(t, 200, 80)),
+	})
+	require.NoError(t, err)
+	_, err = fieldService.CompleteInstallation(installation.ID, FieldCompleteRequest{Result: "success"})
+	require.NoError(t, err)
+
+	act, _, err := actService.BuildAct(installation.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "X96330200K2745612", act.VIN)
+	assert.Equal(t, "А123ВС64", act.LicensePlate)
+	assert.Equal(t, "Петров П.П.", act.SignedBy)
+	assert.Len(t, act.Photos, 1)
+
+	path, err := actService.GetActFile(installation.ID)
+	require.NoError(t, err)
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.True(t, bytes.HasPrefix(data, []byte("%PDF")))
+
+	var stored models.Installation
+	require.NoError(t, db.First(&stored, installation.ID).Error)
+	assert.Equal(t, InstallationActNumber(installation.ID), stored.ActNumber)
+	assert.Equal(t, path, stored.ActPath)
+	assert.NotNil(t, stored.ActGeneratedAt)
+}
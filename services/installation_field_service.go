@@ -0,0 +1,782 @@
+package services
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/image/draw"
+	"gorm.io/gorm"
+
+	"backend_axenta/models"
+)
+
+// DefaultInstallationFilesDir каталог хранения фотографий и подписей с монтажей
+const DefaultInstallationFilesDir = "uploads/installations"
+
+// Ограничения на загружаемые фотографии
+const (
+	MaxInstallationPhotoSize   = 20 << 20   // 20 МБ
+	MaxInstallationPhotoPixels = 50_000_000 // 50 Мп, распакованное изображение занимает до 200 МБ
+	installationThumbnailSize  = 320        // Длинная сторона миниатюры в пикселях
+)
+
+// ErrChecklistIncomplete возвращается при попытке завершить монтаж с невыполненным чек-листом
+var ErrChecklistIncomplete = errors.New("монтаж нельзя завершить")
+
+// InstallationFieldService сервис полевого приложения монтажника: чек-листы, фотографии,
+// подпись клиента, сканирование оборудования и офлайн-синхронизация
+type InstallationFieldService struct {
+	DB         *gorm.DB
+	StorageDir string
+}
+
+// NewInstallationFieldService создает новый экземпляр InstallationFieldService
+func NewInstallationFieldService(db *gorm.DB) *InstallationFieldService {
+	return &InstallationFieldService{DB: db, StorageDir: DefaultInstallationFilesDir}
+}
+
+// ChecklistTemplateItemRequest пункт шаблона чек-листа
+type ChecklistTemplateItemRequest struct {
+	Title         string `json:"title" binding:"required"`
+	Required      *bool  `json:"required"` // По умолчанию пункт обязательный
+	RequiresPhoto bool   `json:"requires_photo"`
+}
+
+// ChecklistTemplateRequest запрос на создание или изменение шаблона чек-листа
+type ChecklistTemplateRequest struct {
+	Name             string                         `json:"name" binding:"required"`
+	InstallationType string                         `json:"installation_type" binding:"required"`
+	RequireSignature bool                           `json:"require_signature"`
+	IsActive         *bool                          `json:"is_active"`
+	Items            []ChecklistTemplateItemRequest `json:"items"`
+}
+
+// ChecklistItemUpdate отметка пункта чек-листа
+type ChecklistItemUpdate struct {
+	Done      bool       `json:"done"`
+	Value     string     `json:"value"`
+	Comment   string     `json:"comment"`
+	ChangedAt *time.Time `json:"changed_at"` // Время отметки на устройстве, по умолчанию - текущее
+}
+
+// PhotoUpload загружаемая фотография
+type PhotoUpload struct {
+	ClientID        string
+	ChecklistItemID *uint
+	Caption         string
+	TakenAt         *time.Time
+	FileName        string
+	Data            []byte
+}
+
+// SignatureRequest подпись клиента в формате PNG (base64 или data URL)
+type SignatureRequest struct {
+	SignerName string     `json:"signer_name" binding:"required"`
+	Image      string     `json:"image" binding:"required"`
+	SignedAt   *time.Time `json:"signed_at"`
+}
+
+// EquipmentScanRequest отсканированный код оборудования: серийный номер, IMEI или QR-код
+type EquipmentScanRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// FieldCompleteRequest данные о завершении монтажа
+type FieldCompleteRequest struct {
+	Result         string     `json:"result"`
+	Notes          string     `json:"notes"`
+	Issues         string     `json:"issues"`
+	ClientFeedback string     `json:"client_feedback"`
+	QualityRating  *float32   `json:"quality_rating"`
+	ActualDuration int        `json:"actual_duration"`
+	MaterialsCost  float64    `json:"materials_cost"`
+	LaborCost      float64    `json:"labor_cost"`
+	CompletedAt    *time.Time `json:"completed_at"` // Время завершения на устройстве, по умолчанию - текущее
+}
+
+// FieldSyncEvent событие, накопленное полевым приложением без связи
+type FieldSyncEvent struct {
+	EventID        string          `json:"event_id"` // Уникальный идентификатор события на устройстве
+	InstallationID uint            `json:"installation_id"`
+	Type           string          `json:"type"` // start, checklist, signature, equipment, complete
+	OccurredAt     time.Time       `json:"occurred_at"`
+	Payload        json.RawMessage `json:"payload"`
+}
+
+// FieldSyncResult результат обработки события синхронизации
+type FieldSyncResult struct {
+	EventID string `json:"event_id"`
+	Status  string `json:"status"` // applied, duplicate, stale, failed
+	Error   string `json:"error,omitempty"`
+}
+
+// CreateChecklistTemplate создает шаблон чек-листа. Активный шаблон для типа работ может быть только один.
+func (s *InstallationFieldService) CreateChecklistTemplate(req ChecklistTemplateRequest) (*models.InstallationChecklistTemplate, error) {
+	template := models.InstallationChecklistTemplate{IsActive: true}
+	if err := s.DB.Transaction(func(tx *gorm.DB) error {
+		return saveChecklistTemplate(tx, &template, req)
+	}); err != nil {
+		return nil, err
+	}
+	return s.GetChecklistTemplate(template.ID)
+}
+
+// UpdateChecklistTemplate изменяет шаблон чек-листа. Чек-листы уже созданных монтажей не меняются.
+func (s *InstallationFieldService) UpdateChecklistTemplate(id uint, req ChecklistTemplateRequest) (*models.InstallationChecklistTemplate, error) {
+	if err := s.DB.Transaction(func(tx *gorm.DB) error {
+		var template models.InstallationChecklistTemplate
+		if err := tx.First(&template, id).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("template_id = ?", id).Delete(&models.InstallationChecklistTemplateItem{}).Error; err != nil {
+			return fmt.Errorf("ошибка при обновлении пунктов чек-листа: %w", err)
+		}
+		return saveChecklistTemplate(tx, &template, req)
+	}); err != nil {
+		return nil, err
+	}
+	return s.GetChecklistTemplate(id)
+}
+
+// saveChecklistTemplate сохраняет шаблон с пунктами и снимает активность с других шаблонов того же типа
+func saveChecklistTemplate(tx *gorm.DB, template *models.InstallationChecklistTemplate, req ChecklistTemplateRequest) error {
+	if len(req.Items) == 0 {
+		return fmt.Errorf("в чек-листе должен быть хотя бы один пункт")
+	}
+	template.Name = req.Name
+	template.InstallationType = req.InstallationType
+	template.RequireSignature = req.RequireSignature
+	if req.IsActive != nil {
+		template.IsActive = *req.IsActive
+	}
+	if err := tx.Omit("Items").Save(template).Error; err != nil {
+		return fmt.Errorf("ошибка при сохранении шаблона чек-листа: %w", err)
+	}
+
+	for i, line := range req.Items {
+		item := models.InstallationChecklistTemplateItem{
+			TemplateID:    template.ID,
+			Position:      i + 1,
+			Title:         line.Title,
+			Required:      line.Required == nil || *line.Required,
+			RequiresPhoto: line.RequiresPhoto,
+		}
+		if err := tx.Create(&item).Error; err != nil {
+			return fmt.Errorf("ошибка при сохранении пункта чек-листа: %w", err)
+		}
+	}
+
+	if template.IsActive {
+		if err := tx.Model(&models.InstallationChecklistTemplate{}).
+			Where("installation_type = ? AND id != ? AND is_active = ?", template.InstallationType, template.ID, true).
+			Update("is_active", false).Error; err != nil {
+			return fmt.Errorf("ошибка при обновлении шаблонов чек-листа: %w", err)
+		}
+	}
+	return nil
+}
+
+// GetChecklistTemplate возвращает шаблон чек-листа с пунктами
+func (s *InstallationFieldService) GetChecklistTemplate(id uint) (*models.InstallationChecklistTemplate, error) {
+	var template models.InstallationChecklistTemplate
+	if err := s.DB.Preload("Items", func(db *gorm.DB) *gorm.DB { return db.Order("position") }).
+		First(&template, id).Error; err != nil {
+		return nil, err
+	}
+	return &template, nil
+}
+
+// GetChecklistTemplates возвращает шаблоны чек-листов, при необходимости по типу работ
+func (s *InstallationFieldService) GetChecklistTemplates(installationType string) ([]models.InstallationChecklistTemplate, error) {
+	query := s.DB.Preload("Items", func(db *gorm.DB) *gorm.DB { return db.Order("position") })
+	if installationType != "" {
+		query = query.Where("installation_type = ?", installationType)
+	}
+	var templates []models.InstallationChecklistTemplate
+	err := query.Order("installation_type, id").Find(&templates).Error
+	return templates, err
+}
+
+// GetFieldInstallation возвращает монтаж с чек-листом, фотографиями и оборудованием для полевого приложения
+func (s *InstallationFieldService) GetFieldInstallation(id uint) (*models.Installation, error) {
+	var installation models.Installation
+	if err := s.DB.First(&installation, id).Error; err != nil {
+		return nil, err
+	}
+	if err := s.ensureChecklist(s.DB, &installation); err != nil {
+		return nil, err
+	}
+
+	if err := s.DB.Preload("Object").Preload("Equipment").
+		Preload("Checklist", func(db *gorm.DB) *gorm.DB { return db.Order("position") }).
+		Preload("FieldPhotos", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+		First(&installation, id).Error; err != nil {
+		return nil, err
+	}
+	return &installation, nil
+}
+
+// ensureChecklist создает чек-лист монтажа из активного шаблона для его типа работ, если его еще нет
+func (s *InstallationFieldService) ensureChecklist(tx *gorm.DB, installation *models.Installation) error {
+	if installation.Status == "completed" || installation.Status == "cancelled" {
+		return nil
+	}
+	var count int64
+	if err := tx.Model(&models.InstallationChecklistItem{}).Where("installation_id = ?", installation.ID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+
+	var template models.InstallationChecklistTemplate
+	err := tx.Preload("Items", func(db *gorm.DB) *gorm.DB { return db.Order("position") }).
+		Where("installation_type = ? AND is_active = ?", installation.Type, true).
+		First(&template).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("ошибка при получении шаблона чек-листа: %w", err)
+	}
+
+	for _, templateItem := range template.Items {
+		templateItemID := templateItem.ID
+		item := models.InstallationChecklistItem{
+			InstallationID: installation.ID,
+			TemplateItemID: &templateItemID,
+			Position:       templateItem.Position,
+			Title:          templateItem.Title,
+			Required:       templateItem.Required,
+			RequiresPhoto:  templateItem.RequiresPhoto,
+		}
+		if err := tx.Create(&item).Error; err != nil {
+			return fmt.Errorf("ошибка при создании чек-листа: %w", err)
+		}
+	}
+	return nil
+}
+
+// requireSignature проверяет, нужна ли подпись клиента по активному шаблону типа работ
+func (s *InstallationFieldService) requireSignature(installation *models.Installation) bool {
+	var count int64
+	s.DB.Model(&models.InstallationChecklistTemplate{}).
+		Where("installation_type = ? AND is_active = ? AND require_signature = ?", installation.Type, true, true).
+		Count(&count)
+	return count > 0
+}
+
+// StartInstallation начинает работы по монтажу. Повторный старт уже начатого монтажа ничего не меняет.
+func (s *InstallationFieldService) StartInstallation(id uint, startedAt *time.Time) (*models.Installation, error) {
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		var installation models.Installation
+		if err := tx.First(&installation, id).Error; err != nil {
+			return err
+		}
+		if installation.Status == "in_progress" {
+			return nil
+		}
+		if installation.Status != "planned" && installation.Status != "postponed" {
+			return fmt.Errorf("монтаж не может быть начат в текущем статусе")
+		}
+
+		now := time.Now()
+		if startedAt == nil {
+			startedAt = &now
+		}
+		if err := tx.Model(&models.Installation{}).Where("id = ?", id).
+			Updates(map[string]interface{}{"status": "in_progress", "started_at": *startedAt}).Error; err != nil {
+			return fmt.Errorf("ошибка при обновлении статуса монтажа: %w", err)
+		}
+		installation.Status = "in_progress"
+		return s.ensureChecklist(tx, &installation)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetFieldInstallation(id)
+}
+
+// activeInstallation возвращает монтаж, по которому можно вносить полевые данные
+func activeInstallation(tx *gorm.DB, id uint) (*models.Installation, error) {
+	var installation models.Installation
+	if err := tx.First(&installation, id).Error; err != nil {
+		return nil, err
+	}
+	if installation.Status == "completed" || installation.Status == "cancelled" {
+		return nil, fmt.Errorf("монтаж уже завершен или отменен")
+	}
+	return &installation, nil
+}
+
+// UpdateChecklistItem отмечает пункт чек-листа. Если пункт уже изменен позже времени отметки
+// (событие из офлайн-очереди устарело), он не перезаписывается и возвращается applied = false.
+func (s *InstallationFieldService) UpdateChecklistItem(installationID, itemID uint, update ChecklistItemUpdate) (*models.InstallationChecklistItem, bool, error) {
+	if _, err := activeInstallation(s.DB, installationID); err != nil {
+		return nil, false, err
+	}
+
+	var item models.InstallationChecklistItem
+	if err := s.DB.Where("installation_id = ?", installationID).First(&item, itemID).Error; err != nil {
+		return nil, false, err
+	}
+
+	changedAt := time.Now()
+	if update.ChangedAt != nil {
+		changedAt = *update.ChangedAt
+	}
+	if item.ChangedAt != nil && changedAt.Before(*item.ChangedAt) {
+		return &item, false, nil
+	}
+
+	if err := s.DB.Model(&item).Updates(map[string]interface{}{
+		"done":       update.Done,
+		"value":      update.Value,
+		"comment":    update.Comment,
+		"changed_at": changedAt,
+	}).Error; err != nil {
+		return nil, false, fmt.Errorf("ошибка при обновлении пункта чек-листа: %w", err)
+	}
+	return &item, true, nil
+}
+
+// UploadPhoto сохраняет фотографию с монтажа и ее миниатюру. Повторная загрузка
+// с тем же ClientID возвращает ранее сохраненную фотографию.
+func (s *InstallationFieldService) UploadPhoto(installationID uint, upload PhotoUpload) (*models.InstallationPhoto, error) {
+	if _, err := activeInstallation(s.DB, installationID); err != nil {
+		return nil, err
+	}
+
+	if upload.ClientID != "" {
+		var existing models.InstallationPhoto
+		err := s.DB.Where("installation_id = ? AND client_id = ?", installationID, upload.ClientID).First(&existing).Error
+		if err == nil {
+			return &existing, nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+	}
+
+	if upload.ChecklistItemID != nil {
+		var count int64
+		s.DB.Model(&models.InstallationChecklistItem{}).
+			Where("id = ? AND installation_id = ?", *upload.ChecklistItemID, installationID).Count(&count)
+		if count == 0 {
+			return nil, fmt.Errorf("пункт чек-листа не найден")
+		}
+	}
+
+	if len(upload.Data) == 0 {
+		return nil, fmt.Errorf("файл фотографии пуст")
+	}
+	if len(upload.Data) > MaxInstallationPhotoSize {
+		return nil, fmt.Errorf("размер фотографии превышает %d МБ", MaxInstallationPhotoSize>>20)
+	}
+	// Размеры читаются из заголовка до распаковки: маленький файл может описывать огромное изображение
+	config, _, err := image.DecodeConfig(bytes.NewReader(upload.Data))
+	if err != nil {
+		return nil, fmt.Errorf("поддерживаются фотографии в форматах JPEG и PNG")
+	}
+	if config.Width <= 0 || config.Height <= 0 || int64(config.Width)*int64(config.Height) > MaxInstallationPhotoPixels {
+		return nil, fmt.Errorf("разрешение фотографии превышает %d Мп", MaxInstallationPhotoPixels/1_000_000)
+	}
+	img, format, err := image.Decode(bytes.NewReader(upload.Data))
+	if err != nil {
+		return nil, fmt.Errorf("поддерживаются фотографии в форматах JPEG и PNG")
+	}
+
+	dir := s.installationDir(installationID)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("ошибка при сохранении фотографии: %w", err)
+	}
+	name := uuid.NewString()
+	filePath := filepath.Join(dir, name+"."+format)
+	thumbnailPath := filepath.Join(dir, name+"_thumb.jpg")
+	if err := os.WriteFile(filePath, upload.Data, 0644); err != nil {
+		return nil, fmt.Errorf("ошибка при сохранении фотографии: %w", err)
+	}
+	if err := writeThumbnail(img, thumbnailPath); err != nil {
+		os.Remove(filePath)
+		return nil, fmt.Errorf("ошибка при создании миниатюры: %w", err)
+	}
+
+	bounds := img.Bounds()
+	photo := models.InstallationPhoto{
+		InstallationID:  installationID,
+		ChecklistItemID: upload.ChecklistItemID,
+		ClientID:        upload.ClientID,
+		FileName:        upload.FileName,
+		FilePath:        filePath,
+		ThumbnailPath:   thumbnailPath,
+		ContentType:     "image/" + format,
+		Size:            int64(len(upload.Data)),
+		Width:           bounds.Dx(),
+		Height:          bounds.Dy(),
+		Caption:         upload.Caption,
+		TakenAt:         upload.TakenAt,
+	}
+	if err := s.DB.Create(&photo).Error; err != nil {
+		os.Remove(filePath)
+		os.Remove(thumbnailPath)
+		return nil, fmt.Errorf("ошибка при сохранении фотографии: %w", err)
+	}
+	return &photo, nil
+}
+
+// GetPhoto возвращает фотографию монтажа
+func (s *InstallationFieldService) GetPhoto(installationID, photoID uint) (*models.InstallationPhoto, error) {
+	var photo models.InstallationPhoto
+	if err := s.DB.Where("installation_id = ?", installationID).First(&photo, photoID).Error; err != nil {
+		return nil, err
+	}
+	return &photo, nil
+}
+
+// installationDir каталог файлов монтажа
+func (s *InstallationFieldService) installationDir(installationID uint) string {
+	dir := s.StorageDir
+	if dir == "" {
+		dir = DefaultInstallationFilesDir
+	}
+	return filepath.Join(dir, fmt.Sprintf("%d", installationID))
+}
+
+// writeThumbnail сохраняет уменьшенную копию изображения в JPEG
+func writeThumbnail(img image.Image, path string) error {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width > installationThumbnailSize || height > installationThumbnailSize {
+		if width >= height {
+			height = height * installationThumbnailSize / width
+			width = installationThumbnailSize
+		} else {
+			width = width * installationThumbnailSize / height
+			height = installationThumbnailSize
+		}
+	}
+	if width < 1 {
+		width = 1
+	}
+	if height < 1 {
+		height = 1
+	}
+
+	thumbnail := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(thumbnail, thumbnail.Bounds(), img, bounds, draw.Over, nil)
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return jpeg.Encode(file, thumbnail, &jpeg.Options{Quality: 80})
+}
+
+// SaveSignature сохраняет подпись клиента. Повторная подпись заменяет предыдущую.
+func (s *InstallationFieldService) SaveSignature(installationID uint, req SignatureRequest) (*models.Installation, error) {
+	if _, err := activeInstallation(s.DB, installationID); err != nil {
+		return nil, err
+	}
+
+	encoded := strings.TrimSpace(req.Image)
+	if i := strings.Index(encoded, ","); strings.HasPrefix(encoded, "data:") && i > 0 {
+		encoded = encoded[i+1:]
+	}
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("подпись должна быть изображением PNG в base64")
+	}
+	if _, err := png.DecodeConfig(bytes.NewReader(data)); err != nil {
+		return nil, fmt.Errorf("подпись должна быть изображением PNG в base64")
+	}
+
+	dir := s.installationDir(installationID)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("ошибка при сохранении подписи: %w", err)
+	}
+	path := filepath.Join(dir, "signature.png")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return nil, fmt.Errorf("ошибка при сохранении подписи: %w", err)
+	}
+
+	signedAt := time.Now()
+	if req.SignedAt != nil {
+		signedAt = *req.SignedAt
+	}
+	if err := s.DB.Model(&models.Installation{}).Where("id = ?", installationID).Updates(map[string]interface{}{
+		"client_signature_path": path,
+		"client_signed_by":      req.SignerName,
+		"client_signed_at":      signedAt,
+	}).Error; err != nil {
+		return nil, fmt.Errorf("ошибка при сохранении подписи: %w", err)
+	}
+	return s.GetFieldInstallation(installationID)
+}
+
+// ScanEquipment устанавливает отсканированное оборудование на объект монтажа и привязывает его к монтажу.
+// Повторное сканирование уже установленного на этот объект оборудования ничего не меняет.
+func (s *InstallationFieldService) ScanEquipment(installationID uint, code string) (*models.Equipment, error) {
+	installation, err := activeInstallation(s.DB, installationID)
+	if err != nil {
+		return nil, err
+	}
+	if installation.Status != "in_progress" {
+		return nil, fmt.Errorf("оборудование можно установить только после начала работ")
+	}
+
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return nil, fmt.Errorf("не указан код оборудования")
+	}
+	var equipment models.Equipment
+	if err := s.DB.Where("serial_number = ? OR imei = ? OR qr_code = ?", code, code, code).
+		First(&equipment).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("оборудование с кодом %s не найдено: %w", code, err)
+		}
+		return nil, err
+	}
+
+	alreadyInstalled := equipment.Status == models.EquipmentStatusInstalled &&
+		equipment.ObjectID != nil && *equipment.ObjectID == installation.ObjectID
+	if !alreadyInstalled {
+		warehouseService := NewWarehouseService(s.DB, nil)
+		if err := warehouseService.ProcessEquipmentInstallation(equipment.ID, installation.ObjectID, installation.InstallerID); err != nil {
+			return nil, err
+		}
+	}
+
+	var linked int64
+	s.DB.Table("installation_equipment").
+		Where("installation_id = ? AND equipment_id = ?", installationID, equipment.ID).Count(&linked)
+	if linked == 0 {
+		if err := s.DB.Table("installation_equipment").Create(map[string]interface{}{
+			"installation_id": installationID,
+			"equipment_id":    equipment.ID,
+		}).Error; err != nil {
+			return nil, fmt.Errorf("ошибка при привязке оборудования к монтажу: %w", err)
+		}
+	}
+
+	if err := s.DB.First(&equipment, equipment.ID).Error; err != nil {
+		return nil, err
+	}
+	return &equipment, nil
+}
+
+// ValidateCompletion проверяет, что по монтажу выполнены обязательные пункты чек-листа,
+// к пунктам приложены нужные фотографии и получена подпись клиента, если она требуется
+func (s *InstallationFieldService) ValidateCompletion(installation *models.Installation) error {
+	var items []models.InstallationChecklistItem
+	if err := s.DB.Preload("Photos").Where("installation_id = ?", installation.ID).
+		Order("position").Find(&items).Error; err != nil {
+		return err
+	}
+
+	var problems []string
+	var notDone, noPhoto []string
+	for _, item := range items {
+		if item.Required && !item.Done {
+			notDone = append(notDone, item.Title)
+		}
+		if item.RequiresPhoto && len(item.Photos) == 0 {
+			noPhoto = append(noPhoto, item.Title)
+		}
+	}
+	if len(notDone) > 0 {
+		problems = append(problems, "не выполнены пункты чек-листа: "+strings.Join(notDone, ", "))
+	}
+	if len(noPhoto) > 0 {
+		problems = append(problems, "нет фотографий к пунктам: "+strings.Join(noPhoto, ", "))
+	}
+	if installation.ClientSignedAt == nil && s.requireSignature(installation) {
+		problems = append(problems, "нет подписи клиента")
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: %s", ErrChecklistIncomplete, strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// CompleteInstallation завершает монтаж после проверки чек-листа и подписи
+func (s *InstallationFieldService) CompleteInstallation(id uint, req FieldCompleteRequest) (*models.Installation, error) {
+	var installation models.Installation
+	if err := s.DB.First(&installation, id).Error; err != nil {
+		return nil, err
+	}
+	if installation.Status != "in_progress" {
+		return nil, fmt.Errorf("монтаж не может быть завершен в текущем статусе")
+	}
+	if err := s.ValidateCompletion(&installation); err != nil {
+		return nil, err
+	}
+
+	completedAt := time.Now()
+	if req.CompletedAt != nil {
+		completedAt = *req.CompletedAt
+	}
+	actualDuration := req.ActualDuration
+	if actualDuration == 0 && installation.StartedAt != nil {
+		actualDuration = int(completedAt.Sub(*installation.StartedAt).Minutes())
+	}
+
+	updates := map[string]interface{}{
+		"status":          "completed",
+		"completed_at":    completedAt,
+		"result":          req.Result,
+		"notes":           req.Notes,
+		"issues":          req.Issues,
+		"client_feedback": req.ClientFeedback,
+		"actual_duration": actualDuration,
+		"materials_cost":  req.MaterialsCost,
+		"labor_cost":      req.LaborCost,
+	}
+	if req.QualityRating != nil {
+		updates["quality_rating"] = *req.QualityRating
+	}
+	if err := s.DB.Model(&models.Installation{}).Where("id = ? AND status = ?", id, "in_progress").
+		Updates(updates).Error; err != nil {
+		return nil, fmt.Errorf("ошибка при завершении монтажа: %w", err)
+	}
+	return s.GetFieldInstallation(id)
+}
+
+// SyncFieldEvents применяет события, накопленные полевым приложением без связи.
+// События применяются в порядке времени на устройстве; событие с уже обработанным
+// EventID повторно не применяется. Ошибка одного события не мешает остальным.
+func (s *InstallationFieldService) SyncFieldEvents(events []FieldSyncEvent) []FieldSyncResult {
+	ordered := make([]int, len(events))
+	for i := range ordered {
+		ordered[i] = i
+	}
+	sort.SliceStable(ordered, func(a, b int) bool {
+		return events[ordered[a]].OccurredAt.Before(events[ordered[b]].OccurredAt)
+	})
+
+	results := make([]FieldSyncResult, len(events))
+	for _, i := range ordered {
+		results[i] = s.syncFieldEvent(events[i])
+	}
+	return results
+}
+
+// syncFieldEvent применяет одно событие синхронизации вместе с записью в журнал событий
+func (s *InstallationFieldService) syncFieldEvent(event FieldSyncEvent) FieldSyncResult {
+	result := FieldSyncResult{EventID: event.EventID}
+	if event.EventID == "" || event.InstallationID == 0 {
+		result.Status, result.Error = "failed", "не указан event_id или installation_id"
+		return result
+	}
+
+	var count int64
+	s.DB.Model(&models.InstallationSyncEvent{}).Where("event_id = ?", event.EventID).Count(&count)
+	if count > 0 {
+		result.Status = "duplicate"
+		return result
+	}
+
+	if event.OccurredAt.IsZero() {
+		event.OccurredAt = time.Now()
+	}
+	status := "applied"
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		fs := &InstallationFieldService{DB: tx, StorageDir: s.StorageDir}
+		applied, err := fs.applyFieldEvent(event)
+		if err != nil {
+			return err
+		}
+		if !applied {
+			status = "stale"
+		}
+		return tx.Create(&models.InstallationSyncEvent{
+			EventID:        event.EventID,
+			InstallationID: event.InstallationID,
+			Type:           event.Type,
+			OccurredAt:     event.OccurredAt,
+			Status:         status,
+		}).Error
+	})
+	if err != nil {
+		result.Status, result.Error = "failed", err.Error()
+		return result
+	}
+	result.Status = status
+	return result
+}
+
+// applyFieldEvent применяет событие; возвращает false, если событие устарело
+func (s *InstallationFieldService) applyFieldEvent(event FieldSyncEvent) (bool, error) {
+	occurredAt := event.OccurredAt
+	decode := func(target interface{}) error {
+		if len(event.Payload) == 0 {
+			return nil
+		}
+		if err := json.Unmarshal(event.Payload, target); err != nil {
+			return fmt.Errorf("некорректные данные события: %w", err)
+		}
+		return nil
+	}
+
+	switch event.Type {
+	case models.FieldEventStart:
+		_, err := s.StartInstallation(event.InstallationID, &occurredAt)
+		return true, err
+
+	case models.FieldEventChecklist:
+		var payload struct {
+			ItemID uint `json:"item_id"`
+			ChecklistItemUpdate
+		}
+		if err := decode(&payload); err != nil {
+			return false, err
+		}
+		payload.ChangedAt = &occurredAt
+		_, applied, err := s.UpdateChecklistItem(event.InstallationID, payload.ItemID, payload.ChecklistItemUpdate)
+		return applied, err
+
+	case models.FieldEventSignature:
+		var payload SignatureRequest
+		if err := decode(&payload); err != nil {
+			return false, err
+		}
+		if payload.SignedAt == nil {
+			payload.SignedAt = &occurredAt
+		}
+		_, err := s.SaveSignature(event.InstallationID, payload)
+		return true, err
+
+	case models.FieldEventEquipment:
+		var payload EquipmentScanRequest
+		if err := decode(&payload); err != nil {
+			return false, err
+		}
+		_, err := s.ScanEquipment(event.InstallationID, payload.Code)
+		return true, err
+
+	case models.FieldEventComplete:
+		var payload FieldCompleteRequest
+		if err := decode(&payload); err != nil {
+			return false, err
+		}
+		if payload.CompletedAt == nil {
+			payload.CompletedAt = &occurredAt
+		}
+		_, err := s.CompleteInstallation(event.InstallationID, payload)
+		return true, err
+	}
+	return false, fmt.Errorf("неизвестный тип события: %s", event.Type)
+}
@@ -0,0 +1,253 @@
+package services
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"backend_axenta/models"
+)
+
+func setupInstallationFieldTest(t *testing.T) (*gorm.DB, *InstallationFieldService, *models.Installation) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(
+		&models.Object{},
+		&models.Equipment{},
+		&models.EquipmentCategory{},
+		&models.WarehouseOperation{},
+		&models.StockAlert{},
+		&models.Installation{},
+		&models.InstallationChecklistTemplate{},
+		&models.InstallationChecklistTemplateItem{},
+		&models.InstallationChecklistItem{},
+		&models.InstallationPhoto{},
+		&models.InstallationSyncEvent{},
+	))
+
+	object := models.Object{Name: "Газель А123ВС", IMEI: "356938035643809"}
+	require.NoError(t, db.Create(&object).Error)
+
+	service := NewInstallationFieldService(db)
+	service.StorageDir = t.TempDir()
+	_, err = service.CreateChecklistTemplate(ChecklistTemplateRequest{
+		Name:             "Монтаж трекера",
+		InstallationType: "монтаж",
+		RequireSignature: true,
+		Items: []ChecklistTemplateItemRequest{
+			{Title: "Подключено питание", RequiresPhoto: true},
+			{Title: "Трекер на связи"},
+			{Title: "Проверка датчика топлива", Required: func() *bool { b := false; return &b }()},
+		},
+	})
+	require.NoError(t, err)
+
+	installation := models.Installation{
+		Type: "монтаж", Status: "planned", ScheduledAt: time.Now(), ObjectID: object.ID, InstallerID: 1,
+	}
+	require.NoError(t, db.Create(&installation).Error)
+	return db, service, &installation
+}
+
+// testPNG возвращает PNG-изображение указанного размера
+func testPNG(t *testing.T, width, height int) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for x := 0; x < width; x++ {
+		img.Set(x, height/2, color.Black)
+	}
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+func TestInstallationFieldService_CompleteRequiresChecklist(t *testing.T) {
+	db, service, installation := setupInstallationFieldTest(t)
+
+	field, err := service.StartInstallation(installation.ID, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "in_progress", field.Status)
+	require.Len(t, field.Checklist, 3)
+	power, online := field.Checklist[0], field.Checklist[1]
+
+	// Пустой чек-лист и нет подписи - завершить нельзя
+	_, err = service.CompleteInstallation(installation.ID, FieldCompleteRequest{Result: "success"})
+	require.ErrorIs(t, err, ErrChecklistIncomplete)
+	assert.Contains(t, err.Error(), "Подключено питание, Трекер на связи")
+	assert.Contains(t, err.Error(), "нет подписи клиента")
+
+	_, _, err = service.UpdateChecklistItem(installation.ID, power.ID, ChecklistItemUpdate{Done: true})
+	require.NoError(t, err)
+	_, _, err = service.UpdateChecklistItem(installation.ID, online.ID, ChecklistItemUpdate{Done: true})
+	require.NoError(t, err)
+
+	// Пункт требует фотографию
+	_, err = service.CompleteInstallation(installation.ID, FieldCompleteRequest{})
+	require.ErrorIs(t, err, ErrChecklistIncomplete)
+	assert.Contains(t, err.Error(), "нет фотографий к пунктам: Подключено питание")
+
+	photo, err := service.UploadPhoto(installation.ID, PhotoUpload{
+		ClientID: "photo-1", ChecklistItemID: &power.ID, FileName: "power.png", Data: testPNG(t, 1280, 640),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1280, photo.Width)
+	assert.Equal(t, "image/png", photo.ContentType)
+
+	thumbnailFile, err := os.Open(photo.ThumbnailPath)
+	require.NoError(t, err)
+	defer thumbnailFile.Close()
+	thumbnail, format, err := image.DecodeConfig(thumbnailFile)
+	require.NoError(t, err)
+	assert.Equal(t, "jpeg", format)
+	assert.Equal(t, 320, thumbnail.Width)
+	assert.Equal(t, 160, thumbnail.Height)
+
+	// Повторная загрузка с тем же client_id не создает дубликат
+	again, err := service.UploadPhoto(installation.ID, PhotoUpload{ClientID: "photo-1", Data: testPNG(t, 10, 10)})
+	require.NoError(t, err)
+	assert.Equal(t, photo.ID, again.ID)
+
+	_, err = service.UploadPhoto(installation.ID, PhotoUpload{Data: []byte("not an image")})
+	assert.Error(t, err)
+
+	// Небольшой файл с заголовком 20000x20000 отклоняется до распаковки
+	bomb := testPNG(t, 10, 10)
+	binary.BigEndian.PutUint32(bomb[16:], 20000)
+	binary.BigEndian.PutUint32(bomb[20:], 20000)
+	binary.BigEndian.PutUint32(bomb[29:], crc32.ChecksumIEEE(bomb[12:29]))
+	_, err = service.UploadPhoto(installation.ID, PhotoUpload{Data: bomb})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "разрешение фотографии превышает 50 Мп")
+
+	// Подпись клиента
+	_, err = service.SaveSignature(installation.ID, SignatureRequest{SignerName: "Петров П.П.", Image: "data:image/png;base64,invalid"})
+	assert.Error(t, err)
+	signed, err := service.SaveSignature(installation.ID, SignatureRequest{
+		SignerName: "Петров П.П.",
+		Image:      "data:image/png;base64," + base64.StdEncoding.EncodeToString(testPNG(t, 200, 80)),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Петров П.П.", signed.ClientSignedBy)
+	assert.FileExists(t, signed.ClientSignaturePath)
+
+	completed, err := service.CompleteInstallation(installation.ID, FieldCompleteRequest{Result: "success", ActualDuration: 95})
+	require.NoError(t, err)
+	assert.Equal(t, "completed", completed.Status)
+	assert.Equal(t, 95, completed.ActualDuration)
+
+	var photos int64
+	db.Model(&models.InstallationPhoto{}).Count(&photos)
+	assert.Equal(t, int64(1), photos)
+}
+
+func TestInstallationFieldService_ScanEquipment(t *testing.T) {
+	db, service, installation := setupInstallationFieldTest(t)
+
+	equipment := models.Equipment{
+		Type: "GPS-tracker", Model: "FMB920", Brand: "Teltonika", SerialNumber: "FMB-0001",
+		IMEI: "352093081234567", Status: models.EquipmentStatusInStock, Condition: "new",
+	}
+	require.NoError(t, db.Create(&equipment).Error)
+
+	// До начала работ оборудование не устанавливается
+	_, err := service.ScanEquipment(installation.ID, equipment.IMEI)
+	require.Error(t, err)
+
+	_, err = service.StartInstallation(installation.ID, nil)
+	require.NoError(t, err)
+
+	installed, err := service.ScanEquipment(installation.ID, equipment.IMEI)
+	require.NoError(t, err)
+	assert.Equal(t, models.EquipmentStatusInstalled, installed.Status)
+	require.NotNil(t, installed.ObjectID)
+	assert.Equal(t, installation.ObjectID, *installed.ObjectID)
+
+	// Повторное сканирование по серийному номеру ничего не меняет
+	_, err = service.ScanEquipment(installation.ID, "FMB-0001")
+	require.NoError(t, err)
+
+	var operations, links int64
+	db.Model(&models.WarehouseOperation{}).Where("equipment_id = ? AND type = ?", equipment.ID, "issue").Count(&operations)
+	db.Table("installation_equipment").Where("installation_id = ?", installation.ID).Count(&links)
+	assert.Equal(t, int64(1), operations)
+	assert.Equal(t, int64(1), links)
+
+	_, err = service.ScanEquipment(installation.ID, "UNKNOWN")
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
+
+func TestInstallationFieldService_SyncFieldEvents(t *testing.T) {
+	db, service, installation := setupInstallationFieldTest(t)
+
+	field, err := service.GetFieldInstallation(installation.ID)
+	require.NoError(t, err)
+	require.Len(t, field.Checklist, 3)
+	itemID := field.Checklist[1].ID
+
+	base := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	payload := func(value interface{}) json.RawMessage {
+		data, err := json.Marshal(value)
+		require.NoError(t, err)
+		return data
+	}
+
+	// События пришли не по порядку: отметка «снято» сделана раньше, чем «выполнено»
+	events := []FieldSyncEvent{
+		{EventID: "ev-2", InstallationID: installation.ID, Type: models.FieldEventChecklist, OccurredAt: base.Add(20 * time.Minute),
+			Payload: payload(map[string]interface{}{"item_id": itemID, "done": true, "value": "online"})},
+		{EventID: "ev-1", InstallationID: installation.ID, Type: models.FieldEventStart, OccurredAt: base},
+		{EventID: "ev-3", InstallationID: installation.ID, Type: "unknown", OccurredAt: base.Add(30 * time.Minute)},
+	}
+	results := service.SyncFieldEvents(events)
+	require.Len(t, results, 3)
+	assert.Equal(t, "applied", results[0].Status)
+	assert.Equal(t, "applied", results[1].Status)
+	assert.Equal(t, "failed", results[2].Status)
+
+	var stored models.Installation
+	require.NoError(t, db.First(&stored, installation.ID).Error)
+	assert.Equal(t, "in_progress", stored.Status)
+	require.NotNil(t, stored.StartedAt)
+	assert.True(t, base.Equal(*stored.StartedAt))
+
+	// Более ранняя отметка из другой офлайн-пачки не перезаписывает пункт
+	results = service.SyncFieldEvents([]FieldSyncEvent{
+		{EventID: "ev-2", InstallationID: installation.ID, Type: models.FieldEventChecklist, OccurredAt: base.Add(20 * time.Minute)},
+		{EventID: "ev-4", InstallationID: installation.ID, Type: models.FieldEventChecklist, OccurredAt: base.Add(10 * time.Minute),
+			Payload: payload(map[string]interface{}{"item_id": itemID, "done": false})},
+	})
+	assert.Equal(t, "duplicate", results[0].Status)
+	assert.Equal(t, "stale", results[1].Status)
+
+	var item models.InstallationChecklistItem
+	require.NoError(t, db.First(&item, itemID).Error)
+	assert.True(t, item.Done)
+	assert.Equal(t, "online", item.Value)
+
+	// Неудачные события не попадают в журнал и могут быть отправлены повторно
+	var logged int64
+	db.Model(&models.InstallationSyncEvent{}).Count(&logged)
+	assert.Equal(t, int64(3), logged)
+
+	// Завершение без выполненного чек-листа отклоняется
+	results = service.SyncFieldEvents([]FieldSyncEvent{
+		{EventID: "ev-5", InstallationID: installation.ID, Type: models.FieldEventComplete, OccurredAt: base.Add(time.Hour)},
+	})
+	assert.Equal(t, "failed", results[0].Status)
+	assert.Contains(t, results[0].Error, "не выполнены пункты чек-листа")
+}
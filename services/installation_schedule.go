@@ -0,0 +1,223 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"backend_axenta/models"
+)
+
+// Ошибки проверки слота монтажника
+var (
+	ErrInstallerUnavailable = errors.New("монтажник недоступен на указанную дату")
+	ErrOutsideWorkingHours  = errors.New("время монтажа выходит за рабочие часы монтажника")
+	ErrScheduleConflict     = errors.New("у монтажника уже есть работы в это время")
+	ErrDailyLimitExceeded   = errors.New("превышено максимальное количество монтажей в день")
+)
+
+// scheduleBuffer запас времени между работами монтажника
+const scheduleBuffer = 30 * time.Minute
+
+// FreeSlot свободное время монтажника, в которое можно начать работу
+type FreeSlot struct {
+	Start    time.Time `json:"start"`
+	End      time.Time `json:"end"`
+	Date     string    `json:"date"`       // Дата в часовом поясе локации, YYYY-MM-DD
+	Local    string    `json:"local_time"` // Время начала в часовом поясе локации, HH:MM
+	Timezone string    `json:"timezone"`
+}
+
+// scheduleTimezone определяет часовой пояс, в котором проверяется расписание монтажа:
+// локация монтажа, локация объекта, первая локация монтажника, часовой пояс компании
+func (s *InstallationService) scheduleTimezone(locationID *uint, objectID uint, installer *models.Installer) *time.Location {
+	var candidates []uint
+	if locationID != nil && *locationID != 0 {
+		candidates = append(candidates, *locationID)
+	}
+	if objectID != 0 {
+		var object models.Object
+		if err := s.DB.Select("id", "location_id").First(&object, objectID).Error; err == nil && object.LocationID != 0 {
+			candidates = append(candidates, object.LocationID)
+		}
+	}
+	if installer != nil && len(installer.LocationIDs) > 0 {
+		candidates = append(candidates, installer.LocationIDs[0])
+	}
+
+	for _, id := range candidates {
+		var location models.Location
+		if err := s.DB.Select("id", "timezone").First(&location, id).Error; err == nil && location.Timezone != "" {
+			return location.TimeLocation()
+		}
+	}
+	return models.LoadTimezone(s.Timezone)
+}
+
+// ValidateInstallationSlot проверяет, что монтажник может выполнить монтаж в запланированное время:
+// рабочий день, рабочие часы и дневной лимит в часовом поясе локации монтажа, отсутствие конфликтов
+func (s *InstallationService) ValidateInstallationSlot(installation *models.Installation) error {
+	var installer models.Installer
+	if err := s.DB.First(&installer, installation.InstallerID).Error; err != nil {
+		return errors.New("монтажник не найден")
+	}
+
+	tz := s.scheduleTimezone(installation.LocationID, installation.ObjectID, &installer)
+	return s.checkInstallerSlot(&installer, installation.ScheduledAt, installation.EstimatedDuration, tz, installation.ID)
+}
+
+// checkInstallerSlot проверяет рабочий день, конфликты, дневной лимит и рабочие часы
+// монтажника для работы, начинающейся в start, в часовом поясе tz
+func (s *InstallationService) checkInstallerSlot(installer *models.Installer, start time.Time, duration int, tz *time.Location, excludeInstallationID uint) error {
+	local := start.In(tz)
+	if !installer.IsAvailableOnDate(local) {
+		return ErrInstallerUnavailable
+	}
+
+	conflicts, err := s.CheckScheduleConflicts(installer.ID, start, duration, excludeInstallationID)
+	if err != nil {
+		return fmt.Errorf("ошибка при проверке расписания: %v", err)
+	}
+	if len(conflicts) > 0 {
+		return ErrScheduleConflict
+	}
+
+	dailyCount, err := s.countDailyInstallations(installer.ID, local, excludeInstallationID)
+	if err != nil {
+		return fmt.Errorf("ошибка при проверке расписания: %v", err)
+	}
+	if int(dailyCount) >= installer.MaxDailyInstallations {
+		return fmt.Errorf("%w (%d)", ErrDailyLimitExceeded, installer.MaxDailyInstallations)
+	}
+
+	if !installer.IsWithinWorkingHours(local, duration) {
+		return fmt.Errorf("%w: %s-%s (%s)", ErrOutsideWorkingHours, installer.WorkingHoursStart, installer.WorkingHoursEnd, tz.String())
+	}
+	return nil
+}
+
+// localDayBounds возвращает границы суток, в которые попадает local, в его часовом поясе
+func localDayBounds(local time.Time) (time.Time, time.Time) {
+	dayStart := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, local.Location())
+	return dayStart, dayStart.AddDate(0, 0, 1)
+}
+
+// countDailyInstallations считает активные монтажи монтажника в местные сутки local
+func (s *InstallationService) countDailyInstallations(installerID uint, local time.Time, excludeInstallationID uint) (int64, error) {
+	dayStart, dayEnd := localDayBounds(local)
+
+	query := s.DB.Model(&models.Installation{}).
+		Where("installer_id = ? AND scheduled_at >= ? AND scheduled_at < ? AND status IN ('planned', 'in_progress')",
+			installerID, dayStart, dayEnd)
+	if excludeInstallationID > 0 {
+		query = query.Where("id != ?", excludeInstallationID)
+	}
+
+	var count int64
+	err := query.Count(&count).Error
+	return count, err
+}
+
+// SuggestFreeSlots подбирает свободные слоты монтажника для работы длительностью duration минут
+// на days дней начиная с from. Время проверяется в часовом поясе локации монтажа
+// (locationID), а если она не указана - первой локации монтажника.
+func (s *InstallationService) SuggestFreeSlots(installerID uint, from time.Time, days int, duration int, locationID *uint, limit int) ([]FreeSlot, error) {
+	var installer models.Installer
+	if err := s.DB.First(&installer, installerID).Error; err != nil {
+		return nil, errors.New("монтажник не найден")
+	}
+	if duration <= 0 {
+		duration = 120
+	}
+	if days <= 0 {
+		days = 7
+	}
+	if limit <= 0 {
+		limit = 20
+	}
+
+	tz := s.scheduleTimezone(locationID, 0, &installer)
+	firstDay, _ := localDayBounds(from.In(tz))
+	lastDay := firstDay.AddDate(0, 0, days)
+
+	var busy []models.Installation
+	if err := s.DB.Where("installer_id = ? AND status IN ('planned', 'in_progress') AND scheduled_at >= ? AND scheduled_at < ?",
+		installerID, firstDay.Add(-24*time.Hour), lastDay).
+		Order("scheduled_at").Find(&busy).Error; err != nil {
+		return nil, fmt.Errorf("ошибка при получении расписания: %v", err)
+	}
+
+	return computeFreeSlots(&installer, busy, from.In(tz), days, duration, limit), nil
+}
+
+// computeFreeSlots раскладывает свободное рабочее время монтажника на слоты длительностью
+// duration с запасом между работами. from задает часовой пояс и момент, раньше которого слоты не предлагаются.
+func computeFreeSlots(installer *models.Installer, busy []models.Installation, from time.Time, days int, duration int, limit int) []FreeSlot {
+	tz := from.Location()
+	length := time.Duration(duration) * time.Minute
+	firstDay, _ := localDayBounds(from)
+
+	type interval struct{ start, end time.Time }
+	occupied := make([]interval, 0, len(busy))
+	for _, installation := range busy {
+		end := installation.ScheduledAt.Add(time.Duration(installation.EstimatedDuration) * time.Minute)
+		occupied = append(occupied, interval{installation.ScheduledAt.Add(-scheduleBuffer), end.Add(scheduleBuffer)})
+	}
+	sort.Slice(occupied, func(i, j int) bool { return occupied[i].start.Before(occupied[j].start) })
+
+	slots := []FreeSlot{}
+	for d := 0; d < days && len(slots) < limit; d++ {
+		day := firstDay.AddDate(0, 0, d)
+		if !installer.IsAvailableOnDate(day) {
+			continue
+		}
+
+		dayStart, dayEnd := localDayBounds(day)
+		dailyCount := 0
+		for _, installation := range busy {
+			if !installation.ScheduledAt.Before(dayStart) && installation.ScheduledAt.Before(dayEnd) {
+				dailyCount++
+			}
+		}
+
+		workStart, workEnd := installer.WorkingHoursOn(day)
+		cursor := workStart
+		if cursor.Before(from) {
+			// Слоты начинаются с ближайшей четверти часа
+			cursor = from.Truncate(15 * time.Minute)
+			if cursor.Before(from) {
+				cursor = cursor.Add(15 * time.Minute)
+			}
+		}
+
+		for dailyCount < installer.MaxDailyInstallations && len(slots) < limit {
+			end := cursor.Add(length)
+			if end.After(workEnd) {
+				break
+			}
+			blocked := false
+			for _, o := range occupied {
+				if o.start.Before(end) && o.end.After(cursor) {
+					cursor = o.end.In(tz)
+					blocked = true
+					break
+				}
+			}
+			if blocked {
+				continue
+			}
+
+			slots = append(slots, FreeSlot{
+				Start:    cursor,
+				End:      end,
+				Date:     cursor.Format("2006-01-02"),
+				Local:    cursor.Format("15:04"),
+				Timezone: tz.String(),
+			})
+			dailyCount++
+			cursor = end.Add(scheduleBuffer)
+		}
+	}
+	return slots
+}
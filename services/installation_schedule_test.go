@@ -0,0 +1,54 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"backend_axenta/models"
+)
+
+func TestComputeFreeSlots(t *testing.T) {
+	novosibirsk := models.LoadTimezone("Asia/Novosibirsk")
+	installer := &models.Installer{
+		IsActive:              true,
+		Status:                "available",
+		WorkingDays:           []int{1, 2, 3, 4, 5},
+		WorkingHoursStart:     "09:00",
+		WorkingHoursEnd:       "18:00",
+		MaxDailyInstallations: 3,
+	}
+
+	// Пятница, 08:00 по Новосибирску; монтаж с 11:00 до 13:00 местного времени
+	from := time.Date(2024, 2, 16, 8, 0, 0, 0, novosibirsk)
+	busy := []models.Installation{{
+		ScheduledAt:       time.Date(2024, 2, 16, 4, 0, 0, 0, time.UTC),
+		EstimatedDuration: 120,
+	}}
+
+	slots := computeFreeSlots(installer, busy, from, 4, 90, 10)
+	require.Len(t, slots, 5)
+
+	// В пятницу: 09:00, затем после монтажа с запасом 30 минут; дневной лимит - 3 работы
+	assert.Equal(t, "2024-02-16", slots[0].Date)
+	assert.Equal(t, "09:00", slots[0].Local)
+	assert.Equal(t, "Asia/Novosibirsk", slots[0].Timezone)
+	assert.Equal(t, "13:30", slots[1].Local)
+	assert.Equal(t, time.Date(2024, 2, 16, 6, 30, 0, 0, time.UTC), slots[1].Start.UTC())
+
+	// Суббота и воскресенье пропускаются, понедельник начинается с начала рабочего дня
+	assert.Equal(t, "2024-02-19", slots[2].Date)
+	assert.Equal(t, "09:00", slots[2].Local)
+	assert.Equal(t, "11:00", slots[3].Local)
+	assert.Equal(t, "13:00", slots[4].Local)
+
+	// Слоты в прошлом не предлагаются
+	slots = computeFreeSlots(installer, nil, time.Date(2024, 2, 16, 16, 10, 0, 0, novosibirsk), 1, 90, 10)
+	require.Len(t, slots, 1)
+	assert.Equal(t, "16:15", slots[0].Local)
+
+	// Работа длиннее рабочего дня не помещается никуда
+	assert.Empty(t, computeFreeSlots(installer, nil, from, 7, 10*60, 10))
+}
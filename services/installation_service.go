@@ -14,6 +14,7 @@ import (
 type InstallationService struct {
 	DB                  *gorm.DB
 	NotificationService *NotificationService
+	Timezone            string // Часовой пояс компании, если у монтажа, объекта и монтажника нет локации
 }
 
 // NewInstallationService создает новый экземпляр InstallationService
@@ -24,40 +25,12 @@ func NewInstallationService(db *gorm.DB, notificationService *NotificationServic
 	}
 }
 
-// ScheduleInstallation планирует новый монтаж с проверкой доступности
+// ScheduleInstallation планирует новый монтаж с проверкой доступности.
+// Рабочий день, рабочие часы и дневной лимит проверяются в часовом поясе локации монтажа.
 func (s *InstallationService) ScheduleInstallation(installation *models.Installation) error {
 	// Проверяем доступность монтажника
-	var installer models.Installer
-	if err := s.DB.First(&installer, installation.InstallerID).Error; err != nil {
-		return errors.New("монтажник не найден")
-	}
-
-	if !installer.IsAvailableOnDate(installation.ScheduledAt) {
-		return errors.New("монтажник недоступен на указанную дату")
-	}
-
-	// Проверяем конфликты в расписании
-	conflicts, err := s.CheckScheduleConflicts(installation.InstallerID, installation.ScheduledAt, installation.EstimatedDuration, 0)
-	if err != nil {
-		return fmt.Errorf("ошибка при проверке расписания: %v", err)
-	}
-
-	if len(conflicts) > 0 {
-		return errors.New("у монтажника уже есть работы в это время")
-	}
-
-	// Проверяем максимальное количество монтажей в день
-	dayStart := time.Date(installation.ScheduledAt.Year(), installation.ScheduledAt.Month(), installation.ScheduledAt.Day(), 0, 0, 0, 0, installation.ScheduledAt.Location())
-	dayEnd := dayStart.Add(24 * time.Hour)
-
-	var dailyCount int64
-	s.DB.Model(&models.Installation{}).
-		Where("installer_id = ? AND scheduled_at BETWEEN ? AND ? AND status IN ('planned', 'in_progress')",
-			installation.InstallerID, dayStart, dayEnd).
-		Count(&dailyCount)
-
-	if int(dailyCount) >= installer.MaxDailyInstallations {
-		return fmt.Errorf("превышено максимальное количество монтажей в день (%d)", installer.MaxDailyInstallations)
+	if err := s.ValidateInstallationSlot(installation); err != nil {
+		return err
 	}
 
 	// Создаем монтаж
@@ -79,22 +52,32 @@ func (s *InstallationService) CheckScheduleConflicts(installerID uint, scheduled
 	endTime := startTime.Add(time.Duration(duration) * time.Minute)
 
 	// Добавляем буферное время (30 минут до и после)
-	bufferStart := startTime.Add(-30 * time.Minute)
-	bufferEnd := endTime.Add(30 * time.Minute)
+	bufferStart := startTime.Add(-scheduleBuffer)
+	bufferEnd := endTime.Add(scheduleBuffer)
 
-	var conflicts []models.Installation
+	var candidates []models.Installation
 	query := s.DB.Where("installer_id = ? AND status IN ('planned', 'in_progress')", installerID)
 
 	if excludeInstallationID > 0 {
 		query = query.Where("id != ?", excludeInstallationID)
 	}
 
-	// Проверяем пересечения по времени
-	query = query.Where("(scheduled_at BETWEEN ? AND ?) OR (scheduled_at + INTERVAL '1 minute' * estimated_duration BETWEEN ? AND ?)",
-		bufferStart, bufferEnd, bufferStart, bufferEnd)
+	// Работы, начавшиеся до интервала, могут продолжаться в нем - берем с запасом в сутки
+	query = query.Where("scheduled_at > ? AND scheduled_at < ?", bufferStart.Add(-24*time.Hour), bufferEnd)
+
+	if err := query.Preload("Object").Find(&candidates).Error; err != nil {
+		return nil, err
+	}
 
-	err := query.Preload("Object").Find(&conflicts).Error
-	return conflicts, err
+	// Проверяем пересечения по времени
+	conflicts := []models.Installation{}
+	for _, installation := range candidates {
+		end := installation.ScheduledAt.Add(time.Duration(installation.EstimatedDuration) * time.Minute)
+		if installation.ScheduledAt.Before(bufferEnd) && end.After(bufferStart) {
+			conflicts = append(conflicts, installation)
+		}
+	}
+	return conflicts, nil
 }
 
 // RescheduleInstallation переносит монтаж на другое время
@@ -120,18 +103,10 @@ func (s *InstallationService) RescheduleInstallation(installationID uint, newSch
 		return errors.New("монтажник не найден")
 	}
 
-	if !installer.IsAvailableOnDate(newScheduledAt) {
-		return errors.New("монтажник недоступен на указанную дату")
-	}
-
-	// Проверяем конфликты (исключая текущий монтаж)
-	conflicts, err := s.CheckScheduleConflicts(installerID, newScheduledAt, installation.EstimatedDuration, installation.ID)
-	if err != nil {
-		return fmt.Errorf("ошибка при проверке расписания: %v", err)
-	}
-
-	if len(conflicts) > 0 {
-		return errors.New("у монтажника уже есть работы в это время")
+	// Проверяем день, часы, лимит и конфликты (исключая текущий монтаж)
+	tz := s.scheduleTimezone(installation.LocationID, installation.ObjectID, &installer)
+	if err := s.checkInstallerSlot(&installer, newScheduledAt, installation.EstimatedDuration, tz, installation.ID); err != nil {
+		return err
 	}
 
 	// Обновляем монтаж
@@ -221,34 +196,14 @@ func (s *InstallationService) GetAvailableInstallers(date time.Time, locationID
 		return nil, err
 	}
 
-	// Фильтруем по доступности на дату и проверяем конфликты
+	// Фильтруем по доступности на дату, рабочим часам, лимиту и конфликтам
 	var availableInstallers []models.Installer
 	for _, installer := range installers {
-		if !installer.IsAvailableOnDate(date) {
+		tz := s.scheduleTimezone(locationID, 0, &installer)
+		if err := s.checkInstallerSlot(&installer, date, duration, tz, 0); err != nil {
 			continue
 		}
-
-		// Проверяем конфликты в расписании
-		conflicts, err := s.CheckScheduleConflicts(installer.ID, date, duration, 0)
-		if err != nil {
-			continue
-		}
-
-		if len(conflicts) == 0 {
-			// Проверяем максимальное количество монтажей в день
-			dayStart := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
-			dayEnd := dayStart.Add(24 * time.Hour)
-
-			var dailyCount int64
-			s.DB.Model(&models.Installation{}).
-				Where("installer_id = ? AND scheduled_at BETWEEN ? AND ? AND status IN ('planned', 'in_progress')",
-					installer.ID, dayStart, dayEnd).
-				Count(&dailyCount)
-
-			if int(dailyCount) < installer.MaxDailyInstallations {
-				availableInstallers = append(availableInstallers, installer)
-			}
-		}
+		availableInstallers = append(availableInstallers, installer)
 	}
 
 	return availableInstallers, nil
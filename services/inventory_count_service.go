@@ -0,0 +1,578 @@
+package services
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/xuri/excelize/v2"
+	"gorm.io/gorm"
+
+	"backend_axenta/models"
+)
+
+// InventoryCountService проводит инвентаризацию складов
+type InventoryCountService struct {
+	DB *gorm.DB
+}
+
+// NewInventoryCountService создает новый экземпляр InventoryCountService
+func NewInventoryCountService(db *gorm.DB) *InventoryCountService {
+	return &InventoryCountService{DB: db}
+}
+
+// inventoryResultNames названия результатов сверки для отчета
+var inventoryResultNames = map[string]string{
+	models.InventoryResultPending:  "Не проверено",
+	models.InventoryResultMatched:  "Совпадает",
+	models.InventoryResultShortage: "Недостача",
+	models.InventoryResultSurplus:  "Излишек",
+	models.InventoryResultUnknown:  "Нет в учете",
+}
+
+// StartCount начинает инвентаризацию склада или места хранения и фиксирует учетные остатки
+func (s *InventoryCountService) StartCount(warehouseID uint, storageBinID *uint, userID uint, notes string) (*models.InventoryCount, error) {
+	var warehouse models.Warehouse
+	if err := s.DB.First(&warehouse, warehouseID).Error; err != nil {
+		return nil, fmt.Errorf("склад не найден: %w", err)
+	}
+
+	var active int64
+	if err := s.DB.Model(&models.InventoryCount{}).
+		Where("warehouse_id = ? AND status IN ?", warehouseID, []string{models.InventoryCountInProgress, models.InventoryCountCompleted}).
+		Count(&active).Error; err != nil {
+		return nil, fmt.Errorf("ошибка при проверке незавершенных инвентаризаций: %w", err)
+	}
+	if active > 0 {
+		return nil, fmt.Errorf("на складе %s уже идет инвентаризация", warehouse.Code)
+	}
+
+	// Область пересчета: место хранения вместе с вложенными
+	stockQuery := s.DB.Preload("StorageBin").
+		Where("warehouse_id = ? AND status IN ?", warehouseID, []string{models.EquipmentStatusInStock, models.EquipmentStatusReserved})
+	if storageBinID != nil {
+		var bin models.StorageBin
+		if err := s.DB.First(&bin, *storageBinID).Error; err != nil {
+			return nil, fmt.Errorf("место хранения не найдено: %w", err)
+		}
+		if bin.WarehouseID != warehouseID {
+			return nil, fmt.Errorf("место хранения %s не относится к складу %s", bin.Path, warehouse.Code)
+		}
+		binIDs := s.DB.Model(&models.StorageBin{}).Select("id").
+			Where("warehouse_id = ? AND (id = ? OR path LIKE ?)", warehouseID, bin.ID, bin.Path+"-%")
+		stockQuery = stockQuery.Where("storage_bin_id IN (?)", binIDs)
+	}
+
+	var stock []models.Equipment
+	if err := stockQuery.Order("id").Find(&stock).Error; err != nil {
+		return nil, fmt.Errorf("ошибка при получении остатков: %w", err)
+	}
+
+	now := time.Now()
+	count := &models.InventoryCount{
+		Status:          models.InventoryCountInProgress,
+		WarehouseID:     warehouseID,
+		StorageBinID:    storageBinID,
+		ExpectedCount:   len(stock),
+		StartedAt:       now,
+		CreatedByUserID: userID,
+		Notes:           notes,
+		CompanyID:       warehouse.CompanyID,
+	}
+
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := createNumberedDocument(tx, count, "INV", now, func(number string) { count.Number = number }); err != nil {
+			return fmt.Errorf("ошибка при создании инвентаризации: %w", err)
+		}
+
+		items := make([]models.InventoryCountItem, 0, len(stock))
+		for i := range stock {
+			equipment := &stock[i]
+			items = append(items, models.InventoryCountItem{
+				InventoryCountID: count.ID,
+				EquipmentID:      &equipment.ID,
+				Code:             inventoryEquipmentCode(equipment),
+				SerialNumber:     equipment.SerialNumber,
+				Description:      inventoryEquipmentDescription(equipment),
+				Expected:         true,
+				ExpectedLocation: models.StockLocationLabel(&warehouse, equipment.StorageBin),
+				ExpectedStatus:   equipment.Status,
+				Result:           models.InventoryResultPending,
+			})
+		}
+		if len(items) > 0 {
+			if err := tx.CreateInBatches(items, 200).Error; err != nil {
+				return fmt.Errorf("ошибка при фиксации остатков: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return count, nil
+}
+
+// ScanCode регистрирует отсканированный QR код, серийный номер или IMEI.
+// Повторное сканирование той же единицы не создает новую позицию.
+func (s *InventoryCountService) ScanCode(countID uint, code string, userID uint) (*models.InventoryCountItem, error) {
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return nil, fmt.Errorf("пустой код")
+	}
+
+	var item models.InventoryCountItem
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		count, err := s.lockCount(tx, countID)
+		if err != nil {
+			return err
+		}
+		if !count.IsEditable() {
+			return fmt.Errorf("инвентаризация %s не принимает результаты сканирования", count.Number)
+		}
+
+		now := time.Now()
+		var equipment models.Equipment
+		err = tx.Preload("Warehouse").Preload("StorageBin").
+			Where("qr_code = ? OR serial_number = ? OR imei = ?", code, code, code).
+			First(&equipment).Error
+		switch {
+		case err == nil:
+			err = tx.Where("inventory_count_id = ? AND equipment_id = ?", countID, equipment.ID).First(&item).Error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				// Оборудование есть в учете, но не числится в области пересчета
+				item = models.InventoryCountItem{
+					InventoryCountID: countID,
+					EquipmentID:      &equipment.ID,
+					Code:             inventoryEquipmentCode(&equipment),
+					SerialNumber:     equipment.SerialNumber,
+					Description:      inventoryEquipmentDescription(&equipment),
+					ExpectedLocation: equipment.WarehouseLocation,
+					ExpectedStatus:   equipment.Status,
+					Result:           models.InventoryResultPending,
+				}
+				if equipment.Warehouse != nil {
+					item.ExpectedLocation = models.StockLocationLabel(equipment.Warehouse, equipment.StorageBin)
+				}
+			} else if err != nil {
+				return fmt.Errorf("ошибка при поиске позиции: %w", err)
+			}
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			err = tx.Where("inventory_count_id = ? AND equipment_id IS NULL AND code = ?", countID, code).First(&item).Error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				item = models.InventoryCountItem{
+					InventoryCountID: countID,
+					Code:             code,
+					Result:           models.InventoryResultPending,
+				}
+			} else if err != nil {
+				return fmt.Errorf("ошибка при поиске позиции: %w", err)
+			}
+		default:
+			return fmt.Errorf("ошибка при поиске оборудования: %w", err)
+		}
+
+		item.Scanned = true
+		item.ScanCount++
+		item.ScannedAt = &now
+		if userID != 0 {
+			item.ScannedByUserID = &userID
+		}
+		if err := tx.Save(&item).Error; err != nil {
+			return fmt.Errorf("ошибка при сохранении результата сканирования: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &item, nil
+}
+
+// CompleteCount завершает пересчет и рассчитывает недостачи и излишки
+func (s *InventoryCountService) CompleteCount(countID uint) (*models.InventoryCount, error) {
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		count, err := s.lockCount(tx, countID)
+		if err != nil {
+			return err
+		}
+		if !count.IsEditable() {
+			return fmt.Errorf("инвентаризация %s уже завершена", count.Number)
+		}
+
+		updates := []struct {
+			where  string
+			result string
+		}{
+			{"expected = ? AND scanned = ?", models.InventoryResultMatched},
+			{"expected = ? AND scanned <> ?", models.InventoryResultShortage},
+		}
+		for _, u := range updates {
+			if err := tx.Model(&models.InventoryCountItem{}).
+				Where("inventory_count_id = ?", countID).Where(u.where, true, true).
+				Update("result", u.result).Error; err != nil {
+				return fmt.Errorf("ошибка при расчете расхождений: %w", err)
+			}
+		}
+		if err := tx.Model(&models.InventoryCountItem{}).
+			Where("inventory_count_id = ? AND expected = ? AND equipment_id IS NOT NULL", countID, false).
+			Update("result", models.InventoryResultSurplus).Error; err != nil {
+			return fmt.Errorf("ошибка при расчете расхождений: %w", err)
+		}
+		if err := tx.Model(&models.InventoryCountItem{}).
+			Where("inventory_count_id = ? AND expected = ? AND equipment_id IS NULL", countID, false).
+			Update("result", models.InventoryResultUnknown).Error; err != nil {
+			return fmt.Errorf("ошибка при расчете расхождений: %w", err)
+		}
+
+		totals, err := s.countResults(tx, countID)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		return tx.Model(count).Updates(map[string]interface{}{
+			"status":         models.InventoryCountCompleted,
+			"completed_at":   now,
+			"scanned_count":  totals.scanned,
+			"matched_count":  totals.byResult[models.InventoryResultMatched],
+			"shortage_count": totals.byResult[models.InventoryResultShortage],
+			"surplus_count":  totals.byResult[models.InventoryResultSurplus],
+			"unknown_count":  totals.byResult[models.InventoryResultUnknown],
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetCount(countID)
+}
+
+// ApproveCount проводит расхождения: списывает недостачу и приходует найденное оборудование на склад.
+// Позиции, учетное состояние которых изменилось после начала пересчета, не проводятся.
+func (s *InventoryCountService) ApproveCount(countID uint, userID uint) (*models.InventoryCount, error) {
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		count, err := s.lockCount(tx, countID)
+		if err != nil {
+			return err
+		}
+		if count.Status != models.InventoryCountCompleted {
+			return fmt.Errorf("провести можно только завершенную инвентаризацию")
+		}
+
+		var warehouse models.Warehouse
+		if err := tx.First(&warehouse, count.WarehouseID).Error; err != nil {
+			return fmt.Errorf("склад не найден: %w", err)
+		}
+
+		var items []models.InventoryCountItem
+		if err := tx.Where("inventory_count_id = ? AND result IN ? AND equipment_id IS NOT NULL", countID,
+			[]string{models.InventoryResultShortage, models.InventoryResultSurplus}).
+			Order("id").Find(&items).Error; err != nil {
+			return fmt.Errorf("ошибка при получении расхождений: %w", err)
+		}
+
+		for i := range items {
+			item := &items[i]
+			var operation *models.WarehouseOperation
+			var note string
+			if item.Result == models.InventoryResultShortage {
+				operation, note, err = s.writeOffShortage(tx, count, &warehouse, item, userID)
+			} else {
+				operation, note, err = s.postSurplus(tx, count, &warehouse, item, userID)
+			}
+			if err != nil {
+				return err
+			}
+
+			updates := map[string]interface{}{"adjustment_note": note}
+			if operation != nil {
+				updates["adjustment_operation_id"] = operation.ID
+			}
+			if err := tx.Model(item).Updates(updates).Error; err != nil {
+				return fmt.Errorf("ошибка при сохранении позиции: %w", err)
+			}
+		}
+
+		now := time.Now()
+		return tx.Model(count).Updates(map[string]interface{}{
+			"status":              models.InventoryCountApproved,
+			"approved_at":         now,
+			"approved_by_user_id": userID,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetCount(countID)
+}
+
+// writeOffShortage списывает ненайденное оборудование
+func (s *InventoryCountService) writeOffShortage(tx *gorm.DB, count *models.InventoryCount, warehouse *models.Warehouse,
+	item *models.InventoryCountItem, userID uint) (*models.WarehouseOperation, string, error) {
+	equipment, err := lockEquipment(tx, *item.EquipmentID)
+	if err != nil {
+		return nil, "оборудование не найдено", nil
+	}
+	if equipment.WarehouseID == nil || *equipment.WarehouseID != count.WarehouseID ||
+		(equipment.Status != models.EquipmentStatusInStock && equipment.Status != models.EquipmentStatusReserved) {
+		return nil, "не проведено: оборудование перемещено после начала инвентаризации", nil
+	}
+
+	// Резерв снимается перед списанием
+	if equipment.Status == models.EquipmentStatusReserved {
+		if err := updateEquipmentState(tx, equipment, map[string]interface{}{"status": models.EquipmentStatusInStock}); err != nil {
+			return nil, "", err
+		}
+	}
+	operation, err := writeOffEquipment(tx, equipment, time.Now(), "недостача по инвентаризации "+count.Number,
+		decimal.Zero, count.Number, userID)
+	if err != nil {
+		return nil, "", err
+	}
+	return operation, "списано", nil
+}
+
+// postSurplus приходует найденное оборудование на склад инвентаризации
+func (s *InventoryCountService) postSurplus(tx *gorm.DB, count *models.InventoryCount, warehouse *models.Warehouse,
+	item *models.InventoryCountItem, userID uint) (*models.WarehouseOperation, string, error) {
+	equipment, err := lockEquipment(tx, *item.EquipmentID)
+	if err != nil {
+		return nil, "оборудование не найдено", nil
+	}
+
+	updates := map[string]interface{}{
+		"warehouse_id":       warehouse.ID,
+		"storage_bin_id":     count.StorageBinID,
+		"warehouse_location": models.StockLocationLabel(warehouse, nil),
+	}
+	switch equipment.Status {
+	case models.EquipmentStatusInStock, models.EquipmentStatusReserved:
+	default:
+		if !equipment.CanTransitionTo(models.EquipmentStatusInStock) {
+			return nil, fmt.Sprintf("не проведено: оборудование в статусе %s требует ручной обработки", equipment.Status), nil
+		}
+		updates["status"] = models.EquipmentStatusInStock
+		updates["object_id"] = nil
+	}
+	if count.StorageBinID != nil {
+		var bin models.StorageBin
+		if err := tx.First(&bin, *count.StorageBinID).Error; err == nil {
+			updates["warehouse_location"] = models.StockLocationLabel(warehouse, &bin)
+		}
+	}
+
+	fromWarehouseID, fromBinID := equipment.WarehouseID, equipment.StorageBinID
+	if err := updateEquipmentState(tx, equipment, updates); err != nil {
+		return nil, "", err
+	}
+
+	operation := &models.WarehouseOperation{
+		Type:            "inventory",
+		Description:     fmt.Sprintf("Оприходование излишка по инвентаризации %s", count.Number),
+		Status:          "completed",
+		EquipmentID:     equipment.ID,
+		Quantity:        1,
+		FromLocation:    item.ExpectedLocation,
+		ToLocation:      updates["warehouse_location"].(string),
+		FromWarehouseID: fromWarehouseID,
+		FromBinID:       fromBinID,
+		ToWarehouseID:   &warehouse.ID,
+		ToBinID:         count.StorageBinID,
+		UserID:          userID,
+		DocumentNumber:  count.Number,
+		CompanyID:       count.CompanyID,
+	}
+	if err := tx.Create(operation).Error; err != nil {
+		return nil, "", fmt.Errorf("ошибка при создании операции оприходования: %w", err)
+	}
+	return operation, "оприходовано на склад", nil
+}
+
+// CancelCount отменяет непроведенную инвентаризацию
+func (s *InventoryCountService) CancelCount(countID uint) error {
+	return s.DB.Transaction(func(tx *gorm.DB) error {
+		count, err := s.lockCount(tx, countID)
+		if err != nil {
+			return err
+		}
+		if count.Status == models.InventoryCountApproved || count.Status == models.InventoryCountCancelled {
+			return fmt.Errorf("инвентаризация %s уже закрыта", count.Number)
+		}
+		return tx.Model(count).Update("status", models.InventoryCountCancelled).Error
+	})
+}
+
+// GetCount возвращает инвентаризацию с итогами
+func (s *InventoryCountService) GetCount(countID uint) (*models.InventoryCount, error) {
+	var count models.InventoryCount
+	if err := s.DB.Preload("Warehouse").Preload("StorageBin").First(&count, countID).Error; err != nil {
+		return nil, fmt.Errorf("инвентаризация не найдена: %w", err)
+	}
+	return &count, nil
+}
+
+// GetCounts возвращает инвентаризации склада
+func (s *InventoryCountService) GetCounts(warehouseID *uint, status string) ([]models.InventoryCount, error) {
+	query := s.DB.Preload("Warehouse")
+	if warehouseID != nil {
+		query = query.Where("warehouse_id = ?", *warehouseID)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var counts []models.InventoryCount
+	if err := query.Order("started_at DESC").Find(&counts).Error; err != nil {
+		return nil, fmt.Errorf("ошибка при получении инвентаризаций: %w", err)
+	}
+	return counts, nil
+}
+
+// GetItems возвращает позиции инвентаризации, при необходимости только с указанным результатом
+func (s *InventoryCountService) GetItems(countID uint, result string) ([]models.InventoryCountItem, error) {
+	query := s.DB.Where("inventory_count_id = ?", countID)
+	if result != "" {
+		query = query.Where("result = ?", result)
+	}
+
+	var items []models.InventoryCountItem
+	if err := query.Order("expected DESC, id").Find(&items).Error; err != nil {
+		return nil, fmt.Errorf("ошибка при получении позиций: %w", err)
+	}
+	return items, nil
+}
+
+// ExportDiscrepancyReport формирует отчет о расхождениях в формате xlsx или csv
+func (s *InventoryCountService) ExportDiscrepancyReport(countID uint, format string) ([]byte, string, error) {
+	count, err := s.GetCount(countID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var items []models.InventoryCountItem
+	if err := s.DB.Where("inventory_count_id = ? AND result <> ?", countID, models.InventoryResultMatched).
+		Order("result, id").Find(&items).Error; err != nil {
+		return nil, "", fmt.Errorf("ошибка при получении расхождений: %w", err)
+	}
+
+	headers := []string{"№", "Результат", "Код", "Серийный номер", "Оборудование", "Учетное место", "Статус в учете", "Сканирований", "Проведение"}
+	rows := make([][]string, 0, len(items))
+	for i, item := range items {
+		rows = append(rows, []string{
+			fmt.Sprintf("%d", i+1),
+			inventoryResultNames[item.Result],
+			item.Code,
+			item.SerialNumber,
+			item.Description,
+			item.ExpectedLocation,
+			item.ExpectedStatus,
+			fmt.Sprintf("%d", item.ScanCount),
+			item.AdjustmentNote,
+		})
+	}
+
+	baseName := "inventory_" + count.Number
+	if format == "csv" {
+		var buf bytes.Buffer
+		buf.WriteString("\xef\xbb\xbf") // BOM для корректного открытия в Excel
+		writer := csv.NewWriter(&buf)
+		writer.Comma = ';'
+		_ = writer.Write(headers)
+		_ = writer.WriteAll(rows)
+		if err := writer.Error(); err != nil {
+			return nil, "", fmt.Errorf("ошибка при формировании CSV: %w", err)
+		}
+		return buf.Bytes(), baseName + ".csv", nil
+	}
+
+	f := excelize.NewFile()
+	defer f.Close()
+	sheet := "Расхождения"
+	f.SetSheetName(f.GetSheetName(0), sheet)
+
+	title := fmt.Sprintf("Инвентаризация %s от %s", count.Number, count.StartedAt.Format("02.01.2006"))
+	if count.Warehouse != nil {
+		title += ", склад " + count.Warehouse.Name
+	}
+	f.SetCellValue(sheet, "A1", title)
+	f.SetCellValue(sheet, "A2", fmt.Sprintf("Числилось: %d, совпало: %d, недостача: %d, излишек: %d, нет в учете: %d",
+		count.ExpectedCount, count.MatchedCount, count.ShortageCount, count.SurplusCount, count.UnknownCount))
+
+	for col, header := range headers {
+		cell, _ := excelize.CoordinatesToCellName(col+1, 4)
+		f.SetCellValue(sheet, cell, header)
+	}
+	for rowIdx, row := range rows {
+		for col, value := range row {
+			cell, _ := excelize.CoordinatesToCellName(col+1, rowIdx+5)
+			f.SetCellValue(sheet, cell, value)
+		}
+	}
+
+	buf, err := f.WriteToBuffer()
+	if err != nil {
+		return nil, "", fmt.Errorf("ошибка при формировании Excel файла: %w", err)
+	}
+	return buf.Bytes(), baseName + ".xlsx", nil
+}
+
+// inventoryTotals итоги пересчета
+type inventoryTotals struct {
+	scanned  int
+	byResult map[string]int
+}
+
+// countResults подсчитывает позиции инвентаризации по результатам
+func (s *InventoryCountService) countResults(tx *gorm.DB, countID uint) (*inventoryTotals, error) {
+	var rows []struct {
+		Result  string
+		Scanned bool
+		Total   int
+	}
+	if err := tx.Model(&models.InventoryCountItem{}).
+		Select("result, scanned, COUNT(*) AS total").
+		Where("inventory_count_id = ?", countID).
+		Group("result, scanned").
+		Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("ошибка при подсчете итогов: %w", err)
+	}
+
+	totals := &inventoryTotals{byResult: map[string]int{}}
+	for _, row := range rows {
+		totals.byResult[row.Result] += row.Total
+		if row.Scanned {
+			totals.scanned += row.Total
+		}
+	}
+	return totals, nil
+}
+
+// lockCount загружает инвентаризацию с блокировкой строки
+func (s *InventoryCountService) lockCount(tx *gorm.DB, countID uint) (*models.InventoryCount, error) {
+	var count models.InventoryCount
+	if err := tx.Clauses(lockingForUpdate).First(&count, countID).Error; err != nil {
+		return nil, fmt.Errorf("инвентаризация не найдена: %w", err)
+	}
+	return &count, nil
+}
+
+// inventoryEquipmentCode код, по которому оборудование ищется при сканировании
+func inventoryEquipmentCode(equipment *models.Equipment) string {
+	if equipment.QRCode != "" {
+		return equipment.QRCode
+	}
+	return equipment.SerialNumber
+}
+
+// inventoryEquipmentDescription краткое описание оборудования для отчета
+func inventoryEquipmentDescription(equipment *models.Equipment) string {
+	return strings.TrimSpace(strings.Join([]string{equipment.Type, equipment.Brand, equipment.Model}, " "))
+}
@@ -0,0 +1,147 @@
+package services
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xuri/excelize/v2"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"backend_axenta/models"
+)
+
+func setupInventoryCountTest(t *testing.T) (*gorm.DB, *InventoryCountService, *models.Warehouse, *models.Warehouse) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(
+		&models.Warehouse{},
+		&models.StorageBin{},
+		&models.Equipment{},
+		&models.WarehouseOperation{},
+		&models.InventoryCount{},
+		&models.InventoryCountItem{},
+	))
+
+	main := &models.Warehouse{Name: "Основной склад", Code: "MSK", IsActive: true}
+	require.NoError(t, db.Create(main).Error)
+	branch := &models.Warehouse{Name: "Филиал", Code: "SPB", IsActive: true}
+	require.NoError(t, db.Create(branch).Error)
+
+	return db, NewInventoryCountService(db), main, branch
+}
+
+func createInventoryEquipment(t *testing.T, db *gorm.DB, serial string, status string, warehouseID *uint) models.Equipment {
+	equipment := models.Equipment{
+		Type: "GPS-tracker", Model: "GT06N", SerialNumber: serial, IMEI: "IMEI-" + serial, QRCode: "EQ-" + serial,
+		Status: status, Condition: "new", WarehouseID: warehouseID,
+	}
+	require.NoError(t, db.Create(&equipment).Error)
+	return equipment
+}
+
+func TestInventoryCountService_FullCycle(t *testing.T) {
+	db, service, main, branch := setupInventoryCountTest(t)
+
+	found := createInventoryEquipment(t, db, "SN-1", models.EquipmentStatusInStock, &main.ID)
+	reserved := createInventoryEquipment(t, db, "SN-2", models.EquipmentStatusReserved, &main.ID)
+	lost := createInventoryEquipment(t, db, "SN-3", models.EquipmentStatusInStock, &main.ID)
+	misplaced := createInventoryEquipment(t, db, "SN-4", models.EquipmentStatusInStock, &branch.ID)
+	createInventoryEquipment(t, db, "SN-5", models.EquipmentStatusInstalled, nil)
+
+	count, err := service.StartCount(main.ID, nil, 1, "Плановая инвентаризация")
+	require.NoError(t, err)
+	assert.Equal(t, 3, count.ExpectedCount)
+	assert.Contains(t, count.Number, "INV-")
+
+	// Вторая инвентаризация того же склада не начинается
+	_, err = service.StartCount(main.ID, nil, 1, "")
+	assert.Error(t, err)
+
+	// Сканирование по QR коду, серийному номеру и IMEI
+	_, err = service.ScanCode(count.ID, found.QRCode, 2)
+	require.NoError(t, err)
+	item, err := service.ScanCode(count.ID, found.SerialNumber, 2)
+	require.NoError(t, err)
+	assert.Equal(t, 2, item.ScanCount)
+	_, err = service.ScanCode(count.ID, reserved.IMEI, 2)
+	require.NoError(t, err)
+	_, err = service.ScanCode(count.ID, misplaced.QRCode, 2)
+	require.NoError(t, err)
+	_, err = service.ScanCode(count.ID, "UNKNOWN-777", 2)
+	require.NoError(t, err)
+
+	count, err = service.CompleteCount(count.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.InventoryCountCompleted, count.Status)
+	assert.Equal(t, 2, count.MatchedCount)
+	assert.Equal(t, 1, count.ShortageCount)
+	assert.Equal(t, 1, count.SurplusCount)
+	assert.Equal(t, 1, count.UnknownCount)
+	assert.Equal(t, 4, count.ScannedCount)
+
+	// После завершения сканирование не принимается
+	_, err = service.ScanCode(count.ID, lost.QRCode, 2)
+	assert.Error(t, err)
+
+	count, err = service.ApproveCount(count.ID, 1)
+	require.NoError(t, err)
+	assert.Equal(t, models.InventoryCountApproved, count.Status)
+
+	var writtenOff models.Equipment
+	require.NoError(t, db.First(&writtenOff, lost.ID).Error)
+	assert.Equal(t, models.EquipmentStatusDisposed, writtenOff.Status)
+	assert.Nil(t, writtenOff.WarehouseID)
+
+	var moved models.Equipment
+	require.NoError(t, db.First(&moved, misplaced.ID).Error)
+	require.NotNil(t, moved.WarehouseID)
+	assert.Equal(t, main.ID, *moved.WarehouseID)
+
+	var operations []models.WarehouseOperation
+	require.NoError(t, db.Where("document_number = ?", count.Number).Order("type").Find(&operations).Error)
+	require.Len(t, operations, 2)
+	assert.Equal(t, "disposal", operations[0].Type)
+	assert.Equal(t, "inventory", operations[1].Type)
+
+	report, fileName, err := service.ExportDiscrepancyReport(count.ID, "xlsx")
+	require.NoError(t, err)
+	assert.Equal(t, "inventory_"+count.Number+".xlsx", fileName)
+
+	f, err := excelize.OpenReader(bytes.NewReader(report))
+	require.NoError(t, err)
+	rows, err := f.GetRows("Расхождения")
+	require.NoError(t, err)
+	assert.Len(t, rows, 4+3) // Заголовок, итоги, пустая строка, шапка таблицы и три расхождения
+}
+
+func TestInventoryCountService_ApproveSkipsChangedEquipment(t *testing.T) {
+	db, service, main, branch := setupInventoryCountTest(t)
+	equipment := createInventoryEquipment(t, db, "SN-1", models.EquipmentStatusInStock, &main.ID)
+
+	count, err := service.StartCount(main.ID, nil, 1, "")
+	require.NoError(t, err)
+	_, err = service.CompleteCount(count.ID)
+	require.NoError(t, err)
+
+	// Во время инвентаризации оборудование перемещено на другой склад
+	require.NoError(t, db.Model(&models.Equipment{}).Where("id = ?", equipment.ID).Update("warehouse_id", branch.ID).Error)
+
+	_, err = service.ApproveCount(count.ID, 1)
+	require.NoError(t, err)
+
+	var current models.Equipment
+	require.NoError(t, db.First(&current, equipment.ID).Error)
+	assert.Equal(t, models.EquipmentStatusInStock, current.Status)
+
+	items, err := service.GetItems(count.ID, models.InventoryResultShortage)
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	assert.Nil(t, items[0].AdjustmentOperationID)
+	assert.Contains(t, items[0].AdjustmentNote, "не проведено")
+}
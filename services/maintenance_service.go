@@ -0,0 +1,652 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"gorm.io/gorm"
+
+	"backend_axenta/models"
+)
+
+// Параметры планового обслуживания по умолчанию
+const (
+	DefaultMaintenanceLeadDays = 14 // Монтаж создается за две недели до срока
+	DefaultMaintenanceDuration = 60 // Продолжительность обслуживания, минут
+	maintenanceSlotWindowDays  = 7  // Слоты подбираются в пределах недели до и после срока
+)
+
+// MaintenanceService представляет сервис планового обслуживания
+type MaintenanceService struct {
+	DB       *gorm.DB
+	Timezone string // Часовой пояс компании для планирования обслуживания
+}
+
+// NewMaintenanceService создает новый экземпляр MaintenanceService
+func NewMaintenanceService(db *gorm.DB) *MaintenanceService {
+	return &MaintenanceService{DB: db}
+}
+
+// MaintenancePlanRequest запрос на создание или изменение плана обслуживания
+type MaintenancePlanRequest struct {
+	Name                string `json:"name" binding:"required"`
+	Description         string `json:"description"`
+	ObjectID            *uint  `json:"object_id"`
+	EquipmentCategoryID *uint  `json:"equipment_category_id"`
+	IntervalMonths      int    `json:"interval_months"`
+	IntervalEngineHours int    `json:"interval_engine_hours"`
+	LeadDays            int    `json:"lead_days"`
+	LeadEngineHours     int    `json:"lead_engine_hours"`
+	EstimatedDuration   int    `json:"estimated_duration"`
+	Priority            string `json:"priority"`
+	Specialization      string `json:"specialization"`
+	IsActive            *bool  `json:"is_active"` // По умолчанию план активен
+}
+
+// MaintenanceRunResult результат проверки планов обслуживания
+type MaintenanceRunResult struct {
+	Created    int                       `json:"created"`    // Созданы монтажи
+	Unassigned int                       `json:"unassigned"` // Нет свободного монтажника
+	Completed  int                       `json:"completed"`  // Отмечено выполнение
+	Cancelled  int                       `json:"cancelled"`  // Монтаж отменен
+	Visits     []models.MaintenanceVisit `json:"visits"`     // Созданные и переназначенные обслуживания
+}
+
+// MaintenanceDue срок очередного обслуживания
+type MaintenanceDue struct {
+	DueAt          time.Time
+	DueEngineHours *float64
+	Reason         string
+	Triggered      bool // Пора создавать монтаж
+}
+
+// MaintenanceSlotCandidate свободный слот монтажника для обслуживания
+type MaintenanceSlotCandidate struct {
+	InstallerID uint
+	Slot        FreeSlot
+}
+
+// MaintenanceCompliance соблюдение плана обслуживания за период
+type MaintenanceCompliance struct {
+	PlanID          uint    `json:"plan_id"`
+	PlanName        string  `json:"plan_name"`
+	Total           int     `json:"total"`
+	CompletedOnTime int     `json:"completed_on_time"`
+	CompletedLate   int     `json:"completed_late"`
+	Overdue         int     `json:"overdue"`    // Срок прошел, обслуживание не выполнено
+	Upcoming        int     `json:"upcoming"`   // Срок еще не наступил
+	Unassigned      int     `json:"unassigned"` // Монтажник не назначен
+	Cancelled       int     `json:"cancelled"`
+	CompliancePct   float64 `json:"compliance_pct"` // Доля выполненных в срок среди выполненных и просроченных
+}
+
+// maintenanceTarget объект (и оборудование на нем), который обслуживается по плану
+type maintenanceTarget struct {
+	Object    models.Object
+	Equipment *models.Equipment
+}
+
+// CreatePlan создает план обслуживания
+func (s *MaintenanceService) CreatePlan(req MaintenancePlanRequest) (*models.MaintenancePlan, error) {
+	plan := &models.MaintenancePlan{IsActive: true}
+	if err := s.applyPlan(plan, req); err != nil {
+		return nil, err
+	}
+	if err := s.DB.Create(plan).Error; err != nil {
+		return nil, fmt.Errorf("ошибка при создании плана обслуживания: %w", err)
+	}
+	return plan, nil
+}
+
+// UpdatePlan изменяет план обслуживания; уже созданные монтажи не меняются
+func (s *MaintenanceService) UpdatePlan(id uint, req MaintenancePlanRequest) (*models.MaintenancePlan, error) {
+	var plan models.MaintenancePlan
+	if err := s.DB.First(&plan, id).Error; err != nil {
+		return nil, err
+	}
+	if err := s.applyPlan(&plan, req); err != nil {
+		return nil, err
+	}
+	if err := s.DB.Save(&plan).Error; err != nil {
+		return nil, fmt.Errorf("ошибка при сохранении плана обслуживания: %w", err)
+	}
+	return &plan, nil
+}
+
+// applyPlan проверяет запрос и переносит его в план
+func (s *MaintenanceService) applyPlan(plan *models.MaintenancePlan, req MaintenancePlanRequest) error {
+	if (req.ObjectID == nil) == (req.EquipmentCategoryID == nil) {
+		return errors.New("укажите объект или категорию оборудования")
+	}
+	if req.IntervalMonths < 0 || req.IntervalEngineHours < 0 || req.LeadDays < 0 || req.LeadEngineHours < 0 || req.EstimatedDuration < 0 {
+		return errors.New("интервалы и сроки не могут быть отрицательными")
+	}
+	if req.IntervalMonths == 0 && req.IntervalEngineHours == 0 {
+		return errors.New("укажите интервал обслуживания в месяцах или моточасах")
+	}
+	if req.ObjectID != nil {
+		if err := s.DB.Select("id").First(&models.Object{}, *req.ObjectID).Error; err != nil {
+			return fmt.Errorf("объект не найден: %w", err)
+		}
+	}
+	if req.EquipmentCategoryID != nil {
+		if err := s.DB.Select("id").First(&models.EquipmentCategory{}, *req.EquipmentCategoryID).Error; err != nil {
+			return fmt.Errorf("категория оборудования не найдена: %w", err)
+		}
+	}
+
+	plan.Name = req.Name
+	plan.Description = req.Description
+	plan.ObjectID = req.ObjectID
+	plan.EquipmentCategoryID = req.EquipmentCategoryID
+	plan.IntervalMonths = req.IntervalMonths
+	plan.IntervalEngineHours = req.IntervalEngineHours
+	plan.LeadDays = req.LeadDays
+	if plan.LeadDays == 0 {
+		plan.LeadDays = DefaultMaintenanceLeadDays
+	}
+	plan.LeadEngineHours = req.LeadEngineHours
+	plan.EstimatedDuration = req.EstimatedDuration
+	if plan.EstimatedDuration == 0 {
+		plan.EstimatedDuration = DefaultMaintenanceDuration
+	}
+	plan.Priority = req.Priority
+	if plan.Priority == "" {
+		plan.Priority = "normal"
+	}
+	plan.Specialization = req.Specialization
+	if req.IsActive != nil {
+		plan.IsActive = *req.IsActive
+	}
+	return nil
+}
+
+// DeletePlan удаляет план обслуживания; запланированные монтажи остаются в расписании
+func (s *MaintenanceService) DeletePlan(id uint) error {
+	result := s.DB.Delete(&models.MaintenancePlan{}, id)
+	if result.Error != nil {
+		return fmt.Errorf("ошибка при удалении плана обслуживания: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// GetPlans возвращает планы обслуживания с фильтром по объекту
+func (s *MaintenanceService) GetPlans(objectID *uint) ([]models.MaintenancePlan, error) {
+	query := s.DB.Preload("Object").Preload("EquipmentCategory").Order("name, id")
+	if objectID != nil {
+		query = query.Where("object_id = ?", *objectID)
+	}
+	var plans []models.MaintenancePlan
+	err := query.Find(&plans).Error
+	return plans, err
+}
+
+// GetPlan возвращает план обслуживания
+func (s *MaintenanceService) GetPlan(id uint) (*models.MaintenancePlan, error) {
+	var plan models.MaintenancePlan
+	if err := s.DB.Preload("Object").Preload("EquipmentCategory").First(&plan, id).Error; err != nil {
+		return nil, err
+	}
+	return &plan, nil
+}
+
+// GetVisits возвращает плановые обслуживания с фильтрами по плану, объекту и статусу
+func (s *MaintenanceService) GetVisits(planID, objectID *uint, status string) ([]models.MaintenanceVisit, error) {
+	query := s.DB.Preload("Installation").Order("due_at DESC, id DESC")
+	if planID != nil {
+		query = query.Where("plan_id = ?", *planID)
+	}
+	if objectID != nil {
+		query = query.Where("object_id = ?", *objectID)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	var visits []models.MaintenanceVisit
+	err := query.Find(&visits).Error
+	return visits, err
+}
+
+// ComputeMaintenanceDue рассчитывает срок очередного обслуживания от последнего обслуживания
+// (lastAt, lastEngineHours) и определяет, пора ли создавать монтаж. По моточасам обслуживание
+// требуется, когда наработка объекта engineHours достигает срока за вычетом LeadEngineHours;
+// точная дата в этом случае неизвестна, поэтому срок - через LeadDays дней или сейчас, если наработка уже превышена.
+func ComputeMaintenanceDue(plan *models.MaintenancePlan, lastAt time.Time, lastEngineHours float64, engineHours *float64, now time.Time) MaintenanceDue {
+	var due MaintenanceDue
+	if plan.IntervalMonths > 0 {
+		due.DueAt = lastAt.AddDate(0, plan.IntervalMonths, 0)
+		due.Reason = fmt.Sprintf("Каждые %d мес.", plan.IntervalMonths)
+		due.Triggered = !now.Before(due.DueAt.AddDate(0, 0, -plan.LeadDays))
+	}
+
+	if plan.IntervalEngineHours > 0 {
+		dueHours := lastEngineHours + float64(plan.IntervalEngineHours)
+		due.DueEngineHours = &dueHours
+		if engineHours != nil && *engineHours >= dueHours-float64(plan.LeadEngineHours) {
+			dueAt := now.AddDate(0, 0, plan.LeadDays)
+			if *engineHours >= dueHours {
+				dueAt = now
+			}
+			if plan.IntervalMonths == 0 || !due.Triggered || dueAt.Before(due.DueAt) {
+				due.DueAt = dueAt
+				due.Reason = fmt.Sprintf("Каждые %d моточасов, наработка %.0f", plan.IntervalEngineHours, *engineHours)
+			}
+			due.Triggered = true
+		}
+	}
+	return due
+}
+
+// PickMaintenanceSlot выбирает слот для обслуживания: самый поздний слот не позже срока,
+// а если таких нет - самый ранний после срока
+func PickMaintenanceSlot(candidates []MaintenanceSlotCandidate, dueAt time.Time) *MaintenanceSlotCandidate {
+	var before, after *MaintenanceSlotCandidate
+	for i := range candidates {
+		candidate := &candidates[i]
+		if !candidate.Slot.Start.After(dueAt) {
+			if before == nil || candidate.Slot.Start.After(before.Slot.Start) {
+				before = candidate
+			}
+		} else if after == nil || candidate.Slot.Start.Before(after.Slot.Start) {
+			after = candidate
+		}
+	}
+	if before != nil {
+		return before
+	}
+	return after
+}
+
+// GenerateDueMaintenance проверяет планы обслуживания: отмечает выполненные и отмененные
+// обслуживания, повторно подбирает монтажников для неназначенных и создает монтажи
+// для объектов, у которых подходит срок обслуживания
+func (s *MaintenanceService) GenerateDueMaintenance(now time.Time) (*MaintenanceRunResult, error) {
+	result := &MaintenanceRunResult{Visits: []models.MaintenanceVisit{}}
+	if err := s.syncVisits(result); err != nil {
+		return nil, err
+	}
+
+	var unassigned []models.MaintenanceVisit
+	if err := s.DB.Preload("Plan").Where("status = ?", models.MaintenanceVisitUnassigned).Find(&unassigned).Error; err != nil {
+		return nil, fmt.Errorf("ошибка при получении неназначенных обслуживаний: %w", err)
+	}
+	for i := range unassigned {
+		visit := &unassigned[i]
+		if visit.Plan == nil || !visit.Plan.IsActive {
+			continue
+		}
+		var object models.Object
+		if err := s.DB.First(&object, visit.ObjectID).Error; err != nil {
+			continue
+		}
+		if err := s.scheduleVisit(visit.Plan, visit, &object, now, result); err != nil {
+			return nil, err
+		}
+	}
+
+	var plans []models.MaintenancePlan
+	if err := s.DB.Where("is_active = ?", true).Order("id").Find(&plans).Error; err != nil {
+		return nil, fmt.Errorf("ошибка при получении планов обслуживания: %w", err)
+	}
+	for i := range plans {
+		plan := &plans[i]
+		targets, err := s.planTargets(plan)
+		if err != nil {
+			return nil, err
+		}
+		for j := range targets {
+			if err := s.generateForTarget(plan, &targets[j], now, result); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return result, nil
+}
+
+// planTargets возвращает объекты и оборудование, обслуживаемые по плану
+func (s *MaintenanceService) planTargets(plan *models.MaintenancePlan) ([]maintenanceTarget, error) {
+	if plan.ObjectID != nil {
+		var object models.Object
+		err := s.DB.Where("is_active = ?", true).First(&object, *plan.ObjectID).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("ошибка при получении объекта плана обслуживания: %w", err)
+		}
+		return []maintenanceTarget{{Object: object}}, nil
+	}
+	if plan.EquipmentCategoryID == nil {
+		return nil, nil
+	}
+
+	var equipment []models.Equipment
+	if err := s.DB.Preload("Object").
+		Where("category_id = ? AND status = ? AND object_id IS NOT NULL", *plan.EquipmentCategoryID, models.EquipmentStatusInstalled).
+		Order("id").Find(&equipment).Error; err != nil {
+		return nil, fmt.Errorf("ошибка при получении оборудования плана обслуживания: %w", err)
+	}
+	targets := make([]maintenanceTarget, 0, len(equipment))
+	for i := range equipment {
+		if equipment[i].Object == nil || !equipment[i].Object.IsActive {
+			continue
+		}
+		targets = append(targets, maintenanceTarget{Object: *equipment[i].Object, Equipment: &equipment[i]})
+	}
+	return targets, nil
+}
+
+// targetQuery ограничивает запрос обслуживаниями плана по объекту или оборудованию
+func targetQuery(db *gorm.DB, plan *models.MaintenancePlan, target *maintenanceTarget) *gorm.DB {
+	query := db.Where("plan_id = ? AND object_id = ?", plan.ID, target.Object.ID)
+	if target.Equipment != nil {
+		return query.Where("equipment_id = ?", target.Equipment.ID)
+	}
+	return query.Where("equipment_id IS NULL")
+}
+
+// lastMaintenance определяет последнее обслуживание: выполненное по плану, отмеченное в оборудовании
+// или завершенный монтаж типа "обслуживание" на объекте. Без истории отсчет ведется от создания плана.
+func (s *MaintenanceService) lastMaintenance(plan *models.MaintenancePlan, target *maintenanceTarget) (time.Time, float64, error) {
+	var visit models.MaintenanceVisit
+	err := targetQuery(s.DB, plan, target).Where("status = ?", models.MaintenanceVisitCompleted).
+		Order("completed_at DESC").First(&visit).Error
+	if err == nil && visit.CompletedAt != nil {
+		hours := 0.0
+		if visit.CompletedEngineHours != nil {
+			hours = *visit.CompletedEngineHours
+		}
+		return *visit.CompletedAt, hours, nil
+	}
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return time.Time{}, 0, err
+	}
+
+	if target.Equipment != nil && target.Equipment.LastMaintenanceAt != nil {
+		return *target.Equipment.LastMaintenanceAt, 0, nil
+	}
+
+	var installation models.Installation
+	err = s.DB.Where("object_id = ? AND type = ? AND status = ? AND completed_at IS NOT NULL",
+		target.Object.ID, models.MaintenanceInstallationType, "completed").
+		Order("completed_at DESC").First(&installation).Error
+	if err == nil {
+		return *installation.CompletedAt, 0, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return time.Time{}, 0, err
+	}
+	return plan.CreatedAt, 0, nil
+}
+
+// generateForTarget создает обслуживание объекта, если подошел срок и нет незавершенного обслуживания
+func (s *MaintenanceService) generateForTarget(plan *models.MaintenancePlan, target *maintenanceTarget, now time.Time, result *MaintenanceRunResult) error {
+	var open int64
+	if err := targetQuery(s.DB.Model(&models.MaintenanceVisit{}), plan, target).
+		Where("status IN ?", []string{models.MaintenanceVisitScheduled, models.MaintenanceVisitUnassigned}).
+		Count(&open).Error; err != nil {
+		return err
+	}
+	if open > 0 {
+		return nil
+	}
+
+	lastAt, lastHours, err := s.lastMaintenance(plan, target)
+	if err != nil {
+		return fmt.Errorf("ошибка при определении последнего обслуживания: %w", err)
+	}
+	due := ComputeMaintenanceDue(plan, lastAt, lastHours, target.Object.EngineHours, now)
+	if !due.Triggered {
+		return nil
+	}
+
+	visit := &models.MaintenanceVisit{
+		PlanID:         plan.ID,
+		ObjectID:       target.Object.ID,
+		DueAt:          due.DueAt,
+		DueEngineHours: due.DueEngineHours,
+		Reason:         due.Reason,
+		Status:         models.MaintenanceVisitUnassigned,
+		CompanyID:      plan.CompanyID,
+	}
+	if target.Equipment != nil {
+		visit.EquipmentID = &target.Equipment.ID
+	}
+	if err := s.DB.Create(visit).Error; err != nil {
+		return fmt.Errorf("ошибка при создании планового обслуживания: %w", err)
+	}
+	return s.scheduleVisit(plan, visit, &target.Object, now, result)
+}
+
+// scheduleVisit подбирает монтажника и создает монтаж для обслуживания.
+// Если свободного монтажника нет, обслуживание остается неназначенным до следующей проверки.
+func (s *MaintenanceService) scheduleVisit(plan *models.MaintenancePlan, visit *models.MaintenanceVisit, object *models.Object, now time.Time, result *MaintenanceRunResult) error {
+	installationService := NewInstallationService(s.DB, nil)
+	installationService.Timezone = s.Timezone
+
+	candidates, err := s.slotCandidates(installationService, plan, object, visit.DueAt, now)
+	if err != nil {
+		return err
+	}
+
+	var locationID *uint
+	if object.LocationID != 0 {
+		locationID = &object.LocationID
+	}
+	description := fmt.Sprintf("Плановое обслуживание: %s", plan.Name)
+	if visit.Reason != "" {
+		description += " (" + visit.Reason + ")"
+	}
+	if visit.EquipmentID != nil {
+		description += fmt.Sprintf(", оборудование #%d", *visit.EquipmentID)
+	}
+
+	// Слоты рассчитаны заранее: если слот уже занят, пробуем следующий подходящий
+	for len(candidates) > 0 {
+		picked := PickMaintenanceSlot(candidates, visit.DueAt)
+		installation := &models.Installation{
+			Type:              models.MaintenanceInstallationType,
+			Status:            "planned",
+			Priority:          plan.Priority,
+			Description:       description,
+			ScheduledAt:       picked.Slot.Start,
+			EstimatedDuration: plan.EstimatedDuration,
+			ObjectID:          object.ID,
+			InstallerID:       picked.InstallerID,
+			LocationID:        locationID,
+			Address:           object.Address,
+			IsBillable:        true,
+			CompanyID:         plan.CompanyID,
+		}
+		if err := installationService.ScheduleInstallation(installation); err != nil {
+			log.Printf("Не удалось запланировать обслуживание %d на монтажника %d: %v", visit.ID, picked.InstallerID, err)
+			for i := range candidates {
+				if &candidates[i] == picked {
+					candidates = append(candidates[:i], candidates[i+1:]...)
+					break
+				}
+			}
+			continue
+		}
+
+		visit.InstallationID = &installation.ID
+		visit.Status = models.MaintenanceVisitScheduled
+		visit.Note = ""
+		if err := s.DB.Save(visit).Error; err != nil {
+			return fmt.Errorf("ошибка при сохранении планового обслуживания: %w", err)
+		}
+		result.Created++
+		result.Visits = append(result.Visits, *visit)
+		return nil
+	}
+
+	visit.Status = models.MaintenanceVisitUnassigned
+	visit.Note = "Нет свободных монтажников"
+	if err := s.DB.Save(visit).Error; err != nil {
+		return fmt.Errorf("ошибка при сохранении планового обслуживания: %w", err)
+	}
+	result.Unassigned++
+	result.Visits = append(result.Visits, *visit)
+	return nil
+}
+
+// slotCandidates собирает свободные слоты подходящих монтажников вокруг срока обслуживания
+func (s *MaintenanceService) slotCandidates(installationService *InstallationService, plan *models.MaintenancePlan, object *models.Object, dueAt, now time.Time) ([]MaintenanceSlotCandidate, error) {
+	var installers []models.Installer
+	if err := s.DB.Where("is_active = ? AND status = ?", true, "available").Find(&installers).Error; err != nil {
+		return nil, fmt.Errorf("ошибка при получении монтажников: %w", err)
+	}
+
+	from := dueAt.AddDate(0, 0, -maintenanceSlotWindowDays)
+	if from.Before(now) {
+		from = now
+	}
+	days := int(math.Ceil(dueAt.Sub(from).Hours()/24)) + maintenanceSlotWindowDays
+	var locationID *uint
+	if object.LocationID != 0 {
+		locationID = &object.LocationID
+	}
+
+	var candidates []MaintenanceSlotCandidate
+	for _, installer := range installers {
+		if locationID != nil && !installer.CanWorkInLocation(*locationID) {
+			continue
+		}
+		if plan.Specialization != "" && !installer.HasSpecialization(plan.Specialization) {
+			continue
+		}
+		slots, err := installationService.SuggestFreeSlots(installer.ID, from, days, plan.EstimatedDuration, locationID, 100)
+		if err != nil {
+			log.Printf("Ошибка подбора слотов монтажника %d: %v", installer.ID, err)
+			continue
+		}
+		for _, slot := range slots {
+			candidates = append(candidates, MaintenanceSlotCandidate{InstallerID: installer.ID, Slot: slot})
+		}
+	}
+	return candidates, nil
+}
+
+// syncVisits переносит в обслуживания результат созданных монтажей
+func (s *MaintenanceService) syncVisits(result *MaintenanceRunResult) error {
+	var visits []models.MaintenanceVisit
+	if err := s.DB.Where("status = ? AND installation_id IS NOT NULL", models.MaintenanceVisitScheduled).
+		Find(&visits).Error; err != nil {
+		return fmt.Errorf("ошибка при получении запланированных обслуживаний: %w", err)
+	}
+
+	tz := models.LoadTimezone(s.Timezone)
+	for i := range visits {
+		visit := &visits[i]
+		var installation models.Installation
+		err := s.DB.Unscoped().First(&installation, *visit.InstallationID).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		switch {
+		case err != nil || installation.DeletedAt.Valid || installation.Status == "cancelled":
+			visit.Status = models.MaintenanceVisitCancelled
+			result.Cancelled++
+		case installation.Status == "completed":
+			completedAt := time.Now()
+			if installation.CompletedAt != nil {
+				completedAt = *installation.CompletedAt
+			}
+			_, dueDayEnd := localDayBounds(visit.DueAt.In(tz))
+			visit.Status = models.MaintenanceVisitCompleted
+			visit.CompletedAt = &completedAt
+			visit.OnTime = completedAt.Before(dueDayEnd)
+
+			var object models.Object
+			if err := s.DB.Select("id", "engine_hours").First(&object, visit.ObjectID).Error; err == nil {
+				visit.CompletedEngineHours = object.EngineHours
+			}
+			if visit.EquipmentID != nil {
+				if err := s.DB.Model(&models.Equipment{}).Where("id = ?", *visit.EquipmentID).
+					UpdateColumn("last_maintenance_at", completedAt).Error; err != nil {
+					return fmt.Errorf("ошибка при обновлении даты обслуживания оборудования: %w", err)
+				}
+			}
+			result.Completed++
+		default:
+			continue
+		}
+
+		if err := s.DB.Save(visit).Error; err != nil {
+			return fmt.Errorf("ошибка при сохранении планового обслуживания: %w", err)
+		}
+	}
+	return nil
+}
+
+// GetCompliance возвращает соблюдение планов обслуживания по срокам в периоде [from, to)
+func (s *MaintenanceService) GetCompliance(planID *uint, from, to, now time.Time) ([]MaintenanceCompliance, error) {
+	planQuery := s.DB.Unscoped().Order("name, id")
+	visitQuery := s.DB.Where("due_at >= ? AND due_at < ?", from, to)
+	if planID != nil {
+		planQuery = planQuery.Where("id = ?", *planID)
+		visitQuery = visitQuery.Where("plan_id = ?", *planID)
+	}
+
+	var plans []models.MaintenancePlan
+	if err := planQuery.Find(&plans).Error; err != nil {
+		return nil, fmt.Errorf("ошибка при получении планов обслуживания: %w", err)
+	}
+	var visits []models.MaintenanceVisit
+	if err := visitQuery.Find(&visits).Error; err != nil {
+		return nil, fmt.Errorf("ошибка при получении обслуживаний: %w", err)
+	}
+	return SummarizeMaintenanceCompliance(plans, visits, now), nil
+}
+
+// SummarizeMaintenanceCompliance считает соблюдение сроков обслуживания по планам.
+// Планы без обслуживаний в периоде не выводятся.
+func SummarizeMaintenanceCompliance(plans []models.MaintenancePlan, visits []models.MaintenanceVisit, now time.Time) []MaintenanceCompliance {
+	byPlan := make(map[uint]*MaintenanceCompliance, len(plans))
+	for _, plan := range plans {
+		byPlan[plan.ID] = &MaintenanceCompliance{PlanID: plan.ID, PlanName: plan.Name}
+	}
+
+	for _, visit := range visits {
+		summary, ok := byPlan[visit.PlanID]
+		if !ok {
+			continue
+		}
+		summary.Total++
+		switch {
+		case visit.Status == models.MaintenanceVisitCompleted && visit.OnTime:
+			summary.CompletedOnTime++
+		case visit.Status == models.MaintenanceVisitCompleted:
+			summary.CompletedLate++
+		case visit.Status == models.MaintenanceVisitCancelled:
+			summary.Cancelled++
+		case visit.DueAt.Before(now):
+			summary.Overdue++
+		case visit.Status == models.MaintenanceVisitUnassigned:
+			summary.Unassigned++
+		default:
+			summary.Upcoming++
+		}
+	}
+
+	result := make([]MaintenanceCompliance, 0, len(byPlan))
+	for _, plan := range plans {
+		summary := byPlan[plan.ID]
+		if summary.Total == 0 {
+			continue
+		}
+		summary.CompliancePct = 100
+		if measured := summary.CompletedOnTime + summary.CompletedLate + summary.Overdue; measured > 0 {
+			summary.CompliancePct = math.Round(float64(summary.CompletedOnTime)/float64(measured)*1000) / 10
+		}
+		result = append(result, *summary)
+	}
+	return result
+}
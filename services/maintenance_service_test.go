@@ -0,0 +1,209 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"backend_axenta/models"
+)
+
+func float64Ptr(v float64) *float64 { return &v }
+
+func TestComputeMaintenanceDue(t *testing.T) {
+	now := time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC)
+	last := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+
+	// Срок через полгода, монтаж создается за 14 дней
+	plan := &models.MaintenancePlan{IntervalMonths: 6, LeadDays: 14}
+	due := ComputeMaintenanceDue(plan, last, 0, nil, now)
+	assert.Equal(t, time.Date(2024, 7, 1, 9, 0, 0, 0, time.UTC), due.DueAt)
+	assert.False(t, due.Triggered)
+	due = ComputeMaintenanceDue(plan, last, 0, nil, now.AddDate(0, 0, 17))
+	assert.True(t, due.Triggered)
+
+	// По моточасам: до срока осталось меньше LeadEngineHours
+	plan = &models.MaintenancePlan{IntervalMonths: 12, IntervalEngineHours: 500, LeadDays: 10, LeadEngineHours: 50}
+	due = ComputeMaintenanceDue(plan, last, 1000, float64Ptr(1400), now)
+	assert.False(t, due.Triggered)
+	require.NotNil(t, due.DueEngineHours)
+	assert.Equal(t, 1500.0, *due.DueEngineHours)
+
+	due = ComputeMaintenanceDue(plan, last, 1000, float64Ptr(1460), now)
+	assert.True(t, due.Triggered)
+	assert.Equal(t, now.AddDate(0, 0, 10), due.DueAt)
+	assert.Contains(t, due.Reason, "моточасов")
+
+	// Наработка превышена - обслуживание нужно сейчас
+	due = ComputeMaintenanceDue(plan, last, 1000, float64Ptr(1520), now)
+	assert.Equal(t, now, due.DueAt)
+
+	// Срок по времени наступает раньше срока по моточасам
+	due = ComputeMaintenanceDue(plan, last.AddDate(0, -7, 0), 1000, float64Ptr(1460), now)
+	assert.True(t, due.Triggered)
+	assert.Equal(t, time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC), due.DueAt)
+	assert.Contains(t, due.Reason, "мес.")
+}
+
+func TestPickMaintenanceSlot(t *testing.T) {
+	due := time.Date(2024, 7, 1, 12, 0, 0, 0, time.UTC)
+	slot := func(installerID uint, start time.Time) MaintenanceSlotCandidate {
+		return MaintenanceSlotCandidate{InstallerID: installerID, Slot: FreeSlot{Start: start, End: start.Add(time.Hour)}}
+	}
+
+	assert.Nil(t, PickMaintenanceSlot(nil, due))
+
+	// Самый поздний слот до срока
+	picked := PickMaintenanceSlot([]MaintenanceSlotCandidate{
+		slot(1, due.AddDate(0, 0, -5)),
+		slot(2, due.Add(-2*time.Hour)),
+		slot(3, due.AddDate(0, 0, 1)),
+	}, due)
+	require.NotNil(t, picked)
+	assert.Equal(t, uint(2), picked.InstallerID)
+
+	// До срока слотов нет - ближайший после срока
+	picked = PickMaintenanceSlot([]MaintenanceSlotCandidate{
+		slot(1, due.AddDate(0, 0, 3)),
+		slot(2, due.AddDate(0, 0, 1)),
+	}, due)
+	assert.Equal(t, uint(2), picked.InstallerID)
+}
+
+func TestMaintenanceService_GenerateDueMaintenance(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(
+		&models.Object{},
+		&models.Equipment{},
+		&models.EquipmentCategory{},
+		&models.Installation{},
+		&models.Installer{},
+		&models.MaintenancePlan{},
+		&models.MaintenanceVisit{},
+	))
+
+	truck := models.Object{Name: "КамАЗ А001АА", IMEI: "356938035643801"}
+	require.NoError(t, db.Create(&truck).Error)
+	loader := models.Object{Name: "Погрузчик", IMEI: "356938035643802", EngineHours: float64Ptr(520)}
+	require.NoError(t, db.Create(&loader).Error)
+
+	category := models.EquipmentCategory{Name: "Датчики топлива", Code: "DUT"}
+	require.NoError(t, db.Create(&category).Error)
+	sensor := models.Equipment{Type: "Датчик топлива", Model: "ДУТ-Е", SerialNumber: "DUT-1", IMEI: "DUT-1", QRCode: "DUT-1",
+		Status: models.EquipmentStatusInstalled, ObjectID: &loader.ID, CategoryID: &category.ID}
+	require.NoError(t, db.Create(&sensor).Error)
+
+	service := NewMaintenanceService(db)
+	_, err = service.CreatePlan(MaintenancePlanRequest{Name: "Без интервала", ObjectID: &truck.ID})
+	require.Error(t, err)
+	_, err = service.CreatePlan(MaintenancePlanRequest{Name: "Без области", IntervalMonths: 6})
+	require.Error(t, err)
+
+	truckPlan, err := service.CreatePlan(MaintenancePlanRequest{Name: "ТО трекера", ObjectID: &truck.ID, IntervalMonths: 6})
+	require.NoError(t, err)
+	assert.Equal(t, DefaultMaintenanceLeadDays, truckPlan.LeadDays)
+	assert.Equal(t, DefaultMaintenanceDuration, truckPlan.EstimatedDuration)
+	sensorPlan, err := service.CreatePlan(MaintenancePlanRequest{Name: "Калибровка ДУТ", EquipmentCategoryID: &category.ID, IntervalEngineHours: 500})
+	require.NoError(t, err)
+
+	// План объекта создан полгода назад без 10 дней: срок через 10 дней, попадает в упреждение
+	now := time.Now()
+	require.NoError(t, db.Model(truckPlan).UpdateColumn("created_at", now.AddDate(0, -6, 10)).Error)
+
+	result, err := service.GenerateDueMaintenance(now)
+	require.NoError(t, err)
+	assert.Equal(t, 0, result.Created)
+	assert.Equal(t, 2, result.Unassigned) // Свободных монтажников нет
+
+	visits, err := service.GetVisits(&sensorPlan.ID, nil, "")
+	require.NoError(t, err)
+	require.Len(t, visits, 1)
+	assert.Equal(t, loader.ID, visits[0].ObjectID)
+	assert.Equal(t, sensor.ID, *visits[0].EquipmentID)
+	assert.Equal(t, 500.0, *visits[0].DueEngineHours)
+	assert.Equal(t, "Нет свободных монтажников", visits[0].Note)
+
+	// Повторная проверка не создает дубликатов
+	_, err = service.GenerateDueMaintenance(now)
+	require.NoError(t, err)
+	var count int64
+	db.Model(&models.MaintenanceVisit{}).Count(&count)
+	assert.Equal(t, int64(2), count)
+
+	// Монтажник назначен вручную, монтаж выполнен в срок
+	visits, err = service.GetVisits(&truckPlan.ID, nil, "")
+	require.NoError(t, err)
+	require.Len(t, visits, 1)
+	completedAt := now.Add(-time.Hour)
+	installation := models.Installation{Type: models.MaintenanceInstallationType, Status: "completed", ScheduledAt: completedAt,
+		CompletedAt: &completedAt, ObjectID: truck.ID, InstallerID: 1}
+	require.NoError(t, db.Create(&installation).Error)
+	require.NoError(t, db.Model(&visits[0]).Updates(map[string]interface{}{
+		"status": models.MaintenanceVisitScheduled, "installation_id": installation.ID,
+	}).Error)
+
+	// Монтаж по датчику отменен
+	visits, err = service.GetVisits(&sensorPlan.ID, nil, "")
+	require.NoError(t, err)
+	cancelled := models.Installation{Type: models.MaintenanceInstallationType, Status: "cancelled", ScheduledAt: now, ObjectID: loader.ID, InstallerID: 1}
+	require.NoError(t, db.Create(&cancelled).Error)
+	require.NoError(t, db.Model(&visits[0]).Updates(map[string]interface{}{
+		"status": models.MaintenanceVisitScheduled, "installation_id": cancelled.ID,
+	}).Error)
+
+	result, err = service.GenerateDueMaintenance(now)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Completed)
+	assert.Equal(t, 1, result.Cancelled)
+	assert.Equal(t, 1, result.Unassigned) // Вместо отмененного создано новое обслуживание датчика
+
+	visits, err = service.GetVisits(&truckPlan.ID, nil, "")
+	require.NoError(t, err)
+	require.Len(t, visits, 1) // Следующий срок - через полгода после выполнения
+	assert.Equal(t, models.MaintenanceVisitCompleted, visits[0].Status)
+	assert.True(t, visits[0].OnTime)
+
+	// Обслуживание датчика по моточасам отмечается в оборудовании
+	visits, err = service.GetVisits(&sensorPlan.ID, nil, models.MaintenanceVisitUnassigned)
+	require.NoError(t, err)
+	require.Len(t, visits, 1)
+	sensorDone := models.Installation{Type: models.MaintenanceInstallationType, Status: "completed", ScheduledAt: now,
+		CompletedAt: &now, ObjectID: loader.ID, InstallerID: 1}
+	require.NoError(t, db.Create(&sensorDone).Error)
+	require.NoError(t, db.Model(&visits[0]).Updates(map[string]interface{}{
+		"status": models.MaintenanceVisitScheduled, "installation_id": sensorDone.ID, "due_at": now.AddDate(0, 0, -3),
+	}).Error)
+	_, err = service.GenerateDueMaintenance(now)
+	require.NoError(t, err)
+
+	var storedSensor models.Equipment
+	require.NoError(t, db.First(&storedSensor, sensor.ID).Error)
+	require.NotNil(t, storedSensor.LastMaintenanceAt)
+	visits, err = service.GetVisits(&sensorPlan.ID, nil, models.MaintenanceVisitCompleted)
+	require.NoError(t, err)
+	require.Len(t, visits, 1)
+	assert.False(t, visits[0].OnTime)
+	assert.Equal(t, 520.0, *visits[0].CompletedEngineHours)
+
+	compliance, err := service.GetCompliance(nil, now.AddDate(0, -1, 0), now.AddDate(0, 1, 0), now)
+	require.NoError(t, err)
+	require.Len(t, compliance, 2)
+	byPlan := map[uint]MaintenanceCompliance{}
+	for _, item := range compliance {
+		byPlan[item.PlanID] = item
+	}
+	assert.Equal(t, 100.0, byPlan[truckPlan.ID].CompliancePct)
+	sensorCompliance := byPlan[sensorPlan.ID]
+	assert.Equal(t, 2, sensorCompliance.Total)
+	assert.Equal(t, 1, sensorCompliance.Cancelled)
+	assert.Equal(t, 1, sensorCompliance.CompletedLate)
+	assert.Equal(t, 0.0, sensorCompliance.CompliancePct)
+}
@@ -0,0 +1,431 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"backend_axenta/models"
+)
+
+// ObjectBulkService представляет сервис массовых операций над объектами
+type ObjectBulkService struct {
+	DB *gorm.DB
+}
+
+// NewObjectBulkService создает новый экземпляр ObjectBulkService.
+// Записи аудита сохраняются в базе данных компании вместе с изменением объекта.
+func NewObjectBulkService(db *gorm.DB) *ObjectBulkService {
+	return &ObjectBulkService{DB: db}
+}
+
+// ObjectBulkRequest запрос массовой операции: объекты задаются списком ID или фильтром
+type ObjectBulkRequest struct {
+	Action    string        `json:"action" binding:"required"`
+	ObjectIDs []uint        `json:"object_ids"`
+	Filter    *ObjectFilter `json:"filter"`
+
+	ContractID *uint      `json:"contract_id"` // move_contract
+	DeleteAt   *time.Time `json:"delete_at"`   // schedule_delete
+	Tags       []string   `json:"tags"`        // add_tags, remove_tags
+	TemplateID *uint      `json:"template_id"` // apply_template
+}
+
+// objectBulkActions допустимые массовые операции
+var objectBulkActions = map[string]bool{
+	models.ObjectBulkActionActivate:       true,
+	models.ObjectBulkActionDeactivate:     true,
+	models.ObjectBulkActionMoveContract:   true,
+	models.ObjectBulkActionScheduleDelete: true,
+	models.ObjectBulkActionAddTags:        true,
+	models.ObjectBulkActionRemoveTags:     true,
+	models.ObjectBulkActionApplyTemplate:  true,
+}
+
+// objectBulkChange изменение одного объекта массовой операцией
+type objectBulkChange struct {
+	updates   map[string]interface{}
+	old       map[string]interface{}
+	contracts []uint // Договоры, стоимость которых нужно пересчитать
+}
+
+// CreateJob проверяет запрос, определяет объекты и создает задание массовой операции.
+// Возвращает задание и ID объектов, которые нужно передать в RunJob.
+func (s *ObjectBulkService) CreateJob(req ObjectBulkRequest, userID *uint) (*models.ObjectBulkJob, []uint, error) {
+	if !objectBulkActions[req.Action] {
+		return nil, nil, fmt.Errorf("неизвестная операция: %s", req.Action)
+	}
+	req.Tags = normalizeObjectTags(req.Tags)
+
+	switch req.Action {
+	case models.ObjectBulkActionMoveContract:
+		if req.ContractID == nil {
+			return nil, nil, errors.New("не указан договор для переноса объектов")
+		}
+		var contract models.Contract
+		if err := s.DB.First(&contract, *req.ContractID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, nil, errors.New("договор не найден")
+			}
+			return nil, nil, err
+		}
+	case models.ObjectBulkActionScheduleDelete:
+		if req.DeleteAt == nil {
+			return nil, nil, errors.New("не указана дата планового удаления")
+		}
+		if req.DeleteAt.Before(time.Now()) {
+			return nil, nil, errors.New("дата планового удаления должна быть в будущем")
+		}
+	case models.ObjectBulkActionAddTags, models.ObjectBulkActionRemoveTags:
+		if len(req.Tags) == 0 {
+			return nil, nil, errors.New("не указаны теги")
+		}
+	case models.ObjectBulkActionApplyTemplate:
+		if req.TemplateID == nil {
+			return nil, nil, errors.New("не указан шаблон")
+		}
+		var template models.ObjectTemplate
+		if err := s.DB.First(&template, *req.TemplateID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, nil, errors.New("шаблон объекта не найден")
+			}
+			return nil, nil, err
+		}
+		if !json.Valid([]byte(orEmptyJSON(template.DefaultSettings))) {
+			return nil, nil, errors.New("настройки по умолчанию шаблона содержат некорректный JSON")
+		}
+	}
+
+	ids, err := s.resolveObjectIDs(req)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	parameters, _ := json.Marshal(req)
+	job := &models.ObjectBulkJob{
+		Action:     req.Action,
+		Parameters: string(parameters),
+		Status:     models.ObjectBulkJobStatusPending,
+		Total:      len(ids),
+		UserID:     userID,
+	}
+	if err := s.DB.Create(job).Error; err != nil {
+		return nil, nil, fmt.Errorf("ошибка при создании задания: %w", err)
+	}
+	return job, ids, nil
+}
+
+// resolveObjectIDs возвращает ID объектов операции: переданный список без повторов
+// или все объекты, подходящие под фильтр
+func (s *ObjectBulkService) resolveObjectIDs(req ObjectBulkRequest) ([]uint, error) {
+	if len(req.ObjectIDs) > 0 {
+		seen := make(map[uint]bool, len(req.ObjectIDs))
+		var ids []uint
+		for _, id := range req.ObjectIDs {
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+		return ids, nil
+	}
+
+	// Пустой фильтр затронул бы все объекты компании
+	if req.Filter == nil || req.Filter.IsEmpty() {
+		return nil, errors.New("не указаны объекты: передайте object_ids или filter")
+	}
+	var ids []uint
+	if err := req.Filter.Apply(s.DB.Model(&models.Object{})).Order("id").Pluck("id", &ids).Error; err != nil {
+		return nil, fmt.Errorf("ошибка при выборе объектов: %w", err)
+	}
+	if len(ids) == 0 {
+		return nil, errors.New("под фильтр не подходит ни один объект")
+	}
+	return ids, nil
+}
+
+// RunJob выполняет задание: каждый объект изменяется в отдельной транзакции, ошибки по объектам
+// не прерывают выполнение и сохраняются в задании. Для каждого объекта пишется запись аудита,
+// стоимость каждого затронутого договора пересчитывается один раз в конце.
+func (s *ObjectBulkService) RunJob(jobID uint, objectIDs []uint) error {
+	var job models.ObjectBulkJob
+	if err := s.DB.First(&job, jobID).Error; err != nil {
+		return err
+	}
+	var req ObjectBulkRequest
+	if err := json.Unmarshal([]byte(job.Parameters), &req); err != nil {
+		return s.failJob(&job, fmt.Errorf("некорректные параметры задания: %w", err))
+	}
+
+	var template *models.ObjectTemplate
+	if req.Action == models.ObjectBulkActionApplyTemplate {
+		template = &models.ObjectTemplate{}
+		if err := s.DB.First(template, *req.TemplateID).Error; err != nil {
+			return s.failJob(&job, fmt.Errorf("шаблон объекта не найден: %w", err))
+		}
+	}
+
+	now := time.Now()
+	job.Status = models.ObjectBulkJobStatusRunning
+	job.StartedAt = &now
+	if err := s.DB.Model(&job).Updates(map[string]interface{}{"status": job.Status, "started_at": now}).Error; err != nil {
+		return err
+	}
+
+	var contracts []uint
+	seenContracts := map[uint]bool{}
+	templateUsage := 0
+	for _, objectID := range objectIDs {
+		change, err := s.applyToObject(&job, req, template, objectID)
+		job.Processed++
+		if err != nil {
+			job.Failed++
+			s.DB.Create(&models.ObjectBulkJobError{JobID: job.ID, ObjectID: objectID, Message: err.Error()})
+		} else {
+			job.Succeeded++
+			for _, contractID := range change.contracts {
+				if !seenContracts[contractID] {
+					seenContracts[contractID] = true
+					contracts = append(contracts, contractID)
+				}
+			}
+			if _, ok := change.updates["template_id"]; ok {
+				templateUsage++
+			}
+		}
+		s.DB.Model(&job).Updates(map[string]interface{}{
+			"processed": job.Processed,
+			"succeeded": job.Succeeded,
+			"failed":    job.Failed,
+		})
+	}
+
+	var problems []string
+	if template != nil && templateUsage > 0 {
+		if err := s.DB.Model(template).UpdateColumn("usage_count", gorm.Expr("usage_count + ?", templateUsage)).Error; err != nil {
+			problems = append(problems, fmt.Sprintf("счетчик использования шаблона не обновлен: %v", err))
+		}
+	}
+	for _, contractID := range contracts {
+		if err := ApplyContractTariff(s.DB, contractID); err != nil {
+			problems = append(problems, fmt.Sprintf("стоимость договора %d не пересчитана: %v", contractID, err))
+		}
+	}
+
+	switch {
+	case job.Failed == 0 && len(problems) == 0:
+		job.Status = models.ObjectBulkJobStatusCompleted
+	case job.Succeeded == 0 && job.Total > 0:
+		job.Status = models.ObjectBulkJobStatusFailed
+	default:
+		job.Status = models.ObjectBulkJobStatusCompletedWithErrors
+	}
+	completedAt := time.Now()
+	job.CompletedAt = &completedAt
+	job.ErrorMsg = strings.Join(problems, "; ")
+	return s.DB.Model(&job).Updates(map[string]interface{}{
+		"status":       job.Status,
+		"completed_at": completedAt,
+		"error_msg":    job.ErrorMsg,
+	}).Error
+}
+
+// failJob завершает задание с ошибкой, не относящейся к отдельному объекту
+func (s *ObjectBulkService) failJob(job *models.ObjectBulkJob, err error) error {
+	now := time.Now()
+	s.DB.Model(job).Updates(map[string]interface{}{
+		"status":       models.ObjectBulkJobStatusFailed,
+		"error_msg":    err.Error(),
+		"completed_at": now,
+	})
+	return err
+}
+
+// applyToObject изменяет один объект и пишет запись аудита в той же транзакции
+func (s *ObjectBulkService) applyToObject(job *models.ObjectBulkJob, req ObjectBulkRequest, template *models.ObjectTemplate, objectID uint) (*objectBulkChange, error) {
+	var change *objectBulkChange
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		var object models.Object
+		if err := tx.Clauses(lockingForUpdate).First(&object, objectID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("объект не найден")
+			}
+			return err
+		}
+
+		var err error
+		change, err = buildObjectBulkChange(&object, req, template)
+		if err != nil {
+			return err
+		}
+		if len(change.updates) > 0 {
+			if err := tx.Model(&object).Updates(change.updates).Error; err != nil {
+				return fmt.Errorf("ошибка сохранения объекта: %w", err)
+			}
+		}
+
+		resourceID := object.ID
+		return NewAuditService(tx, nil).LogSuccess(AuditContext{
+			UserID:     job.UserID,
+			Action:     ActionObjectUpdate,
+			Resource:   "object",
+			ResourceID: &resourceID,
+			OldValues:  change.old,
+			NewValues:  change.updates,
+			Details: map[string]interface{}{
+				"bulk_job_id": job.ID,
+				"bulk_action": job.Action,
+			},
+		})
+	})
+	return change, err
+}
+
+// buildObjectBulkChange вычисляет изменение объекта для массовой операции
+func buildObjectBulkChange(object *models.Object, req ObjectBulkRequest, template *models.ObjectTemplate) (*objectBulkChange, error) {
+	change := &objectBulkChange{updates: map[string]interface{}{}, old: map[string]interface{}{}}
+	set := func(column string, oldValue, newValue interface{}) {
+		change.old[column] = oldValue
+		change.updates[column] = newValue
+	}
+
+	switch req.Action {
+	case models.ObjectBulkActionActivate, models.ObjectBulkActionDeactivate:
+		active := req.Action == models.ObjectBulkActionActivate
+		if object.ScheduledDeleteAt != nil && active {
+			return nil, errors.New("объект запланирован к удалению, сначала отмените удаление")
+		}
+		status := "inactive"
+		if active {
+			status = "active"
+		}
+		if object.IsActive != active || object.Status != status {
+			set("is_active", object.IsActive, active)
+			set("status", object.Status, status)
+			change.contracts = []uint{object.ContractID}
+		}
+
+	case models.ObjectBulkActionMoveContract:
+		if object.ContractID != *req.ContractID {
+			set("contract_id", object.ContractID, *req.ContractID)
+			change.contracts = []uint{object.ContractID, *req.ContractID}
+		}
+
+	case models.ObjectBulkActionScheduleDelete:
+		set("scheduled_delete_at", object.ScheduledDeleteAt, *req.DeleteAt)
+		set("status", object.Status, "scheduled_delete")
+		if object.IsActive {
+			set("is_active", true, false)
+			change.contracts = []uint{object.ContractID}
+		}
+
+	case models.ObjectBulkActionAddTags, models.ObjectBulkActionRemoveTags:
+		var tags []string
+		if req.Action == models.ObjectBulkActionAddTags {
+			tags = mergeObjectTags(object.Tags, req.Tags, nil)
+		} else {
+			tags = mergeObjectTags(object.Tags, nil, req.Tags)
+		}
+		if strings.Join(tags, "\x00") != strings.Join(object.Tags, "\x00") {
+			set("tags", object.Tags, tags)
+		}
+
+	case models.ObjectBulkActionApplyTemplate:
+		settings, err := applyTemplateSettings(object.Settings, template.DefaultSettings)
+		if err != nil {
+			return nil, err
+		}
+		if object.TemplateID == nil || *object.TemplateID != template.ID {
+			set("template_id", object.TemplateID, template.ID)
+		}
+		if settings != object.Settings {
+			set("settings", object.Settings, settings)
+		}
+	}
+	return change, nil
+}
+
+// normalizeObjectTags убирает пустые и повторяющиеся теги
+func normalizeObjectTags(tags []string) []string {
+	return mergeObjectTags(nil, tags, nil)
+}
+
+// mergeObjectTags добавляет и удаляет теги объекта, сохраняя порядок и без повторов
+func mergeObjectTags(current, add, remove []string) []string {
+	removed := make(map[string]bool, len(remove))
+	for _, tag := range remove {
+		removed[strings.TrimSpace(tag)] = true
+	}
+	seen := map[string]bool{}
+	result := []string{}
+	for _, list := range [][]string{current, add} {
+		for _, tag := range list {
+			tag = strings.TrimSpace(tag)
+			if tag == "" || seen[tag] || removed[tag] {
+				continue
+			}
+			seen[tag] = true
+			result = append(result, tag)
+		}
+	}
+	return result
+}
+
+// applyTemplateSettings дополняет настройки объекта настройками по умолчанию шаблона;
+// при совпадении ключей применяется значение шаблона
+func applyTemplateSettings(objectSettings, defaultSettings string) (string, error) {
+	settings := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(orEmptyJSON(objectSettings)), &settings); err != nil {
+		return "", errors.New("настройки объекта содержат некорректный JSON")
+	}
+	defaults := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(orEmptyJSON(defaultSettings)), &defaults); err != nil {
+		return "", errors.New("настройки по умолчанию шаблона содержат некорректный JSON")
+	}
+	if len(defaults) == 0 {
+		return objectSettings, nil
+	}
+	for key, value := range defaults {
+		settings[key] = value
+	}
+	data, err := json.Marshal(settings)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// orEmptyJSON заменяет пустую строку пустым JSON объектом
+func orEmptyJSON(value string) string {
+	if strings.TrimSpace(value) == "" {
+		return "{}"
+	}
+	return value
+}
+
+// GetJob возвращает задание массовой операции с ошибками по объектам
+func (s *ObjectBulkService) GetJob(id uint) (*models.ObjectBulkJob, error) {
+	var job models.ObjectBulkJob
+	if err := s.DB.Preload("Errors", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id")
+	}).First(&job, id).Error; err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// GetJobs возвращает последние задания массовых операций
+func (s *ObjectBulkService) GetJobs(limit int) ([]models.ObjectBulkJob, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	var jobs []models.ObjectBulkJob
+	if err := s.DB.Order("id DESC").Limit(limit).Find(&jobs).Error; err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
@@ -0,0 +1,165 @@
+package services
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"backend_axenta/models"
+)
+
+func setupObjectBulkTestDB(t *testing.T) (*gorm.DB, models.Contract, models.Contract, []models.Object) {
+	db, contract := setupObjectImportTestDB(t)
+	require.NoError(t, db.AutoMigrate(&models.ObjectBulkJob{}, &models.ObjectBulkJobError{}, &models.AuditLog{}))
+
+	target := models.Contract{
+		Number:       "Д-2",
+		Title:        "Мониторинг спецтехники",
+		ClientName:   "ООО Стройка",
+		StartDate:    time.Now(),
+		EndDate:      time.Now().AddDate(1, 0, 0),
+		TariffPlanID: contract.TariffPlanID,
+		Status:       "active",
+		IsActive:     true,
+	}
+	require.NoError(t, db.Create(&target).Error)
+
+	objects := []models.Object{
+		{Name: "КамАЗ", Type: "vehicle", IMEI: "356938035643801", ContractID: contract.ID, Status: "active", IsActive: true},
+		{Name: "ГАЗель", Type: "vehicle", IMEI: "356938035643802", ContractID: contract.ID, Status: "active", IsActive: true, Settings: `{"speed_limit":90}`},
+		{Name: "Генератор", Type: "equipment", IMEI: "356938035643803", ContractID: contract.ID, Status: "active", IsActive: true, Settings: "не json"},
+	}
+	require.NoError(t, db.Create(&objects).Error)
+	return db, contract, target, objects
+}
+
+func TestObjectBulkService_MoveAndDeactivate(t *testing.T) {
+	db, contract, target, objects := setupObjectBulkTestDB(t)
+	service := NewObjectBulkService(db)
+	userID := uint(5)
+
+	_, _, err := service.CreateJob(ObjectBulkRequest{Action: "archive", ObjectIDs: []uint{objects[0].ID}}, nil)
+	require.Error(t, err)
+	_, _, err = service.CreateJob(ObjectBulkRequest{Action: models.ObjectBulkActionDeactivate}, nil)
+	require.Error(t, err)
+	_, _, err = service.CreateJob(ObjectBulkRequest{Action: models.ObjectBulkActionMoveContract, ObjectIDs: []uint{objects[0].ID}}, nil)
+	require.Error(t, err)
+
+	// Перенос двух объектов и несуществующего ID: частичная ошибка
+	job, ids, err := service.CreateJob(ObjectBulkRequest{
+		Action:     models.ObjectBulkActionMoveContract,
+		ObjectIDs:  []uint{objects[0].ID, objects[1].ID, objects[0].ID, 999},
+		ContractID: &target.ID,
+	}, &userID)
+	require.NoError(t, err)
+	assert.Equal(t, models.ObjectBulkJobStatusPending, job.Status)
+	assert.Equal(t, 3, job.Total)
+	require.NoError(t, service.RunJob(job.ID, ids))
+
+	job, err = service.GetJob(job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.ObjectBulkJobStatusCompletedWithErrors, job.Status)
+	assert.Equal(t, 3, job.Processed)
+	assert.Equal(t, 2, job.Succeeded)
+	assert.Equal(t, 1, job.Failed)
+	require.Len(t, job.Errors, 1)
+	assert.Equal(t, uint(999), job.Errors[0].ObjectID)
+	assert.Equal(t, "объект не найден", job.Errors[0].Message)
+	assert.NotNil(t, job.CompletedAt)
+
+	var moved int64
+	db.Model(&models.Object{}).Where("contract_id = ?", target.ID).Count(&moved)
+	assert.Equal(t, int64(2), moved)
+
+	// Стоимость пересчитана для старого и нового договора
+	require.NoError(t, db.First(&contract, contract.ID).Error)
+	require.NoError(t, db.First(&target, target.ID).Error)
+	assert.True(t, decimal.NewFromInt(1000).Equal(contract.TotalAmount), contract.TotalAmount.String())
+	assert.True(t, decimal.NewFromInt(2000).Equal(target.TotalAmount), target.TotalAmount.String())
+
+	// Одна запись аудита на каждый измененный объект
+	var audit []models.AuditLog
+	require.NoError(t, db.Order("id").Find(&audit).Error)
+	require.Len(t, audit, 2)
+	assert.Equal(t, string(ActionObjectUpdate), audit[0].Action)
+	require.NotNil(t, audit[0].ResourceID)
+	assert.Equal(t, objects[0].ID, *audit[0].ResourceID)
+	require.NotNil(t, audit[0].UserID)
+	assert.Equal(t, userID, *audit[0].UserID)
+	var details map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(audit[0].Details), &details))
+	assert.Equal(t, models.ObjectBulkActionMoveContract, details["bulk_action"])
+
+	// Деактивация по фильтру
+	job, ids, err = service.CreateJob(ObjectBulkRequest{
+		Action: models.ObjectBulkActionDeactivate,
+		Filter: &ObjectFilter{ContractID: &target.ID},
+	}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []uint{objects[0].ID, objects[1].ID}, ids)
+	require.NoError(t, service.RunJob(job.ID, ids))
+
+	job, err = service.GetJob(job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.ObjectBulkJobStatusCompleted, job.Status)
+	var inactive int64
+	db.Model(&models.Object{}).Where("contract_id = ? AND is_active = ? AND status = ?", target.ID, false, "inactive").Count(&inactive)
+	assert.Equal(t, int64(2), inactive)
+	require.NoError(t, db.First(&target, target.ID).Error)
+	assert.True(t, decimal.NewFromInt(1000).Equal(target.TotalAmount), target.TotalAmount.String())
+
+	_, _, err = service.CreateJob(ObjectBulkRequest{Action: models.ObjectBulkActionActivate, Filter: &ObjectFilter{Status: "maintenance"}}, nil)
+	assert.EqualError(t, err, "под фильтр не подходит ни один объект")
+
+	jobs, err := service.GetJobs(0)
+	require.NoError(t, err)
+	assert.Len(t, jobs, 2)
+}
+
+func TestObjectBulkService_ApplyTemplate(t *testing.T) {
+	db, _, _, objects := setupObjectBulkTestDB(t)
+	service := NewObjectBulkService(db)
+
+	template := models.ObjectTemplate{Name: "Спецтехника", Category: "vehicle", DefaultSettings: `{"speed_limit":60,"track_interval":30}`, IsActive: true}
+	require.NoError(t, db.Create(&template).Error)
+
+	job, ids, err := service.CreateJob(ObjectBulkRequest{
+		Action:     models.ObjectBulkActionApplyTemplate,
+		ObjectIDs:  []uint{objects[0].ID, objects[1].ID, objects[2].ID},
+		TemplateID: &template.ID,
+	}, nil)
+	require.NoError(t, err)
+	require.NoError(t, service.RunJob(job.ID, ids))
+
+	job, err = service.GetJob(job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, job.Succeeded)
+	require.Len(t, job.Errors, 1)
+	assert.Equal(t, objects[2].ID, job.Errors[0].ObjectID)
+	assert.Equal(t, "настройки объекта содержат некорректный JSON", job.Errors[0].Message)
+
+	var updated models.Object
+	require.NoError(t, db.First(&updated, objects[1].ID).Error)
+	require.NotNil(t, updated.TemplateID)
+	assert.Equal(t, template.ID, *updated.TemplateID)
+	assert.JSONEq(t, `{"speed_limit":60,"track_interval":30}`, updated.Settings)
+
+	// Объект с ошибкой не изменен
+	var failed models.Object
+	require.NoError(t, db.First(&failed, objects[2].ID).Error)
+	assert.Nil(t, failed.TemplateID)
+
+	require.NoError(t, db.First(&template, template.ID).Error)
+	assert.Equal(t, 2, template.UsageCount)
+}
+
+func TestMergeObjectTags(t *testing.T) {
+	assert.Equal(t, []string{"груз", "север", "юг"}, mergeObjectTags([]string{"груз", "север"}, []string{" юг ", "груз", ""}, nil))
+	assert.Equal(t, []string{"север"}, mergeObjectTags([]string{"груз", "север"}, nil, []string{"груз", "запад"}))
+	assert.Empty(t, normalizeObjectTags([]string{" ", ""}))
+}
@@ -0,0 +1,542 @@
+package services
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+	"gorm.io/gorm"
+
+	"backend_axenta/models"
+)
+
+// ErrObjectImportInvalid в файле импорта есть строки с ошибками
+var ErrObjectImportInvalid = errors.New("в файле есть строки с ошибками, объекты не созданы")
+
+// objectImportBatchSize количество объектов в одном INSERT при импорте
+const objectImportBatchSize = 100
+
+// ObjectImportService представляет сервис массового импорта и выгрузки объектов
+type ObjectImportService struct {
+	DB *gorm.DB
+}
+
+// NewObjectImportService создает новый экземпляр ObjectImportService
+func NewObjectImportService(db *gorm.DB) *ObjectImportService {
+	return &ObjectImportService{DB: db}
+}
+
+// ObjectImportDefaults значения для строк, в которых соответствующая колонка не заполнена
+type ObjectImportDefaults struct {
+	ContractID *uint  `form:"contract_id"`
+	TemplateID *uint  `form:"template_id"`
+	LocationID *uint  `form:"location_id"`
+	Type       string `form:"type"`
+}
+
+// ObjectImportRow строка файла импорта объектов
+type ObjectImportRow struct {
+	LineNumber   int      `json:"line_number"`
+	Name         string   `json:"name"`
+	Type         string   `json:"type"`
+	Description  string   `json:"description,omitempty"`
+	IMEI         string   `json:"imei"`
+	PhoneNumber  string   `json:"phone_number,omitempty"`
+	SerialNumber string   `json:"serial_number,omitempty"`
+	VIN          string   `json:"vin,omitempty"`
+	LicensePlate string   `json:"license_plate,omitempty"`
+	Address      string   `json:"address,omitempty"`
+	Latitude     *float64 `json:"latitude,omitempty"`
+	Longitude    *float64 `json:"longitude,omitempty"`
+	Status       string   `json:"status"`
+	Notes        string   `json:"notes,omitempty"`
+	ExternalID   string   `json:"external_id,omitempty"`
+
+	// Ссылки из файла: ID или номер договора, ID или название шаблона, ID или город локации
+	Contract string `json:"contract,omitempty"`
+	Template string `json:"template,omitempty"`
+	Location string `json:"location,omitempty"`
+
+	// Найденные связи
+	ContractID uint  `json:"contract_id"`
+	TemplateID *uint `json:"template_id"`
+	LocationID uint  `json:"location_id"`
+
+	Errors []string `json:"errors,omitempty"`
+}
+
+// ObjectImportResult результат проверки или импорта объектов
+type ObjectImportResult struct {
+	DryRun    bool              `json:"dry_run"`
+	Total     int               `json:"total"`
+	Valid     int               `json:"valid"`
+	Invalid   int               `json:"invalid"`
+	Created   int               `json:"created"`
+	Contracts []uint            `json:"contracts"` // Договоры, стоимость которых пересчитана
+	Rows      []ObjectImportRow `json:"rows"`
+}
+
+// objectImportColumns допустимые названия колонок файла импорта объектов
+var objectImportColumns = map[string][]string{
+	"name":          {"name", "название", "наименование", "объект"},
+	"type":          {"type", "тип"},
+	"description":   {"description", "описание"},
+	"imei":          {"imei", "имей"},
+	"phone_number":  {"phone_number", "phone", "телефон", "номер телефона", "номер sim"},
+	"serial_number": {"serial_number", "серийный номер"},
+	"vin":           {"vin"},
+	"license_plate": {"license_plate", "госномер", "гос. номер", "государственный номер", "номер тс"},
+	"address":       {"address", "адрес"},
+	"latitude":      {"latitude", "lat", "широта"},
+	"longitude":     {"longitude", "lon", "lng", "долгота"},
+	"contract":      {"contract", "contract_id", "contract_number", "договор", "номер договора"},
+	"template":      {"template", "template_id", "шаблон"},
+	"location":      {"location", "location_id", "city", "локация", "город"},
+	"status":        {"status", "статус"},
+	"notes":         {"notes", "заметки", "примечание"},
+	"external_id":   {"external_id", "внешний id"},
+}
+
+// objectExportHeaders заголовки выгрузки объектов; выгруженный файл можно загрузить обратно
+var objectExportHeaders = []string{"Название", "Тип", "IMEI", "Телефон", "Серийный номер", "VIN", "Госномер",
+	"Договор", "Шаблон", "Город", "Адрес", "Широта", "Долгота", "Статус", "Описание", "Заметки", "Внешний ID"}
+
+// objectImportStatuses допустимые статусы импортируемых объектов
+var objectImportStatuses = map[string]bool{"active": true, "inactive": true, "maintenance": true}
+
+// ParseObjectImportFile разбирает Excel (.xlsx) или CSV файл со списком объектов.
+// Первая строка - заголовок; обязательны колонки названия и IMEI.
+func ParseObjectImportFile(filename string, data []byte) ([]ObjectImportRow, error) {
+	rows, err := readSpreadsheetRows(filename, data)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("файл не содержит данных")
+	}
+
+	columns := matchSpreadsheetColumns(rows[0], objectImportColumns)
+	if _, ok := columns["name"]; !ok {
+		return nil, fmt.Errorf("в файле отсутствует колонка с названием объекта")
+	}
+	if _, ok := columns["imei"]; !ok {
+		return nil, fmt.Errorf("в файле отсутствует колонка с IMEI")
+	}
+
+	get := func(record []string, field string) string {
+		if i, ok := columns[field]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+	coordinate := func(row *ObjectImportRow, record []string, field, title string) *float64 {
+		raw := get(record, field)
+		if raw == "" {
+			return nil
+		}
+		value, err := strconv.ParseFloat(strings.ReplaceAll(raw, ",", "."), 64)
+		if err != nil {
+			row.Errors = append(row.Errors, fmt.Sprintf("неверная %s %q", title, raw))
+			return nil
+		}
+		return &value
+	}
+
+	var result []ObjectImportRow
+	for i, record := range rows[1:] {
+		empty := true
+		for _, value := range record {
+			if strings.TrimSpace(value) != "" {
+				empty = false
+				break
+			}
+		}
+		if empty {
+			continue
+		}
+
+		row := ObjectImportRow{
+			LineNumber:   i + 2,
+			Name:         get(record, "name"),
+			Type:         get(record, "type"),
+			Description:  get(record, "description"),
+			IMEI:         get(record, "imei"),
+			PhoneNumber:  get(record, "phone_number"),
+			SerialNumber: get(record, "serial_number"),
+			VIN:          strings.ToUpper(get(record, "vin")),
+			LicensePlate: strings.ToUpper(strings.ReplaceAll(get(record, "license_plate"), " ", "")),
+			Address:      get(record, "address"),
+			Status:       strings.ToLower(get(record, "status")),
+			Notes:        get(record, "notes"),
+			ExternalID:   get(record, "external_id"),
+			Contract:     get(record, "contract"),
+			Template:     get(record, "template"),
+			Location:     get(record, "location"),
+		}
+		row.Latitude = coordinate(&row, record, "latitude", "широта")
+		row.Longitude = coordinate(&row, record, "longitude", "долгота")
+		result = append(result, row)
+	}
+
+	if len(result) == 0 {
+		return nil, fmt.Errorf("в файле не найдено ни одного объекта")
+	}
+	return result, nil
+}
+
+// importReferences кэш найденных договоров, шаблонов и локаций по ссылкам из файла
+type importReferences struct {
+	db        *gorm.DB
+	contracts map[string]*models.Contract
+	templates map[string]*models.ObjectTemplate
+	locations map[string]*models.Location
+}
+
+// findByReference ищет запись по числовому ID, а если не найдена - по значению поля
+func findByReference(db *gorm.DB, dest interface{}, reference, column string) error {
+	if id, err := strconv.ParseUint(reference, 10, 32); err == nil {
+		if err := db.First(dest, id).Error; err == nil {
+			return nil
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+	}
+	return db.Where("LOWER("+column+") = LOWER(?)", reference).First(dest).Error
+}
+
+func (r *importReferences) contract(reference string) (*models.Contract, error) {
+	if contract, ok := r.contracts[reference]; ok {
+		return contract, nil
+	}
+	var contract models.Contract
+	if err := findByReference(r.db, &contract, reference, "number"); err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		r.contracts[reference] = nil
+		return nil, nil
+	}
+	r.contracts[reference] = &contract
+	return &contract, nil
+}
+
+func (r *importReferences) template(reference string) (*models.ObjectTemplate, error) {
+	if template, ok := r.templates[reference]; ok {
+		return template, nil
+	}
+	var template models.ObjectTemplate
+	if err := findByReference(r.db, &template, reference, "name"); err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		r.templates[reference] = nil
+		return nil, nil
+	}
+	r.templates[reference] = &template
+	return &template, nil
+}
+
+func (r *importReferences) location(reference string) (*models.Location, error) {
+	if location, ok := r.locations[reference]; ok {
+		return location, nil
+	}
+	var location models.Location
+	if err := findByReference(r.db, &location, reference, "city"); err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		r.locations[reference] = nil
+		return nil, nil
+	}
+	r.locations[reference] = &location
+	return &location, nil
+}
+
+// ValidateRows проверяет строки импорта: обязательные поля, формат и уникальность IMEI
+// в файле и среди существующих объектов, договор, шаблон и локацию. Ошибки записываются в строки.
+func (s *ObjectImportService) ValidateRows(rows []ObjectImportRow, defaults ObjectImportDefaults) ([]ObjectImportRow, error) {
+	refs := &importReferences{
+		db:        s.DB,
+		contracts: map[string]*models.Contract{},
+		templates: map[string]*models.ObjectTemplate{},
+		locations: map[string]*models.Location{},
+	}
+	reference := func(value string, id *uint) string {
+		if value == "" && id != nil {
+			return strconv.FormatUint(uint64(*id), 10)
+		}
+		return value
+	}
+
+	seenIMEIs := map[string]int{}
+	var imeis []string
+	for i := range rows {
+		row := &rows[i]
+		if row.LineNumber == 0 {
+			row.LineNumber = i + 1
+		}
+
+		if row.Name == "" {
+			row.Errors = append(row.Errors, "не указано название объекта")
+		}
+
+		switch {
+		case row.IMEI == "":
+			row.Errors = append(row.Errors, "не указан IMEI")
+		case !imeiPattern.MatchString(row.IMEI):
+			row.Errors = append(row.Errors, "IMEI должен состоять из 15 цифр")
+		default:
+			if first, ok := seenIMEIs[row.IMEI]; ok {
+				row.Errors = append(row.Errors, fmt.Sprintf("IMEI повторяется в строке %d", first))
+			} else {
+				seenIMEIs[row.IMEI] = row.LineNumber
+				imeis = append(imeis, row.IMEI)
+			}
+		}
+
+		if ref := reference(row.Contract, defaults.ContractID); ref == "" {
+			row.Errors = append(row.Errors, "не указан договор")
+		} else if contract, err := refs.contract(ref); err != nil {
+			return nil, fmt.Errorf("ошибка при поиске договора: %w", err)
+		} else if contract == nil {
+			row.Errors = append(row.Errors, fmt.Sprintf("договор %q не найден", ref))
+		} else {
+			row.ContractID = contract.ID
+		}
+
+		var template *models.ObjectTemplate
+		if ref := reference(row.Template, defaults.TemplateID); ref != "" {
+			found, err := refs.template(ref)
+			if err != nil {
+				return nil, fmt.Errorf("ошибка при поиске шаблона: %w", err)
+			}
+			if found == nil {
+				row.Errors = append(row.Errors, fmt.Sprintf("шаблон %q не найден", ref))
+			} else {
+				template = found
+				row.TemplateID = &found.ID
+			}
+		}
+
+		if ref := reference(row.Location, defaults.LocationID); ref != "" {
+			location, err := refs.location(ref)
+			if err != nil {
+				return nil, fmt.Errorf("ошибка при поиске локации: %w", err)
+			}
+			if location == nil {
+				row.Errors = append(row.Errors, fmt.Sprintf("локация %q не найдена", ref))
+			} else {
+				row.LocationID = location.ID
+			}
+		}
+
+		if row.Type == "" {
+			row.Type = defaults.Type
+		}
+		if row.Type == "" && template != nil {
+			row.Type = template.Category
+		}
+		if row.Type == "" {
+			row.Type = "vehicle"
+		}
+		if row.Status == "" {
+			row.Status = "active"
+		}
+		if !objectImportStatuses[row.Status] {
+			row.Errors = append(row.Errors, fmt.Sprintf("неизвестный статус %q", row.Status))
+		}
+	}
+
+	// Уникальный индекс IMEI учитывает и удаленные объекты
+	if len(imeis) > 0 {
+		var existing []models.Object
+		if err := s.DB.Unscoped().Select("id", "imei", "name").Where("imei IN ?", imeis).Find(&existing).Error; err != nil {
+			return nil, fmt.Errorf("ошибка при проверке IMEI: %w", err)
+		}
+		lines := make(map[int]int, len(rows))
+		for i := range rows {
+			lines[rows[i].LineNumber] = i
+		}
+		for _, object := range existing {
+			row := &rows[lines[seenIMEIs[object.IMEI]]]
+			row.Errors = append(row.Errors, fmt.Sprintf("объект с таким IMEI уже существует: %s (ID %d)", object.Name, object.ID))
+		}
+	}
+	return rows, nil
+}
+
+// Import проверяет строки и, если ошибок нет и это не пробный запуск, создает все объекты
+// в одной транзакции и один раз пересчитывает стоимость каждого затронутого договора.
+// При ошибках в строках возвращается результат с ошибками и ErrObjectImportInvalid.
+func (s *ObjectImportService) Import(rows []ObjectImportRow, defaults ObjectImportDefaults, dryRun bool) (*ObjectImportResult, error) {
+	rows, err := s.ValidateRows(rows, defaults)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ObjectImportResult{DryRun: dryRun, Total: len(rows), Rows: rows, Contracts: []uint{}}
+	for _, row := range rows {
+		if len(row.Errors) > 0 {
+			result.Invalid++
+		} else {
+			result.Valid++
+		}
+	}
+	if result.Invalid > 0 {
+		return result, ErrObjectImportInvalid
+	}
+	if dryRun {
+		return result, nil
+	}
+
+	objects := make([]models.Object, 0, len(rows))
+	templateUsage := map[uint]int{}
+	var contractIDs []uint
+	seenContracts := map[uint]bool{}
+	for _, row := range rows {
+		objects = append(objects, models.Object{
+			Name:         row.Name,
+			Type:         row.Type,
+			Description:  row.Description,
+			IMEI:         row.IMEI,
+			PhoneNumber:  row.PhoneNumber,
+			SerialNumber: row.SerialNumber,
+			VIN:          row.VIN,
+			LicensePlate: row.LicensePlate,
+			Address:      row.Address,
+			Latitude:     row.Latitude,
+			Longitude:    row.Longitude,
+			Status:       row.Status,
+			IsActive:     row.Status != "inactive",
+			ContractID:   row.ContractID,
+			TemplateID:   row.TemplateID,
+			LocationID:   row.LocationID,
+			Notes:        row.Notes,
+			ExternalID:   row.ExternalID,
+		})
+		if row.TemplateID != nil {
+			templateUsage[*row.TemplateID]++
+		}
+		if !seenContracts[row.ContractID] {
+			seenContracts[row.ContractID] = true
+			contractIDs = append(contractIDs, row.ContractID)
+		}
+	}
+
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.CreateInBatches(&objects, objectImportBatchSize).Error; err != nil {
+			return fmt.Errorf("ошибка при создании объектов: %w", err)
+		}
+		// is_active имеет значение по умолчанию true, поэтому false при создании не сохраняется
+		var inactiveIDs []uint
+		for i, row := range rows {
+			if row.Status == "inactive" {
+				inactiveIDs = append(inactiveIDs, objects[i].ID)
+			}
+		}
+		if len(inactiveIDs) > 0 {
+			if err := tx.Model(&models.Object{}).Where("id IN ?", inactiveIDs).Update("is_active", false).Error; err != nil {
+				return fmt.Errorf("ошибка при создании объектов: %w", err)
+			}
+		}
+		for templateID, count := range templateUsage {
+			if err := tx.Model(&models.ObjectTemplate{}).Where("id = ?", templateID).
+				UpdateColumn("usage_count", gorm.Expr("usage_count + ?", count)).Error; err != nil {
+				return fmt.Errorf("ошибка при обновлении счетчика шаблона: %w", err)
+			}
+		}
+		for _, contractID := range contractIDs {
+			if err := ApplyContractTariff(tx, contractID); err != nil {
+				return fmt.Errorf("ошибка пересчета стоимости договора %d: %w", contractID, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result.Created = len(objects)
+	result.Contracts = contractIDs
+	return result, nil
+}
+
+// ExportObjects выгружает объекты по фильтру в формате xlsx или csv
+func (s *ObjectImportService) ExportObjects(filter ObjectFilter, format string) ([]byte, string, error) {
+	query := filter.Apply(s.DB.Model(&models.Object{})).
+		Preload("Contract").Preload("Template").Preload("Location").Order("name, id")
+
+	var objects []models.Object
+	if err := query.Find(&objects).Error; err != nil {
+		return nil, "", fmt.Errorf("ошибка при получении объектов: %w", err)
+	}
+	return RenderObjectExport(objects, format)
+}
+
+// RenderObjectExport формирует файл со списком объектов в формате файла импорта
+func RenderObjectExport(objects []models.Object, format string) ([]byte, string, error) {
+	coordinate := func(value *float64) string {
+		if value == nil {
+			return ""
+		}
+		return strconv.FormatFloat(*value, 'f', -1, 64)
+	}
+
+	rows := make([][]string, 0, len(objects))
+	for _, object := range objects {
+		contract, template, location := "", "", ""
+		if object.Contract != nil {
+			contract = object.Contract.Number
+		}
+		if object.Template != nil {
+			template = object.Template.Name
+		}
+		if object.Location != nil {
+			location = object.Location.City
+		}
+		rows = append(rows, []string{
+			object.Name, object.Type, object.IMEI, object.PhoneNumber, object.SerialNumber, object.VIN, object.LicensePlate,
+			contract, template, location, object.Address, coordinate(object.Latitude), coordinate(object.Longitude),
+			object.Status, object.Description, object.Notes, object.ExternalID,
+		})
+	}
+
+	baseName := "objects"
+	if format == "csv" {
+		var buf bytes.Buffer
+		buf.WriteString("\xef\xbb\xbf") // BOM для корректного открытия в Excel
+		writer := csv.NewWriter(&buf)
+		writer.Comma = ';'
+		_ = writer.Write(objectExportHeaders)
+		_ = writer.WriteAll(rows)
+		if err := writer.Error(); err != nil {
+			return nil, "", fmt.Errorf("ошибка при формировании CSV: %w", err)
+		}
+		return buf.Bytes(), baseName + ".csv", nil
+	}
+
+	f := excelize.NewFile()
+	defer f.Close()
+	sheet := "Объекты"
+	f.SetSheetName(f.GetSheetName(0), sheet)
+	for col, header := range objectExportHeaders {
+		cell, _ := excelize.CoordinatesToCellName(col+1, 1)
+		f.SetCellValue(sheet, cell, header)
+	}
+	for rowIdx, row := range rows {
+		for col, value := range row {
+			cell, _ := excelize.CoordinatesToCellName(col+1, rowIdx+2)
+			f.SetCellValue(sheet, cell, value)
+		}
+	}
+
+	buf, err := f.WriteToBuffer()
+	if err != nil {
+		return nil, "", fmt.Errorf("ошибка при формировании Excel: %w", err)
+	}
+	return buf.Bytes(), baseName + ".xlsx", nil
+}
@@ -0,0 +1,161 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"backend_axenta/models"
+)
+
+func setupObjectImportTestDB(t *testing.T) (*gorm.DB, models.Contract) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(
+		&models.BillingPlan{},
+		&models.Contract{},
+		&models.Object{},
+		&models.ObjectTemplate{},
+		&models.Location{},
+	))
+
+	plan := models.BillingPlan{Name: "Базовый", Price: decimal.NewFromInt(1000), Currency: "RUB", BillingPeriod: "monthly", IsActive: true}
+	require.NoError(t, db.Create(&plan).Error)
+	contract := models.Contract{
+		Number:       "Д-1",
+		Title:        "Мониторинг транспорта",
+		ClientName:   "ООО Логистика",
+		StartDate:    time.Now(),
+		EndDate:      time.Now().AddDate(1, 0, 0),
+		TariffPlanID: plan.ID,
+		Status:       "active",
+		IsActive:     true,
+	}
+	require.NoError(t, db.Create(&contract).Error)
+	require.NoError(t, db.Create(&models.ObjectTemplate{Name: "Грузовик", Category: "vehicle", IsActive: true}).Error)
+	require.NoError(t, db.Create(&models.Location{City: "Москва", IsActive: true}).Error)
+	return db, contract
+}
+
+func TestObjectImportService_ValidationPreview(t *testing.T) {
+	db, _ := setupObjectImportTestDB(t)
+	service := NewObjectImportService(db)
+
+	csvData := "Название;IMEI;Договор;Шаблон;Город;Широта\n" +
+		"КамАЗ А001АА;356938035643801;Д-1;Грузовик;Москва;55,75\n" +
+		"ГАЗель В002ВВ;12345;Д-1;Грузовик;Москва;\n" +
+		"МАЗ С003СС;356938035643801;Д-1;;;\n" +
+		";356938035643802;Д-2;Легковой;Казань;север\n"
+	rows, err := ParseObjectImportFile("objects.csv", []byte(csvData))
+	require.NoError(t, err)
+	require.Len(t, rows, 4)
+	require.NotNil(t, rows[0].Latitude)
+	assert.Equal(t, 55.75, *rows[0].Latitude)
+
+	result, err := service.Import(rows, ObjectImportDefaults{}, true)
+	require.ErrorIs(t, err, ErrObjectImportInvalid)
+	assert.Equal(t, 4, result.Total)
+	assert.Equal(t, 1, result.Valid)
+	assert.Equal(t, 3, result.Invalid)
+
+	assert.Empty(t, result.Rows[0].Errors)
+	assert.Equal(t, "vehicle", result.Rows[0].Type)
+	assert.Equal(t, "active", result.Rows[0].Status)
+	assert.Equal(t, 3, result.Rows[1].LineNumber)
+	assert.Contains(t, result.Rows[1].Errors, "IMEI должен состоять из 15 цифр")
+	assert.Contains(t, result.Rows[2].Errors, "IMEI повторяется в строке 2")
+
+	errorsText := strings.Join(result.Rows[3].Errors, "; ")
+	assert.Contains(t, errorsText, "не указано название")
+	assert.Contains(t, errorsText, "договор \"Д-2\" не найден")
+	assert.Contains(t, errorsText, "шаблон \"Легковой\" не найден")
+	assert.Contains(t, errorsText, "локация \"Казань\" не найдена")
+	assert.Contains(t, errorsText, "неверная широта")
+
+	var count int64
+	db.Model(&models.Object{}).Count(&count)
+	assert.Zero(t, count)
+}
+
+func TestObjectImportService_ImportAndExport(t *testing.T) {
+	db, contract := setupObjectImportTestDB(t)
+	service := NewObjectImportService(db)
+
+	var template models.ObjectTemplate
+	require.NoError(t, db.First(&template).Error)
+
+	// Договор и шаблон по умолчанию из формы, статус неактивного объекта из файла
+	csvData := "name,imei,license_plate,status\n" +
+		"КамАЗ,356938035643801,а 001 аа 77,\n" +
+		"ГАЗель,356938035643802,,\n" +
+		"Прицеп,356938035643803,,inactive\n"
+	rows, err := ParseObjectImportFile("objects.csv", []byte(csvData))
+	require.NoError(t, err)
+	defaults := ObjectImportDefaults{ContractID: &contract.ID, TemplateID: &template.ID}
+
+	preview, err := service.Import(rows, defaults, true)
+	require.NoError(t, err)
+	assert.Equal(t, 3, preview.Valid)
+	assert.Zero(t, preview.Created)
+
+	rows, err = ParseObjectImportFile("objects.csv", []byte(csvData))
+	require.NoError(t, err)
+	result, err := service.Import(rows, defaults, false)
+	require.NoError(t, err)
+	assert.Equal(t, 3, result.Created)
+	assert.Equal(t, []uint{contract.ID}, result.Contracts)
+
+	var objects []models.Object
+	require.NoError(t, db.Order("id").Find(&objects).Error)
+	require.Len(t, objects, 3)
+	assert.Equal(t, "А001АА77", objects[0].LicensePlate)
+	assert.Equal(t, contract.ID, objects[0].ContractID)
+	assert.False(t, objects[2].IsActive)
+
+	require.NoError(t, db.First(&template, template.ID).Error)
+	assert.Equal(t, 3, template.UsageCount)
+
+	// Стоимость договора пересчитана один раз по всем объектам: 2 активных + 1 неактивный за 50%
+	require.NoError(t, db.First(&contract, contract.ID).Error)
+	assert.True(t, decimal.NewFromInt(2500).Equal(contract.TotalAmount), contract.TotalAmount.String())
+
+	// Повторный импорт отклоняется: IMEI уже заняты
+	rows, err = ParseObjectImportFile("objects.csv", []byte(csvData))
+	require.NoError(t, err)
+	_, err = service.Import(rows, defaults, false)
+	require.ErrorIs(t, err, ErrObjectImportInvalid)
+	assert.Contains(t, rows[0].Errors[0], "объект с таким IMEI уже существует")
+
+	// Выгрузка читается обратно импортом
+	for _, format := range []string{"csv", "xlsx"} {
+		data, filename, err := service.ExportObjects(ObjectFilter{ContractID: &contract.ID, Status: "active"}, format)
+		require.NoError(t, err)
+		assert.Equal(t, "objects."+format, filename)
+
+		exported, err := ParseObjectImportFile(filename, data)
+		require.NoError(t, err)
+		require.Len(t, exported, 2)
+		assert.Equal(t, "ГАЗель", exported[0].Name)
+		assert.Equal(t, "КамАЗ", exported[1].Name)
+		assert.Equal(t, "356938035643801", exported[1].IMEI)
+		assert.Equal(t, "Д-1", exported[1].Contract)
+		assert.Equal(t, "Грузовик", exported[1].Template)
+		assert.Equal(t, "А001АА77", exported[1].LicensePlate)
+	}
+
+	data, _, err := service.ExportObjects(ObjectFilter{Search: "43802"}, "csv")
+	require.NoError(t, err)
+	exported, err := ParseObjectImportFile("objects.csv", data)
+	require.NoError(t, err)
+	require.Len(t, exported, 1)
+	assert.Equal(t, "ГАЗель", exported[0].Name)
+}
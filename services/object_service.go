@@ -0,0 +1,83 @@
+package services
+
+import (
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+
+	"backend_axenta/models"
+)
+
+// ObjectFilter фильтр объектов, совпадает с фильтрами списка объектов
+type ObjectFilter struct {
+	Status     string `json:"status"`
+	Type       string `json:"type"`
+	Search     string `json:"search"`
+	ContractID *uint  `json:"contract_id"`
+	TemplateID *uint  `json:"template_id"`
+	LocationID *uint  `json:"location_id"`
+}
+
+// IsEmpty проверяет, что ни одно условие фильтра не задано
+func (f ObjectFilter) IsEmpty() bool {
+	return f.Status == "" && f.Type == "" && f.Search == "" &&
+		f.ContractID == nil && f.TemplateID == nil && f.LocationID == nil
+}
+
+// Apply добавляет условия фильтра к запросу объектов
+func (f ObjectFilter) Apply(query *gorm.DB) *gorm.DB {
+	if f.Status != "" {
+		query = query.Where("status = ?", f.Status)
+	}
+	if f.Type != "" {
+		query = query.Where("type = ?", f.Type)
+	}
+	if f.ContractID != nil {
+		query = query.Where("contract_id = ?", *f.ContractID)
+	}
+	if f.TemplateID != nil {
+		query = query.Where("template_id = ?", *f.TemplateID)
+	}
+	if f.LocationID != nil {
+		query = query.Where("location_id = ?", *f.LocationID)
+	}
+	if f.Search != "" {
+		search := "%" + f.Search + "%"
+		query = query.Where("LOWER(name) LIKE LOWER(?) OR imei LIKE ? OR phone_number LIKE ? OR LOWER(license_plate) LIKE LOWER(?)",
+			search, search, search, search)
+	}
+	return query
+}
+
+// ApplyContractTariff пересчитывает стоимость договора по тарифу и количеству его объектов
+func ApplyContractTariff(db *gorm.DB, contractID uint) error {
+	// Загружаем договор с тарифным планом
+	var contract models.Contract
+	if err := db.Preload("TariffPlan").First(&contract, contractID).Error; err != nil {
+		return err
+	}
+
+	// Если у договора нет тарифного плана, пропускаем
+	if contract.TariffPlanID == 0 {
+		return nil
+	}
+
+	// Получаем количество объектов по договору
+	var totalObjects int64
+	var activeObjects int64
+	db.Model(&models.Object{}).Where("contract_id = ?", contract.ID).Count(&totalObjects)
+	db.Model(&models.Object{}).Where("contract_id = ? AND is_active = ?", contract.ID, true).Count(&activeObjects)
+	inactiveObjects := totalObjects - activeObjects
+
+	// Создаем TariffPlan для расчета стоимости
+	tariffPlan := models.TariffPlan{
+		BillingPlan:        contract.TariffPlan,
+		PricePerObject:     contract.TariffPlan.Price,
+		InactivePriceRatio: decimal.NewFromFloat(0.5), // 50% для неактивных объектов
+	}
+
+	// Рассчитываем новую стоимость договора
+	newCost := tariffPlan.CalculateObjectPrice(int(totalObjects), int(inactiveObjects))
+
+	// Обновляем стоимость договора
+	return db.Model(&contract).Update("total_amount", newCost).Error
+}
@@ -0,0 +1,687 @@
+package services
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/xuri/excelize/v2"
+	"gorm.io/gorm"
+
+	"backend_axenta/models"
+)
+
+// PayrollService рассчитывает вознаграждение монтажников за выполненные монтажи
+type PayrollService struct {
+	DB       *gorm.DB
+	Timezone string // Часовой пояс компании для границ расчетного месяца
+}
+
+// NewPayrollService создает новый экземпляр PayrollService
+func NewPayrollService(db *gorm.DB) *PayrollService {
+	return &PayrollService{DB: db}
+}
+
+// PayrollRateRequest запрос на создание или изменение сдельной ставки
+type PayrollRateRequest struct {
+	Name             string          `json:"name"`
+	InstallationType string          `json:"installation_type"`
+	InstallerType    string          `json:"installer_type"`
+	InstallerID      *uint           `json:"installer_id"`
+	PieceRate        decimal.Decimal `json:"piece_rate"`
+	PayHourly        bool            `json:"pay_hourly"`
+	TravelRate       decimal.Decimal `json:"travel_rate"`
+	TravelFixed      decimal.Decimal `json:"travel_fixed"`
+	ValidFrom        *time.Time      `json:"valid_from"`
+	ValidTo          *time.Time      `json:"valid_to"`
+	IsActive         *bool           `json:"is_active"` // По умолчанию ставка активна
+}
+
+// PayrollPenaltyRuleRequest запрос на создание или изменение правила удержания
+type PayrollPenaltyRuleRequest struct {
+	Name          string          `json:"name"`
+	RatingBelow   float32         `json:"rating_below" binding:"required"`
+	PenaltyPct    decimal.Decimal `json:"penalty_pct"`
+	PenaltyAmount decimal.Decimal `json:"penalty_amount"`
+	IsActive      *bool           `json:"is_active"` // По умолчанию правило активно
+}
+
+// PayrollGenerateRequest запрос на расчет расчетных листов за месяц
+type PayrollGenerateRequest struct {
+	Month        string `json:"month" binding:"required"` // YYYY-MM
+	InstallerIDs []uint `json:"installer_ids"`            // Пусто - все монтажники с завершенными монтажами
+}
+
+// Допустимые типы монтажника в ставке; пусто - ставка для любого типа
+var payrollInstallerTypes = map[string]bool{"": true, "staff": true, "contractor": true, "partner": true}
+
+// CreateRate создает сдельную ставку
+func (s *PayrollService) CreateRate(req PayrollRateRequest) (*models.PayrollRate, error) {
+	rate := models.PayrollRate{IsActive: true}
+	if err := applyPayrollRate(&rate, req); err != nil {
+		return nil, err
+	}
+	if err := s.DB.Create(&rate).Error; err != nil {
+		return nil, fmt.Errorf("ошибка при создании ставки: %w", err)
+	}
+	return &rate, nil
+}
+
+// UpdateRate изменяет сдельную ставку. Утвержденные расчетные листы не пересчитываются.
+func (s *PayrollService) UpdateRate(id uint, req PayrollRateRequest) (*models.PayrollRate, error) {
+	var rate models.PayrollRate
+	if err := s.DB.First(&rate, id).Error; err != nil {
+		return nil, err
+	}
+	if err := applyPayrollRate(&rate, req); err != nil {
+		return nil, err
+	}
+	if err := s.DB.Save(&rate).Error; err != nil {
+		return nil, fmt.Errorf("ошибка при обновлении ставки: %w", err)
+	}
+	return &rate, nil
+}
+
+// applyPayrollRate проверяет и переносит поля запроса в ставку
+func applyPayrollRate(rate *models.PayrollRate, req PayrollRateRequest) error {
+	if !payrollInstallerTypes[req.InstallerType] {
+		return fmt.Errorf("неизвестный тип монтажника: %s", req.InstallerType)
+	}
+	if req.PieceRate.IsNegative() || req.TravelRate.IsNegative() || req.TravelFixed.IsNegative() {
+		return fmt.Errorf("ставки не могут быть отрицательными")
+	}
+	if req.ValidFrom != nil && req.ValidTo != nil && req.ValidTo.Before(*req.ValidFrom) {
+		return fmt.Errorf("дата окончания действия ставки раньше даты начала")
+	}
+
+	rate.Name = req.Name
+	rate.InstallationType = req.InstallationType
+	rate.InstallerType = req.InstallerType
+	rate.InstallerID = req.InstallerID
+	rate.PieceRate = req.PieceRate
+	rate.PayHourly = req.PayHourly
+	rate.TravelRate = req.TravelRate
+	rate.TravelFixed = req.TravelFixed
+	rate.ValidFrom = req.ValidFrom
+	rate.ValidTo = req.ValidTo
+	if req.IsActive != nil {
+		rate.IsActive = *req.IsActive
+	}
+	return nil
+}
+
+// DeleteRate удаляет сдельную ставку
+func (s *PayrollService) DeleteRate(id uint) error {
+	result := s.DB.Delete(&models.PayrollRate{}, id)
+	if result.Error != nil {
+		return fmt.Errorf("ошибка при удалении ставки: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// GetRates возвращает сдельные ставки, при необходимости только ставки монтажника
+func (s *PayrollService) GetRates(installerID *uint) ([]models.PayrollRate, error) {
+	query := s.DB.Order("installation_type, installer_type, id")
+	if installerID != nil {
+		query = query.Where("installer_id = ?", *installerID)
+	}
+	var rates []models.PayrollRate
+	err := query.Find(&rates).Error
+	return rates, err
+}
+
+// CreatePenaltyRule создает правило удержания за низкую оценку качества
+func (s *PayrollService) CreatePenaltyRule(req PayrollPenaltyRuleRequest) (*models.PayrollPenaltyRule, error) {
+	rule := models.PayrollPenaltyRule{IsActive: true}
+	if err := applyPayrollPenaltyRule(&rule, req); err != nil {
+		return nil, err
+	}
+	if err := s.DB.Create(&rule).Error; err != nil {
+		return nil, fmt.Errorf("ошибка при создании правила удержания: %w", err)
+	}
+	return &rule, nil
+}
+
+// UpdatePenaltyRule изменяет правило удержания
+func (s *PayrollService) UpdatePenaltyRule(id uint, req PayrollPenaltyRuleRequest) (*models.PayrollPenaltyRule, error) {
+	var rule models.PayrollPenaltyRule
+	if err := s.DB.First(&rule, id).Error; err != nil {
+		return nil, err
+	}
+	if err := applyPayrollPenaltyRule(&rule, req); err != nil {
+		return nil, err
+	}
+	if err := s.DB.Save(&rule).Error; err != nil {
+		return nil, fmt.Errorf("ошибка при обновлении правила удержания: %w", err)
+	}
+	return &rule, nil
+}
+
+// applyPayrollPenaltyRule проверяет и переносит поля запроса в правило удержания
+func applyPayrollPenaltyRule(rule *models.PayrollPenaltyRule, req PayrollPenaltyRuleRequest) error {
+	if req.RatingBelow <= 1 || req.RatingBelow > 5 {
+		return fmt.Errorf("порог оценки должен быть больше 1 и не больше 5")
+	}
+	if req.PenaltyPct.IsNegative() || req.PenaltyPct.GreaterThan(decimal.NewFromInt(100)) {
+		return fmt.Errorf("процент удержания должен быть от 0 до 100")
+	}
+	if req.PenaltyAmount.IsNegative() {
+		return fmt.Errorf("сумма удержания не может быть отрицательной")
+	}
+	if req.PenaltyPct.IsZero() && req.PenaltyAmount.IsZero() {
+		return fmt.Errorf("укажите процент или сумму удержания")
+	}
+
+	rule.Name = req.Name
+	rule.RatingBelow = req.RatingBelow
+	rule.PenaltyPct = req.PenaltyPct
+	rule.PenaltyAmount = req.PenaltyAmount
+	if req.IsActive != nil {
+		rule.IsActive = *req.IsActive
+	}
+	return nil
+}
+
+// DeletePenaltyRule удаляет правило удержания
+func (s *PayrollService) DeletePenaltyRule(id uint) error {
+	result := s.DB.Delete(&models.PayrollPenaltyRule{}, id)
+	if result.Error != nil {
+		return fmt.Errorf("ошибка при удалении правила удержания: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// GetPenaltyRules возвращает правила удержаний по возрастанию порога оценки
+func (s *PayrollService) GetPenaltyRules() ([]models.PayrollPenaltyRule, error) {
+	var rules []models.PayrollPenaltyRule
+	err := s.DB.Order("rating_below, id").Find(&rules).Error
+	return rules, err
+}
+
+// PayrollMonth возвращает границы расчетного месяца YYYY-MM в часовом поясе tz
+func PayrollMonth(month string, tz *time.Location) (time.Time, time.Time, error) {
+	start, err := time.ParseInLocation("2006-01", month, tz)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("некорректный месяц %q, ожидается формат YYYY-MM", month)
+	}
+	return start, start.AddDate(0, 1, 0), nil
+}
+
+// matchPayrollRate выбирает наиболее конкретную действующую ставку для монтажа:
+// ставка монтажника важнее ставки для типа монтажника, ставка для вида работ - общей.
+// При равной конкретности выбирается более новая ставка.
+func matchPayrollRate(rates []models.PayrollRate, installer *models.Installer, installation *models.Installation, at time.Time) *models.PayrollRate {
+	var best *models.PayrollRate
+	bestScore := -1
+	for i := range rates {
+		rate := &rates[i]
+		if !rate.IsActive ||
+			(rate.ValidFrom != nil && at.Before(*rate.ValidFrom)) ||
+			(rate.ValidTo != nil && at.After(*rate.ValidTo)) {
+			continue
+		}
+
+		score := 0
+		if rate.InstallerID != nil {
+			if installer == nil || *rate.InstallerID != installer.ID {
+				continue
+			}
+			score += 4
+		}
+		if rate.InstallerType != "" {
+			if installer == nil || rate.InstallerType != installer.Type {
+				continue
+			}
+			score += 2
+		}
+		if rate.InstallationType != "" {
+			if rate.InstallationType != installation.Type {
+				continue
+			}
+			score++
+		}
+
+		if score > bestScore || (score == bestScore && rate.ID > best.ID) {
+			best, bestScore = rate, score
+		}
+	}
+	return best
+}
+
+// matchPenaltyRule выбирает самое строгое правило удержания для оценки качества
+func matchPenaltyRule(rules []models.PayrollPenaltyRule, rating *float32) *models.PayrollPenaltyRule {
+	if rating == nil {
+		return nil
+	}
+	var best *models.PayrollPenaltyRule
+	for i := range rules {
+		rule := &rules[i]
+		if !rule.IsActive || *rating >= rule.RatingBelow {
+			continue
+		}
+		if best == nil || rule.RatingBelow < best.RatingBelow {
+			best = rule
+		}
+	}
+	return best
+}
+
+// minutesAmount стоимость минут по часовой ставке
+func minutesAmount(minutes int, hourlyRate decimal.Decimal) decimal.Decimal {
+	if minutes <= 0 || hourlyRate.IsZero() {
+		return decimal.Zero
+	}
+	return hourlyRate.Mul(decimal.NewFromInt(int64(minutes))).Div(decimal.NewFromInt(60)).Round(2)
+}
+
+// CalculatePayrollLine рассчитывает начисление за монтаж:
+//   - сдельная оплата по подходящей ставке, без ставки - стоимость работ из монтажа;
+//   - оплата фактического времени по часовой ставке монтажника, если так указано в ставке
+//     или ставка не найдена;
+//   - компенсация времени в пути;
+//   - удержание за низкую оценку качества, не больше начисления за работу.
+func CalculatePayrollLine(installation *models.Installation, installer *models.Installer, rates []models.PayrollRate, rules []models.PayrollPenaltyRule) models.PayrollStatementLine {
+	at := installation.ScheduledAt
+	if installation.CompletedAt != nil {
+		at = *installation.CompletedAt
+	}
+	hourlyRate := decimal.Zero
+	if installer != nil {
+		hourlyRate = installer.HourlyRate
+	}
+
+	line := models.PayrollStatementLine{
+		InstallationID: installation.ID,
+		Description:    fmt.Sprintf("%s, %s", installation.Type, at.Format("02.01.2006")),
+		CompletedAt:    installation.CompletedAt,
+		ActualDuration: installation.ActualDuration,
+		TravelTime:     installation.TravelTime,
+		QualityRating:  installation.QualityRating,
+	}
+
+	rate := matchPayrollRate(rates, installer, installation, at)
+	if rate != nil {
+		line.RateID = &rate.ID
+		line.PieceAmount = rate.PieceRate
+		if rate.PayHourly {
+			line.HourlyAmount = minutesAmount(installation.ActualDuration, hourlyRate)
+		}
+		line.TravelAmount = rate.TravelFixed.Add(minutesAmount(installation.TravelTime, rate.TravelRate))
+	} else {
+		// Без ставки начисляется либо стоимость работ из монтажа, либо время по часовой ставке,
+		// но не обе суммы сразу; строка требует проверки перед утверждением
+		line.NeedsReview = true
+		if labor := decimal.NewFromFloat(installation.LaborCost).Round(2); labor.IsPositive() {
+			line.PieceAmount = labor
+			line.Note = "Ставка не найдена, начислена стоимость работ из монтажа"
+		} else if line.HourlyAmount = minutesAmount(installation.ActualDuration, hourlyRate); line.HourlyAmount.IsPositive() {
+			line.Note = "Ставка не найдена, начислено время по часовой ставке"
+		} else {
+			line.Note = "Не найдена ставка"
+		}
+	}
+
+	workAmount := line.PieceAmount.Add(line.HourlyAmount)
+	if rule := matchPenaltyRule(rules, installation.QualityRating); rule != nil {
+		penalty := workAmount.Mul(rule.PenaltyPct).Div(decimal.NewFromInt(100)).Add(rule.PenaltyAmount).Round(2)
+		if penalty.GreaterThan(workAmount) {
+			penalty = workAmount
+		}
+		line.PenaltyAmount = penalty
+		penaltyNote := fmt.Sprintf("Удержание за оценку %.1f", *installation.QualityRating)
+		if line.Note != "" {
+			penaltyNote = line.Note + "; " + penaltyNote
+		}
+		line.Note = penaltyNote
+	}
+
+	line.TotalAmount = workAmount.Add(line.TravelAmount).Sub(line.PenaltyAmount)
+	return line
+}
+
+// summarizePayrollStatement пересчитывает итоги расчетного листа по строкам
+func summarizePayrollStatement(statement *models.PayrollStatement) {
+	statement.InstallationsCount = len(statement.Lines)
+	statement.WorkMinutes, statement.TravelMinutes = 0, 0
+	statement.ReviewCount = 0
+	statement.PieceAmount, statement.HourlyAmount = decimal.Zero, decimal.Zero
+	statement.TravelAmount, statement.PenaltyAmount, statement.TotalAmount = decimal.Zero, decimal.Zero, decimal.Zero
+	for _, line := range statement.Lines {
+		statement.WorkMinutes += line.ActualDuration
+		statement.TravelMinutes += line.TravelTime
+		if line.NeedsReview {
+			statement.ReviewCount++
+		}
+		statement.PieceAmount = statement.PieceAmount.Add(line.PieceAmount)
+		statement.HourlyAmount = statement.HourlyAmount.Add(line.HourlyAmount)
+		statement.TravelAmount = statement.TravelAmount.Add(line.TravelAmount)
+		statement.PenaltyAmount = statement.PenaltyAmount.Add(line.PenaltyAmount)
+		statement.TotalAmount = statement.TotalAmount.Add(line.TotalAmount)
+	}
+}
+
+// PayrollStatementNumber номер расчетного листа монтажника за месяц
+func PayrollStatementNumber(periodStart time.Time, installerID uint) string {
+	return fmt.Sprintf("РЛ-%s-%04d", periodStart.Format("200601"), installerID)
+}
+
+// GenerateStatements рассчитывает расчетные листы монтажников за месяц по завершенным монтажам.
+// Черновики пересчитываются заново, утвержденные и выплаченные листы не меняются.
+func (s *PayrollService) GenerateStatements(req PayrollGenerateRequest) ([]models.PayrollStatement, error) {
+	start, end, err := PayrollMonth(req.Month, models.LoadTimezone(s.Timezone))
+	if err != nil {
+		return nil, err
+	}
+
+	query := s.DB.Where("status = ? AND completed_at >= ? AND completed_at < ?", "completed", start, end)
+	if len(req.InstallerIDs) > 0 {
+		query = query.Where("installer_id IN ?", req.InstallerIDs)
+	}
+	var installations []models.Installation
+	if err := query.Order("completed_at, id").Find(&installations).Error; err != nil {
+		return nil, fmt.Errorf("ошибка при получении монтажей: %w", err)
+	}
+	byInstaller := make(map[uint][]models.Installation)
+	for _, installation := range installations {
+		byInstaller[installation.InstallerID] = append(byInstaller[installation.InstallerID], installation)
+	}
+
+	var rates []models.PayrollRate
+	if err := s.DB.Where("is_active = ?", true).Find(&rates).Error; err != nil {
+		return nil, fmt.Errorf("ошибка при получении ставок: %w", err)
+	}
+	var rules []models.PayrollPenaltyRule
+	if err := s.DB.Where("is_active = ?", true).Find(&rules).Error; err != nil {
+		return nil, fmt.Errorf("ошибка при получении правил удержаний: %w", err)
+	}
+
+	installerIDs := make([]uint, 0, len(byInstaller))
+	for id := range byInstaller {
+		installerIDs = append(installerIDs, id)
+	}
+	sort.Slice(installerIDs, func(i, j int) bool { return installerIDs[i] < installerIDs[j] })
+
+	statements := make([]models.PayrollStatement, 0, len(installerIDs))
+	for _, installerID := range installerIDs {
+		var installer *models.Installer
+		var loaded models.Installer
+		if err := s.DB.First(&loaded, installerID).Error; err == nil {
+			installer = &loaded
+		}
+
+		statement, err := s.saveStatement(installerID, installer, start, end, byInstaller[installerID], rates, rules)
+		if err != nil {
+			return nil, err
+		}
+		statements = append(statements, *statement)
+	}
+	return statements, nil
+}
+
+// saveStatement создает или пересчитывает черновик расчетного листа монтажника
+func (s *PayrollService) saveStatement(installerID uint, installer *models.Installer, start, end time.Time,
+	installations []models.Installation, rates []models.PayrollRate, rules []models.PayrollPenaltyRule) (*models.PayrollStatement, error) {
+	var statement models.PayrollStatement
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(lockingForUpdate).
+			Where("installer_id = ? AND period_start = ?", installerID, start).
+			First(&statement).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		if statement.ID != 0 && !statement.IsEditable() {
+			return nil
+		}
+
+		statement.Number = PayrollStatementNumber(start, installerID)
+		statement.Status = models.PayrollStatusDraft
+		statement.InstallerID = installerID
+		statement.PeriodStart = start
+		statement.PeriodEnd = end
+		statement.CalculatedAt = time.Now()
+		if installer != nil {
+			statement.InstallerName = installer.GetFullName()
+			statement.InstallerType = installer.Type
+		}
+		if len(installations) > 0 {
+			statement.CompanyID = installations[0].CompanyID
+		}
+
+		statement.Lines = make([]models.PayrollStatementLine, 0, len(installations))
+		for i := range installations {
+			statement.Lines = append(statement.Lines, CalculatePayrollLine(&installations[i], installer, rates, rules))
+		}
+		summarizePayrollStatement(&statement)
+
+		if statement.ID != 0 {
+			if err := tx.Where("statement_id = ?", statement.ID).Delete(&models.PayrollStatementLine{}).Error; err != nil {
+				return fmt.Errorf("ошибка при пересчете расчетного листа: %w", err)
+			}
+		}
+		lines := statement.Lines
+		if err := tx.Omit("Lines", "Installer").Save(&statement).Error; err != nil {
+			return fmt.Errorf("ошибка при сохранении расчетного листа: %w", err)
+		}
+		for i := range lines {
+			lines[i].StatementID = statement.ID
+		}
+		if len(lines) > 0 {
+			if err := tx.Create(&lines).Error; err != nil {
+				return fmt.Errorf("ошибка при сохранении начислений: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetStatement(statement.ID)
+}
+
+// GetStatement возвращает расчетный лист с начислениями
+func (s *PayrollService) GetStatement(id uint) (*models.PayrollStatement, error) {
+	var statement models.PayrollStatement
+	if err := s.DB.Preload("Lines", func(db *gorm.DB) *gorm.DB { return db.Order("completed_at, id") }).
+		First(&statement, id).Error; err != nil {
+		return nil, err
+	}
+	return &statement, nil
+}
+
+// GetStatements возвращает расчетные листы за месяц с фильтрами по монтажнику и статусу
+func (s *PayrollService) GetStatements(month string, installerID *uint, status string) ([]models.PayrollStatement, error) {
+	query := s.DB.Order("period_start DESC, installer_name, id")
+	if month != "" {
+		start, _, err := PayrollMonth(month, models.LoadTimezone(s.Timezone))
+		if err != nil {
+			return nil, err
+		}
+		query = query.Where("period_start = ?", start)
+	}
+	if installerID != nil {
+		query = query.Where("installer_id = ?", *installerID)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var statements []models.PayrollStatement
+	err := query.Find(&statements).Error
+	return statements, err
+}
+
+// changeStatementStatus переводит расчетный лист из статуса from в статус to
+func (s *PayrollService) changeStatementStatus(id uint, from, to string, updates map[string]interface{}) (*models.PayrollStatement, error) {
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		var statement models.PayrollStatement
+		if err := tx.Clauses(lockingForUpdate).First(&statement, id).Error; err != nil {
+			return err
+		}
+		if statement.Status != from {
+			return fmt.Errorf("расчетный лист %s в статусе %s, действие недоступно", statement.Number, statement.Status)
+		}
+		updates["status"] = to
+		return tx.Model(&models.PayrollStatement{}).Where("id = ?", id).Updates(updates).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetStatement(id)
+}
+
+// ApproveStatement утверждает расчетный лист; после утверждения он не пересчитывается
+func (s *PayrollService) ApproveStatement(id, userID uint) (*models.PayrollStatement, error) {
+	updates := map[string]interface{}{"approved_at": time.Now()}
+	if userID != 0 {
+		updates["approved_by_user_id"] = userID
+	}
+	return s.changeStatementStatus(id, models.PayrollStatusDraft, models.PayrollStatusApproved, updates)
+}
+
+// ReopenStatement возвращает утвержденный расчетный лист в черновик для пересчета
+func (s *PayrollService) ReopenStatement(id uint) (*models.PayrollStatement, error) {
+	return s.changeStatementStatus(id, models.PayrollStatusApproved, models.PayrollStatusDraft, map[string]interface{}{
+		"approved_at":         nil,
+		"approved_by_user_id": nil,
+	})
+}
+
+// MarkStatementPaid отмечает выплату по утвержденному расчетному листу
+func (s *PayrollService) MarkStatementPaid(id uint, paidAt *time.Time) (*models.PayrollStatement, error) {
+	now := time.Now()
+	if paidAt == nil {
+		paidAt = &now
+	}
+	return s.changeStatementStatus(id, models.PayrollStatusApproved, models.PayrollStatusPaid, map[string]interface{}{
+		"paid_at": *paidAt,
+	})
+}
+
+// payrollStatusNames названия статусов расчетных листов для выгрузки
+var payrollStatusNames = map[string]string{
+	models.PayrollStatusDraft:    "Черновик",
+	models.PayrollStatusApproved: "Утвержден",
+	models.PayrollStatusPaid:     "Выплачен",
+}
+
+// ExportStatements выгружает ведомость за месяц в формате xlsx или csv
+func (s *PayrollService) ExportStatements(month, format string) ([]byte, string, error) {
+	statements, err := s.GetStatements(month, nil, "")
+	if err != nil {
+		return nil, "", err
+	}
+	for i := range statements {
+		if err := s.DB.Where("statement_id = ?", statements[i].ID).Order("completed_at, id").
+			Find(&statements[i].Lines).Error; err != nil {
+			return nil, "", fmt.Errorf("ошибка при получении начислений: %w", err)
+		}
+	}
+	return RenderPayrollExport(statements, month, format)
+}
+
+// RenderPayrollExport формирует ведомость начислений: итоги по монтажникам и начисления по монтажам (только xlsx)
+func RenderPayrollExport(statements []models.PayrollStatement, month, format string) ([]byte, string, error) {
+	headers := []string{"№", "Расчетный лист", "Монтажник", "Тип", "Статус", "Монтажей", "Часов работы", "Часов в пути",
+		"Сдельно", "Повременно", "Дорога", "Удержания", "К выплате"}
+	rows := make([][]string, 0, len(statements))
+	total := decimal.Zero
+	for i, statement := range statements {
+		rows = append(rows, []string{
+			fmt.Sprintf("%d", i+1),
+			statement.Number,
+			statement.InstallerName,
+			statement.InstallerType,
+			payrollStatusNames[statement.Status],
+			fmt.Sprintf("%d", statement.InstallationsCount),
+			decimal.NewFromInt(int64(statement.WorkMinutes)).Div(decimal.NewFromInt(60)).StringFixed(2),
+			decimal.NewFromInt(int64(statement.TravelMinutes)).Div(decimal.NewFromInt(60)).StringFixed(2),
+			statement.PieceAmount.StringFixed(2),
+			statement.HourlyAmount.StringFixed(2),
+			statement.TravelAmount.StringFixed(2),
+			statement.PenaltyAmount.StringFixed(2),
+			statement.TotalAmount.StringFixed(2),
+		})
+		total = total.Add(statement.TotalAmount)
+	}
+
+	baseName := "payroll_" + month
+	if format == "csv" {
+		var buf bytes.Buffer
+		buf.WriteString("\xef\xbb\xbf") // BOM для корректного открытия в Excel
+		writer := csv.NewWriter(&buf)
+		writer.Comma = ';'
+		_ = writer.Write(headers)
+		_ = writer.WriteAll(rows)
+		if err := writer.Error(); err != nil {
+			return nil, "", fmt.Errorf("ошибка при формировании CSV: %w", err)
+		}
+		return buf.Bytes(), baseName + ".csv", nil
+	}
+
+	f := excelize.NewFile()
+	defer f.Close()
+	sheet := "Ведомость"
+	f.SetSheetName(f.GetSheetName(0), sheet)
+	f.SetCellValue(sheet, "A1", "Ведомость начислений монтажникам за "+month)
+	for col, header := range headers {
+		cell, _ := excelize.CoordinatesToCellName(col+1, 3)
+		f.SetCellValue(sheet, cell, header)
+	}
+	for rowIdx, row := range rows {
+		for col, value := range row {
+			cell, _ := excelize.CoordinatesToCellName(col+1, rowIdx+4)
+			f.SetCellValue(sheet, cell, value)
+		}
+	}
+	totalRow := len(rows) + 4
+	labelCell, _ := excelize.CoordinatesToCellName(len(headers)-1, totalRow)
+	totalCell, _ := excelize.CoordinatesToCellName(len(headers), totalRow)
+	f.SetCellValue(sheet, labelCell, "Итого")
+	f.SetCellValue(sheet, totalCell, total.StringFixed(2))
+
+	linesSheet := "Начисления"
+	f.NewSheet(linesSheet)
+	lineHeaders := []string{"Расчетный лист", "Монтажник", "Монтаж", "Работа", "Минут работы", "Минут в пути", "Оценка",
+		"Сдельно", "Повременно", "Дорога", "Удержание", "Итого", "Примечание"}
+	for col, header := range lineHeaders {
+		cell, _ := excelize.CoordinatesToCellName(col+1, 1)
+		f.SetCellValue(linesSheet, cell, header)
+	}
+
+	row := 2
+	for _, statement := range statements {
+		for _, line := range statement.Lines {
+			rating := ""
+			if line.QualityRating != nil {
+				rating = fmt.Sprintf("%.1f", *line.QualityRating)
+			}
+			values := []interface{}{
+				statement.Number, statement.InstallerName, line.InstallationID, line.Description,
+				line.ActualDuration, line.TravelTime, rating,
+				line.PieceAmount.StringFixed(2), line.HourlyAmount.StringFixed(2), line.TravelAmount.StringFixed(2),
+				line.PenaltyAmount.StringFixed(2), line.TotalAmount.StringFixed(2), line.Note,
+			}
+			for col, value := range values {
+				cell, _ := excelize.CoordinatesToCellName(col+1, row)
+				f.SetCellValue(linesSheet, cell, value)
+			}
+			row++
+		}
+	}
+
+	buf, err := f.WriteToBuffer()
+	if err != nil {
+		return nil, "", fmt.Errorf("ошибка при формировании Excel файла: %w", err)
+	}
+	return buf.Bytes(), baseName + ".xlsx", nil
+}
@@ -0,0 +1,208 @@
+package services
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xuri/excelize/v2"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"backend_axenta/models"
+)
+
+func float32Ptr(v float32) *float32 { return &v }
+
+func TestCalculatePayrollLine(t *testing.T) {
+	completed := time.Date(2024, 3, 12, 15, 0, 0, 0, time.UTC)
+	installer := &models.Installer{ID: 7, Type: "contractor", HourlyRate: decimal.NewFromInt(600)}
+	installerID := uint(7)
+	rates := []models.PayrollRate{
+		{ID: 1, PieceRate: decimal.NewFromInt(1000), IsActive: true},
+		{ID: 2, InstallationType: "монтаж", PieceRate: decimal.NewFromInt(1500), IsActive: true},
+		{ID: 3, InstallerType: "contractor", InstallationType: "монтаж", PieceRate: decimal.NewFromInt(1800),
+			TravelRate: decimal.NewFromInt(300), TravelFixed: decimal.NewFromInt(200), IsActive: true},
+		{ID: 4, InstallerID: &installerID, PieceRate: decimal.NewFromInt(2000), PayHourly: true, IsActive: true,
+			ValidTo: func() *time.Time { v := completed.AddDate(0, 0, -1); return &v }()},
+		{ID: 5, InstallerType: "contractor", InstallationType: "монтаж", PieceRate: decimal.NewFromInt(9999), IsActive: false},
+	}
+	rules := []models.PayrollPenaltyRule{
+		{ID: 1, RatingBelow: 4, PenaltyPct: decimal.NewFromInt(10), IsActive: true},
+		{ID: 2, RatingBelow: 3, PenaltyPct: decimal.NewFromInt(50), PenaltyAmount: decimal.NewFromInt(100), IsActive: true},
+	}
+
+	installation := &models.Installation{
+		ID: 11, Type: "монтаж", CompletedAt: &completed, ActualDuration: 90, TravelTime: 40, QualityRating: float32Ptr(3.5),
+	}
+
+	// Ставка монтажника истекла, выбирается ставка для подрядчиков на этот вид работ
+	line := CalculatePayrollLine(installation, installer, rates, rules)
+	require.NotNil(t, line.RateID)
+	assert.Equal(t, uint(3), *line.RateID)
+	assert.Equal(t, "1800", line.PieceAmount.String())
+	assert.True(t, line.HourlyAmount.IsZero())
+	assert.Equal(t, "400", line.TravelAmount.String()) // 200 + 300 * 40 / 60
+	assert.Equal(t, "180", line.PenaltyAmount.String())
+	assert.Equal(t, "2020", line.TotalAmount.String())
+
+	// Ставка монтажника с повременной оплатой; при оценке ниже 3 применяется самое строгое правило
+	rates[3].ValidTo = nil
+	installation.QualityRating = float32Ptr(2)
+	line = CalculatePayrollLine(installation, installer, rates, rules)
+	assert.Equal(t, uint(4), *line.RateID)
+	assert.Equal(t, "900", line.HourlyAmount.String())
+	assert.Equal(t, "1550", line.PenaltyAmount.String()) // 50% от 2900 + 100
+	assert.Equal(t, "1350", line.TotalAmount.String())
+
+	// Удержание не превышает начисление за работу
+	rules[1].PenaltyAmount = decimal.NewFromInt(10000)
+	line = CalculatePayrollLine(installation, installer, rates, rules)
+	assert.Equal(t, "2900", line.PenaltyAmount.String())
+	assert.True(t, line.TotalAmount.IsZero())
+
+	// Без ставок - стоимость работ из монтажа без повременной оплаты, строка требует проверки
+	installation.QualityRating = nil
+	installation.LaborCost = 1200
+	line = CalculatePayrollLine(installation, installer, nil, rules)
+	assert.Nil(t, line.RateID)
+	assert.True(t, line.NeedsReview)
+	assert.Equal(t, "1200", line.PieceAmount.String())
+	assert.True(t, line.HourlyAmount.IsZero())
+	assert.Equal(t, "1200", line.TotalAmount.String())
+
+	// Стоимость работ не указана - время по часовой ставке монтажника
+	installation.LaborCost = 0
+	line = CalculatePayrollLine(installation, installer, nil, rules)
+	assert.True(t, line.NeedsReview)
+	assert.True(t, line.PieceAmount.IsZero())
+	assert.Equal(t, "900", line.HourlyAmount.String())
+	assert.Equal(t, "900", line.TotalAmount.String())
+
+	// Монтажник не найден и стоимость не указана
+	line = CalculatePayrollLine(installation, nil, nil, nil)
+	assert.True(t, line.TotalAmount.IsZero())
+	assert.True(t, line.NeedsReview)
+	assert.Equal(t, "Не найдена ставка", line.Note)
+}
+
+func TestPayrollService_Statements(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(
+		&models.Object{},
+		&models.Installation{},
+		&models.PayrollRate{},
+		&models.PayrollPenaltyRule{},
+		&models.PayrollStatement{},
+		&models.PayrollStatementLine{},
+	))
+
+	object := models.Object{Name: "Газель А123ВС", IMEI: "356938035643809"}
+	require.NoError(t, db.Create(&object).Error)
+
+	service := NewPayrollService(db)
+	service.Timezone = "Europe/Moscow"
+	_, err = service.CreateRate(PayrollRateRequest{Name: "Монтаж", InstallationType: "монтаж", PieceRate: decimal.NewFromInt(1500), TravelRate: decimal.NewFromInt(300)})
+	require.NoError(t, err)
+	_, err = service.CreatePenaltyRule(PayrollPenaltyRuleRequest{Name: "Низкая оценка", RatingBelow: 4, PenaltyPct: decimal.NewFromInt(20)})
+	require.NoError(t, err)
+
+	msk := models.LoadTimezone("Europe/Moscow")
+	create := func(installerID uint, status string, completedAt time.Time, rating *float32) {
+		installation := models.Installation{
+			Type: "монтаж", Status: status, ScheduledAt: completedAt, CompletedAt: &completedAt,
+			ObjectID: object.ID, InstallerID: installerID, TravelTime: 60, QualityRating: rating, CompanyID: 3,
+		}
+		require.NoError(t, db.Create(&installation).Error)
+	}
+	create(1, "completed", time.Date(2024, 3, 1, 1, 0, 0, 0, msk), nil)
+	create(1, "completed", time.Date(2024, 3, 20, 12, 0, 0, 0, msk), float32Ptr(3))
+	create(2, "completed", time.Date(2024, 3, 31, 23, 0, 0, 0, msk), nil)
+	create(2, "cancelled", time.Date(2024, 3, 10, 12, 0, 0, 0, msk), nil)
+	create(2, "completed", time.Date(2024, 4, 1, 0, 30, 0, 0, msk), nil)
+
+	_, err = service.GenerateStatements(PayrollGenerateRequest{Month: "2024/03"})
+	require.Error(t, err)
+
+	statements, err := service.GenerateStatements(PayrollGenerateRequest{Month: "2024-03"})
+	require.NoError(t, err)
+	require.Len(t, statements, 2)
+
+	first := statements[0]
+	assert.Equal(t, "РЛ-202403-0001", first.Number)
+	assert.Equal(t, models.PayrollStatusDraft, first.Status)
+	assert.Equal(t, uint(3), first.CompanyID)
+	assert.Equal(t, 2, first.InstallationsCount)
+	require.Len(t, first.Lines, 2)
+	assert.Equal(t, "3000", first.PieceAmount.String())
+	assert.Equal(t, "600", first.TravelAmount.String())
+	assert.Equal(t, "300", first.PenaltyAmount.String())
+	assert.Equal(t, "3300", first.TotalAmount.String())
+	assert.Equal(t, 1, statements[1].InstallationsCount)
+
+	// Утвержденный лист не пересчитывается при изменении ставок
+	_, err = service.MarkStatementPaid(first.ID, nil)
+	require.Error(t, err)
+	approved, err := service.ApproveStatement(first.ID, 5)
+	require.NoError(t, err)
+	assert.Equal(t, models.PayrollStatusApproved, approved.Status)
+	require.NotNil(t, approved.ApprovedByUserID)
+
+	rates, err := service.GetRates(nil)
+	require.NoError(t, err)
+	_, err = service.UpdateRate(rates[0].ID, PayrollRateRequest{Name: "Монтаж", InstallationType: "монтаж", PieceRate: decimal.NewFromInt(2000)})
+	require.NoError(t, err)
+
+	statements, err = service.GenerateStatements(PayrollGenerateRequest{Month: "2024-03"})
+	require.NoError(t, err)
+	assert.Equal(t, "3300", statements[0].TotalAmount.String())
+	assert.Equal(t, "2000", statements[1].TotalAmount.String())
+
+	var lines int64
+	db.Model(&models.PayrollStatementLine{}).Count(&lines)
+	assert.Equal(t, int64(3), lines)
+
+	// После возврата в черновик лист пересчитывается по новой ставке
+	_, err = service.ReopenStatement(first.ID)
+	require.NoError(t, err)
+	statements, err = service.GenerateStatements(PayrollGenerateRequest{Month: "2024-03", InstallerIDs: []uint{1}})
+	require.NoError(t, err)
+	require.Len(t, statements, 1)
+	assert.Equal(t, "3600", statements[0].TotalAmount.String()) // 4000 - 20% от 2000
+
+	_, err = service.ApproveStatement(first.ID, 5)
+	require.NoError(t, err)
+	paid, err := service.MarkStatementPaid(first.ID, nil)
+	require.NoError(t, err)
+	assert.Equal(t, models.PayrollStatusPaid, paid.Status)
+	assert.NotNil(t, paid.PaidAt)
+
+	list, err := service.GetStatements("2024-03", nil, models.PayrollStatusPaid)
+	require.NoError(t, err)
+	assert.Len(t, list, 1)
+
+	data, fileName, err := service.ExportStatements("2024-03", "xlsx")
+	require.NoError(t, err)
+	assert.Equal(t, "payroll_2024-03.xlsx", fileName)
+	f, err := excelize.OpenReader(bytes.NewReader(data))
+	require.NoError(t, err)
+	defer f.Close()
+	total, err := f.GetCellValue("Ведомость", "M6")
+	require.NoError(t, err)
+	assert.Equal(t, "5600.00", total)
+	lineRows, err := f.GetRows("Начисления")
+	require.NoError(t, err)
+	assert.Len(t, lineRows, 4)
+
+	data, fileName, err = service.ExportStatements("2024-03", "csv")
+	require.NoError(t, err)
+	assert.Equal(t, "payroll_2024-03.csv", fileName)
+	assert.Contains(t, string(data), "РЛ-202403-0002;")
+}
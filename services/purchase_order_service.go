@@ -0,0 +1,654 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+
+	"backend_axenta/models"
+)
+
+// defaultReplenishmentHorizonDays горизонт учета запланированных монтажей, если у категории не указан срок поставки
+const defaultReplenishmentHorizonDays = 14
+
+// PurchaseOrderService ведет заказы поставщикам и планирование пополнения запасов
+type PurchaseOrderService struct {
+	DB *gorm.DB
+}
+
+// NewPurchaseOrderService создает новый экземпляр PurchaseOrderService
+func NewPurchaseOrderService(db *gorm.DB) *PurchaseOrderService {
+	return &PurchaseOrderService{DB: db}
+}
+
+// PurchaseOrderLine строка заказа поставщику
+type PurchaseOrderLine struct {
+	CategoryID    *uint           `json:"category_id"`
+	EquipmentType string          `json:"equipment_type"`
+	Model         string          `json:"model"`
+	Brand         string          `json:"brand"`
+	Quantity      int             `json:"quantity"`
+	UnitPrice     decimal.Decimal `json:"unit_price"`
+}
+
+// PurchaseOrderRequest данные для создания заказа поставщику
+type PurchaseOrderRequest struct {
+	SupplierName string              `json:"supplier_name"`
+	SupplierINN  string              `json:"supplier_inn"`
+	WarehouseID  uint                `json:"warehouse_id"`
+	ExpectedAt   *time.Time          `json:"expected_at"`
+	Notes        string              `json:"notes"`
+	UserID       uint                `json:"-"`
+	Items        []PurchaseOrderLine `json:"items"`
+}
+
+// ReplenishmentSuggestion рекомендация по заказу для категории оборудования.
+// Доступный запас = на складе - требуется для запланированных монтажей + в открытых заказах.
+type ReplenishmentSuggestion struct {
+	CategoryID    uint   `json:"category_id"`
+	CategoryName  string `json:"category_name"`
+	InStock       int64  `json:"in_stock"`       // Свободно на складе
+	Reserved      int64  `json:"reserved"`       // Зарезервировано (в остаток не входит)
+	PlannedDemand int64  `json:"planned_demand"` // Свободное оборудование, назначенное на монтажи в горизонте планирования
+	OnOrder       int64  `json:"on_order"`       // Ожидается по открытым заказам
+	Available     int64  `json:"available"`
+	ReorderPoint  int    `json:"reorder_point"`
+	HorizonDays   int    `json:"horizon_days"`
+
+	SuggestedQuantity int             `json:"suggested_quantity"`
+	SupplierName      string          `json:"supplier_name"`
+	SupplierINN       string          `json:"supplier_inn"`
+	EquipmentType     string          `json:"equipment_type"`
+	Model             string          `json:"model"`
+	Brand             string          `json:"brand"`
+	UnitPrice         decimal.Decimal `json:"unit_price"` // Цена последней закупки
+	Amount            decimal.Decimal `json:"amount"`
+	ExpectedAt        time.Time       `json:"expected_at"`
+
+	AlertID *uint `json:"alert_id,omitempty"` // Активное уведомление о низком остатке
+}
+
+// ReplenishmentOrderRequest формирование заказов по плану пополнения
+type ReplenishmentOrderRequest struct {
+	WarehouseID  uint   `json:"warehouse_id"`
+	CategoryIDs  []uint `json:"category_ids"`  // Пусто - все категории, требующие пополнения
+	HorizonDays  int    `json:"horizon_days"`  // 0 - срок поставки категории
+	SupplierName string `json:"supplier_name"` // Поставщик для категорий без основного поставщика
+	SupplierINN  string `json:"supplier_inn"`
+	UserID       uint   `json:"-"`
+}
+
+// ReplenishmentSettings параметры пополнения категории оборудования
+type ReplenishmentSettings struct {
+	ReorderPoint          int    `json:"reorder_point"`
+	ReorderQuantity       int    `json:"reorder_quantity"`
+	LeadTimeDays          int    `json:"lead_time_days"`
+	PreferredSupplierName string `json:"preferred_supplier_name"`
+	PreferredSupplierINN  string `json:"preferred_supplier_inn"`
+}
+
+// openPurchaseOrderStatuses статусы заказов, по которым ожидается поставка
+var openPurchaseOrderStatuses = []string{
+	models.PurchaseOrderStatusDraft,
+	models.PurchaseOrderStatusOrdered,
+	models.PurchaseOrderStatusPartiallyReceived,
+}
+
+// UpdateReplenishmentSettings задает точку заказа, размер партии, срок поставки и поставщика категории
+func (s *PurchaseOrderService) UpdateReplenishmentSettings(categoryID uint, settings ReplenishmentSettings) (*models.EquipmentCategory, error) {
+	if settings.ReorderPoint < 0 || settings.ReorderQuantity < 0 || settings.LeadTimeDays < 0 {
+		return nil, fmt.Errorf("параметры пополнения не могут быть отрицательными")
+	}
+
+	var category models.EquipmentCategory
+	if err := s.DB.First(&category, categoryID).Error; err != nil {
+		return nil, fmt.Errorf("категория оборудования не найдена: %w", err)
+	}
+	if err := s.DB.Model(&category).Updates(map[string]interface{}{
+		"reorder_point":           settings.ReorderPoint,
+		"reorder_quantity":        settings.ReorderQuantity,
+		"lead_time_days":          settings.LeadTimeDays,
+		"preferred_supplier_name": strings.TrimSpace(settings.PreferredSupplierName),
+		"preferred_supplier_inn":  strings.TrimSpace(settings.PreferredSupplierINN),
+	}).Error; err != nil {
+		return nil, fmt.Errorf("ошибка при обновлении категории: %w", err)
+	}
+	return &category, s.DB.First(&category, categoryID).Error
+}
+
+// CreatePurchaseOrder создает черновик заказа поставщику
+func (s *PurchaseOrderService) CreatePurchaseOrder(req PurchaseOrderRequest) (*models.PurchaseOrder, error) {
+	var order *models.PurchaseOrder
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		order, err = createPurchaseOrder(tx, req, false)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetPurchaseOrder(order.ID)
+}
+
+// createPurchaseOrder проверяет и сохраняет заказ поставщику в транзакции
+func createPurchaseOrder(tx *gorm.DB, req PurchaseOrderRequest, fromReplenishment bool) (*models.PurchaseOrder, error) {
+	req.SupplierName = strings.TrimSpace(req.SupplierName)
+	if req.SupplierName == "" {
+		return nil, fmt.Errorf("не указан поставщик")
+	}
+	if len(req.Items) == 0 {
+		return nil, fmt.Errorf("заказ не содержит позиций")
+	}
+
+	var warehouse models.Warehouse
+	if err := tx.First(&warehouse, req.WarehouseID).Error; err != nil {
+		return nil, fmt.Errorf("склад не найден: %w", err)
+	}
+	if !warehouse.IsActive {
+		return nil, fmt.Errorf("склад %s отключен", warehouse.Code)
+	}
+
+	items := make([]models.PurchaseOrderItem, 0, len(req.Items))
+	total := decimal.Zero
+	for i, line := range req.Items {
+		if strings.TrimSpace(line.EquipmentType) == "" || strings.TrimSpace(line.Model) == "" {
+			return nil, fmt.Errorf("позиция %d: не указаны тип и модель оборудования", i+1)
+		}
+		if line.Quantity <= 0 {
+			return nil, fmt.Errorf("позиция %d: количество должно быть больше нуля", i+1)
+		}
+		if line.UnitPrice.IsNegative() {
+			return nil, fmt.Errorf("позиция %d: цена не может быть отрицательной", i+1)
+		}
+		if line.CategoryID != nil {
+			var category models.EquipmentCategory
+			if err := tx.First(&category, *line.CategoryID).Error; err != nil {
+				return nil, fmt.Errorf("позиция %d: категория оборудования не найдена: %w", i+1, err)
+			}
+		}
+
+		amount := line.UnitPrice.Mul(decimal.NewFromInt(int64(line.Quantity)))
+		total = total.Add(amount)
+		items = append(items, models.PurchaseOrderItem{
+			LineNumber:    i + 1,
+			CategoryID:    line.CategoryID,
+			EquipmentType: strings.TrimSpace(line.EquipmentType),
+			Model:         strings.TrimSpace(line.Model),
+			Brand:         strings.TrimSpace(line.Brand),
+			Quantity:      line.Quantity,
+			UnitPrice:     line.UnitPrice,
+			Amount:        amount,
+		})
+	}
+
+	now := time.Now()
+	order := &models.PurchaseOrder{
+		Status:            models.PurchaseOrderStatusDraft,
+		SupplierName:      req.SupplierName,
+		SupplierINN:       strings.TrimSpace(req.SupplierINN),
+		WarehouseID:       warehouse.ID,
+		ExpectedAt:        req.ExpectedAt,
+		TotalAmount:       total,
+		Notes:             req.Notes,
+		FromReplenishment: fromReplenishment,
+		UserID:            req.UserID,
+		Items:             items,
+		CompanyID:         warehouse.CompanyID,
+	}
+	if err := createNumberedDocument(tx, order, "PO", now, func(number string) { order.Number = number }); err != nil {
+		return nil, fmt.Errorf("ошибка при создании заказа: %w", err)
+	}
+	return order, nil
+}
+
+// GetPurchaseOrder возвращает заказ поставщику с позициями и полученными партиями
+func (s *PurchaseOrderService) GetPurchaseOrder(id uint) (*models.PurchaseOrder, error) {
+	var order models.PurchaseOrder
+	if err := s.DB.Preload("Warehouse").Preload("User").
+		Preload("Items", func(db *gorm.DB) *gorm.DB { return db.Order("line_number") }).
+		Preload("Items.Category").
+		Preload("Receipts", func(db *gorm.DB) *gorm.DB { return db.Order("received_at") }).
+		First(&order, id).Error; err != nil {
+		return nil, fmt.Errorf("заказ не найден: %w", err)
+	}
+	return &order, nil
+}
+
+// GetPurchaseOrders возвращает список заказов поставщикам
+func (s *PurchaseOrderService) GetPurchaseOrders(status, supplier string, warehouseID *uint, limit, offset int) ([]models.PurchaseOrder, int64, error) {
+	query := s.DB.Model(&models.PurchaseOrder{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if supplier != "" {
+		query = query.Where("LOWER(supplier_name) LIKE ?", "%"+strings.ToLower(supplier)+"%")
+	}
+	if warehouseID != nil {
+		query = query.Where("warehouse_id = ?", *warehouseID)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("ошибка при подсчете заказов: %w", err)
+	}
+
+	var orders []models.PurchaseOrder
+	if err := query.Preload("Warehouse").Preload("Items", func(db *gorm.DB) *gorm.DB { return db.Order("line_number") }).
+		Order("created_at DESC, id DESC").Limit(limit).Offset(offset).
+		Find(&orders).Error; err != nil {
+		return nil, 0, fmt.Errorf("ошибка при получении заказов: %w", err)
+	}
+	return orders, total, nil
+}
+
+// lockPurchaseOrder читает заказ с блокировкой строки до конца транзакции
+func lockPurchaseOrder(tx *gorm.DB, id uint) (*models.PurchaseOrder, error) {
+	var order models.PurchaseOrder
+	if err := tx.Clauses(lockingForUpdate).Preload("Items").First(&order, id).Error; err != nil {
+		return nil, fmt.Errorf("заказ не найден: %w", err)
+	}
+	return &order, nil
+}
+
+// SubmitPurchaseOrder отправляет черновик заказа поставщику. Если ожидаемая дата поставки
+// не указана, она рассчитывается по наибольшему сроку поставки категорий заказа.
+func (s *PurchaseOrderService) SubmitPurchaseOrder(id uint, expectedAt *time.Time) (*models.PurchaseOrder, error) {
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		order, err := lockPurchaseOrder(tx, id)
+		if err != nil {
+			return err
+		}
+		if order.Status != models.PurchaseOrderStatusDraft {
+			return fmt.Errorf("отправить поставщику можно только черновик заказа")
+		}
+
+		now := time.Now()
+		if expectedAt == nil {
+			expectedAt = order.ExpectedAt
+		}
+		if expectedAt == nil {
+			leadTime, err := purchaseOrderLeadTime(tx, order)
+			if err != nil {
+				return err
+			}
+			if leadTime > 0 {
+				expected := now.AddDate(0, 0, leadTime)
+				expectedAt = &expected
+			}
+		}
+
+		return tx.Model(&models.PurchaseOrder{}).Where("id = ?", order.ID).Updates(map[string]interface{}{
+			"status":      models.PurchaseOrderStatusOrdered,
+			"ordered_at":  now,
+			"expected_at": expectedAt,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetPurchaseOrder(id)
+}
+
+// purchaseOrderLeadTime наибольший срок поставки среди категорий позиций заказа
+func purchaseOrderLeadTime(tx *gorm.DB, order *models.PurchaseOrder) (int, error) {
+	var categoryIDs []uint
+	for _, item := range order.Items {
+		if item.CategoryID != nil {
+			categoryIDs = append(categoryIDs, *item.CategoryID)
+		}
+	}
+	if len(categoryIDs) == 0 {
+		return 0, nil
+	}
+	var leadTime int
+	if err := tx.Model(&models.EquipmentCategory{}).Where("id IN ?", categoryIDs).
+		Select("COALESCE(MAX(lead_time_days), 0)").Scan(&leadTime).Error; err != nil {
+		return 0, fmt.Errorf("ошибка при расчете срока поставки: %w", err)
+	}
+	return leadTime, nil
+}
+
+// CancelPurchaseOrder отменяет заказ. Если часть поставки уже получена,
+// заказ закрывается с недопоставкой.
+func (s *PurchaseOrderService) CancelPurchaseOrder(id uint, reason string) (*models.PurchaseOrder, error) {
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		order, err := lockPurchaseOrder(tx, id)
+		if err != nil {
+			return err
+		}
+		if !order.IsOpen() {
+			return fmt.Errorf("заказ %s уже закрыт", order.Number)
+		}
+
+		status := models.PurchaseOrderStatusCancelled
+		if order.Status == models.PurchaseOrderStatusPartiallyReceived {
+			status = models.PurchaseOrderStatusClosed
+		}
+		notes := order.Notes
+		if reason = strings.TrimSpace(reason); reason != "" {
+			notes = strings.TrimSpace(notes + "\n" + reason)
+		}
+		return tx.Model(&models.PurchaseOrder{}).Where("id = ?", order.ID).Updates(map[string]interface{}{
+			"status":    status,
+			"closed_at": time.Now(),
+			"notes":     notes,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetPurchaseOrder(id)
+}
+
+// receivePurchaseOrderItem отмечает получение партии по позиции заказа и пересчитывает статус заказа.
+// Получать можно только по отправленному поставщику заказу и не больше остатка позиции.
+func receivePurchaseOrderItem(tx *gorm.DB, itemID uint, quantity int) (*models.PurchaseOrder, *models.PurchaseOrderItem, error) {
+	var item models.PurchaseOrderItem
+	if err := tx.First(&item, itemID).Error; err != nil {
+		return nil, nil, fmt.Errorf("позиция заказа не найдена: %w", err)
+	}
+	order, err := lockPurchaseOrder(tx, item.PurchaseOrderID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if order.Status != models.PurchaseOrderStatusOrdered && order.Status != models.PurchaseOrderStatusPartiallyReceived {
+		return nil, nil, fmt.Errorf("заказ %s не ожидает поставки", order.Number)
+	}
+
+	var current *models.PurchaseOrderItem
+	for i := range order.Items {
+		if order.Items[i].ID == item.ID {
+			current = &order.Items[i]
+		}
+	}
+	if quantity > current.RemainingQuantity() {
+		return nil, nil, fmt.Errorf("поступает %d шт., по позиции %d заказа %s ожидается %d шт.",
+			quantity, current.LineNumber, order.Number, current.RemainingQuantity())
+	}
+	current.ReceivedQuantity += quantity
+	if err := tx.Model(&models.PurchaseOrderItem{}).Where("id = ?", current.ID).
+		Update("received_quantity", current.ReceivedQuantity).Error; err != nil {
+		return nil, nil, fmt.Errorf("ошибка при обновлении позиции заказа: %w", err)
+	}
+
+	updates := map[string]interface{}{"status": models.PurchaseOrderStatusReceived, "closed_at": time.Now()}
+	for _, orderItem := range order.Items {
+		if orderItem.RemainingQuantity() > 0 {
+			updates = map[string]interface{}{"status": models.PurchaseOrderStatusPartiallyReceived}
+			break
+		}
+	}
+	if err := tx.Model(&models.PurchaseOrder{}).Where("id = ?", order.ID).Updates(updates).Error; err != nil {
+		return nil, nil, fmt.Errorf("ошибка при обновлении заказа: %w", err)
+	}
+	order.Status = updates["status"].(string)
+
+	return order, current, nil
+}
+
+// GetReplenishmentPlan рассчитывает, какие категории оборудования пора заказать.
+// Категория попадает в план, когда доступный запас не превышает точку заказа; количество
+// кратно размеру партии и поднимает запас выше точки заказа. Учитываются свободное
+// оборудование, назначенное на монтажи в пределах срока поставки (или horizonDays),
+// и открытые заказы поставщикам.
+func (s *PurchaseOrderService) GetReplenishmentPlan(warehouseID *uint, horizonDays int) ([]ReplenishmentSuggestion, error) {
+	var categories []models.EquipmentCategory
+	if err := s.DB.Where("is_active = ?", true).Order("name").Find(&categories).Error; err != nil {
+		return nil, fmt.Errorf("ошибка при получении категорий: %w", err)
+	}
+
+	now := time.Now()
+	suggestions := make([]ReplenishmentSuggestion, 0)
+	for _, category := range categories {
+		reorderPoint := category.ReorderPoint
+		if reorderPoint <= 0 {
+			reorderPoint = category.MinStockLevel
+		}
+		if reorderPoint <= 0 {
+			continue
+		}
+
+		horizon := horizonDays
+		if horizon <= 0 {
+			horizon = category.LeadTimeDays
+		}
+		if horizon <= 0 {
+			horizon = defaultReplenishmentHorizonDays
+		}
+
+		suggestion := ReplenishmentSuggestion{
+			CategoryID:   category.ID,
+			CategoryName: category.Name,
+			ReorderPoint: reorderPoint,
+			HorizonDays:  horizon,
+		}
+		if err := s.fillStockPosition(&suggestion, warehouseID, now.AddDate(0, 0, horizon)); err != nil {
+			return nil, err
+		}
+		suggestion.Available = suggestion.InStock - suggestion.PlannedDemand + suggestion.OnOrder
+		if suggestion.Available > int64(reorderPoint) {
+			continue
+		}
+
+		batch := category.ReorderQuantity
+		if batch <= 0 {
+			batch = reorderPoint
+		}
+		deficit := int64(reorderPoint) - suggestion.Available
+		suggestion.SuggestedQuantity = int(deficit/int64(batch)+1) * batch
+
+		if err := s.fillPurchaseDefaults(&suggestion, category); err != nil {
+			return nil, err
+		}
+		suggestion.Amount = suggestion.UnitPrice.Mul(decimal.NewFromInt(int64(suggestion.SuggestedQuantity)))
+		suggestion.ExpectedAt = now.AddDate(0, 0, category.LeadTimeDays)
+
+		var alert models.StockAlert
+		if err := s.DB.Where("equipment_category_id = ? AND type = ? AND status = ?", category.ID, "low_stock", "active").
+			Order("id DESC").Limit(1).Find(&alert).Error; err != nil {
+			return nil, fmt.Errorf("ошибка при получении уведомлений: %w", err)
+		}
+		if alert.ID != 0 {
+			suggestion.AlertID = &alert.ID
+		}
+
+		suggestions = append(suggestions, suggestion)
+	}
+
+	return suggestions, nil
+}
+
+// fillStockPosition считает остаток, резерв, потребность монтажей и ожидаемые поставки категории
+func (s *PurchaseOrderService) fillStockPosition(suggestion *ReplenishmentSuggestion, warehouseID *uint, horizon time.Time) error {
+	equipment := func() *gorm.DB {
+		query := s.DB.Model(&models.Equipment{}).Where("equipment.category_id = ?", suggestion.CategoryID)
+		if warehouseID != nil {
+			query = query.Where("equipment.warehouse_id = ?", *warehouseID)
+		}
+		return query
+	}
+
+	if err := equipment().Where("equipment.status = ?", models.EquipmentStatusInStock).
+		Count(&suggestion.InStock).Error; err != nil {
+		return fmt.Errorf("ошибка при подсчете остатка: %w", err)
+	}
+	if err := equipment().Where("equipment.status = ?", models.EquipmentStatusReserved).
+		Count(&suggestion.Reserved).Error; err != nil {
+		return fmt.Errorf("ошибка при подсчете резерва: %w", err)
+	}
+
+	// Свободное оборудование, уже назначенное на предстоящие монтажи, покинет склад
+	if err := equipment().
+		Joins("JOIN installation_equipment ON installation_equipment.equipment_id = equipment.id").
+		Joins("JOIN installations ON installations.id = installation_equipment.installation_id").
+		Where("equipment.status = ?", models.EquipmentStatusInStock).
+		Where("installations.deleted_at IS NULL AND installations.status IN ? AND installations.scheduled_at <= ?",
+			[]string{"planned", "postponed", "in_progress"}, horizon).
+		Distinct("equipment.id").
+		Count(&suggestion.PlannedDemand).Error; err != nil {
+		return fmt.Errorf("ошибка при подсчете потребности монтажей: %w", err)
+	}
+
+	onOrder := s.DB.Table("purchase_order_items").
+		Joins("JOIN purchase_orders ON purchase_orders.id = purchase_order_items.purchase_order_id").
+		Where("purchase_orders.deleted_at IS NULL AND purchase_orders.status IN ?", openPurchaseOrderStatuses).
+		Where("purchase_order_items.category_id = ?", suggestion.CategoryID)
+	if warehouseID != nil {
+		onOrder = onOrder.Where("purchase_orders.warehouse_id = ?", *warehouseID)
+	}
+	if err := onOrder.Select("COALESCE(SUM(purchase_order_items.quantity - purchase_order_items.received_quantity), 0)").
+		Scan(&suggestion.OnOrder).Error; err != nil {
+		return fmt.Errorf("ошибка при подсчете ожидаемых поставок: %w", err)
+	}
+	return nil
+}
+
+// fillPurchaseDefaults подставляет поставщика, модель и цену из настроек категории и последней закупки
+func (s *PurchaseOrderService) fillPurchaseDefaults(suggestion *ReplenishmentSuggestion, category models.EquipmentCategory) error {
+	suggestion.SupplierName = category.PreferredSupplierName
+	suggestion.SupplierINN = category.PreferredSupplierINN
+
+	var receipt models.GoodsReceipt
+	if err := s.DB.Where("category_id = ?", category.ID).Order("received_at DESC, id DESC").
+		Limit(1).Find(&receipt).Error; err != nil {
+		return fmt.Errorf("ошибка при получении последней закупки: %w", err)
+	}
+	if receipt.ID != 0 {
+		if suggestion.SupplierName == "" {
+			suggestion.SupplierName = receipt.SupplierName
+			suggestion.SupplierINN = receipt.SupplierINN
+		}
+		suggestion.EquipmentType = receipt.EquipmentType
+		suggestion.Model = receipt.Model
+		suggestion.Brand = receipt.Brand
+		suggestion.UnitPrice = receipt.UnitPrice
+		return nil
+	}
+
+	var equipment models.Equipment
+	if err := s.DB.Where("category_id = ?", category.ID).Order("id DESC").
+		Limit(1).Find(&equipment).Error; err != nil {
+		return fmt.Errorf("ошибка при получении оборудования категории: %w", err)
+	}
+	if equipment.ID != 0 {
+		suggestion.EquipmentType = equipment.Type
+		suggestion.Model = equipment.Model
+		suggestion.Brand = equipment.Brand
+		suggestion.UnitPrice = equipment.PurchasePrice
+	}
+	return nil
+}
+
+// CreateReplenishmentOrders формирует черновики заказов по плану пополнения: по одному заказу
+// на поставщика. Активные уведомления о низком остатке по заказанным категориям отмечаются прочитанными.
+func (s *PurchaseOrderService) CreateReplenishmentOrders(req ReplenishmentOrderRequest) ([]models.PurchaseOrder, error) {
+	warehouseID := req.WarehouseID
+	plan, err := s.GetReplenishmentPlan(&warehouseID, req.HorizonDays)
+	if err != nil {
+		return nil, err
+	}
+
+	selected := make(map[uint]bool, len(req.CategoryIDs))
+	for _, id := range req.CategoryIDs {
+		selected[id] = true
+	}
+
+	type supplierKey struct{ name, inn string }
+	groups := make(map[supplierKey][]ReplenishmentSuggestion)
+	var keys []supplierKey
+	var problems []string
+	for _, suggestion := range plan {
+		if len(selected) > 0 && !selected[suggestion.CategoryID] {
+			continue
+		}
+		key := supplierKey{suggestion.SupplierName, suggestion.SupplierINN}
+		if key.name == "" {
+			key = supplierKey{strings.TrimSpace(req.SupplierName), strings.TrimSpace(req.SupplierINN)}
+		}
+		if key.name == "" {
+			problems = append(problems, fmt.Sprintf("%s: не указан поставщик", suggestion.CategoryName))
+			continue
+		}
+		if suggestion.Model == "" {
+			problems = append(problems, fmt.Sprintf("%s: нет закупок, модель оборудования неизвестна", suggestion.CategoryName))
+			continue
+		}
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], suggestion)
+	}
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("%s", strings.Join(problems, "; "))
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("нет категорий, требующих пополнения")
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].name < keys[j].name })
+
+	var orderIDs []uint
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		for _, key := range keys {
+			orderReq := PurchaseOrderRequest{
+				SupplierName: key.name,
+				SupplierINN:  key.inn,
+				WarehouseID:  req.WarehouseID,
+				Notes:        "Сформирован по плану пополнения",
+				UserID:       req.UserID,
+			}
+			var alertIDs []uint
+			var expectedAt time.Time
+			for _, suggestion := range groups[key] {
+				categoryID := suggestion.CategoryID
+				orderReq.Items = append(orderReq.Items, PurchaseOrderLine{
+					CategoryID:    &categoryID,
+					EquipmentType: suggestion.EquipmentType,
+					Model:         suggestion.Model,
+					Brand:         suggestion.Brand,
+					Quantity:      suggestion.SuggestedQuantity,
+					UnitPrice:     suggestion.UnitPrice,
+				})
+				if suggestion.AlertID != nil {
+					alertIDs = append(alertIDs, *suggestion.AlertID)
+				}
+				if suggestion.ExpectedAt.After(expectedAt) {
+					expectedAt = suggestion.ExpectedAt
+				}
+			}
+			orderReq.ExpectedAt = &expectedAt
+
+			order, err := createPurchaseOrder(tx, orderReq, true)
+			if err != nil {
+				return err
+			}
+			orderIDs = append(orderIDs, order.ID)
+
+			if len(alertIDs) > 0 {
+				if err := tx.Model(&models.StockAlert{}).Where("id IN ?", alertIDs).Updates(map[string]interface{}{
+					"status":  "acknowledged",
+					"read_at": time.Now(),
+				}).Error; err != nil {
+					return fmt.Errorf("ошибка при обновлении уведомлений: %w", err)
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	orders := make([]models.PurchaseOrder, 0, len(orderIDs))
+	for _, id := range orderIDs {
+		order, err := s.GetPurchaseOrder(id)
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, *order)
+	}
+	return orders, nil
+}
@@ -0,0 +1,228 @@
+package services
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"backend_axenta/models"
+)
+
+func setupPurchaseOrderTest(t *testing.T) (*gorm.DB, *PurchaseOrderService, *models.Warehouse, *models.EquipmentCategory) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(
+		&models.Warehouse{},
+		&models.StorageBin{},
+		&models.Equipment{},
+		&models.EquipmentCategory{},
+		&models.WarehouseOperation{},
+		&models.Installation{},
+		&models.StockAlert{},
+		&models.GoodsReceipt{},
+		&models.GoodsReceiptItem{},
+		&models.PurchaseOrder{},
+		&models.PurchaseOrderItem{},
+	))
+
+	warehouse := &models.Warehouse{Name: "Основной склад", Code: "MSK", Type: models.WarehouseTypeStock, IsActive: true}
+	require.NoError(t, db.Create(warehouse).Error)
+	category := &models.EquipmentCategory{Name: "GPS-трекеры", Code: "GPS", MinStockLevel: 3, IsActive: true}
+	require.NoError(t, db.Create(category).Error)
+
+	service := NewPurchaseOrderService(db)
+	_, err = service.UpdateReplenishmentSettings(category.ID, ReplenishmentSettings{ReorderPoint: 5, ReorderQuantity: 10, LeadTimeDays: 7})
+	require.NoError(t, err)
+
+	return db, service, warehouse, category
+}
+
+func TestPurchaseOrderService_ReplenishmentPlan(t *testing.T) {
+	db, service, warehouse, category := setupPurchaseOrderTest(t)
+
+	receipt, err := NewGoodsReceiptService(db).CreateReceipt(GoodsReceiptRequest{
+		SupplierName: "ООО Навтелеком", WarehouseID: warehouse.ID, CategoryID: &category.ID,
+		EquipmentType: "GPS-tracker", Model: "GT06N", Brand: "Concox", UnitPrice: decimal.NewFromInt(3000),
+		Items: []GoodsReceiptLine{{SerialNumber: "SN-1"}, {SerialNumber: "SN-2"}, {SerialNumber: "SN-3"}, {SerialNumber: "SN-4"}},
+	})
+	require.NoError(t, err)
+
+	// Оборудование назначено на монтаж через 3 дня и через месяц - в горизонт попадает только первый
+	for i, days := range []int{3, 30} {
+		installation := models.Installation{
+			Type: "монтаж", Status: "planned", ScheduledAt: time.Now().AddDate(0, 0, days), ObjectID: 1, InstallerID: 1,
+			Equipment: []models.Equipment{{ID: receipt.Items[i].EquipmentID}},
+		}
+		require.NoError(t, db.Omit("Equipment.*").Create(&installation).Error)
+	}
+	alert := models.StockAlert{Type: "low_stock", Title: "Низкий остаток", EquipmentCategoryID: &category.ID, Status: "active"}
+	require.NoError(t, db.Create(&alert).Error)
+
+	plan, err := service.GetReplenishmentPlan(&warehouse.ID, 0)
+	require.NoError(t, err)
+	require.Len(t, plan, 1)
+	suggestion := plan[0]
+	assert.Equal(t, int64(4), suggestion.InStock)
+	assert.Equal(t, int64(1), suggestion.PlannedDemand)
+	assert.Equal(t, int64(3), suggestion.Available)
+	assert.Equal(t, 7, suggestion.HorizonDays)
+	assert.Equal(t, 10, suggestion.SuggestedQuantity)
+	assert.Equal(t, "ООО Навтелеком", suggestion.SupplierName)
+	assert.Equal(t, "GT06N", suggestion.Model)
+	assert.True(t, decimal.NewFromInt(30000).Equal(suggestion.Amount))
+	require.NotNil(t, suggestion.AlertID)
+
+	// С горизонтом в два месяца учитываются оба монтажа
+	plan, err = service.GetReplenishmentPlan(&warehouse.ID, 60)
+	require.NoError(t, err)
+	require.Len(t, plan, 1)
+	assert.Equal(t, int64(2), plan[0].PlannedDemand)
+
+	orders, err := service.CreateReplenishmentOrders(ReplenishmentOrderRequest{WarehouseID: warehouse.ID})
+	require.NoError(t, err)
+	require.Len(t, orders, 1)
+	order := orders[0]
+	assert.Equal(t, models.PurchaseOrderStatusDraft, order.Status)
+	assert.True(t, order.FromReplenishment)
+	require.Len(t, order.Items, 1)
+	assert.Equal(t, 10, order.Items[0].Quantity)
+	assert.True(t, decimal.NewFromInt(30000).Equal(order.TotalAmount))
+
+	require.NoError(t, db.First(&alert, alert.ID).Error)
+	assert.Equal(t, "acknowledged", alert.Status)
+
+	// Заказанное количество учитывается в плане
+	plan, err = service.GetReplenishmentPlan(&warehouse.ID, 0)
+	require.NoError(t, err)
+	assert.Empty(t, plan)
+}
+
+func TestPurchaseOrderService_PartialReceipts(t *testing.T) {
+	db, service, warehouse, category := setupPurchaseOrderTest(t)
+	receiptService := NewGoodsReceiptService(db)
+
+	order, err := service.CreatePurchaseOrder(PurchaseOrderRequest{
+		SupplierName: "ООО Навтелеком", WarehouseID: warehouse.ID,
+		Items: []PurchaseOrderLine{{CategoryID: &category.ID, EquipmentType: "GPS-tracker", Model: "GT06N", Quantity: 5, UnitPrice: decimal.NewFromInt(3000)}},
+	})
+	require.NoError(t, err)
+	itemID := order.Items[0].ID
+
+	// Черновик еще не отправлен поставщику
+	_, err = receiptService.CreateReceipt(GoodsReceiptRequest{PurchaseOrderItemID: &itemID, Items: []GoodsReceiptLine{{SerialNumber: "SN-1"}}})
+	assert.Error(t, err)
+
+	order, err = service.SubmitPurchaseOrder(order.ID, nil)
+	require.NoError(t, err)
+	assert.Equal(t, models.PurchaseOrderStatusOrdered, order.Status)
+	require.NotNil(t, order.ExpectedAt)
+	assert.WithinDuration(t, time.Now().AddDate(0, 0, 7), *order.ExpectedAt, time.Minute)
+
+	// Реквизиты партии берутся из заказа
+	receipt, err := receiptService.CreateReceipt(GoodsReceiptRequest{
+		PurchaseOrderItemID: &itemID,
+		Items:               []GoodsReceiptLine{{SerialNumber: "SN-1"}, {SerialNumber: "SN-2"}, {SerialNumber: "SN-3"}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "ООО Навтелеком", receipt.SupplierName)
+	assert.Equal(t, "GT06N", receipt.Model)
+	require.NotNil(t, receipt.CategoryID)
+	require.NotNil(t, receipt.PurchaseOrderID)
+	assert.Equal(t, order.ID, *receipt.PurchaseOrderID)
+	assert.True(t, decimal.NewFromInt(9000).Equal(receipt.TotalAmount))
+
+	order, err = service.GetPurchaseOrder(order.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.PurchaseOrderStatusPartiallyReceived, order.Status)
+	assert.Equal(t, 3, order.Items[0].ReceivedQuantity)
+	assert.Len(t, order.Receipts, 1)
+
+	// Поставка сверх заказа не принимается, документ не создается
+	_, err = receiptService.CreateReceipt(GoodsReceiptRequest{
+		PurchaseOrderItemID: &itemID,
+		Items:               []GoodsReceiptLine{{SerialNumber: "SN-4"}, {SerialNumber: "SN-5"}, {SerialNumber: "SN-6"}},
+	})
+	assert.Error(t, err)
+	var equipmentCount int64
+	db.Model(&models.Equipment{}).Count(&equipmentCount)
+	assert.Equal(t, int64(3), equipmentCount)
+
+	order, err = service.CancelPurchaseOrder(order.ID, "Поставщик снял модель с производства")
+	require.NoError(t, err)
+	assert.Equal(t, models.PurchaseOrderStatusClosed, order.Status)
+	require.NotNil(t, order.ClosedAt)
+
+	_, err = service.CancelPurchaseOrder(order.ID, "")
+	assert.Error(t, err)
+}
+
+func TestPurchaseOrderService_ConcurrentNumbering(t *testing.T) {
+	// Файловая БД общая для всех горутин, SQLite выполняет транзакции по очереди
+	dsn := filepath.Join(t.TempDir(), "orders.db") + "?_busy_timeout=5000"
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+	require.NoError(t, db.AutoMigrate(&models.Warehouse{}, &models.EquipmentCategory{}, &models.GoodsReceipt{}, &models.PurchaseOrder{}, &models.PurchaseOrderItem{}))
+
+	warehouse := &models.Warehouse{Name: "Основной склад", Code: "MSK", Type: models.WarehouseTypeStock, IsActive: true}
+	require.NoError(t, db.Create(warehouse).Error)
+
+	// Другая транзакция занимает номер между его формированием и вставкой заказа
+	var competed atomic.Bool
+	require.NoError(t, db.Callback().Query().After("gorm:query").Register("test:competing_order", func(tx *gorm.DB) {
+		if _, ok := tx.Statement.Dest.(*[]string); !ok || tx.Statement.Table != "purchase_orders" || !competed.CompareAndSwap(false, true) {
+			return
+		}
+		number := fmt.Sprintf("PO-%s-001", time.Now().Format("20060102"))
+		tx.Session(&gorm.Session{NewDB: true}).Create(&models.PurchaseOrder{Number: number, Status: models.PurchaseOrderStatusDraft, SupplierName: "ООО Конкурент", WarehouseID: warehouse.ID})
+	}))
+
+	service := NewPurchaseOrderService(db)
+	const managers = 8
+	var wg sync.WaitGroup
+	errs := make(chan error, managers)
+	for i := 0; i < managers; i++ {
+		wg.Add(1)
+		go func(userID uint) {
+			defer wg.Done()
+			_, err := service.CreatePurchaseOrder(PurchaseOrderRequest{
+				SupplierName: "ООО Навтелеком", WarehouseID: warehouse.ID, UserID: userID,
+				Items: []PurchaseOrderLine{{EquipmentType: "GPS-tracker", Model: "GT06N", Quantity: 5, UnitPrice: decimal.NewFromInt(3000)}},
+			})
+			errs <- err
+		}(uint(i + 1))
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		assert.NoError(t, err)
+	}
+	assert.True(t, competed.Load())
+
+	var numbers []string
+	require.NoError(t, db.Model(&models.PurchaseOrder{}).Order("number").Pluck("number", &numbers).Error)
+	require.Len(t, numbers, managers+1)
+	unique := map[string]bool{}
+	for _, number := range numbers {
+		unique[number] = true
+	}
+	assert.Len(t, unique, managers+1)
+	assert.Equal(t, fmt.Sprintf("PO-%s-009", time.Now().Format("20060102")), numbers[managers])
+}
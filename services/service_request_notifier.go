@@ -0,0 +1,182 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"backend_axenta/models"
+)
+
+// serviceRequestNotifyTimeout ограничение времени доставки уведомлений по одной заявке
+const serviceRequestNotifyTimeout = 30 * time.Second
+
+// serviceRequestNotification задание на доставку уведомлений по заявке
+type serviceRequestNotification struct {
+	CompanyID uuid.UUID
+	RequestID uint
+	Attempt   int
+}
+
+// ServiceRequestNotifier доставляет заявителю уведомления о событиях заявки вне обработки HTTP запроса:
+// ставит в очередь вебхук service_request.updated и для заявок из Битрикс24 добавляет
+// комментарии в ленту сделки. Событие отмечается только после успешной доставки,
+// недоставленные комментарии отправляются повторно с интервалами доставки вебхуков.
+type ServiceRequestNotifier struct {
+	DB     *gorm.DB
+	logger *log.Logger
+
+	// postComment добавляет комментарий в ленту сделки Битрикс24 (подменяется в тестах)
+	postComment func(ctx context.Context, companyID uuid.UUID, dealID, text string) error
+
+	queue   chan serviceRequestNotification
+	stop    chan struct{}
+	running bool
+	mu      sync.Mutex
+}
+
+// NewServiceRequestNotifier создает сервис уведомлений заявителей
+func NewServiceRequestNotifier(db *gorm.DB) *ServiceRequestNotifier {
+	logger := log.New(os.Stdout, "[SERVICE_REQUESTS] ", log.LstdFlags)
+	client := NewBitrix24Client(logger)
+	oauth := NewBitrix24OAuthService(db, client, logger)
+
+	return &ServiceRequestNotifier{
+		DB:     db,
+		logger: logger,
+		postComment: func(ctx context.Context, companyID uuid.UUID, dealID, text string) error {
+			credentials, err := oauth.GetCredentials(ctx, companyID)
+			if err != nil {
+				return err
+			}
+			_, err = client.AddTimelineComment(ctx, credentials, "deal", dealID, text)
+			return err
+		},
+		queue: make(chan serviceRequestNotification, 100),
+	}
+}
+
+// Enqueue ставит заявку в очередь доставки уведомлений
+func (n *ServiceRequestNotifier) Enqueue(companyID uuid.UUID, requestID uint) {
+	n.enqueue(serviceRequestNotification{CompanyID: companyID, RequestID: requestID})
+}
+
+func (n *ServiceRequestNotifier) enqueue(job serviceRequestNotification) {
+	select {
+	case n.queue <- job:
+	default:
+		// События остаются недоставленными и будут отправлены при следующем изменении заявки
+		n.logger.Printf("Очередь уведомлений переполнена, заявка %d пропущена", job.RequestID)
+	}
+}
+
+// Start запускает фоновую доставку уведомлений
+func (n *ServiceRequestNotifier) Start() {
+	n.mu.Lock()
+	if n.running {
+		n.mu.Unlock()
+		return
+	}
+	n.running = true
+	n.stop = make(chan struct{})
+	stop := n.stop
+	n.mu.Unlock()
+
+	for {
+		select {
+		case job := <-n.queue:
+			n.process(job)
+		case <-stop:
+			return
+		}
+	}
+}
+
+// Stop останавливает фоновую доставку уведомлений
+func (n *ServiceRequestNotifier) Stop() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.running {
+		close(n.stop)
+		n.running = false
+	}
+}
+
+// process доставляет уведомления и планирует повторную попытку при ошибке
+func (n *ServiceRequestNotifier) process(job serviceRequestNotification) {
+	ctx, cancel := context.WithTimeout(context.Background(), serviceRequestNotifyTimeout)
+	defer cancel()
+
+	err := n.Deliver(ctx, job.CompanyID, job.RequestID)
+	if err == nil {
+		return
+	}
+	if job.Attempt >= len(webhookRetryDelays) {
+		n.logger.Printf("Заявка %d: уведомления не доставлены после %d попыток: %v", job.RequestID, job.Attempt+1, err)
+		return
+	}
+
+	delay := webhookRetryDelays[job.Attempt]
+	n.logger.Printf("Заявка %d: ошибка доставки уведомлений, повтор через %s: %v", job.RequestID, delay, err)
+	job.Attempt++
+	time.AfterFunc(delay, func() { n.enqueue(job) })
+}
+
+// Deliver отправляет недоставленные уведомления по заявке
+func (n *ServiceRequestNotifier) Deliver(ctx context.Context, companyID uuid.UUID, requestID uint) error {
+	requestService := NewServiceRequestService(n.DB)
+	var request models.ServiceRequest
+	if err := n.DB.First(&request, requestID).Error; err != nil {
+		return fmt.Errorf("заявка не найдена: %w", err)
+	}
+
+	events, err := requestService.PendingNotifications(request.ID)
+	if err != nil {
+		return fmt.Errorf("ошибка получения событий заявки: %w", err)
+	}
+	if len(events) > 0 {
+		if globalWebhookService != nil {
+			if _, err := globalWebhookService.Publish(companyID, models.WebhookEventServiceRequestUpdated, map[string]interface{}{
+				"request": request,
+				"events":  events,
+			}); err != nil {
+				return err
+			}
+		}
+
+		ids := make([]uint, 0, len(events))
+		for _, event := range events {
+			ids = append(ids, event.ID)
+		}
+		if err := requestService.MarkNotified(ids); err != nil {
+			return fmt.Errorf("ошибка отметки уведомлений: %w", err)
+		}
+	}
+
+	if request.Source != models.ServiceRequestSourceBitrix24 || request.ExternalID == "" {
+		return nil
+	}
+
+	events, err = requestService.PendingExternalNotifications(request.ID)
+	if err != nil {
+		return fmt.Errorf("ошибка получения событий заявки: %w", err)
+	}
+	// Комментарии добавляются по порядку: при ошибке следующие события ждут повторной попытки
+	for i := range events {
+		text := ServiceRequestNotificationText(&request, &events[i])
+		if err := n.postComment(ctx, companyID, request.ExternalID, text); err != nil {
+			return fmt.Errorf("ошибка добавления комментария в Битрикс24: %w", err)
+		}
+		if err := requestService.MarkExternalNotified(events[i].ID); err != nil {
+			return fmt.Errorf("ошибка отметки уведомления: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"backend_axenta/models"
+)
+
+func TestServiceRequestNotifier_Deliver(t *testing.T) {
+	db := setupServiceRequestTestDB(t)
+	require.NoError(t, db.AutoMigrate(&models.WebhookSubscription{}, &models.WebhookDelivery{}))
+	companyID := uuid.New()
+
+	webhookService := NewWebhookService(db)
+	webhookService.allowInternalHosts = true
+	SetWebhookService(webhookService)
+	defer SetWebhookService(nil)
+	subscription := &models.WebhookSubscription{CompanyID: companyID, Name: "Портал", URL: "http://127.0.0.1:9/hook", IsActive: true}
+	subscription.SetEvents([]string{models.WebhookEventServiceRequestUpdated})
+	require.NoError(t, webhookService.CreateSubscription(subscription))
+
+	requestService := NewServiceRequestService(db)
+	request, _, err := requestService.CreateRequest(ServiceRequestCreate{
+		Source: models.ServiceRequestSourceBitrix24, ExternalID: "deal-42", Subject: "Трекер не на связи",
+	})
+	require.NoError(t, err)
+
+	notifier := NewServiceRequestNotifier(db)
+	var comments []string
+	bitrixErr := errors.New("портал недоступен")
+	notifier.postComment = func(ctx context.Context, company uuid.UUID, dealID, text string) error {
+		assert.Equal(t, companyID, company)
+		assert.Equal(t, "deal-42", dealID)
+		if bitrixErr != nil {
+			return bitrixErr
+		}
+		comments = append(comments, text)
+		return nil
+	}
+
+	// Битрикс24 недоступен: вебхук поставлен в очередь, комментарий ждет повторной попытки
+	err = notifier.Deliver(context.Background(), companyID, request.ID)
+	require.Error(t, err)
+	var event models.ServiceRequestEvent
+	require.NoError(t, db.Where("request_id = ?", request.ID).First(&event).Error)
+	assert.NotNil(t, event.NotifiedAt)
+	assert.Nil(t, event.ExternalNotifiedAt)
+
+	// Повторная попытка добавляет комментарий и не публикует вебхук второй раз
+	bitrixErr = nil
+	require.NoError(t, notifier.Deliver(context.Background(), companyID, request.ID))
+	assert.Len(t, comments, 1)
+	require.NoError(t, db.First(&event, event.ID).Error)
+	assert.NotNil(t, event.ExternalNotifiedAt)
+
+	var deliveries int64
+	require.NoError(t, db.Model(&models.WebhookDelivery{}).Count(&deliveries).Error)
+	assert.Equal(t, int64(1), deliveries)
+
+	require.NoError(t, notifier.Deliver(context.Background(), companyID, request.ID))
+	assert.Len(t, comments, 1)
+
+	// Заявки из других источников в Битрикс24 не отправляются
+	phone, _, err := requestService.CreateRequest(ServiceRequestCreate{Source: models.ServiceRequestSourcePhone, Subject: "Не работает датчик"})
+	require.NoError(t, err)
+	require.NoError(t, notifier.Deliver(context.Background(), companyID, phone.ID))
+	assert.Len(t, comments, 1)
+}
@@ -0,0 +1,723 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"backend_axenta/models"
+)
+
+// ServiceRequestSLA сроки реакции и решения заявки
+type ServiceRequestSLA struct {
+	Response   time.Duration
+	Resolution time.Duration
+}
+
+// DefaultServiceRequestSLA сроки реакции и решения заявок по приоритету
+var DefaultServiceRequestSLA = map[string]ServiceRequestSLA{
+	"urgent": {Response: time.Hour, Resolution: 8 * time.Hour},
+	"high":   {Response: 4 * time.Hour, Resolution: 24 * time.Hour},
+	"normal": {Response: 8 * time.Hour, Resolution: 72 * time.Hour},
+	"low":    {Response: 24 * time.Hour, Resolution: 7 * 24 * time.Hour},
+}
+
+// Допустимые источники заявок
+var serviceRequestSources = map[string]bool{
+	models.ServiceRequestSourcePhone:    true,
+	models.ServiceRequestSourceEmail:    true,
+	models.ServiceRequestSourceBitrix24: true,
+	models.ServiceRequestSourcePortal:   true,
+}
+
+// ServiceRequestService представляет сервис заявок на обслуживание
+type ServiceRequestService struct {
+	DB       *gorm.DB
+	Timezone string // Часовой пояс компании для планирования монтажей по заявке
+}
+
+// NewServiceRequestService создает новый экземпляр ServiceRequestService
+func NewServiceRequestService(db *gorm.DB) *ServiceRequestService {
+	return &ServiceRequestService{DB: db}
+}
+
+// ServiceRequestCreate запрос на регистрацию заявки
+type ServiceRequestCreate struct {
+	Source         string `json:"source" binding:"required"` // phone, email, bitrix24, portal
+	ExternalID     string `json:"external_id"`               // Повторная заявка с тем же источником и ID не создается
+	Priority       string `json:"priority"`
+	Category       string `json:"category"`
+	Subject        string `json:"subject" binding:"required"`
+	Details        string `json:"details"`
+	Address        string `json:"address"`
+	RequesterName  string `json:"requester_name"`
+	RequesterPhone string `json:"requester_phone"`
+	RequesterEmail string `json:"requester_email"`
+	ObjectID       *uint  `json:"object_id"`
+	ObjectIMEI     string `json:"object_imei"`   // Поиск объекта по IMEI, если ID неизвестен
+	LicensePlate   string `json:"license_plate"` // Поиск объекта по госномеру
+	ContractID     *uint  `json:"contract_id"`   // По умолчанию - договор объекта
+	UserID         uint   `json:"-"`
+}
+
+// ServiceRequestFilter фильтр списка заявок
+type ServiceRequestFilter struct {
+	Status         string
+	Priority       string
+	Source         string
+	ObjectID       *uint
+	ContractID     *uint
+	AssignedUserID *uint
+	Overdue        bool // Только заявки с нарушенным сроком реакции или решения
+	Search         string
+	Limit          int
+	Offset         int
+}
+
+// ServiceRequestTriage разбор заявки: уточнение приоритета, объекта и ответственного
+type ServiceRequestTriage struct {
+	Priority       string `json:"priority"`
+	Category       string `json:"category"`
+	ObjectID       *uint  `json:"object_id"`
+	ContractID     *uint  `json:"contract_id"`
+	AssignedUserID *uint  `json:"assigned_user_id"`
+	Message        string `json:"message"` // Сообщение заявителю
+	UserID         uint   `json:"-"`
+}
+
+// ServiceRequestInstallation монтаж, создаваемый по заявке
+type ServiceRequestInstallation struct {
+	Type              string    `json:"type"`      // По умолчанию - категория заявки
+	ObjectID          *uint     `json:"object_id"` // По умолчанию - объект заявки
+	InstallerID       uint      `json:"installer_id" binding:"required"`
+	ScheduledAt       time.Time `json:"scheduled_at" binding:"required"`
+	EstimatedDuration int       `json:"estimated_duration"`
+	Description       string    `json:"description"`
+}
+
+// ServiceRequestConvert запрос на создание монтажей по заявке
+type ServiceRequestConvert struct {
+	Installations []ServiceRequestInstallation `json:"installations" binding:"required,min=1"`
+	Message       string                       `json:"message"` // Сообщение заявителю
+	UserID        uint                         `json:"-"`
+}
+
+// ServiceRequestNumber номер заявки для клиента
+func ServiceRequestNumber(id uint) string {
+	return fmt.Sprintf("ЗВ-%06d", id)
+}
+
+// applyServiceRequestSLA рассчитывает сроки реакции и решения от момента поступления заявки
+func applyServiceRequestSLA(request *models.ServiceRequest) {
+	sla, ok := DefaultServiceRequestSLA[request.Priority]
+	if !ok {
+		sla = DefaultServiceRequestSLA["normal"]
+	}
+	request.ResponseDueAt = request.CreatedAt.Add(sla.Response)
+	request.ResolutionDueAt = request.CreatedAt.Add(sla.Resolution)
+}
+
+// validPriority проверяет приоритет заявки
+func validPriority(priority string) bool {
+	_, ok := DefaultServiceRequestSLA[priority]
+	return ok
+}
+
+// findServiceRequestObject определяет объект заявки по ID, IMEI или госномеру
+func (s *ServiceRequestService) findServiceRequestObject(objectID *uint, imei, plate string) (*models.Object, error) {
+	query := s.DB
+	switch {
+	case objectID != nil:
+		query = query.Where("id = ?", *objectID)
+	case imei != "":
+		query = query.Where("imei = ?", strings.TrimSpace(imei))
+	case plate != "":
+		query = query.Where("UPPER(license_plate) = ?", strings.ToUpper(strings.ReplaceAll(plate, " ", "")))
+	default:
+		return nil, nil
+	}
+
+	var object models.Object
+	if err := query.First(&object).Error; err != nil {
+		return nil, fmt.Errorf("объект не найден: %w", err)
+	}
+	return &object, nil
+}
+
+// CreateRequest регистрирует заявку. Для заявок из внешних систем повторная регистрация
+// с тем же источником и внешним ID возвращает существующую заявку (created = false).
+func (s *ServiceRequestService) CreateRequest(req ServiceRequestCreate) (*models.ServiceRequest, bool, error) {
+	if !serviceRequestSources[req.Source] {
+		return nil, false, fmt.Errorf("неизвестный источник заявки: %s", req.Source)
+	}
+	if req.Priority == "" {
+		req.Priority = "normal"
+	}
+	if !validPriority(req.Priority) {
+		return nil, false, fmt.Errorf("неизвестный приоритет заявки: %s", req.Priority)
+	}
+
+	if req.ExternalID != "" {
+		var existing models.ServiceRequest
+		err := s.DB.Where("source = ? AND external_id = ?", req.Source, req.ExternalID).First(&existing).Error
+		if err == nil {
+			return &existing, false, nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, false, err
+		}
+	}
+
+	object, err := s.findServiceRequestObject(req.ObjectID, req.ObjectIMEI, req.LicensePlate)
+	if err != nil {
+		return nil, false, err
+	}
+
+	request := &models.ServiceRequest{
+		Source:         req.Source,
+		ExternalID:     req.ExternalID,
+		Status:         models.ServiceRequestStatusNew,
+		Priority:       req.Priority,
+		Category:       req.Category,
+		Subject:        req.Subject,
+		Details:        req.Details,
+		Address:        req.Address,
+		RequesterName:  req.RequesterName,
+		RequesterPhone: req.RequesterPhone,
+		RequesterEmail: req.RequesterEmail,
+		ContractID:     req.ContractID,
+		CreatedAt:      time.Now(),
+	}
+	if req.UserID != 0 {
+		request.CreatedByUserID = &req.UserID
+	}
+	if object != nil {
+		request.ObjectID = &object.ID
+		if request.ContractID == nil && object.ContractID != 0 {
+			request.ContractID = &object.ContractID
+		}
+		if request.Address == "" {
+			request.Address = object.Address
+		}
+	}
+	applyServiceRequestSLA(request)
+
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(request).Error; err != nil {
+			return fmt.Errorf("ошибка при создании заявки: %w", err)
+		}
+		request.Number = ServiceRequestNumber(request.ID)
+		if err := tx.Model(request).UpdateColumn("number", request.Number).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.ServiceRequestEvent{
+			RequestID: request.ID,
+			Type:      models.ServiceRequestEventCreated,
+			ToStatus:  request.Status,
+			Message:   fmt.Sprintf("Заявка %s принята", request.Number),
+			Public:    true,
+			UserID:    request.CreatedByUserID,
+		}).Error
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return request, true, nil
+}
+
+// GetRequests возвращает заявки по фильтру и их общее количество
+func (s *ServiceRequestService) GetRequests(filter ServiceRequestFilter, now time.Time) ([]models.ServiceRequest, int64, error) {
+	query := s.DB.Model(&models.ServiceRequest{})
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.Priority != "" {
+		query = query.Where("priority = ?", filter.Priority)
+	}
+	if filter.Source != "" {
+		query = query.Where("source = ?", filter.Source)
+	}
+	if filter.ObjectID != nil {
+		query = query.Where("object_id = ?", *filter.ObjectID)
+	}
+	if filter.ContractID != nil {
+		query = query.Where("contract_id = ?", *filter.ContractID)
+	}
+	if filter.AssignedUserID != nil {
+		query = query.Where("assigned_user_id = ?", *filter.AssignedUserID)
+	}
+	if filter.Overdue {
+		query = query.Where("status NOT IN ?", []string{
+			models.ServiceRequestStatusResolved, models.ServiceRequestStatusClosed,
+			models.ServiceRequestStatusCancelled, models.ServiceRequestStatusRejected,
+		}).Where("(responded_at IS NULL AND response_due_at < ?) OR resolution_due_at < ?", now, now)
+	}
+	if filter.Search != "" {
+		search := "%" + strings.ToLower(filter.Search) + "%"
+		query = query.Where("LOWER(number) LIKE ? OR LOWER(subject) LIKE ? OR LOWER(requester_name) LIKE ? OR requester_phone LIKE ?",
+			search, search, search, search)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("ошибка при подсчете заявок: %w", err)
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = 20
+	}
+
+	var requests []models.ServiceRequest
+	if err := query.Preload("Object").Order("created_at DESC, id DESC").
+		Limit(filter.Limit).Offset(filter.Offset).Find(&requests).Error; err != nil {
+		return nil, 0, fmt.Errorf("ошибка при получении заявок: %w", err)
+	}
+	return requests, total, nil
+}
+
+// GetRequest возвращает заявку с монтажами и историей
+func (s *ServiceRequestService) GetRequest(id uint) (*models.ServiceRequest, error) {
+	var request models.ServiceRequest
+	if err := s.DB.Preload("Object").Preload("Contract").
+		Preload("Installations", func(db *gorm.DB) *gorm.DB { return db.Order("scheduled_at, id") }).
+		Preload("Events", func(db *gorm.DB) *gorm.DB { return db.Order("created_at, id") }).
+		First(&request, id).Error; err != nil {
+		return nil, fmt.Errorf("заявка не найдена: %w", err)
+	}
+	return &request, nil
+}
+
+// lockServiceRequest читает заявку с блокировкой строки до конца транзакции
+func lockServiceRequest(tx *gorm.DB, id uint) (*models.ServiceRequest, error) {
+	var request models.ServiceRequest
+	if err := tx.Clauses(lockingForUpdate).First(&request, id).Error; err != nil {
+		return nil, fmt.Errorf("заявка не найдена: %w", err)
+	}
+	return &request, nil
+}
+
+// transitionServiceRequest переводит заявку в новый статус, отмечает сроки и записывает событие
+func transitionServiceRequest(tx *gorm.DB, request *models.ServiceRequest, to, message string, userID *uint, installationID *uint) error {
+	if !models.CanTransitionServiceRequest(request.Status, to) {
+		return fmt.Errorf("заявку %s нельзя перевести из статуса %s в статус %s", request.Number, request.Status, to)
+	}
+
+	now := time.Now()
+	from := request.Status
+	request.Status = to
+	switch to {
+	case models.ServiceRequestStatusTriaged, models.ServiceRequestStatusScheduled, models.ServiceRequestStatusInProgress,
+		models.ServiceRequestStatusResolved, models.ServiceRequestStatusRejected:
+		if request.RespondedAt == nil {
+			request.RespondedAt = &now
+		}
+	}
+	switch to {
+	case models.ServiceRequestStatusResolved:
+		request.ResolvedAt = &now
+	case models.ServiceRequestStatusClosed:
+		request.ClosedAt = &now
+	case models.ServiceRequestStatusTriaged:
+		request.ResolvedAt = nil // Заявка возвращена в работу
+	}
+
+	if err := tx.Omit("Installations", "Events", "Object", "Contract").Save(request).Error; err != nil {
+		return fmt.Errorf("ошибка при сохранении заявки: %w", err)
+	}
+	return tx.Create(&models.ServiceRequestEvent{
+		RequestID:      request.ID,
+		Type:           models.ServiceRequestEventStatus,
+		FromStatus:     from,
+		ToStatus:       to,
+		Message:        message,
+		Public:         true,
+		InstallationID: installationID,
+		UserID:         userID,
+	}).Error
+}
+
+// TriageRequest разбирает новую заявку: уточняет приоритет, объект, договор и ответственного.
+// При смене приоритета сроки пересчитываются от момента поступления заявки.
+func (s *ServiceRequestService) TriageRequest(id uint, req ServiceRequestTriage) (*models.ServiceRequest, error) {
+	if req.Priority != "" && !validPriority(req.Priority) {
+		return nil, fmt.Errorf("неизвестный приоритет заявки: %s", req.Priority)
+	}
+	var userID *uint
+	if req.UserID != 0 {
+		userID = &req.UserID
+	}
+
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		request, err := lockServiceRequest(tx, id)
+		if err != nil {
+			return err
+		}
+		if !request.IsOpen() {
+			return fmt.Errorf("заявка %s уже закрыта", request.Number)
+		}
+
+		if req.ObjectID != nil {
+			var object models.Object
+			if err := tx.First(&object, *req.ObjectID).Error; err != nil {
+				return fmt.Errorf("объект не найден: %w", err)
+			}
+			request.ObjectID = &object.ID
+			if req.ContractID == nil && object.ContractID != 0 {
+				request.ContractID = &object.ContractID
+			}
+		}
+		if req.ContractID != nil {
+			request.ContractID = req.ContractID
+		}
+		if req.Category != "" {
+			request.Category = req.Category
+		}
+		if req.AssignedUserID != nil {
+			request.AssignedUserID = req.AssignedUserID
+		}
+		if req.Priority != "" && req.Priority != request.Priority {
+			request.Priority = req.Priority
+			applyServiceRequestSLA(request)
+		}
+		request.TriagedByUserID = userID
+
+		message := req.Message
+		if message == "" {
+			message = "Заявка принята в работу"
+		}
+		if request.Status == models.ServiceRequestStatusNew {
+			return transitionServiceRequest(tx, request, models.ServiceRequestStatusTriaged, message, userID, nil)
+		}
+		return tx.Omit("Installations", "Events", "Object", "Contract").Save(request).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetRequest(id)
+}
+
+// ChangeStatus меняет статус заявки вручную: решение без выезда, закрытие, отмена, отклонение.
+// Статусы scheduled и in_progress устанавливаются по монтажам заявки.
+// При отмене или отклонении незавершенные монтажи заявки отменяются.
+func (s *ServiceRequestService) ChangeStatus(id uint, to, message string, userID uint) (*models.ServiceRequest, error) {
+	if to == models.ServiceRequestStatusScheduled || to == models.ServiceRequestStatusInProgress {
+		return nil, errors.New("статус заявки устанавливается по ее монтажам")
+	}
+	var user *uint
+	if userID != 0 {
+		user = &userID
+	}
+
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		request, err := lockServiceRequest(tx, id)
+		if err != nil {
+			return err
+		}
+		if to == models.ServiceRequestStatusResolved && message != "" {
+			request.Resolution = message
+		}
+		if err := transitionServiceRequest(tx, request, to, message, user, nil); err != nil {
+			return err
+		}
+
+		if to == models.ServiceRequestStatusCancelled || to == models.ServiceRequestStatusRejected {
+			return tx.Model(&models.Installation{}).
+				Where("service_request_id = ? AND status IN ?", id, []string{"planned", "postponed"}).
+				Update("status", "cancelled").Error
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetRequest(id)
+}
+
+// AddComment добавляет комментарий к заявке; публичный комментарий сообщается заявителю
+func (s *ServiceRequestService) AddComment(id uint, message string, public bool, userID uint) (*models.ServiceRequestEvent, error) {
+	if strings.TrimSpace(message) == "" {
+		return nil, errors.New("комментарий не может быть пустым")
+	}
+	if err := s.DB.Select("id").First(&models.ServiceRequest{}, id).Error; err != nil {
+		return nil, fmt.Errorf("заявка не найдена: %w", err)
+	}
+
+	event := &models.ServiceRequestEvent{
+		RequestID: id,
+		Type:      models.ServiceRequestEventComment,
+		Message:   message,
+		Public:    public,
+	}
+	if userID != 0 {
+		event.UserID = &userID
+	}
+	if err := s.DB.Create(event).Error; err != nil {
+		return nil, fmt.Errorf("ошибка при добавлении комментария: %w", err)
+	}
+	return event, nil
+}
+
+// ConvertToInstallations создает по заявке один или несколько монтажей с проверкой расписания
+// монтажников. Монтажи создаются все или ни одного; заявка переходит в статус scheduled.
+func (s *ServiceRequestService) ConvertToInstallations(id uint, req ServiceRequestConvert) (*models.ServiceRequest, error) {
+	if len(req.Installations) == 0 {
+		return nil, errors.New("не указаны монтажи")
+	}
+	var userID *uint
+	if req.UserID != 0 {
+		userID = &req.UserID
+	}
+
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		request, err := lockServiceRequest(tx, id)
+		if err != nil {
+			return err
+		}
+		if !request.IsOpen() {
+			return fmt.Errorf("заявка %s уже закрыта", request.Number)
+		}
+
+		installationService := NewInstallationService(tx, nil)
+		installationService.Timezone = s.Timezone
+
+		var first *models.Installation
+		for i, item := range req.Installations {
+			objectID := request.ObjectID
+			if item.ObjectID != nil {
+				objectID = item.ObjectID
+			}
+			if objectID == nil {
+				return fmt.Errorf("монтаж %d: не указан объект", i+1)
+			}
+			var object models.Object
+			if err := tx.First(&object, *objectID).Error; err != nil {
+				return fmt.Errorf("монтаж %d: объект не найден: %w", i+1, err)
+			}
+
+			installationType := item.Type
+			if installationType == "" {
+				installationType = request.Category
+			}
+			if installationType == "" {
+				installationType = "диагностика"
+			}
+			description := item.Description
+			if description == "" {
+				description = fmt.Sprintf("Заявка %s: %s", request.Number, request.Subject)
+			}
+			duration := item.EstimatedDuration
+			if duration <= 0 {
+				duration = DefaultMaintenanceDuration
+			}
+			address := request.Address
+			if address == "" {
+				address = object.Address
+			}
+			contact := strings.TrimSpace(request.RequesterName + " " + request.RequesterPhone)
+
+			installation := &models.Installation{
+				Type:              installationType,
+				Status:            "planned",
+				Priority:          request.Priority,
+				Description:       description,
+				ScheduledAt:       item.ScheduledAt,
+				EstimatedDuration: duration,
+				ObjectID:          object.ID,
+				InstallerID:       item.InstallerID,
+				ServiceRequestID:  &request.ID,
+				ClientContact:     contact,
+				Address:           address,
+				IsBillable:        true,
+				CompanyID:         request.CompanyID,
+			}
+			if object.LocationID != 0 {
+				installation.LocationID = &object.LocationID
+			}
+			if userID != nil {
+				installation.CreatedByUserID = *userID
+			}
+			if err := installationService.ScheduleInstallation(installation); err != nil {
+				return fmt.Errorf("монтаж %d: %w", i+1, err)
+			}
+			if err := tx.Create(&models.ServiceRequestEvent{
+				RequestID:      request.ID,
+				Type:           models.ServiceRequestEventInstallation,
+				Message:        fmt.Sprintf("Создан монтаж #%d (%s)", installation.ID, installation.Type),
+				InstallationID: &installation.ID,
+				UserID:         userID,
+			}).Error; err != nil {
+				return err
+			}
+			if first == nil || installation.ScheduledAt.Before(first.ScheduledAt) {
+				first = installation
+			}
+		}
+
+		if request.ObjectID == nil {
+			request.ObjectID = &first.ObjectID
+		}
+		message := req.Message
+		if message == "" {
+			message = s.scheduledMessage(first)
+		}
+		if request.Status == models.ServiceRequestStatusNew || request.Status == models.ServiceRequestStatusTriaged {
+			return transitionServiceRequest(tx, request, models.ServiceRequestStatusScheduled, message, userID, &first.ID)
+		}
+		return tx.Omit("Installations", "Events", "Object", "Contract").Save(request).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetRequest(id)
+}
+
+// scheduledMessage сообщение заявителю о запланированном выезде
+func (s *ServiceRequestService) scheduledMessage(installation *models.Installation) string {
+	tz := models.LoadTimezone(s.Timezone)
+	return fmt.Sprintf("Выезд специалиста запланирован на %s", installation.ScheduledAt.In(tz).Format("02.01.2006 15:04"))
+}
+
+// SyncFromInstallation обновляет статус заявки по состоянию ее монтажей: монтажник приступил
+// к работам - in_progress, есть запланированные - scheduled, все завершены - resolved,
+// все отменены - заявка возвращается на разбор. Возвращает nil, если монтаж создан не по заявке.
+func (s *ServiceRequestService) SyncFromInstallation(installationID uint) (*models.ServiceRequest, error) {
+	var installation models.Installation
+	if err := s.DB.Select("id", "service_request_id").First(&installation, installationID).Error; err != nil {
+		return nil, fmt.Errorf("монтаж не найден: %w", err)
+	}
+	if installation.ServiceRequestID == nil {
+		return nil, nil
+	}
+	requestID := *installation.ServiceRequestID
+
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		request, err := lockServiceRequest(tx, requestID)
+		if err != nil {
+			return err
+		}
+		if !request.IsOpen() {
+			return nil
+		}
+
+		var installations []models.Installation
+		if err := tx.Where("service_request_id = ?", requestID).Find(&installations).Error; err != nil {
+			return err
+		}
+		to, message, changed := serviceRequestStatusFromInstallations(installations, s.Timezone)
+		if changed == nil || to == request.Status || !models.CanTransitionServiceRequest(request.Status, to) {
+			return nil
+		}
+		if to == models.ServiceRequestStatusResolved && request.Resolution == "" {
+			request.Resolution = message
+		}
+		return transitionServiceRequest(tx, request, to, message, nil, &changed.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetRequest(requestID)
+}
+
+// serviceRequestStatusFromInstallations определяет статус заявки по ее монтажам и сообщение заявителю
+func serviceRequestStatusFromInstallations(installations []models.Installation, timezone string) (string, string, *models.Installation) {
+	if len(installations) == 0 {
+		return "", "", nil
+	}
+	sort.Slice(installations, func(i, j int) bool { return installations[i].ScheduledAt.Before(installations[j].ScheduledAt) })
+
+	var inProgress, planned, completed *models.Installation
+	var results []string
+	for i := range installations {
+		installation := &installations[i]
+		switch installation.Status {
+		case "in_progress":
+			if inProgress == nil {
+				inProgress = installation
+			}
+		case "planned", "postponed":
+			if planned == nil {
+				planned = installation
+			}
+		case "completed":
+			completed = installation
+			if installation.Result != "" {
+				results = append(results, installation.Result)
+			}
+		}
+	}
+
+	switch {
+	case inProgress != nil:
+		return models.ServiceRequestStatusInProgress, "Специалист приступил к работам", inProgress
+	case planned != nil:
+		tz := models.LoadTimezone(timezone)
+		return models.ServiceRequestStatusScheduled,
+			fmt.Sprintf("Выезд специалиста запланирован на %s", planned.ScheduledAt.In(tz).Format("02.01.2006 15:04")), planned
+	case completed != nil:
+		message := "Работы выполнены"
+		if len(results) > 0 {
+			message += ": " + strings.Join(results, "; ")
+		}
+		return models.ServiceRequestStatusResolved, message, completed
+	default:
+		return models.ServiceRequestStatusTriaged, "Выезд отменен, заявка возвращена на разбор", &installations[len(installations)-1]
+	}
+}
+
+// PendingNotifications возвращает публичные события заявки, о которых заявитель еще не уведомлен
+func (s *ServiceRequestService) PendingNotifications(requestID uint) ([]models.ServiceRequestEvent, error) {
+	var events []models.ServiceRequestEvent
+	err := s.DB.Where("request_id = ? AND public = ? AND notified_at IS NULL", requestID, true).
+		Order("created_at, id").Find(&events).Error
+	return events, err
+}
+
+// MarkNotified отмечает события как доставленные заявителю
+func (s *ServiceRequestService) MarkNotified(eventIDs []uint) error {
+	if len(eventIDs) == 0 {
+		return nil
+	}
+	return s.DB.Model(&models.ServiceRequestEvent{}).Where("id IN ?", eventIDs).
+		UpdateColumn("notified_at", time.Now()).Error
+}
+
+// PendingExternalNotifications возвращает публичные события заявки, еще не добавленные в ленту сделки Битрикс24
+func (s *ServiceRequestService) PendingExternalNotifications(requestID uint) ([]models.ServiceRequestEvent, error) {
+	var events []models.ServiceRequestEvent
+	err := s.DB.Where("request_id = ? AND public = ? AND external_notified_at IS NULL", requestID, true).
+		Order("created_at, id").Find(&events).Error
+	return events, err
+}
+
+// MarkExternalNotified отмечает событие как добавленное в ленту сделки Битрикс24
+func (s *ServiceRequestService) MarkExternalNotified(eventID uint) error {
+	return s.DB.Model(&models.ServiceRequestEvent{}).Where("id = ?", eventID).
+		UpdateColumn("external_notified_at", time.Now()).Error
+}
+
+// serviceRequestStatusNames названия статусов заявки для заявителя
+var serviceRequestStatusNames = map[string]string{
+	models.ServiceRequestStatusNew:        "Принята",
+	models.ServiceRequestStatusTriaged:    "В работе",
+	models.ServiceRequestStatusScheduled:  "Выезд запланирован",
+	models.ServiceRequestStatusInProgress: "Специалист на объекте",
+	models.ServiceRequestStatusResolved:   "Выполнена",
+	models.ServiceRequestStatusClosed:     "Закрыта",
+	models.ServiceRequestStatusCancelled:  "Отменена",
+	models.ServiceRequestStatusRejected:   "Отклонена",
+}
+
+// ServiceRequestNotificationText текст уведомления заявителя о событии заявки
+func ServiceRequestNotificationText(request *models.ServiceRequest, event *models.ServiceRequestEvent) string {
+	text := fmt.Sprintf("Заявка %s: %s", request.Number, serviceRequestStatusNames[request.Status])
+	if event.ToStatus != "" {
+		text = fmt.Sprintf("Заявка %s: %s", request.Number, serviceRequestStatusNames[event.ToStatus])
+	}
+	if event.Message != "" {
+		text += ". " + event.Message
+	}
+	return text
+}
@@ -0,0 +1,194 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"backend_axenta/models"
+)
+
+func setupServiceRequestTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(
+		&models.Object{},
+		&models.Contract{},
+		&models.Installation{},
+		&models.Installer{},
+		&models.ServiceRequest{},
+		&models.ServiceRequestEvent{},
+	))
+	return db
+}
+
+func TestServiceRequestService_CreateAndTriage(t *testing.T) {
+	db := setupServiceRequestTestDB(t)
+	truck := models.Object{Name: "КамАЗ А001АА", IMEI: "356938035643801", ContractID: 7, Address: "Склад на Садовой"}
+	require.NoError(t, db.Create(&truck).Error)
+
+	service := NewServiceRequestService(db)
+	_, _, err := service.CreateRequest(ServiceRequestCreate{Source: "fax", Subject: "Трекер не на связи"})
+	require.Error(t, err)
+	_, _, err = service.CreateRequest(ServiceRequestCreate{Source: models.ServiceRequestSourcePhone, Subject: "Трекер не на связи", Priority: "asap"})
+	require.Error(t, err)
+	_, _, err = service.CreateRequest(ServiceRequestCreate{Source: models.ServiceRequestSourcePhone, Subject: "Трекер не на связи", ObjectIMEI: "000"})
+	require.Error(t, err)
+
+	// Объект находится по IMEI, договор и адрес берутся из объекта
+	request, created, err := service.CreateRequest(ServiceRequestCreate{
+		Source:         models.ServiceRequestSourceBitrix24,
+		ExternalID:     "deal-42",
+		Subject:        "Трекер не на связи",
+		RequesterName:  "Иванов",
+		RequesterPhone: "+79990001122",
+		ObjectIMEI:     truck.IMEI,
+		UserID:         3,
+	})
+	require.NoError(t, err)
+	assert.True(t, created)
+	assert.Equal(t, ServiceRequestNumber(request.ID), request.Number)
+	assert.Equal(t, models.ServiceRequestStatusNew, request.Status)
+	assert.Equal(t, "normal", request.Priority)
+	require.NotNil(t, request.ObjectID)
+	assert.Equal(t, truck.ID, *request.ObjectID)
+	require.NotNil(t, request.ContractID)
+	assert.Equal(t, uint(7), *request.ContractID)
+	assert.Equal(t, truck.Address, request.Address)
+	assert.Equal(t, request.CreatedAt.Add(8*time.Hour), request.ResponseDueAt)
+	assert.Equal(t, request.CreatedAt.Add(72*time.Hour), request.ResolutionDueAt)
+
+	// Повторная заявка из Битрикс24 по той же сделке не создается
+	duplicate, created, err := service.CreateRequest(ServiceRequestCreate{
+		Source: models.ServiceRequestSourceBitrix24, ExternalID: "deal-42", Subject: "Трекер не на связи",
+	})
+	require.NoError(t, err)
+	assert.False(t, created)
+	assert.Equal(t, request.ID, duplicate.ID)
+
+	// Смена приоритета при разборе пересчитывает сроки от момента поступления
+	assignee := uint(5)
+	triaged, err := service.TriageRequest(request.ID, ServiceRequestTriage{Priority: "urgent", AssignedUserID: &assignee, UserID: 3})
+	require.NoError(t, err)
+	assert.Equal(t, models.ServiceRequestStatusTriaged, triaged.Status)
+	assert.WithinDuration(t, request.CreatedAt.Add(time.Hour), triaged.ResponseDueAt, time.Second)
+	assert.WithinDuration(t, request.CreatedAt.Add(8*time.Hour), triaged.ResolutionDueAt, time.Second)
+	require.NotNil(t, triaged.RespondedAt)
+	assert.False(t, triaged.IsResponseOverdue(time.Now()))
+	assert.Equal(t, assignee, *triaged.AssignedUserID)
+	require.Len(t, triaged.Events, 2)
+	assert.Equal(t, models.ServiceRequestEventCreated, triaged.Events[0].Type)
+	assert.Equal(t, models.ServiceRequestStatusTriaged, triaged.Events[1].ToStatus)
+
+	// Заявитель еще не уведомлен о событиях; внутренний комментарий ему не отправляется
+	_, err = service.AddComment(request.ID, "Проверить SIM-карту", false, 3)
+	require.NoError(t, err)
+	pending, err := service.PendingNotifications(request.ID)
+	require.NoError(t, err)
+	require.Len(t, pending, 2)
+	require.NoError(t, service.MarkNotified([]uint{pending[0].ID, pending[1].ID}))
+	pending, err = service.PendingNotifications(request.ID)
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+
+	// Просроченные заявки
+	late, _, err := service.CreateRequest(ServiceRequestCreate{Source: models.ServiceRequestSourceEmail, Subject: "Не работает датчик", Priority: "low"})
+	require.NoError(t, err)
+	overdue, total, err := service.GetRequests(ServiceRequestFilter{Overdue: true}, time.Now().Add(25*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), total) // Срочная - решение просрочено, низкий приоритет - реакция просрочена
+	assert.Len(t, overdue, 2)
+	found, total, err := service.GetRequests(ServiceRequestFilter{Search: "датчик"}, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Equal(t, late.ID, found[0].ID)
+}
+
+func TestServiceRequestService_StatusAndInstallations(t *testing.T) {
+	db := setupServiceRequestTestDB(t)
+	truck := models.Object{Name: "КамАЗ А001АА", IMEI: "356938035643801"}
+	require.NoError(t, db.Create(&truck).Error)
+
+	service := NewServiceRequestService(db)
+	request, _, err := service.CreateRequest(ServiceRequestCreate{Source: models.ServiceRequestSourcePortal, Subject: "Трекер не на связи", ObjectID: &truck.ID})
+	require.NoError(t, err)
+
+	// Статус scheduled устанавливается только монтажами заявки
+	_, err = service.ChangeStatus(request.ID, models.ServiceRequestStatusScheduled, "", 1)
+	require.Error(t, err)
+	_, err = service.ChangeStatus(request.ID, models.ServiceRequestStatusClosed, "", 1)
+	require.Error(t, err)
+
+	// Монтажник не найден - ни один монтаж не создается, заявка остается новой
+	_, err = service.ConvertToInstallations(request.ID, ServiceRequestConvert{Installations: []ServiceRequestInstallation{
+		{InstallerID: 99, ScheduledAt: time.Now().Add(24 * time.Hour)},
+	}})
+	require.Error(t, err)
+	var count int64
+	db.Model(&models.Installation{}).Count(&count)
+	assert.Zero(t, count)
+	unchanged, err := service.GetRequest(request.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.ServiceRequestStatusNew, unchanged.Status)
+
+	// Монтажи по заявке
+	scheduledAt := time.Now().Add(24 * time.Hour)
+	first := models.Installation{Type: "диагностика", Status: "planned", ScheduledAt: scheduledAt, ObjectID: truck.ID, InstallerID: 1, ServiceRequestID: &request.ID}
+	second := models.Installation{Type: "замена", Status: "planned", ScheduledAt: scheduledAt.Add(2 * time.Hour), ObjectID: truck.ID, InstallerID: 1, ServiceRequestID: &request.ID}
+	require.NoError(t, db.Create(&first).Error)
+	require.NoError(t, db.Create(&second).Error)
+
+	synced, err := service.SyncFromInstallation(first.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.ServiceRequestStatusScheduled, synced.Status)
+	assert.Len(t, synced.Installations, 2)
+
+	require.NoError(t, db.Model(&first).Update("status", "in_progress").Error)
+	synced, err = service.SyncFromInstallation(first.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.ServiceRequestStatusInProgress, synced.Status)
+
+	// Одна работа выполнена, вторая запланирована - заявка ждет второго выезда
+	require.NoError(t, db.Model(&first).Updates(map[string]interface{}{"status": "completed", "result": "Заменен предохранитель"}).Error)
+	synced, err = service.SyncFromInstallation(first.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.ServiceRequestStatusScheduled, synced.Status)
+
+	require.NoError(t, db.Model(&second).Updates(map[string]interface{}{"status": "completed", "result": "Заменен трекер"}).Error)
+	synced, err = service.SyncFromInstallation(second.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.ServiceRequestStatusResolved, synced.Status)
+	assert.Equal(t, "Работы выполнены: Заменен предохранитель; Заменен трекер", synced.Resolution)
+	require.NotNil(t, synced.ResolvedAt)
+
+	closed, err := service.ChangeStatus(request.ID, models.ServiceRequestStatusClosed, "Клиент подтвердил", 1)
+	require.NoError(t, err)
+	assert.Equal(t, models.ServiceRequestStatusClosed, closed.Status)
+	require.NotNil(t, closed.ClosedAt)
+
+	// Монтаж без заявки не меняет заявки
+	other := models.Installation{Type: "монтаж", Status: "planned", ScheduledAt: scheduledAt, ObjectID: truck.ID, InstallerID: 1}
+	require.NoError(t, db.Create(&other).Error)
+	none, err := service.SyncFromInstallation(other.ID)
+	require.NoError(t, err)
+	assert.Nil(t, none)
+
+	// Отмена заявки отменяет ее запланированные монтажи
+	cancelled, _, err := service.CreateRequest(ServiceRequestCreate{Source: models.ServiceRequestSourcePhone, Subject: "Установить трекер", ObjectID: &truck.ID})
+	require.NoError(t, err)
+	planned := models.Installation{Type: "монтаж", Status: "planned", ScheduledAt: scheduledAt, ObjectID: truck.ID, InstallerID: 1, ServiceRequestID: &cancelled.ID}
+	require.NoError(t, db.Create(&planned).Error)
+	_, err = service.SyncFromInstallation(planned.ID)
+	require.NoError(t, err)
+	_, err = service.ChangeStatus(cancelled.ID, models.ServiceRequestStatusCancelled, "Клиент отказался", 1)
+	require.NoError(t, err)
+	require.NoError(t, db.First(&planned, planned.ID).Error)
+	assert.Equal(t, "cancelled", planned.Status)
+}
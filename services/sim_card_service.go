@@ -0,0 +1,813 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
+
+	"backend_axenta/models"
+)
+
+// SimCardService ведет учет SIM-карт, операторов связи и сверку начислений
+type SimCardService struct {
+	DB *gorm.DB
+}
+
+// NewSimCardService создает новый экземпляр SimCardService
+func NewSimCardService(db *gorm.DB) *SimCardService {
+	return &SimCardService{DB: db}
+}
+
+// SimCardLine строка партии SIM-карт
+type SimCardLine struct {
+	ICCID  string `json:"iccid"`
+	MSISDN string `json:"msisdn"`
+}
+
+// SimCardBatchRequest поступление партии SIM-карт на склад
+type SimCardBatchRequest struct {
+	OperatorID  uint             `json:"operator_id"`
+	WarehouseID *uint            `json:"warehouse_id"`
+	TariffName  string           `json:"tariff_name"`
+	MonthlyCost *decimal.Decimal `json:"monthly_cost"` // Если не указана - тариф оператора по умолчанию
+	Items       []SimCardLine    `json:"items"`
+}
+
+// SimCardFilter параметры поиска SIM-карт
+type SimCardFilter struct {
+	OperatorID  *uint
+	WarehouseID *uint
+	Status      string
+	Search      string // ICCID или номер телефона
+	Unpaired    bool   // Только не установленные в трекер
+}
+
+// IdleSimCard оплачиваемая SIM-карта в трекере, который не работает на активном объекте
+type IdleSimCard struct {
+	SimCardID   uint            `json:"sim_card_id"`
+	ICCID       string          `json:"iccid"`
+	MSISDN      string          `json:"msisdn"`
+	MonthlyCost decimal.Decimal `json:"monthly_cost"`
+	EquipmentID uint            `json:"equipment_id"`
+	ObjectID    *uint           `json:"object_id"`
+	ObjectName  string          `json:"object_name"`
+	Reason      string          `json:"reason"`
+}
+
+// SimReconciliation результат сверки начислений оператора за месяц
+type SimReconciliation struct {
+	OperatorID     uint                       `json:"operator_id"`
+	Period         time.Time                  `json:"period"`
+	Charges        []models.SimOperatorCharge `json:"charges"`
+	NotCharged     []models.SimCard           `json:"not_charged"` // Числятся оплачиваемыми, но отсутствуют в детализации
+	TotalCharged   decimal.Decimal            `json:"total_charged"`
+	TotalExpected  decimal.Decimal            `json:"total_expected"`
+	Overpayment    decimal.Decimal            `json:"overpayment"` // Начислено сверх тарифа, по неизвестным и неиспользуемым SIM-картам
+	ResultCounts   map[string]int             `json:"result_counts"`
+	ImportedCount  int                        `json:"imported_count"`
+	SkippedRows    int                        `json:"skipped_rows"`
+	ChargesPresent bool                       `json:"charges_present"`
+}
+
+// iccidPattern ICCID состоит из 18-20 цифр, у некоторых операторов с завершающей F
+var iccidPattern = regexp.MustCompile(`^\d{18,20}$`)
+
+// simChargeColumns допустимые названия колонок детализации оператора
+var simChargeColumns = map[string][]string{
+	"iccid":  {"iccid", "icc", "icc id", "номер sim", "номер sim-карты", "sim"},
+	"msisdn": {"msisdn", "номер", "телефон", "номер телефона", "абонентский номер", "phone"},
+	"amount": {"сумма", "amount", "начислено", "итого", "стоимость", "сумма с ндс"},
+}
+
+// NormalizeICCID убирает пробелы и завершающую F, проверяет формат ICCID
+func NormalizeICCID(value string) (string, error) {
+	iccid := strings.ToUpper(strings.Join(strings.Fields(value), ""))
+	iccid = strings.TrimSuffix(iccid, "F")
+	if !iccidPattern.MatchString(iccid) {
+		return "", fmt.Errorf("неверный ICCID %q: ожидается 18-20 цифр", value)
+	}
+	return iccid, nil
+}
+
+// NormalizeMSISDN приводит российский номер к виду +7XXXXXXXXXX, остальные номера - к цифрам с +
+func NormalizeMSISDN(value string) string {
+	var digits strings.Builder
+	for _, r := range value {
+		if r >= '0' && r <= '9' {
+			digits.WriteRune(r)
+		}
+	}
+	number := digits.String()
+	switch {
+	case number == "":
+		return ""
+	case len(number) == 10:
+		number = "7" + number
+	case len(number) == 11 && number[0] == '8':
+		number = "7" + number[1:]
+	}
+	return "+" + number
+}
+
+// CreateOperator добавляет оператора связи
+func (s *SimCardService) CreateOperator(operator *models.MobileOperator) error {
+	operator.Name = strings.TrimSpace(operator.Name)
+	if operator.Name == "" {
+		return fmt.Errorf("не указано название оператора")
+	}
+	operator.Code = strings.ToLower(strings.TrimSpace(operator.Code))
+	if operator.Code != "" {
+		var count int64
+		s.DB.Model(&models.MobileOperator{}).Where("code = ?", operator.Code).Count(&count)
+		if count > 0 {
+			return fmt.Errorf("оператор с кодом %s уже существует", operator.Code)
+		}
+	}
+	operator.IsActive = true
+	if err := s.DB.Create(operator).Error; err != nil {
+		return fmt.Errorf("ошибка при создании оператора: %w", err)
+	}
+	return nil
+}
+
+// UpdateOperator обновляет настройки оператора связи
+func (s *SimCardService) UpdateOperator(id uint, input models.MobileOperator) (*models.MobileOperator, error) {
+	var operator models.MobileOperator
+	if err := s.DB.First(&operator, id).Error; err != nil {
+		return nil, fmt.Errorf("оператор не найден: %w", err)
+	}
+
+	if err := s.DB.Model(&operator).Updates(map[string]interface{}{
+		"name":                 strings.TrimSpace(input.Name),
+		"apn":                  input.APN,
+		"apn_user":             input.APNUser,
+		"apn_password":         input.APNPassword,
+		"contract_number":      input.ContractNumber,
+		"support_phone":        input.SupportPhone,
+		"manager_contact":      input.ManagerContact,
+		"default_tariff":       input.DefaultTariff,
+		"default_monthly_cost": input.DefaultMonthlyCost,
+		"is_active":            input.IsActive,
+		"notes":                input.Notes,
+	}).Error; err != nil {
+		return nil, fmt.Errorf("ошибка при обновлении оператора: %w", err)
+	}
+
+	return &operator, s.DB.First(&operator, id).Error
+}
+
+// GetOperators возвращает операторов связи
+func (s *SimCardService) GetOperators(activeOnly bool) ([]models.MobileOperator, error) {
+	query := s.DB.Order("name")
+	if activeOnly {
+		query = query.Where("is_active = ?", true)
+	}
+	var operators []models.MobileOperator
+	if err := query.Find(&operators).Error; err != nil {
+		return nil, fmt.Errorf("ошибка при получении операторов: %w", err)
+	}
+	return operators, nil
+}
+
+// CreateSimCards оприходует партию SIM-карт. Документ проводится целиком:
+// при неверном или повторяющемся ICCID не создается ни одна карта.
+func (s *SimCardService) CreateSimCards(req SimCardBatchRequest) ([]models.SimCard, error) {
+	if len(req.Items) == 0 {
+		return nil, fmt.Errorf("не переданы SIM-карты")
+	}
+
+	var operator models.MobileOperator
+	if err := s.DB.First(&operator, req.OperatorID).Error; err != nil {
+		return nil, fmt.Errorf("оператор не найден: %w", err)
+	}
+	tariff := req.TariffName
+	if tariff == "" {
+		tariff = operator.DefaultTariff
+	}
+	monthlyCost := operator.DefaultMonthlyCost
+	if req.MonthlyCost != nil {
+		monthlyCost = *req.MonthlyCost
+	}
+
+	var warehouse *models.Warehouse
+	if req.WarehouseID != nil {
+		warehouse = &models.Warehouse{}
+		if err := s.DB.First(warehouse, *req.WarehouseID).Error; err != nil {
+			return nil, fmt.Errorf("склад не найден: %w", err)
+		}
+	}
+
+	cards := make([]models.SimCard, 0, len(req.Items))
+	seen := make(map[string]bool, len(req.Items))
+	var problems []string
+	for i, item := range req.Items {
+		iccid, err := NormalizeICCID(item.ICCID)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("строка %d: %v", i+1, err))
+			continue
+		}
+		if seen[iccid] {
+			problems = append(problems, fmt.Sprintf("строка %d: ICCID %s повторяется", i+1, iccid))
+			continue
+		}
+		seen[iccid] = true
+
+		card := models.SimCard{
+			ICCID:       iccid,
+			MSISDN:      NormalizeMSISDN(item.MSISDN),
+			OperatorID:  operator.ID,
+			TariffName:  tariff,
+			MonthlyCost: monthlyCost,
+			Status:      models.SimStatusInStock,
+			CompanyID:   operator.CompanyID,
+		}
+		if warehouse != nil {
+			card.WarehouseID = &warehouse.ID
+		}
+		cards = append(cards, card)
+	}
+
+	if len(seen) > 0 {
+		var existing []string
+		iccids := make([]string, 0, len(seen))
+		for iccid := range seen {
+			iccids = append(iccids, iccid)
+		}
+		if err := s.DB.Unscoped().Model(&models.SimCard{}).Where("iccid IN ?", iccids).Pluck("iccid", &existing).Error; err != nil {
+			return nil, fmt.Errorf("ошибка при проверке ICCID: %w", err)
+		}
+		for _, iccid := range existing {
+			problems = append(problems, fmt.Sprintf("SIM-карта %s уже на учете", iccid))
+		}
+	}
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("%s", strings.Join(problems, "; "))
+	}
+
+	if err := s.DB.Create(&cards).Error; err != nil {
+		return nil, fmt.Errorf("ошибка при создании SIM-карт: %w", err)
+	}
+	return cards, nil
+}
+
+// GetSimCard возвращает SIM-карту с оператором и трекером
+func (s *SimCardService) GetSimCard(id uint) (*models.SimCard, error) {
+	var card models.SimCard
+	if err := s.DB.Preload("Operator").Preload("Warehouse").Preload("Equipment").First(&card, id).Error; err != nil {
+		return nil, fmt.Errorf("SIM-карта не найдена: %w", err)
+	}
+	return &card, nil
+}
+
+// GetSimCards возвращает SIM-карты по фильтру
+func (s *SimCardService) GetSimCards(filter SimCardFilter, limit, offset int) ([]models.SimCard, int64, error) {
+	query := s.DB.Model(&models.SimCard{})
+	if filter.OperatorID != nil {
+		query = query.Where("operator_id = ?", *filter.OperatorID)
+	}
+	if filter.WarehouseID != nil {
+		query = query.Where("warehouse_id = ?", *filter.WarehouseID)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.Unpaired {
+		query = query.Where("equipment_id IS NULL")
+	}
+	if filter.Search != "" {
+		search := "%" + strings.TrimSpace(filter.Search) + "%"
+		query = query.Where("iccid LIKE ? OR msisdn LIKE ?", search, search)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("ошибка при подсчете SIM-карт: %w", err)
+	}
+
+	var cards []models.SimCard
+	if err := query.Preload("Operator").Preload("Equipment").
+		Order("id DESC").Limit(limit).Offset(offset).
+		Find(&cards).Error; err != nil {
+		return nil, 0, fmt.Errorf("ошибка при получении SIM-карт: %w", err)
+	}
+	return cards, total, nil
+}
+
+// lockSimCard читает SIM-карту с блокировкой строки до конца транзакции
+func lockSimCard(tx *gorm.DB, id uint) (*models.SimCard, error) {
+	var card models.SimCard
+	if err := tx.Clauses(lockingForUpdate).First(&card, id).Error; err != nil {
+		return nil, fmt.Errorf("SIM-карта не найдена: %w", err)
+	}
+	return &card, nil
+}
+
+// PairSimCard устанавливает SIM-карту в трекер. Номер SIM-карты записывается в оборудование
+// и объект, на котором трекер установлен.
+func (s *SimCardService) PairSimCard(simID, equipmentID, userID uint) (*models.SimCard, error) {
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		card, err := lockSimCard(tx, simID)
+		if err != nil {
+			return err
+		}
+		if card.EquipmentID != nil {
+			return fmt.Errorf("SIM-карта уже установлена в трекер %d", *card.EquipmentID)
+		}
+		if card.Status == models.SimStatusTerminated {
+			return fmt.Errorf("договор по SIM-карте расторгнут")
+		}
+
+		equipment, err := lockEquipment(tx, equipmentID)
+		if err != nil {
+			return err
+		}
+		if equipment.Status == models.EquipmentStatusDisposed {
+			return fmt.Errorf("оборудование списано")
+		}
+		var paired int64
+		if err := tx.Model(&models.SimCard{}).Where("equipment_id = ?", equipment.ID).Count(&paired).Error; err != nil {
+			return fmt.Errorf("ошибка при проверке SIM-карт трекера: %w", err)
+		}
+		if paired > 0 {
+			return fmt.Errorf("в трекер %s уже установлена SIM-карта", equipment.SerialNumber)
+		}
+
+		now := time.Now()
+		updates := map[string]interface{}{
+			"equipment_id": equipment.ID,
+			"warehouse_id": nil,
+			"paired_at":    now,
+		}
+		if card.Status == models.SimStatusInStock {
+			updates["status"] = models.SimStatusActive
+		}
+		if card.ActivatedAt == nil {
+			updates["activated_at"] = now
+		}
+		if err := tx.Model(&models.SimCard{}).Where("id = ?", card.ID).Updates(updates).Error; err != nil {
+			return fmt.Errorf("ошибка при обновлении SIM-карты: %w", err)
+		}
+
+		if err := s.syncPhoneNumber(tx, equipment, card.MSISDN); err != nil {
+			return err
+		}
+		return createSimOperation(tx, equipment, userID,
+			fmt.Sprintf("Установлена SIM-карта %s (%s)", card.ICCID, card.MSISDN))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetSimCard(simID)
+}
+
+// UnpairSimCard извлекает SIM-карту из трекера и возвращает ее на склад
+func (s *SimCardService) UnpairSimCard(simID uint, warehouseID *uint, userID uint) (*models.SimCard, error) {
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		card, err := lockSimCard(tx, simID)
+		if err != nil {
+			return err
+		}
+		if card.EquipmentID == nil {
+			return fmt.Errorf("SIM-карта не установлена в трекер")
+		}
+		if warehouseID != nil {
+			var count int64
+			tx.Model(&models.Warehouse{}).Where("id = ?", *warehouseID).Count(&count)
+			if count == 0 {
+				return fmt.Errorf("склад не найден: %w", gorm.ErrRecordNotFound)
+			}
+		}
+
+		equipment, err := lockEquipment(tx, *card.EquipmentID)
+		if err != nil {
+			return err
+		}
+
+		updates := map[string]interface{}{
+			"equipment_id": nil,
+			"warehouse_id": warehouseID,
+			"paired_at":    nil,
+		}
+		if card.Status == models.SimStatusActive {
+			updates["status"] = models.SimStatusInStock
+		}
+		if err := tx.Model(&models.SimCard{}).Where("id = ?", card.ID).Updates(updates).Error; err != nil {
+			return fmt.Errorf("ошибка при обновлении SIM-карты: %w", err)
+		}
+
+		if equipment.PhoneNumber == card.MSISDN {
+			if err := s.syncPhoneNumber(tx, equipment, ""); err != nil {
+				return err
+			}
+		}
+		return createSimOperation(tx, equipment, userID,
+			fmt.Sprintf("Извлечена SIM-карта %s (%s)", card.ICCID, card.MSISDN))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetSimCard(simID)
+}
+
+// syncPhoneNumber записывает номер SIM-карты в трекер и объект, на котором он установлен
+func (s *SimCardService) syncPhoneNumber(tx *gorm.DB, equipment *models.Equipment, msisdn string) error {
+	if err := updateEquipmentState(tx, equipment, map[string]interface{}{"phone_number": msisdn}); err != nil {
+		return err
+	}
+	if equipment.ObjectID != nil {
+		if err := tx.Model(&models.Object{}).Where("id = ?", *equipment.ObjectID).
+			Update("phone_number", msisdn).Error; err != nil {
+			return fmt.Errorf("ошибка при обновлении номера объекта: %w", err)
+		}
+	}
+	return nil
+}
+
+// createSimOperation записывает установку или извлечение SIM-карты в историю трекера
+func createSimOperation(tx *gorm.DB, equipment *models.Equipment, userID uint, description string) error {
+	operation := models.WarehouseOperation{
+		Type:         "sim",
+		Description:  description,
+		Status:       "completed",
+		EquipmentID:  equipment.ID,
+		Quantity:     1,
+		FromLocation: equipment.WarehouseLocation,
+		ToLocation:   equipment.WarehouseLocation,
+		UserID:       userID,
+	}
+	if err := tx.Create(&operation).Error; err != nil {
+		return fmt.Errorf("ошибка при создании операции: %w", err)
+	}
+	return nil
+}
+
+// SetSimCardStatus приостанавливает, возобновляет или расторгает SIM-карту.
+// Расторгнуть можно только SIM-карту, извлеченную из трекера.
+func (s *SimCardService) SetSimCardStatus(simID uint, status string) (*models.SimCard, error) {
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		card, err := lockSimCard(tx, simID)
+		if err != nil {
+			return err
+		}
+		if card.Status == models.SimStatusTerminated {
+			return fmt.Errorf("договор по SIM-карте расторгнут")
+		}
+
+		now := time.Now()
+		updates := map[string]interface{}{"status": status}
+		switch status {
+		case models.SimStatusSuspended:
+			updates["suspended_at"] = now
+		case models.SimStatusActive, models.SimStatusInStock:
+			// Возобновление: статус определяется тем, установлена ли карта в трекер
+			if card.EquipmentID != nil {
+				updates["status"] = models.SimStatusActive
+			} else {
+				updates["status"] = models.SimStatusInStock
+			}
+			updates["suspended_at"] = nil
+		case models.SimStatusTerminated:
+			if card.EquipmentID != nil {
+				return fmt.Errorf("перед расторжением извлеките SIM-карту из трекера")
+			}
+			updates["terminated_at"] = now
+			updates["warehouse_id"] = nil
+		default:
+			return fmt.Errorf("неизвестный статус SIM-карты: %s", status)
+		}
+
+		if err := tx.Model(&models.SimCard{}).Where("id = ?", card.ID).Updates(updates).Error; err != nil {
+			return fmt.Errorf("ошибка при обновлении SIM-карты: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetSimCard(simID)
+}
+
+// GetIdleSimCards возвращает оплачиваемые SIM-карты в трекерах, которые не установлены
+// на объект либо установлены на неактивный или удаленный объект
+func (s *SimCardService) GetIdleSimCards() ([]IdleSimCard, error) {
+	type idleRow struct {
+		SimCardID     uint
+		ICCID         string
+		MSISDN        string
+		MonthlyCost   decimal.Decimal
+		EquipmentID   uint
+		ObjectID      *uint
+		ObjectName    *string
+		ObjectStatus  *string
+		ObjectActive  *bool
+		ObjectDeleted *time.Time
+		ObjectExists  *uint
+	}
+	var rows []idleRow
+	if err := s.DB.Table("sim_cards").
+		Select(`sim_cards.id AS sim_card_id, sim_cards.iccid, sim_cards.msisdn, sim_cards.monthly_cost,
+			equipment.id AS equipment_id, equipment.object_id, objects.name AS object_name,
+			objects.status AS object_status, objects.is_active AS object_active,
+			objects.deleted_at AS object_deleted, objects.id AS object_exists`).
+		Joins("JOIN equipment ON equipment.id = sim_cards.equipment_id").
+		Joins("LEFT JOIN objects ON objects.id = equipment.object_id").
+		Where("sim_cards.status = ? AND sim_cards.deleted_at IS NULL", models.SimStatusActive).
+		Where(`equipment.object_id IS NULL OR objects.id IS NULL OR objects.deleted_at IS NOT NULL
+			OR objects.is_active = ? OR objects.status IN ?`, false, []string{"inactive", "deleted"}).
+		Order("sim_cards.id").
+		Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("ошибка при поиске неиспользуемых SIM-карт: %w", err)
+	}
+
+	idle := make([]IdleSimCard, 0, len(rows))
+	for _, row := range rows {
+		item := IdleSimCard{
+			SimCardID:   row.SimCardID,
+			ICCID:       row.ICCID,
+			MSISDN:      row.MSISDN,
+			MonthlyCost: row.MonthlyCost,
+			EquipmentID: row.EquipmentID,
+			ObjectID:    row.ObjectID,
+		}
+		if row.ObjectName != nil {
+			item.ObjectName = *row.ObjectName
+		}
+		switch {
+		case row.ObjectID == nil:
+			item.Reason = "трекер не установлен на объект"
+		case row.ObjectExists == nil || row.ObjectDeleted != nil || (row.ObjectStatus != nil && *row.ObjectStatus == "deleted"):
+			item.Reason = "объект удален"
+		default:
+			item.Reason = "объект неактивен"
+		}
+		idle = append(idle, item)
+	}
+	return idle, nil
+}
+
+// CheckIdleSimCards создает складские уведомления по оплачиваемым SIM-картам неактивных объектов.
+// Для одного трекера держится одно активное уведомление.
+func (s *SimCardService) CheckIdleSimCards() (int, error) {
+	idle, err := s.GetIdleSimCards()
+	if err != nil {
+		return 0, err
+	}
+
+	created := 0
+	for _, item := range idle {
+		var existing int64
+		if err := s.DB.Model(&models.StockAlert{}).
+			Where("equipment_id = ? AND type = ? AND status = ?", item.EquipmentID, "idle_sim", "active").
+			Count(&existing).Error; err != nil {
+			return created, fmt.Errorf("ошибка при проверке существующих уведомлений: %w", err)
+		}
+		if existing > 0 {
+			continue
+		}
+
+		metadata, _ := json.Marshal(item)
+		equipmentID := item.EquipmentID
+		alert := models.StockAlert{
+			Type:  "idle_sim",
+			Title: fmt.Sprintf("Оплачивается неиспользуемая SIM-карта %s", item.MSISDN),
+			Description: fmt.Sprintf("SIM-карта %s (ICCID %s, %s в месяц): %s. Приостановите или расторгните SIM-карту.",
+				item.MSISDN, item.ICCID, item.MonthlyCost.StringFixed(2), item.Reason),
+			Severity:    "medium",
+			EquipmentID: &equipmentID,
+			Status:      "active",
+			Metadata:    string(metadata),
+		}
+		if err := s.DB.Create(&alert).Error; err != nil {
+			return created, fmt.Errorf("ошибка при создании уведомления: %w", err)
+		}
+		created++
+	}
+	return created, nil
+}
+
+// ParseSimChargePeriod разбирает месяц начислений в формате YYYY-MM
+func ParseSimChargePeriod(value string) (time.Time, error) {
+	period, err := time.Parse("2006-01", value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("неверный период %q, ожидается YYYY-MM", value)
+	}
+	return period, nil
+}
+
+// ImportOperatorCharges загружает детализацию начислений оператора за месяц (Excel или CSV)
+// и сверяет ее с учетом. Повторная загрузка за тот же месяц заменяет предыдущую.
+func (s *SimCardService) ImportOperatorCharges(operatorID uint, period time.Time, filename string, data []byte, userID uint) (*SimReconciliation, error) {
+	var operator models.MobileOperator
+	if err := s.DB.First(&operator, operatorID).Error; err != nil {
+		return nil, fmt.Errorf("оператор не найден: %w", err)
+	}
+
+	rows, err := readSpreadsheetRows(filename, data)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) < 2 {
+		return nil, fmt.Errorf("файл не содержит данных")
+	}
+	columns := matchSpreadsheetColumns(rows[0], simChargeColumns)
+	if _, ok := columns["amount"]; !ok {
+		return nil, fmt.Errorf("в файле отсутствует колонка с суммой начисления")
+	}
+	_, hasICCID := columns["iccid"]
+	_, hasMSISDN := columns["msisdn"]
+	if !hasICCID && !hasMSISDN {
+		return nil, fmt.Errorf("в файле отсутствует колонка с ICCID или номером телефона")
+	}
+	get := func(record []string, field string) string {
+		if i, ok := columns[field]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	charges := make([]models.SimOperatorCharge, 0, len(rows)-1)
+	skipped := 0
+	for i, record := range rows[1:] {
+		lineNumber := i + 2
+		rawAmount := get(record, "amount")
+		rawICCID := get(record, "iccid")
+		rawMSISDN := get(record, "msisdn")
+		if rawAmount == "" || (rawICCID == "" && rawMSISDN == "") {
+			skipped++ // Пустые и итоговые строки детализации
+			continue
+		}
+		amount, err := parseBankAmount(rawAmount)
+		if err != nil {
+			return nil, fmt.Errorf("строка %d: неверная сумма %q", lineNumber, rawAmount)
+		}
+
+		charge := models.SimOperatorCharge{
+			OperatorID:       operator.ID,
+			Period:           period,
+			LineNumber:       lineNumber,
+			MSISDN:           NormalizeMSISDN(rawMSISDN),
+			Amount:           amount,
+			ImportedByUserID: userID,
+			CompanyID:        operator.CompanyID,
+		}
+		if rawICCID != "" {
+			if iccid, err := NormalizeICCID(rawICCID); err == nil {
+				charge.ICCID = iccid
+			} else {
+				charge.ICCID = rawICCID
+			}
+		}
+		charges = append(charges, charge)
+	}
+	if len(charges) == 0 {
+		return nil, fmt.Errorf("в файле не найдено ни одного начисления")
+	}
+
+	if err := s.matchCharges(operator.ID, charges); err != nil {
+		return nil, err
+	}
+
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("operator_id = ? AND period = ?", operator.ID, period).
+			Delete(&models.SimOperatorCharge{}).Error; err != nil {
+			return fmt.Errorf("ошибка при удалении предыдущей загрузки: %w", err)
+		}
+		if err := tx.CreateInBatches(&charges, 200).Error; err != nil {
+			return fmt.Errorf("ошибка при сохранении начислений: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	reconciliation, err := s.GetReconciliation(operator.ID, period)
+	if err != nil {
+		return nil, err
+	}
+	reconciliation.ImportedCount = len(charges)
+	reconciliation.SkippedRows = skipped
+	return reconciliation, nil
+}
+
+// matchCharges сопоставляет начисления с SIM-картами по ICCID или номеру телефона и определяет результат сверки
+func (s *SimCardService) matchCharges(operatorID uint, charges []models.SimOperatorCharge) error {
+	var cards []models.SimCard
+	if err := s.DB.Unscoped().Where("operator_id = ?", operatorID).Find(&cards).Error; err != nil {
+		return fmt.Errorf("ошибка при получении SIM-карт оператора: %w", err)
+	}
+	byICCID := make(map[string]*models.SimCard, len(cards))
+	byMSISDN := make(map[string]*models.SimCard, len(cards))
+	for i := range cards {
+		byICCID[cards[i].ICCID] = &cards[i]
+		if cards[i].MSISDN != "" && cards[i].DeletedAt.Time.IsZero() {
+			byMSISDN[cards[i].MSISDN] = &cards[i]
+		}
+	}
+
+	idle, err := s.GetIdleSimCards()
+	if err != nil {
+		return err
+	}
+	idleReasons := make(map[uint]string, len(idle))
+	for _, item := range idle {
+		idleReasons[item.SimCardID] = item.Reason
+	}
+
+	for i := range charges {
+		charge := &charges[i]
+		card := byICCID[charge.ICCID]
+		if card == nil && charge.MSISDN != "" {
+			card = byMSISDN[charge.MSISDN]
+		}
+		if card == nil {
+			charge.Result = models.SimChargeUnknownSim
+			charge.Comment = "SIM-карта не найдена в учете"
+			continue
+		}
+
+		charge.SimCardID = &card.ID
+		charge.ExpectedAmount = card.MonthlyCost
+		switch {
+		case card.Status == models.SimStatusTerminated || !card.DeletedAt.Time.IsZero():
+			charge.ExpectedAmount = decimal.Zero
+			charge.Result = models.SimChargeTerminated
+			charge.Comment = "начисление по расторгнутой SIM-карте"
+		case idleReasons[card.ID] != "":
+			charge.Result = models.SimChargeInactiveObject
+			charge.Comment = idleReasons[card.ID]
+		case !charge.Amount.Equal(card.MonthlyCost):
+			charge.Result = models.SimChargeCostMismatch
+			charge.Comment = fmt.Sprintf("по тарифу %s, начислено %s", card.MonthlyCost.StringFixed(2), charge.Amount.StringFixed(2))
+		default:
+			charge.Result = models.SimChargeMatched
+		}
+	}
+	return nil
+}
+
+// GetReconciliation возвращает результат сверки начислений оператора за месяц
+func (s *SimCardService) GetReconciliation(operatorID uint, period time.Time) (*SimReconciliation, error) {
+	var charges []models.SimOperatorCharge
+	if err := s.DB.Preload("SimCard").
+		Where("operator_id = ? AND period = ?", operatorID, period).
+		Order("line_number").
+		Find(&charges).Error; err != nil {
+		return nil, fmt.Errorf("ошибка при получении начислений: %w", err)
+	}
+
+	reconciliation := &SimReconciliation{
+		OperatorID:     operatorID,
+		Period:         period,
+		Charges:        charges,
+		NotCharged:     []models.SimCard{},
+		TotalCharged:   decimal.Zero,
+		TotalExpected:  decimal.Zero,
+		Overpayment:    decimal.Zero,
+		ResultCounts:   map[string]int{},
+		ChargesPresent: len(charges) > 0,
+	}
+	charged := make(map[uint]bool, len(charges))
+	for _, charge := range charges {
+		reconciliation.TotalCharged = reconciliation.TotalCharged.Add(charge.Amount)
+		reconciliation.ResultCounts[charge.Result]++
+		if charge.SimCardID != nil {
+			charged[*charge.SimCardID] = true
+		}
+
+		switch charge.Result {
+		case models.SimChargeUnknownSim, models.SimChargeTerminated, models.SimChargeInactiveObject:
+			reconciliation.Overpayment = reconciliation.Overpayment.Add(charge.Amount)
+		default:
+			reconciliation.TotalExpected = reconciliation.TotalExpected.Add(charge.ExpectedAmount)
+			if diff := charge.Amount.Sub(charge.ExpectedAmount); diff.IsPositive() {
+				reconciliation.Overpayment = reconciliation.Overpayment.Add(diff)
+			}
+		}
+	}
+
+	// SIM-карты, числившиеся оплачиваемыми в течение месяца, но не попавшие в детализацию
+	if reconciliation.ChargesPresent {
+		var cards []models.SimCard
+		if err := s.DB.Where("operator_id = ? AND status IN ? AND created_at < ?", operatorID,
+			[]string{models.SimStatusActive, models.SimStatusInStock}, period.AddDate(0, 1, 0)).
+			Order("id").Find(&cards).Error; err != nil {
+			return nil, fmt.Errorf("ошибка при получении SIM-карт: %w", err)
+		}
+		for _, card := range cards {
+			if !charged[card.ID] {
+				reconciliation.NotCharged = append(reconciliation.NotCharged, card)
+			}
+		}
+	}
+
+	return reconciliation, nil
+}
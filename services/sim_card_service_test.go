@@ -0,0 +1,206 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"backend_axenta/models"
+)
+
+func setupSimCardTest(t *testing.T) (*gorm.DB, *SimCardService, *models.Warehouse, *models.MobileOperator) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(
+		&models.Warehouse{},
+		&models.Equipment{},
+		&models.EquipmentCategory{},
+		&models.WarehouseOperation{},
+		&models.Object{},
+		&models.StockAlert{},
+		&models.MobileOperator{},
+		&models.SimCard{},
+		&models.SimOperatorCharge{},
+	))
+
+	warehouse := &models.Warehouse{Name: "Основной склад", Code: "MSK", IsActive: true}
+	require.NoError(t, db.Create(warehouse).Error)
+
+	service := NewSimCardService(db)
+	operator := &models.MobileOperator{Name: "МТС", Code: "MTS", APN: "internet.mts.ru", DefaultTariff: "M2M", DefaultMonthlyCost: decimal.NewFromInt(150)}
+	require.NoError(t, service.CreateOperator(operator))
+
+	return db, service, warehouse, operator
+}
+
+func createSimTestEquipment(t *testing.T, db *gorm.DB, serial string, objectID *uint) models.Equipment {
+	status := models.EquipmentStatusInStock
+	if objectID != nil {
+		status = models.EquipmentStatusInstalled
+	}
+	equipment := models.Equipment{
+		Type: "GPS-tracker", Model: "GT06N", SerialNumber: serial, IMEI: "35000000000" + serial,
+		QRCode: "EQ-" + serial, Status: status, Condition: "new", ObjectID: objectID,
+	}
+	require.NoError(t, db.Create(&equipment).Error)
+	return equipment
+}
+
+func TestSimCardService_CreateAndPair(t *testing.T) {
+	db, service, warehouse, operator := setupSimCardTest(t)
+	assert.Equal(t, "mts", operator.Code)
+
+	cards, err := service.CreateSimCards(SimCardBatchRequest{
+		OperatorID:  operator.ID,
+		WarehouseID: &warehouse.ID,
+		Items: []SimCardLine{
+			{ICCID: "8970 1010 0000 0000 001F", MSISDN: "8 (900) 000-00-01"},
+			{ICCID: "89701010000000000002", MSISDN: "9000000002"},
+		},
+	})
+	require.NoError(t, err)
+	require.Len(t, cards, 2)
+	assert.Equal(t, "8970101000000000001", cards[0].ICCID)
+	assert.Equal(t, "+79000000001", cards[0].MSISDN)
+	assert.Equal(t, "M2M", cards[0].TariffName)
+	assert.True(t, decimal.NewFromInt(150).Equal(cards[0].MonthlyCost))
+
+	// Партия с повтором или неверным ICCID не проводится
+	_, err = service.CreateSimCards(SimCardBatchRequest{OperatorID: operator.ID, Items: []SimCardLine{
+		{ICCID: "89701010000000000003"}, {ICCID: "89701010000000000002"},
+	}})
+	assert.Error(t, err)
+	_, err = service.CreateSimCards(SimCardBatchRequest{OperatorID: operator.ID, Items: []SimCardLine{{ICCID: "123"}}})
+	assert.Error(t, err)
+	var count int64
+	db.Model(&models.SimCard{}).Count(&count)
+	assert.Equal(t, int64(2), count)
+
+	object := models.Object{Name: "Газель А001АА", Type: "vehicle", IMEI: "350000000000001", IsActive: true, Status: "active"}
+	require.NoError(t, db.Create(&object).Error)
+	tracker := createSimTestEquipment(t, db, "0001", &object.ID)
+
+	paired, err := service.PairSimCard(cards[0].ID, tracker.ID, 1)
+	require.NoError(t, err)
+	assert.Equal(t, models.SimStatusActive, paired.Status)
+	assert.Nil(t, paired.WarehouseID)
+	require.NotNil(t, paired.ActivatedAt)
+	require.NotNil(t, paired.Equipment)
+	assert.Equal(t, "+79000000001", paired.Equipment.PhoneNumber)
+
+	var updatedObject models.Object
+	require.NoError(t, db.First(&updatedObject, object.ID).Error)
+	assert.Equal(t, "+79000000001", updatedObject.PhoneNumber)
+
+	// Вторая SIM-карта в тот же трекер не устанавливается
+	_, err = service.PairSimCard(cards[1].ID, tracker.ID, 1)
+	assert.Error(t, err)
+
+	// Расторгнуть установленную SIM-карту нельзя
+	_, err = service.SetSimCardStatus(cards[0].ID, models.SimStatusTerminated)
+	assert.Error(t, err)
+
+	unpaired, err := service.UnpairSimCard(cards[0].ID, &warehouse.ID, 1)
+	require.NoError(t, err)
+	assert.Equal(t, models.SimStatusInStock, unpaired.Status)
+	assert.Nil(t, unpaired.EquipmentID)
+	require.NotNil(t, unpaired.WarehouseID)
+
+	var updatedTracker models.Equipment
+	require.NoError(t, db.First(&updatedTracker, tracker.ID).Error)
+	assert.Empty(t, updatedTracker.PhoneNumber)
+
+	var operations int64
+	db.Model(&models.WarehouseOperation{}).Where("equipment_id = ? AND type = ?", tracker.ID, "sim").Count(&operations)
+	assert.Equal(t, int64(2), operations)
+
+	terminated, err := service.SetSimCardStatus(cards[0].ID, models.SimStatusTerminated)
+	require.NoError(t, err)
+	require.NotNil(t, terminated.TerminatedAt)
+	_, err = service.PairSimCard(cards[0].ID, tracker.ID, 1)
+	assert.Error(t, err)
+}
+
+func TestSimCardService_IdleAlertsAndReconciliation(t *testing.T) {
+	db, service, _, operator := setupSimCardTest(t)
+
+	cards, err := service.CreateSimCards(SimCardBatchRequest{OperatorID: operator.ID, Items: []SimCardLine{
+		{ICCID: "89701010000000000001", MSISDN: "+79000000001"},
+		{ICCID: "89701010000000000002", MSISDN: "+79000000002"},
+		{ICCID: "89701010000000000003", MSISDN: "+79000000003"},
+		{ICCID: "89701010000000000004", MSISDN: "+79000000004"},
+	}})
+	require.NoError(t, err)
+
+	active := models.Object{Name: "Активный", Type: "vehicle", IMEI: "350000000000001", IsActive: true, Status: "active"}
+	inactive := models.Object{Name: "Неактивный", Type: "vehicle", IMEI: "350000000000002", IsActive: false, Status: "inactive"}
+	require.NoError(t, db.Create(&active).Error)
+	require.NoError(t, db.Create(&inactive).Error)
+	// Объект в корзине: трекер на нем продолжает оплачиваться
+	deleted := models.Object{Name: "Удаленный", Type: "vehicle", IMEI: "350000000000003", IsActive: true, Status: "active"}
+	require.NoError(t, db.Create(&deleted).Error)
+	require.NoError(t, db.Delete(&deleted).Error)
+
+	trackers := []models.Equipment{
+		createSimTestEquipment(t, db, "0001", &active.ID),
+		createSimTestEquipment(t, db, "0002", &inactive.ID),
+		createSimTestEquipment(t, db, "0003", &deleted.ID),
+	}
+	for i, tracker := range trackers {
+		_, err := service.PairSimCard(cards[i].ID, tracker.ID, 1)
+		require.NoError(t, err)
+	}
+
+	idle, err := service.GetIdleSimCards()
+	require.NoError(t, err)
+	require.Len(t, idle, 2)
+	assert.Equal(t, cards[1].ID, idle[0].SimCardID)
+	assert.Equal(t, "объект неактивен", idle[0].Reason)
+	assert.Equal(t, cards[2].ID, idle[1].SimCardID)
+	assert.Equal(t, "объект удален", idle[1].Reason)
+
+	created, err := service.CheckIdleSimCards()
+	require.NoError(t, err)
+	assert.Equal(t, 2, created)
+	created, err = service.CheckIdleSimCards()
+	require.NoError(t, err)
+	assert.Equal(t, 0, created, "повторная проверка не дублирует уведомления")
+
+	period, err := ParseSimChargePeriod(time.Now().Format("2006-01"))
+	require.NoError(t, err)
+	csv := "Номер телефона;ICCID;Сумма\n" +
+		"+7 900 000-00-01;89701010000000000001;150,00\n" +
+		"79000000002;;150,00\n" +
+		";89701010000000000003;180,00\n" +
+		"79009999999;;150,00\n" +
+		";;Итого\n"
+	result, err := service.ImportOperatorCharges(operator.ID, period, "mts.csv", []byte(csv), 1)
+	require.NoError(t, err)
+	assert.Equal(t, 4, result.ImportedCount)
+	assert.Equal(t, 1, result.SkippedRows)
+	assert.Equal(t, 1, result.ResultCounts[models.SimChargeMatched])
+	assert.Equal(t, 2, result.ResultCounts[models.SimChargeInactiveObject])
+	assert.Equal(t, 1, result.ResultCounts[models.SimChargeUnknownSim])
+	assert.True(t, decimal.NewFromInt(630).Equal(result.TotalCharged))
+	assert.True(t, decimal.NewFromInt(480).Equal(result.Overpayment))
+	require.Len(t, result.NotCharged, 1)
+	assert.Equal(t, cards[3].ID, result.NotCharged[0].ID)
+
+	// Повторная загрузка за тот же месяц заменяет детализацию
+	_, err = service.ImportOperatorCharges(operator.ID, period, "mts.csv",
+		[]byte("ICCID;Сумма\n89701010000000000001;200\n"), 1)
+	require.NoError(t, err)
+	result, err = service.GetReconciliation(operator.ID, period)
+	require.NoError(t, err)
+	require.Len(t, result.Charges, 1)
+	assert.Equal(t, models.SimChargeCostMismatch, result.Charges[0].Result)
+	assert.True(t, decimal.NewFromInt(50).Equal(result.Overpayment))
+}
@@ -0,0 +1,797 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"backend_axenta/models"
+)
+
+// DefaultSLAWarningPercent доля срока, после которой прогнозируется нарушение SLA
+const DefaultSLAWarningPercent = 80
+
+// openInstallationStatuses статусы монтажей, по которым еще идут сроки SLA
+var openInstallationStatuses = []string{"planned", "in_progress", "postponed"}
+
+// SLAService представляет сервис контроля SLA монтажей
+type SLAService struct {
+	DB                  *gorm.DB
+	NotificationService *NotificationService
+	Timezone            string // Часовой пояс компании для рабочего календаря
+}
+
+// NewSLAService создает новый экземпляр SLAService
+func NewSLAService(db *gorm.DB, notificationService *NotificationService) *SLAService {
+	return &SLAService{DB: db, NotificationService: notificationService}
+}
+
+// SLAPolicyRequest запрос на создание или изменение политики SLA
+type SLAPolicyRequest struct {
+	Name               string  `json:"name" binding:"required"`
+	ContractID         *uint   `json:"contract_id"`
+	Priority           string  `json:"priority"`
+	ScheduleHours      float64 `json:"schedule_hours"`
+	ArrivalHours       float64 `json:"arrival_hours"`
+	CompletionHours    float64 `json:"completion_hours"`
+	BusinessHoursStart string  `json:"business_hours_start"` // По умолчанию 09:00
+	BusinessHoursEnd   string  `json:"business_hours_end"`   // По умолчанию 18:00
+	BusinessDays       string  `json:"business_days"`        // По умолчанию 1,2,3,4,5
+	AroundTheClock     bool    `json:"around_the_clock"`
+	WarningPercent     int     `json:"warning_percent"` // По умолчанию 80
+	EscalationEmails   string  `json:"escalation_emails"`
+	EscalationPhones   string  `json:"escalation_phones"`
+	IsActive           *bool   `json:"is_active"` // По умолчанию политика активна
+}
+
+// SLAMetricStatus состояние одного срока SLA монтажа
+type SLAMetricStatus struct {
+	Metric       string     `json:"metric"`
+	BudgetHours  float64    `json:"budget_hours"`          // Срок в рабочих часах
+	Deadline     *time.Time `json:"deadline,omitempty"`    // Крайний срок с учетом рабочего календаря
+	ActualAt     *time.Time `json:"actual_at,omitempty"`   // Фактическое выполнение
+	ExpectedAt   *time.Time `json:"expected_at,omitempty"` // Ожидаемое выполнение по расписанию
+	ElapsedHours float64    `json:"elapsed_hours"`         // Израсходовано рабочих часов
+	UsedPct      float64    `json:"used_pct"`              // Израсходовано срока, %
+	State        string     `json:"state"`                 // on_track, at_risk, breached, met, none
+	Reason       string     `json:"reason,omitempty"`      // Причина прогноза нарушения
+}
+
+// InstallationSLA состояние SLA монтажа
+type InstallationSLA struct {
+	InstallationID uint              `json:"installation_id"`
+	Status         string            `json:"status"` // Статус монтажа
+	PolicyID       uint              `json:"policy_id"`
+	PolicyName     string            `json:"policy_name"`
+	ContractID     uint              `json:"contract_id"`
+	ClientName     string            `json:"client_name"`
+	InstallerID    uint              `json:"installer_id"`
+	ClockStart     time.Time         `json:"clock_start"` // Поступление заявки или создание монтажа
+	Metrics        []SLAMetricStatus `json:"metrics"`
+	State          string            `json:"state"` // Худшее состояние по срокам
+}
+
+// SLAMetricCompliance соблюдение одного срока SLA
+type SLAMetricCompliance struct {
+	Met           int     `json:"met"`
+	Breached      int     `json:"breached"`
+	CompliancePct float64 `json:"compliance_pct"`
+}
+
+// SLAComplianceRow соблюдение SLA по клиенту или монтажнику
+type SLAComplianceRow struct {
+	ID            uint                `json:"id"`
+	Name          string              `json:"name"`
+	Total         int                 `json:"total"`
+	Met           int                 `json:"met"`      // Все сроки выполнены
+	Breached      int                 `json:"breached"` // Нарушен хотя бы один срок
+	Open          int                 `json:"open"`     // Сроки еще идут
+	CompliancePct float64             `json:"compliance_pct"`
+	Schedule      SLAMetricCompliance `json:"schedule"`
+	Arrival       SLAMetricCompliance `json:"arrival"`
+	Completion    SLAMetricCompliance `json:"completion"`
+}
+
+// SLAComplianceReport отчет о соблюдении SLA за период
+type SLAComplianceReport struct {
+	From        time.Time          `json:"from"`
+	To          time.Time          `json:"to"`
+	ByClient    []SLAComplianceRow `json:"by_client"`
+	ByInstaller []SLAComplianceRow `json:"by_installer"`
+}
+
+// BusinessCalendar рабочий календарь для отсчета сроков SLA
+type BusinessCalendar struct {
+	Location       *time.Location
+	StartMinute    int // Начало рабочего дня, минут от полуночи
+	EndMinute      int // Конец рабочего дня, минут от полуночи
+	Days           map[time.Weekday]bool
+	AroundTheClock bool
+}
+
+// parseClock разбирает время HH:MM в минуты от полуночи
+func parseClock(value string) (int, error) {
+	parsed, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("неверное время %q, ожидается HH:MM", value)
+	}
+	return parsed.Hour()*60 + parsed.Minute(), nil
+}
+
+// parseBusinessDays разбирает список рабочих дней "1,2,3,4,5" (1 = понедельник, 7 = воскресенье)
+func parseBusinessDays(value string) (map[time.Weekday]bool, error) {
+	days := make(map[time.Weekday]bool)
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		day, err := strconv.Atoi(part)
+		if err != nil || day < 1 || day > 7 {
+			return nil, fmt.Errorf("неверный рабочий день %q, ожидается число от 1 до 7", part)
+		}
+		days[time.Weekday(day%7)] = true
+	}
+	if len(days) == 0 {
+		return nil, errors.New("не указаны рабочие дни")
+	}
+	return days, nil
+}
+
+// NewBusinessCalendar создает рабочий календарь политики SLA в часовом поясе tz.
+// Неверно заданный календарь политики заменяется на 09:00-18:00 по будням.
+func NewBusinessCalendar(policy *models.SLAPolicy, tz *time.Location) BusinessCalendar {
+	calendar := BusinessCalendar{Location: tz, AroundTheClock: policy.AroundTheClock}
+	start, errStart := parseClock(policy.BusinessHoursStart)
+	end, errEnd := parseClock(policy.BusinessHoursEnd)
+	if errStart != nil || errEnd != nil || start >= end {
+		start, end = 9*60, 18*60
+	}
+	calendar.StartMinute, calendar.EndMinute = start, end
+
+	days, err := parseBusinessDays(policy.BusinessDays)
+	if err != nil {
+		days, _ = parseBusinessDays("1,2,3,4,5")
+	}
+	calendar.Days = days
+	return calendar
+}
+
+// workingWindow возвращает рабочее время в день, которому принадлежит local
+func (c BusinessCalendar) workingWindow(local time.Time) (time.Time, time.Time, bool) {
+	dayStart, _ := localDayBounds(local)
+	if !c.Days[local.Weekday()] {
+		return time.Time{}, time.Time{}, false
+	}
+	return dayStart.Add(time.Duration(c.StartMinute) * time.Minute), dayStart.Add(time.Duration(c.EndMinute) * time.Minute), true
+}
+
+// Add возвращает момент, когда от from пройдет d рабочего времени
+func (c BusinessCalendar) Add(from time.Time, d time.Duration) time.Time {
+	if c.AroundTheClock || d <= 0 {
+		return from.Add(d)
+	}
+	current := from.In(c.Location)
+	for i := 0; i < 3660; i++ {
+		workStart, workEnd, working := c.workingWindow(current)
+		if working && current.Before(workEnd) {
+			if current.Before(workStart) {
+				current = workStart
+			}
+			available := workEnd.Sub(current)
+			if d <= available {
+				return current.Add(d)
+			}
+			d -= available
+		}
+		_, current = localDayBounds(current)
+	}
+	return current
+}
+
+// Between возвращает рабочее время между from и to
+func (c BusinessCalendar) Between(from, to time.Time) time.Duration {
+	if !to.After(from) {
+		return 0
+	}
+	if c.AroundTheClock {
+		return to.Sub(from)
+	}
+
+	var total time.Duration
+	current := from.In(c.Location)
+	for i := 0; i < 3660 && current.Before(to); i++ {
+		if workStart, workEnd, working := c.workingWindow(current); working {
+			start, end := workStart, workEnd
+			if current.After(start) {
+				start = current
+			}
+			if to.Before(end) {
+				end = to
+			}
+			if end.After(start) {
+				total += end.Sub(start)
+			}
+		}
+		_, current = localDayBounds(current)
+	}
+	return total
+}
+
+// SelectSLAPolicy выбирает политику для монтажа: политика договора важнее общей,
+// политика для приоритета важнее политики для любого приоритета
+func SelectSLAPolicy(policies []models.SLAPolicy, contractID uint, priority string) *models.SLAPolicy {
+	var best *models.SLAPolicy
+	bestScore := -1
+	for i := range policies {
+		policy := &policies[i]
+		if !policy.IsActive {
+			continue
+		}
+		score := 0
+		if policy.ContractID != nil {
+			if *policy.ContractID != contractID {
+				continue
+			}
+			score += 2
+		}
+		if policy.Priority != "" {
+			if policy.Priority != priority {
+				continue
+			}
+			score++
+		}
+		if score > bestScore || (score == bestScore && policy.ID < best.ID) {
+			best, bestScore = policy, score
+		}
+	}
+	return best
+}
+
+// evaluateSLAMetric определяет состояние срока: выполнен ли он вовремя, нарушен или
+// прогнозируется нарушение - по расписанию (expected) или по израсходованной доле срока
+func evaluateSLAMetric(metric string, budgetHours float64, clockStart time.Time, actual, expected *time.Time,
+	calendar BusinessCalendar, warningPercent int, now time.Time) SLAMetricStatus {
+	status := SLAMetricStatus{Metric: metric, BudgetHours: budgetHours, ActualAt: actual, ExpectedAt: expected, State: models.SLAStateNone}
+	if budgetHours <= 0 {
+		return status
+	}
+
+	budget := time.Duration(budgetHours * float64(time.Hour))
+	deadline := calendar.Add(clockStart, budget)
+	status.Deadline = &deadline
+
+	until := now
+	if actual != nil {
+		until = *actual
+	}
+	elapsed := calendar.Between(clockStart, until)
+	status.ElapsedHours = math.Round(elapsed.Hours()*100) / 100
+	status.UsedPct = math.Round(float64(elapsed)/float64(budget)*1000) / 10
+
+	switch {
+	case actual != nil && actual.After(deadline):
+		status.State = models.SLAStateBreached
+	case actual != nil:
+		status.State = models.SLAStateMet
+	case now.After(deadline):
+		status.State = models.SLAStateBreached
+	case expected != nil && expected.After(deadline):
+		status.State = models.SLAStateAtRisk
+		status.Reason = "По расписанию срок будет нарушен"
+	case status.UsedPct >= float64(warningPercent):
+		status.State = models.SLAStateAtRisk
+		status.Reason = fmt.Sprintf("Израсходовано %.0f%% срока", status.UsedPct)
+	default:
+		status.State = models.SLAStateOnTrack
+	}
+	return status
+}
+
+// slaStateSeverity порядок состояний для определения худшего
+var slaStateSeverity = map[string]int{
+	models.SLAStateNone:     0,
+	models.SLAStateMet:      1,
+	models.SLAStateOnTrack:  2,
+	models.SLAStateAtRisk:   3,
+	models.SLAStateBreached: 4,
+}
+
+// EvaluateInstallationSLA рассчитывает сроки SLA монтажа по политике: постановка в расписание
+// (создание монтажа), прибытие (начало работ) и завершение, отсчитываемые от clockStart
+func EvaluateInstallationSLA(installation *models.Installation, clockStart time.Time, policy *models.SLAPolicy, tz *time.Location, now time.Time) InstallationSLA {
+	calendar := NewBusinessCalendar(policy, tz)
+	warning := policy.WarningPercent
+	if warning <= 0 {
+		warning = DefaultSLAWarningPercent
+	}
+
+	scheduledAt := installation.CreatedAt
+	arrival := installation.StartedAt
+	if arrival == nil && installation.Status == "completed" {
+		arrival = installation.CompletedAt
+	}
+	var expectedArrival, expectedCompletion *time.Time
+	if arrival == nil {
+		expectedArrival = &installation.ScheduledAt
+	}
+	if installation.CompletedAt == nil {
+		start := installation.ScheduledAt
+		if installation.StartedAt != nil {
+			start = *installation.StartedAt
+		}
+		completion := start.Add(time.Duration(installation.EstimatedDuration) * time.Minute)
+		expectedCompletion = &completion
+	}
+
+	result := InstallationSLA{
+		InstallationID: installation.ID,
+		Status:         installation.Status,
+		PolicyID:       policy.ID,
+		PolicyName:     policy.Name,
+		InstallerID:    installation.InstallerID,
+		ClockStart:     clockStart,
+		Metrics: []SLAMetricStatus{
+			evaluateSLAMetric(models.SLAMetricSchedule, policy.ScheduleHours, clockStart, &scheduledAt, nil, calendar, warning, now),
+			evaluateSLAMetric(models.SLAMetricArrival, policy.ArrivalHours, clockStart, arrival, expectedArrival, calendar, warning, now),
+			evaluateSLAMetric(models.SLAMetricCompletion, policy.CompletionHours, clockStart, installation.CompletedAt, expectedCompletion, calendar, warning, now),
+		},
+		State: models.SLAStateNone,
+	}
+	for _, metric := range result.Metrics {
+		if slaStateSeverity[metric.State] > slaStateSeverity[result.State] {
+			result.State = metric.State
+		}
+	}
+	return result
+}
+
+// CreatePolicy создает политику SLA
+func (s *SLAService) CreatePolicy(req SLAPolicyRequest) (*models.SLAPolicy, error) {
+	policy := &models.SLAPolicy{IsActive: true}
+	if err := s.applyPolicy(policy, req); err != nil {
+		return nil, err
+	}
+	if err := s.DB.Create(policy).Error; err != nil {
+		return nil, fmt.Errorf("ошибка при создании политики SLA: %w", err)
+	}
+	return policy, nil
+}
+
+// UpdatePolicy изменяет политику SLA; сроки пересчитываются для всех монтажей по политике
+func (s *SLAService) UpdatePolicy(id uint, req SLAPolicyRequest) (*models.SLAPolicy, error) {
+	var policy models.SLAPolicy
+	if err := s.DB.First(&policy, id).Error; err != nil {
+		return nil, err
+	}
+	if err := s.applyPolicy(&policy, req); err != nil {
+		return nil, err
+	}
+	if err := s.DB.Save(&policy).Error; err != nil {
+		return nil, fmt.Errorf("ошибка при сохранении политики SLA: %w", err)
+	}
+	return &policy, nil
+}
+
+// applyPolicy проверяет запрос и переносит его в политику
+func (s *SLAService) applyPolicy(policy *models.SLAPolicy, req SLAPolicyRequest) error {
+	if req.ScheduleHours < 0 || req.ArrivalHours < 0 || req.CompletionHours < 0 {
+		return errors.New("сроки SLA не могут быть отрицательными")
+	}
+	if req.ScheduleHours == 0 && req.ArrivalHours == 0 && req.CompletionHours == 0 {
+		return errors.New("укажите хотя бы один срок SLA")
+	}
+	if req.Priority != "" && !validPriority(req.Priority) {
+		return fmt.Errorf("неизвестный приоритет: %s", req.Priority)
+	}
+	if req.WarningPercent < 0 || req.WarningPercent > 100 {
+		return errors.New("порог предупреждения должен быть от 0 до 100%")
+	}
+	if req.BusinessHoursStart == "" {
+		req.BusinessHoursStart = "09:00"
+	}
+	if req.BusinessHoursEnd == "" {
+		req.BusinessHoursEnd = "18:00"
+	}
+	if req.BusinessDays == "" {
+		req.BusinessDays = "1,2,3,4,5"
+	}
+	start, err := parseClock(req.BusinessHoursStart)
+	if err != nil {
+		return err
+	}
+	end, err := parseClock(req.BusinessHoursEnd)
+	if err != nil {
+		return err
+	}
+	if start >= end {
+		return errors.New("начало рабочего дня должно быть раньше конца")
+	}
+	if _, err := parseBusinessDays(req.BusinessDays); err != nil {
+		return err
+	}
+	if req.ContractID != nil {
+		if err := s.DB.Select("id").First(&models.Contract{}, *req.ContractID).Error; err != nil {
+			return fmt.Errorf("договор не найден: %w", err)
+		}
+	}
+
+	policy.Name = req.Name
+	policy.ContractID = req.ContractID
+	policy.Priority = req.Priority
+	policy.ScheduleHours = req.ScheduleHours
+	policy.ArrivalHours = req.ArrivalHours
+	policy.CompletionHours = req.CompletionHours
+	policy.BusinessHoursStart = req.BusinessHoursStart
+	policy.BusinessHoursEnd = req.BusinessHoursEnd
+	policy.BusinessDays = req.BusinessDays
+	policy.AroundTheClock = req.AroundTheClock
+	policy.WarningPercent = req.WarningPercent
+	if policy.WarningPercent == 0 {
+		policy.WarningPercent = DefaultSLAWarningPercent
+	}
+	policy.EscalationEmails = req.EscalationEmails
+	policy.EscalationPhones = req.EscalationPhones
+	if req.IsActive != nil {
+		policy.IsActive = *req.IsActive
+	}
+	return nil
+}
+
+// DeletePolicy удаляет политику SLA
+func (s *SLAService) DeletePolicy(id uint) error {
+	result := s.DB.Delete(&models.SLAPolicy{}, id)
+	if result.Error != nil {
+		return fmt.Errorf("ошибка при удалении политики SLA: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// GetPolicies возвращает политики SLA с фильтром по договору
+func (s *SLAService) GetPolicies(contractID *uint) ([]models.SLAPolicy, error) {
+	query := s.DB.Preload("Contract").Order("name, id")
+	if contractID != nil {
+		query = query.Where("contract_id = ?", *contractID)
+	}
+	var policies []models.SLAPolicy
+	err := query.Find(&policies).Error
+	return policies, err
+}
+
+// GetPolicy возвращает политику SLA
+func (s *SLAService) GetPolicy(id uint) (*models.SLAPolicy, error) {
+	var policy models.SLAPolicy
+	if err := s.DB.Preload("Contract").First(&policy, id).Error; err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+// evaluate рассчитывает SLA монтажей. Монтажи, к которым не применяется ни одна политика, пропускаются.
+func (s *SLAService) evaluate(installations []models.Installation, now time.Time) ([]InstallationSLA, error) {
+	if len(installations) == 0 {
+		return nil, nil
+	}
+	var policies []models.SLAPolicy
+	if err := s.DB.Where("is_active = ?", true).Find(&policies).Error; err != nil {
+		return nil, fmt.Errorf("ошибка при получении политик SLA: %w", err)
+	}
+	if len(policies) == 0 {
+		return nil, nil
+	}
+
+	objectIDs := make([]uint, 0, len(installations))
+	var requestIDs []uint
+	for _, installation := range installations {
+		objectIDs = append(objectIDs, installation.ObjectID)
+		if installation.ServiceRequestID != nil {
+			requestIDs = append(requestIDs, *installation.ServiceRequestID)
+		}
+	}
+
+	var objects []models.Object
+	if err := s.DB.Unscoped().Select("id", "contract_id").Where("id IN ?", objectIDs).Find(&objects).Error; err != nil {
+		return nil, err
+	}
+	objectContracts := make(map[uint]uint, len(objects))
+	contractIDs := make([]uint, 0, len(objects))
+	for _, object := range objects {
+		objectContracts[object.ID] = object.ContractID
+		contractIDs = append(contractIDs, object.ContractID)
+	}
+	var contracts []models.Contract
+	if err := s.DB.Unscoped().Select("id", "client_name").Where("id IN ?", contractIDs).Find(&contracts).Error; err != nil {
+		return nil, err
+	}
+	clientNames := make(map[uint]string, len(contracts))
+	for _, contract := range contracts {
+		clientNames[contract.ID] = contract.ClientName
+	}
+
+	requestCreated := make(map[uint]time.Time)
+	if len(requestIDs) > 0 {
+		var requests []models.ServiceRequest
+		if err := s.DB.Unscoped().Select("id", "created_at").Where("id IN ?", requestIDs).Find(&requests).Error; err != nil {
+			return nil, err
+		}
+		for _, request := range requests {
+			requestCreated[request.ID] = request.CreatedAt
+		}
+	}
+
+	tz := models.LoadTimezone(s.Timezone)
+	result := make([]InstallationSLA, 0, len(installations))
+	for i := range installations {
+		installation := &installations[i]
+		contractID := objectContracts[installation.ObjectID]
+		policy := SelectSLAPolicy(policies, contractID, installation.Priority)
+		if policy == nil {
+			continue
+		}
+		clockStart := installation.CreatedAt
+		if installation.ServiceRequestID != nil {
+			if created, ok := requestCreated[*installation.ServiceRequestID]; ok {
+				clockStart = created
+			}
+		}
+		evaluation := EvaluateInstallationSLA(installation, clockStart, policy, tz, now)
+		evaluation.ContractID = contractID
+		evaluation.ClientName = clientNames[contractID]
+		result = append(result, evaluation)
+	}
+	return result, nil
+}
+
+// GetInstallationSLA возвращает сроки SLA монтажа; nil, если политика к монтажу не применяется
+func (s *SLAService) GetInstallationSLA(installationID uint, now time.Time) (*InstallationSLA, error) {
+	var installation models.Installation
+	if err := s.DB.First(&installation, installationID).Error; err != nil {
+		return nil, fmt.Errorf("монтаж не найден: %w", err)
+	}
+	evaluations, err := s.evaluate([]models.Installation{installation}, now)
+	if err != nil || len(evaluations) == 0 {
+		return nil, err
+	}
+	return &evaluations[0], nil
+}
+
+// GetAtRisk возвращает незавершенные монтажи, по которым срок SLA нарушен или прогнозируется нарушение
+func (s *SLAService) GetAtRisk(now time.Time) ([]InstallationSLA, error) {
+	var installations []models.Installation
+	if err := s.DB.Where("status IN ?", openInstallationStatuses).Find(&installations).Error; err != nil {
+		return nil, fmt.Errorf("ошибка при получении монтажей: %w", err)
+	}
+	evaluations, err := s.evaluate(installations, now)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]InstallationSLA, 0)
+	for _, evaluation := range evaluations {
+		if evaluation.State == models.SLAStateAtRisk || evaluation.State == models.SLAStateBreached {
+			result = append(result, evaluation)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].State != result[j].State {
+			return result[i].State == models.SLAStateBreached
+		}
+		return result[i].InstallationID < result[j].InstallationID
+	})
+	return result, nil
+}
+
+// slaMetricNames названия сроков SLA для уведомлений
+var slaMetricNames = map[string]string{
+	models.SLAMetricSchedule:   "постановки в расписание",
+	models.SLAMetricArrival:    "прибытия на объект",
+	models.SLAMetricCompletion: "завершения работ",
+}
+
+// CheckEscalations проверяет сроки SLA незавершенных монтажей и эскалирует прогнозируемые
+// и фактические нарушения контактам политики. Каждое состояние каждого срока эскалируется один раз.
+func (s *SLAService) CheckEscalations(now time.Time) ([]models.SLAEscalation, error) {
+	atRisk, err := s.GetAtRisk(now)
+	if err != nil {
+		return nil, err
+	}
+
+	policies := make(map[uint]*models.SLAPolicy)
+	escalations := make([]models.SLAEscalation, 0)
+	for _, evaluation := range atRisk {
+		for _, metric := range evaluation.Metrics {
+			if metric.State != models.SLAStateAtRisk && metric.State != models.SLAStateBreached {
+				continue
+			}
+			var count int64
+			if err := s.DB.Model(&models.SLAEscalation{}).
+				Where("installation_id = ? AND metric = ? AND state = ?", evaluation.InstallationID, metric.Metric, metric.State).
+				Count(&count).Error; err != nil {
+				return nil, err
+			}
+			if count > 0 {
+				continue
+			}
+
+			policy, ok := policies[evaluation.PolicyID]
+			if !ok {
+				policy = &models.SLAPolicy{}
+				if err := s.DB.Unscoped().First(policy, evaluation.PolicyID).Error; err != nil {
+					return nil, err
+				}
+				policies[evaluation.PolicyID] = policy
+			}
+
+			message := fmt.Sprintf("Монтаж #%d: срок %s нарушен (%s)", evaluation.InstallationID,
+				slaMetricNames[metric.Metric], metric.Deadline.In(models.LoadTimezone(s.Timezone)).Format("02.01.2006 15:04"))
+			if metric.State == models.SLAStateAtRisk {
+				message = fmt.Sprintf("Монтаж #%d: под угрозой срок %s (%s). %s", evaluation.InstallationID,
+					slaMetricNames[metric.Metric], metric.Deadline.In(models.LoadTimezone(s.Timezone)).Format("02.01.2006 15:04"), metric.Reason)
+			}
+			escalation := models.SLAEscalation{
+				InstallationID: evaluation.InstallationID,
+				PolicyID:       policy.ID,
+				Metric:         metric.Metric,
+				State:          metric.State,
+				Deadline:       *metric.Deadline,
+				Message:        message,
+				CompanyID:      policy.CompanyID,
+			}
+			if err := s.DB.Create(&escalation).Error; err != nil {
+				return nil, fmt.Errorf("ошибка при сохранении эскалации SLA: %w", err)
+			}
+			s.notifyEscalation(policy, &escalation, evaluation.ClientName)
+			escalations = append(escalations, escalation)
+		}
+	}
+	return escalations, nil
+}
+
+// notifyEscalation отправляет эскалацию на адреса и телефоны политики
+func (s *SLAService) notifyEscalation(policy *models.SLAPolicy, escalation *models.SLAEscalation, clientName string) {
+	if s.NotificationService == nil {
+		return
+	}
+	data := map[string]interface{}{
+		"installation_id": escalation.InstallationID,
+		"metric":          escalation.Metric,
+		"state":           escalation.State,
+		"deadline":        escalation.Deadline,
+		"client_name":     clientName,
+		"message":         escalation.Message,
+	}
+	send := func(channel, recipients string) {
+		for _, recipient := range strings.Split(recipients, ",") {
+			if recipient = strings.TrimSpace(recipient); recipient == "" {
+				continue
+			}
+			if err := s.NotificationService.SendNotification("installation_sla_"+escalation.State, channel, recipient, data,
+				escalation.CompanyID, escalation.InstallationID, "installation"); err != nil {
+				log.Printf("Ошибка отправки эскалации SLA по монтажу %d: %v", escalation.InstallationID, err)
+			}
+		}
+	}
+	send("email", policy.EscalationEmails)
+	send("sms", policy.EscalationPhones)
+}
+
+// GetComplianceReport возвращает соблюдение SLA по клиентам и монтажникам для монтажей,
+// созданных в периоде [from, to). Отмененные монтажи не учитываются.
+func (s *SLAService) GetComplianceReport(from, to, now time.Time) (*SLAComplianceReport, error) {
+	var installations []models.Installation
+	if err := s.DB.Preload("Installer").
+		Where("created_at >= ? AND created_at < ? AND status <> ?", from, to, "cancelled").
+		Find(&installations).Error; err != nil {
+		return nil, fmt.Errorf("ошибка при получении монтажей: %w", err)
+	}
+	evaluations, err := s.evaluate(installations, now)
+	if err != nil {
+		return nil, err
+	}
+
+	installerNames := make(map[uint]string)
+	for _, installation := range installations {
+		if installation.Installer != nil {
+			installerNames[installation.InstallerID] = installation.Installer.GetFullName()
+		}
+	}
+
+	report := &SLAComplianceReport{
+		From: from,
+		To:   to,
+		ByClient: SummarizeSLACompliance(evaluations, func(e InstallationSLA) (uint, string) {
+			if e.ClientName == "" {
+				return e.ContractID, "Без договора"
+			}
+			return e.ContractID, e.ClientName
+		}),
+		ByInstaller: SummarizeSLACompliance(evaluations, func(e InstallationSLA) (uint, string) {
+			if name, ok := installerNames[e.InstallerID]; ok {
+				return e.InstallerID, name
+			}
+			return e.InstallerID, fmt.Sprintf("Монтажник #%d", e.InstallerID)
+		}),
+	}
+	return report, nil
+}
+
+// SummarizeSLACompliance группирует состояния SLA монтажей по ключу (клиент, монтажник).
+// Процент соблюдения считается по монтажам, у которых все сроки выполнены или хотя бы один нарушен.
+func SummarizeSLACompliance(evaluations []InstallationSLA, key func(InstallationSLA) (uint, string)) []SLAComplianceRow {
+	rows := make(map[uint]*SLAComplianceRow)
+	order := make([]uint, 0)
+	for _, evaluation := range evaluations {
+		id, name := key(evaluation)
+		row, ok := rows[id]
+		if !ok {
+			row = &SLAComplianceRow{ID: id, Name: name}
+			rows[id] = row
+			order = append(order, id)
+		}
+		row.Total++
+
+		closed := true
+		breached := false
+		for _, metric := range evaluation.Metrics {
+			var target *SLAMetricCompliance
+			switch metric.Metric {
+			case models.SLAMetricSchedule:
+				target = &row.Schedule
+			case models.SLAMetricArrival:
+				target = &row.Arrival
+			case models.SLAMetricCompletion:
+				target = &row.Completion
+			}
+			switch metric.State {
+			case models.SLAStateMet:
+				target.Met++
+			case models.SLAStateBreached:
+				target.Breached++
+				breached = true
+			case models.SLAStateOnTrack, models.SLAStateAtRisk:
+				closed = false
+			}
+		}
+		switch {
+		case breached:
+			row.Breached++
+		case closed:
+			row.Met++
+		default:
+			row.Open++
+		}
+	}
+
+	pct := func(met, breached int) float64 {
+		if met+breached == 0 {
+			return 100
+		}
+		return math.Round(float64(met)/float64(met+breached)*1000) / 10
+	}
+	result := make([]SLAComplianceRow, 0, len(order))
+	for _, id := range order {
+		row := rows[id]
+		row.CompliancePct = pct(row.Met, row.Breached)
+		row.Schedule.CompliancePct = pct(row.Schedule.Met, row.Schedule.Breached)
+		row.Arrival.CompliancePct = pct(row.Arrival.Met, row.Arrival.Breached)
+		row.Completion.CompliancePct = pct(row.Completion.Met, row.Completion.Breached)
+		result = append(result, *row)
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].CompliancePct != result[j].CompliancePct {
+			return result[i].CompliancePct < result[j].CompliancePct
+		}
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
@@ -0,0 +1,199 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"backend_axenta/models"
+)
+
+func TestBusinessCalendar(t *testing.T) {
+	calendar := NewBusinessCalendar(&models.SLAPolicy{BusinessHoursStart: "09:00", BusinessHoursEnd: "18:00", BusinessDays: "1,2,3,4,5"}, time.UTC)
+
+	// Пятница 16:00 + 4 рабочих часа = понедельник 11:00
+	friday := time.Date(2024, 6, 7, 16, 0, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2024, 6, 10, 11, 0, 0, 0, time.UTC), calendar.Add(friday, 4*time.Hour))
+	assert.Equal(t, 4*time.Hour, calendar.Between(friday, time.Date(2024, 6, 10, 11, 0, 0, 0, time.UTC)))
+
+	// Заявка ночью в субботу отсчитывается с начала понедельника
+	saturday := time.Date(2024, 6, 8, 23, 0, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2024, 6, 10, 10, 0, 0, 0, time.UTC), calendar.Add(saturday, time.Hour))
+	assert.Zero(t, calendar.Between(saturday, time.Date(2024, 6, 10, 8, 0, 0, 0, time.UTC)))
+
+	// Круглосуточная политика
+	around := NewBusinessCalendar(&models.SLAPolicy{AroundTheClock: true}, time.UTC)
+	assert.Equal(t, saturday.Add(4*time.Hour), around.Add(saturday, 4*time.Hour))
+
+	// Рабочий день в часовом поясе компании
+	moscow := time.FixedZone("MSK", 3*60*60)
+	local := NewBusinessCalendar(&models.SLAPolicy{BusinessHoursStart: "10:00", BusinessHoursEnd: "19:00", BusinessDays: "1,2,3,4,5,6"}, moscow)
+	assert.Equal(t, time.Date(2024, 6, 7, 16, 0, 0, 0, time.UTC), local.Add(time.Date(2024, 6, 7, 15, 0, 0, 0, time.UTC), time.Hour).UTC())
+	assert.Equal(t, time.Date(2024, 6, 8, 8, 0, 0, 0, time.UTC), local.Add(time.Date(2024, 6, 7, 16, 30, 0, 0, time.UTC), time.Hour).UTC())
+}
+
+func TestSelectSLAPolicy(t *testing.T) {
+	contractID := uint(7)
+	otherContract := uint(8)
+	policies := []models.SLAPolicy{
+		{ID: 1, Name: "Общая", IsActive: true},
+		{ID: 2, Name: "Срочные", Priority: "urgent", IsActive: true},
+		{ID: 3, Name: "Договор 7", ContractID: &contractID, IsActive: true},
+		{ID: 4, Name: "Договор 7, срочные", ContractID: &contractID, Priority: "urgent", IsActive: false},
+		{ID: 5, Name: "Договор 8", ContractID: &otherContract, IsActive: true},
+	}
+
+	assert.Equal(t, uint(1), SelectSLAPolicy(policies, 1, "normal").ID)
+	assert.Equal(t, uint(2), SelectSLAPolicy(policies, 1, "urgent").ID)
+	assert.Equal(t, uint(3), SelectSLAPolicy(policies, 7, "urgent").ID) // Политика договора важнее, неактивная пропускается
+	assert.Equal(t, uint(5), SelectSLAPolicy(policies, 8, "low").ID)
+	assert.Nil(t, SelectSLAPolicy(policies[2:3], 1, "normal"))
+}
+
+func TestEvaluateInstallationSLA(t *testing.T) {
+	policy := &models.SLAPolicy{ID: 1, Name: "Стандарт", ScheduleHours: 2, ArrivalHours: 8, CompletionHours: 16,
+		BusinessHoursStart: "09:00", BusinessHoursEnd: "18:00", BusinessDays: "1,2,3,4,5", WarningPercent: 80}
+	clockStart := time.Date(2024, 6, 3, 9, 0, 0, 0, time.UTC) // Понедельник
+
+	// Монтаж создан через час, выезд запланирован на вторник 11:00 - позже срока прибытия (понедельник 17:00)
+	installation := &models.Installation{
+		ID:                1,
+		Status:            "planned",
+		CreatedAt:         clockStart.Add(time.Hour),
+		ScheduledAt:       time.Date(2024, 6, 4, 11, 0, 0, 0, time.UTC),
+		EstimatedDuration: 120,
+	}
+	now := clockStart.Add(2 * time.Hour)
+	sla := EvaluateInstallationSLA(installation, clockStart, policy, time.UTC, now)
+	require.Len(t, sla.Metrics, 3)
+	assert.Equal(t, models.SLAStateMet, sla.Metrics[0].State)
+	assert.Equal(t, models.SLAStateAtRisk, sla.Metrics[1].State)
+	assert.Equal(t, time.Date(2024, 6, 3, 17, 0, 0, 0, time.UTC), *sla.Metrics[1].Deadline)
+	assert.Equal(t, models.SLAStateOnTrack, sla.Metrics[2].State) // Завершение во вторник 13:00, срок - вторник 16:00
+	assert.Equal(t, models.SLAStateAtRisk, sla.State)
+
+	// Выезд по расписанию в срок, но израсходовано больше 80% срока
+	installation.ScheduledAt = time.Date(2024, 6, 3, 16, 30, 0, 0, time.UTC)
+	sla = EvaluateInstallationSLA(installation, clockStart, policy, time.UTC, time.Date(2024, 6, 3, 15, 30, 0, 0, time.UTC))
+	assert.Equal(t, models.SLAStateAtRisk, sla.Metrics[1].State)
+	assert.Equal(t, 81.3, sla.Metrics[1].UsedPct)
+
+	// Монтажник приехал с опозданием, работы завершены в срок
+	started := time.Date(2024, 6, 4, 10, 0, 0, 0, time.UTC)
+	completed := time.Date(2024, 6, 4, 12, 0, 0, 0, time.UTC)
+	installation.Status = "completed"
+	installation.StartedAt = &started
+	installation.CompletedAt = &completed
+	sla = EvaluateInstallationSLA(installation, clockStart, policy, time.UTC, time.Date(2024, 6, 10, 9, 0, 0, 0, time.UTC))
+	assert.Equal(t, models.SLAStateBreached, sla.Metrics[1].State)
+	assert.Equal(t, models.SLAStateMet, sla.Metrics[2].State)
+	assert.Equal(t, models.SLAStateBreached, sla.State)
+
+	// Срок, не заданный в политике, не контролируется
+	sla = EvaluateInstallationSLA(installation, clockStart, &models.SLAPolicy{CompletionHours: 16}, time.UTC, completed)
+	assert.Equal(t, models.SLAStateNone, sla.Metrics[0].State)
+	assert.Nil(t, sla.Metrics[0].Deadline)
+}
+
+func TestSLAService_EscalationsAndCompliance(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(
+		&models.Object{},
+		&models.Contract{},
+		&models.Installation{},
+		&models.Installer{},
+		&models.ServiceRequest{},
+		&models.SLAPolicy{},
+		&models.SLAEscalation{},
+	))
+
+	contract := models.Contract{Number: "Д-1", Title: "Мониторинг", ClientName: "ООО Логистика", TariffPlanID: 1,
+		StartDate: time.Now().AddDate(-1, 0, 0), EndDate: time.Now().AddDate(1, 0, 0)}
+	require.NoError(t, db.Create(&contract).Error)
+	truck := models.Object{Name: "КамАЗ А001АА", IMEI: "356938035643801", ContractID: contract.ID}
+	require.NoError(t, db.Create(&truck).Error)
+	car := models.Object{Name: "Газель В002ВВ", IMEI: "356938035643802"}
+	require.NoError(t, db.Create(&car).Error)
+
+	service := NewSLAService(db, NewNotificationService(db, nil))
+	service.Timezone = "UTC"
+	_, err = service.CreatePolicy(SLAPolicyRequest{Name: "Пустая"})
+	require.Error(t, err)
+	_, err = service.CreatePolicy(SLAPolicyRequest{Name: "Неверный календарь", ArrivalHours: 4, BusinessHoursStart: "18:00", BusinessHoursEnd: "09:00"})
+	require.Error(t, err)
+	_, err = service.CreatePolicy(SLAPolicyRequest{Name: "Неверные дни", ArrivalHours: 4, BusinessDays: "1,8"})
+	require.Error(t, err)
+
+	general, err := service.CreatePolicy(SLAPolicyRequest{Name: "Общая", ArrivalHours: 24, CompletionHours: 48, AroundTheClock: true})
+	require.NoError(t, err)
+	assert.Equal(t, DefaultSLAWarningPercent, general.WarningPercent)
+	assert.True(t, general.IsActive)
+	strict, err := service.CreatePolicy(SLAPolicyRequest{Name: "Логистика", ContractID: &contract.ID, ArrivalHours: 4, CompletionHours: 8,
+		AroundTheClock: true, EscalationEmails: "dispatch@example.com"})
+	require.NoError(t, err)
+
+	now := time.Now()
+	// По договору: заявка поступила 5 часов назад, монтажник еще не приехал
+	request := models.ServiceRequest{Source: models.ServiceRequestSourcePhone, Status: models.ServiceRequestStatusScheduled,
+		Priority: "normal", Subject: "Трекер не на связи", CreatedAt: now.Add(-5 * time.Hour)}
+	require.NoError(t, db.Create(&request).Error)
+	late := models.Installation{Type: "диагностика", Status: "planned", ScheduledAt: now.Add(time.Hour), EstimatedDuration: 60,
+		ObjectID: truck.ID, InstallerID: 1, ServiceRequestID: &request.ID}
+	require.NoError(t, db.Create(&late).Error)
+	// Без договора: выезд через 2 часа, общий срок прибытия 24 часа
+	fine := models.Installation{Type: "монтаж", Status: "planned", ScheduledAt: now.Add(2 * time.Hour), EstimatedDuration: 60,
+		ObjectID: car.ID, InstallerID: 2}
+	require.NoError(t, db.Create(&fine).Error)
+
+	sla, err := service.GetInstallationSLA(late.ID, now)
+	require.NoError(t, err)
+	require.NotNil(t, sla)
+	assert.Equal(t, strict.ID, sla.PolicyID)
+	assert.Equal(t, "ООО Логистика", sla.ClientName)
+	assert.WithinDuration(t, request.CreatedAt, sla.ClockStart, time.Second)
+	assert.Equal(t, models.SLAStateBreached, sla.Metrics[1].State)
+	assert.Equal(t, models.SLAStateOnTrack, sla.Metrics[2].State) // Завершение по расписанию через 2 часа, срок - через 3
+
+	atRisk, err := service.GetAtRisk(now)
+	require.NoError(t, err)
+	require.Len(t, atRisk, 1)
+	assert.Equal(t, late.ID, atRisk[0].InstallationID)
+
+	escalations, err := service.CheckEscalations(now)
+	require.NoError(t, err)
+	require.Len(t, escalations, 1)
+	assert.Equal(t, models.SLAMetricArrival, escalations[0].Metric)
+	assert.Equal(t, models.SLAStateBreached, escalations[0].State)
+	assert.Contains(t, escalations[0].Message, "прибытия на объект")
+
+	// Повторная проверка не эскалирует то же нарушение
+	escalations, err = service.CheckEscalations(now.Add(time.Minute))
+	require.NoError(t, err)
+	assert.Empty(t, escalations)
+
+	// Завершение монтажей и отчет о соблюдении
+	completedAt := now.Add(2 * time.Hour)
+	require.NoError(t, db.Model(&late).Updates(map[string]interface{}{"status": "completed", "started_at": now, "completed_at": completedAt}).Error)
+	require.NoError(t, db.Model(&fine).Updates(map[string]interface{}{"status": "completed", "started_at": completedAt, "completed_at": completedAt.Add(time.Hour)}).Error)
+
+	report, err := service.GetComplianceReport(now.Add(-24*time.Hour), now.Add(24*time.Hour), now.Add(4*time.Hour))
+	require.NoError(t, err)
+	require.Len(t, report.ByClient, 2)
+	assert.Equal(t, "ООО Логистика", report.ByClient[0].Name)
+	assert.Equal(t, 1, report.ByClient[0].Breached)
+	assert.Equal(t, 0.0, report.ByClient[0].CompliancePct)
+	assert.Equal(t, 1, report.ByClient[0].Completion.Met)
+	assert.Equal(t, "Без договора", report.ByClient[1].Name)
+	assert.Equal(t, 100.0, report.ByClient[1].CompliancePct)
+	require.Len(t, report.ByInstaller, 2)
+	assert.Equal(t, "Монтажник #1", report.ByInstaller[0].Name)
+	assert.Equal(t, 1, report.ByInstaller[1].Met)
+}
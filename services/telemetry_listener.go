@@ -0,0 +1,91 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"net"
+	"sync"
+	"time"
+)
+
+// DefaultTelemetryIdleTimeout время ожидания данных от трекера до закрытия соединения
+const DefaultTelemetryIdleTimeout = 5 * time.Minute
+
+// telemetryListener общая часть TCP серверов телеметрии: прием соединений трекеров
+// и их закрытие при остановке сервера
+type telemetryListener struct {
+	name string // Название протокола для журнала
+
+	mu       sync.Mutex
+	listener net.Listener
+	conns    map[net.Conn]struct{}
+	closed   bool
+	wg       sync.WaitGroup
+}
+
+// serve принимает соединения и обслуживает каждое функцией handle в отдельной горутине.
+// После завершения handle соединение закрывается.
+func (l *telemetryListener) serve(listener net.Listener, handle func(net.Conn)) error {
+	l.mu.Lock()
+	if l.closed {
+		l.mu.Unlock()
+		listener.Close()
+		return net.ErrClosed
+	}
+	l.listener = listener
+	if l.conns == nil {
+		l.conns = map[net.Conn]struct{}{}
+	}
+	l.mu.Unlock()
+
+	log.Printf("Сервер %s принимает соединения на %s", l.name, listener.Addr())
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			l.mu.Lock()
+			closed := l.closed
+			l.mu.Unlock()
+			if closed {
+				return nil
+			}
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				time.Sleep(100 * time.Millisecond)
+				continue
+			}
+			return err
+		}
+
+		l.mu.Lock()
+		l.conns[conn] = struct{}{}
+		l.wg.Add(1)
+		l.mu.Unlock()
+		go func() {
+			defer func() {
+				conn.Close()
+				l.mu.Lock()
+				delete(l.conns, conn)
+				l.mu.Unlock()
+				l.wg.Done()
+			}()
+			handle(conn)
+		}()
+	}
+}
+
+// Close останавливает прием соединений и закрывает подключения трекеров
+func (l *telemetryListener) Close() error {
+	l.mu.Lock()
+	l.closed = true
+	var err error
+	if l.listener != nil {
+		err = l.listener.Close()
+	}
+	for conn := range l.conns {
+		conn.Close()
+	}
+	l.mu.Unlock()
+
+	l.wg.Wait()
+	return err
+}
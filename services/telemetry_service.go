@@ -0,0 +1,163 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+
+	"backend_axenta/models"
+)
+
+// ErrTelemetryUnknownDevice трекер с таким IMEI не привязан ни к одному объекту
+var ErrTelemetryUnknownDevice = errors.New("устройство с таким IMEI не зарегистрировано")
+
+// schemaNamePattern допустимое имя схемы компании
+var schemaNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// TelemetryService принимает телеметрию трекеров: находит объект по IMEI,
+// сохраняет точки и обновляет последнее положение объекта
+type TelemetryService struct {
+	DB *gorm.DB
+
+	// MultiTenant - искать устройства в схемах всех активных компаний; иначе
+	// объекты и телеметрия находятся в таблицах DB без указания схемы
+	MultiTenant bool
+
+	mu      sync.RWMutex
+	schemas map[string]string // IMEI -> схема компании
+}
+
+// NewTelemetryService создает новый экземпляр TelemetryService
+func NewTelemetryService(db *gorm.DB, multiTenant bool) *TelemetryService {
+	return &TelemetryService{DB: db, MultiTenant: multiTenant, schemas: map[string]string{}}
+}
+
+// TelemetryDevice авторизованный трекер
+type TelemetryDevice struct {
+	IMEI     string
+	ObjectID uint
+	Schema   string // Схема компании, пусто в режиме одной базы
+}
+
+// table возвращает имя таблицы в схеме компании устройства. Таблицы указываются
+// со схемой явно, так как соединения пула не привязаны к search_path компании.
+func (d *TelemetryDevice) table(name string) string {
+	if d.Schema == "" {
+		return name
+	}
+	return d.Schema + "." + name
+}
+
+// Authenticate находит объект по IMEI трекера
+func (s *TelemetryService) Authenticate(imei string) (*TelemetryDevice, error) {
+	if !imeiPattern.MatchString(imei) {
+		return nil, ErrTelemetryUnknownDevice
+	}
+	if !s.MultiTenant {
+		return s.findDevice(imei, "")
+	}
+
+	s.mu.RLock()
+	schema, cached := s.schemas[imei]
+	s.mu.RUnlock()
+	if cached {
+		device, err := s.findDevice(imei, schema)
+		if err == nil || !errors.Is(err, ErrTelemetryUnknownDevice) {
+			return device, err
+		}
+		// Объект удален или перенесен в другую компанию
+		s.mu.Lock()
+		delete(s.schemas, imei)
+		s.mu.Unlock()
+	}
+
+	var schemas []string
+	if err := s.DB.Model(&models.Company{}).Where("is_active = ? AND database_schema <> ''", true).
+		Order("id").Pluck("database_schema", &schemas).Error; err != nil {
+		return nil, fmt.Errorf("ошибка при получении списка компаний: %w", err)
+	}
+	for _, schema := range schemas {
+		if !schemaNamePattern.MatchString(schema) {
+			continue
+		}
+		device, err := s.findDevice(imei, schema)
+		if errors.Is(err, ErrTelemetryUnknownDevice) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		s.mu.Lock()
+		s.schemas[imei] = schema
+		s.mu.Unlock()
+		return device, nil
+	}
+	return nil, ErrTelemetryUnknownDevice
+}
+
+// findDevice ищет неудаленный объект с IMEI в схеме компании
+func (s *TelemetryService) findDevice(imei, schema string) (*TelemetryDevice, error) {
+	device := &TelemetryDevice{IMEI: imei, Schema: schema}
+	var ids []uint
+	if err := s.DB.Table(device.table("objects")).Where("imei = ? AND deleted_at IS NULL", imei).
+		Limit(1).Pluck("id", &ids).Error; err != nil {
+		return nil, fmt.Errorf("ошибка при поиске объекта: %w", err)
+	}
+	if len(ids) == 0 {
+		return nil, ErrTelemetryUnknownDevice
+	}
+	device.ObjectID = ids[0]
+	return device, nil
+}
+
+// Store сохраняет точки телеметрии устройства и обновляет положение и время
+// последней активности объекта по самой поздней точке, если она новее сохраненной
+func (s *TelemetryService) Store(device *TelemetryDevice, points []models.TelemetryPoint) error {
+	if len(points) == 0 {
+		return nil
+	}
+
+	latest := -1
+	for i := range points {
+		points[i].ObjectID = device.ObjectID
+		if latest < 0 || points[i].RecordedAt.After(points[latest].RecordedAt) {
+			latest = i
+		}
+	}
+
+	return s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Table(device.table("telemetry_points")).Create(&points).Error; err != nil {
+			return fmt.Errorf("ошибка при сохранении телеметрии: %w", err)
+		}
+
+		point := points[latest]
+		updates := map[string]interface{}{"last_activity_at": point.RecordedAt}
+		if point.HasPosition() {
+			updates["latitude"] = *point.Latitude
+			updates["longitude"] = *point.Longitude
+		}
+		if err := tx.Table(device.table("objects")).
+			Where("id = ? AND (last_activity_at IS NULL OR last_activity_at < ?)", device.ObjectID, point.RecordedAt).
+			Updates(updates).Error; err != nil {
+			return fmt.Errorf("ошибка при обновлении положения объекта: %w", err)
+		}
+		return nil
+	})
+}
+
+// GetObjectTelemetry возвращает точки телеметрии объекта за период, от новых к старым
+func GetObjectTelemetry(db *gorm.DB, objectID uint, from, to time.Time, limit int) ([]models.TelemetryPoint, error) {
+	if limit <= 0 || limit > 10000 {
+		limit = 1000
+	}
+	var points []models.TelemetryPoint
+	if err := db.Where("object_id = ? AND recorded_at >= ? AND recorded_at < ?", objectID, from, to).
+		Order("recorded_at DESC").Limit(limit).Find(&points).Error; err != nil {
+		return nil, err
+	}
+	return points, nil
+}
@@ -0,0 +1,154 @@
+package services
+
+import (
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"backend_axenta/models"
+)
+
+// setupWarehouseConcurrencyTest создает файловую БД, общую для всех горутин теста
+func setupWarehouseConcurrencyTest(t *testing.T) (*gorm.DB, *WarehouseService) {
+	dsn := filepath.Join(t.TempDir(), "warehouse.db") + "?_busy_timeout=5000"
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+
+	// SQLite допускает только одного писателя, транзакции выполняются по очереди
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	require.NoError(t, db.AutoMigrate(
+		&models.Warehouse{},
+		&models.StorageBin{},
+		&models.Equipment{},
+		&models.WarehouseOperation{},
+		&models.Object{},
+	))
+
+	return db, NewWarehouseService(db, nil)
+}
+
+func createConcurrencyTestEquipment(t *testing.T, db *gorm.DB, status string) models.Equipment {
+	equipment := models.Equipment{
+		Type: "GPS-tracker", Model: "GT06N", SerialNumber: "SN-" + status, IMEI: "IMEI-" + status, QRCode: "QR-" + status,
+		Status: status, Condition: "new",
+	}
+	require.NoError(t, db.Create(&equipment).Error)
+	return equipment
+}
+
+func TestWarehouseService_ConcurrentReserve(t *testing.T) {
+	db, ws := setupWarehouseConcurrencyTest(t)
+	equipment := createConcurrencyTestEquipment(t, db, models.EquipmentStatusInStock)
+
+	const managers = 8
+	var wg sync.WaitGroup
+	errs := make(chan error, managers)
+	for i := 0; i < managers; i++ {
+		wg.Add(1)
+		go func(userID uint) {
+			defer wg.Done()
+			errs <- ws.ReserveEquipment(equipment.ID, userID, "Резерв под монтаж")
+		}(uint(i + 1))
+	}
+	wg.Wait()
+	close(errs)
+
+	succeeded := 0
+	for err := range errs {
+		if err == nil {
+			succeeded++
+		}
+	}
+	assert.Equal(t, 1, succeeded)
+
+	var reserved models.Equipment
+	require.NoError(t, db.First(&reserved, equipment.ID).Error)
+	assert.Equal(t, models.EquipmentStatusReserved, reserved.Status)
+	assert.Equal(t, uint(2), reserved.Version)
+
+	var operations int64
+	db.Model(&models.WarehouseOperation{}).Where("equipment_id = ? AND type = 'reserve'", equipment.ID).Count(&operations)
+	assert.Equal(t, int64(1), operations)
+}
+
+func TestWarehouseService_ConcurrentInstallation(t *testing.T) {
+	db, ws := setupWarehouseConcurrencyTest(t)
+	equipment := createConcurrencyTestEquipment(t, db, models.EquipmentStatusInStock)
+
+	objects := []models.Object{{Name: "Объект 1", IMEI: "100000000000001"}, {Name: "Объект 2", IMEI: "100000000000002"}}
+	require.NoError(t, db.Create(&objects).Error)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, len(objects))
+	for _, object := range objects {
+		wg.Add(1)
+		go func(objectID uint) {
+			defer wg.Done()
+			errs <- ws.ProcessEquipmentInstallation(equipment.ID, objectID, 1)
+		}(object.ID)
+	}
+	wg.Wait()
+	close(errs)
+
+	succeeded := 0
+	for err := range errs {
+		if err == nil {
+			succeeded++
+		}
+	}
+	assert.Equal(t, 1, succeeded)
+
+	var operations int64
+	db.Model(&models.WarehouseOperation{}).Where("equipment_id = ? AND type = 'issue'", equipment.ID).Count(&operations)
+	assert.Equal(t, int64(1), operations)
+}
+
+func TestWarehouseService_StaleEquipmentVersion(t *testing.T) {
+	db, _ := setupWarehouseConcurrencyTest(t)
+	equipment := createConcurrencyTestEquipment(t, db, models.EquipmentStatusInStock)
+
+	// Параллельная операция успела изменить запись после чтения
+	stale := equipment
+	require.NoError(t, db.Model(&models.Equipment{}).Where("id = ?", equipment.ID).
+		Update("version", gorm.Expr("version + 1")).Error)
+
+	err := updateEquipmentState(db, &stale, map[string]interface{}{"status": models.EquipmentStatusReserved})
+	assert.ErrorIs(t, err, ErrEquipmentConflict)
+
+	var current models.Equipment
+	require.NoError(t, db.First(&current, equipment.ID).Error)
+	assert.Equal(t, models.EquipmentStatusInStock, current.Status)
+}
+
+func TestWarehouseService_StatusTransitions(t *testing.T) {
+	db, ws := setupWarehouseConcurrencyTest(t)
+	object := models.Object{Name: "Объект", IMEI: "100000000000003"}
+	require.NoError(t, db.Create(&object).Error)
+
+	// in_stock → reserved → installed
+	equipment := createConcurrencyTestEquipment(t, db, models.EquipmentStatusInStock)
+	require.NoError(t, ws.ReserveEquipment(equipment.ID, 1, ""))
+	assert.Error(t, ws.ReserveEquipment(equipment.ID, 1, ""))
+	require.NoError(t, ws.ProcessEquipmentInstallation(equipment.ID, object.ID, 1))
+	assert.Error(t, ws.UnreserveEquipment(equipment.ID, 1))
+
+	// Списанное оборудование не возвращается в оборот
+	disposed := createConcurrencyTestEquipment(t, db, models.EquipmentStatusDisposed)
+	assert.Error(t, ws.ReserveEquipment(disposed.ID, 1, ""))
+	assert.Error(t, ws.ProcessEquipmentInstallation(disposed.ID, object.ID, 1))
+
+	assert.True(t, models.CanTransitionEquipmentStatus(models.EquipmentStatusInstalled, models.EquipmentStatusInStock))
+	assert.False(t, models.CanTransitionEquipmentStatus(models.EquipmentStatusReserved, models.EquipmentStatusDisposed))
+}
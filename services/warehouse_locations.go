@@ -0,0 +1,369 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"backend_axenta/models"
+)
+
+// StockTransferRequest параметры перемещения оборудования между складами
+type StockTransferRequest struct {
+	EquipmentID     uint   `json:"equipment_id" binding:"required"`
+	FromWarehouseID *uint  `json:"from_warehouse_id"` // Ожидаемый склад-отправитель (для проверки)
+	ToWarehouseID   uint   `json:"to_warehouse_id" binding:"required"`
+	ToBinID         *uint  `json:"to_bin_id"`
+	UserID          uint   `json:"user_id"`
+	DocumentNumber  string `json:"document_number"`
+	Notes           string `json:"notes"`
+}
+
+// StockOnHandFilter фильтры отчета об остатках
+type StockOnHandFilter struct {
+	WarehouseID *uint
+	CategoryID  *uint
+	Type        string // warehouse, van
+}
+
+// StockOnHandRow остаток оборудования на складе в разрезе категории
+type StockOnHandRow struct {
+	WarehouseID   uint   `json:"warehouse_id"`
+	WarehouseCode string `json:"warehouse_code"`
+	WarehouseName string `json:"warehouse_name"`
+	WarehouseType string `json:"warehouse_type"`
+	CategoryID    *uint  `json:"category_id"`
+	CategoryName  string `json:"category_name"`
+	InStock       int64  `json:"in_stock"`
+	Reserved      int64  `json:"reserved"`
+	Total         int64  `json:"total"`
+}
+
+// CreateWarehouse создает склад или мобильный склад монтажника
+func (ws *WarehouseService) CreateWarehouse(warehouse *models.Warehouse) error {
+	warehouse.Code = strings.ToUpper(strings.TrimSpace(warehouse.Code))
+	if warehouse.Type == "" {
+		warehouse.Type = models.WarehouseTypeStock
+	}
+	if strings.TrimSpace(warehouse.Name) == "" || warehouse.Code == "" {
+		return fmt.Errorf("название и код склада обязательны")
+	}
+
+	switch warehouse.Type {
+	case models.WarehouseTypeStock:
+		warehouse.InstallerID = nil
+	case models.WarehouseTypeVan:
+		if warehouse.InstallerID == nil {
+			return fmt.Errorf("для мобильного склада необходимо указать монтажника")
+		}
+		var count int64
+		if err := ws.DB.Model(&models.Warehouse{}).
+			Where("type = ? AND installer_id = ?", models.WarehouseTypeVan, *warehouse.InstallerID).
+			Count(&count).Error; err != nil {
+			return fmt.Errorf("ошибка при проверке мобильного склада: %w", err)
+		}
+		if count > 0 {
+			return fmt.Errorf("у монтажника уже есть мобильный склад")
+		}
+	default:
+		return fmt.Errorf("неизвестный тип склада: %s", warehouse.Type)
+	}
+
+	var count int64
+	if err := ws.DB.Model(&models.Warehouse{}).Where("code = ?", warehouse.Code).Count(&count).Error; err != nil {
+		return fmt.Errorf("ошибка при проверке кода склада: %w", err)
+	}
+	if count > 0 {
+		return fmt.Errorf("склад с кодом %s уже существует", warehouse.Code)
+	}
+
+	if err := ws.DB.Create(warehouse).Error; err != nil {
+		return fmt.Errorf("ошибка при создании склада: %w", err)
+	}
+	return nil
+}
+
+// GetWarehouses возвращает список складов
+func (ws *WarehouseService) GetWarehouses(warehouseType string, activeOnly bool) ([]models.Warehouse, error) {
+	query := ws.DB.Preload("Location").Preload("Installer")
+	if warehouseType != "" {
+		query = query.Where("type = ?", warehouseType)
+	}
+	if activeOnly {
+		query = query.Where("is_active = ?", true)
+	}
+
+	var warehouses []models.Warehouse
+	if err := query.Order("type, name").Find(&warehouses).Error; err != nil {
+		return nil, fmt.Errorf("ошибка при получении складов: %w", err)
+	}
+	return warehouses, nil
+}
+
+// UpdateWarehouse обновляет склад. Код и тип склада не меняются.
+func (ws *WarehouseService) UpdateWarehouse(id uint, updates models.Warehouse) (*models.Warehouse, error) {
+	var warehouse models.Warehouse
+	if err := ws.DB.First(&warehouse, id).Error; err != nil {
+		return nil, fmt.Errorf("склад не найден: %w", err)
+	}
+
+	if !updates.IsActive && warehouse.IsActive {
+		var count int64
+		if err := ws.DB.Model(&models.Equipment{}).
+			Where("warehouse_id = ? AND status IN ?", id, []string{"in_stock", "reserved"}).
+			Count(&count).Error; err != nil {
+			return nil, fmt.Errorf("ошибка при проверке остатков: %w", err)
+		}
+		if count > 0 {
+			return nil, fmt.Errorf("на складе числится оборудование (%d шт.), перед отключением его необходимо переместить", count)
+		}
+	}
+
+	if err := ws.DB.Model(&warehouse).Updates(map[string]interface{}{
+		"name":                updates.Name,
+		"address":             updates.Address,
+		"location_id":         updates.LocationID,
+		"responsible_user_id": updates.ResponsibleUserID,
+		"is_active":           updates.IsActive,
+		"notes":               updates.Notes,
+	}).Error; err != nil {
+		return nil, fmt.Errorf("ошибка при обновлении склада: %w", err)
+	}
+	if err := ws.DB.First(&warehouse, id).Error; err != nil {
+		return nil, fmt.Errorf("ошибка при загрузке склада: %w", err)
+	}
+	return &warehouse, nil
+}
+
+// GetInstallerWarehouse возвращает мобильный склад монтажника, создавая его при первом обращении
+func (ws *WarehouseService) GetInstallerWarehouse(installerID uint) (*models.Warehouse, error) {
+	var warehouse models.Warehouse
+	err := ws.DB.Where("type = ? AND installer_id = ?", models.WarehouseTypeVan, installerID).First(&warehouse).Error
+	if err == nil {
+		return &warehouse, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("ошибка при поиске мобильного склада: %w", err)
+	}
+
+	var installer models.Installer
+	if err := ws.DB.First(&installer, installerID).Error; err != nil {
+		return nil, fmt.Errorf("монтажник не найден: %w", err)
+	}
+
+	warehouse = models.Warehouse{
+		Name:        fmt.Sprintf("Мобильный склад: %s", installer.GetFullName()),
+		Code:        fmt.Sprintf("VAN-%d", installer.ID),
+		Type:        models.WarehouseTypeVan,
+		InstallerID: &installer.ID,
+		IsActive:    true,
+	}
+	if err := ws.CreateWarehouse(&warehouse); err != nil {
+		return nil, err
+	}
+	return &warehouse, nil
+}
+
+// CreateStorageBin создает место хранения с проверкой иерархии склад → зона → стеллаж → ячейка
+func (ws *WarehouseService) CreateStorageBin(bin *models.StorageBin) error {
+	if !models.IsValidStorageBinType(bin.Type) {
+		return fmt.Errorf("неизвестный тип места хранения: %s", bin.Type)
+	}
+	if strings.TrimSpace(bin.Code) == "" {
+		return fmt.Errorf("код места хранения обязателен")
+	}
+
+	var warehouse models.Warehouse
+	if err := ws.DB.First(&warehouse, bin.WarehouseID).Error; err != nil {
+		return fmt.Errorf("склад не найден: %w", err)
+	}
+	if warehouse.IsMobile() {
+		return fmt.Errorf("мобильный склад не делится на места хранения")
+	}
+
+	var parent *models.StorageBin
+	if bin.ParentID != nil {
+		parent = &models.StorageBin{}
+		if err := ws.DB.First(parent, *bin.ParentID).Error; err != nil {
+			return fmt.Errorf("родительское место хранения не найдено: %w", err)
+		}
+	}
+	if !bin.CanBeChildOf(parent) {
+		return fmt.Errorf("место хранения типа %s не может располагаться здесь", bin.Type)
+	}
+
+	bin.Code = strings.ToUpper(strings.TrimSpace(bin.Code))
+	bin.Path = bin.BuildPath(parent)
+
+	var count int64
+	if err := ws.DB.Model(&models.StorageBin{}).
+		Where("warehouse_id = ? AND path = ?", bin.WarehouseID, bin.Path).
+		Count(&count).Error; err != nil {
+		return fmt.Errorf("ошибка при проверке адреса: %w", err)
+	}
+	if count > 0 {
+		return fmt.Errorf("место хранения %s уже существует", bin.Path)
+	}
+
+	if err := ws.DB.Create(bin).Error; err != nil {
+		return fmt.Errorf("ошибка при создании места хранения: %w", err)
+	}
+	return nil
+}
+
+// GetStorageBins возвращает дерево мест хранения склада
+func (ws *WarehouseService) GetStorageBins(warehouseID uint) ([]models.StorageBin, error) {
+	var bins []models.StorageBin
+	if err := ws.DB.Where("warehouse_id = ?", warehouseID).Order("path").Find(&bins).Error; err != nil {
+		return nil, fmt.Errorf("ошибка при получении мест хранения: %w", err)
+	}
+	return buildStorageBinTree(bins, nil), nil
+}
+
+// buildStorageBinTree собирает дерево мест хранения из плоского списка
+func buildStorageBinTree(bins []models.StorageBin, parentID *uint) []models.StorageBin {
+	var result []models.StorageBin
+	for _, bin := range bins {
+		if (parentID == nil && bin.ParentID == nil) || (parentID != nil && bin.ParentID != nil && *bin.ParentID == *parentID) {
+			bin.Children = buildStorageBinTree(bins, &bin.ID)
+			result = append(result, bin)
+		}
+	}
+	return result
+}
+
+// TransferEquipment перемещает оборудование между складами и местами хранения
+func (ws *WarehouseService) TransferEquipment(req StockTransferRequest) (*models.WarehouseOperation, error) {
+	var operation *models.WarehouseOperation
+
+	err := ws.DB.Transaction(func(tx *gorm.DB) error {
+		equipment, err := lockEquipment(tx, req.EquipmentID)
+		if err != nil {
+			return err
+		}
+		if equipment.Status != models.EquipmentStatusInStock && equipment.Status != models.EquipmentStatusReserved {
+			return fmt.Errorf("перемещать можно только оборудование на складе, текущий статус: %s", equipment.Status)
+		}
+		if req.FromWarehouseID != nil && (equipment.WarehouseID == nil || *equipment.WarehouseID != *req.FromWarehouseID) {
+			return fmt.Errorf("оборудование не числится на складе-отправителе")
+		}
+
+		var target models.Warehouse
+		if err := tx.First(&target, req.ToWarehouseID).Error; err != nil {
+			return fmt.Errorf("склад-получатель не найден: %w", err)
+		}
+		if !target.IsActive {
+			return fmt.Errorf("склад-получатель %s отключен", target.Code)
+		}
+
+		var targetBin *models.StorageBin
+		if req.ToBinID != nil {
+			targetBin = &models.StorageBin{}
+			if err := tx.First(targetBin, *req.ToBinID).Error; err != nil {
+				return fmt.Errorf("место хранения не найдено: %w", err)
+			}
+			if targetBin.WarehouseID != target.ID {
+				return fmt.Errorf("место хранения %s не относится к складу %s", targetBin.Path, target.Code)
+			}
+			if !targetBin.IsActive {
+				return fmt.Errorf("место хранения %s отключено", targetBin.Path)
+			}
+		}
+
+		sameWarehouse := equipment.WarehouseID != nil && *equipment.WarehouseID == target.ID
+		sameBin := (equipment.StorageBinID == nil && req.ToBinID == nil) ||
+			(equipment.StorageBinID != nil && req.ToBinID != nil && *equipment.StorageBinID == *req.ToBinID)
+		if sameWarehouse && sameBin {
+			return fmt.Errorf("оборудование уже находится в указанном месте")
+		}
+
+		fromLocation := equipment.WarehouseLocation
+		if equipment.WarehouseID != nil {
+			var source models.Warehouse
+			if err := tx.First(&source, *equipment.WarehouseID).Error; err == nil {
+				var sourceBin *models.StorageBin
+				if equipment.StorageBinID != nil {
+					sourceBin = &models.StorageBin{}
+					if err := tx.First(sourceBin, *equipment.StorageBinID).Error; err != nil {
+						sourceBin = nil
+					}
+				}
+				fromLocation = models.StockLocationLabel(&source, sourceBin)
+			}
+		}
+		toLocation := models.StockLocationLabel(&target, targetBin)
+
+		fromWarehouseID, fromBinID := equipment.WarehouseID, equipment.StorageBinID
+		if err := updateEquipmentState(tx, equipment, map[string]interface{}{
+			"warehouse_id":       target.ID,
+			"storage_bin_id":     req.ToBinID,
+			"warehouse_location": toLocation,
+		}); err != nil {
+			return err
+		}
+
+		operation = &models.WarehouseOperation{
+			Type:            "transfer",
+			Description:     fmt.Sprintf("Перемещение оборудования с %s на %s", fromLocation, toLocation),
+			EquipmentID:     equipment.ID,
+			Quantity:        1,
+			FromLocation:    fromLocation,
+			ToLocation:      toLocation,
+			FromWarehouseID: fromWarehouseID,
+			ToWarehouseID:   &target.ID,
+			FromBinID:       fromBinID,
+			ToBinID:         req.ToBinID,
+			UserID:          req.UserID,
+			Status:          "completed",
+			DocumentNumber:  req.DocumentNumber,
+			Notes:           req.Notes,
+		}
+		if err := tx.Create(operation).Error; err != nil {
+			return fmt.Errorf("ошибка при создании операции: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return operation, nil
+}
+
+// GetStockOnHand возвращает остатки оборудования по складам и категориям
+func (ws *WarehouseService) GetStockOnHand(filter StockOnHandFilter) ([]StockOnHandRow, error) {
+	query := ws.DB.Table("equipment").
+		Select(`equipment.warehouse_id,
+			warehouses.code AS warehouse_code,
+			warehouses.name AS warehouse_name,
+			warehouses.type AS warehouse_type,
+			equipment.category_id,
+			COALESCE(equipment_categories.name, 'Без категории') AS category_name,
+			SUM(CASE WHEN equipment.status = 'in_stock' THEN 1 ELSE 0 END) AS in_stock,
+			SUM(CASE WHEN equipment.status = 'reserved' THEN 1 ELSE 0 END) AS reserved,
+			COUNT(*) AS total`).
+		Joins("JOIN warehouses ON warehouses.id = equipment.warehouse_id AND warehouses.deleted_at IS NULL").
+		Joins("LEFT JOIN equipment_categories ON equipment_categories.id = equipment.category_id").
+		Where("equipment.deleted_at IS NULL AND equipment.status IN ?", []string{"in_stock", "reserved"})
+
+	if filter.WarehouseID != nil {
+		query = query.Where("equipment.warehouse_id = ?", *filter.WarehouseID)
+	}
+	if filter.CategoryID != nil {
+		query = query.Where("equipment.category_id = ?", *filter.CategoryID)
+	}
+	if filter.Type != "" {
+		query = query.Where("warehouses.type = ?", filter.Type)
+	}
+
+	var rows []StockOnHandRow
+	if err := query.
+		Group("equipment.warehouse_id, warehouses.code, warehouses.name, warehouses.type, equipment.category_id, equipment_categories.name").
+		Order("warehouses.code, category_name").
+		Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("ошибка при получении остатков: %w", err)
+	}
+	return rows, nil
+}
@@ -0,0 +1,153 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"backend_axenta/models"
+)
+
+func setupWarehouseLocationsTest(t *testing.T) (*gorm.DB, *WarehouseService) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, db.AutoMigrate(
+		&models.Warehouse{},
+		&models.StorageBin{},
+		&models.Equipment{},
+		&models.EquipmentCategory{},
+		&models.WarehouseOperation{},
+	))
+
+	return db, NewWarehouseService(db, nil)
+}
+
+func createTestWarehouse(t *testing.T, ws *WarehouseService, code string) *models.Warehouse {
+	warehouse := &models.Warehouse{Name: "Склад " + code, Code: code, IsActive: true}
+	require.NoError(t, ws.CreateWarehouse(warehouse))
+	return warehouse
+}
+
+func TestWarehouseService_CreateStorageBinHierarchy(t *testing.T) {
+	_, ws := setupWarehouseLocationsTest(t)
+	warehouse := createTestWarehouse(t, ws, "msk")
+	assert.Equal(t, "MSK", warehouse.Code)
+
+	zone := &models.StorageBin{WarehouseID: warehouse.ID, Type: models.StorageBinZone, Code: "a", IsActive: true}
+	require.NoError(t, ws.CreateStorageBin(zone))
+	shelf := &models.StorageBin{WarehouseID: warehouse.ID, ParentID: &zone.ID, Type: models.StorageBinShelf, Code: "03", IsActive: true}
+	require.NoError(t, ws.CreateStorageBin(shelf))
+	bin := &models.StorageBin{WarehouseID: warehouse.ID, ParentID: &shelf.ID, Type: models.StorageBinBin, Code: "12", IsActive: true}
+	require.NoError(t, ws.CreateStorageBin(bin))
+	assert.Equal(t, "A-03-12", bin.Path)
+
+	// Ячейка не может располагаться непосредственно в зоне
+	wrongLevel := &models.StorageBin{WarehouseID: warehouse.ID, ParentID: &zone.ID, Type: models.StorageBinBin, Code: "01"}
+	assert.Error(t, ws.CreateStorageBin(wrongLevel))
+
+	// Адрес внутри склада уникален
+	duplicate := &models.StorageBin{WarehouseID: warehouse.ID, ParentID: &shelf.ID, Type: models.StorageBinBin, Code: "12"}
+	assert.Error(t, ws.CreateStorageBin(duplicate))
+
+	tree, err := ws.GetStorageBins(warehouse.ID)
+	require.NoError(t, err)
+	require.Len(t, tree, 1)
+	require.Len(t, tree[0].Children, 1)
+	require.Len(t, tree[0].Children[0].Children, 1)
+	assert.Equal(t, "A-03-12", tree[0].Children[0].Children[0].Path)
+}
+
+func TestWarehouseService_TransferEquipment(t *testing.T) {
+	db, ws := setupWarehouseLocationsTest(t)
+	main := createTestWarehouse(t, ws, "MAIN")
+	branch := createTestWarehouse(t, ws, "SPB")
+
+	zone := &models.StorageBin{WarehouseID: branch.ID, Type: models.StorageBinZone, Code: "B", IsActive: true}
+	require.NoError(t, ws.CreateStorageBin(zone))
+	foreignZone := &models.StorageBin{WarehouseID: main.ID, Type: models.StorageBinZone, Code: "C", IsActive: true}
+	require.NoError(t, ws.CreateStorageBin(foreignZone))
+
+	equipment := models.Equipment{
+		Type: "GPS-tracker", Model: "GT06N", SerialNumber: "TR-001", IMEI: "350000000000001", QRCode: "QR-TR-001",
+		Status: "in_stock", WarehouseID: &main.ID, WarehouseLocation: "MAIN",
+	}
+	require.NoError(t, db.Create(&equipment).Error)
+
+	// Место хранения должно относиться к складу-получателю
+	_, err := ws.TransferEquipment(StockTransferRequest{EquipmentID: equipment.ID, ToWarehouseID: branch.ID, ToBinID: &foreignZone.ID})
+	assert.Error(t, err)
+
+	// Склад-отправитель должен совпадать с текущим
+	_, err = ws.TransferEquipment(StockTransferRequest{EquipmentID: equipment.ID, FromWarehouseID: &branch.ID, ToWarehouseID: branch.ID})
+	assert.Error(t, err)
+
+	operation, err := ws.TransferEquipment(StockTransferRequest{
+		EquipmentID: equipment.ID, FromWarehouseID: &main.ID, ToWarehouseID: branch.ID, ToBinID: &zone.ID, UserID: 7,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "transfer", operation.Type)
+	assert.Equal(t, "MAIN", operation.FromLocation)
+	assert.Equal(t, "SPB/B", operation.ToLocation)
+	require.NotNil(t, operation.FromWarehouseID)
+	assert.Equal(t, main.ID, *operation.FromWarehouseID)
+	require.NotNil(t, operation.ToBinID)
+	assert.Equal(t, zone.ID, *operation.ToBinID)
+
+	var moved models.Equipment
+	require.NoError(t, db.First(&moved, equipment.ID).Error)
+	require.NotNil(t, moved.WarehouseID)
+	assert.Equal(t, branch.ID, *moved.WarehouseID)
+	assert.Equal(t, "SPB/B", moved.WarehouseLocation)
+
+	// Повторное перемещение в то же место отклоняется
+	_, err = ws.TransferEquipment(StockTransferRequest{EquipmentID: equipment.ID, ToWarehouseID: branch.ID, ToBinID: &zone.ID})
+	assert.Error(t, err)
+
+	// Установленное оборудование не перемещается между складами
+	require.NoError(t, db.Model(&moved).Update("status", "installed").Error)
+	_, err = ws.TransferEquipment(StockTransferRequest{EquipmentID: equipment.ID, ToWarehouseID: main.ID})
+	assert.Error(t, err)
+}
+
+func TestWarehouseService_GetStockOnHand(t *testing.T) {
+	db, ws := setupWarehouseLocationsTest(t)
+	main := createTestWarehouse(t, ws, "MAIN")
+	installerID := uint(5)
+	van := &models.Warehouse{Name: "Мобильный склад", Code: "VAN-5", Type: models.WarehouseTypeVan, InstallerID: &installerID, IsActive: true}
+	require.NoError(t, ws.CreateWarehouse(van))
+
+	// У монтажника может быть только один мобильный склад
+	assert.Error(t, ws.CreateWarehouse(&models.Warehouse{Name: "Второй", Code: "VAN-5B", Type: models.WarehouseTypeVan, InstallerID: &installerID}))
+
+	trackers := models.EquipmentCategory{Name: "Трекеры", Code: "TRK", IsActive: true}
+	require.NoError(t, db.Create(&trackers).Error)
+
+	items := []models.Equipment{
+		{Type: "tracker", Model: "A", SerialNumber: "S1", IMEI: "I1", QRCode: "Q1", Status: "in_stock", CategoryID: &trackers.ID, WarehouseID: &main.ID},
+		{Type: "tracker", Model: "A", SerialNumber: "S2", IMEI: "I2", QRCode: "Q2", Status: "reserved", CategoryID: &trackers.ID, WarehouseID: &main.ID},
+		{Type: "tracker", Model: "A", SerialNumber: "S3", IMEI: "I3", QRCode: "Q3", Status: "in_stock", CategoryID: &trackers.ID, WarehouseID: &van.ID},
+		{Type: "tracker", Model: "A", SerialNumber: "S4", IMEI: "I4", QRCode: "Q4", Status: "installed", CategoryID: &trackers.ID},
+	}
+	require.NoError(t, db.Create(&items).Error)
+
+	rows, err := ws.GetStockOnHand(StockOnHandFilter{})
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+	assert.Equal(t, "MAIN", rows[0].WarehouseCode)
+	assert.Equal(t, int64(1), rows[0].InStock)
+	assert.Equal(t, int64(1), rows[0].Reserved)
+	assert.Equal(t, "Трекеры", rows[0].CategoryName)
+
+	rows, err = ws.GetStockOnHand(StockOnHandFilter{Type: models.WarehouseTypeVan})
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	assert.Equal(t, "VAN-5", rows[0].WarehouseCode)
+	assert.Equal(t, int64(1), rows[0].Total)
+}
@@ -0,0 +1,131 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"backend_axenta/models"
+)
+
+// WarehouseScheduler периодически запускает проверки склада по всем активным компаниям:
+// низкие остатки, неиспользуемые SIM-карты, плановое обслуживание и эскалации SLA монтажей
+type WarehouseScheduler struct {
+	DB                  *gorm.DB
+	NotificationService *NotificationService
+
+	// MultiTenant проверки выполняются в схеме каждой компании; иначе в текущей схеме подключения
+	MultiTenant bool
+
+	logger  *log.Logger
+	stop    chan struct{}
+	running bool
+	mu      sync.Mutex
+}
+
+// NewWarehouseScheduler создает планировщик проверок склада
+func NewWarehouseScheduler(db *gorm.DB, notificationService *NotificationService, multiTenant bool) *WarehouseScheduler {
+	return &WarehouseScheduler{
+		DB:                  db,
+		NotificationService: notificationService,
+		MultiTenant:         multiTenant,
+		logger:              log.New(os.Stdout, "[WAREHOUSE] ", log.LstdFlags),
+	}
+}
+
+// Start запускает периодические проверки склада
+func (s *WarehouseScheduler) Start(interval time.Duration) {
+	s.mu.Lock()
+	if s.running {
+		s.mu.Unlock()
+		return
+	}
+	s.running = true
+	s.stop = make(chan struct{})
+	stop := s.stop
+	s.mu.Unlock()
+
+	s.logger.Printf("Проверки склада запущены, интервал %s", interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		s.RunChecks()
+
+		select {
+		case <-ticker.C:
+		case <-stop:
+			return
+		}
+	}
+}
+
+// Stop останавливает периодические проверки склада
+func (s *WarehouseScheduler) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.running {
+		close(s.stop)
+		s.running = false
+	}
+}
+
+// RunChecks выполняет проверки склада для каждой активной компании
+func (s *WarehouseScheduler) RunChecks() {
+	var companies []models.Company
+	if err := s.DB.Where("is_active = ? AND database_schema <> ''", true).Order("id").Find(&companies).Error; err != nil {
+		s.logger.Printf("Ошибка при получении списка компаний: %v", err)
+		return
+	}
+
+	for _, company := range companies {
+		if err := s.checkCompany(company.ID, company.DatabaseSchema); err != nil {
+			s.logger.Printf("Компания %s: %v", company.ID, err)
+		}
+	}
+}
+
+// checkCompany выполняет проверки компании; события вебхуков публикуются от ее имени
+func (s *WarehouseScheduler) checkCompany(companyID uuid.UUID, schema string) error {
+	if s.MultiTenant && !schemaNamePattern.MatchString(schema) {
+		return fmt.Errorf("недопустимое имя схемы %q", schema)
+	}
+
+	// Проверка выполняется на одном соединении, чтобы search_path компании действовал на все ее запросы
+	return s.DB.Connection(func(tx *gorm.DB) error {
+		conn := tx.Session(&gorm.Session{})
+		if s.MultiTenant {
+			if err := conn.Exec(fmt.Sprintf("SET search_path TO %s", schema)).Error; err != nil {
+				return fmt.Errorf("ошибка переключения на схему %s: %w", schema, err)
+			}
+			// Соединение возвращается в пул со схемой по умолчанию
+			defer conn.Exec("SET search_path TO public")
+		}
+
+		warehouseService := NewWarehouseService(conn, s.NotificationService)
+		warehouseService.CompanyID = companyID
+		if err := warehouseService.CheckLowStockLevels(); err != nil {
+			return fmt.Errorf("ошибка при проверке низких остатков: %w", err)
+		}
+
+		// Остальные проверки независимы: ошибка одной не отменяет следующие
+		now := time.Now()
+		if _, err := NewSimCardService(conn).CheckIdleSimCards(); err != nil {
+			s.logger.Printf("Компания %s: ошибка при проверке неиспользуемых SIM-карт: %v", companyID, err)
+		}
+		if _, err := NewMaintenanceService(conn).GenerateDueMaintenance(now); err != nil {
+			s.logger.Printf("Компания %s: ошибка при планировании обслуживания: %v", companyID, err)
+		}
+		if _, err := NewSLAService(conn, s.NotificationService).CheckEscalations(now); err != nil {
+			s.logger.Printf("Компания %s: ошибка при проверке сроков SLA монтажей: %v", companyID, err)
+		}
+		return nil
+	})
+}
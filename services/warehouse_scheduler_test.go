@@ -0,0 +1,76 @@
+package services
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"backend_axenta/models"
+)
+
+func TestWarehouseScheduler_RunChecks(t *testing.T) {
+	// Файловая база: проверка компании и публикация события используют разные соединения
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "warehouse.db")+"?_busy_timeout=5000"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+
+	// Таблица компаний создается вручную: значение по умолчанию для UUID не поддерживается SQLite
+	require.NoError(t, db.Exec(`CREATE TABLE companies (id TEXT PRIMARY KEY, name TEXT, database_schema TEXT, is_active BOOLEAN, deleted_at DATETIME)`).Error)
+	require.NoError(t, db.AutoMigrate(&models.Equipment{}, &models.EquipmentCategory{}, &models.StockAlert{},
+		&models.Object{}, &models.User{}, &models.Role{}, &models.WebhookSubscription{}, &models.WebhookDelivery{},
+		&models.Installation{}, &models.Installer{}, &models.MaintenancePlan{}, &models.MaintenanceVisit{}))
+
+	companyID := uuid.New()
+	require.NoError(t, db.Exec(`INSERT INTO companies (id, name, database_schema, is_active) VALUES (?, ?, ?, ?), (?, ?, ?, ?)`,
+		companyID, "Активная", "tenant_active", true,
+		uuid.New(), "Отключенная", "tenant_inactive", false).Error)
+
+	category := models.EquipmentCategory{Name: "GPS Trackers", Code: "GPS", MinStockLevel: 5, IsActive: true}
+	require.NoError(t, db.Create(&category).Error)
+
+	// План обслуживания объекта, срок которого уже наступил
+	truck := models.Object{Name: "КамАЗ А001АА", IMEI: "356938035643801"}
+	require.NoError(t, db.Create(&truck).Error)
+	plan, err := NewMaintenanceService(db).CreatePlan(MaintenancePlanRequest{Name: "ТО трекера", ObjectID: &truck.ID, IntervalMonths: 6})
+	require.NoError(t, err)
+	require.NoError(t, db.Model(plan).UpdateColumn("created_at", time.Now().AddDate(0, -7, 0)).Error)
+
+	webhooks := NewWebhookService(db)
+	webhooks.allowInternalHosts = true
+	SetWebhookService(webhooks)
+	t.Cleanup(func() { SetWebhookService(nil) })
+	subscription := &models.WebhookSubscription{CompanyID: companyID, Name: "ERP", URL: "http://127.0.0.1/hook", IsActive: true}
+	subscription.SetEvents([]string{models.WebhookEventStockLow})
+	require.NoError(t, webhooks.CreateSubscription(subscription))
+
+	scheduler := NewWarehouseScheduler(db, nil, false)
+	scheduler.RunChecks()
+
+	var alerts int64
+	require.NoError(t, db.Model(&models.StockAlert{}).Where("equipment_category_id = ?", category.ID).Count(&alerts).Error)
+	assert.Equal(t, int64(1), alerts)
+
+	// Событие stock.low публикуется от имени активной компании
+	var deliveries []models.WebhookDelivery
+	require.NoError(t, db.Find(&deliveries).Error)
+	if assert.Len(t, deliveries, 1) {
+		assert.Equal(t, models.WebhookEventStockLow, deliveries[0].EventType)
+		assert.Equal(t, companyID, deliveries[0].CompanyID)
+	}
+
+	// Визит обслуживания запланирован той же проверкой
+	var visits int64
+	require.NoError(t, db.Model(&models.MaintenanceVisit{}).Where("plan_id = ?", plan.ID).Count(&visits).Error)
+	assert.Equal(t, int64(1), visits)
+
+	// Остановка без запуска не блокируется
+	scheduler.Stop()
+}
@@ -1,11 +1,16 @@
 package services
 
 import (
+	"errors"
 	"fmt"
 	"log"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	"backend_axenta/models"
 )
@@ -14,6 +19,9 @@ import (
 type WarehouseService struct {
 	DB                  *gorm.DB
 	NotificationService *NotificationService
+
+	// CompanyID компания склада для событий вебхуков; если не задана, события не публикуются
+	CompanyID uuid.UUID
 }
 
 // NewWarehouseService создает новый экземпляр WarehouseService
@@ -36,7 +44,7 @@ func (ws *WarehouseService) CheckLowStockLevels() error {
 		var inStockCount int64
 		if err := ws.DB.Model(&models.Equipment{}).
 			Where("category_id = ? AND status = 'in_stock'", category.ID).
-			Count(&inStockCount); err != nil {
+			Count(&inStockCount).Error; err != nil {
 			log.Printf("Ошибка при подсчете оборудования для категории %s: %v", category.Name, err)
 			continue
 		}
@@ -90,6 +98,9 @@ func (ws *WarehouseService) createLowStockAlert(category models.EquipmentCategor
 	if ws.NotificationService != nil {
 		go ws.NotificationService.SendStockAlert(alert)
 	}
+	if ws.CompanyID != uuid.Nil {
+		PublishWebhookEvent(ws.CompanyID, models.WebhookEventStockLow, alert)
+	}
 
 	return nil
 }
@@ -223,115 +234,178 @@ func (ws *WarehouseService) createMaintenanceDueAlert(equipment models.Equipment
 	return nil
 }
 
-// ProcessEquipmentInstallation обрабатывает установку оборудования на объект
-func (ws *WarehouseService) ProcessEquipmentInstallation(equipmentID uint, objectID uint, installerID uint) error {
-	// Проверяем доступность оборудования
+// ErrEquipmentConflict возвращается, если оборудование изменено параллельной операцией
+var ErrEquipmentConflict = errors.New("оборудование было изменено другой операцией, повторите попытку")
+
+// lockingForUpdate блокировка строки до конца транзакции (SELECT ... FOR UPDATE)
+var lockingForUpdate = clause.Locking{Strength: "UPDATE"}
+
+// lockEquipment загружает оборудование с блокировкой строки до конца транзакции
+func lockEquipment(tx *gorm.DB, equipmentID uint) (*models.Equipment, error) {
 	var equipment models.Equipment
-	if err := ws.DB.First(&equipment, equipmentID).Error; err != nil {
-		return fmt.Errorf("оборудование не найдено: %w", err)
+	if err := tx.Clauses(lockingForUpdate).First(&equipment, equipmentID).Error; err != nil {
+		return nil, fmt.Errorf("оборудование не найдено: %w", err)
 	}
+	return &equipment, nil
+}
 
-	if !equipment.IsAvailable() {
-		return fmt.Errorf("оборудование недоступно для установки")
+// updateEquipmentState изменяет оборудование с проверкой перехода статуса и версии записи.
+// Если запись изменена после чтения, возвращается ErrEquipmentConflict.
+func updateEquipmentState(tx *gorm.DB, equipment *models.Equipment, updates map[string]interface{}) error {
+	if status, ok := updates["status"].(string); ok && status != equipment.Status && !equipment.CanTransitionTo(status) {
+		return fmt.Errorf("недопустимый переход статуса оборудования: %s → %s", equipment.Status, status)
 	}
 
-	// Проверяем существование объекта
-	var object models.Object
-	if err := ws.DB.First(&object, objectID).Error; err != nil {
-		return fmt.Errorf("объект не найден: %w", err)
+	updates["version"] = equipment.Version + 1
+	result := tx.Model(&models.Equipment{}).
+		Where("id = ? AND version = ?", equipment.ID, equipment.Version).
+		Updates(updates)
+	if result.Error != nil {
+		return fmt.Errorf("ошибка при обновлении оборудования: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrEquipmentConflict
 	}
 
-	// Начинаем транзакцию
-	tx := ws.DB.Begin()
-	defer func() {
-		if r := recover(); r != nil {
-			tx.Rollback()
-		}
-	}()
+	equipment.Version++
+	return nil
+}
 
-	// Обновляем статус оборудования
-	equipment.Status = "installed"
-	equipment.ObjectID = &objectID
-	if err := tx.Save(&equipment).Error; err != nil {
-		tx.Rollback()
-		return fmt.Errorf("ошибка при обновлении оборудования: %w", err)
+// nextDocumentNumber формирует номер складского документа вида GR-20260115-003,
+// следующий за наибольшим номером за день
+func nextDocumentNumber(tx *gorm.DB, model interface{}, prefix string, date time.Time) (string, error) {
+	prefix = prefix + "-" + date.Format("20060102") + "-"
+	var numbers []string
+	if err := tx.Unscoped().Model(model).Where("number LIKE ?", prefix+"%").
+		Order("LENGTH(number) DESC, number DESC").Limit(1).Pluck("number", &numbers).Error; err != nil {
+		return "", fmt.Errorf("ошибка при формировании номера документа: %w", err)
 	}
-
-	// Создаем операцию выдачи
-	operation := models.WarehouseOperation{
-		Type:         "issue",
-		Description:  fmt.Sprintf("Выдача оборудования для установки на объект %s", object.Name),
-		EquipmentID:  equipmentID,
-		UserID:       installerID,
-		Status:       "completed",
-		FromLocation: equipment.WarehouseLocation,
-		ToLocation:   "Установлено на объекте",
+	last := 0
+	if len(numbers) > 0 {
+		last, _ = strconv.Atoi(strings.TrimPrefix(numbers[0], prefix))
 	}
+	return fmt.Sprintf("%s%03d", prefix, last+1), nil
+}
 
-	if err := tx.Create(&operation).Error; err != nil {
-		tx.Rollback()
-		return fmt.Errorf("ошибка при создании операции: %w", err)
-	}
+// documentNumberAttempts число попыток присвоить номер, занятый параллельно созданным документом
+const documentNumberAttempts = 5
+
+// createNumberedDocument присваивает складскому документу следующий номер и сохраняет его.
+// Если номер одновременно занял другой документ, вставка откатывается до точки сохранения
+// и номер формируется заново.
+func createNumberedDocument(tx *gorm.DB, document interface{}, prefix string, date time.Time, setNumber func(string)) error {
+	for attempt := 1; ; attempt++ {
+		number, err := nextDocumentNumber(tx, document, prefix, date)
+		if err != nil {
+			return err
+		}
+		setNumber(number)
 
-	// Фиксируем транзакцию
-	if err := tx.Commit().Error; err != nil {
-		return fmt.Errorf("ошибка при фиксации транзакции: %w", err)
+		err = tx.Transaction(func(savepoint *gorm.DB) error {
+			return savepoint.Create(document).Error
+		})
+		if err == nil || !isUniqueViolation(err) || attempt == documentNumberAttempts {
+			return err
+		}
 	}
-
-	return nil
 }
 
-// ProcessEquipmentReturn обрабатывает возврат оборудования на склад
-func (ws *WarehouseService) ProcessEquipmentReturn(equipmentID uint, userID uint, warehouseLocation string) error {
-	// Проверяем оборудование
-	var equipment models.Equipment
-	if err := ws.DB.First(&equipment, equipmentID).Error; err != nil {
-		return fmt.Errorf("оборудование не найдено: %w", err)
+// isUniqueViolation проверяет, что ошибка вызвана нарушением уникального индекса
+// (SQLSTATE 23505 в PostgreSQL, UNIQUE constraint в SQLite)
+func isUniqueViolation(err error) bool {
+	if err == nil {
+		return false
 	}
+	message := err.Error()
+	return strings.Contains(message, "SQLSTATE 23505") || strings.Contains(message, "UNIQUE constraint failed")
+}
 
-	if equipment.Status != "installed" {
-		return fmt.Errorf("оборудование не установлено")
-	}
+// ProcessEquipmentInstallation обрабатывает установку оборудования на объект.
+// Устанавливать можно оборудование на складе или зарезервированное под монтаж.
+func (ws *WarehouseService) ProcessEquipmentInstallation(equipmentID uint, objectID uint, installerID uint) error {
+	return ws.DB.Transaction(func(tx *gorm.DB) error {
+		equipment, err := lockEquipment(tx, equipmentID)
+		if err != nil {
+			return err
+		}
 
-	// Начинаем транзакцию
-	tx := ws.DB.Begin()
-	defer func() {
-		if r := recover(); r != nil {
-			tx.Rollback()
+		if !equipment.CanTransitionTo(models.EquipmentStatusInstalled) ||
+			equipment.Condition == "damaged" || equipment.Condition == "broken" {
+			return fmt.Errorf("оборудование недоступно для установки")
 		}
-	}()
 
-	// Обновляем статус оборудования
-	equipment.Status = "in_stock"
-	equipment.ObjectID = nil
-	equipment.WarehouseLocation = warehouseLocation
-	if err := tx.Save(&equipment).Error; err != nil {
-		tx.Rollback()
-		return fmt.Errorf("ошибка при обновлении оборудования: %w", err)
-	}
+		// Проверяем существование объекта
+		var object models.Object
+		if err := tx.First(&object, objectID).Error; err != nil {
+			return fmt.Errorf("объект не найден: %w", err)
+		}
 
-	// Создаем операцию поступления
-	operation := models.WarehouseOperation{
-		Type:         "receive",
-		Description:  "Возврат оборудования с объекта на склад",
-		EquipmentID:  equipmentID,
-		UserID:       userID,
-		Status:       "completed",
-		FromLocation: "Объект",
-		ToLocation:   warehouseLocation,
-	}
+		// Оборудование списывается со склада, на котором числилось
+		operation := models.WarehouseOperation{
+			Type:            "issue",
+			Description:     fmt.Sprintf("Выдача оборудования для установки на объект %s", object.Name),
+			EquipmentID:     equipmentID,
+			UserID:          installerID,
+			Status:          "completed",
+			FromLocation:    equipment.WarehouseLocation,
+			ToLocation:      "Установлено на объекте",
+			FromWarehouseID: equipment.WarehouseID,
+			FromBinID:       equipment.StorageBinID,
+		}
 
-	if err := tx.Create(&operation).Error; err != nil {
-		tx.Rollback()
-		return fmt.Errorf("ошибка при создании операции: %w", err)
-	}
+		if err := updateEquipmentState(tx, equipment, map[string]interface{}{
+			"status":             models.EquipmentStatusInstalled,
+			"object_id":          objectID,
+			"warehouse_id":       nil,
+			"storage_bin_id":     nil,
+			"warehouse_location": "",
+		}); err != nil {
+			return err
+		}
 
-	// Фиксируем транзакцию
-	if err := tx.Commit().Error; err != nil {
-		return fmt.Errorf("ошибка при фиксации транзакции: %w", err)
-	}
+		if err := tx.Create(&operation).Error; err != nil {
+			return fmt.Errorf("ошибка при создании операции: %w", err)
+		}
+		return nil
+	})
+}
 
-	return nil
+// ProcessEquipmentReturn обрабатывает возврат оборудования на склад
+func (ws *WarehouseService) ProcessEquipmentReturn(equipmentID uint, userID uint, warehouseLocation string) error {
+	return ws.DB.Transaction(func(tx *gorm.DB) error {
+		equipment, err := lockEquipment(tx, equipmentID)
+		if err != nil {
+			return err
+		}
+
+		if equipment.Status != models.EquipmentStatusInstalled {
+			return fmt.Errorf("оборудование не установлено")
+		}
+
+		if err := updateEquipmentState(tx, equipment, map[string]interface{}{
+			"status":             models.EquipmentStatusInStock,
+			"object_id":          nil,
+			"warehouse_location": warehouseLocation,
+		}); err != nil {
+			return err
+		}
+
+		// Создаем операцию поступления
+		operation := models.WarehouseOperation{
+			Type:         "receive",
+			Description:  "Возврат оборудования с объекта на склад",
+			EquipmentID:  equipmentID,
+			UserID:       userID,
+			Status:       "completed",
+			FromLocation: "Объект",
+			ToLocation:   warehouseLocation,
+		}
+
+		if err := tx.Create(&operation).Error; err != nil {
+			return fmt.Errorf("ошибка при создании операции: %w", err)
+		}
+		return nil
+	})
 }
 
 // RunPeriodicChecks запускает периодические проверки склада
@@ -350,6 +424,18 @@ func (ws *WarehouseService) RunPeriodicChecks() {
 		log.Printf("Ошибка при проверке необходимости обслуживания: %v", err)
 	}
 
+	if _, err := NewSimCardService(ws.DB).CheckIdleSimCards(); err != nil {
+		log.Printf("Ошибка при проверке неиспользуемых SIM-карт: %v", err)
+	}
+
+	if _, err := NewMaintenanceService(ws.DB).GenerateDueMaintenance(time.Now()); err != nil {
+		log.Printf("Ошибка при планировании обслуживания: %v", err)
+	}
+
+	if _, err := NewSLAService(ws.DB, ws.NotificationService).CheckEscalations(time.Now()); err != nil {
+		log.Printf("Ошибка при проверке сроков SLA монтажей: %v", err)
+	}
+
 	log.Println("Периодические проверки склада завершены")
 }
 
@@ -399,97 +485,69 @@ func (ws *WarehouseService) GetEquipmentHistory(equipmentID uint) ([]models.Ware
 
 // ReserveEquipment резервирует оборудование для установки
 func (ws *WarehouseService) ReserveEquipment(equipmentID uint, userID uint, notes string) error {
-	var equipment models.Equipment
-	if err := ws.DB.First(&equipment, equipmentID).Error; err != nil {
-		return fmt.Errorf("оборудование не найдено: %w", err)
-	}
-
-	if !equipment.IsAvailable() {
-		return fmt.Errorf("оборудование недоступно для резервирования")
-	}
-
-	// Начинаем транзакцию
-	tx := ws.DB.Begin()
-	defer func() {
-		if r := recover(); r != nil {
-			tx.Rollback()
+	return ws.DB.Transaction(func(tx *gorm.DB) error {
+		equipment, err := lockEquipment(tx, equipmentID)
+		if err != nil {
+			return err
 		}
-	}()
 
-	// Обновляем статус оборудования
-	equipment.Status = "reserved"
-	if err := tx.Save(&equipment).Error; err != nil {
-		tx.Rollback()
-		return fmt.Errorf("ошибка при резервировании оборудования: %w", err)
-	}
-
-	// Создаем операцию резервирования
-	operation := models.WarehouseOperation{
-		Type:        "reserve",
-		Description: "Резервирование оборудования",
-		EquipmentID: equipmentID,
-		UserID:      userID,
-		Status:      "completed",
-		Notes:       notes,
-	}
+		if !equipment.IsAvailable() {
+			return fmt.Errorf("оборудование недоступно для резервирования")
+		}
 
-	if err := tx.Create(&operation).Error; err != nil {
-		tx.Rollback()
-		return fmt.Errorf("ошибка при создании операции: %w", err)
-	}
+		if err := updateEquipmentState(tx, equipment, map[string]interface{}{
+			"status": models.EquipmentStatusReserved,
+		}); err != nil {
+			return err
+		}
 
-	// Фиксируем транзакцию
-	if err := tx.Commit().Error; err != nil {
-		return fmt.Errorf("ошибка при фиксации транзакции: %w", err)
-	}
+		// Создаем операцию резервирования
+		operation := models.WarehouseOperation{
+			Type:        "reserve",
+			Description: "Резервирование оборудования",
+			EquipmentID: equipmentID,
+			UserID:      userID,
+			Status:      "completed",
+			Notes:       notes,
+		}
 
-	return nil
+		if err := tx.Create(&operation).Error; err != nil {
+			return fmt.Errorf("ошибка при создании операции: %w", err)
+		}
+		return nil
+	})
 }
 
 // UnreserveEquipment снимает резервирование с оборудования
 func (ws *WarehouseService) UnreserveEquipment(equipmentID uint, userID uint) error {
-	var equipment models.Equipment
-	if err := ws.DB.First(&equipment, equipmentID).Error; err != nil {
-		return fmt.Errorf("оборудование не найдено: %w", err)
-	}
-
-	if equipment.Status != "reserved" {
-		return fmt.Errorf("оборудование не зарезервировано")
-	}
-
-	// Начинаем транзакцию
-	tx := ws.DB.Begin()
-	defer func() {
-		if r := recover(); r != nil {
-			tx.Rollback()
+	return ws.DB.Transaction(func(tx *gorm.DB) error {
+		equipment, err := lockEquipment(tx, equipmentID)
+		if err != nil {
+			return err
 		}
-	}()
 
-	// Обновляем статус оборудования
-	equipment.Status = "in_stock"
-	if err := tx.Save(&equipment).Error; err != nil {
-		tx.Rollback()
-		return fmt.Errorf("ошибка при снятии резервирования: %w", err)
-	}
-
-	// Создаем операцию снятия резервирования
-	operation := models.WarehouseOperation{
-		Type:        "unreserve",
-		Description: "Снятие резервирования с оборудования",
-		EquipmentID: equipmentID,
-		UserID:      userID,
-		Status:      "completed",
-	}
+		if equipment.Status != models.EquipmentStatusReserved {
+			return fmt.Errorf("оборудование не зарезервировано")
+		}
 
-	if err := tx.Create(&operation).Error; err != nil {
-		tx.Rollback()
-		return fmt.Errorf("ошибка при создании операции: %w", err)
-	}
+		if err := updateEquipmentState(tx, equipment, map[string]interface{}{
+			"status": models.EquipmentStatusInStock,
+		}); err != nil {
+			return err
+		}
 
-	// Фиксируем транзакцию
-	if err := tx.Commit().Error; err != nil {
-		return fmt.Errorf("ошибка при фиксации транзакции: %w", err)
-	}
+		// Создаем операцию снятия резервирования
+		operation := models.WarehouseOperation{
+			Type:        "unreserve",
+			Description: "Снятие резервирования с оборудования",
+			EquipmentID: equipmentID,
+			UserID:      userID,
+			Status:      "completed",
+		}
 
-	return nil
+		if err := tx.Create(&operation).Error; err != nil {
+			return fmt.Errorf("ошибка при создании операции: %w", err)
+		}
+		return nil
+	})
 }
@@ -4,6 +4,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
@@ -59,6 +60,17 @@ func TestWarehouseService_CheckLowStockLevels(t *testing.T) {
 		db.Create(&equipment)
 	}
 
+	// Подписчик на событие низкого остатка
+	assert.NoError(t, db.AutoMigrate(&models.WebhookSubscription{}, &models.WebhookDelivery{}))
+	ws.CompanyID = uuid.New()
+	webhooks := NewWebhookService(db)
+	webhooks.allowInternalHosts = true
+	subscription := &models.WebhookSubscription{CompanyID: ws.CompanyID, Name: "ERP", URL: "http://127.0.0.1/hook", IsActive: true}
+	subscription.SetEvents([]string{models.WebhookEventStockLow})
+	assert.NoError(t, webhooks.CreateSubscription(subscription))
+	SetWebhookService(webhooks)
+	t.Cleanup(func() { SetWebhookService(nil) })
+
 	// Запускаем проверку
 	err := ws.CheckLowStockLevels()
 	assert.NoError(t, err)
@@ -69,6 +81,14 @@ func TestWarehouseService_CheckLowStockLevels(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, "active", alert.Status)
 	assert.Contains(t, alert.Title, "GPS Trackers")
+
+	// Событие публикуется только при создании уведомления, но не при повторной проверке
+	assert.NoError(t, ws.CheckLowStockLevels())
+	var deliveries []models.WebhookDelivery
+	assert.NoError(t, db.Find(&deliveries).Error)
+	if assert.Len(t, deliveries, 1) {
+		assert.Equal(t, models.WebhookEventStockLow, deliveries[0].EventType)
+	}
 }
 
 func TestWarehouseService_CheckExpiredWarranties(t *testing.T) {
@@ -0,0 +1,623 @@
+package services
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"backend_axenta/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Заголовки исходящих вебхуков
+const (
+	WebhookHeaderEvent     = "X-Axenta-Event"
+	WebhookHeaderDelivery  = "X-Axenta-Delivery"
+	WebhookHeaderTimestamp = "X-Axenta-Timestamp"
+	WebhookHeaderSignature = "X-Axenta-Signature"
+)
+
+// webhookRetryDelays интервалы между повторными попытками доставки
+var webhookRetryDelays = []time.Duration{
+	time.Minute,
+	5 * time.Minute,
+	30 * time.Minute,
+	2 * time.Hour,
+	6 * time.Hour,
+}
+
+// webhookResponseLimit максимальный размер сохраняемого фрагмента ответа получателя.
+// Фрагмент доступен в журнале доставок, поэтому ответ целиком не сохраняется.
+const webhookResponseLimit = 256
+
+// errWebhookAddressForbidden адрес получателя находится во внутренней сети
+var errWebhookAddressForbidden = errors.New("адрес получателя вебхука находится во внутренней сети")
+
+// webhookForbiddenNetworks внутренние сети, недоступные получателям вебхуков, помимо
+// loopback, частных и link-local адресов
+var webhookForbiddenNetworks = []*net.IPNet{
+	mustParseCIDR("100.64.0.0/10"), // Carrier-grade NAT
+	mustParseCIDR("0.0.0.0/8"),
+}
+
+func mustParseCIDR(cidr string) *net.IPNet {
+	_, network, err := net.ParseCIDR(cidr)
+	if err != nil {
+		panic(err)
+	}
+	return network
+}
+
+// isForbiddenWebhookIP проверяет, относится ли адрес к внутренней сети
+func isForbiddenWebhookIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsMulticast() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() {
+		return true
+	}
+	for _, network := range webhookForbiddenNetworks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// webhookDialControl проверяет адрес непосредственно перед подключением, уже после
+// разрешения имени, поэтому смена DNS записи после проверки подписки не помогает
+// обойти запрет внутренних адресов
+func webhookDialControl(network, address string, conn syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || isForbiddenWebhookIP(ip) {
+		return errWebhookAddressForbidden
+	}
+	return nil
+}
+
+// newWebhookHTTPClient создает клиент, который не подключается к внутренним адресам.
+// Прокси не используется: иначе проверялся бы адрес прокси, а не получателя.
+func newWebhookHTTPClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout:   10 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control:   webhookDialControl,
+	}
+	return &http.Client{
+		Transport: &http.Transport{
+			Proxy:                 nil,
+			DialContext:           dialer.DialContext,
+			MaxIdleConns:          100,
+			IdleConnTimeout:       90 * time.Second,
+			TLSHandshakeTimeout:   10 * time.Second,
+			ExpectContinueTimeout: time.Second,
+		},
+		// Перенаправления не выполняются: ответ 3xx считается ошибкой доставки
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// WebhookEvent событие, отправляемое подписчикам
+type WebhookEvent struct {
+	ID        string      `json:"id"`
+	Type      string      `json:"type"`
+	CompanyID uuid.UUID   `json:"company_id"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
+
+// WebhookService управляет подписками на события и доставкой вебхуков
+type WebhookService struct {
+	db         *gorm.DB
+	httpClient *http.Client
+	logger     *log.Logger
+
+	// allowInternalHosts разрешает адреса во внутренней сети (для тестов)
+	allowInternalHosts bool
+
+	wake    chan struct{}
+	stop    chan struct{}
+	running bool
+	mu      sync.Mutex
+}
+
+// NewWebhookService создает новый сервис вебхуков
+func NewWebhookService(db *gorm.DB) *WebhookService {
+	return &WebhookService{
+		db:         db,
+		httpClient: newWebhookHTTPClient(),
+		logger:     log.New(os.Stdout, "[WEBHOOKS] ", log.LstdFlags),
+		wake:       make(chan struct{}, 1),
+	}
+}
+
+// SignWebhookPayload вычисляет подпись тела запроса: HMAC-SHA256 от "<timestamp>.<body>"
+func SignWebhookPayload(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// GetSubscriptions возвращает подписки компании
+func (s *WebhookService) GetSubscriptions(companyID uuid.UUID) ([]models.WebhookSubscription, error) {
+	var subscriptions []models.WebhookSubscription
+	if err := s.db.Where("company_id = ?", companyID).Order("id").Find(&subscriptions).Error; err != nil {
+		return nil, fmt.Errorf("ошибка получения подписок: %w", err)
+	}
+	return subscriptions, nil
+}
+
+// GetSubscription возвращает подписку компании по ID
+func (s *WebhookService) GetSubscription(companyID uuid.UUID, subscriptionID uint) (*models.WebhookSubscription, error) {
+	var subscription models.WebhookSubscription
+	if err := s.db.Where("id = ? AND company_id = ?", subscriptionID, companyID).First(&subscription).Error; err != nil {
+		return nil, fmt.Errorf("подписка не найдена: %w", err)
+	}
+	return &subscription, nil
+}
+
+// CreateSubscription создает подписку и генерирует ключ подписи, если он не задан
+func (s *WebhookService) CreateSubscription(subscription *models.WebhookSubscription) error {
+	if err := validateWebhookSubscription(subscription, s.allowInternalHosts); err != nil {
+		return err
+	}
+
+	if subscription.Secret == "" {
+		secret, err := generateWebhookSecret()
+		if err != nil {
+			return err
+		}
+		subscription.Secret = secret
+	}
+
+	isActive := subscription.IsActive
+	if err := s.db.Create(subscription).Error; err != nil {
+		return fmt.Errorf("ошибка создания подписки: %w", err)
+	}
+
+	// При создании gorm подставляет значение по умолчанию вместо false
+	if !isActive && subscription.IsActive {
+		if err := s.db.Model(subscription).Update("is_active", false).Error; err != nil {
+			return fmt.Errorf("ошибка обновления статуса подписки: %w", err)
+		}
+	}
+	return nil
+}
+
+// UpdateSubscription сохраняет изменения подписки
+func (s *WebhookService) UpdateSubscription(subscription *models.WebhookSubscription) error {
+	if err := validateWebhookSubscription(subscription, s.allowInternalHosts); err != nil {
+		return err
+	}
+
+	// Включение подписки сбрасывает счетчик ошибок
+	if subscription.IsActive {
+		subscription.ConsecutiveFailures = 0
+	}
+
+	if err := s.db.Save(subscription).Error; err != nil {
+		return fmt.Errorf("ошибка обновления подписки: %w", err)
+	}
+	return nil
+}
+
+// DeleteSubscription удаляет подписку и отменяет недоставленные события
+func (s *WebhookService) DeleteSubscription(companyID uuid.UUID, subscriptionID uint) error {
+	subscription, err := s.GetSubscription(companyID, subscriptionID)
+	if err != nil {
+		return err
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.WebhookDelivery{}).
+			Where("subscription_id = ? AND status = ?", subscription.ID, models.WebhookDeliveryPending).
+			Updates(map[string]interface{}{
+				"status":          models.WebhookDeliveryFailed,
+				"error":           "подписка удалена",
+				"next_attempt_at": nil,
+			}).Error; err != nil {
+			return fmt.Errorf("ошибка отмены доставок: %w", err)
+		}
+		if err := tx.Delete(subscription).Error; err != nil {
+			return fmt.Errorf("ошибка удаления подписки: %w", err)
+		}
+		return nil
+	})
+}
+
+// RotateSecret генерирует новый ключ подписи
+func (s *WebhookService) RotateSecret(companyID uuid.UUID, subscriptionID uint) (*models.WebhookSubscription, error) {
+	subscription, err := s.GetSubscription(companyID, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := generateWebhookSecret()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.db.Model(subscription).Update("secret", secret).Error; err != nil {
+		return nil, fmt.Errorf("ошибка обновления ключа подписи: %w", err)
+	}
+	subscription.Secret = secret
+	return subscription, nil
+}
+
+// GetDeliveries возвращает журнал доставки событий подписки
+func (s *WebhookService) GetDeliveries(companyID uuid.UUID, subscriptionID uint, status string, limit, offset int) ([]models.WebhookDelivery, int64, error) {
+	query := s.db.Model(&models.WebhookDelivery{}).Where("company_id = ? AND subscription_id = ?", companyID, subscriptionID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("ошибка подсчета доставок: %w", err)
+	}
+
+	var deliveries []models.WebhookDelivery
+	if err := query.Order("id DESC").Limit(limit).Offset(offset).Find(&deliveries).Error; err != nil {
+		return nil, 0, fmt.Errorf("ошибка получения доставок: %w", err)
+	}
+	return deliveries, total, nil
+}
+
+// Publish ставит событие в очередь доставки всем активным подпискам компании
+func (s *WebhookService) Publish(companyID uuid.UUID, eventType string, data interface{}) (int, error) {
+	var subscriptions []models.WebhookSubscription
+	if err := s.db.Where("company_id = ? AND is_active = ?", companyID, true).Find(&subscriptions).Error; err != nil {
+		return 0, fmt.Errorf("ошибка получения подписок: %w", err)
+	}
+
+	var matched []models.WebhookSubscription
+	for _, subscription := range subscriptions {
+		if subscription.HandlesEvent(eventType) {
+			matched = append(matched, subscription)
+		}
+	}
+	if len(matched) == 0 {
+		return 0, nil
+	}
+
+	event, payload, err := buildWebhookEvent(companyID, eventType, data)
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	deliveries := make([]models.WebhookDelivery, 0, len(matched))
+	for _, subscription := range matched {
+		deliveries = append(deliveries, models.WebhookDelivery{
+			CompanyID:      companyID,
+			SubscriptionID: subscription.ID,
+			EventID:        event.ID,
+			EventType:      eventType,
+			Payload:        string(payload),
+			Status:         models.WebhookDeliveryPending,
+			NextAttemptAt:  &now,
+		})
+	}
+	if err := s.db.Create(&deliveries).Error; err != nil {
+		return 0, fmt.Errorf("ошибка постановки событий в очередь: %w", err)
+	}
+
+	s.notify()
+	return len(deliveries), nil
+}
+
+// SendTestEvent синхронно отправляет тестовое событие подписчику
+func (s *WebhookService) SendTestEvent(companyID uuid.UUID, subscriptionID uint) (*models.WebhookDelivery, error) {
+	subscription, err := s.GetSubscription(companyID, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	event, payload, err := buildWebhookEvent(companyID, models.WebhookEventTest, map[string]interface{}{
+		"subscription_id": subscription.ID,
+		"message":         "Тестовое событие",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Тестовое событие отправляется однократно, без повторных попыток
+	delivery := &models.WebhookDelivery{
+		CompanyID:      companyID,
+		SubscriptionID: subscription.ID,
+		EventID:        event.ID,
+		EventType:      models.WebhookEventTest,
+		Payload:        string(payload),
+		Status:         models.WebhookDeliveryPending,
+	}
+	if err := s.db.Create(delivery).Error; err != nil {
+		return nil, fmt.Errorf("ошибка создания доставки: %w", err)
+	}
+
+	s.deliver(delivery, subscription, 1)
+	return delivery, nil
+}
+
+// Redeliver повторно отправляет событие из журнала доставки
+func (s *WebhookService) Redeliver(companyID uuid.UUID, subscriptionID, deliveryID uint) (*models.WebhookDelivery, error) {
+	var original models.WebhookDelivery
+	if err := s.db.Where("id = ? AND company_id = ? AND subscription_id = ?", deliveryID, companyID, subscriptionID).
+		First(&original).Error; err != nil {
+		return nil, fmt.Errorf("доставка не найдена: %w", err)
+	}
+
+	subscription, err := s.GetSubscription(companyID, original.SubscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	delivery := &models.WebhookDelivery{
+		CompanyID:      companyID,
+		SubscriptionID: subscription.ID,
+		EventID:        original.EventID,
+		EventType:      original.EventType,
+		Payload:        original.Payload,
+		Status:         models.WebhookDeliveryPending,
+	}
+	if err := s.db.Create(delivery).Error; err != nil {
+		return nil, fmt.Errorf("ошибка создания доставки: %w", err)
+	}
+
+	s.deliver(delivery, subscription, 1)
+	return delivery, nil
+}
+
+// ProcessPendingDeliveries отправляет события, время доставки которых наступило
+func (s *WebhookService) ProcessPendingDeliveries(limit int) (int, error) {
+	var deliveries []models.WebhookDelivery
+	if err := s.db.Where("status = ? AND next_attempt_at <= ?", models.WebhookDeliveryPending, time.Now()).
+		Order("next_attempt_at").Limit(limit).Find(&deliveries).Error; err != nil {
+		return 0, fmt.Errorf("ошибка получения очереди доставки: %w", err)
+	}
+
+	subscriptions := make(map[uint]*models.WebhookSubscription)
+	for i := range deliveries {
+		delivery := &deliveries[i]
+
+		subscription, ok := subscriptions[delivery.SubscriptionID]
+		if !ok {
+			var loaded models.WebhookSubscription
+			if err := s.db.First(&loaded, delivery.SubscriptionID).Error; err == nil {
+				subscription = &loaded
+			}
+			subscriptions[delivery.SubscriptionID] = subscription
+		}
+
+		if subscription == nil || !subscription.IsActive {
+			s.db.Model(delivery).Updates(map[string]interface{}{
+				"status":          models.WebhookDeliveryFailed,
+				"error":           "подписка отключена или удалена",
+				"next_attempt_at": nil,
+			})
+			continue
+		}
+
+		maxAttempts := subscription.MaxAttempts
+		if maxAttempts <= 0 {
+			maxAttempts = len(webhookRetryDelays) + 1
+		}
+		s.deliver(delivery, subscription, maxAttempts)
+	}
+
+	return len(deliveries), nil
+}
+
+// Start запускает фоновую доставку событий
+func (s *WebhookService) Start(interval time.Duration) {
+	s.mu.Lock()
+	if s.running {
+		s.mu.Unlock()
+		return
+	}
+	s.running = true
+	s.stop = make(chan struct{})
+	s.mu.Unlock()
+
+	s.logger.Printf("Доставка вебхуков запущена, интервал %s", interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.ProcessPendingDeliveries(100); err != nil {
+			s.logger.Printf("Ошибка обработки очереди вебхуков: %v", err)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-s.wake:
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+// Stop останавливает фоновую доставку событий
+func (s *WebhookService) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.running {
+		close(s.stop)
+		s.running = false
+	}
+}
+
+// notify будит фоновую доставку после постановки событий в очередь
+func (s *WebhookService) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// deliver выполняет одну попытку доставки и планирует повтор при ошибке
+func (s *WebhookService) deliver(delivery *models.WebhookDelivery, subscription *models.WebhookSubscription, maxAttempts int) {
+	statusCode, responseBody, duration, err := s.send(delivery, subscription)
+
+	now := time.Now()
+	delivery.Attempts++
+	delivery.ResponseStatus = statusCode
+	delivery.ResponseBody = responseBody
+	delivery.DurationMs = duration.Milliseconds()
+	delivery.NextAttemptAt = nil
+	delivery.Error = ""
+
+	subscriptionUpdates := map[string]interface{}{"last_delivery_at": now}
+
+	if err == nil {
+		delivery.Status = models.WebhookDeliverySuccess
+		delivery.DeliveredAt = &now
+		subscriptionUpdates["last_success_at"] = now
+		subscriptionUpdates["last_error"] = ""
+		subscriptionUpdates["consecutive_failures"] = 0
+	} else {
+		delivery.Error = err.Error()
+		if delivery.Attempts < maxAttempts {
+			delay := webhookRetryDelays[len(webhookRetryDelays)-1]
+			if delivery.Attempts-1 < len(webhookRetryDelays) {
+				delay = webhookRetryDelays[delivery.Attempts-1]
+			}
+			next := now.Add(delay)
+			delivery.Status = models.WebhookDeliveryPending
+			delivery.NextAttemptAt = &next
+		} else {
+			delivery.Status = models.WebhookDeliveryFailed
+		}
+		subscriptionUpdates["last_error"] = err.Error()
+		subscriptionUpdates["consecutive_failures"] = gorm.Expr("consecutive_failures + 1")
+
+		s.logger.Printf("Ошибка доставки события %s (%s) подписчику %s, попытка %d: %v",
+			delivery.EventID, delivery.EventType, subscription.URL, delivery.Attempts, err)
+	}
+
+	if err := s.db.Save(delivery).Error; err != nil {
+		s.logger.Printf("Ошибка сохранения доставки %d: %v", delivery.ID, err)
+	}
+	if err := s.db.Model(subscription).Updates(subscriptionUpdates).Error; err != nil {
+		s.logger.Printf("Ошибка обновления подписки %d: %v", subscription.ID, err)
+	}
+}
+
+// send отправляет подписанный запрос получателю
+func (s *WebhookService) send(delivery *models.WebhookDelivery, subscription *models.WebhookSubscription) (int, string, time.Duration, error) {
+	body := []byte(delivery.Payload)
+	timestamp := time.Now().Unix()
+
+	req, err := http.NewRequest(http.MethodPost, subscription.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, "", 0, fmt.Errorf("ошибка создания запроса: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Axenta-Webhooks/1.0")
+	req.Header.Set(WebhookHeaderEvent, delivery.EventType)
+	req.Header.Set(WebhookHeaderDelivery, delivery.EventID)
+	req.Header.Set(WebhookHeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(WebhookHeaderSignature, SignWebhookPayload(subscription.Secret, timestamp, body))
+
+	timeout := time.Duration(subscription.TimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	client := *s.httpClient
+	client.Timeout = timeout
+
+	started := time.Now()
+	resp, err := client.Do(req)
+	duration := time.Since(started)
+	if err != nil {
+		return 0, "", duration, fmt.Errorf("ошибка отправки запроса: %w", err)
+	}
+	defer resp.Body.Close()
+
+	excerpt, _ := io.ReadAll(io.LimitReader(resp.Body, webhookResponseLimit))
+	responseBody := strings.ToValidUTF8(string(excerpt), "")
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, responseBody, duration, fmt.Errorf("получатель ответил статусом %d", resp.StatusCode)
+	}
+	return resp.StatusCode, responseBody, duration, nil
+}
+
+// buildWebhookEvent формирует событие и его JSON представление
+func buildWebhookEvent(companyID uuid.UUID, eventType string, data interface{}) (*WebhookEvent, []byte, error) {
+	event := &WebhookEvent{
+		ID:        uuid.New().String(),
+		Type:      eventType,
+		CompanyID: companyID,
+		CreatedAt: time.Now().UTC(),
+		Data:      data,
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return nil, nil, fmt.Errorf("ошибка сериализации события: %w", err)
+	}
+	return event, payload, nil
+}
+
+// validateWebhookSubscription проверяет адрес и события подписки
+func validateWebhookSubscription(subscription *models.WebhookSubscription, allowInternalHosts bool) error {
+	if subscription.Name == "" {
+		return errors.New("не указано название подписки")
+	}
+
+	parsed, err := url.Parse(subscription.URL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return errors.New("некорректный адрес получателя вебхука")
+	}
+	// Адреса, заданные IP, проверяются сразу; имена - при каждом подключении
+	if !allowInternalHosts {
+		ip := net.ParseIP(parsed.Hostname())
+		if (ip != nil && isForbiddenWebhookIP(ip)) || strings.EqualFold(parsed.Hostname(), "localhost") {
+			return errWebhookAddressForbidden
+		}
+	}
+
+	events := subscription.Events()
+	if len(events) == 0 {
+		return errors.New("не указаны события подписки")
+	}
+	for _, event := range events {
+		if !models.IsValidWebhookEvent(event) {
+			return fmt.Errorf("неизвестный тип события: %s", event)
+		}
+	}
+	return nil
+}
+
+// generateWebhookSecret генерирует случайный ключ подписи
+func generateWebhookSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("ошибка генерации ключа подписи: %w", err)
+	}
+	return "whsec_" + hex.EncodeToString(buf), nil
+}
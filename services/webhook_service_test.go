@@ -0,0 +1,188 @@
+package services
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"backend_axenta/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// webhookReceiver тестовый получатель вебхуков
+type webhookReceiver struct {
+	mu       sync.Mutex
+	status   int
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func (r *webhookReceiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	body, _ := io.ReadAll(req.Body)
+	r.requests = append(r.requests, req)
+	r.bodies = append(r.bodies, body)
+	w.WriteHeader(r.status)
+}
+
+func setupWebhookTest(t *testing.T) (*gorm.DB, *WebhookService, *webhookReceiver, string, uuid.UUID) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&models.WebhookSubscription{}, &models.WebhookDelivery{}))
+
+	receiver := &webhookReceiver{status: http.StatusOK}
+	server := httptest.NewServer(receiver)
+	t.Cleanup(server.Close)
+
+	// Тестовый получатель слушает loopback, недоступный обычному клиенту вебхуков
+	service := NewWebhookService(db)
+	service.allowInternalHosts = true
+	service.httpClient = &http.Client{}
+
+	return db, service, receiver, server.URL, uuid.New()
+}
+
+func TestWebhookService_PublishDeliversSignedEvent(t *testing.T) {
+	db, service, receiver, url, companyID := setupWebhookTest(t)
+
+	subscription := &models.WebhookSubscription{CompanyID: companyID, Name: "CRM", URL: url, IsActive: true}
+	subscription.SetEvents([]string{models.WebhookEventInvoicePaid})
+	require.NoError(t, service.CreateSubscription(subscription))
+	require.NotEmpty(t, subscription.Secret)
+
+	// Событие, на которое нет подписки, не ставится в очередь
+	queued, err := service.Publish(companyID, models.WebhookEventObjectCreated, map[string]interface{}{"id": 1})
+	require.NoError(t, err)
+	assert.Equal(t, 0, queued)
+
+	queued, err = service.Publish(companyID, models.WebhookEventInvoicePaid, map[string]interface{}{"id": 42, "number": "INV-042"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, queued)
+
+	processed, err := service.ProcessPendingDeliveries(10)
+	require.NoError(t, err)
+	assert.Equal(t, 1, processed)
+
+	require.Len(t, receiver.requests, 1)
+	req, body := receiver.requests[0], receiver.bodies[0]
+	assert.Equal(t, models.WebhookEventInvoicePaid, req.Header.Get(WebhookHeaderEvent))
+
+	timestamp, err := strconv.ParseInt(req.Header.Get(WebhookHeaderTimestamp), 10, 64)
+	require.NoError(t, err)
+	assert.Equal(t, SignWebhookPayload(subscription.Secret, timestamp, body), req.Header.Get(WebhookHeaderSignature))
+
+	var event WebhookEvent
+	require.NoError(t, json.Unmarshal(body, &event))
+	assert.Equal(t, models.WebhookEventInvoicePaid, event.Type)
+	assert.Equal(t, req.Header.Get(WebhookHeaderDelivery), event.ID)
+
+	var delivery models.WebhookDelivery
+	require.NoError(t, db.First(&delivery).Error)
+	assert.Equal(t, models.WebhookDeliverySuccess, delivery.Status)
+	assert.Equal(t, 1, delivery.Attempts)
+	assert.Equal(t, http.StatusOK, delivery.ResponseStatus)
+}
+
+func TestWebhookService_RetriesFailedDelivery(t *testing.T) {
+	db, service, receiver, url, companyID := setupWebhookTest(t)
+	receiver.status = http.StatusInternalServerError
+
+	subscription := &models.WebhookSubscription{CompanyID: companyID, Name: "ERP", URL: url, IsActive: true, MaxAttempts: 2}
+	subscription.SetEvents([]string{models.WebhookEventAll})
+	require.NoError(t, service.CreateSubscription(subscription))
+
+	_, err := service.Publish(companyID, models.WebhookEventStockLow, map[string]interface{}{"category": "Трекеры"})
+	require.NoError(t, err)
+	_, err = service.ProcessPendingDeliveries(10)
+	require.NoError(t, err)
+
+	var delivery models.WebhookDelivery
+	require.NoError(t, db.First(&delivery).Error)
+	assert.Equal(t, models.WebhookDeliveryPending, delivery.Status)
+	assert.Equal(t, 1, delivery.Attempts)
+	require.NotNil(t, delivery.NextAttemptAt)
+	assert.True(t, delivery.NextAttemptAt.After(time.Now()))
+
+	// Повтор еще не наступил
+	processed, err := service.ProcessPendingDeliveries(10)
+	require.NoError(t, err)
+	assert.Equal(t, 0, processed)
+
+	// Последняя попытка исчерпывает лимит
+	require.NoError(t, db.Model(&delivery).Update("next_attempt_at", time.Now().Add(-time.Second)).Error)
+	_, err = service.ProcessPendingDeliveries(10)
+	require.NoError(t, err)
+
+	var failed models.WebhookDelivery
+	require.NoError(t, db.First(&failed, delivery.ID).Error)
+	assert.Equal(t, models.WebhookDeliveryFailed, failed.Status)
+	assert.Equal(t, 2, failed.Attempts)
+	assert.Nil(t, failed.NextAttemptAt)
+
+	require.NoError(t, db.First(subscription, subscription.ID).Error)
+	assert.Equal(t, 2, subscription.ConsecutiveFailures)
+	assert.Len(t, receiver.requests, 2)
+}
+
+func TestWebhookService_SendTestEvent(t *testing.T) {
+	_, service, receiver, url, companyID := setupWebhookTest(t)
+
+	subscription := &models.WebhookSubscription{CompanyID: companyID, Name: "CRM", URL: url, IsActive: false}
+	subscription.SetEvents([]string{models.WebhookEventObjectCreated})
+	require.NoError(t, service.CreateSubscription(subscription))
+
+	// Отключенная подписка не получает событий, но тестовое событие отправляется
+	queued, err := service.Publish(companyID, models.WebhookEventObjectCreated, map[string]interface{}{"id": 1})
+	require.NoError(t, err)
+	assert.Equal(t, 0, queued)
+
+	delivery, err := service.SendTestEvent(companyID, subscription.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.WebhookDeliverySuccess, delivery.Status)
+	require.Len(t, receiver.requests, 1)
+	assert.Equal(t, models.WebhookEventTest, receiver.requests[0].Header.Get(WebhookHeaderEvent))
+}
+
+func TestWebhookService_CreateSubscriptionValidation(t *testing.T) {
+	_, service, _, url, companyID := setupWebhookTest(t)
+
+	invalidURL := &models.WebhookSubscription{CompanyID: companyID, Name: "CRM", URL: "ftp://example.com", EventTypes: models.WebhookEventInvoicePaid}
+	assert.Error(t, service.CreateSubscription(invalidURL))
+
+	unknownEvent := &models.WebhookSubscription{CompanyID: companyID, Name: "CRM", URL: url, EventTypes: "invoice.deleted"}
+	assert.Error(t, service.CreateSubscription(unknownEvent))
+}
+
+func TestWebhookService_RejectsInternalAddresses(t *testing.T) {
+	db, _, receiver, url, companyID := setupWebhookTest(t)
+	service := NewWebhookService(db)
+
+	for _, address := range []string{"http://127.0.0.1:8080/hook", "http://localhost/hook", "http://169.254.169.254/latest/meta-data", "http://10.0.0.5/hook", "http://[::1]/hook"} {
+		subscription := &models.WebhookSubscription{CompanyID: companyID, Name: "CRM", URL: address, EventTypes: models.WebhookEventInvoicePaid}
+		assert.Error(t, service.CreateSubscription(subscription), address)
+	}
+
+	// Адрес, прошедший проверку при создании, проверяется повторно при подключении
+	subscription := &models.WebhookSubscription{CompanyID: companyID, Name: "CRM", URL: url, EventTypes: models.WebhookEventInvoicePaid, IsActive: true, Secret: "whsec_test"}
+	require.NoError(t, db.Create(subscription).Error)
+	delivery, err := service.SendTestEvent(companyID, subscription.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.WebhookDeliveryFailed, delivery.Status)
+	assert.Contains(t, delivery.Error, "внутренней сети")
+	assert.Empty(t, receiver.requests)
+}
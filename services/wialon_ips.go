@@ -0,0 +1,291 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"backend_axenta/models"
+)
+
+// Коды ответов протокола Wialon IPS
+const (
+	wialonAckOK           = "1"
+	wialonAckStructure    = "-1" // Неверная структура пакета
+	wialonAckTime         = "0"  // Неверное время
+	wialonAckCoordinates  = "10" // Ошибка координат
+	wialonAckMotion       = "11" // Ошибка скорости, курса или высоты
+	wialonAckSatellites   = "12" // Ошибка количества спутников или HDOP
+	wialonAckIO           = "13" // Ошибка входов или выходов
+	wialonAckADC          = "14" // Ошибка АЦП
+	wialonAckParams       = "15" // Ошибка дополнительных параметров
+	wialonAckCRCShort     = "13" // Ошибка контрольной суммы в пакете #SD#
+	wialonAckCRCData      = "16" // Ошибка контрольной суммы в пакете #D#
+	wialonLoginOK         = "1"
+	wialonLoginRejected   = "0"
+	wialonLoginCRCInvalid = "10"
+)
+
+// wialonPacketError ошибка разбора пакета с кодом ответа протокола
+type wialonPacketError struct {
+	Code    string
+	Message string
+}
+
+func (e *wialonPacketError) Error() string {
+	return e.Message
+}
+
+func wialonError(code, format string, args ...interface{}) error {
+	return &wialonPacketError{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// wialonCRC16 контрольная сумма Wialon IPS 2.0 (CRC-16/ARC)
+func wialonCRC16(data string) uint16 {
+	var crc uint16
+	for i := 0; i < len(data); i++ {
+		crc ^= uint16(data[i])
+		for bit := 0; bit < 8; bit++ {
+			if crc&1 != 0 {
+				crc = crc>>1 ^ 0xA001
+			} else {
+				crc >>= 1
+			}
+		}
+	}
+	return crc
+}
+
+// splitWialonCRC отделяет контрольную сумму, следующую за последним разделителем,
+// и проверяет ее. Сумма считается по данным вместе с разделителем.
+func splitWialonCRC(payload string, separator byte) (string, bool) {
+	idx := strings.LastIndexByte(payload, separator)
+	if idx < 0 {
+		return payload, false
+	}
+	expected, err := strconv.ParseUint(payload[idx+1:], 16, 16)
+	if err != nil {
+		return payload, false
+	}
+	return payload[:idx], uint16(expected) == wialonCRC16(payload[:idx+1])
+}
+
+// WialonIPSLogin данные пакета авторизации #L#
+type WialonIPSLogin struct {
+	Version  string // 1.1 или 2.0
+	IMEI     string
+	Password string
+}
+
+// ParseWialonIPSLogin разбирает тело пакета #L#: "imei;password" (1.1)
+// или "2.0;imei;password;crc16" (2.0)
+func ParseWialonIPSLogin(payload string) (*WialonIPSLogin, error) {
+	if strings.HasPrefix(payload, "2.0;") {
+		body, ok := splitWialonCRC(payload, ';')
+		if !ok {
+			return nil, wialonError(wialonLoginCRCInvalid, "неверная контрольная сумма пакета авторизации")
+		}
+		fields := strings.Split(body, ";")
+		if len(fields) != 3 {
+			return nil, wialonError(wialonLoginRejected, "неверная структура пакета авторизации")
+		}
+		return &WialonIPSLogin{Version: "2.0", IMEI: fields[1], Password: fields[2]}, nil
+	}
+
+	fields := strings.Split(payload, ";")
+	if len(fields) != 2 {
+		return nil, wialonError(wialonLoginRejected, "неверная структура пакета авторизации")
+	}
+	return &WialonIPSLogin{Version: "1.1", IMEI: fields[0], Password: fields[1]}, nil
+}
+
+// ParseWialonIPSShortData разбирает запись сокращенного пакета данных #SD#:
+// date;time;lat1;lat2;lon1;lon2;speed;course;alt;sats
+func ParseWialonIPSShortData(record string, now time.Time) (*models.TelemetryPoint, error) {
+	fields := strings.Split(record, ";")
+	if len(fields) != 10 {
+		return nil, wialonError(wialonAckStructure, "сокращенный пакет должен содержать 10 полей, получено %d", len(fields))
+	}
+	return parseWialonPosition(fields, now)
+}
+
+// ParseWialonIPSData разбирает запись расширенного пакета данных #D#:
+// date;time;lat1;lat2;lon1;lon2;speed;course;alt;sats;hdop;inputs;outputs;adc;ibutton;params
+func ParseWialonIPSData(record string, now time.Time) (*models.TelemetryPoint, error) {
+	fields := strings.Split(record, ";")
+	if len(fields) != 16 {
+		return nil, wialonError(wialonAckStructure, "пакет данных должен содержать 16 полей, получено %d", len(fields))
+	}
+	point, err := parseWialonPosition(fields[:10], now)
+	if err != nil {
+		return nil, err
+	}
+
+	if point.HDOP, err = parseWialonFloat(fields[10]); err != nil {
+		return nil, wialonError(wialonAckSatellites, "неверный HDOP %q", fields[10])
+	}
+	if point.Inputs, err = parseWialonInt(fields[11]); err != nil {
+		return nil, wialonError(wialonAckIO, "неверные входы %q", fields[11])
+	}
+	if point.Outputs, err = parseWialonInt(fields[12]); err != nil {
+		return nil, wialonError(wialonAckIO, "неверные выходы %q", fields[12])
+	}
+
+	params := map[string]interface{}{}
+	if fields[13] != "" && fields[13] != "NA" {
+		for i, value := range strings.Split(fields[13], ",") {
+			adc, err := parseWialonFloat(value)
+			if err != nil {
+				return nil, wialonError(wialonAckADC, "неверное значение АЦП %q", value)
+			}
+			if adc != nil {
+				params[fmt.Sprintf("adc%d", i+1)] = *adc
+			}
+		}
+	}
+	if fields[14] != "" && fields[14] != "NA" {
+		params["ibutton"] = fields[14]
+	}
+	if fields[15] != "" && fields[15] != "NA" {
+		for _, param := range strings.Split(fields[15], ",") {
+			name, value, err := parseWialonParam(param)
+			if err != nil {
+				return nil, err
+			}
+			params[name] = value
+		}
+	}
+	if len(params) > 0 {
+		data, _ := json.Marshal(params)
+		point.Params = string(data)
+	}
+	return point, nil
+}
+
+// parseWialonParam разбирает дополнительный параметр "имя:тип:значение",
+// тип 1 - целое, 2 - дробное, 3 - строка
+func parseWialonParam(param string) (string, interface{}, error) {
+	parts := strings.SplitN(param, ":", 3)
+	if len(parts) != 3 || parts[0] == "" {
+		return "", nil, wialonError(wialonAckParams, "неверный параметр %q", param)
+	}
+	switch parts[1] {
+	case "1":
+		value, err := strconv.ParseInt(parts[2], 10, 64)
+		if err != nil {
+			return "", nil, wialonError(wialonAckParams, "неверное целое значение параметра %q", param)
+		}
+		return parts[0], value, nil
+	case "2":
+		value, err := parseFiniteFloat(parts[2])
+		if err != nil {
+			return "", nil, wialonError(wialonAckParams, "неверное дробное значение параметра %q", param)
+		}
+		return parts[0], value, nil
+	case "3":
+		return parts[0], parts[2], nil
+	}
+	return "", nil, wialonError(wialonAckParams, "неизвестный тип параметра %q", param)
+}
+
+// parseWialonPosition разбирает общие поля пакетов данных: время, координаты, скорость, курс, высоту и спутники
+func parseWialonPosition(fields []string, now time.Time) (*models.TelemetryPoint, error) {
+	point := &models.TelemetryPoint{Protocol: models.TelemetryProtocolWialonIPS, RecordedAt: now.UTC()}
+
+	if fields[0] != "NA" || fields[1] != "NA" {
+		recordedAt, err := time.ParseInLocation("020106150405", fields[0]+fields[1], time.UTC)
+		if err != nil {
+			return nil, wialonError(wialonAckTime, "неверное время %s %s", fields[0], fields[1])
+		}
+		point.RecordedAt = recordedAt
+	}
+
+	var err error
+	if point.Latitude, err = parseWialonCoordinate(fields[2], fields[3], "N", "S", 90); err != nil {
+		return nil, err
+	}
+	if point.Longitude, err = parseWialonCoordinate(fields[4], fields[5], "E", "W", 180); err != nil {
+		return nil, err
+	}
+	if (point.Latitude == nil) != (point.Longitude == nil) {
+		return nil, wialonError(wialonAckCoordinates, "указана только одна координата")
+	}
+
+	if point.Speed, err = parseWialonFloat(fields[6]); err != nil || (point.Speed != nil && *point.Speed < 0) {
+		return nil, wialonError(wialonAckMotion, "неверная скорость %q", fields[6])
+	}
+	if point.Course, err = parseWialonInt(fields[7]); err != nil || (point.Course != nil && (*point.Course < 0 || *point.Course > 360)) {
+		return nil, wialonError(wialonAckMotion, "неверный курс %q", fields[7])
+	}
+	if point.Altitude, err = parseWialonFloat(fields[8]); err != nil {
+		return nil, wialonError(wialonAckMotion, "неверная высота %q", fields[8])
+	}
+	if point.Satellites, err = parseWialonInt(fields[9]); err != nil || (point.Satellites != nil && *point.Satellites < 0) {
+		return nil, wialonError(wialonAckSatellites, "неверное количество спутников %q", fields[9])
+	}
+	return point, nil
+}
+
+// parseWialonCoordinate переводит координату из формата (D)DDMM.MMMM и полушария в градусы
+func parseWialonCoordinate(value, hemisphere, positive, negative string, limit float64) (*float64, error) {
+	if value == "NA" && hemisphere == "NA" {
+		return nil, nil
+	}
+	raw, err := parseFiniteFloat(value)
+	if err != nil || raw < 0 || (hemisphere != positive && hemisphere != negative) {
+		return nil, wialonError(wialonAckCoordinates, "неверная координата %s%s", value, hemisphere)
+	}
+	degrees := math.Floor(raw / 100)
+	minutes := raw - degrees*100
+	if minutes >= 60 {
+		return nil, wialonError(wialonAckCoordinates, "неверная координата %s%s", value, hemisphere)
+	}
+	result := degrees + minutes/60
+	if hemisphere == negative {
+		result = -result
+	}
+	if math.Abs(result) > limit {
+		return nil, wialonError(wialonAckCoordinates, "неверная координата %s%s", value, hemisphere)
+	}
+	return &result, nil
+}
+
+// parseWialonFloat разбирает дробное значение, NA - значение неизвестно
+func parseWialonFloat(value string) (*float64, error) {
+	if value == "NA" || value == "" {
+		return nil, nil
+	}
+	result, err := parseFiniteFloat(value)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// parseFiniteFloat разбирает дробное число; NaN и бесконечность не принимаются,
+// так как они проходят проверки диапазона и не сериализуются в JSON
+func parseFiniteFloat(value string) (float64, error) {
+	result, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, err
+	}
+	if math.IsNaN(result) || math.IsInf(result, 0) {
+		return 0, fmt.Errorf("недопустимое значение %q", value)
+	}
+	return result, nil
+}
+
+// parseWialonInt разбирает целое значение, NA - значение неизвестно
+func parseWialonInt(value string) (*int, error) {
+	if value == "NA" || value == "" {
+		return nil, nil
+	}
+	result, err := strconv.Atoi(value)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
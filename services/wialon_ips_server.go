@@ -0,0 +1,206 @@
+package services
+
+import (
+	"bufio"
+	"errors"
+	"log"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"backend_axenta/models"
+)
+
+// wialonMaxPacketSize максимальная длина пакета Wialon IPS (пакет черного ящика может быть большим)
+const wialonMaxPacketSize = 1 << 20
+
+// WialonIPSServer TCP сервер приема телеметрии по протоколу Wialon IPS 1.1 и 2.0.
+// Трекер авторизуется пакетом #L# по IMEI объекта, пароль не проверяется.
+type WialonIPSServer struct {
+	telemetryListener
+
+	Telemetry   *TelemetryService
+	IdleTimeout time.Duration
+}
+
+// NewWialonIPSServer создает новый экземпляр WialonIPSServer
+func NewWialonIPSServer(telemetry *TelemetryService) *WialonIPSServer {
+	return &WialonIPSServer{
+		telemetryListener: telemetryListener{name: "Wialon IPS"},
+		Telemetry:         telemetry,
+		IdleTimeout:       DefaultTelemetryIdleTimeout,
+	}
+}
+
+// ListenAndServe начинает прием соединений трекеров на адресе addr
+func (s *WialonIPSServer) ListenAndServe(addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(listener)
+}
+
+// Serve принимает соединения трекеров, пока сервер не будет остановлен
+func (s *WialonIPSServer) Serve(listener net.Listener) error {
+	return s.serve(listener, s.handleConn)
+}
+
+// handleConn обслуживает соединение одного трекера
+func (s *WialonIPSServer) handleConn(conn net.Conn) {
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 4096), wialonMaxPacketSize)
+	session := &wialonIPSSession{telemetry: s.Telemetry}
+	for {
+		conn.SetReadDeadline(time.Now().Add(s.IdleTimeout))
+		if !scanner.Scan() {
+			return
+		}
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		reply, keep := session.handle(line, time.Now())
+		if reply != "" {
+			conn.SetWriteDeadline(time.Now().Add(s.IdleTimeout))
+			if _, err := conn.Write([]byte(reply + "\r\n")); err != nil {
+				return
+			}
+		}
+		if !keep {
+			return
+		}
+	}
+}
+
+// wialonIPSSession состояние соединения трекера
+type wialonIPSSession struct {
+	telemetry *TelemetryService
+	version   string
+	device    *TelemetryDevice
+}
+
+// handle обрабатывает пакет и возвращает ответ трекеру и признак продолжения соединения
+func (s *wialonIPSSession) handle(line string, now time.Time) (string, bool) {
+	if len(line) < 3 || line[0] != '#' {
+		return "", false
+	}
+	end := strings.IndexByte(line[1:], '#')
+	if end < 0 {
+		return "", false
+	}
+	packetType := line[1 : end+1]
+	payload := line[end+2:]
+
+	if packetType == "L" {
+		return s.login(payload)
+	}
+	// До авторизации данные не принимаются
+	if s.device == nil {
+		return "", false
+	}
+
+	switch packetType {
+	case "P":
+		return "#AP#", true
+	case "SD":
+		return s.data(payload, now, "#ASD#", wialonAckCRCShort, ParseWialonIPSShortData)
+	case "D":
+		return s.data(payload, now, "#AD#", wialonAckCRCData, ParseWialonIPSData)
+	case "B":
+		return s.blackBox(payload, now)
+	case "M":
+		log.Printf("Wialon IPS: сообщение от %s: %s", s.device.IMEI, payload)
+		return "#AM#1", true
+	}
+	// Неподдерживаемые пакеты (изображения, DDD) пропускаются
+	return "", true
+}
+
+// login авторизует трекер по IMEI
+func (s *wialonIPSSession) login(payload string) (string, bool) {
+	login, err := ParseWialonIPSLogin(payload)
+	if err != nil {
+		return "#AL#" + wialonErrorCode(err, wialonLoginRejected), false
+	}
+
+	device, err := s.telemetry.Authenticate(login.IMEI)
+	if err != nil {
+		if !errors.Is(err, ErrTelemetryUnknownDevice) {
+			log.Printf("Wialon IPS: ошибка авторизации %s: %v", login.IMEI, err)
+		}
+		return "#AL#" + wialonLoginRejected, false
+	}
+	s.version = login.Version
+	s.device = device
+	return "#AL#" + wialonLoginOK, true
+}
+
+// data принимает пакет #SD# или #D# с одной точкой
+func (s *wialonIPSSession) data(payload string, now time.Time, ack, crcCode string,
+	parse func(string, time.Time) (*models.TelemetryPoint, error)) (string, bool) {
+	if s.version == "2.0" {
+		body, ok := splitWialonCRC(payload, ';')
+		if !ok {
+			return ack + crcCode, true
+		}
+		payload = body
+	}
+
+	point, err := parse(payload, now)
+	if err != nil {
+		return ack + wialonErrorCode(err, wialonAckStructure), true
+	}
+	if err := s.telemetry.Store(s.device, []models.TelemetryPoint{*point}); err != nil {
+		// Без подтверждения трекер повторит отправку из черного ящика
+		log.Printf("Wialon IPS: %v", err)
+		return "", false
+	}
+	return ack + wialonAckOK, true
+}
+
+// blackBox принимает пакет #B# с несколькими записями, разделенными "|".
+// В ответе передается количество принятых записей.
+func (s *wialonIPSSession) blackBox(payload string, now time.Time) (string, bool) {
+	if s.version == "2.0" {
+		body, ok := splitWialonCRC(payload, '|')
+		if !ok {
+			return "#AB#0", true
+		}
+		payload = body
+	}
+
+	var points []models.TelemetryPoint
+	for _, record := range strings.Split(payload, "|") {
+		var point *models.TelemetryPoint
+		var err error
+		switch strings.Count(record, ";") {
+		case 9:
+			point, err = ParseWialonIPSShortData(record, now)
+		case 15:
+			point, err = ParseWialonIPSData(record, now)
+		default:
+			continue
+		}
+		if err == nil {
+			points = append(points, *point)
+		}
+	}
+
+	if err := s.telemetry.Store(s.device, points); err != nil {
+		log.Printf("Wialon IPS: %v", err)
+		return "", false
+	}
+	return "#AB#" + strconv.Itoa(len(points)), true
+}
+
+// wialonErrorCode возвращает код ответа для ошибки разбора пакета
+func wialonErrorCode(err error, fallback string) string {
+	var packetErr *wialonPacketError
+	if errors.As(err, &packetErr) {
+		return packetErr.Code
+	}
+	return fallback
+}
@@ -0,0 +1,200 @@
+package services
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"backend_axenta/models"
+)
+
+// withWialonCRC дописывает к пакету версии 2.0 контрольную сумму
+func withWialonCRC(body string) string {
+	return fmt.Sprintf("%s%X", body, wialonCRC16(body))
+}
+
+func TestWialonIPSParsing(t *testing.T) {
+	assert.Equal(t, uint16(0xBB3D), wialonCRC16("123456789"))
+
+	login, err := ParseWialonIPSLogin("356938035643809;NA")
+	require.NoError(t, err)
+	assert.Equal(t, "1.1", login.Version)
+	assert.Equal(t, "356938035643809", login.IMEI)
+
+	login, err = ParseWialonIPSLogin(withWialonCRC("2.0;356938035643809;secret;"))
+	require.NoError(t, err)
+	assert.Equal(t, "2.0", login.Version)
+	assert.Equal(t, "secret", login.Password)
+
+	_, err = ParseWialonIPSLogin("2.0;356938035643809;secret;FFFF")
+	assert.Equal(t, wialonLoginCRCInvalid, wialonErrorCode(err, ""))
+
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	point, err := ParseWialonIPSData("181026;093015;5544.6025;N;03739.6834;E;62;184;156;9;0.9;5;1;12.5,NA,3.3;00000A1B2C3D;fuel:2:41.5,driver:3:Иванов,odometer:1:120500", now)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2026, 10, 18, 9, 30, 15, 0, time.UTC), point.RecordedAt)
+	require.True(t, point.HasPosition())
+	assert.InDelta(t, 55.743375, *point.Latitude, 1e-6)
+	assert.InDelta(t, 37.661390, *point.Longitude, 1e-6)
+	assert.Equal(t, 62.0, *point.Speed)
+	assert.Equal(t, 184, *point.Course)
+	assert.Equal(t, 9, *point.Satellites)
+	assert.Equal(t, 5, *point.Inputs)
+	assert.Equal(t, models.TelemetryProtocolWialonIPS, point.Protocol)
+
+	var params map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(point.Params), &params))
+	assert.Equal(t, 12.5, params["adc1"])
+	assert.NotContains(t, params, "adc2")
+	assert.Equal(t, 3.3, params["adc3"])
+	assert.Equal(t, "00000A1B2C3D", params["ibutton"])
+	assert.Equal(t, 41.5, params["fuel"])
+	assert.Equal(t, "Иванов", params["driver"])
+	assert.Equal(t, float64(120500), params["odometer"])
+
+	// Неизвестные значения и время приема сервером
+	point, err = ParseWialonIPSShortData("NA;NA;3330.0000;S;07030.0000;W;NA;NA;NA;NA", now)
+	require.NoError(t, err)
+	assert.Equal(t, now, point.RecordedAt)
+	assert.InDelta(t, -33.5, *point.Latitude, 1e-9)
+	assert.InDelta(t, -70.5, *point.Longitude, 1e-9)
+	assert.Nil(t, point.Speed)
+
+	cases := map[string]string{
+		"181026;093015;5544.6025;N;03739.6834;E;62;184;156":       wialonAckStructure,
+		"321026;093015;5544.6025;N;03739.6834;E;62;184;156;9":     wialonAckTime,
+		"181026;093015;9544.6025;N;03739.6834;E;62;184;156;9":     wialonAckCoordinates,
+		"181026;093015;5575.0000;N;03739.6834;E;62;184;156;9":     wialonAckCoordinates,
+		"181026;093015;5544.6025;N;NA;NA;62;184;156;9":            wialonAckCoordinates,
+		"181026;093015;5544.6025;N;03739.6834;E;-5;184;156;9":     wialonAckMotion,
+		"181026;093015;5544.6025;N;03739.6834;E;62;400;156;9":     wialonAckMotion,
+		"181026;093015;5544.6025;N;03739.6834;E;62;184;156;много": wialonAckSatellites,
+		// NaN и бесконечность проходят проверки диапазона и не принимаются
+		"181026;093015;NaN;N;03739.6834;E;62;184;156;9":         wialonAckCoordinates,
+		"181026;093015;5544.6025;N;Inf;E;62;184;156;9":          wialonAckCoordinates,
+		"181026;093015;5544.6025;N;03739.6834;E;NaN;184;156;9":  wialonAckMotion,
+		"181026;093015;5544.6025;N;03739.6834;E;+Inf;184;156;9": wialonAckMotion,
+		"181026;093015;5544.6025;N;03739.6834;E;62;184;-Inf;9":  wialonAckMotion,
+		"181026;093015;5544.6025;N;03739.6834;E;62;184;NaN;9":   wialonAckMotion,
+	}
+	for record, code := range cases {
+		_, err := ParseWialonIPSShortData(record, now)
+		assert.Equal(t, code, wialonErrorCode(err, ""), record)
+	}
+
+	_, err = ParseWialonIPSData("181026;093015;NA;NA;NA;NA;NA;NA;NA;NA;NA;x;NA;NA;NA;NA", now)
+	assert.Equal(t, wialonAckIO, wialonErrorCode(err, ""))
+	_, err = ParseWialonIPSData("181026;093015;NA;NA;NA;NA;NA;NA;NA;NA;NA;NA;NA;1,x;NA;NA", now)
+	assert.Equal(t, wialonAckADC, wialonErrorCode(err, ""))
+	_, err = ParseWialonIPSData("181026;093015;NA;NA;NA;NA;NA;NA;NA;NA;NA;NA;NA;NA;NA;fuel:4:1", now)
+	assert.Equal(t, wialonAckParams, wialonErrorCode(err, ""))
+	_, err = ParseWialonIPSData("181026;093015;NA;NA;NA;NA;NA;NA;NA;NA;NA;NA;NA;1,NaN;NA;NA", now)
+	assert.Equal(t, wialonAckADC, wialonErrorCode(err, ""))
+	_, err = ParseWialonIPSData("181026;093015;NA;NA;NA;NA;NA;NA;NA;NA;NA;NA;NA;NA;NA;fuel:2:Inf", now)
+	assert.Equal(t, wialonAckParams, wialonErrorCode(err, ""))
+	_, err = ParseWialonIPSData("181026;093015;NA;NA;NA;NA;NA;NA;NA;NA;NaN;NA;NA;NA;NA;NA", now)
+	assert.Equal(t, wialonAckSatellites, wialonErrorCode(err, ""))
+}
+
+func setupTelemetryTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	// Соединения сервера должны работать с той же базой в памяти
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	require.NoError(t, db.AutoMigrate(&models.Object{}, &models.TelemetryPoint{}))
+	return db
+}
+
+// simulatedTracker трекер, подключенный к серверу телеметрии
+type simulatedTracker struct {
+	t      *testing.T
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+func dialTracker(t *testing.T, addr string) *simulatedTracker {
+	conn, err := net.DialTimeout("tcp", addr, time.Second)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return &simulatedTracker{t: t, conn: conn, reader: bufio.NewReader(conn)}
+}
+
+// send отправляет пакет и возвращает ответ сервера; пустая строка - соединение закрыто без ответа
+func (d *simulatedTracker) send(packet string) string {
+	d.conn.SetDeadline(time.Now().Add(2 * time.Second))
+	_, err := d.conn.Write([]byte(packet + "\r\n"))
+	require.NoError(d.t, err)
+	line, err := d.reader.ReadString('\n')
+	if err != nil {
+		return ""
+	}
+	return line[:len(line)-2]
+}
+
+func TestWialonIPSServer_SimulatedDevice(t *testing.T) {
+	db := setupTelemetryTestDB(t)
+	truck := models.Object{Name: "КамАЗ", Type: "vehicle", IMEI: "356938035643809", ContractID: 1, Status: "active", IsActive: true}
+	require.NoError(t, db.Create(&truck).Error)
+
+	server := NewWialonIPSServer(NewTelemetryService(db, false))
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go server.Serve(listener)
+	defer server.Close()
+	addr := listener.Addr().String()
+
+	// Неизвестное устройство и данные без авторизации отклоняются
+	assert.Equal(t, "#AL#0", dialTracker(t, addr).send("#L#356938035640000;NA"))
+	assert.Equal(t, "", dialTracker(t, addr).send("#SD#181026;093015;5544.6025;N;03739.6834;E;62;184;156;9"))
+
+	// Трекер Wialon IPS 1.1
+	tracker := dialTracker(t, addr)
+	assert.Equal(t, "#AL#1", tracker.send("#L#356938035643809;NA"))
+	assert.Equal(t, "#AP#", tracker.send("#P#"))
+	assert.Equal(t, "#ASD#1", tracker.send("#SD#181026;093015;5544.6025;N;03739.6834;E;62;184;156;9"))
+	assert.Equal(t, "#ASD#10", tracker.send("#SD#181026;093020;9544.6025;N;03739.6834;E;62;184;156;9"))
+	assert.Equal(t, "#AB#2", tracker.send("#B#181026;092000;5540.0000;N;03730.0000;E;40;90;150;8|"+
+		"181026;092500;5542.0000;N;03735.0000;E;50;90;150;8;1.1;0;0;NA;NA;fuel:2:40.1|broken"))
+
+	// Трекер Wialon IPS 2.0 с контрольными суммами
+	tracker = dialTracker(t, addr)
+	assert.Equal(t, "#AL#1", tracker.send("#L#"+withWialonCRC("2.0;356938035643809;NA;")))
+	assert.Equal(t, "#AD#1", tracker.send("#D#"+withWialonCRC("181026;094000;5545.0000;N;03740.0000;E;0;0;160;10;0.8;1;0;NA;NA;fuel:2:39.5;")))
+	assert.Equal(t, "#AD#16", tracker.send("#D#181026;094100;5545.0000;N;03740.0000;E;0;0;160;10;0.8;1;0;NA;NA;NA;0000"))
+	// Устаревшая точка из черного ящика не перезаписывает текущее положение
+	assert.Equal(t, "#AB#1", tracker.send("#B#"+withWialonCRC("181026;080000;5500.0000;N;03700.0000;E;0;0;160;10|")))
+
+	var points []models.TelemetryPoint
+	require.NoError(t, db.Order("recorded_at").Find(&points).Error)
+	require.Len(t, points, 5)
+	for _, point := range points {
+		assert.Equal(t, truck.ID, point.ObjectID)
+	}
+	assert.Equal(t, time.Date(2026, 10, 18, 8, 0, 0, 0, time.UTC), points[0].RecordedAt.UTC())
+	assert.JSONEq(t, `{"fuel":39.5}`, points[4].Params)
+
+	require.NoError(t, db.First(&truck, truck.ID).Error)
+	require.NotNil(t, truck.LastActivityAt)
+	assert.Equal(t, time.Date(2026, 10, 18, 9, 40, 0, 0, time.UTC), truck.LastActivityAt.UTC())
+	assert.InDelta(t, 55.75, *truck.Latitude, 1e-9)
+	assert.InDelta(t, 37.666667, *truck.Longitude, 1e-6)
+
+	from := time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC)
+	history, err := GetObjectTelemetry(db, truck.ID, from, from.Add(time.Hour), 0)
+	require.NoError(t, err)
+	require.Len(t, history, 4)
+	assert.Equal(t, time.Date(2026, 10, 18, 9, 40, 0, 0, time.UTC), history[0].RecordedAt.UTC())
+}